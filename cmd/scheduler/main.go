@@ -1,16 +1,41 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	accountrepo "thyra/internal/accounts/repositories"
+	accountservices "thyra/internal/accounts/services"
+	analyticsrepo "thyra/internal/analytics/repositories/performance"
+	analyticsservices "thyra/internal/analytics/services/performance"
 	"thyra/internal/common/db"
+	"thyra/internal/common/mailer"
+	compliancerepo "thyra/internal/compliance/repositories"
+	complianceservices "thyra/internal/compliance/services"
+	jobrepo "thyra/internal/jobs/repositories"
+	jobservices "thyra/internal/jobs/services"
+	orderrepo "thyra/internal/orders/repositories"
+	orderservices "thyra/internal/orders/services"
+	savingsrepo "thyra/internal/savings/repositories"
+	savingsservices "thyra/internal/savings/services"
+	taxrepo "thyra/internal/tax/repositories"
+	taxservices "thyra/internal/tax/services"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
 )
 
 func main() {
+	backfillFrom := flag.String("backfill-from", "", "value accounts for every day from this date (YYYY-MM-DD) and exit")
+	backfillTo := flag.String("backfill-to", "", "last day to value when backfilling (YYYY-MM-DD), defaults to yesterday")
+	flag.Parse()
+
 	const maxRetries = 50
 	const retryInterval = 10 * time.Second
 
@@ -42,52 +67,245 @@ func main() {
 	}
 
 	log.Printf("Database is active")
-	performScheduledTasks(testDB)
+	if *backfillFrom != "" {
+		if err := runValuationBackfill(testDB, *backfillFrom, *backfillTo); err != nil {
+			log.Fatalf("Valuation backfill failed: %v", err)
+		}
+		return
+	}
 
-	ticker := time.NewTicker(1 * time.Hour)
-	for {
-		select {
-		case <-ticker.C:
-			performScheduledTasks(testDB)
+	scheduler := jobservices.NewSchedulerFromEnv(jobrepo.NewJobRepository(testDB))
+	for _, job := range scheduledJobs(testDB) {
+		if err := scheduler.Register(job); err != nil {
+			log.Fatalf("Failed to register job %s: %v", job.Name, err)
 		}
 	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := scheduler.Run(ctx); err != nil && err != context.Canceled {
+		log.Fatalf("Scheduler stopped: %v", err)
+	}
+	log.Printf("Scheduler stopped")
 }
 
-func performScheduledTasks(db *sqlx.DB) {
+// scheduledJobs lists the jobs and when they run, in the scheduler's local
+// time. Every job is safe to run again after a failed or repeated attempt.
+func scheduledJobs(db *sqlx.DB) []jobservices.Job {
+	return []jobservices.Job{
+		{Name: "holdings-snapshot", Schedule: "5 0 * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return snapshotHoldings(db, time.Now().AddDate(0, 0, -1))
+		}},
+		{Name: "account-valuation", Schedule: "55 23 * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return runAccountValuation(ctx, db)
+		}},
+		{Name: "interest-accrual", Schedule: "15 0 * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return runInterestAccrual(ctx, db)
+		}},
+		{Name: "margin-check", Schedule: "0 * * * *", MaxAttempts: 2, Run: func(ctx context.Context) error {
+			return runMarginCheck(ctx, db)
+		}},
+		{Name: "aml-monitoring", Schedule: "*/30 * * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return runAMLMonitoring(ctx, db)
+		}},
+		{Name: "sanctions-import", Schedule: "20 * * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return importSanctionsLists(ctx, db)
+		}},
+		{Name: "isk-quarter-capture", Schedule: "10 0 * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return runIskQuarterCapture(ctx, db)
+		}},
+		{Name: "savings-plans", Schedule: "0 7 * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return runSavingsPlans(ctx, db)
+		}},
+		{Name: "order-aggregation", Schedule: "*/5 * * * *", MaxAttempts: 3, Run: func(ctx context.Context) error {
+			return runOrderAggregation(ctx, db)
+		}},
+	}
+}
+
+// snapshotHoldings stores every account's holdings as of the snapshot date.
+func snapshotHoldings(db *sqlx.DB, snapshotDate time.Time) error {
 	accountIDs, err := fetchAllAccounts(db)
 	if err != nil {
-		log.Printf("Error fetching accounts: %v", err)
-		return
+		return err
 	}
 
-	snapshotDate := time.Now().AddDate(0, 0, -1)
+	failed := 0
 	for _, accountID := range accountIDs {
 		tx, err := db.Beginx()
 		if err != nil {
-			log.Printf("Failed to start a transaction: %v", err)
-			continue
+			return fmt.Errorf("failed to start a transaction: %v", err)
 		}
 
 		err = CalculateAndStoreHoldings(tx, accountID, snapshotDate)
 		if err != nil {
 			tx.Rollback()
-			log.Printf("Failed to calculate and store performance for account %s: %v", accountID, err)
-		} else {
-			tx.Commit()
+			log.Printf("Failed to store holdings snapshot for account %s: %v", accountID, err)
+			failed++
+		} else if err := tx.Commit(); err != nil {
+			log.Printf("Failed to commit holdings snapshot for account %s: %v", accountID, err)
+			failed++
 		}
 	}
+	if failed > 0 {
+		return fmt.Errorf("holdings snapshot failed for %d of %d accounts", failed, len(accountIDs))
+	}
+	return nil
+}
+
+// runAccountValuation stores today's value of every account, replacing the
+// value from the previous run of the day.
+func runAccountValuation(ctx context.Context, db *sqlx.DB) error {
+	valuationService := analyticsservices.NewValuationService(analyticsrepo.NewValuationRepository(db.DB))
+	result, err := valuationService.RunDaily(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Account valuation: %d accounts valued, %d failed", result.AccountsValued, result.Failures)
+	if result.Failures > 0 {
+		return fmt.Errorf("valuation failed for %d accounts", result.Failures)
+	}
+	return nil
+}
+
+// runValuationBackfill values every account for each day in the range from
+// its holdings snapshots.
+func runValuationBackfill(db *sqlx.DB, from, to string) error {
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return fmt.Errorf("invalid -backfill-from date: %v", err)
+	}
+	toDate := time.Now().AddDate(0, 0, -1)
+	if to != "" {
+		if toDate, err = time.Parse("2006-01-02", to); err != nil {
+			return fmt.Errorf("invalid -backfill-to date: %v", err)
+		}
+	}
+
+	valuationService := analyticsservices.NewValuationService(analyticsrepo.NewValuationRepository(db.DB))
+	result, err := valuationService.Backfill(context.Background(), fromDate, toDate)
+	if err != nil {
+		return err
+	}
+	log.Printf("Valuation backfill: %d days, %d account valuations, %d failed", result.Dates, result.AccountsValued, result.Failures)
+	return nil
+}
+
+// runInterestAccrual accrues daily interest on cash balances and, once a
+// month has ended, posts it against the house account.
+func runInterestAccrual(ctx context.Context, db *sqlx.DB) error {
+	interestService := accountservices.NewInterestServiceFromEnv(accountrepo.NewInterestRepository(db))
+	result, err := interestService.Run(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Interest: %d accruals for %d accounts, %d monthly postings", result.Accruals, result.AccountsAccrued, result.Postings)
+	return nil
+}
+
+// runMarginCheck opens margin calls on accounts whose borrowing exceeds the
+// lending value of their collateral and force-sells holdings when a call is
+// not covered by its deadline.
+func runMarginCheck(ctx context.Context, db *sqlx.DB) error {
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		return fmt.Errorf("mailer not configured: %v", err)
+	}
+	liquidator := orderservices.NewOrdersService(db, orderrepo.NewOrdersRepository(db),
+		complianceservices.NewPreTradeService(db, compliancerepo.NewPreTradeRepository(db)))
+	marginService := accountservices.NewMarginServiceFromEnv(accountrepo.NewMarginRepository(db), liquidator, mail)
+
+	result, err := marginService.RunMarginCheck(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Margin check: %d accounts, %d calls opened, %d covered, %d liquidations",
+		result.AccountsChecked, result.CallsOpened, result.CallsMet, result.Liquidations)
+	return nil
+}
+
+// runSavingsPlans places the buy orders of savings plans due today. A plan
+// date is run at most once, so retries only pick up plans not yet reached.
+func runSavingsPlans(ctx context.Context, db *sqlx.DB) error {
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		return fmt.Errorf("mailer not configured: %v", err)
+	}
+	orders := orderservices.NewOrdersService(db, orderrepo.NewOrdersRepository(db),
+		complianceservices.NewPreTradeService(db, compliancerepo.NewPreTradeRepository(db)))
+	runService := savingsservices.NewSavingsRunService(db, savingsrepo.NewSavingsPlanRepository(db), orders, mail)
+
+	result, err := runService.RunDuePlans(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Savings plans: %d due, %d executed, %d skipped, %d failed",
+		result.PlansDue, result.Executed, result.Skipped, result.Failed)
+	return nil
+}
+
+// runOrderAggregation nets executed client orders in fractional instruments against
+// the house and queues the whole-share market orders needed.
+func runOrderAggregation(ctx context.Context, db *sqlx.DB) error {
+	aggregationService := orderservices.NewAggregationService(orderrepo.NewAggregationRepository(db))
+	result, err := aggregationService.RunAggregation(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Order aggregation: %d orders in %d instruments, %d market orders",
+		result.Orders, result.Instruments, result.MarketOrders)
+	return nil
+}
+
+// runAMLMonitoring evaluates cash movements that were not monitored when they
+// were booked.
+func runAMLMonitoring(ctx context.Context, db *sqlx.DB) error {
+	amlService := complianceservices.NewAMLService(compliancerepo.NewAMLRepository(db))
+	result, err := amlService.RunBatch(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("AML monitoring: %d imported, %d evaluated, %d new alerts", result.Imported, result.Evaluated, result.Alerts)
+	return nil
+}
+
+// runIskQuarterCapture stores the quarter start values of ISK accounts that
+// the schablonskatt capital base is computed from.
+func runIskQuarterCapture(ctx context.Context, db *sqlx.DB) error {
+	iskService := taxservices.NewIskService(taxrepo.NewIskRepository(db))
+	result, err := iskService.CaptureQuarterValues(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("ISK quarter capture: %d accounts, %d values captured", result.AccountsChecked, result.ValuesCaptured)
+	return nil
+}
+
+// importSanctionsLists picks up new list files dropped into the sanctions list
+// directory. Each import re-screens every customer.
+func importSanctionsLists(ctx context.Context, db *sqlx.DB) error {
+	screeningService := complianceservices.NewScreeningServiceFromEnv(compliancerepo.NewScreeningRepository(db))
+	results, err := screeningService.ImportNewListFiles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		log.Printf("Imported sanctions list %s (%d entries): %d customers screened, %d new cases",
+			result.List.FileName, result.List.EntryCount, result.Run.CustomersScreened, result.Run.NewCases)
+	}
+	return nil
 }
 
 type HoldingSnapshot struct {
-	SnapshotID   uuid.UUID `db:"snapshotid"` // Assuming you want to handle this in Go
-	AccountID    uuid.UUID `db:"account_id"`
-	AssetID      uuid.UUID `db:"asset_id"`
-	Quantity     float64   `db:"quantity"`
-	SnapshotDate time.Time `db:"snapshot_date"`
+	SnapshotID   uuid.UUID       `db:"snapshotid"` // Assuming you want to handle this in Go
+	AccountID    uuid.UUID       `db:"account_id"`
+	AssetID      uuid.UUID       `db:"asset_id"`
+	Quantity     decimal.Decimal `db:"quantity"`
+	SnapshotDate time.Time       `db:"snapshot_date"`
 }
 type Holding struct {
-	AssetID  uuid.UUID `db:"asset_id"`
-	Quantity float64   `db:"quantity"`
+	AssetID  uuid.UUID       `db:"asset_id"`
+	Quantity decimal.Decimal `db:"quantity"`
 }
 
 func CalculateAndStoreHoldings(tx *sqlx.Tx, accountID uuid.UUID, snapshotDate time.Time) error {
@@ -96,6 +314,13 @@ func CalculateAndStoreHoldings(tx *sqlx.Tx, accountID uuid.UUID, snapshotDate ti
 		return fmt.Errorf("error fetching holdings for account %s: %v", accountID, err)
 	}
 
+	// A retry or manual rerun of the daily job must replace the day's
+	// snapshot rather than adding to it.
+	_, err = tx.Exec("DELETE FROM thyrasec.holdings_snapshots WHERE account_id = $1 AND snapshot_date = $2::date", accountID, snapshotDate)
+	if err != nil {
+		return fmt.Errorf("error clearing holdings snapshot for account %s: %v", accountID, err)
+	}
+
 	for _, holding := range holdings {
 		snapshot := HoldingSnapshot{
 			AccountID:    accountID,
@@ -138,41 +363,3 @@ func fetchAllAccounts(db *sqlx.DB) ([]uuid.UUID, error) {
 
 	return accountIDs, nil
 }
-
-/*func CalculateAndStorePerformance(tx *sqlx.Tx, accountID uuid.UUID, snapshotDate time.Time) error {
-	totalValue, err := calculateTotalValue(tx, accountID, snapshotDate) // pass 'tx' as the first argument
-	if err != nil {
-		return fmt.Errorf("error calculating total value: %v", err)
-	}
-
-	Snapshot := AccountSnapshot{
-		AccountID:    accountID,
-		SnapshotDate: snapshotDate,
-		TotalValue:   totalValue,
-	}
-
-	query := `INSERT INTO thyrasec.account_snapshots (account_id, snapshot_date, total_value) VALUES (:account_id, :snapshot_date, :total_value)`
-	_, err = tx.NamedExec(query, Snapshot)
-	if err != nil {
-		return fmt.Errorf("error storing account snapshot: %v", err)
-	}
-
-	return nil
-}
-func calculateTotalValue(tx *sqlx.Tx, accountID uuid.UUID, snapshotDate time.Time) (float64, error) {
-	var totalValue float64
-
-	// Query to calculate the total value of holdings based on the snapshot date
-	query := `
-    SELECT COALESCE(SUM(h.quantity * p.current_price), 0)
-    FROM thyrasec.holdings AS h
-    INNER JOIN thyrasec.assets AS p ON h.asset_id = p.id
-    WHERE h.account_id = $1`
-
-	err := tx.Get(&totalValue, query, accountID)
-	if err != nil {
-		return 0, fmt.Errorf("error calculating total value: %v", err)
-	}
-
-	return totalValue, nil
-}*/
@@ -8,6 +8,7 @@ import (
 	"thyra/internal/common/utils"
 	orderroutes "thyra/internal/orders/routes"
 	transactionroutes "thyra/internal/transactions/routes"
+	userutils "thyra/internal/users/utils"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -73,7 +74,11 @@ func main() {
 	dbConn := db.GetDB()
 	dbxConn := db.GetDB() // Assuming you have a function to get sqlx DB connection
 
-	utils.InitializeUsersModule(dbxConn, v1)
+	// Authentication and the MFA step-up checks share one clock
+	clock := userutils.SystemClock()
+
+	userService := utils.InitializeUsersModule(dbxConn, v1, clock)
+	utils.InitializeOnboardingModule(dbxConn, v1, userService)
 	v1.Use(helpers.DBContext(), helpers.TokenMiddleware)
 
 	// Initialize modules
@@ -81,9 +86,15 @@ func main() {
 	utils.InitializeAssetModule(dbxConn, v1)
 	utils.InitializeAnalyticsModule(dbConn.DB, v1)
 	utils.InitializePositionsModule(dbxConn, v1)
+	utils.InitializeComplianceModule(dbxConn, v1)
+	utils.InitializeTransfersModule(dbxConn, v1)
+	utils.InitializeTaxModule(dbxConn, v1)
+	utils.InitializeJobsModule(dbxConn, v1)
+	utils.InitializePortfoliosModule(dbxConn, v1)
+	utils.InitializeSavingsModule(dbxConn, v1)
 
 	// Setup routes for other modules if needed
-	transactionroutes.SetupRoutes(v1)
+	transactionroutes.SetupRoutes(v1, clock)
 	orderroutes.SetupRoutes(v1)
 	// Set up your routes by calling the SetupRoutes function from the "routes" package
 
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+CREATE TABLE IF NOT EXISTS thyrasec.user_mfa
+(
+    user_id uuid NOT NULL,
+    user_type character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    totp_secret character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    enabled boolean NOT NULL DEFAULT false,
+    last_used_step bigint NOT NULL DEFAULT 0,
+    confirmed_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_mfa_pkey PRIMARY KEY (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.user_recovery_codes
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    user_id uuid NOT NULL,
+    code_hash character varying(512) COLLATE pg_catalog."default" NOT NULL,
+    used_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_recovery_codes_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_user_mfa FOREIGN KEY (user_id)
+        REFERENCES thyrasec.user_mfa (user_id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON thyrasec.user_recovery_codes(user_id);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.user_recovery_codes;
+DROP TABLE thyrasec.user_mfa
+-- +goose StatementEnd
@@ -0,0 +1,192 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+CREATE TABLE IF NOT EXISTS thyrasec.users
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    username character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    password_hash character varying(512) COLLATE pg_catalog."default" NOT NULL,
+    email character varying(255) COLLATE pg_catalog."default",
+    customer_number character varying(100) COLLATE pg_catalog."default",
+    status user_status DEFAULT 'ACTIVE'::user_status,
+    last_login timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT users_pkey PRIMARY KEY (id),
+    CONSTRAINT users_email_key UNIQUE (email),
+    CONSTRAINT users_username_key UNIQUE (username)
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.user_roles
+(
+    user_id uuid NOT NULL,
+    role character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_roles_pkey PRIMARY KEY (user_id, role),
+    CONSTRAINT user_roles_role_check CHECK (role IN ('admin', 'partner_advisor', 'customer')),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.customer_profiles
+(
+    user_id uuid NOT NULL,
+    full_name character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    address character varying(512) COLLATE pg_catalog."default",
+    phone_number character varying(50) COLLATE pg_catalog."default",
+    CONSTRAINT customer_profiles_pkey PRIMARY KEY (user_id),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.partner_advisor_profiles
+(
+    user_id uuid NOT NULL,
+    full_name character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    company_name character varying(255) COLLATE pg_catalog."default",
+    phone_number character varying(50) COLLATE pg_catalog."default",
+    CONSTRAINT partner_advisor_profiles_pkey PRIMARY KEY (user_id),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+-- Rows that cannot be merged automatically are recorded here and left in the
+-- legacy tables until an admin resolves them by hand.
+CREATE TABLE IF NOT EXISTS thyrasec.user_migration_conflicts
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    source_table character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    source_id uuid NOT NULL,
+    username character varying(255) COLLATE pg_catalog."default",
+    email character varying(255) COLLATE pg_catalog."default",
+    reason character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    resolved boolean NOT NULL DEFAULT false,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_migration_conflicts_pkey PRIMARY KEY (id)
+);
+
+-- Maps every migrated legacy row to the user it was merged into, so ids from
+-- the role tables that were folded into another user can still be resolved.
+CREATE TABLE IF NOT EXISTS thyrasec.user_legacy_ids
+(
+    legacy_id uuid NOT NULL,
+    source_table character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    user_id uuid NOT NULL,
+    CONSTRAINT user_legacy_ids_pkey PRIMARY KEY (source_table, legacy_id),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TEMP TABLE legacy_users AS
+SELECT l.source_table || ':' || l.id AS row_key, l.*
+FROM (
+    SELECT 'customers' AS source_table, 'customer' AS role, 1 AS precedence,
+        id, username, password_hash, email, customer_number, status, last_login, created_at, updated_at
+    FROM thyrasec.customers
+    UNION ALL
+    SELECT 'partners_advisors', 'partner_advisor', 2,
+        id, username, password_hash, email, customer_number, status, last_login, created_at, updated_at
+    FROM thyrasec.partners_advisors
+    UNION ALL
+    SELECT 'admins', 'admin', 3,
+        id, username, password_hash, email, customer_number, status, last_login, created_at, updated_at
+    FROM thyrasec.admins
+) l;
+
+-- Rows are only merged when they carry the same id. A different id with the
+-- same username or email, ignoring case, may well be a different person, so
+-- both sides are left for an admin, as are ids whose rows disagree.
+CREATE TEMP TABLE legacy_person_conflicts AS
+SELECT a.id, 'username used by another user' AS reason
+FROM legacy_users a
+JOIN legacy_users b ON a.id <> b.id AND lower(a.username) = lower(b.username)
+UNION
+SELECT a.id, 'email used by another user'
+FROM legacy_users a
+JOIN legacy_users b ON a.id <> b.id AND lower(a.email) = lower(b.email)
+UNION
+SELECT id, 'conflicting usernames'
+FROM legacy_users
+GROUP BY id
+HAVING count(DISTINCT lower(username)) > 1
+UNION
+SELECT id, 'conflicting emails'
+FROM legacy_users
+GROUP BY id
+HAVING count(DISTINCT lower(email)) > 1;
+
+INSERT INTO thyrasec.user_migration_conflicts (source_table, source_id, username, email, reason)
+SELECT u.source_table, u.id, u.username, u.email, c.reason
+FROM legacy_users u
+JOIN legacy_person_conflicts c ON c.id = u.id;
+
+-- An id found in several role tables becomes one user with a role per table.
+-- The customer row supplies the credentials, then partner advisor, then admin.
+CREATE TEMP TABLE legacy_merges AS
+SELECT u.row_key, u.source_table, u.id AS legacy_id, u.id AS user_id,
+    row_number() OVER (PARTITION BY u.id ORDER BY u.precedence) = 1 AS canonical
+FROM legacy_users u
+WHERE u.id NOT IN (SELECT id FROM legacy_person_conflicts);
+
+-- Ids are kept so existing references and tokens stay valid.
+INSERT INTO thyrasec.users (id, username, password_hash, email, customer_number, status, last_login, created_at, updated_at)
+SELECT u.id, u.username, u.password_hash, u.email, u.customer_number, u.status, u.last_login, u.created_at, u.updated_at
+FROM legacy_users u
+JOIN legacy_merges m ON m.row_key = u.row_key
+WHERE m.canonical;
+
+INSERT INTO thyrasec.user_legacy_ids (legacy_id, source_table, user_id)
+SELECT legacy_id, source_table, user_id
+FROM legacy_merges;
+
+-- Merged rows may lack an email on the winning row
+UPDATE thyrasec.users usr
+SET email = merged.email
+FROM (
+    SELECT m.user_id, min(u.email) AS email
+    FROM thyrasec.user_legacy_ids m
+    JOIN legacy_users u ON u.id = m.legacy_id AND u.source_table = m.source_table
+    GROUP BY m.user_id
+) merged
+WHERE usr.id = merged.user_id AND usr.email IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_lower_key ON thyrasec.users (lower(username));
+
+INSERT INTO thyrasec.user_roles (user_id, role)
+SELECT m.user_id, u.role
+FROM thyrasec.user_legacy_ids m
+JOIN legacy_users u ON u.id = m.legacy_id AND u.source_table = m.source_table;
+
+INSERT INTO thyrasec.partner_advisor_profiles (user_id, full_name, company_name, phone_number)
+SELECT m.user_id, pa.full_name, pa.company_name, pa.phone_number
+FROM thyrasec.partners_advisors pa
+JOIN thyrasec.user_legacy_ids m ON m.legacy_id = pa.id AND m.source_table = 'partners_advisors';
+
+INSERT INTO thyrasec.customer_profiles (user_id, full_name, address, phone_number)
+SELECT m.user_id, c.full_name, c.address, c.phone_number
+FROM thyrasec.customers c
+JOIN thyrasec.user_legacy_ids m ON m.legacy_id = c.id AND m.source_table = 'customers';
+
+DROP TABLE legacy_merges;
+DROP TABLE legacy_person_conflicts;
+DROP TABLE legacy_users;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.user_legacy_ids;
+DROP TABLE thyrasec.user_migration_conflicts;
+DROP TABLE thyrasec.partner_advisor_profiles;
+DROP TABLE thyrasec.customer_profiles;
+DROP TABLE thyrasec.user_roles;
+DROP TABLE thyrasec.users
+-- +goose StatementEnd
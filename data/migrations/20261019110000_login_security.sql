@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+ALTER TABLE thyrasec.users
+    ADD COLUMN IF NOT EXISTS locked_at timestamp without time zone,
+    ADD COLUMN IF NOT EXISTS locked_reason character varying(255) COLLATE pg_catalog."default";
+
+-- Failed attempt counters keyed by username or client IP.
+CREATE TABLE IF NOT EXISTS thyrasec.login_failures
+(
+    key_type character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    key_value character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    failure_count integer NOT NULL DEFAULT 0,
+    last_failure_at timestamp without time zone NOT NULL,
+    CONSTRAINT login_failures_pkey PRIMARY KEY (key_type, key_value),
+    CONSTRAINT login_failures_key_type_check CHECK (key_type IN ('username', 'ip'))
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.login_history
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    user_id uuid,
+    username character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    ip_address character varying(64) COLLATE pg_catalog."default",
+    user_agent character varying(512) COLLATE pg_catalog."default",
+    success boolean NOT NULL,
+    failure_reason character varying(100) COLLATE pg_catalog."default",
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT login_history_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_history_user_id ON thyrasec.login_history(user_id, created_at);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.login_history;
+DROP TABLE thyrasec.login_failures;
+ALTER TABLE thyrasec.users DROP COLUMN IF EXISTS locked_reason, DROP COLUMN IF EXISTS locked_at
+-- +goose StatementEnd
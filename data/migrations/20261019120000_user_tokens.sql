@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+ALTER TABLE thyrasec.users
+    ADD COLUMN IF NOT EXISTS email_verified_at timestamp without time zone,
+    ADD COLUMN IF NOT EXISTS password_changed_at timestamp without time zone;
+
+-- Single-use tokens for email verification and password reset. Only a SHA-256
+-- hash of the token is stored.
+CREATE TABLE IF NOT EXISTS thyrasec.user_tokens
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    user_id uuid NOT NULL,
+    purpose character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    token_hash character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    used_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT user_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT user_tokens_purpose_check CHECK (purpose IN ('email_verification', 'password_reset')),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON thyrasec.user_tokens(user_id, purpose);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.user_tokens;
+ALTER TABLE thyrasec.users DROP COLUMN IF EXISTS password_changed_at, DROP COLUMN IF EXISTS email_verified_at
+-- +goose StatementEnd
@@ -0,0 +1,83 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+CREATE TABLE IF NOT EXISTS thyrasec.kyc_profiles
+(
+    user_id uuid NOT NULL,
+    national_id character varying(20) COLLATE pg_catalog."default",
+    date_of_birth date,
+    citizenship character(2) COLLATE pg_catalog."default",
+    tax_residency character(2) COLLATE pg_catalog."default",
+    is_pep boolean NOT NULL DEFAULT false,
+    pep_details text COLLATE pg_catalog."default",
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'pending',
+    submitted_at timestamp without time zone,
+    reviewed_by uuid,
+    reviewed_at timestamp without time zone,
+    review_note text COLLATE pg_catalog."default",
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT kyc_profiles_pkey PRIMARY KEY (user_id),
+    CONSTRAINT kyc_profiles_status_check CHECK (status IN ('pending', 'approved', 'rejected')),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_profiles_status ON thyrasec.kyc_profiles(status, submitted_at);
+
+CREATE TABLE IF NOT EXISTS thyrasec.kyc_documents
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    user_id uuid NOT NULL,
+    document_type character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    file_name character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    content_type character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    size_bytes bigint NOT NULL,
+    storage_key character varying(512) COLLATE pg_catalog."default" NOT NULL,
+    uploaded_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT kyc_documents_pkey PRIMARY KEY (id),
+    CONSTRAINT kyc_documents_storage_key_key UNIQUE (storage_key),
+    CONSTRAINT kyc_documents_type_check CHECK (document_type IN ('passport', 'national_id_card', 'drivers_license', 'proof_of_address')),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.kyc_profiles (user_id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_user_id ON thyrasec.kyc_documents(user_id);
+
+-- Every status change, including resubmissions after a rejection.
+CREATE TABLE IF NOT EXISTS thyrasec.kyc_status_history
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    user_id uuid NOT NULL,
+    from_status character varying(20) COLLATE pg_catalog."default",
+    to_status character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    changed_by uuid NOT NULL,
+    note text COLLATE pg_catalog."default",
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT kyc_status_history_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.kyc_profiles (user_id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_status_history_user_id ON thyrasec.kyc_status_history(user_id, created_at);
+
+-- Customers onboarded by an admin before KYC existed keep trading.
+INSERT INTO thyrasec.kyc_profiles (user_id, status, reviewed_at, review_note)
+SELECT r.user_id, 'approved', CURRENT_TIMESTAMP, 'approved before KYC onboarding was introduced'
+FROM thyrasec.user_roles r
+WHERE r.role = 'customer'
+ON CONFLICT (user_id) DO NOTHING;
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.kyc_status_history;
+DROP TABLE thyrasec.kyc_documents;
+DROP TABLE thyrasec.kyc_profiles
+-- +goose StatementEnd
@@ -0,0 +1,137 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Risk profile uses the PRIIPs summary risk indicator scale 1-7.
+CREATE TABLE IF NOT EXISTS thyrasec.customer_suitability
+(
+    user_id uuid NOT NULL,
+    risk_profile smallint NOT NULL,
+    knowledge_level character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    assessed_by uuid NOT NULL,
+    assessed_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    valid_until timestamp without time zone,
+    CONSTRAINT customer_suitability_pkey PRIMARY KEY (user_id),
+    CONSTRAINT customer_suitability_risk_profile_check CHECK (risk_profile BETWEEN 1 AND 7),
+    CONSTRAINT customer_suitability_knowledge_level_check CHECK (knowledge_level IN ('none', 'basic', 'informed', 'advanced')),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+-- Complexity and risk class per assets.instrument_type, falling back to the asset type.
+CREATE TABLE IF NOT EXISTS thyrasec.instrument_classifications
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    instrument_type character varying(100) COLLATE pg_catalog."default",
+    asset_type_id uuid,
+    complexity character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    risk_class smallint NOT NULL,
+    CONSTRAINT instrument_classifications_pkey PRIMARY KEY (id),
+    CONSTRAINT instrument_classifications_instrument_type_key UNIQUE (instrument_type),
+    CONSTRAINT instrument_classifications_asset_type_id_key UNIQUE (asset_type_id),
+    CONSTRAINT instrument_classifications_key_check CHECK ((instrument_type IS NULL) <> (asset_type_id IS NULL)),
+    CONSTRAINT instrument_classifications_complexity_check CHECK (complexity IN ('non_complex', 'complex', 'highly_complex')),
+    CONSTRAINT instrument_classifications_risk_class_check CHECK (risk_class BETWEEN 1 AND 7),
+    CONSTRAINT fk_asset_type FOREIGN KEY (asset_type_id)
+        REFERENCES thyrasec.asset_types (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.restricted_instruments
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    asset_id uuid NOT NULL,
+    restriction character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    reason text COLLATE pg_catalog."default" NOT NULL,
+    valid_from timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    valid_to timestamp without time zone,
+    created_by uuid NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT restricted_instruments_pkey PRIMARY KEY (id),
+    CONSTRAINT restricted_instruments_restriction_check CHECK (restriction IN ('no_buy', 'no_trade')),
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_restricted_instruments_asset_id ON thyrasec.restricted_instruments(asset_id);
+
+CREATE TABLE IF NOT EXISTS thyrasec.account_concentration_limits
+(
+    account_id uuid NOT NULL,
+    max_position_pct numeric(5,2) NOT NULL,
+    updated_by uuid NOT NULL,
+    updated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT account_concentration_limits_pkey PRIMARY KEY (account_id),
+    CONSTRAINT account_concentration_limits_pct_check CHECK (max_position_pct > 0 AND max_position_pct <= 100),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.account_type_order_limits
+(
+    account_type_id uuid NOT NULL,
+    max_order_value numeric(20,2) NOT NULL,
+    updated_by uuid NOT NULL,
+    updated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT account_type_order_limits_pkey PRIMARY KEY (account_type_id),
+    CONSTRAINT account_type_order_limits_value_check CHECK (max_order_value > 0),
+    CONSTRAINT fk_account_type FOREIGN KEY (account_type_id)
+        REFERENCES thyrasec.account_types (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+-- Overrides of the rules' default enabled flag and action.
+CREATE TABLE IF NOT EXISTS thyrasec.pretrade_rule_settings
+(
+    rule_name character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    action character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    updated_by uuid,
+    updated_at timestamp without time zone,
+    CONSTRAINT pretrade_rule_settings_pkey PRIMARY KEY (rule_name),
+    CONSTRAINT pretrade_rule_settings_action_check CHECK (action IN ('block', 'acknowledge'))
+);
+
+-- One row per rule evaluated for an order. Rejected attempts are kept with a
+-- NULL order_id.
+CREATE TABLE IF NOT EXISTS thyrasec.order_compliance_checks
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    order_id uuid,
+    account_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    rule_name character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    outcome character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    message text COLLATE pg_catalog."default",
+    acknowledged boolean NOT NULL DEFAULT false,
+    acknowledged_by uuid,
+    evaluated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT order_compliance_checks_pkey PRIMARY KEY (id),
+    CONSTRAINT order_compliance_checks_outcome_check CHECK (outcome IN ('pass', 'acknowledge', 'block')),
+    CONSTRAINT fk_order FOREIGN KEY (order_id)
+        REFERENCES thyrasec.orders (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_compliance_checks_order_id ON thyrasec.order_compliance_checks(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_compliance_checks_account_id ON thyrasec.order_compliance_checks(account_id, evaluated_at);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.order_compliance_checks;
+DROP TABLE thyrasec.pretrade_rule_settings;
+DROP TABLE thyrasec.account_type_order_limits;
+DROP TABLE thyrasec.account_concentration_limits;
+DROP TABLE thyrasec.restricted_instruments;
+DROP TABLE thyrasec.instrument_classifications;
+DROP TABLE thyrasec.customer_suitability
+-- +goose StatementEnd
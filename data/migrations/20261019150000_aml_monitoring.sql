@@ -0,0 +1,109 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Deposits and withdrawals seen by AML monitoring. Rows are written when a
+-- transaction is booked through TransactionService, or imported by the batch.
+CREATE TABLE IF NOT EXISTS thyrasec.aml_cash_events
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    transaction_id uuid NOT NULL,
+    account_id uuid NOT NULL,
+    direction character varying(3) COLLATE pg_catalog."default" NOT NULL,
+    amount numeric(20,2) NOT NULL,
+    occurred_at timestamp without time zone NOT NULL,
+    evaluated_at timestamp without time zone,
+    CONSTRAINT aml_cash_events_pkey PRIMARY KEY (id),
+    CONSTRAINT aml_cash_events_transaction_id_key UNIQUE (transaction_id),
+    CONSTRAINT aml_cash_events_direction_check CHECK (direction IN ('in', 'out')),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_aml_cash_events_account_id ON thyrasec.aml_cash_events(account_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_aml_cash_events_unevaluated ON thyrasec.aml_cash_events(occurred_at) WHERE evaluated_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS thyrasec.aml_rule_settings
+(
+    rule_name character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    amount_threshold numeric(20,2) NOT NULL DEFAULT 0,
+    window_days integer NOT NULL DEFAULT 0,
+    min_count integer NOT NULL DEFAULT 0,
+    updated_by uuid,
+    updated_at timestamp without time zone,
+    CONSTRAINT aml_rule_settings_pkey PRIMARY KEY (rule_name)
+);
+
+-- Case management. An account has at most one alert per rule that is not closed;
+-- later hits are linked to it.
+CREATE TABLE IF NOT EXISTS thyrasec.aml_alerts
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    rule_name character varying(50) COLLATE pg_catalog."default" NOT NULL,
+    description text COLLATE pg_catalog."default" NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'open',
+    assignee_id uuid,
+    resolution character varying(20) COLLATE pg_catalog."default",
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_triggered_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at timestamp without time zone,
+    closed_by uuid,
+    CONSTRAINT aml_alerts_pkey PRIMARY KEY (id),
+    CONSTRAINT aml_alerts_status_check CHECK (status IN ('open', 'in_review', 'escalated', 'closed')),
+    CONSTRAINT aml_alerts_resolution_check CHECK (resolution IN ('false_positive', 'no_action', 'reported')),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_assignee FOREIGN KEY (assignee_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_aml_alerts_one_open ON thyrasec.aml_alerts(account_id, rule_name) WHERE status <> 'closed';
+CREATE INDEX IF NOT EXISTS idx_aml_alerts_status ON thyrasec.aml_alerts(status, created_at);
+
+CREATE TABLE IF NOT EXISTS thyrasec.aml_alert_events
+(
+    alert_id uuid NOT NULL,
+    event_id uuid NOT NULL,
+    CONSTRAINT aml_alert_events_pkey PRIMARY KEY (alert_id, event_id),
+    CONSTRAINT fk_alert FOREIGN KEY (alert_id)
+        REFERENCES thyrasec.aml_alerts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_event FOREIGN KEY (event_id)
+        REFERENCES thyrasec.aml_cash_events (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.aml_alert_notes
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    alert_id uuid NOT NULL,
+    author_id uuid NOT NULL,
+    note text COLLATE pg_catalog."default" NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT aml_alert_notes_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_alert FOREIGN KEY (alert_id)
+        REFERENCES thyrasec.aml_alerts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_aml_alert_notes_alert_id ON thyrasec.aml_alert_notes(alert_id, created_at);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.aml_alert_notes;
+DROP TABLE thyrasec.aml_alert_events;
+DROP TABLE thyrasec.aml_alerts;
+DROP TABLE thyrasec.aml_rule_settings;
+DROP TABLE thyrasec.aml_cash_events
+-- +goose StatementEnd
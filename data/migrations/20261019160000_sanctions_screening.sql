@@ -0,0 +1,100 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- One row per imported list file. A new file for the same source supersedes
+-- the previous one; only active lists are screened against.
+CREATE TABLE IF NOT EXISTS thyrasec.sanctions_lists
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    source character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    list_type character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    file_name character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    file_sha256 character(64) COLLATE pg_catalog."default" NOT NULL,
+    entry_count integer NOT NULL DEFAULT 0,
+    active boolean NOT NULL DEFAULT true,
+    imported_by uuid,
+    imported_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT sanctions_lists_pkey PRIMARY KEY (id),
+    CONSTRAINT sanctions_lists_file_sha256_key UNIQUE (file_sha256),
+    CONSTRAINT sanctions_lists_list_type_check CHECK (list_type IN ('sanctions', 'pep'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sanctions_lists_active_source ON thyrasec.sanctions_lists(source) WHERE active;
+
+CREATE TABLE IF NOT EXISTS thyrasec.sanctions_entries
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    list_id uuid NOT NULL,
+    external_id character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    subject_type character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    names text[] NOT NULL,
+    birth_dates text[] NOT NULL DEFAULT '{}',
+    countries text[] NOT NULL DEFAULT '{}',
+    programme character varying(255) COLLATE pg_catalog."default",
+    CONSTRAINT sanctions_entries_pkey PRIMARY KEY (id),
+    CONSTRAINT sanctions_entries_list_external_key UNIQUE (list_id, external_id),
+    CONSTRAINT fk_list FOREIGN KEY (list_id)
+        REFERENCES thyrasec.sanctions_lists (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+-- A hit is raised once per customer and listed subject; re-screening against
+-- a newer version of the same list does not reopen a case already decided.
+CREATE TABLE IF NOT EXISTS thyrasec.screening_cases
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    user_id uuid NOT NULL,
+    list_source character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    list_type character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    external_id character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    entry_id uuid,
+    customer_name character varying(255) COLLATE pg_catalog."default" NOT NULL,
+    matched_name text COLLATE pg_catalog."default" NOT NULL,
+    score numeric(5,4) NOT NULL,
+    birth_date_match boolean,
+    trigger character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'open',
+    reviewed_by uuid,
+    reviewed_at timestamp without time zone,
+    review_note text COLLATE pg_catalog."default",
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT screening_cases_pkey PRIMARY KEY (id),
+    CONSTRAINT screening_cases_subject_key UNIQUE (user_id, list_source, external_id),
+    CONSTRAINT screening_cases_status_check CHECK (status IN ('open', 'confirmed_match', 'false_positive')),
+    CONSTRAINT fk_user FOREIGN KEY (user_id)
+        REFERENCES thyrasec.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_entry FOREIGN KEY (entry_id)
+        REFERENCES thyrasec.sanctions_entries (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_screening_cases_status ON thyrasec.screening_cases(status, created_at);
+
+CREATE TABLE IF NOT EXISTS thyrasec.screening_runs
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    trigger character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    list_id uuid,
+    customers_screened integer NOT NULL DEFAULT 0,
+    new_cases integer NOT NULL DEFAULT 0,
+    started_at timestamp without time zone NOT NULL,
+    finished_at timestamp without time zone NOT NULL,
+    CONSTRAINT screening_runs_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_list FOREIGN KEY (list_id)
+        REFERENCES thyrasec.sanctions_lists (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.screening_runs;
+DROP TABLE thyrasec.screening_cases;
+DROP TABLE thyrasec.sanctions_entries;
+DROP TABLE thyrasec.sanctions_lists
+-- +goose StatementEnd
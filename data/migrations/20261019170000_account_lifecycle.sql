@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- account_status was free text; map what is there onto the lifecycle and
+-- treat anything unrecognised as active, which is how it has been handled.
+UPDATE thyrasec.accounts
+SET account_status = CASE lower(trim(account_status))
+        WHEN 'pending' THEN 'pending'
+        WHEN 'frozen' THEN 'frozen'
+        WHEN 'blocked' THEN 'frozen'
+        WHEN 'closing' THEN 'closing'
+        WHEN 'closed' THEN 'closed'
+        ELSE 'active'
+    END;
+
+ALTER TABLE thyrasec.accounts
+    ALTER COLUMN account_status SET DEFAULT 'active',
+    ALTER COLUMN account_status SET NOT NULL,
+    ADD CONSTRAINT accounts_status_check CHECK (account_status IN ('pending', 'active', 'frozen', 'closing', 'closed')),
+    ADD COLUMN IF NOT EXISTS closed_at timestamp with time zone;
+
+CREATE TABLE IF NOT EXISTS thyrasec.account_status_history
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    from_status character varying(20) COLLATE pg_catalog."default",
+    to_status character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    reason text COLLATE pg_catalog."default" NOT NULL,
+    changed_by uuid NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT account_status_history_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_status_history_account_id ON thyrasec.account_status_history(account_id, created_at);
+
+-- The closure checks tell held from free quantity. Existing positions are
+-- free apart from what open sell orders have already reserved.
+ALTER TABLE thyrasec.holdings
+    ADD COLUMN IF NOT EXISTS available_quantity integer NOT NULL DEFAULT 0;
+
+UPDATE thyrasec.holdings h
+SET available_quantity = GREATEST(h.quantity - COALESCE((
+        SELECT SUM(o.quantity)
+        FROM thyrasec.orders o
+        JOIN thyrasec.order_types ot ON ot.id::text = o.order_type
+        WHERE o.account_id = h.account_id
+          AND o.asset_id = h.asset_id
+          AND ot.order_type_name = 'order_type_sell'
+          AND o.status IN ('created', 'confirmed', 'pending', 'executed')
+    ), 0), 0);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE thyrasec.holdings
+    DROP COLUMN IF EXISTS available_quantity;
+DROP TABLE thyrasec.account_status_history;
+ALTER TABLE thyrasec.accounts
+    DROP COLUMN IF EXISTS closed_at,
+    DROP CONSTRAINT IF EXISTS accounts_status_check,
+    ALTER COLUMN account_status DROP NOT NULL,
+    ALTER COLUMN account_status DROP DEFAULT
+-- +goose StatementEnd
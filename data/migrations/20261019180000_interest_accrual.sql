@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- interest_rate is the annual credit rate in percent; overdrawn balances are
+-- charged debit_interest_rate, also in percent.
+ALTER TABLE thyrasec.accounts
+    ADD COLUMN IF NOT EXISTS debit_interest_rate double precision;
+
+INSERT INTO thyrasec.transactions_types (transaction_type_name)
+SELECT 'Interest'
+WHERE NOT EXISTS (SELECT 1 FROM thyrasec.transactions_types WHERE transaction_type_name = 'Interest');
+
+CREATE TABLE IF NOT EXISTS thyrasec.interest_postings
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    period_start date NOT NULL,
+    period_end date NOT NULL,
+    amount numeric(20,2) NOT NULL,
+    client_transaction_id uuid,
+    house_transaction_id uuid,
+    posted_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT interest_postings_pkey PRIMARY KEY (id),
+    CONSTRAINT interest_postings_account_period_key UNIQUE (account_id, period_start),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.interest_accruals
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    accrual_date date NOT NULL,
+    balance numeric(20,2) NOT NULL,
+    annual_rate double precision NOT NULL,
+    day_count character varying(10) COLLATE pg_catalog."default" NOT NULL,
+    amount numeric(20,6) NOT NULL,
+    posting_id uuid,
+    created_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT interest_accruals_pkey PRIMARY KEY (id),
+    CONSTRAINT interest_accruals_account_date_key UNIQUE (account_id, accrual_date),
+    CONSTRAINT interest_accruals_day_count_check CHECK (day_count IN ('ACT/360', 'ACT/365')),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_posting FOREIGN KEY (posting_id)
+        REFERENCES thyrasec.interest_postings (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_interest_accruals_unposted ON thyrasec.interest_accruals(account_id, accrual_date) WHERE posting_id IS NULL;
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.interest_accruals;
+DROP TABLE thyrasec.interest_postings;
+DELETE FROM thyrasec.transactions_types WHERE transaction_type_name = 'Interest';
+ALTER TABLE thyrasec.accounts
+    DROP COLUMN IF EXISTS debit_interest_rate
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+ALTER TABLE thyrasec.accounts
+    ADD COLUMN IF NOT EXISTS margin_enabled boolean NOT NULL DEFAULT false;
+
+-- Loan-to-value per asset type; an asset type without a row lends nothing.
+CREATE TABLE IF NOT EXISTS thyrasec.margin_haircuts
+(
+    asset_type_id uuid NOT NULL,
+    loan_to_value numeric(5,4) NOT NULL,
+    updated_by uuid NOT NULL,
+    updated_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT margin_haircuts_pkey PRIMARY KEY (asset_type_id),
+    CONSTRAINT margin_haircuts_loan_to_value_check CHECK (loan_to_value >= 0 AND loan_to_value < 1),
+    CONSTRAINT fk_asset_type FOREIGN KEY (asset_type_id)
+        REFERENCES thyrasec.asset_types (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.margin_calls
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'open',
+    margin_used numeric(20,2) NOT NULL,
+    lending_value numeric(20,2) NOT NULL,
+    deficit numeric(20,2) NOT NULL,
+    deadline timestamp with time zone NOT NULL,
+    liquidation_order_ids uuid[],
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    resolved_at timestamp with time zone,
+    CONSTRAINT margin_calls_pkey PRIMARY KEY (id),
+    CONSTRAINT margin_calls_status_check CHECK (status IN ('open', 'met', 'liquidating', 'liquidated')),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_margin_calls_one_active ON thyrasec.margin_calls(account_id) WHERE status IN ('open', 'liquidating');
+CREATE INDEX IF NOT EXISTS idx_margin_calls_status ON thyrasec.margin_calls(status, deadline);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.margin_calls;
+DROP TABLE thyrasec.margin_haircuts;
+ALTER TABLE thyrasec.accounts
+    DROP COLUMN IF EXISTS margin_enabled
+-- +goose StatementEnd
@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Total acquisition cost of the quantity held, carried across transfers.
+ALTER TABLE thyrasec.holdings
+    ADD COLUMN IF NOT EXISTS acquisition_cost numeric(20,2) NOT NULL DEFAULT 0;
+
+-- Settlement records what was actually delivered and paid; the holding's
+-- acquisition cost is taken from it.
+ALTER TABLE thyrasec.orders
+    ADD COLUMN IF NOT EXISTS settledquantity double precision,
+    ADD COLUMN IF NOT EXISTS settledamount double precision;
+
+INSERT INTO thyrasec.transactions_types (transaction_type_name)
+SELECT name
+FROM (VALUES ('Cash Transfer Out'), ('Cash Transfer In'), ('Security Transfer Out'), ('Security Transfer In')) AS t(name)
+WHERE NOT EXISTS (SELECT 1 FROM thyrasec.transactions_types WHERE transaction_type_name = t.name);
+
+CREATE TABLE IF NOT EXISTS thyrasec.account_transfers
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    transfer_type character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    from_account_id uuid NOT NULL,
+    to_account_id uuid NOT NULL,
+    amount numeric(20,2),
+    currency character varying(3) COLLATE pg_catalog."default" NOT NULL,
+    asset_id uuid,
+    quantity double precision,
+    cost_basis numeric(20,2),
+    comment text COLLATE pg_catalog."default",
+    requires_approval boolean NOT NULL DEFAULT false,
+    requested_by uuid NOT NULL,
+    decided_by uuid,
+    decision_note text COLLATE pg_catalog."default",
+    decided_at timestamp with time zone,
+    out_transaction_id uuid,
+    in_transaction_id uuid,
+    order_no text COLLATE pg_catalog."default",
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    completed_at timestamp with time zone,
+    CONSTRAINT account_transfers_pkey PRIMARY KEY (id),
+    CONSTRAINT account_transfers_type_check CHECK (transfer_type IN ('cash', 'securities')),
+    CONSTRAINT account_transfers_status_check CHECK (status IN ('pending_approval', 'completed', 'rejected')),
+    CONSTRAINT account_transfers_accounts_check CHECK (from_account_id <> to_account_id),
+    CONSTRAINT fk_from_account FOREIGN KEY (from_account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_to_account FOREIGN KEY (to_account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_transfers_from ON thyrasec.account_transfers(from_account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_account_transfers_to ON thyrasec.account_transfers(to_account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_account_transfers_status ON thyrasec.account_transfers(status);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.account_transfers;
+DELETE FROM thyrasec.transactions_types
+WHERE transaction_type_name IN ('Cash Transfer Out', 'Cash Transfer In', 'Security Transfer Out', 'Security Transfer In');
+ALTER TABLE thyrasec.orders
+    DROP COLUMN IF EXISTS settledamount,
+    DROP COLUMN IF EXISTS settledquantity;
+ALTER TABLE thyrasec.holdings
+    DROP COLUMN IF EXISTS acquisition_cost
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+INSERT INTO thyrasec.transactions_types (transaction_type_name)
+SELECT name
+FROM (VALUES ('Security Delivery In'), ('Security Delivery Out')) AS t(name)
+WHERE NOT EXISTS (SELECT 1 FROM thyrasec.transactions_types WHERE transaction_type_name = t.name);
+
+-- Deliveries of securities to or from another institution. DVP deliveries
+-- settle against a cash payment, FOP deliveries move securities only.
+CREATE TABLE IF NOT EXISTS thyrasec.security_deliveries
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    direction character varying(3) COLLATE pg_catalog."default" NOT NULL,
+    settlement_type character varying(3) COLLATE pg_catalog."default" NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'instructed',
+    account_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    quantity double precision NOT NULL,
+    cash_amount numeric(20,2),
+    cost_basis numeric(20,2),
+    currency character varying(3) COLLATE pg_catalog."default" NOT NULL,
+    counterparty_name text COLLATE pg_catalog."default" NOT NULL,
+    counterparty_account text COLLATE pg_catalog."default" NOT NULL,
+    counterparty_bic character varying(11) COLLATE pg_catalog."default",
+    intended_settlement_date date,
+    external_reference text COLLATE pg_catalog."default",
+    failure_reason text COLLATE pg_catalog."default",
+    comment text COLLATE pg_catalog."default",
+    instructed_by uuid NOT NULL,
+    confirmed_by uuid,
+    confirmed_at timestamp with time zone,
+    settled_by uuid,
+    settled_at timestamp with time zone,
+    failed_at timestamp with time zone,
+    transaction_id uuid,
+    order_no text COLLATE pg_catalog."default",
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT security_deliveries_pkey PRIMARY KEY (id),
+    CONSTRAINT security_deliveries_direction_check CHECK (direction IN ('in', 'out')),
+    CONSTRAINT security_deliveries_settlement_type_check CHECK (settlement_type IN ('fop', 'dvp')),
+    CONSTRAINT security_deliveries_status_check CHECK (status IN ('instructed', 'matched', 'settled', 'failed')),
+    CONSTRAINT security_deliveries_quantity_check CHECK (quantity > 0),
+    CONSTRAINT security_deliveries_dvp_check CHECK (settlement_type = 'fop' OR cash_amount > 0),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS idx_security_deliveries_account ON thyrasec.security_deliveries(account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_security_deliveries_status ON thyrasec.security_deliveries(status);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.security_deliveries;
+DELETE FROM thyrasec.transactions_types
+WHERE transaction_type_name IN ('Security Delivery In', 'Security Delivery Out')
+-- +goose StatementEnd
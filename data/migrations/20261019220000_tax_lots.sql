@@ -0,0 +1,138 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- One lot per acquisition. Sales and outbound deliveries consume lots, so
+-- the remaining columns always sum to what the holding still carries. Lots
+-- migrated without a purchase record have no known cost or date and are left
+-- out of profit and loss until an admin enters them.
+CREATE TABLE IF NOT EXISTS thyrasec.tax_lots
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    acquired_at date,
+    quantity numeric(24,8) NOT NULL,
+    remaining_quantity numeric(24,8) NOT NULL,
+    cost numeric(20,2) NOT NULL,
+    remaining_cost numeric(20,2) NOT NULL,
+    source character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    source_id uuid,
+    cost_known boolean NOT NULL DEFAULT true,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    closed_at timestamp with time zone,
+    CONSTRAINT tax_lots_pkey PRIMARY KEY (id),
+    CONSTRAINT tax_lots_acquired_at_check CHECK (acquired_at IS NOT NULL OR NOT cost_known),
+    CONSTRAINT tax_lots_source_check CHECK (source IN ('opening', 'buy', 'transfer', 'delivery')),
+    CONSTRAINT tax_lots_quantity_check CHECK (remaining_quantity >= 0 AND remaining_quantity <= quantity),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_lots_open ON thyrasec.tax_lots(account_id, asset_id, acquired_at)
+    WHERE remaining_quantity > 0;
+
+CREATE TABLE IF NOT EXISTS thyrasec.realized_gains
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    order_id uuid,
+    sale_date date NOT NULL,
+    quantity numeric(24,8) NOT NULL,
+    proceeds numeric(20,2) NOT NULL,
+    cost_basis numeric(20,2) NOT NULL,
+    realized_pnl numeric(20,2) NOT NULL,
+    method character varying(10) COLLATE pg_catalog."default" NOT NULL,
+    cost_known boolean NOT NULL DEFAULT true,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT realized_gains_pkey PRIMARY KEY (id),
+    CONSTRAINT realized_gains_method_check CHECK (method IN ('fifo', 'average')),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS idx_realized_gains_account ON thyrasec.realized_gains(account_id, sale_date);
+
+-- Which lots each sale consumed.
+CREATE TABLE IF NOT EXISTS thyrasec.realized_gain_lots
+(
+    realized_gain_id uuid NOT NULL,
+    tax_lot_id uuid NOT NULL,
+    quantity numeric(24,8) NOT NULL,
+    cost numeric(20,2) NOT NULL,
+    CONSTRAINT realized_gain_lots_pkey PRIMARY KEY (realized_gain_id, tax_lot_id),
+    CONSTRAINT fk_realized_gain FOREIGN KEY (realized_gain_id)
+        REFERENCES thyrasec.realized_gains (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_tax_lot FOREIGN KEY (tax_lot_id)
+        REFERENCES thyrasec.tax_lots (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+-- Existing holdings are rebuilt from their settled buy orders. What is held
+-- now is what was bought last, so orders are taken newest first until the
+-- holding is covered. A settled order is not updated again, so updated_at is
+-- its settlement date, within days of the trade.
+WITH buys AS (
+    SELECT o.id, o.account_id, o.asset_id, o.updated_at::date AS acquired_at,
+        o.settledquantity::numeric AS quantity, o.settledamount::numeric AS cost,
+        SUM(o.settledquantity::numeric) OVER (
+            PARTITION BY o.account_id, o.asset_id
+            ORDER BY o.updated_at DESC, o.id
+        ) AS bought_since
+    FROM thyrasec.orders o
+    JOIN thyrasec.order_types ot ON ot.id::text = o.order_type
+    WHERE ot.order_type_name = 'order_type_buy'
+      AND o.status = 'settled'
+      AND o.settledquantity > 0
+      AND o.settledamount IS NOT NULL
+)
+INSERT INTO thyrasec.tax_lots (account_id, asset_id, acquired_at, quantity, remaining_quantity, cost, remaining_cost, source, source_id)
+SELECT b.account_id, b.asset_id, b.acquired_at, t.quantity, t.quantity,
+    ROUND(b.cost * t.quantity / b.quantity, 2), ROUND(b.cost * t.quantity / b.quantity, 2), 'buy', b.id
+FROM buys b
+JOIN thyrasec.holdings h ON h.account_id = b.account_id AND h.asset_id = b.asset_id
+CROSS JOIN LATERAL (
+    SELECT LEAST(b.quantity, h.quantity - (b.bought_since - b.quantity)) AS quantity
+) t
+WHERE t.quantity > 0;
+
+-- Whatever the orders do not explain becomes an opening lot of unknown cost.
+INSERT INTO thyrasec.tax_lots (account_id, asset_id, acquired_at, quantity, remaining_quantity, cost, remaining_cost, source, cost_known)
+SELECT h.account_id, h.asset_id, NULL, t.quantity, t.quantity, 0, 0, 'opening', false
+FROM thyrasec.holdings h
+CROSS JOIN LATERAL (
+    SELECT h.quantity - COALESCE(SUM(l.quantity), 0) AS quantity
+    FROM thyrasec.tax_lots l
+    WHERE l.account_id = h.account_id AND l.asset_id = h.asset_id
+) t
+WHERE t.quantity > 0;
+
+UPDATE thyrasec.holdings h
+SET acquisition_cost = COALESCE((
+        SELECT SUM(l.remaining_cost)
+        FROM thyrasec.tax_lots l
+        WHERE l.account_id = h.account_id AND l.asset_id = h.asset_id AND l.remaining_quantity > 0
+    ), 0);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.realized_gain_lots;
+DROP TABLE thyrasec.realized_gains;
+DROP TABLE thyrasec.tax_lots
+-- +goose StatementEnd
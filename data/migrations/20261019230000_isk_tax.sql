@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Account types taxed as investeringssparkonto carry tax_regime 'isk'.
+ALTER TABLE thyrasec.account_types
+    ADD COLUMN IF NOT EXISTS tax_regime character varying(10) COLLATE pg_catalog."default" NOT NULL DEFAULT 'standard',
+    ADD CONSTRAINT account_types_tax_regime_check CHECK (tax_regime IN ('standard', 'isk'));
+
+UPDATE thyrasec.account_types
+SET tax_regime = 'isk'
+WHERE lower(account_type_name) IN ('isk', 'investeringssparkonto');
+
+INSERT INTO thyrasec.account_types (account_type_name, tax_regime)
+SELECT 'ISK', 'isk'
+WHERE NOT EXISTS (SELECT 1 FROM thyrasec.account_types WHERE tax_regime = 'isk');
+
+-- Government borrowing rate (statslåneräntan) on 30 November of the year
+-- before the tax year, in percent, and the tax-free capital base per person.
+CREATE TABLE IF NOT EXISTS thyrasec.isk_tax_rates
+(
+    tax_year integer NOT NULL,
+    government_borrowing_rate numeric(6,4) NOT NULL,
+    tax_free_amount numeric(20,2) NOT NULL DEFAULT 0,
+    updated_by uuid,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT isk_tax_rates_pkey PRIMARY KEY (tax_year),
+    CONSTRAINT isk_tax_rates_tax_free_check CHECK (tax_free_amount >= 0)
+);
+
+INSERT INTO thyrasec.isk_tax_rates (tax_year, government_borrowing_rate, tax_free_amount)
+VALUES (2024, 2.62, 0), (2025, 1.96, 150000)
+ON CONFLICT (tax_year) DO NOTHING;
+
+-- Market value of each ISK account at the start of each quarter, captured
+-- by the scheduler on the quarter date. Cash is only known for values
+-- captured on the day; later captures are marked cash_estimated.
+CREATE TABLE IF NOT EXISTS thyrasec.isk_quarter_values
+(
+    account_id uuid NOT NULL,
+    quarter_date date NOT NULL,
+    securities_value numeric(20,2) NOT NULL,
+    cash_value numeric(20,2) NOT NULL,
+    total_value numeric(20,2) NOT NULL,
+    cash_estimated boolean NOT NULL DEFAULT false,
+    captured_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT isk_quarter_values_pkey PRIMARY KEY (account_id, quarter_date),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE thyrasec.isk_quarter_values;
+DROP TABLE thyrasec.isk_tax_rates;
+ALTER TABLE thyrasec.account_types
+    DROP CONSTRAINT IF EXISTS account_types_tax_regime_check,
+    DROP COLUMN IF EXISTS tax_regime
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- End-of-day valuations split into securities and cash, in the account's
+-- currency. cash_estimated marks backfilled days whose cash balance was
+-- carried over from the nearest captured day.
+ALTER TABLE thyrasec.account_snapshots
+    ADD COLUMN IF NOT EXISTS currency character varying(3) COLLATE pg_catalog."default",
+    ADD COLUMN IF NOT EXISTS securities_value numeric(20,2),
+    ADD COLUMN IF NOT EXISTS cash_value numeric(20,2),
+    ADD COLUMN IF NOT EXISTS cash_estimated boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS valued_at timestamp with time zone NOT NULL DEFAULT now();
+
+-- Closing exchange rates: one unit of base_currency costs rate units of
+-- quote_currency.
+CREATE TABLE IF NOT EXISTS thyrasec.fx_rates
+(
+    base_currency character varying(3) COLLATE pg_catalog."default" NOT NULL,
+    quote_currency character varying(3) COLLATE pg_catalog."default" NOT NULL,
+    rate_date date NOT NULL,
+    rate numeric(20,8) NOT NULL,
+    CONSTRAINT fx_rates_pkey PRIMARY KEY (base_currency, quote_currency, rate_date),
+    CONSTRAINT fx_rates_rate_check CHECK (rate > 0)
+);
+
+-- The scheduler runs hourly, so holdings snapshots were written several
+-- times per day. Keep one row per account, asset and day.
+DELETE FROM thyrasec.holdings_snapshots hs
+USING thyrasec.holdings_snapshots newer
+WHERE hs.account_id = newer.account_id
+    AND hs.asset_id = newer.asset_id
+    AND hs.snapshot_date = newer.snapshot_date
+    AND hs.ctid < newer.ctid;
+
+CREATE UNIQUE INDEX IF NOT EXISTS holdings_snapshots_account_asset_date_idx
+    ON thyrasec.holdings_snapshots (account_id, asset_id, snapshot_date);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX IF EXISTS thyrasec.holdings_snapshots_account_asset_date_idx;
+DROP TABLE IF EXISTS thyrasec.fx_rates;
+ALTER TABLE thyrasec.account_snapshots
+    DROP COLUMN IF EXISTS valued_at,
+    DROP COLUMN IF EXISTS cash_estimated,
+    DROP COLUMN IF EXISTS cash_value,
+    DROP COLUMN IF EXISTS securities_value,
+    DROP COLUMN IF EXISTS currency
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Jobs registered by the scheduler, so the API can list and trigger them.
+CREATE TABLE IF NOT EXISTS thyrasec.scheduled_jobs
+(
+    name character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    schedule character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    max_attempts integer NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT scheduled_jobs_pkey PRIMARY KEY (name),
+    CONSTRAINT scheduled_jobs_max_attempts_check CHECK (max_attempts >= 1)
+);
+
+-- On-demand runs requested through the API, picked up by the scheduler
+-- replica holding the leader lock.
+CREATE TABLE IF NOT EXISTS thyrasec.job_triggers
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    job_name character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    requested_by uuid NOT NULL,
+    requested_at timestamp with time zone NOT NULL DEFAULT now(),
+    claimed_at timestamp with time zone,
+    CONSTRAINT job_triggers_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_job FOREIGN KEY (job_name)
+        REFERENCES thyrasec.scheduled_jobs (name) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS job_triggers_unclaimed_idx
+    ON thyrasec.job_triggers (requested_at) WHERE claimed_at IS NULL;
+
+-- One row per attempt. Retries of the same run share a run_group.
+CREATE TABLE IF NOT EXISTS thyrasec.job_runs
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    run_group uuid NOT NULL,
+    job_name character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    trigger character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    trigger_id uuid,
+    attempt integer NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    finished_at timestamp with time zone,
+    duration_ms bigint,
+    error text COLLATE pg_catalog."default",
+    CONSTRAINT job_runs_pkey PRIMARY KEY (id),
+    CONSTRAINT job_runs_trigger_check CHECK (trigger IN ('schedule', 'manual')),
+    CONSTRAINT job_runs_status_check CHECK (status IN ('running', 'succeeded', 'failed')),
+    CONSTRAINT fk_trigger FOREIGN KEY (trigger_id)
+        REFERENCES thyrasec.job_triggers (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_idx
+    ON thyrasec.job_runs (job_name, started_at DESC);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS thyrasec.job_runs;
+DROP TABLE IF EXISTS thyrasec.job_triggers;
+DROP TABLE IF EXISTS thyrasec.scheduled_jobs
+-- +goose StatementEnd
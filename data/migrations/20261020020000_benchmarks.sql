@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- A benchmark is a single instrument (one component weighted 1) or a
+-- weighted composite brought back to its target weights on the rebalance
+-- schedule.
+CREATE TABLE IF NOT EXISTS thyrasec.benchmarks
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    name character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    description text COLLATE pg_catalog."default",
+    rebalance_frequency character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'none',
+    created_by uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT benchmarks_pkey PRIMARY KEY (id),
+    CONSTRAINT benchmarks_name_key UNIQUE (name),
+    CONSTRAINT benchmarks_rebalance_frequency_check
+        CHECK (rebalance_frequency IN ('none', 'daily', 'monthly', 'quarterly', 'annually'))
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.benchmark_components
+(
+    benchmark_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    weight numeric(9,6) NOT NULL,
+    CONSTRAINT benchmark_components_pkey PRIMARY KEY (benchmark_id, asset_id),
+    CONSTRAINT benchmark_components_weight_check CHECK (weight > 0 AND weight <= 1),
+    CONSTRAINT fk_benchmark FOREIGN KEY (benchmark_id)
+        REFERENCES thyrasec.benchmarks (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS thyrasec.benchmark_components;
+DROP TABLE IF EXISTS thyrasec.benchmarks
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- The holdings of a fund, as weights of its value, used to look through the
+-- fund in allocation breakdowns. Weights need not add up to 1; the rest is
+-- reported under the fund's own classification.
+CREATE TABLE IF NOT EXISTS thyrasec.fund_constituents
+(
+    fund_asset_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    weight numeric(9,6) NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT fund_constituents_pkey PRIMARY KEY (fund_asset_id, asset_id),
+    CONSTRAINT fund_constituents_weight_check CHECK (weight > 0 AND weight <= 1),
+    CONSTRAINT fund_constituents_not_self CHECK (fund_asset_id <> asset_id),
+    CONSTRAINT fk_fund_asset FOREIGN KEY (fund_asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS thyrasec.fund_constituents
+-- +goose StatementEnd
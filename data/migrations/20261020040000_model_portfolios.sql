@@ -0,0 +1,128 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- A model portfolio is a target allocation shared by many accounts. Target
+-- weights are shares of the invested part of an account and add up to 1;
+-- cash_buffer is the share of the account's value kept in cash. An account
+-- is rebalanced once any holding drifts further than its band from target.
+CREATE TABLE IF NOT EXISTS thyrasec.model_portfolios
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    name character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    description text COLLATE pg_catalog."default",
+    drift_band numeric(9,6) NOT NULL DEFAULT 0.05,
+    cash_buffer numeric(9,6) NOT NULL DEFAULT 0,
+    min_trade_amount numeric(20,2) NOT NULL DEFAULT 0,
+    created_by uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT model_portfolios_pkey PRIMARY KEY (id),
+    CONSTRAINT model_portfolios_name_key UNIQUE (name),
+    CONSTRAINT model_portfolios_drift_band_check CHECK (drift_band > 0 AND drift_band <= 1),
+    CONSTRAINT model_portfolios_cash_buffer_check CHECK (cash_buffer >= 0 AND cash_buffer < 1),
+    CONSTRAINT model_portfolios_min_trade_amount_check CHECK (min_trade_amount >= 0)
+);
+
+-- drift_band overrides the portfolio's band for one instrument.
+CREATE TABLE IF NOT EXISTS thyrasec.model_portfolio_targets
+(
+    model_portfolio_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    target_weight numeric(9,6) NOT NULL,
+    drift_band numeric(9,6),
+    CONSTRAINT model_portfolio_targets_pkey PRIMARY KEY (model_portfolio_id, asset_id),
+    CONSTRAINT model_portfolio_targets_weight_check CHECK (target_weight > 0 AND target_weight <= 1),
+    CONSTRAINT model_portfolio_targets_drift_band_check CHECK (drift_band IS NULL OR (drift_band > 0 AND drift_band <= 1)),
+    CONSTRAINT fk_model_portfolio FOREIGN KEY (model_portfolio_id)
+        REFERENCES thyrasec.model_portfolios (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.account_model_portfolios
+(
+    account_id uuid NOT NULL,
+    model_portfolio_id uuid NOT NULL,
+    assigned_by uuid NOT NULL,
+    assigned_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT account_model_portfolios_pkey PRIMARY KEY (account_id),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_model_portfolio FOREIGN KEY (model_portfolio_id)
+        REFERENCES thyrasec.model_portfolios (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+-- A proposal holds the trades the rebalancing engine suggested. Nothing is
+-- traded until an advisor approves it; each trade then records the order
+-- placed for it or why placing it failed.
+CREATE TABLE IF NOT EXISTS thyrasec.rebalance_proposals
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    model_portfolio_id uuid NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'pending',
+    total_value numeric(20,2) NOT NULL,
+    cash_value numeric(20,2) NOT NULL,
+    created_by uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    decided_by uuid,
+    decided_at timestamp with time zone,
+    CONSTRAINT rebalance_proposals_pkey PRIMARY KEY (id),
+    CONSTRAINT rebalance_proposals_status_check
+        CHECK (status IN ('pending', 'approved', 'rejected', 'expired', 'submitted', 'partially_submitted', 'failed')),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_model_portfolio FOREIGN KEY (model_portfolio_id)
+        REFERENCES thyrasec.model_portfolios (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS idx_rebalance_proposals_account
+    ON thyrasec.rebalance_proposals (account_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS thyrasec.rebalance_trades
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    proposal_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    side character varying(4) COLLATE pg_catalog."default" NOT NULL,
+    quantity numeric(20,6) NOT NULL,
+    price numeric(20,6) NOT NULL,
+    amount numeric(20,2) NOT NULL,
+    current_weight numeric(9,6) NOT NULL,
+    target_weight numeric(9,6) NOT NULL,
+    order_id uuid,
+    error text COLLATE pg_catalog."default",
+    CONSTRAINT rebalance_trades_pkey PRIMARY KEY (id),
+    CONSTRAINT rebalance_trades_side_check CHECK (side IN ('buy', 'sell')),
+    CONSTRAINT rebalance_trades_quantity_check CHECK (quantity > 0),
+    CONSTRAINT fk_proposal FOREIGN KEY (proposal_id)
+        REFERENCES thyrasec.rebalance_proposals (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS thyrasec.rebalance_trades;
+DROP TABLE IF EXISTS thyrasec.rebalance_proposals;
+DROP TABLE IF EXISTS thyrasec.account_model_portfolios;
+DROP TABLE IF EXISTS thyrasec.model_portfolio_targets;
+DROP TABLE IF EXISTS thyrasec.model_portfolios
+-- +goose StatementEnd
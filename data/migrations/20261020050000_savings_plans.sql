@@ -0,0 +1,104 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- A savings plan invests a fixed amount from an account on the same day
+-- every month, split over its allocations by weight. Days past the end of a
+-- shorter month run on its last day.
+CREATE TABLE IF NOT EXISTS thyrasec.savings_plans
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL,
+    name character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    amount numeric(20,2) NOT NULL,
+    day_of_month smallint NOT NULL,
+    rounding_rule character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'round_down',
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL DEFAULT 'active',
+    start_date date NOT NULL,
+    end_date date,
+    next_run_date date NOT NULL,
+    created_by uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT savings_plans_pkey PRIMARY KEY (id),
+    CONSTRAINT savings_plans_amount_check CHECK (amount > 0),
+    CONSTRAINT savings_plans_day_of_month_check CHECK (day_of_month BETWEEN 1 AND 31),
+    CONSTRAINT savings_plans_rounding_rule_check CHECK (rounding_rule IN ('round_down', 'round_nearest')),
+    CONSTRAINT savings_plans_status_check CHECK (status IN ('active', 'paused', 'cancelled')),
+    CONSTRAINT fk_account FOREIGN KEY (account_id)
+        REFERENCES thyrasec.accounts (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_savings_plans_due
+    ON thyrasec.savings_plans (next_run_date)
+    WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS thyrasec.savings_plan_allocations
+(
+    plan_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    weight numeric(9,6) NOT NULL,
+    CONSTRAINT savings_plan_allocations_pkey PRIMARY KEY (plan_id, asset_id),
+    CONSTRAINT savings_plan_allocations_weight_check CHECK (weight > 0 AND weight <= 1),
+    CONSTRAINT fk_plan FOREIGN KEY (plan_id)
+        REFERENCES thyrasec.savings_plans (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+-- One run per plan and scheduled date. A run is recorded before any order
+-- is placed, so a run left 'running' by a crash is never repeated.
+CREATE TABLE IF NOT EXISTS thyrasec.savings_plan_runs
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    plan_id uuid NOT NULL,
+    scheduled_date date NOT NULL,
+    status character varying(20) COLLATE pg_catalog."default" NOT NULL,
+    message text COLLATE pg_catalog."default",
+    invested_amount numeric(20,2) NOT NULL DEFAULT 0,
+    started_at timestamp with time zone NOT NULL DEFAULT now(),
+    finished_at timestamp with time zone,
+    CONSTRAINT savings_plan_runs_pkey PRIMARY KEY (id),
+    CONSTRAINT savings_plan_runs_plan_date_key UNIQUE (plan_id, scheduled_date),
+    CONSTRAINT savings_plan_runs_status_check
+        CHECK (status IN ('running', 'executed', 'partially_executed', 'skipped', 'failed')),
+    CONSTRAINT fk_plan FOREIGN KEY (plan_id)
+        REFERENCES thyrasec.savings_plans (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.savings_plan_run_orders
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    run_id uuid NOT NULL,
+    asset_id uuid NOT NULL,
+    quantity numeric(20,6) NOT NULL,
+    price numeric(20,6) NOT NULL,
+    amount numeric(20,2) NOT NULL,
+    order_id uuid,
+    error text COLLATE pg_catalog."default",
+    CONSTRAINT savings_plan_run_orders_pkey PRIMARY KEY (id),
+    CONSTRAINT fk_run FOREIGN KEY (run_id)
+        REFERENCES thyrasec.savings_plan_runs (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE IF EXISTS thyrasec.savings_plan_run_orders;
+DROP TABLE IF EXISTS thyrasec.savings_plan_runs;
+DROP TABLE IF EXISTS thyrasec.savings_plan_allocations;
+DROP TABLE IF EXISTS thyrasec.savings_plans
+-- +goose StatementEnd
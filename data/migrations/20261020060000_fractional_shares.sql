@@ -0,0 +1,115 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Number of decimals an instrument can be traded and held in; 0 means whole
+-- shares only.
+ALTER TABLE thyrasec.assets
+    ADD COLUMN IF NOT EXISTS quantity_precision smallint NOT NULL DEFAULT 0,
+    ADD CONSTRAINT assets_quantity_precision_check CHECK (quantity_precision BETWEEN 0 AND 8);
+
+ALTER TABLE thyrasec.holdings
+    ALTER COLUMN quantity TYPE numeric(24,8),
+    ALTER COLUMN available_quantity TYPE numeric(24,8);
+
+ALTER TABLE thyrasec.reservations
+    ALTER COLUMN quantity TYPE numeric(24,8);
+
+ALTER TABLE thyrasec.holdings_snapshots
+    ALTER COLUMN quantity TYPE numeric(24,8);
+
+ALTER TABLE thyrasec.orders
+    ALTER COLUMN quantity TYPE numeric(24,8),
+    ALTER COLUMN settledquantity TYPE numeric(24,8),
+    ALTER COLUMN settledamount TYPE numeric(20,2);
+
+ALTER TABLE thyrasec.account_transfers
+    ALTER COLUMN quantity TYPE numeric(24,8);
+
+ALTER TABLE thyrasec.security_deliveries
+    ALTER COLUMN quantity TYPE numeric(24,8);
+
+-- Fractional client orders are filled by the house, which nets them per
+-- instrument and trades the difference in whole shares on the market. What
+-- is left over, always less than one share, is carried by the house.
+CREATE TABLE IF NOT EXISTS thyrasec.house_fractional_positions
+(
+    asset_id uuid NOT NULL,
+    quantity numeric(24,8) NOT NULL DEFAULT 0,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT house_fractional_positions_pkey PRIMARY KEY (asset_id),
+    CONSTRAINT house_fractional_positions_quantity_check CHECK (quantity >= 0 AND quantity < 1),
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS thyrasec.order_aggregations
+(
+    id uuid NOT NULL DEFAULT uuid_generate_v4(),
+    asset_id uuid NOT NULL,
+    order_count integer NOT NULL,
+    client_buy_quantity numeric(24,8) NOT NULL,
+    client_sell_quantity numeric(24,8) NOT NULL,
+    house_quantity_before numeric(24,8) NOT NULL,
+    house_quantity_after numeric(24,8) NOT NULL,
+    market_side character varying(4) COLLATE pg_catalog."default",
+    market_quantity numeric(24,8) NOT NULL DEFAULT 0,
+    market_status character varying(20) COLLATE pg_catalog."default",
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT order_aggregations_pkey PRIMARY KEY (id),
+    CONSTRAINT order_aggregations_market_check CHECK (
+        (market_side IS NULL AND market_quantity = 0 AND market_status IS NULL)
+        OR (market_side IN ('buy', 'sell') AND market_quantity > 0 AND market_quantity = trunc(market_quantity)
+            AND market_status IN ('pending', 'sent', 'filled', 'cancelled'))),
+    CONSTRAINT fk_asset FOREIGN KEY (asset_id)
+        REFERENCES thyrasec.assets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_aggregations_asset ON thyrasec.order_aggregations(asset_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_order_aggregations_market_status ON thyrasec.order_aggregations(market_status)
+    WHERE market_status IS NOT NULL;
+
+ALTER TABLE thyrasec.orders
+    ADD COLUMN IF NOT EXISTS aggregation_id uuid REFERENCES thyrasec.order_aggregations (id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_unaggregated ON thyrasec.orders(asset_id)
+    WHERE aggregation_id IS NULL;
+
+ALTER TABLE thyrasec.savings_plans
+    DROP CONSTRAINT IF EXISTS savings_plans_rounding_rule_check,
+    ADD CONSTRAINT savings_plans_rounding_rule_check CHECK (rounding_rule IN ('round_down', 'round_nearest', 'fractional'));
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+UPDATE thyrasec.savings_plans SET rounding_rule = 'round_down' WHERE rounding_rule = 'fractional';
+ALTER TABLE thyrasec.savings_plans
+    DROP CONSTRAINT IF EXISTS savings_plans_rounding_rule_check,
+    ADD CONSTRAINT savings_plans_rounding_rule_check CHECK (rounding_rule IN ('round_down', 'round_nearest'));
+DROP INDEX IF EXISTS thyrasec.idx_orders_unaggregated;
+ALTER TABLE thyrasec.orders
+    DROP COLUMN IF EXISTS aggregation_id;
+DROP TABLE IF EXISTS thyrasec.order_aggregations;
+DROP TABLE IF EXISTS thyrasec.house_fractional_positions;
+ALTER TABLE thyrasec.security_deliveries
+    ALTER COLUMN quantity TYPE double precision;
+ALTER TABLE thyrasec.account_transfers
+    ALTER COLUMN quantity TYPE double precision;
+ALTER TABLE thyrasec.orders
+    ALTER COLUMN quantity TYPE integer USING trunc(quantity),
+    ALTER COLUMN settledquantity TYPE double precision,
+    ALTER COLUMN settledamount TYPE double precision;
+ALTER TABLE thyrasec.holdings_snapshots
+    ALTER COLUMN quantity TYPE DECIMAL(15,4);
+ALTER TABLE thyrasec.reservations
+    ALTER COLUMN quantity TYPE integer USING trunc(quantity);
+ALTER TABLE thyrasec.holdings
+    ALTER COLUMN quantity TYPE integer USING trunc(quantity),
+    ALTER COLUMN available_quantity TYPE integer USING trunc(available_quantity);
+ALTER TABLE thyrasec.assets
+    DROP CONSTRAINT IF EXISTS assets_quantity_precision_check,
+    DROP COLUMN IF EXISTS quantity_precision
+-- +goose StatementEnd
@@ -7,6 +7,7 @@ import (
 	"thyra/internal/accounts/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AccountHandler struct {
@@ -32,6 +33,10 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 
 	ctx := c.Request.Context()
 	if err := h.service.CreateAccount(ctx, account, authUserID.(string)); err != nil {
+		if err == services.ErrInvalidInitialStatus {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account", "details": err.Error()})
 		return
 	}
@@ -92,3 +97,31 @@ func (h *AccountHandler) GetHouseAccount(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"house_account_id": accountID})
 }
+
+// GetBuyingPower returns what the account can spend, with the breakdown.
+func (h *AccountHandler) GetBuyingPower(c *gin.Context) {
+	authUserID, _ := c.Get("userID")
+	authUserRole, _ := c.Get("userType")
+
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID in URL"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	power, err := h.service.GetBuyingPower(ctx, accountID, authUserID.(string), authUserRole.(string))
+	if err != nil {
+		switch err {
+		case services.ErrBuyingPowerAccessDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case services.ErrAccountNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate buying power", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, power)
+}
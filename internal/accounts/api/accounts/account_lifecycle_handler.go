@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"thyra/internal/accounts/models"
+	"thyra/internal/accounts/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AccountLifecycleHandler struct {
+	service *services.AccountLifecycleService
+}
+
+func NewAccountLifecycleHandler(service *services.AccountLifecycleService) *AccountLifecycleHandler {
+	return &AccountLifecycleHandler{service: service}
+}
+
+// ChangeStatus freezes, unfreezes, winds down or reopens an account.
+func (h *AccountLifecycleHandler) ChangeStatus(c *gin.Context) {
+	accountID, authUserID, authUserRole, ok := lifecycleRequest(c)
+	if !ok {
+		return
+	}
+
+	var req models.AccountStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if err := h.service.ChangeStatus(c.Request.Context(), accountID, authUserID, authUserRole, req); err != nil {
+		writeLifecycleError(c, err, "Failed to change account status")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account_id": accountID, "status": req.Status})
+}
+
+// CloseAccount closes an empty account or starts winding down one that is
+// not. A 202 means liquidation orders were placed and the account is closing.
+func (h *AccountLifecycleHandler) CloseAccount(c *gin.Context) {
+	accountID, authUserID, authUserRole, ok := lifecycleRequest(c)
+	if !ok {
+		return
+	}
+
+	var req models.CloseAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	result, err := h.service.CloseAccount(c.Request.Context(), accountID, authUserID, authUserRole, req)
+	if err != nil {
+		writeLifecycleError(c, err, "Failed to close account")
+		return
+	}
+
+	if result.Status == models.AccountStatusClosed {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.JSON(http.StatusAccepted, result)
+}
+
+func (h *AccountLifecycleHandler) GetStatusHistory(c *gin.Context) {
+	accountID, _, authUserRole, ok := lifecycleRequest(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetStatusHistory(c.Request.Context(), accountID, authUserRole)
+	if err != nil {
+		writeLifecycleError(c, err, "Failed to fetch status history")
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetClosureCheck shows what has to be cleared before the account can close.
+func (h *AccountLifecycleHandler) GetClosureCheck(c *gin.Context) {
+	accountID, _, authUserRole, ok := lifecycleRequest(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.service.GetClosureState(c.Request.Context(), accountID, authUserRole)
+	if err != nil {
+		writeLifecycleError(c, err, "Failed to check account closure")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"can_close": state.Empty(), "state": state})
+}
+
+func lifecycleRequest(c *gin.Context) (uuid.UUID, uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, uuid.Nil, "", false
+	}
+
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID in URL"})
+		return uuid.Nil, uuid.Nil, "", false
+	}
+
+	return accountID, authUserID, userRole, true
+}
+
+func writeLifecycleError(c *gin.Context, err error, message string) {
+	var transitionErr *services.TransitionError
+	var notEmptyErr *services.AccountNotEmptyError
+	switch {
+	case errors.As(err, &transitionErr):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &notEmptyErr):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "state": notEmptyErr.State})
+	case err == services.ErrLifecycleAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err == services.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == services.ErrInvalidAccountStatus, err == services.ErrStatusReasonRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == services.ErrStatusChanged, err == services.ErrAccountClosed:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
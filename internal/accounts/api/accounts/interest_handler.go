@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/accounts/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type InterestHandler struct {
+	service *services.InterestService
+}
+
+func NewInterestHandler(service *services.InterestService) *InterestHandler {
+	return &InterestHandler{service: service}
+}
+
+// GetAccruedInterest returns the interest accrued to date on an account.
+func (h *InterestHandler) GetAccruedInterest(c *gin.Context) {
+	authUserID, authUserRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID in URL"})
+		return
+	}
+
+	interest, err := h.service.GetAccruedInterest(c.Request.Context(), accountID, authUserID, authUserRole)
+	if err != nil {
+		switch err {
+		case services.ErrInterestAccessDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case services.ErrAccountNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accrued interest", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, interest)
+}
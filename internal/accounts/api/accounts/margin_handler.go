@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/accounts/models"
+	"thyra/internal/accounts/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MarginHandler struct {
+	service *services.MarginService
+}
+
+func NewMarginHandler(service *services.MarginService) *MarginHandler {
+	return &MarginHandler{service: service}
+}
+
+func (h *MarginHandler) GetHaircuts(c *gin.Context) {
+	haircuts, err := h.service.GetHaircuts(c.Request.Context())
+	if err != nil {
+		writeMarginError(c, err, "Failed to fetch margin haircuts")
+		return
+	}
+
+	c.JSON(http.StatusOK, haircuts)
+}
+
+func (h *MarginHandler) SetHaircut(c *gin.Context) {
+	authUserID, authUserRole, ok := marginUser(c)
+	if !ok {
+		return
+	}
+	assetTypeID, err := uuid.Parse(c.Param("assetTypeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset type ID in URL"})
+		return
+	}
+
+	var req models.MarginHaircutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if err := h.service.SetHaircut(c.Request.Context(), assetTypeID, authUserID, authUserRole, req); err != nil {
+		writeMarginError(c, err, "Failed to update margin haircut")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"asset_type_id": assetTypeID, "loan_to_value": req.LoanToValue})
+}
+
+// GetCalls lists margin calls across accounts, optionally filtered by ?status=.
+func (h *MarginHandler) GetCalls(c *gin.Context) {
+	_, authUserRole, ok := marginUser(c)
+	if !ok {
+		return
+	}
+
+	calls, err := h.service.GetCalls(c.Request.Context(), authUserRole, c.Query("status"))
+	if err != nil {
+		writeMarginError(c, err, "Failed to fetch margin calls")
+		return
+	}
+
+	c.JSON(http.StatusOK, calls)
+}
+
+func (h *MarginHandler) SetMarginEnabled(c *gin.Context) {
+	authUserID, authUserRole, ok := marginUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := marginAccountID(c)
+	if !ok {
+		return
+	}
+
+	var req models.MarginSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if err := h.service.SetMarginEnabled(c.Request.Context(), accountID, authUserID, authUserRole, req); err != nil {
+		writeMarginError(c, err, "Failed to update margin settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account_id": accountID, "margin_enabled": req.Enabled})
+}
+
+// GetMarginPosition shows the account's collateral, lending value and any
+// deficit.
+func (h *MarginHandler) GetMarginPosition(c *gin.Context) {
+	authUserID, authUserRole, ok := marginUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := marginAccountID(c)
+	if !ok {
+		return
+	}
+
+	position, err := h.service.GetMarginPosition(c.Request.Context(), accountID, authUserID.String(), authUserRole)
+	if err != nil {
+		writeMarginError(c, err, "Failed to calculate margin position")
+		return
+	}
+
+	c.JSON(http.StatusOK, position)
+}
+
+func (h *MarginHandler) GetAccountCalls(c *gin.Context) {
+	authUserID, authUserRole, ok := marginUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := marginAccountID(c)
+	if !ok {
+		return
+	}
+
+	calls, err := h.service.GetAccountCalls(c.Request.Context(), accountID, authUserID.String(), authUserRole)
+	if err != nil {
+		writeMarginError(c, err, "Failed to fetch margin calls")
+		return
+	}
+
+	c.JSON(http.StatusOK, calls)
+}
+
+func marginUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+	return authUserID, userRole, true
+}
+
+func marginAccountID(c *gin.Context) (uuid.UUID, bool) {
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID in URL"})
+		return uuid.Nil, false
+	}
+	return accountID, true
+}
+
+func writeMarginError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrMarginAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrAssetTypeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidLoanToValue, services.ErrInvalidCallStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -17,6 +17,7 @@ type Account struct {
 	AccountStatus       string    `db:"account_status" json:"account_status"`
 	InterestRate        float64   `db:"interest_rate" json:"interest_rate"`
 	OverdraftLimit      float64   `db:"overdraft_limit" json:"overdraft_limit"`
+	DebitInterestRate   *float64  `db:"debit_interest_rate" json:"debit_interest_rate"`
 	AccountDescription  string    `db:"account_description" json:"account_description"`
 	AccountHolderId     uuid.UUID `db:"account_holder_id" json:"account_holder_id"`
 	CreatedAt           time.Time `db:"created_at" json:"created_at"`
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AccountStatusPending = "pending"
+	AccountStatusActive  = "active"
+	AccountStatusFrozen  = "frozen"
+	AccountStatusClosing = "closing"
+	AccountStatusClosed  = "closed"
+)
+
+type AccountStatusChange struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	AccountID  uuid.UUID `db:"account_id" json:"account_id"`
+	FromStatus *string   `db:"from_status" json:"from_status"`
+	ToStatus   string    `db:"to_status" json:"to_status"`
+	Reason     string    `db:"reason" json:"reason"`
+	ChangedBy  uuid.UUID `db:"changed_by" json:"changed_by"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type AccountStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// CloseAccountRequest closes an account. With Liquidate set, remaining
+// holdings are sold and the account stays in closing until the sales settle
+// and the cash has been paid out.
+type CloseAccountRequest struct {
+	Reason    string `json:"reason"`
+	Liquidate bool   `json:"liquidate"`
+}
+
+type ClosureHolding struct {
+	AssetID           uuid.UUID `db:"asset_id" json:"asset_id"`
+	Quantity          float64   `db:"quantity" json:"quantity"`
+	AvailableQuantity float64   `db:"available_quantity" json:"available_quantity"`
+}
+
+// ClosureState is what stands between an account and closure.
+type ClosureState struct {
+	Status         string           `json:"status"`
+	AccountBalance float64          `json:"account_balance"`
+	AvailableCash  float64          `json:"available_cash"`
+	ReservedCash   float64          `json:"reserved_cash"`
+	OpenOrders     int              `json:"open_orders"`
+	Holdings       []ClosureHolding `json:"holdings"`
+}
+
+// Empty reports whether the account holds nothing and has nothing pending.
+func (s ClosureState) Empty() bool {
+	return s.AccountBalance == 0 && s.AvailableCash == 0 && s.ReservedCash == 0 &&
+		s.OpenOrders == 0 && len(s.Holdings) == 0
+}
+
+type CloseAccountResult struct {
+	Status            string        `json:"status"`
+	LiquidationOrders []uuid.UUID   `json:"liquidation_orders,omitempty"`
+	Remaining         *ClosureState `json:"remaining,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// BuyingPower breaks down what an account can spend. Available cash is the
+// cash balance less cash reserved for open buy orders. Withdrawals may draw
+// on the overdraft limit; purchases may also use the proceeds of sales that
+// have traded but not yet settled and, on margin accounts, borrow against
+// the lending value of their collateral.
+type BuyingPower struct {
+	AccountID           uuid.UUID       `db:"id" json:"account_id"`
+	Currency            string          `db:"account_currency" json:"currency"`
+	CashBalance         decimal.Decimal `db:"account_balance" json:"cash_balance"`
+	ReservedCash        decimal.Decimal `db:"reserved_cash" json:"reserved_cash"`
+	AvailableCash       decimal.Decimal `db:"available_cash" json:"available_cash"`
+	OverdraftLimit      decimal.Decimal `db:"overdraft_limit" json:"overdraft_limit"`
+	PendingSaleProceeds decimal.Decimal `db:"-" json:"pending_sale_proceeds"`
+	MarginEnabled       bool            `db:"margin_enabled" json:"margin_enabled"`
+	MarginLendingValue  decimal.Decimal `db:"-" json:"margin_lending_value"`
+	MarginUsed          decimal.Decimal `db:"-" json:"margin_used"`
+	WithdrawableCash    decimal.Decimal `db:"-" json:"withdrawable_cash"`
+	BuyingPower         decimal.Decimal `db:"-" json:"buying_power"`
+}
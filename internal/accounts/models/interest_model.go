@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// InterestAccount is an account the accrual job considers, with its annual
+// rates in percent.
+type InterestAccount struct {
+	AccountID         uuid.UUID  `db:"id"`
+	AccountHolderID   uuid.UUID  `db:"account_holder_id"`
+	InterestRate      float64    `db:"interest_rate"`
+	DebitInterestRate float64    `db:"debit_interest_rate"`
+	LastAccrualDate   *time.Time `db:"last_accrual_date"`
+}
+
+// DailyBalance is an account's cash balance at the end of a day.
+type DailyBalance struct {
+	Date    time.Time       `db:"balance_date"`
+	Balance decimal.Decimal `db:"balance"`
+}
+
+type InterestAccrual struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	AccountID   uuid.UUID       `db:"account_id" json:"account_id"`
+	AccrualDate time.Time       `db:"accrual_date" json:"accrual_date"`
+	Balance     decimal.Decimal `db:"balance" json:"balance"`
+	AnnualRate  float64         `db:"annual_rate" json:"annual_rate"`
+	DayCount    string          `db:"day_count" json:"day_count"`
+	Amount      decimal.Decimal `db:"amount" json:"amount"`
+	PostingID   *uuid.UUID      `db:"posting_id" json:"posting_id"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+}
+
+// InterestPosting books a month of accruals. Months that round to zero are
+// recorded without transactions.
+type InterestPosting struct {
+	ID                  uuid.UUID       `db:"id" json:"id"`
+	AccountID           uuid.UUID       `db:"account_id" json:"account_id"`
+	PeriodStart         time.Time       `db:"period_start" json:"period_start"`
+	PeriodEnd           time.Time       `db:"period_end" json:"period_end"`
+	Amount              decimal.Decimal `db:"amount" json:"amount"`
+	ClientTransactionID *uuid.UUID      `db:"client_transaction_id" json:"client_transaction_id"`
+	HouseTransactionID  *uuid.UUID      `db:"house_transaction_id" json:"house_transaction_id"`
+	PostedAt            time.Time       `db:"posted_at" json:"posted_at"`
+}
+
+// PendingInterest is the unposted interest of one account for one month.
+type PendingInterest struct {
+	AccountID       uuid.UUID       `db:"account_id"`
+	AccountHolderID uuid.UUID       `db:"account_holder_id"`
+	PeriodStart     time.Time       `db:"period_start"`
+	PeriodEnd       time.Time       `db:"period_end"`
+	Amount          decimal.Decimal `db:"amount"`
+}
+
+// AccruedInterest summarises an account's interest up to the last accrual.
+// Credit interest is positive and debit interest negative.
+type AccruedInterest struct {
+	AccountID       uuid.UUID         `json:"account_id"`
+	AccruedUnposted decimal.Decimal   `json:"accrued_unposted"`
+	PostedThisYear  decimal.Decimal   `json:"posted_this_year"`
+	LastAccrualDate *time.Time        `json:"last_accrual_date"`
+	Accruals        []InterestAccrual `json:"accruals"`
+	Postings        []InterestPosting `json:"postings"`
+}
+
+type InterestRunResult struct {
+	AccountsAccrued int `json:"accounts_accrued"`
+	Accruals        int `json:"accruals"`
+	Postings        int `json:"postings"`
+}
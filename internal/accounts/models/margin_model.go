@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MarginCallOpen        = "open"
+	MarginCallMet         = "met"
+	MarginCallLiquidating = "liquidating"
+	MarginCallLiquidated  = "liquidated"
+)
+
+type MarginHaircut struct {
+	AssetTypeID   uuid.UUID       `db:"asset_type_id" json:"asset_type_id"`
+	AssetTypeName string          `db:"type_name" json:"asset_type_name"`
+	LoanToValue   decimal.Decimal `db:"loan_to_value" json:"loan_to_value"`
+	UpdatedBy     *uuid.UUID      `db:"updated_by" json:"updated_by"`
+	UpdatedAt     *time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type MarginHaircutRequest struct {
+	LoanToValue decimal.Decimal `json:"loan_to_value"`
+}
+
+type MarginSettingsRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// CollateralPosition is a holding valued at its current price with the
+// lending value its asset type's loan-to-value allows.
+type CollateralPosition struct {
+	AssetID     uuid.UUID       `db:"asset_id" json:"asset_id"`
+	AssetTypeID *uuid.UUID      `db:"asset_type_id" json:"asset_type_id"`
+	Quantity    decimal.Decimal `db:"quantity" json:"quantity"`
+	// AvailableQuantity excludes what open sells and pending transfers have
+	// reserved; only that much can be sold in a forced liquidation.
+	AvailableQuantity decimal.Decimal `db:"available_quantity" json:"available_quantity"`
+	QuantityPrecision int32           `db:"quantity_precision" json:"-"`
+	Price             decimal.Decimal `db:"current_price" json:"price"`
+	LoanToValue       decimal.Decimal `db:"loan_to_value" json:"loan_to_value"`
+	MarketValue       decimal.Decimal `db:"-" json:"market_value"`
+	LendingValue      decimal.Decimal `db:"-" json:"lending_value"`
+}
+
+// MarginPosition compares what the account has borrowed beyond its
+// overdraft limit with the lending value of its collateral. Open purchases
+// count as collateral at their order amount since their cash is already
+// reserved.
+type MarginPosition struct {
+	AccountID              uuid.UUID            `json:"account_id"`
+	MarginEnabled          bool                 `json:"margin_enabled"`
+	CollateralValue        decimal.Decimal      `json:"collateral_value"`
+	PendingPurchaseLending decimal.Decimal      `json:"pending_purchase_lending_value"`
+	LendingValue           decimal.Decimal      `json:"lending_value"`
+	MarginUsed             decimal.Decimal      `json:"margin_used"`
+	Deficit                decimal.Decimal      `json:"deficit"`
+	Positions              []CollateralPosition `json:"positions"`
+}
+
+type MarginCall struct {
+	ID                  uuid.UUID       `db:"id" json:"id"`
+	AccountID           uuid.UUID       `db:"account_id" json:"account_id"`
+	Status              string          `db:"status" json:"status"`
+	MarginUsed          decimal.Decimal `db:"margin_used" json:"margin_used"`
+	LendingValue        decimal.Decimal `db:"lending_value" json:"lending_value"`
+	Deficit             decimal.Decimal `db:"deficit" json:"deficit"`
+	Deadline            time.Time       `db:"deadline" json:"deadline"`
+	LiquidationOrderIDs pq.StringArray  `db:"liquidation_order_ids" json:"liquidation_order_ids"`
+	CreatedAt           time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time       `db:"updated_at" json:"updated_at"`
+	ResolvedAt          *time.Time      `db:"resolved_at" json:"resolved_at"`
+}
+
+// MarginContact is who to notify about a margin call.
+type MarginContact struct {
+	AccountNumber string  `db:"account_number"`
+	Username      string  `db:"username"`
+	Email         *string `db:"email"`
+}
+
+type MarginRunResult struct {
+	AccountsChecked int `json:"accounts_checked"`
+	CallsOpened     int `json:"calls_opened"`
+	CallsMet        int `json:"calls_met"`
+	Liquidations    int `json:"liquidations"`
+}
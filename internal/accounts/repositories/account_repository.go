@@ -3,7 +3,9 @@ package repository
 import (
 	"context" // Added import for logging
 	"thyra/internal/accounts/models"
+	"thyra/internal/accounts/utils"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -18,10 +20,10 @@ func NewAccountRepository(db *sqlx.DB) *AccountRepository {
 func (r *AccountRepository) CreateAccount(ctx context.Context, account models.Account, accountNumber string, authUserID string) error {
 	_, err := r.db.NamedExecContext(ctx, `
         INSERT INTO thyrasec.accounts (account_name, account_type, account_owner_company, account_balance, account_currency,
-            account_number, account_status, interest_rate, overdraft_limit,
+            account_number, account_status, interest_rate, overdraft_limit, debit_interest_rate,
             account_description, account_holder_id, created_by, updated_by)
         VALUES (:account_name, :account_type, :account_owner_company, :account_balance, :account_currency,
-            :account_number, :account_status, :interest_rate, :overdraft_limit,
+            :account_number, :account_status, :interest_rate, :overdraft_limit, :debit_interest_rate,
             :account_description, :account_holder_id, :created_by, :updated_by)
     `, map[string]interface{}{
 		"account_name":          account.AccountName,
@@ -34,6 +36,7 @@ func (r *AccountRepository) CreateAccount(ctx context.Context, account models.Ac
 		"account_status":      account.AccountStatus,
 		"interest_rate":       account.InterestRate,
 		"overdraft_limit":     account.OverdraftLimit,
+		"debit_interest_rate": account.DebitInterestRate,
 		"account_description": account.AccountDescription,
 		"account_holder_id":   account.AccountHolderId,
 		"created_by":          authUserID,
@@ -54,6 +57,7 @@ func (r *AccountRepository) GetAccountsByUser(ctx context.Context, userID string
 	accounts.account_status,
 	accounts.interest_rate,
 	accounts.overdraft_limit,
+	accounts.debit_interest_rate,
 	accounts.account_description,
 	accounts.account_holder_id,
 	accounts.created_at,
@@ -84,6 +88,7 @@ func (r *AccountRepository) GetAllAccounts(ctx context.Context) ([]models.Accoun
         accounts.account_status,
         accounts.interest_rate,
         accounts.overdraft_limit,
+        accounts.debit_interest_rate,
         accounts.account_description,
         accounts.account_holder_id,
         accounts.created_at,
@@ -118,3 +123,13 @@ func (r *AccountRepository) GetHouseAccount(ctx context.Context) (string, error)
 	err := r.db.GetContext(ctx, &accountID, query, "House")
 	return accountID, err
 }
+
+func (r *AccountRepository) GetAccountHolder(ctx context.Context, accountID uuid.UUID) (uuid.UUID, error) {
+	var holderID uuid.UUID
+	err := r.db.GetContext(ctx, &holderID, "SELECT account_holder_id FROM thyrasec.accounts WHERE id = $1", accountID)
+	return holderID, err
+}
+
+func (r *AccountRepository) GetBuyingPower(ctx context.Context, accountID uuid.UUID) (models.BuyingPower, error) {
+	return utils.GetBuyingPower(r.db, accountID)
+}
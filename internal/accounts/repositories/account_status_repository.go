@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/accounts/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountStatusRepository struct {
+	db *sqlx.DB
+}
+
+func NewAccountStatusRepository(db *sqlx.DB) *AccountStatusRepository {
+	return &AccountStatusRepository{db: db}
+}
+
+func (r *AccountStatusRepository) GetAccountStatus(ctx context.Context, accountID uuid.UUID) (string, error) {
+	var status string
+	err := r.db.GetContext(ctx, &status, `SELECT account_status FROM thyrasec.accounts WHERE id = $1`, accountID)
+	return status, err
+}
+
+// ChangeStatus moves the account from one status to another and records the
+// change. It returns false without writing anything if the account is no
+// longer in the expected status.
+func (r *AccountStatusRepository) ChangeStatus(ctx context.Context, accountID uuid.UUID, from, to, reason string, changedBy uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+        UPDATE thyrasec.accounts
+        SET account_status = $1,
+            closed_at = CASE WHEN $1 = 'closed' THEN NOW() ELSE NULL END,
+            updated_at = NOW(),
+            updated_by = $2
+        WHERE id = $3 AND account_status = $4
+    `, to, changedBy.String(), accountID, from)
+	if err != nil {
+		return false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO thyrasec.account_status_history (account_id, from_status, to_status, reason, changed_by)
+        VALUES ($1, $2, $3, $4, $5)
+    `, accountID, from, to, reason, changedBy); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *AccountStatusRepository) GetStatusHistory(ctx context.Context, accountID uuid.UUID) ([]models.AccountStatusChange, error) {
+	history := []models.AccountStatusChange{}
+	err := r.db.SelectContext(ctx, &history, `
+        SELECT id, account_id, from_status, to_status, reason, changed_by, created_at
+        FROM thyrasec.account_status_history
+        WHERE account_id = $1
+        ORDER BY created_at DESC
+    `, accountID)
+	return history, err
+}
+
+// GetClosureState collects the balances, positions and open orders that
+// have to be cleared before the account can be closed.
+func (r *AccountStatusRepository) GetClosureState(ctx context.Context, accountID uuid.UUID) (models.ClosureState, error) {
+	var state models.ClosureState
+	err := r.db.QueryRowxContext(ctx, `
+        SELECT account_status, account_balance, available_cash, reserved_cash
+        FROM thyrasec.accounts
+        WHERE id = $1
+    `, accountID).Scan(&state.Status, &state.AccountBalance, &state.AvailableCash, &state.ReservedCash)
+	if err != nil {
+		return state, err
+	}
+
+	if err := r.db.GetContext(ctx, &state.OpenOrders, `
+        SELECT COUNT(*)
+        FROM thyrasec.orders
+        WHERE account_id = $1 AND status IN ('created', 'confirmed', 'pending')
+    `, accountID); err != nil {
+		return state, err
+	}
+
+	state.Holdings = []models.ClosureHolding{}
+	err = r.db.SelectContext(ctx, &state.Holdings, `
+        SELECT asset_id, quantity, available_quantity
+        FROM thyrasec.holdings
+        WHERE account_id = $1 AND (quantity > 0 OR available_quantity > 0)
+        ORDER BY asset_id
+    `, accountID)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return state, err
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/accounts/models"
+	"thyra/internal/accounts/utils"
+	orderutils "thyra/internal/orders/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type InterestRepository struct {
+	db *sqlx.DB
+}
+
+func NewInterestRepository(db *sqlx.DB) *InterestRepository {
+	return &InterestRepository{db: db}
+}
+
+// GetInterestAccounts returns the open customer accounts that earn or pay
+// interest, with the date of their latest accrual.
+func (r *InterestRepository) GetInterestAccounts(ctx context.Context) ([]models.InterestAccount, error) {
+	var accounts []models.InterestAccount
+	query := `
+        SELECT a.id, a.account_holder_id,
+            COALESCE(a.interest_rate, 0) AS interest_rate,
+            COALESCE(a.debit_interest_rate, 0) AS debit_interest_rate,
+            (SELECT MAX(ia.accrual_date) FROM thyrasec.interest_accruals ia WHERE ia.account_id = a.id) AS last_accrual_date
+        FROM thyrasec.accounts a
+        LEFT JOIN thyrasec.account_types at ON at.id = a.account_type
+        WHERE a.account_status IN ('active', 'frozen', 'closing')
+            AND at.account_type_name IS DISTINCT FROM 'House'
+            AND (COALESCE(a.interest_rate, 0) <> 0 OR COALESCE(a.debit_interest_rate, 0) <> 0)`
+	err := r.db.SelectContext(ctx, &accounts, query)
+	return accounts, err
+}
+
+// GetEndOfDayBalances returns the account's balance at the end of each day
+// from start to end. Past balances are not stored, so each is the current
+// balance less the cash transactions booked on the account after that day.
+func (r *InterestRepository) GetEndOfDayBalances(ctx context.Context, accountID uuid.UUID, start, end time.Time) ([]models.DailyBalance, error) {
+	balances := []models.DailyBalance{}
+	query := `
+        SELECT d::date AS balance_date,
+            a.account_balance - COALESCE((
+                SELECT SUM(t.cash_amount)
+                FROM thyrasec.transactions t
+                WHERE t.cash_account_id = a.id
+                    AND t.created_at >= d + interval '1 day'
+                    AND NOT t.canceled
+            ), 0) AS balance
+        FROM thyrasec.accounts a
+        CROSS JOIN generate_series($2::date, $3::date, interval '1 day') d
+        WHERE a.id = $1
+        ORDER BY d`
+	err := r.db.SelectContext(ctx, &balances, query, accountID, start, end)
+	return balances, err
+}
+
+// InsertAccruals stores daily accruals, skipping days already accrued.
+func (r *InterestRepository) InsertAccruals(ctx context.Context, accruals []models.InterestAccrual) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	inserted := 0
+	for _, accrual := range accruals {
+		result, err := tx.ExecContext(ctx, `
+            INSERT INTO thyrasec.interest_accruals (account_id, accrual_date, balance, annual_rate, day_count, amount)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            ON CONFLICT (account_id, accrual_date) DO NOTHING`,
+			accrual.AccountID, accrual.AccrualDate, accrual.Balance, accrual.AnnualRate, accrual.DayCount, accrual.Amount)
+		if err != nil {
+			return 0, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		inserted += int(rows)
+	}
+
+	return inserted, tx.Commit()
+}
+
+// GetPendingInterest sums unposted accruals per account and calendar month for
+// months ending before the given date.
+func (r *InterestRepository) GetPendingInterest(ctx context.Context, before time.Time) ([]models.PendingInterest, error) {
+	var pending []models.PendingInterest
+	query := `
+        SELECT ia.account_id, a.account_holder_id,
+            date_trunc('month', ia.accrual_date)::date AS period_start,
+            (date_trunc('month', ia.accrual_date) + interval '1 month - 1 day')::date AS period_end,
+            SUM(ia.amount) AS amount
+        FROM thyrasec.interest_accruals ia
+        JOIN thyrasec.accounts a ON a.id = ia.account_id
+        WHERE ia.posting_id IS NULL AND ia.accrual_date < $1
+        GROUP BY ia.account_id, a.account_holder_id, date_trunc('month', ia.accrual_date)
+        ORDER BY period_start, ia.account_id`
+	err := r.db.SelectContext(ctx, &pending, query, before)
+	return pending, err
+}
+
+// PostInterest books a month of accrued interest as a pair of cash
+// transactions between the account and the house account and marks the
+// accruals as posted. It returns false if the month was already posted.
+func (r *InterestRepository) PostInterest(ctx context.Context, pending models.PendingInterest, houseAccountID, interestTypeID uuid.UUID) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	amount := pending.Amount.Round(2)
+	var clientTransactionID, houseTransactionID *uuid.UUID
+	if !amount.IsZero() {
+		clientID, houseID := uuid.New(), uuid.New()
+		clientTransactionID, houseTransactionID = &clientID, &houseID
+
+		comment := "Interest " + pending.PeriodStart.Format("2006-01")
+		orderNumber := orderutils.GenerateOrderNumber()
+		if err := insertInterestTransaction(ctx, tx, clientID, interestTypeID, pending.AccountID, pending.AccountHolderID, amount, comment, pending.PeriodEnd, orderNumber); err != nil {
+			return false, err
+		}
+		if err := insertInterestTransaction(ctx, tx, houseID, interestTypeID, houseAccountID, uuid.Nil, amount.Neg(), comment, pending.PeriodEnd, orderNumber); err != nil {
+			return false, err
+		}
+		if err := adjustCash(ctx, tx, pending.AccountID, amount); err != nil {
+			return false, err
+		}
+		if err := adjustCash(ctx, tx, houseAccountID, amount.Neg()); err != nil {
+			return false, err
+		}
+	}
+
+	var postingID uuid.UUID
+	err = tx.QueryRowxContext(ctx, `
+        INSERT INTO thyrasec.interest_postings (account_id, period_start, period_end, amount, client_transaction_id, house_transaction_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (account_id, period_start) DO NOTHING
+        RETURNING id`,
+		pending.AccountID, pending.PeriodStart, pending.PeriodEnd, amount, clientTransactionID, houseTransactionID).Scan(&postingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE thyrasec.interest_accruals
+        SET posting_id = $1
+        WHERE account_id = $2 AND accrual_date BETWEEN $3 AND $4 AND posting_id IS NULL`,
+		postingID, pending.AccountID, pending.PeriodStart, pending.PeriodEnd); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func insertInterestTransaction(ctx context.Context, tx *sqlx.Tx, id, typeID, accountID, ownerID uuid.UUID, amount decimal.Decimal, comment string, bookingDate time.Time, orderNumber string) error {
+	now := time.Now()
+	cashAmount, _ := amount.Float64()
+	_, err := tx.ExecContext(ctx, `
+        INSERT INTO thyrasec.transactions (
+            id, type, cash_amount, cash_account_id, created_by_id, updated_by_id, created_at, updated_at,
+            corrected, canceled, comment, transaction_owner_id, transaction_owner_account_id,
+            trade_date, settlement_date, order_no
+        )
+        VALUES ($1, $2, $3, $4, $5, $5, $6, $6, false, false, $7, $8, $4, $9, $9, $10)`,
+		id, typeID, cashAmount, accountID, uuid.Nil, now, comment, ownerID, bookingDate, orderNumber)
+	return err
+}
+
+func adjustCash(ctx context.Context, tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) error {
+	_, err := tx.ExecContext(ctx, `
+        UPDATE thyrasec.accounts
+        SET account_balance = account_balance + $1,
+            available_cash = available_cash + $1,
+            updated_at = NOW()
+        WHERE id = $2`, amount, accountID)
+	return err
+}
+
+func (r *InterestRepository) GetInterestTypeID(ctx context.Context) (uuid.UUID, error) {
+	var typeID uuid.UUID
+	err := r.db.GetContext(ctx, &typeID, `SELECT type_id FROM thyrasec.transactions_types WHERE transaction_type_name = 'Interest' LIMIT 1`)
+	return typeID, err
+}
+
+func (r *InterestRepository) GetHouseAccountID(ctx context.Context) (uuid.UUID, error) {
+	houseAccount, err := utils.GetHouseAccount(r.db)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(houseAccount)
+}
+
+func (r *InterestRepository) GetAccountHolder(ctx context.Context, accountID uuid.UUID) (uuid.UUID, error) {
+	var holderID uuid.UUID
+	err := r.db.GetContext(ctx, &holderID, `SELECT account_holder_id FROM thyrasec.accounts WHERE id = $1`, accountID)
+	return holderID, err
+}
+
+func (r *InterestRepository) GetUnpostedAccruals(ctx context.Context, accountID uuid.UUID) ([]models.InterestAccrual, error) {
+	accruals := []models.InterestAccrual{}
+	err := r.db.SelectContext(ctx, &accruals, `
+        SELECT id, account_id, accrual_date, balance, annual_rate, day_count, amount, posting_id, created_at
+        FROM thyrasec.interest_accruals
+        WHERE account_id = $1 AND posting_id IS NULL
+        ORDER BY accrual_date`, accountID)
+	return accruals, err
+}
+
+func (r *InterestRepository) GetPostings(ctx context.Context, accountID uuid.UUID, since time.Time) ([]models.InterestPosting, error) {
+	postings := []models.InterestPosting{}
+	err := r.db.SelectContext(ctx, &postings, `
+        SELECT id, account_id, period_start, period_end, amount, client_transaction_id, house_transaction_id, posted_at
+        FROM thyrasec.interest_postings
+        WHERE account_id = $1 AND period_start >= $2
+        ORDER BY period_start`, accountID, since)
+	return postings, err
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/accounts/models"
+	"thyra/internal/accounts/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+type MarginRepository struct {
+	db *sqlx.DB
+}
+
+func NewMarginRepository(db *sqlx.DB) *MarginRepository {
+	return &MarginRepository{db: db}
+}
+
+// GetHaircuts lists every asset type with its loan-to-value; types without a
+// haircut lend nothing.
+func (r *MarginRepository) GetHaircuts(ctx context.Context) ([]models.MarginHaircut, error) {
+	haircuts := []models.MarginHaircut{}
+	err := r.db.SelectContext(ctx, &haircuts, `
+        SELECT at.id AS asset_type_id, at.type_name, COALESCE(mh.loan_to_value, 0) AS loan_to_value,
+            mh.updated_by, mh.updated_at
+        FROM thyrasec.asset_types at
+        LEFT JOIN thyrasec.margin_haircuts mh ON mh.asset_type_id = at.id
+        ORDER BY at.type_name`)
+	return haircuts, err
+}
+
+func (r *MarginRepository) AssetTypeExists(ctx context.Context, assetTypeID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM thyrasec.asset_types WHERE id = $1)`, assetTypeID)
+	return exists, err
+}
+
+func (r *MarginRepository) SetHaircut(ctx context.Context, assetTypeID uuid.UUID, loanToValue decimal.Decimal, updatedBy uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO thyrasec.margin_haircuts (asset_type_id, loan_to_value, updated_by, updated_at)
+        VALUES ($1, $2, $3, NOW())
+        ON CONFLICT (asset_type_id) DO UPDATE
+        SET loan_to_value = EXCLUDED.loan_to_value, updated_by = EXCLUDED.updated_by, updated_at = NOW()`,
+		assetTypeID, loanToValue, updatedBy)
+	return err
+}
+
+// SetMarginEnabled reports false if the account does not exist.
+func (r *MarginRepository) SetMarginEnabled(ctx context.Context, accountID uuid.UUID, enabled bool, updatedBy uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.accounts
+        SET margin_enabled = $1, updated_at = NOW(), updated_by = $2
+        WHERE id = $3`, enabled, updatedBy.String(), accountID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *MarginRepository) GetAccountHolder(ctx context.Context, accountID uuid.UUID) (uuid.UUID, error) {
+	var holderID uuid.UUID
+	err := r.db.GetContext(ctx, &holderID, `SELECT account_holder_id FROM thyrasec.accounts WHERE id = $1`, accountID)
+	return holderID, err
+}
+
+func (r *MarginRepository) GetMarginPosition(ctx context.Context, accountID uuid.UUID) (models.MarginPosition, error) {
+	return utils.GetMarginPosition(r.db, accountID)
+}
+
+// GetMarginAccounts returns the open margin accounts and any account that
+// still has an active margin call.
+func (r *MarginRepository) GetMarginAccounts(ctx context.Context) ([]uuid.UUID, error) {
+	var accountIDs []uuid.UUID
+	err := r.db.SelectContext(ctx, &accountIDs, `
+        SELECT id FROM thyrasec.accounts
+        WHERE margin_enabled AND account_status IN ('active', 'frozen', 'closing')
+        UNION
+        SELECT account_id FROM thyrasec.margin_calls WHERE status IN ('open', 'liquidating')`)
+	return accountIDs, err
+}
+
+// GetActiveCall returns the account's open or liquidating call, or nil.
+func (r *MarginRepository) GetActiveCall(ctx context.Context, accountID uuid.UUID) (*models.MarginCall, error) {
+	var call models.MarginCall
+	err := r.db.GetContext(ctx, &call, `
+        SELECT * FROM thyrasec.margin_calls
+        WHERE account_id = $1 AND status IN ('open', 'liquidating')`, accountID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+// OpenCall records a margin call. It returns false if the account already
+// has an active call.
+func (r *MarginRepository) OpenCall(ctx context.Context, call *models.MarginCall) (bool, error) {
+	err := r.db.QueryRowxContext(ctx, `
+        INSERT INTO thyrasec.margin_calls (account_id, status, margin_used, lending_value, deficit, deadline)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT DO NOTHING
+        RETURNING id, created_at, updated_at`,
+		call.AccountID, models.MarginCallOpen, call.MarginUsed, call.LendingValue, call.Deficit, call.Deadline).
+		Scan(&call.ID, &call.CreatedAt, &call.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	call.Status = models.MarginCallOpen
+	return true, nil
+}
+
+// UpdateCallPosition refreshes the figures on an active call.
+func (r *MarginRepository) UpdateCallPosition(ctx context.Context, callID uuid.UUID, marginUsed, lendingValue, deficit decimal.Decimal) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.margin_calls
+        SET margin_used = $1, lending_value = $2, deficit = $3, updated_at = NOW()
+        WHERE id = $4`, marginUsed, lendingValue, deficit, callID)
+	return err
+}
+
+func (r *MarginRepository) ResolveCall(ctx context.Context, callID uuid.UUID, status string, resolvedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.margin_calls
+        SET status = $1, resolved_at = $2, updated_at = NOW()
+        WHERE id = $3`, status, resolvedAt, callID)
+	return err
+}
+
+func (r *MarginRepository) StartLiquidation(ctx context.Context, callID uuid.UUID, orderIDs []uuid.UUID) error {
+	ids := make(pq.StringArray, 0, len(orderIDs))
+	for _, id := range orderIDs {
+		ids = append(ids, id.String())
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.margin_calls
+        SET status = 'liquidating',
+            liquidation_order_ids = COALESCE(liquidation_order_ids, '{}') || $1::uuid[],
+            updated_at = NOW()
+        WHERE id = $2`, ids, callID)
+	return err
+}
+
+// HasOpenLiquidationOrders reports whether any of the call's forced sales
+// has not settled yet.
+func (r *MarginRepository) HasOpenLiquidationOrders(ctx context.Context, call models.MarginCall) (bool, error) {
+	if len(call.LiquidationOrderIDs) == 0 {
+		return false, nil
+	}
+	var open bool
+	err := r.db.GetContext(ctx, &open, `
+        SELECT EXISTS (
+            SELECT 1 FROM thyrasec.orders
+            WHERE id = ANY($1::uuid[]) AND status IN ('created', 'confirmed', 'executed', 'pending')
+        )`, call.LiquidationOrderIDs)
+	return open, err
+}
+
+// GetCalls lists margin calls, optionally for one account or status.
+func (r *MarginRepository) GetCalls(ctx context.Context, accountID *uuid.UUID, status string) ([]models.MarginCall, error) {
+	calls := []models.MarginCall{}
+	err := r.db.SelectContext(ctx, &calls, `
+        SELECT * FROM thyrasec.margin_calls
+        WHERE ($1::uuid IS NULL OR account_id = $1)
+            AND ($2 = '' OR status = $2)
+        ORDER BY created_at DESC`, accountID, status)
+	return calls, err
+}
+
+func (r *MarginRepository) GetContact(ctx context.Context, accountID uuid.UUID) (models.MarginContact, error) {
+	var contact models.MarginContact
+	err := r.db.GetContext(ctx, &contact, `
+        SELECT COALESCE(a.account_number, '') AS account_number, u.username, u.email
+        FROM thyrasec.accounts a
+        JOIN thyrasec.users u ON u.id = a.account_holder_id
+        WHERE a.id = $1`, accountID)
+	return contact, err
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.RouterGroup, accountBalanceHandler *handlers.AccountBalanceHandler, accountHandler *handlers.AccountHandler) {
+func SetupRoutes(router *gin.RouterGroup, accountBalanceHandler *handlers.AccountBalanceHandler, accountHandler *handlers.AccountHandler, lifecycleHandler *handlers.AccountLifecycleHandler, interestHandler *handlers.InterestHandler, marginHandler *handlers.MarginHandler) {
 	router.POST("/create/account", accountHandler.CreateAccount)
 	router.GET("/user/:userId/accounts", accountHandler.GetAccountsByUser)
 	router.GET("/accounts", accountHandler.GetAllAccounts)
@@ -17,4 +17,19 @@ func SetupRoutes(router *gin.RouterGroup, accountBalanceHandler *handlers.Accoun
 
 	router.GET("/user/:userId/aggregated-values", accountBalanceHandler.GetAggregatedValues)
 	router.GET("/account/:accountId/values", accountBalanceHandler.GetSpecificAccountValue)
+	router.GET("/account/:accountId/buying-power", accountHandler.GetBuyingPower)
+
+	router.PUT("/account/:accountId/status", lifecycleHandler.ChangeStatus)
+	router.POST("/account/:accountId/close", lifecycleHandler.CloseAccount)
+	router.GET("/account/:accountId/status-history", lifecycleHandler.GetStatusHistory)
+	router.GET("/account/:accountId/closure-check", lifecycleHandler.GetClosureCheck)
+
+	router.GET("/account/:accountId/interest", interestHandler.GetAccruedInterest)
+
+	router.GET("/margin/haircuts", marginHandler.GetHaircuts)
+	router.PUT("/margin/haircuts/:assetTypeId", marginHandler.SetHaircut)
+	router.GET("/margin/calls", marginHandler.GetCalls)
+	router.PUT("/account/:accountId/margin", marginHandler.SetMarginEnabled)
+	router.GET("/account/:accountId/margin", marginHandler.GetMarginPosition)
+	router.GET("/account/:accountId/margin-calls", marginHandler.GetAccountCalls)
 }
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"thyra/internal/accounts/models"
+	repository "thyra/internal/accounts/repositories"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLifecycleAccessDenied = errors.New("only admins can change account status")
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrInvalidAccountStatus  = errors.New("status must be one of pending, active, frozen, closing or closed")
+	ErrStatusReasonRequired  = errors.New("a reason is required to change account status")
+	ErrStatusChanged         = errors.New("account status was changed concurrently, retry")
+	ErrAccountClosed         = errors.New("account is already closed")
+)
+
+// TransitionError is returned when the lifecycle does not allow moving
+// directly between the two statuses.
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("account status cannot change from %s to %s", e.From, e.To)
+}
+
+// AccountNotEmptyError carries what is still left on an account that was asked
+// to close.
+type AccountNotEmptyError struct {
+	State models.ClosureState
+}
+
+func (e *AccountNotEmptyError) Error() string {
+	return "account still has holdings, cash or open orders"
+}
+
+// AccountLiquidator places sell orders for everything the account holds and
+// returns the IDs of the orders placed.
+type AccountLiquidator interface {
+	LiquidateAccount(accountID, requestedBy uuid.UUID) ([]uuid.UUID, error)
+}
+
+// statusTransitions lists the statuses reachable from each status through
+// the status endpoint. Closing an account goes through CloseAccount so the
+// balance checks always run.
+var statusTransitions = map[string][]string{
+	models.AccountStatusPending: {models.AccountStatusActive},
+	models.AccountStatusActive:  {models.AccountStatusFrozen, models.AccountStatusClosing},
+	models.AccountStatusFrozen:  {models.AccountStatusActive},
+	models.AccountStatusClosing: {models.AccountStatusActive},
+	models.AccountStatusClosed:  {models.AccountStatusActive},
+}
+
+// closeTransitions lists the steps CloseAccount may take. An active account
+// winds down through closing, while a pending account was never opened and
+// can be closed directly.
+var closeTransitions = map[string][]string{
+	models.AccountStatusPending: {models.AccountStatusClosed},
+	models.AccountStatusActive:  {models.AccountStatusClosing},
+	models.AccountStatusClosing: {models.AccountStatusClosed},
+}
+
+var accountStatuses = []string{
+	models.AccountStatusPending,
+	models.AccountStatusActive,
+	models.AccountStatusFrozen,
+	models.AccountStatusClosing,
+	models.AccountStatusClosed,
+}
+
+func isAccountStatus(status string) bool {
+	for _, s := range accountStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func canTransition(from, to string) bool {
+	return allowsTransition(statusTransitions, from, to)
+}
+
+func canClose(from, to string) bool {
+	return allowsTransition(closeTransitions, from, to)
+}
+
+func allowsTransition(transitions map[string][]string, from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+type AccountLifecycleService struct {
+	repo       *repository.AccountStatusRepository
+	liquidator AccountLiquidator
+}
+
+func NewAccountLifecycleService(repo *repository.AccountStatusRepository, liquidator AccountLiquidator) *AccountLifecycleService {
+	return &AccountLifecycleService{repo: repo, liquidator: liquidator}
+}
+
+// ChangeStatus freezes, unfreezes, starts winding down or reopens an account.
+func (s *AccountLifecycleService) ChangeStatus(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string, req models.AccountStatusRequest) error {
+	if authUserRole != "admin" {
+		return ErrLifecycleAccessDenied
+	}
+	if !isAccountStatus(req.Status) {
+		return ErrInvalidAccountStatus
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return ErrStatusReasonRequired
+	}
+
+	current, err := s.currentStatus(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if !canTransition(current, req.Status) {
+		return &TransitionError{From: current, To: req.Status}
+	}
+
+	return s.transition(ctx, accountID, current, req.Status, reason, authUserID)
+}
+
+// CloseAccount closes an empty account. An active account that still holds
+// securities is moved to closing and, if asked, its holdings are sold; the
+// remaining cash has to be withdrawn before the account can be closed. Frozen
+// accounts have to be unfrozen first.
+func (s *AccountLifecycleService) CloseAccount(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string, req models.CloseAccountRequest) (models.CloseAccountResult, error) {
+	if authUserRole != "admin" {
+		return models.CloseAccountResult{}, ErrLifecycleAccessDenied
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return models.CloseAccountResult{}, ErrStatusReasonRequired
+	}
+
+	state, err := s.closureState(ctx, accountID)
+	if err != nil {
+		return models.CloseAccountResult{}, err
+	}
+
+	if state.Status == models.AccountStatusClosed {
+		return models.CloseAccountResult{}, ErrAccountClosed
+	}
+	if canClose(state.Status, models.AccountStatusClosing) {
+		if err := s.transition(ctx, accountID, state.Status, models.AccountStatusClosing, reason, authUserID); err != nil {
+			return models.CloseAccountResult{}, err
+		}
+		state.Status = models.AccountStatusClosing
+	}
+	if !canClose(state.Status, models.AccountStatusClosed) {
+		return models.CloseAccountResult{}, &TransitionError{From: state.Status, To: models.AccountStatusClosed}
+	}
+
+	if state.Empty() {
+		if err := s.transition(ctx, accountID, state.Status, models.AccountStatusClosed, reason, authUserID); err != nil {
+			return models.CloseAccountResult{}, err
+		}
+		return models.CloseAccountResult{Status: models.AccountStatusClosed}, nil
+	}
+
+	result := models.CloseAccountResult{Status: state.Status}
+	if req.Liquidate && len(state.Holdings) > 0 && s.liquidator != nil {
+		orderIDs, err := s.liquidator.LiquidateAccount(accountID, authUserID)
+		result.LiquidationOrders = orderIDs
+		if err != nil {
+			return result, err
+		}
+
+		state, err = s.closureState(ctx, accountID)
+		if err != nil {
+			return result, err
+		}
+		result.Remaining = &state
+		return result, nil
+	}
+
+	return result, &AccountNotEmptyError{State: state}
+}
+
+func (s *AccountLifecycleService) GetStatusHistory(ctx context.Context, accountID uuid.UUID, authUserRole string) ([]models.AccountStatusChange, error) {
+	if authUserRole != "admin" {
+		return nil, ErrLifecycleAccessDenied
+	}
+	if _, err := s.currentStatus(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStatusHistory(ctx, accountID)
+}
+
+func (s *AccountLifecycleService) GetClosureState(ctx context.Context, accountID uuid.UUID, authUserRole string) (models.ClosureState, error) {
+	if authUserRole != "admin" {
+		return models.ClosureState{}, ErrLifecycleAccessDenied
+	}
+	return s.closureState(ctx, accountID)
+}
+
+func (s *AccountLifecycleService) currentStatus(ctx context.Context, accountID uuid.UUID) (string, error) {
+	status, err := s.repo.GetAccountStatus(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return "", ErrAccountNotFound
+	}
+	return status, err
+}
+
+func (s *AccountLifecycleService) closureState(ctx context.Context, accountID uuid.UUID) (models.ClosureState, error) {
+	state, err := s.repo.GetClosureState(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return state, ErrAccountNotFound
+	}
+	return state, err
+}
+
+func (s *AccountLifecycleService) transition(ctx context.Context, accountID uuid.UUID, from, to, reason string, changedBy uuid.UUID) error {
+	changed, err := s.repo.ChangeStatus(ctx, accountID, from, to, reason, changedBy)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return ErrStatusChanged
+	}
+	return nil
+}
@@ -2,12 +2,21 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"thyra/internal/accounts/models"
 	repository "thyra/internal/accounts/repositories"
 	"thyra/internal/accounts/utils"
+
+	"github.com/google/uuid"
 )
 
+// ErrInvalidInitialStatus is returned when a new account is not opened as
+// pending or active.
+var ErrInvalidInitialStatus = errors.New("new accounts must be pending or active")
+
+var ErrBuyingPowerAccessDenied = errors.New("not allowed to fetch another user's buying power")
+
 type AccountService struct {
 	repo *repository.AccountRepository
 }
@@ -17,6 +26,14 @@ func NewAccountService(repo *repository.AccountRepository) *AccountService {
 }
 
 func (s *AccountService) CreateAccount(ctx context.Context, account models.Account, authUserID string) error {
+	switch account.AccountStatus {
+	case "":
+		account.AccountStatus = models.AccountStatusActive
+	case models.AccountStatusPending, models.AccountStatusActive:
+	default:
+		return ErrInvalidInitialStatus
+	}
+
 	accountNumber := utils.GenerateAccountNumber(account.AccountType.String())
 	return s.repo.CreateAccount(ctx, account, accountNumber, authUserID)
 }
@@ -48,3 +65,17 @@ func (s *AccountService) GetHouseAccount(ctx context.Context, authUserRole strin
 	}
 	return s.repo.GetHouseAccount(ctx)
 }
+
+func (s *AccountService) GetBuyingPower(ctx context.Context, accountID uuid.UUID, authUserID string, authUserRole string) (models.BuyingPower, error) {
+	holderID, err := s.repo.GetAccountHolder(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return models.BuyingPower{}, ErrAccountNotFound
+	}
+	if err != nil {
+		return models.BuyingPower{}, err
+	}
+	if authUserRole != "admin" && authUserID != holderID.String() {
+		return models.BuyingPower{}, ErrBuyingPowerAccessDenied
+	}
+	return s.repo.GetBuyingPower(ctx, accountID)
+}
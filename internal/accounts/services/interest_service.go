@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"thyra/internal/accounts/models"
+	repository "thyra/internal/accounts/repositories"
+	"thyra/internal/accounts/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// maxAccrualCatchUpDays bounds how far back a missed accrual is filled in.
+const maxAccrualCatchUpDays = 7
+
+var ErrInterestAccessDenied = errors.New("not allowed to view interest on another user's account")
+
+type InterestService struct {
+	repo     *repository.InterestRepository
+	dayCount string
+}
+
+func NewInterestService(repo *repository.InterestRepository, dayCount string) *InterestService {
+	return &InterestService{repo: repo, dayCount: dayCount}
+}
+
+// NewInterestServiceFromEnv reads the day-count convention from
+// INTEREST_DAY_COUNT, defaulting to ACT/365.
+func NewInterestServiceFromEnv(repo *repository.InterestRepository) *InterestService {
+	dayCount := utils.DayCountACT365
+	if value := os.Getenv("INTEREST_DAY_COUNT"); value != "" {
+		parsed, err := utils.ParseDayCount(value)
+		if err != nil {
+			log.Printf("Ignoring INTEREST_DAY_COUNT: %v", err)
+		} else {
+			dayCount = parsed
+		}
+	}
+	return NewInterestService(repo, dayCount)
+}
+
+// Run accrues interest for every completed day up to now and posts the
+// accruals of completed months.
+func (s *InterestService) Run(ctx context.Context, now time.Time) (models.InterestRunResult, error) {
+	var result models.InterestRunResult
+	accounts, accruals, err := s.AccrueInterest(ctx, now)
+	result.AccountsAccrued, result.Accruals = accounts, accruals
+	if err != nil {
+		return result, err
+	}
+
+	result.Postings, err = s.PostInterest(ctx, now)
+	return result, err
+}
+
+// AccrueInterest stores a daily accrual for each day before today that has
+// not been accrued, on that day's end-of-day balance. Positive balances earn
+// interest_rate; overdrawn balances are charged debit_interest_rate.
+func (s *InterestService) AccrueInterest(ctx context.Context, now time.Time) (int, int, error) {
+	today := truncateToDate(now)
+	yesterday := today.AddDate(0, 0, -1)
+	earliest := today.AddDate(0, 0, -maxAccrualCatchUpDays)
+
+	accounts, err := s.repo.GetInterestAccounts(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var accruals []models.InterestAccrual
+	accrued := 0
+	for _, account := range accounts {
+		start := yesterday
+		if account.LastAccrualDate != nil {
+			start = truncateToDate(*account.LastAccrualDate).AddDate(0, 0, 1)
+			if start.Before(earliest) {
+				start = earliest
+			}
+		}
+		if start.After(yesterday) {
+			continue
+		}
+
+		balances, err := s.repo.GetEndOfDayBalances(ctx, account.AccountID, start, yesterday)
+		if err != nil {
+			return 0, 0, err
+		}
+		before := len(accruals)
+		for _, day := range balances {
+			rate := account.InterestRate
+			if day.Balance.IsNegative() {
+				rate = account.DebitInterestRate
+			}
+			if rate == 0 || day.Balance.IsZero() {
+				continue
+			}
+			accruals = append(accruals, models.InterestAccrual{
+				AccountID:   account.AccountID,
+				AccrualDate: truncateToDate(day.Date),
+				Balance:     day.Balance,
+				AnnualRate:  rate,
+				DayCount:    s.dayCount,
+				Amount:      utils.DailyInterest(day.Balance, rate, s.dayCount),
+			})
+		}
+		if len(accruals) > before {
+			accrued++
+		}
+	}
+
+	if len(accruals) == 0 {
+		return 0, 0, nil
+	}
+	inserted, err := s.repo.InsertAccruals(ctx, accruals)
+	return accrued, inserted, err
+}
+
+// PostInterest books the accruals of every month before the current one. A
+// posting that fails is logged and retried on the next run.
+func (s *InterestService) PostInterest(ctx context.Context, now time.Time) (int, error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	pending, err := s.repo.GetPendingInterest(ctx, monthStart)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	houseAccountID, err := s.repo.GetHouseAccountID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	interestTypeID, err := s.repo.GetInterestTypeID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, month := range pending {
+		ok, err := s.repo.PostInterest(ctx, month, houseAccountID, interestTypeID)
+		if err != nil {
+			log.Printf("Posting interest for account %s, %s failed: %v", month.AccountID, month.PeriodStart.Format("2006-01"), err)
+			continue
+		}
+		if ok {
+			posted++
+		}
+	}
+	return posted, nil
+}
+
+// GetAccruedInterest shows the interest accrued but not yet posted, and what
+// has been posted so far this year.
+func (s *InterestService) GetAccruedInterest(ctx context.Context, accountID uuid.UUID, authUserID string, authUserRole string) (models.AccruedInterest, error) {
+	holderID, err := s.repo.GetAccountHolder(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return models.AccruedInterest{}, ErrAccountNotFound
+	}
+	if err != nil {
+		return models.AccruedInterest{}, err
+	}
+	if authUserRole != "admin" && authUserID != holderID.String() {
+		return models.AccruedInterest{}, ErrInterestAccessDenied
+	}
+
+	accruals, err := s.repo.GetUnpostedAccruals(ctx, accountID)
+	if err != nil {
+		return models.AccruedInterest{}, err
+	}
+	now := time.Now()
+	postings, err := s.repo.GetPostings(ctx, accountID, time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return models.AccruedInterest{}, err
+	}
+
+	summary := models.AccruedInterest{
+		AccountID:       accountID,
+		AccruedUnposted: decimal.Zero,
+		PostedThisYear:  decimal.Zero,
+		Accruals:        accruals,
+		Postings:        postings,
+	}
+	for _, accrual := range accruals {
+		summary.AccruedUnposted = summary.AccruedUnposted.Add(accrual.Amount)
+	}
+	if len(accruals) > 0 {
+		summary.LastAccrualDate = &accruals[len(accruals)-1].AccrualDate
+	}
+	for _, posting := range postings {
+		summary.PostedThisYear = summary.PostedThisYear.Add(posting.Amount)
+	}
+	summary.AccruedUnposted = summary.AccruedUnposted.Round(2)
+	return summary, nil
+}
+
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
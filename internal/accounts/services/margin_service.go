@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"thyra/internal/accounts/models"
+	repository "thyra/internal/accounts/repositories"
+	"thyra/internal/common/mailer"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultMarginCallDeadline is how long a client has to cover a margin call
+// before holdings are sold.
+const DefaultMarginCallDeadline = 48 * time.Hour
+
+var (
+	ErrMarginAccessDenied = errors.New("not allowed to manage margin")
+	ErrInvalidLoanToValue = errors.New("loan_to_value must be at least 0 and below 1")
+	ErrAssetTypeNotFound  = errors.New("asset type not found")
+	ErrInvalidCallStatus  = errors.New("status must be one of open, met, liquidating or liquidated")
+)
+
+// PositionLiquidator sells part of a holding at the current price.
+type PositionLiquidator interface {
+	LiquidatePosition(accountID, assetID uuid.UUID, quantity decimal.Decimal, requestedBy uuid.UUID, reason string) (uuid.UUID, error)
+}
+
+type MarginService struct {
+	repo       *repository.MarginRepository
+	liquidator PositionLiquidator
+	mailer     mailer.Mailer
+	deadline   time.Duration
+}
+
+func NewMarginService(repo *repository.MarginRepository, liquidator PositionLiquidator, mailer mailer.Mailer, deadline time.Duration) *MarginService {
+	return &MarginService{repo: repo, liquidator: liquidator, mailer: mailer, deadline: deadline}
+}
+
+// NewMarginServiceFromEnv reads the margin call deadline in hours from
+// MARGIN_CALL_DEADLINE_HOURS.
+func NewMarginServiceFromEnv(repo *repository.MarginRepository, liquidator PositionLiquidator, mailer mailer.Mailer) *MarginService {
+	deadline := DefaultMarginCallDeadline
+	if value := os.Getenv("MARGIN_CALL_DEADLINE_HOURS"); value != "" {
+		if hours, err := strconv.Atoi(value); err == nil && hours > 0 {
+			deadline = time.Duration(hours) * time.Hour
+		} else {
+			log.Printf("Ignoring invalid MARGIN_CALL_DEADLINE_HOURS %q", value)
+		}
+	}
+	return NewMarginService(repo, liquidator, mailer, deadline)
+}
+
+func (s *MarginService) GetHaircuts(ctx context.Context) ([]models.MarginHaircut, error) {
+	return s.repo.GetHaircuts(ctx)
+}
+
+func (s *MarginService) SetHaircut(ctx context.Context, assetTypeID, authUserID uuid.UUID, authUserRole string, req models.MarginHaircutRequest) error {
+	if authUserRole != "admin" {
+		return ErrMarginAccessDenied
+	}
+	if req.LoanToValue.IsNegative() || req.LoanToValue.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return ErrInvalidLoanToValue
+	}
+	exists, err := s.repo.AssetTypeExists(ctx, assetTypeID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrAssetTypeNotFound
+	}
+	return s.repo.SetHaircut(ctx, assetTypeID, req.LoanToValue, authUserID)
+}
+
+func (s *MarginService) SetMarginEnabled(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string, req models.MarginSettingsRequest) error {
+	if authUserRole != "admin" {
+		return ErrMarginAccessDenied
+	}
+	found, err := s.repo.SetMarginEnabled(ctx, accountID, req.Enabled, authUserID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+func (s *MarginService) GetMarginPosition(ctx context.Context, accountID uuid.UUID, authUserID string, authUserRole string) (models.MarginPosition, error) {
+	if err := s.checkAccountAccess(ctx, accountID, authUserID, authUserRole); err != nil {
+		return models.MarginPosition{}, err
+	}
+	return s.repo.GetMarginPosition(ctx, accountID)
+}
+
+func (s *MarginService) GetAccountCalls(ctx context.Context, accountID uuid.UUID, authUserID string, authUserRole string) ([]models.MarginCall, error) {
+	if err := s.checkAccountAccess(ctx, accountID, authUserID, authUserRole); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCalls(ctx, &accountID, "")
+}
+
+func (s *MarginService) GetCalls(ctx context.Context, authUserRole string, status string) ([]models.MarginCall, error) {
+	if authUserRole != "admin" {
+		return nil, ErrMarginAccessDenied
+	}
+	switch status {
+	case "", models.MarginCallOpen, models.MarginCallMet, models.MarginCallLiquidating, models.MarginCallLiquidated:
+	default:
+		return nil, ErrInvalidCallStatus
+	}
+	return s.repo.GetCalls(ctx, nil, status)
+}
+
+// RunMarginCheck revalues every margin account. A deficit opens a margin
+// call and notifies the client; a call that is covered is closed as met, and
+// one still in deficit after its deadline has holdings sold to cover it.
+func (s *MarginService) RunMarginCheck(ctx context.Context, now time.Time) (models.MarginRunResult, error) {
+	var result models.MarginRunResult
+	accountIDs, err := s.repo.GetMarginAccounts(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	for _, accountID := range accountIDs {
+		result.AccountsChecked++
+		if err := s.checkAccount(ctx, accountID, now, &result); err != nil {
+			log.Printf("Margin check for account %s failed: %v", accountID, err)
+		}
+	}
+	return result, nil
+}
+
+func (s *MarginService) checkAccount(ctx context.Context, accountID uuid.UUID, now time.Time, result *models.MarginRunResult) error {
+	position, err := s.repo.GetMarginPosition(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	call, err := s.repo.GetActiveCall(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if call == nil {
+		if !position.Deficit.IsPositive() {
+			return nil
+		}
+		call = &models.MarginCall{
+			AccountID:    accountID,
+			MarginUsed:   position.MarginUsed,
+			LendingValue: position.LendingValue,
+			Deficit:      position.Deficit,
+			Deadline:     now.Add(s.deadline),
+		}
+		opened, err := s.repo.OpenCall(ctx, call)
+		if err != nil || !opened {
+			return err
+		}
+		result.CallsOpened++
+		s.notify(ctx, accountID, "Margin call on your account",
+			fmt.Sprintf("Your borrowing of %s exceeds the lending value of your holdings, %s, by %s.\n\nPlease deposit cash or sell holdings to cover %s before %s. If the deficit is not covered by then, holdings will be sold to cover it.\n",
+				position.MarginUsed.StringFixed(2), position.LendingValue.StringFixed(2), position.Deficit.StringFixed(2),
+				position.Deficit.StringFixed(2), call.Deadline.Format("2006-01-02 15:04 MST")))
+		return nil
+	}
+
+	if !position.Deficit.IsPositive() {
+		status := models.MarginCallMet
+		if call.Status == models.MarginCallLiquidating {
+			status = models.MarginCallLiquidated
+		}
+		if err := s.repo.ResolveCall(ctx, call.ID, status, now); err != nil {
+			return err
+		}
+		result.CallsMet++
+		if status == models.MarginCallMet {
+			s.notify(ctx, accountID, "Margin call covered", "The margin call on your account has been covered. No further action is needed.\n")
+		}
+		return nil
+	}
+
+	if err := s.repo.UpdateCallPosition(ctx, call.ID, position.MarginUsed, position.LendingValue, position.Deficit); err != nil {
+		return err
+	}
+	if now.Before(call.Deadline) {
+		return nil
+	}
+	// Forced sales settle over the coming days; only sell more once the
+	// earlier ones are reflected in the position.
+	if call.Status == models.MarginCallLiquidating {
+		pending, err := s.repo.HasOpenLiquidationOrders(ctx, *call)
+		if err != nil || pending {
+			return err
+		}
+	}
+
+	orderIDs, err := s.liquidate(accountID, position)
+	if len(orderIDs) > 0 {
+		if startErr := s.repo.StartLiquidation(ctx, call.ID, orderIDs); startErr != nil {
+			return startErr
+		}
+		result.Liquidations++
+		s.notify(ctx, accountID, "Holdings sold to cover margin call",
+			fmt.Sprintf("The margin call on your account was not covered by %s. %d sell orders have been placed to cover the deficit of %s.\n",
+				call.Deadline.Format("2006-01-02 15:04 MST"), len(orderIDs), position.Deficit.StringFixed(2)))
+	}
+	return err
+}
+
+// liquidate sells enough to cover the deficit, starting with the holdings
+// that lend the least: selling a unit worth v with loan-to-value l reduces the
+// deficit by v·(1-l).
+func (s *MarginService) liquidate(accountID uuid.UUID, position models.MarginPosition) ([]uuid.UUID, error) {
+	if s.liquidator == nil {
+		return nil, errors.New("no liquidator configured")
+	}
+
+	holdings := make([]models.CollateralPosition, 0, len(position.Positions))
+	for _, holding := range position.Positions {
+		if holding.Price.IsPositive() && holding.LoanToValue.LessThan(decimal.NewFromInt(1)) {
+			holdings = append(holdings, holding)
+		}
+	}
+	sort.SliceStable(holdings, func(i, j int) bool {
+		if !holdings[i].LoanToValue.Equal(holdings[j].LoanToValue) {
+			return holdings[i].LoanToValue.LessThan(holdings[j].LoanToValue)
+		}
+		return holdings[i].MarketValue.GreaterThan(holdings[j].MarketValue)
+	})
+
+	remaining := position.Deficit
+	orderIDs := []uuid.UUID{}
+	for _, holding := range holdings {
+		if !remaining.IsPositive() {
+			break
+		}
+		// Sell enough to cover the deficit in the smallest unit the
+		// instrument trades in, but never what is already reserved
+		relief := holding.Price.Mul(decimal.NewFromInt(1).Sub(holding.LoanToValue))
+		quantity := remaining.Div(relief).RoundCeil(holding.QuantityPrecision)
+		if quantity.GreaterThan(holding.AvailableQuantity) {
+			quantity = holding.AvailableQuantity
+		}
+		if !quantity.IsPositive() {
+			continue
+		}
+
+		orderID, err := s.liquidator.LiquidatePosition(accountID, holding.AssetID, quantity, uuid.Nil, "Forced sale to cover margin call")
+		if err != nil {
+			return orderIDs, err
+		}
+		orderIDs = append(orderIDs, orderID)
+		remaining = remaining.Sub(relief.Mul(quantity))
+	}
+	return orderIDs, nil
+}
+
+// notify emails the account holder. Failures are logged; the call itself is
+// already recorded and visible through the API.
+func (s *MarginService) notify(ctx context.Context, accountID uuid.UUID, subject, body string) {
+	if s.mailer == nil {
+		return
+	}
+	contact, err := s.repo.GetContact(ctx, accountID)
+	if err != nil {
+		log.Printf("Error loading contact for margin notification on account %s: %v", accountID, err)
+		return
+	}
+	if contact.Email == nil || *contact.Email == "" {
+		log.Printf("No email address for margin notification on account %s", accountID)
+		return
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      *contact.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hi %s,\n\nAccount %s:\n\n%s", contact.Username, contact.AccountNumber, body),
+	})
+	if err != nil {
+		log.Printf("Error sending margin notification for account %s: %v", accountID, err)
+	}
+}
+
+func (s *MarginService) checkAccountAccess(ctx context.Context, accountID uuid.UUID, authUserID string, authUserRole string) error {
+	holderID, err := s.repo.GetAccountHolder(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if authUserRole != "admin" && authUserID != holderID.String() {
+		return ErrMarginAccessDenied
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"thyra/internal/accounts/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	OperationBuy         = "buy"
+	OperationSell        = "sell"
+	OperationDeposit     = "deposit"
+	OperationWithdrawal  = "withdrawal"
+	OperationTransferIn  = "transfer_in"
+	OperationTransferOut = "transfer_out"
+)
+
+// allowedOperations lists what each status permits. A pending account can be
+// funded before it is opened; a closing account can only be wound down.
+var allowedOperations = map[string]map[string]bool{
+	models.AccountStatusPending: {OperationDeposit: true, OperationTransferIn: true},
+	models.AccountStatusActive: {OperationBuy: true, OperationSell: true, OperationDeposit: true, OperationWithdrawal: true,
+		OperationTransferIn: true, OperationTransferOut: true},
+	models.AccountStatusFrozen:  {},
+	models.AccountStatusClosing: {OperationSell: true, OperationWithdrawal: true, OperationTransferOut: true},
+	models.AccountStatusClosed:  {},
+}
+
+// AccountStatusError is returned when the account's status does not permit
+// the operation.
+type AccountStatusError struct {
+	Status    string
+	Operation string
+}
+
+func (e *AccountStatusError) Error() string {
+	return fmt.Sprintf("%s is not allowed on a %s account", e.Operation, e.Status)
+}
+
+// CheckAccountAllows fails with an *AccountStatusError unless the account's
+// status permits the operation. Run it on the transaction that performs the
+// operation so a concurrent freeze is not missed.
+func CheckAccountAllows(q sqlx.Queryer, accountID uuid.UUID, operation string) error {
+	var status string
+	err := q.QueryRowx(`SELECT account_status FROM thyrasec.accounts WHERE id = $1`, accountID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("account %s not found", accountID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !allowedOperations[status][operation] {
+		return &AccountStatusError{Status: status, Operation: operation}
+	}
+	return nil
+}
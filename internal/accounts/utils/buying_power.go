@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"thyra/internal/accounts/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+var ErrInsufficientBuyingPower = errors.New("insufficient buying power")
+
+// GetBuyingPower calculates the account's buying power from its cash,
+// reservations, overdraft limit and unsettled sales.
+func GetBuyingPower(q sqlx.Queryer, accountID uuid.UUID) (models.BuyingPower, error) {
+	return loadBuyingPower(q, accountID, false)
+}
+
+// CheckCashAvailable fails with ErrInsufficientBuyingPower unless the account
+// can cover amount for the operation: buys are checked against buying power,
+// withdrawals against withdrawable cash and transfers out against the cash
+// on the account, since the overdraft must not fund another account. Run it
+// on the transaction that moves the cash; the account row stays locked until
+// that transaction ends.
+func CheckCashAvailable(q sqlx.Queryer, accountID uuid.UUID, amount decimal.Decimal, operation string) error {
+	power, err := loadBuyingPower(q, accountID, true)
+	if err != nil {
+		return err
+	}
+
+	limit := power.BuyingPower
+	switch operation {
+	case OperationWithdrawal:
+		limit = power.WithdrawableCash
+	case OperationTransferOut:
+		limit = decimal.Max(power.AvailableCash, decimal.Zero)
+	}
+	if amount.GreaterThan(limit) {
+		return fmt.Errorf("%w: %s %s requested, %s available", ErrInsufficientBuyingPower,
+			amount.StringFixed(2), power.Currency, limit.StringFixed(2))
+	}
+	return nil
+}
+
+// pendingSaleProceedsQuery sums sales not yet settled. orders.order_type is
+// text, so the order type id is cast to compare.
+const pendingSaleProceedsQuery = `
+        SELECT COALESCE(SUM(o.total_amount), 0)
+        FROM thyrasec.orders o
+        JOIN thyrasec.order_types ot ON ot.id::text = o.order_type
+        WHERE o.account_id = $1
+            AND ot.order_type_name = 'order_type_sell'
+            AND o.status IN ('confirmed', 'executed')`
+
+func loadBuyingPower(q sqlx.Queryer, accountID uuid.UUID, lock bool) (models.BuyingPower, error) {
+	query := `
+        SELECT id, account_currency, account_balance, reserved_cash, available_cash,
+            COALESCE(overdraft_limit, 0) AS overdraft_limit, margin_enabled
+        FROM thyrasec.accounts
+        WHERE id = $1`
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	var power models.BuyingPower
+	err := sqlx.Get(q, &power, query, accountID)
+	if err == sql.ErrNoRows {
+		return power, fmt.Errorf("account %s not found", accountID)
+	}
+	if err != nil {
+		return power, err
+	}
+
+	err = sqlx.Get(q, &power.PendingSaleProceeds, pendingSaleProceedsQuery, accountID)
+	if err != nil {
+		return power, err
+	}
+
+	cashAndCredit := power.AvailableCash.Add(power.OverdraftLimit)
+	power.WithdrawableCash = decimal.Max(cashAndCredit, decimal.Zero)
+
+	// Margin already drawn shows up as cash below the overdraft limit, so
+	// adding the full lending value leaves what can still be borrowed.
+	power.MarginLendingValue = decimal.Zero
+	power.MarginUsed = decimal.Zero
+	if power.MarginEnabled {
+		margin, err := GetMarginPosition(q, accountID)
+		if err != nil {
+			return power, err
+		}
+		power.MarginLendingValue = margin.LendingValue
+		power.MarginUsed = margin.MarginUsed
+	}
+
+	power.BuyingPower = decimal.Max(cashAndCredit.Add(power.MarginLendingValue), decimal.Zero).Add(power.PendingSaleProceeds)
+	return power, nil
+}
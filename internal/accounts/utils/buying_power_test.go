@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+
+	_ "github.com/lib/pq"
+)
+
+// testDB connects to the migrated database in TEST_DATABASE_URL, skipping
+// the test when none is configured.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("connecting to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestPendingSaleProceedsQuery(t *testing.T) {
+	db := testDB(t)
+
+	var proceeds decimal.Decimal
+	if err := db.Get(&proceeds, pendingSaleProceedsQuery, uuid.New()); err != nil {
+		t.Fatalf("pending sale proceeds query failed: %v", err)
+	}
+	if !proceeds.IsZero() {
+		t.Errorf("proceeds for unknown account = %s, want 0", proceeds)
+	}
+}
+
+func TestCheckCashAvailableUnknownAccount(t *testing.T) {
+	db := testDB(t)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	if err := CheckCashAvailable(tx, uuid.New(), decimal.NewFromInt(1), OperationBuy); err == nil {
+		t.Error("CheckCashAvailable on unknown account succeeded, want error")
+	}
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DayCountACT360 = "ACT/360"
+	DayCountACT365 = "ACT/365"
+)
+
+// ParseDayCount validates a day-count convention name.
+func ParseDayCount(convention string) (string, error) {
+	switch convention {
+	case DayCountACT360, DayCountACT365:
+		return convention, nil
+	default:
+		return "", fmt.Errorf("unsupported day-count convention %q, use %s or %s", convention, DayCountACT360, DayCountACT365)
+	}
+}
+
+// DailyInterest is the interest for one calendar day on balance at an annual
+// rate given in percent. Under ACT/365 the year is always 365 days, leap
+// years included.
+func DailyInterest(balance decimal.Decimal, annualRatePercent float64, convention string) decimal.Decimal {
+	yearDays := int64(365)
+	if convention == DayCountACT360 {
+		yearDays = 360
+	}
+	return balance.
+		Mul(decimal.NewFromFloat(annualRatePercent)).
+		Div(decimal.NewFromInt(100 * yearDays)).
+		Round(6)
+}
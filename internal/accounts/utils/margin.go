@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"thyra/internal/accounts/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// pendingPurchaseLendingQuery sums the lending value of buys not yet settled.
+// orders.order_type is text, so the order type id is cast to compare.
+const pendingPurchaseLendingQuery = `
+        SELECT COALESCE(SUM(o.total_amount * COALESCE(mh.loan_to_value, 0)), 0)
+        FROM thyrasec.orders o
+        JOIN thyrasec.order_types ot ON ot.id::text = o.order_type
+        JOIN thyrasec.assets a ON a.id = o.asset_id
+        LEFT JOIN thyrasec.margin_haircuts mh ON mh.asset_type_id = a.asset_type_id
+        WHERE o.account_id = $1
+            AND ot.order_type_name = 'order_type_buy'
+            AND o.status IN ('created', 'confirmed', 'executed')`
+
+// GetMarginPosition values the account's collateral with the configured
+// haircuts and compares it with the margin in use.
+func GetMarginPosition(q sqlx.Queryer, accountID uuid.UUID) (models.MarginPosition, error) {
+	position := models.MarginPosition{AccountID: accountID, Positions: []models.CollateralPosition{}}
+
+	var account struct {
+		MarginEnabled  bool            `db:"margin_enabled"`
+		AvailableCash  decimal.Decimal `db:"available_cash"`
+		OverdraftLimit decimal.Decimal `db:"overdraft_limit"`
+	}
+	err := sqlx.Get(q, &account, `
+        SELECT margin_enabled, available_cash, COALESCE(overdraft_limit, 0) AS overdraft_limit
+        FROM thyrasec.accounts
+        WHERE id = $1`, accountID)
+	if err != nil {
+		return position, fmt.Errorf("error loading account %s: %w", accountID, err)
+	}
+	position.MarginEnabled = account.MarginEnabled
+
+	err = sqlx.Select(q, &position.Positions, `
+        SELECT h.asset_id, a.asset_type_id, h.quantity, h.available_quantity, a.quantity_precision,
+            COALESCE(a.current_price, 0) AS current_price,
+            COALESCE(mh.loan_to_value, 0) AS loan_to_value
+        FROM thyrasec.holdings h
+        JOIN thyrasec.assets a ON a.id = h.asset_id
+        LEFT JOIN thyrasec.margin_haircuts mh ON mh.asset_type_id = a.asset_type_id
+        WHERE h.account_id = $1 AND h.quantity > 0
+        ORDER BY h.asset_id`, accountID)
+	if err != nil {
+		return position, err
+	}
+
+	err = sqlx.Get(q, &position.PendingPurchaseLending, pendingPurchaseLendingQuery, accountID)
+	if err != nil {
+		return position, err
+	}
+	position.PendingPurchaseLending = position.PendingPurchaseLending.Round(2)
+
+	position.CollateralValue = decimal.Zero
+	position.LendingValue = position.PendingPurchaseLending
+	for i := range position.Positions {
+		p := &position.Positions[i]
+		p.MarketValue = p.Quantity.Mul(p.Price).Round(2)
+		p.LendingValue = p.MarketValue.Mul(p.LoanToValue).Round(2)
+		position.CollateralValue = position.CollateralValue.Add(p.MarketValue)
+		position.LendingValue = position.LendingValue.Add(p.LendingValue)
+	}
+	if !position.MarginEnabled {
+		position.LendingValue = decimal.Zero
+	}
+
+	position.MarginUsed = account.AvailableCash.Add(account.OverdraftLimit).Neg()
+	if position.MarginUsed.IsNegative() {
+		position.MarginUsed = decimal.Zero
+	}
+	position.Deficit = position.MarginUsed.Sub(position.LendingValue)
+	if position.Deficit.IsNegative() {
+		position.Deficit = decimal.Zero
+	}
+	return position, nil
+}
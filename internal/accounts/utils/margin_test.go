@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestPendingPurchaseLendingQuery(t *testing.T) {
+	db := testDB(t)
+
+	var lending decimal.Decimal
+	if err := db.Get(&lending, pendingPurchaseLendingQuery, uuid.New()); err != nil {
+		t.Fatalf("pending purchase lending query failed: %v", err)
+	}
+	if !lending.IsZero() {
+		t.Errorf("lending for unknown account = %s, want 0", lending)
+	}
+}
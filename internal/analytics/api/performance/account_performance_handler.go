@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"net/http"
-	services "thyra/internal/analytics/services/performance" // Replace with your actual package path
+	services "thyra/internal/analytics/services/performance"
+	userutils "thyra/internal/users/utils"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+const dateLayout = "2006-01-02"
+
 type AccountPerformanceHandler struct {
 	accountService *services.AccountPerformanceService
 }
@@ -19,71 +22,113 @@ func NewAccountPerformanceHandler(accountService *services.AccountPerformanceSer
 	}
 }
 
+// GetAccountPerformanceChange returns the account's returns for the standard
+// periods ending at ?asOf= (default today), plus the ?startDate=&endDate=
+// range when given.
 func (h *AccountPerformanceHandler) GetAccountPerformanceChange(c *gin.Context) {
-	// Extract account ID from request
-	accountIDStr := c.Param("accountId")
-	accountID, err := uuid.Parse(accountIDStr)
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	accountID, err := uuid.Parse(c.Param("accountId"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID format"})
 		return
 	}
+	asOf, custom, ok := performanceDates(c)
+	if !ok {
+		return
+	}
 
-	// Parse startDate and endDate from string to time.Time
-	layout := "2006-01-02" // This is the Go layout for date format, adjust if your format is different
-	startDateStr := c.Query("startDate")
-	endDateStr := c.Query("endDate")
-
-	startDate, err := time.Parse(layout, startDateStr)
+	report, err := h.accountService.GetAccountPerformance(c.Request.Context(), accountID, authUserID, authUserRole, asOf, custom)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+		writePerformanceError(c, err)
 		return
 	}
 
-	endDate, err := time.Parse(layout, endDateStr)
+	c.JSON(http.StatusOK, report)
+}
+
+// GetUserPerformanceChange returns the combined returns of all of the user's accounts.
+func (h *AccountPerformanceHandler) GetUserPerformanceChange(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	asOf, custom, ok := performanceDates(c)
+	if !ok {
 		return
 	}
 
-	// Now call the service with parsed time.Time values
-	valueChange, err := h.accountService.GetAccountValueChange(c, accountID, startDate, endDate)
+	report, err := h.accountService.GetUserPerformance(c.Request.Context(), userID, authUserID, authUserRole, asOf, custom)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writePerformanceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, valueChange)
+	c.JSON(http.StatusOK, report)
 }
 
-func (h *AccountPerformanceHandler) GetUserPerformanceChange(c *gin.Context) {
-	userIdStr := c.Param("userId")
-	userID, err := uuid.Parse(userIdStr)
+func performanceUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
-		return
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
 	}
+	return authUserID, userRole, true
+}
 
-	// Parse startDate and endDate from string to time.Time
-	layout := "2006-01-02" // This is the Go layout for date format, adjust if your format is different
-	startDateStr := c.Query("startDate")
-	endDateStr := c.Query("endDate")
+func performanceDates(c *gin.Context) (time.Time, *services.Period, bool) {
+	now := time.Now()
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("asOf"); value != "" {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf date format"})
+			return time.Time{}, nil, false
+		}
+		asOf = parsed
+	}
 
-	startDate, err := time.Parse(layout, startDateStr)
+	startDateStr, endDateStr := c.Query("startDate"), c.Query("endDate")
+	if startDateStr == "" && endDateStr == "" {
+		return asOf, nil, true
+	}
+	startDate, err := time.Parse(dateLayout, startDateStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
-		return
+		return time.Time{}, nil, false
 	}
-
-	endDate, err := time.Parse(layout, endDateStr)
+	endDate, err := time.Parse(dateLayout, endDateStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
-		return
+		return time.Time{}, nil, false
 	}
-	valueChange, err := h.accountService.GetUserValueChange(c, userID, startDate, endDate)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if endDate.After(asOf) {
+		asOf = endDate
 	}
+	return asOf, &services.Period{Start: startDate, End: endDate}, true
+}
 
-	c.JSON(http.StatusOK, valueChange)
+func writePerformanceError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrPerformanceAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrNoValuations:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidPeriod:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate performance", "details": err.Error()})
+	}
 }
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"thyra/internal/analytics/models"
+	services "thyra/internal/analytics/services/performance"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AllocationHandler struct {
+	service *services.AllocationService
+}
+
+func NewAllocationHandler(service *services.AllocationService) *AllocationHandler {
+	return &AllocationHandler{service: service}
+}
+
+// GetAccountAllocation breaks the account's market value down in ?currency=
+// (default the account currency), looking through funds when
+// ?lookThrough=true.
+func (h *AllocationHandler) GetAccountAllocation(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID format"})
+		return
+	}
+	lookThrough, ok := lookThroughQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetAccountAllocation(c.Request.Context(), accountID, authUserID, authUserRole, c.Query("currency"), lookThrough)
+	if err != nil {
+		writeAllocationError(c, err, "Failed to calculate allocation")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetUserAllocation breaks down all of the user's open accounts together.
+func (h *AllocationHandler) GetUserAllocation(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	lookThrough, ok := lookThroughQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetUserAllocation(c.Request.Context(), userID, authUserID, authUserRole, c.Query("currency"), lookThrough)
+	if err != nil {
+		writeAllocationError(c, err, "Failed to calculate allocation")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *AllocationHandler) GetFundConstituents(c *gin.Context) {
+	if _, _, ok := performanceUser(c); !ok {
+		return
+	}
+	fundAssetID, err := uuid.Parse(c.Param("assetId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset ID format"})
+		return
+	}
+
+	constituents, err := h.service.GetFundConstituents(c.Request.Context(), fundAssetID)
+	if err != nil {
+		writeAllocationError(c, err, "Failed to fetch fund constituents")
+		return
+	}
+
+	c.JSON(http.StatusOK, constituents)
+}
+
+func (h *AllocationHandler) SetFundConstituents(c *gin.Context) {
+	_, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	fundAssetID, err := uuid.Parse(c.Param("assetId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset ID format"})
+		return
+	}
+	var req models.FundConstituentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	constituents, err := h.service.SetFundConstituents(c.Request.Context(), fundAssetID, authUserRole, req)
+	if err != nil {
+		writeAllocationError(c, err, "Failed to update fund constituents")
+		return
+	}
+
+	c.JSON(http.StatusOK, constituents)
+}
+
+func lookThroughQuery(c *gin.Context) (bool, bool) {
+	value := c.Query("lookThrough")
+	if value == "" {
+		return false, true
+	}
+	lookThrough, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lookThrough must be true or false"})
+		return false, false
+	}
+	return lookThrough, true
+}
+
+func writeAllocationError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrMissingFxRate) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrAllocationAccessDenied, services.ErrConstituentsDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrFundNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidCurrency, services.ErrInvalidConstituents:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
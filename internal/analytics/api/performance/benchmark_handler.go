@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"thyra/internal/analytics/models"
+	services "thyra/internal/analytics/services/performance"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BenchmarkHandler struct {
+	service *services.BenchmarkService
+}
+
+func NewBenchmarkHandler(service *services.BenchmarkService) *BenchmarkHandler {
+	return &BenchmarkHandler{service: service}
+}
+
+func (h *BenchmarkHandler) CreateBenchmark(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	var req models.BenchmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	benchmark, err := h.service.CreateBenchmark(c.Request.Context(), authUserID, authUserRole, req)
+	if err != nil {
+		writeBenchmarkError(c, err, "Failed to create benchmark")
+		return
+	}
+
+	c.JSON(http.StatusCreated, benchmark)
+}
+
+func (h *BenchmarkHandler) UpdateBenchmark(c *gin.Context) {
+	_, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	benchmarkID, ok := benchmarkIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.BenchmarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	benchmark, err := h.service.UpdateBenchmark(c.Request.Context(), benchmarkID, authUserRole, req)
+	if err != nil {
+		writeBenchmarkError(c, err, "Failed to update benchmark")
+		return
+	}
+
+	c.JSON(http.StatusOK, benchmark)
+}
+
+func (h *BenchmarkHandler) GetBenchmarks(c *gin.Context) {
+	if _, _, ok := performanceUser(c); !ok {
+		return
+	}
+
+	benchmarks, err := h.service.GetBenchmarks(c.Request.Context())
+	if err != nil {
+		writeBenchmarkError(c, err, "Failed to fetch benchmarks")
+		return
+	}
+
+	c.JSON(http.StatusOK, benchmarks)
+}
+
+func (h *BenchmarkHandler) GetBenchmark(c *gin.Context) {
+	if _, _, ok := performanceUser(c); !ok {
+		return
+	}
+	benchmarkID, ok := benchmarkIDParam(c)
+	if !ok {
+		return
+	}
+
+	benchmark, err := h.service.GetBenchmark(c.Request.Context(), benchmarkID)
+	if err != nil {
+		writeBenchmarkError(c, err, "Failed to fetch benchmark")
+		return
+	}
+
+	c.JSON(http.StatusOK, benchmark)
+}
+
+// GetBenchmarkReturns returns the benchmark's cumulative returns between
+// ?startDate= and ?endDate=.
+func (h *BenchmarkHandler) GetBenchmarkReturns(c *gin.Context) {
+	if _, _, ok := performanceUser(c); !ok {
+		return
+	}
+	benchmarkID, ok := benchmarkIDParam(c)
+	if !ok {
+		return
+	}
+	startDate, endDate, ok := dateRange(c)
+	if !ok {
+		return
+	}
+
+	returns, err := h.service.GetBenchmarkReturns(c.Request.Context(), benchmarkID, startDate, endDate)
+	if err != nil {
+		writeBenchmarkError(c, err, "Failed to calculate benchmark returns")
+		return
+	}
+
+	c.JSON(http.StatusOK, returns)
+}
+
+// GetAccountComparison compares the account with ?benchmarkId= between
+// ?startDate= and ?endDate=.
+func (h *BenchmarkHandler) GetAccountComparison(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID format"})
+		return
+	}
+	benchmarkID, startDate, endDate, ok := comparisonQuery(c)
+	if !ok {
+		return
+	}
+
+	comparison, err := h.service.CompareAccount(c.Request.Context(), accountID, benchmarkID, authUserID, authUserRole, startDate, endDate)
+	if err != nil {
+		writeBenchmarkError(c, err, "Failed to compare with benchmark")
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+func (h *BenchmarkHandler) GetUserComparison(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	benchmarkID, startDate, endDate, ok := comparisonQuery(c)
+	if !ok {
+		return
+	}
+
+	comparison, err := h.service.CompareUser(c.Request.Context(), userID, benchmarkID, authUserID, authUserRole, startDate, endDate)
+	if err != nil {
+		writeBenchmarkError(c, err, "Failed to compare with benchmark")
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+func benchmarkIDParam(c *gin.Context) (uuid.UUID, bool) {
+	benchmarkID, err := uuid.Parse(c.Param("benchmarkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid benchmark ID in URL"})
+		return uuid.Nil, false
+	}
+	return benchmarkID, true
+}
+
+func comparisonQuery(c *gin.Context) (uuid.UUID, time.Time, time.Time, bool) {
+	benchmarkID, err := uuid.Parse(c.Query("benchmarkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or missing benchmarkId"})
+		return uuid.Nil, time.Time{}, time.Time{}, false
+	}
+	startDate, endDate, ok := dateRange(c)
+	return benchmarkID, startDate, endDate, ok
+}
+
+func dateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	startDate, err := time.Parse(dateLayout, c.Query("startDate"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+		return time.Time{}, time.Time{}, false
+	}
+	endDate, err := time.Parse(dateLayout, c.Query("endDate"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+		return time.Time{}, time.Time{}, false
+	}
+	return startDate, endDate, true
+}
+
+func writeBenchmarkError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrNoBenchmarkPrices) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrBenchmarkAccessDenied, services.ErrPerformanceAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrBenchmarkNotFound, services.ErrBenchmarkAssetNotFound, services.ErrAccountNotFound, services.ErrNoValuations:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrBenchmarkNameRequired, services.ErrInvalidBenchmark, services.ErrInvalidWeights,
+		services.ErrInvalidRebalanceFrequency, services.ErrInvalidPeriod:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrBenchmarkNameTaken:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
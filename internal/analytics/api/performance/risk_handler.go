@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	services "thyra/internal/analytics/services/performance"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RiskHandler struct {
+	service *services.RiskService
+}
+
+func NewRiskHandler(service *services.RiskService) *RiskHandler {
+	return &RiskHandler{service: service}
+}
+
+// GetAccountRisk returns the account's risk figures between ?startDate= and
+// ?endDate= (default the year up to today), with beta against ?benchmarkId=
+// when given.
+func (h *RiskHandler) GetAccountRisk(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID format"})
+		return
+	}
+	startDate, endDate, benchmarkID, ok := riskQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetAccountRisk(c.Request.Context(), accountID, authUserID, authUserRole, startDate, endDate, benchmarkID)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetUserRisk returns the risk figures of all of the user's accounts together.
+func (h *RiskHandler) GetUserRisk(c *gin.Context) {
+	authUserID, authUserRole, ok := performanceUser(c)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		return
+	}
+	startDate, endDate, benchmarkID, ok := riskQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetUserRisk(c.Request.Context(), userID, authUserID, authUserRole, startDate, endDate, benchmarkID)
+	if err != nil {
+		writeRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func riskQuery(c *gin.Context) (time.Time, time.Time, *uuid.UUID, bool) {
+	now := time.Now()
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("endDate"); value != "" {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end date format"})
+			return time.Time{}, time.Time{}, nil, false
+		}
+		endDate = parsed
+	}
+	startDate := endDate.AddDate(-1, 0, 1)
+	if value := c.Query("startDate"); value != "" {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date format"})
+			return time.Time{}, time.Time{}, nil, false
+		}
+		startDate = parsed
+	}
+
+	var benchmarkID *uuid.UUID
+	if value := c.Query("benchmarkId"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid benchmarkId"})
+			return time.Time{}, time.Time{}, nil, false
+		}
+		benchmarkID = &parsed
+	}
+	return startDate, endDate, benchmarkID, true
+}
+
+func writeRiskError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrNoBenchmarkPrices) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrPerformanceAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrNoValuations, services.ErrBenchmarkNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidPeriod:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrInsufficientHistory:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate risk", "details": err.Error()})
+	}
+}
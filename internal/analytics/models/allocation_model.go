@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	AllocationBySector    = "sector"
+	AllocationByCountry   = "country"
+	AllocationByCurrency  = "currency"
+	AllocationByAssetType = "asset_type"
+
+	AllocationCash         = "Cash"
+	AllocationUnclassified = "Unclassified"
+)
+
+type AllocationAccount struct {
+	ID             uuid.UUID
+	HolderID       uuid.UUID
+	Currency       string
+	AccountBalance decimal.Decimal
+}
+
+// Classification is what an asset is grouped by. Empty fields are reported
+// as unclassified.
+type Classification struct {
+	Sector    string
+	Country   string
+	Currency  string
+	AssetType string
+}
+
+// AllocationPosition is a holding priced at the latest price, in the asset's
+// currency.
+type AllocationPosition struct {
+	AssetID  uuid.UUID
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+	Classification
+}
+
+type FundConstituent struct {
+	AssetID uuid.UUID `json:"asset_id" binding:"required"`
+	Weight  float64   `json:"weight" binding:"required"`
+}
+
+type FundConstituentsRequest struct {
+	Constituents []FundConstituent `json:"constituents"`
+}
+
+// ConstituentExposure is a constituent's share of its fund's value.
+type ConstituentExposure struct {
+	Weight float64
+	Classification
+}
+
+// Exposure is a share of the portfolio's value, in the reporting currency,
+// with the classification it is grouped by.
+type Exposure struct {
+	Value decimal.Decimal
+	Classification
+}
+
+// AllocationReport groups the portfolio's market value, cash included, in
+// the reporting currency. Weights are in percent of the total value.
+type AllocationReport struct {
+	AccountID   *uuid.UUID         `json:"account_id,omitempty"`
+	UserID      *uuid.UUID         `json:"user_id,omitempty"`
+	Currency    string             `json:"currency"`
+	AsOf        time.Time          `json:"as_of"`
+	LookThrough bool               `json:"look_through"`
+	TotalValue  decimal.Decimal    `json:"total_value"`
+	Sectors     []AllocationBucket `json:"sectors"`
+	Countries   []AllocationBucket `json:"countries"`
+	Currencies  []AllocationBucket `json:"currencies"`
+	AssetTypes  []AllocationBucket `json:"asset_types"`
+}
+
+type AllocationBucket struct {
+	Name   string          `json:"name"`
+	Value  decimal.Decimal `json:"value"`
+	Weight float64         `json:"weight"`
+}
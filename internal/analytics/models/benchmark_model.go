@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RebalanceNone      = "none"
+	RebalanceDaily     = "daily"
+	RebalanceMonthly   = "monthly"
+	RebalanceQuarterly = "quarterly"
+	RebalanceAnnually  = "annually"
+)
+
+type Benchmark struct {
+	ID                 uuid.UUID            `json:"id"`
+	Name               string               `json:"name"`
+	Description        *string              `json:"description,omitempty"`
+	RebalanceFrequency string               `json:"rebalance_frequency"`
+	Components         []BenchmarkComponent `json:"components"`
+	CreatedBy          uuid.UUID            `json:"created_by"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
+}
+
+type BenchmarkComponent struct {
+	AssetID uuid.UUID `json:"asset_id" binding:"required"`
+	Weight  float64   `json:"weight" binding:"required"`
+}
+
+// BenchmarkRequest defines a benchmark. A single instrument benchmark needs
+// only asset_id; a composite lists its components, with weights adding up
+// to 1.
+type BenchmarkRequest struct {
+	Name               string               `json:"name" binding:"required"`
+	Description        *string              `json:"description"`
+	AssetID            *uuid.UUID           `json:"asset_id"`
+	Components         []BenchmarkComponent `json:"components"`
+	RebalanceFrequency string               `json:"rebalance_frequency"`
+}
+
+type PricePoint struct {
+	Date  time.Time
+	Price float64
+}
+
+// BenchmarkReturns is the benchmark's cumulative return in percent on each
+// price date of the period.
+type BenchmarkReturns struct {
+	BenchmarkID uuid.UUID         `json:"benchmark_id"`
+	StartDate   time.Time         `json:"start_date"`
+	EndDate     time.Time         `json:"end_date"`
+	TotalReturn float64           `json:"total_return"`
+	Series      []CumulativePoint `json:"series"`
+}
+
+type CumulativePoint struct {
+	Date   time.Time `json:"date"`
+	Return float64   `json:"return"`
+}
+
+type ComparisonPoint struct {
+	Date            time.Time `json:"date"`
+	PortfolioReturn float64   `json:"portfolio_return"`
+	BenchmarkReturn float64   `json:"benchmark_return"`
+}
+
+// BenchmarkComparison sets the portfolio's time weighted return against the
+// benchmark over the same dates, in percent. Tracking error is the
+// annualized standard deviation of the per-period active returns, and the
+// information ratio the annualized mean active return divided by it; both
+// are left out with fewer than two observations.
+type BenchmarkComparison struct {
+	AccountID        *uuid.UUID        `json:"account_id,omitempty"`
+	UserID           *uuid.UUID        `json:"user_id,omitempty"`
+	BenchmarkID      uuid.UUID         `json:"benchmark_id"`
+	BenchmarkName    string            `json:"benchmark_name"`
+	StartDate        time.Time         `json:"start_date"`
+	EndDate          time.Time         `json:"end_date"`
+	PortfolioReturn  float64           `json:"portfolio_return"`
+	BenchmarkReturn  float64           `json:"benchmark_return"`
+	ActiveReturn     float64           `json:"active_return"`
+	TrackingError    *float64          `json:"tracking_error"`
+	InformationRatio *float64          `json:"information_ratio"`
+	Observations     int               `json:"observations"`
+	Series           []ComparisonPoint `json:"series"`
+}
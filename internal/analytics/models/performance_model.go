@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PeriodMTD            = "mtd"
+	PeriodQTD            = "qtd"
+	PeriodYTD            = "ytd"
+	PeriodOneYear        = "1y"
+	PeriodSinceInception = "since_inception"
+	PeriodCustom         = "custom"
+)
+
+// Valuation is the end-of-day market value of an account, or of all of a
+// user's accounts.
+type Valuation struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+// CashFlow is money or securities moved into (positive) or out of
+// (negative) an account by its holder. Trading, fees and interest are not
+// cash flows, they are part of the return.
+type CashFlow struct {
+	Date   time.Time `json:"date"`
+	Amount float64   `json:"amount"`
+}
+
+// SubPeriodReturn is the return between two consecutive valuations.
+type SubPeriodReturn struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Return float64   `json:"return"`
+}
+
+// PeriodReturn holds the returns for one period in percent. Annualized
+// returns are only given for periods of at least a year, and the money
+// weighted return is left out when no rate solves the cash flows.
+type PeriodReturn struct {
+	Period                        string    `json:"period"`
+	StartDate                     time.Time `json:"start_date"`
+	EndDate                       time.Time `json:"end_date"`
+	StartValue                    float64   `json:"start_value"`
+	EndValue                      float64   `json:"end_value"`
+	NetCashFlow                   float64   `json:"net_cash_flow"`
+	Gain                          float64   `json:"gain"`
+	TimeWeightedReturn            float64   `json:"time_weighted_return"`
+	AnnualizedTimeWeightedReturn  *float64  `json:"annualized_time_weighted_return"`
+	MoneyWeightedReturn           *float64  `json:"money_weighted_return"`
+	AnnualizedMoneyWeightedReturn *float64  `json:"annualized_money_weighted_return"`
+}
+
+type PerformanceReport struct {
+	AccountID     *uuid.UUID     `json:"account_id,omitempty"`
+	UserID        *uuid.UUID     `json:"user_id,omitempty"`
+	AsOf          time.Time      `json:"as_of"`
+	InceptionDate time.Time      `json:"inception_date"`
+	Periods       []PeriodReturn `json:"periods"`
+}
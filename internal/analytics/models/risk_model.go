@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	VaRMethodHistorical = "historical"
+	VaRMethodParametric = "parametric"
+)
+
+// RiskReport describes the risk of a portfolio over a period, measured on
+// the returns between consecutive daily valuations. Returns, volatility,
+// drawdown and value at risk are in percent; volatility is annualized.
+type RiskReport struct {
+	AccountID    *uuid.UUID    `json:"account_id,omitempty"`
+	UserID       *uuid.UUID    `json:"user_id,omitempty"`
+	StartDate    time.Time     `json:"start_date"`
+	EndDate      time.Time     `json:"end_date"`
+	Observations int           `json:"observations"`
+	TotalReturn  float64       `json:"total_return"`
+	Volatility   float64       `json:"volatility"`
+	RiskFreeRate float64       `json:"risk_free_rate"`
+	SharpeRatio  *float64      `json:"sharpe_ratio"`
+	MaxDrawdown  Drawdown      `json:"max_drawdown"`
+	ValueAtRisk  []ValueAtRisk `json:"value_at_risk"`
+	Beta         *BetaEstimate `json:"beta,omitempty"`
+}
+
+// Drawdown is the largest fall from a peak. RecoveryDate is left out while
+// the portfolio is still below the peak.
+type Drawdown struct {
+	Drawdown     float64    `json:"drawdown"`
+	PeakDate     *time.Time `json:"peak_date,omitempty"`
+	TroughDate   *time.Time `json:"trough_date,omitempty"`
+	RecoveryDate *time.Time `json:"recovery_date,omitempty"`
+}
+
+// ValueAtRisk is the one day loss, as a positive percentage of the portfolio
+// value, that is exceeded with probability 1-confidence, and the expected
+// loss when it is (conditional value at risk).
+type ValueAtRisk struct {
+	Method                 string  `json:"method"`
+	Confidence             float64 `json:"confidence"`
+	ValueAtRisk            float64 `json:"value_at_risk"`
+	ConditionalValueAtRisk float64 `json:"conditional_value_at_risk"`
+}
+
+// BetaEstimate is the portfolio's beta against a benchmark over the same
+// sub-periods. Beta is nil if the benchmark did not move.
+type BetaEstimate struct {
+	BenchmarkID   uuid.UUID `json:"benchmark_id"`
+	BenchmarkName string    `json:"benchmark_name"`
+	Beta          *float64  `json:"beta"`
+}
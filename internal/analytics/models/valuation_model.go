@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type ValuationAccount struct {
+	ID             uuid.UUID
+	Currency       string
+	AccountBalance decimal.Decimal
+}
+
+// PricedPosition is a holding with the latest price on or before the
+// valuation date, in the asset's currency.
+type PricedPosition struct {
+	AssetID  uuid.UUID
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+	Currency string
+}
+
+type AccountValuation struct {
+	AccountID       uuid.UUID       `json:"account_id"`
+	SnapshotDate    time.Time       `json:"snapshot_date"`
+	Currency        string          `json:"currency"`
+	SecuritiesValue decimal.Decimal `json:"securities_value"`
+	CashValue       decimal.Decimal `json:"cash_value"`
+	TotalValue      decimal.Decimal `json:"total_value"`
+	CashEstimated   bool            `json:"cash_estimated"`
+}
+
+type ValuationRunResult struct {
+	Dates          int
+	AccountsValued int
+	Failures       int
+}
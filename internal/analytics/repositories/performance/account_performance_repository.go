@@ -3,8 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
-	"sort"
+	"thyra/internal/analytics/models"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,192 +19,89 @@ func NewAccountPerformanceRepository(db *sql.DB) *AccountPerformanceRepository {
 	}
 }
 
-type ValueChange struct {
-	StartValue       float64
-	EndValue         float64
-	Change           float64
-	PercentualChange float64
-	StartDate        time.Time
-	EndDate          time.Time
-	Snapshots        []SnapshotValue // Add this field to your struct
+func (r *AccountPerformanceRepository) GetAccountHolderID(ctx context.Context, accountID uuid.UUID) (uuid.UUID, error) {
+	var holderID uuid.UUID
+	err := r.db.QueryRowContext(ctx, "SELECT account_holder_id FROM thyrasec.accounts WHERE id = $1", accountID).Scan(&holderID)
+	return holderID, err
 }
 
-type SnapshotValue struct {
-	Date  time.Time
-	Value float64
-}
-
-func (r *AccountPerformanceRepository) GetAccountPerformanceChange(ctx context.Context, accountID uuid.UUID, startDate, endDate time.Time) (ValueChange, error) {
-	snapshots, err := r.fetchAccountSnapshots(ctx, accountID, startDate, endDate)
-	if err != nil {
-		return ValueChange{}, err
-	}
-	transactions, err := r.fetchTransactions(ctx, accountID, startDate, endDate)
-	if err != nil {
-		return ValueChange{}, err
-	}
-
-	totalCashFlow := calculateCashFlows(transactions)
-	// Use the first and last snapshots for start and end values
-	startValue := snapshots[0].Value
-	endValue := snapshots[len(snapshots)-1].Value
-
-	// Adjust end value for cash flows
-	adjustedEndValue := endValue - totalCashFlow
-
-	// Calculate value change using adjusted end value
-	valueChange := adjustedEndValue - startValue
-	percentualChange := 0.0
-	if startValue != 0 {
-		percentualChange = (valueChange / startValue) * 100
-	}
-
-	return ValueChange{
-		StartValue:       startValue,
-		EndValue:         adjustedEndValue, // Use adjusted end value here
-		Change:           valueChange,
-		PercentualChange: percentualChange,
-		StartDate:        snapshots[0].Date,
-		EndDate:          snapshots[len(snapshots)-1].Date,
-		Snapshots:        snapshots,
-	}, nil
-}
-
-func (r *AccountPerformanceRepository) calculateTotalMarketValue(ctx context.Context, accountID uuid.UUID, date time.Time) (float64, time.Time, error) {
-	var totalMarketValue float64
-	var actualDate time.Time
-
-	// Adjusted query to handle dates before the first recorded holding
-	query := `
-	WITH ranked_snapshots AS (
-		SELECT hs.quantity, ap.price, hs.snapshot_date,
-		ROW_NUMBER() OVER (ORDER BY ABS(hs.snapshot_date - $2)) as rn
-		FROM thyrasec.holdings_snapshots hs
-		JOIN thyrasec.asset_prices ap ON hs.asset_id = ap.asset_id
-		WHERE hs.account_id = $1
-	)
-	SELECT quantity, price, snapshot_date FROM ranked_snapshots WHERE rn = 1;
-	`
-
-	row := r.db.QueryRowContext(ctx, query, accountID, date)
-	var quantity, price float64
-	if err := row.Scan(&quantity, &price, &actualDate); err != nil {
-		if err == sql.ErrNoRows {
-			// Handle the case where there is no data
-			return 0, time.Time{}, fmt.Errorf("no data available for account %s", accountID)
-		}
-		return 0, time.Time{}, err
-	}
-
-	totalMarketValue = quantity * price
-	return totalMarketValue, actualDate, nil
-}
-
-//Returns the average performance of all of a users account
-//Returns the average performance of all of a users account
-func (r *AccountPerformanceRepository) GetUserPerformanceChange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (ValueChange, error) {
-	accountIDs, err := r.fetchUserAccountIDs(ctx, userID)
-	if err != nil {
-		return ValueChange{}, err
-	}
-
-	snapshotMap := make(map[time.Time]float64)
-	snapshotCount := make(map[time.Time]int)
-
-	for _, accountID := range accountIDs {
-		accountSnapshots, err := r.fetchAggregatedAccountSnapshots(ctx, accountID, startDate, endDate)
-		if err != nil {
-			return ValueChange{}, err
-		}
-
-		// Aggregate snapshot values by date
-		for _, snapshot := range accountSnapshots {
-			snapshotMap[snapshot.Date] += snapshot.Value
-			snapshotCount[snapshot.Date]++
-		}
-	}
-
-	// Check if there are any snapshots
-	if len(snapshotMap) == 0 {
-		return ValueChange{}, fmt.Errorf("no data available for user %s", userID)
-	}
-
-	// Calculate average snapshot values and sort them by date
-	var snapshots []SnapshotValue
-	for date, totalValue := range snapshotMap {
-		avgValue := totalValue / float64(snapshotCount[date])
-		snapshots = append(snapshots, SnapshotValue{Date: date, Value: avgValue})
-	}
-
-	sort.Slice(snapshots, func(i, j int) bool {
-		return snapshots[i].Date.Before(snapshots[j].Date)
-	})
-
-	startValue := snapshots[0].Value
-	endValue := snapshots[len(snapshots)-1].Value
-
-	valueChange := endValue - startValue
-	percentualChange := 0.0
-	if startValue != 0 {
-		percentualChange = (valueChange / startValue) * 100
-	}
-
-	return ValueChange{
-		StartValue:       startValue,
-		EndValue:         endValue,
-		Change:           valueChange,
-		PercentualChange: percentualChange,
-		StartDate:        startDate,
-		EndDate:          endDate,
-		Snapshots:        snapshots,
-	}, nil
-}
+// GetUserAccountIDs returns the ID of all of a user's accounts
+func (r *AccountPerformanceRepository) GetUserAccountIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var accountIDs []uuid.UUID
 
-func (r *AccountPerformanceRepository) fetchAggregatedAccountSnapshots(ctx context.Context, accountID uuid.UUID, startDate, endDate time.Time) ([]SnapshotValue, error) {
-	// Query to fetch snapshot data between startDate and endDate
-	var snapshots []SnapshotValue
-	query := `SELECT hs.snapshot_date, hs.quantity * ap.price as value
-	FROM thyrasec.holdings_snapshots hs
-	JOIN thyrasec.asset_prices ap ON hs.asset_id = ap.asset_id
-	AND ap.price_date = (
-		SELECT MAX(price_date)
-		FROM thyrasec.asset_prices
-		WHERE asset_id = hs.asset_id AND price_date <= hs.snapshot_date
-	)
-	WHERE hs.account_id = $1 AND hs.snapshot_date BETWEEN $2 AND $3
-	`
-	rows, err := r.db.QueryContext(ctx, query, accountID, startDate, endDate)
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM thyrasec.accounts WHERE account_holder_id = $1", userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var snapshot SnapshotValue
-		if err := rows.Scan(&snapshot.Date, &snapshot.Value); err != nil {
+		var accountID uuid.UUID
+		if err := rows.Scan(&accountID); err != nil {
 			return nil, err
 		}
-		snapshots = append(snapshots, snapshot)
+		accountIDs = append(accountIDs, accountID)
 	}
-	return snapshots, nil
+	return accountIDs, rows.Err()
 }
 
-//Fetches holding snapshot
-func (r *AccountPerformanceRepository) fetchAccountSnapshots(ctx context.Context, accountID uuid.UUID, startDate, endDate time.Time) ([]SnapshotValue, error) {
-	var snapshots []SnapshotValue
-
-	// Updated query to fetch the most recent price for each snapshot date
-	query := `
-	SELECT hs.snapshot_date, hs.quantity * ap.price as value
+// GetValuations returns the account's end-of-day values up to and including
+// the end date, oldest first. Accounts without valuation snapshots fall back
+// to their holdings snapshots priced at the latest price on or before each
+// date, which leaves out cash.
+func (r *AccountPerformanceRepository) GetValuations(ctx context.Context, accountID uuid.UUID, endDate time.Time) ([]models.Valuation, error) {
+	valuations, err := r.queryValuations(ctx, `
+	SELECT snapshot_date, total_value
+	FROM thyrasec.account_snapshots
+	WHERE account_id = $1 AND snapshot_date <= $2 AND total_value IS NOT NULL
+	ORDER BY snapshot_date
+	`, accountID, endDate)
+	if err != nil || len(valuations) > 0 {
+		return valuations, err
+	}
+
+	return r.queryValuations(ctx, `
+	SELECT hs.snapshot_date, SUM(hs.quantity * COALESCE(
+	    (SELECT ap.price FROM thyrasec.asset_prices ap
+	     WHERE ap.asset_id = hs.asset_id AND ap.price_date <= hs.snapshot_date
+	     ORDER BY ap.price_date DESC LIMIT 1),
+	    a.current_price, 0))
 	FROM thyrasec.holdings_snapshots hs
-	JOIN thyrasec.asset_prices ap ON hs.asset_id = ap.asset_id
-	AND ap.price_date = (
-	    SELECT MAX(ap_inner.price_date)
-	    FROM thyrasec.asset_prices ap_inner
-	    WHERE ap_inner.asset_id = hs.asset_id AND ap_inner.price_date <= hs.snapshot_date
-	)
-	WHERE hs.account_id = $1 AND hs.snapshot_date BETWEEN $2 AND $3
+	JOIN thyrasec.assets a ON a.id = hs.asset_id
+	WHERE hs.account_id = $1 AND hs.snapshot_date <= $2
+	GROUP BY hs.snapshot_date
 	ORDER BY hs.snapshot_date
+	`, accountID, endDate)
+}
+
+// GetExternalCashFlows returns the deposits, withdrawals, transfers and free
+// of payment deliveries booked on the account after startDate up to and
+// including endDate, oldest first. Securities are valued at the market price
+// on the trade date rather than the cost carried with them.
+func (r *AccountPerformanceRepository) GetExternalCashFlows(ctx context.Context, accountID uuid.UUID, startDate, endDate time.Time) ([]models.CashFlow, error) {
+	query := `
+	SELECT COALESCE(tr.trade_date, tr.created_at::date) AS flow_date,
+	    CASE
+	        WHEN tt.transaction_type_name ILIKE '%withdraw%' THEN -ABS(tr.cash_amount)
+	        WHEN tt.transaction_type_name ILIKE '%deposit%' THEN ABS(tr.cash_amount)
+	        WHEN tt.transaction_type_name IN ('Cash Transfer In', 'Cash Transfer Out') THEN tr.cash_amount
+	        ELSE tr.asset_quantity * COALESCE(
+	            (SELECT ap.price FROM thyrasec.asset_prices ap
+	             WHERE ap.asset_id = tr.asset_id AND ap.price_date <= COALESCE(tr.trade_date, tr.created_at::date)
+	             ORDER BY ap.price_date DESC LIMIT 1),
+	            a.current_price, tr.asset_price, 0)
+	    END AS amount
+	FROM thyrasec.transactions tr
+	JOIN thyrasec.transactions_types tt ON tt.type_id = tr.type
+	LEFT JOIN thyrasec.assets a ON a.id = tr.asset_id
+	WHERE tr.transaction_owner_account_id = $1 AND NOT tr.canceled
+	    AND COALESCE(tr.trade_date, tr.created_at::date) > $2
+	    AND COALESCE(tr.trade_date, tr.created_at::date) <= $3
+	    AND (tt.transaction_type_name ILIKE '%withdraw%'
+	        OR tt.transaction_type_name ILIKE '%deposit%'
+	        OR tt.transaction_type_name IN ('Cash Transfer In', 'Cash Transfer Out', 'Security Transfer In', 'Security Transfer Out')
+	        OR (tt.transaction_type_name IN ('Security Delivery In', 'Security Delivery Out') AND tr.cash_amount IS NULL))
+	ORDER BY flow_date
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, accountID, startDate, endDate)
@@ -214,102 +110,36 @@ func (r *AccountPerformanceRepository) fetchAccountSnapshots(ctx context.Context
 	}
 	defer rows.Close()
 
+	var flows []models.CashFlow
 	for rows.Next() {
-		var snapshot SnapshotValue
-		if err := rows.Scan(&snapshot.Date, &snapshot.Value); err != nil {
+		var flow models.CashFlow
+		var amount sql.NullFloat64
+		if err := rows.Scan(&flow.Date, &amount); err != nil {
 			return nil, err
 		}
-		snapshots = append(snapshots, snapshot)
-	}
-	return snapshots, nil
-}
-
-//Returns the ID of all accounts
-func (r *AccountPerformanceRepository) fetchUserAccountIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
-	var accountIDs []uuid.UUID
-
-	query := "SELECT id FROM thyrasec.accounts WHERE account_holder_id = $1"
-	rows, err := r.db.QueryContext(ctx, query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var accountID uuid.UUID
-		if err := rows.Scan(&accountID); err != nil {
-			return nil, err
+		if !amount.Valid || amount.Float64 == 0 {
+			continue
 		}
-		accountIDs = append(accountIDs, accountID)
+		flow.Amount = amount.Float64
+		flows = append(flows, flow)
 	}
-
-	return accountIDs, nil
-}
-
-type Transaction struct {
-	Id                 uuid.UUID `json:"id" db:"id"`
-	TransactionOwnerId uuid.UUID `json:"transaction_owner_id" db:"transaction_owner_id"`
-	AccountOwnerId     uuid.UUID `json:"account_owner_id" db:"account_owner_id"`
-	Type               uuid.UUID `json:"type" db:"type"`
-	Asset1Id           uuid.UUID `json:"asset1_id" db:"asset1_id"`                 // Nullable field
-	Asset2Id           uuid.UUID `json:"asset2_id" db:"asset2_id"`                 // Nullable field
-	AccountAsset1Id    uuid.UUID `json:"account_asset1_id" db:"account_asset1_id"` // Nullable field
-	AccountAsset2Id    uuid.UUID `json:"account_asset2_id" db:"account_asset2_id"` // Nullable field
-	AmountAsset1       *float64  `json:"amount_asset1" db:"amount_asset1"`         // Nullable field
-	AmountAsset2       *float64  `json:"amount_asset2" db:"amount_asset2"`         // Nullable field
-	CreatedById        uuid.UUID `json:"created_by_id" db:"created_by_id"`
-	UpdatedById        uuid.UUID `json:"updated_by_id" db:"updated_by_id"`
-	CreatedAt          time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
-	Corrected          bool      `json:"corrected" db:"corrected"`
-	Canceled           bool      `json:"canceled" db:"canceled"`
-	Comment            *string   `json:"comment" db:"comment"` // Nullable field
-	Asset2_currency    uuid.UUID `json:"asset2_currency" db:"asset2_currency"`
-	Asset2_price       uuid.UUID `json:"asset2_price" db:"asset2_price"`
-	OrderNumber        string    `json:"order_no" db:"order_no"`
-	BusinessEvent      uuid.UUID `json:"business_event" db:"business_event"`
-	Trade_date         time.Time `json:"trade_date" db:"trade_date"`
-	Settlement_date    time.Time `json:"settlement_date" db:"settlement_date"`
+	return flows, rows.Err()
 }
 
-func (r *AccountPerformanceRepository) fetchTransactions(ctx context.Context, accountID uuid.UUID, startDate, endDate time.Time) ([]Transaction, error) {
-	var transactions []Transaction
-
-	// Query to fetch transactions
-	query := `
-    SELECT id, type, amount_asset1, amount_asset2, trade_date
-    FROM thyrasec.transactions
-    WHERE account_owner_id = $1 AND trade_date BETWEEN $2 AND $3
-    `
-
-	rows, err := r.db.QueryContext(ctx, query, accountID, startDate, endDate)
+func (r *AccountPerformanceRepository) queryValuations(ctx context.Context, query string, args ...interface{}) ([]models.Valuation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var valuations []models.Valuation
 	for rows.Next() {
-		var transaction Transaction
-		if err := rows.Scan(&transaction.Id, &transaction.Type, &transaction.AmountAsset1, &transaction.AmountAsset2, &transaction.Trade_date); err != nil {
+		var valuation models.Valuation
+		if err := rows.Scan(&valuation.Date, &valuation.Value); err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, transaction)
-	}
-	return transactions, nil
-}
-
-func calculateCashFlows(transactions []Transaction) float64 {
-	var totalCashFlow float64
-	for _, transaction := range transactions {
-		// Check for deposit
-		if transaction.AmountAsset1 != nil && *transaction.AmountAsset1 > 0 {
-			totalCashFlow += *transaction.AmountAsset1 // Adding deposit to cash flow
-		}
-
-		// Check for withdrawal
-		if transaction.AmountAsset2 != nil && *transaction.AmountAsset2 > 0 {
-			totalCashFlow -= *transaction.AmountAsset2 // Subtracting withdrawal from cash flow
-		}
+		valuations = append(valuations, valuation)
 	}
-	return totalCashFlow
+	return valuations, rows.Err()
 }
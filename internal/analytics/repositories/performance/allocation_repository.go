@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/analytics/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type AllocationRepository struct {
+	db *sql.DB
+}
+
+func NewAllocationRepository(db *sql.DB) *AllocationRepository {
+	return &AllocationRepository{db: db}
+}
+
+func (r *AllocationRepository) GetAccount(ctx context.Context, accountID uuid.UUID) (models.AllocationAccount, error) {
+	var account models.AllocationAccount
+	err := r.db.QueryRowContext(ctx, `
+	SELECT id, account_holder_id, account_currency, account_balance FROM thyrasec.accounts WHERE id = $1
+	`, accountID).Scan(&account.ID, &account.HolderID, &account.Currency, &account.AccountBalance)
+	return account, err
+}
+
+// GetUserAccounts returns the user's open accounts.
+func (r *AllocationRepository) GetUserAccounts(ctx context.Context, userID uuid.UUID) ([]models.AllocationAccount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT id, account_holder_id, account_currency, account_balance FROM thyrasec.accounts
+	WHERE account_holder_id = $1 AND closed_at IS NULL
+	ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.AllocationAccount
+	for rows.Next() {
+		var account models.AllocationAccount
+		if err := rows.Scan(&account.ID, &account.HolderID, &account.Currency, &account.AccountBalance); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetPositions returns the account's holdings at their latest price with the
+// assets' classification.
+func (r *AllocationRepository) GetPositions(ctx context.Context, accountID uuid.UUID) ([]models.AllocationPosition, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT h.asset_id, h.quantity, COALESCE(
+	    (SELECT ap.price FROM thyrasec.asset_prices ap
+	     WHERE ap.asset_id = h.asset_id
+	     ORDER BY ap.price_date DESC LIMIT 1),
+	    a.current_price, 0),
+	    COALESCE(a.sector, ''), COALESCE(a.country, ''), COALESCE(a.currency, ''), COALESCE(t.type_name, '')
+	FROM thyrasec.holdings h
+	JOIN thyrasec.assets a ON a.id = h.asset_id
+	LEFT JOIN thyrasec.asset_types t ON t.id = a.asset_type_id
+	WHERE h.account_id = $1 AND h.quantity > 0
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []models.AllocationPosition
+	for rows.Next() {
+		var position models.AllocationPosition
+		err := rows.Scan(&position.AssetID, &position.Quantity, &position.Price,
+			&position.Sector, &position.Country, &position.Currency, &position.AssetType)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, rows.Err()
+}
+
+func (r *AllocationRepository) CountAssets(ctx context.Context, assetIDs []uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM thyrasec.assets WHERE id = ANY($1::uuid[])", uuidArray(assetIDs)).Scan(&count)
+	return count, err
+}
+
+func (r *AllocationRepository) GetConstituents(ctx context.Context, fundAssetID uuid.UUID) ([]models.FundConstituent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT asset_id, weight FROM thyrasec.fund_constituents
+	WHERE fund_asset_id = $1
+	ORDER BY weight DESC
+	`, fundAssetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constituents []models.FundConstituent
+	for rows.Next() {
+		var constituent models.FundConstituent
+		if err := rows.Scan(&constituent.AssetID, &constituent.Weight); err != nil {
+			return nil, err
+		}
+		constituents = append(constituents, constituent)
+	}
+	return constituents, rows.Err()
+}
+
+// GetLookThroughExposures returns, for each fund among the assets that has
+// constituents, the weight and classification of every constituent.
+func (r *AllocationRepository) GetLookThroughExposures(ctx context.Context, fundAssetIDs []uuid.UUID) (map[uuid.UUID][]models.ConstituentExposure, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT fc.fund_asset_id, fc.weight,
+	    COALESCE(a.sector, ''), COALESCE(a.country, ''), COALESCE(a.currency, ''), COALESCE(t.type_name, '')
+	FROM thyrasec.fund_constituents fc
+	JOIN thyrasec.assets a ON a.id = fc.asset_id
+	LEFT JOIN thyrasec.asset_types t ON t.id = a.asset_type_id
+	WHERE fc.fund_asset_id = ANY($1::uuid[])
+	`, uuidArray(fundAssetIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exposures := map[uuid.UUID][]models.ConstituentExposure{}
+	for rows.Next() {
+		var fundAssetID uuid.UUID
+		var exposure models.ConstituentExposure
+		err := rows.Scan(&fundAssetID, &exposure.Weight,
+			&exposure.Sector, &exposure.Country, &exposure.Currency, &exposure.AssetType)
+		if err != nil {
+			return nil, err
+		}
+		exposures[fundAssetID] = append(exposures[fundAssetID], exposure)
+	}
+	return exposures, rows.Err()
+}
+
+// SetConstituents replaces the fund's constituents.
+func (r *AllocationRepository) SetConstituents(ctx context.Context, fundAssetID uuid.UUID, constituents []models.FundConstituent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM thyrasec.fund_constituents WHERE fund_asset_id = $1", fundAssetID); err != nil {
+		return err
+	}
+	for _, constituent := range constituents {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO thyrasec.fund_constituents (fund_asset_id, asset_id, weight) VALUES ($1, $2, $3)
+		`, fundAssetID, constituent.AssetID, constituent.Weight)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func uuidArray(ids []uuid.UUID) pq.StringArray {
+	array := make(pq.StringArray, 0, len(ids))
+	for _, id := range ids {
+		array = append(array, id.String())
+	}
+	return array
+}
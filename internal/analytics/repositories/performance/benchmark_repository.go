@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/analytics/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BenchmarkRepository struct {
+	db *sql.DB
+}
+
+func NewBenchmarkRepository(db *sql.DB) *BenchmarkRepository {
+	return &BenchmarkRepository{db: db}
+}
+
+func (r *BenchmarkRepository) AssetExists(ctx context.Context, assetID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM thyrasec.assets WHERE id = $1)", assetID).Scan(&exists)
+	return exists, err
+}
+
+func (r *BenchmarkRepository) NameTaken(ctx context.Context, name string, exceptID uuid.UUID) (bool, error) {
+	var taken bool
+	err := r.db.QueryRowContext(ctx, `
+	SELECT EXISTS (SELECT 1 FROM thyrasec.benchmarks WHERE lower(name) = lower($1) AND id <> $2)
+	`, name, exceptID).Scan(&taken)
+	return taken, err
+}
+
+// SaveBenchmark inserts or updates the benchmark and replaces its components.
+func (r *BenchmarkRepository) SaveBenchmark(ctx context.Context, benchmark models.Benchmark) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO thyrasec.benchmarks (id, name, description, rebalance_frequency, created_by, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (id) DO UPDATE SET
+	    name = EXCLUDED.name,
+	    description = EXCLUDED.description,
+	    rebalance_frequency = EXCLUDED.rebalance_frequency,
+	    updated_at = EXCLUDED.updated_at
+	`, benchmark.ID, benchmark.Name, benchmark.Description, benchmark.RebalanceFrequency,
+		benchmark.CreatedBy, benchmark.CreatedAt, benchmark.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM thyrasec.benchmark_components WHERE benchmark_id = $1", benchmark.ID); err != nil {
+		return err
+	}
+	for _, component := range benchmark.Components {
+		_, err := tx.ExecContext(ctx, `
+		INSERT INTO thyrasec.benchmark_components (benchmark_id, asset_id, weight) VALUES ($1, $2, $3)
+		`, benchmark.ID, component.AssetID, component.Weight)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *BenchmarkRepository) GetBenchmarks(ctx context.Context) ([]models.Benchmark, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT id, name, description, rebalance_frequency, created_by, created_at, updated_at
+	FROM thyrasec.benchmarks
+	ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	benchmarks := []models.Benchmark{}
+	for rows.Next() {
+		var benchmark models.Benchmark
+		if err := rows.Scan(&benchmark.ID, &benchmark.Name, &benchmark.Description, &benchmark.RebalanceFrequency,
+			&benchmark.CreatedBy, &benchmark.CreatedAt, &benchmark.UpdatedAt); err != nil {
+			return nil, err
+		}
+		benchmarks = append(benchmarks, benchmark)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range benchmarks {
+		if benchmarks[i].Components, err = r.getComponents(ctx, benchmarks[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return benchmarks, nil
+}
+
+func (r *BenchmarkRepository) GetBenchmark(ctx context.Context, benchmarkID uuid.UUID) (models.Benchmark, error) {
+	var benchmark models.Benchmark
+	err := r.db.QueryRowContext(ctx, `
+	SELECT id, name, description, rebalance_frequency, created_by, created_at, updated_at
+	FROM thyrasec.benchmarks
+	WHERE id = $1
+	`, benchmarkID).Scan(&benchmark.ID, &benchmark.Name, &benchmark.Description, &benchmark.RebalanceFrequency,
+		&benchmark.CreatedBy, &benchmark.CreatedAt, &benchmark.UpdatedAt)
+	if err != nil {
+		return benchmark, err
+	}
+	benchmark.Components, err = r.getComponents(ctx, benchmarkID)
+	return benchmark, err
+}
+
+// GetPrices returns the asset's prices up to end, oldest first, starting
+// with its last price on or before start.
+func (r *BenchmarkRepository) GetPrices(ctx context.Context, assetID uuid.UUID, start, end time.Time) ([]models.PricePoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT price_date, price
+	FROM thyrasec.asset_prices
+	WHERE asset_id = $1 AND price IS NOT NULL AND price_date <= $3
+	    AND price_date >= COALESCE(
+	        (SELECT MAX(price_date) FROM thyrasec.asset_prices
+	         WHERE asset_id = $1 AND price IS NOT NULL AND price_date <= $2),
+	        $2)
+	ORDER BY price_date
+	`, assetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prices []models.PricePoint
+	for rows.Next() {
+		var point models.PricePoint
+		if err := rows.Scan(&point.Date, &point.Price); err != nil {
+			return nil, err
+		}
+		prices = append(prices, point)
+	}
+	return prices, rows.Err()
+}
+
+func (r *BenchmarkRepository) getComponents(ctx context.Context, benchmarkID uuid.UUID) ([]models.BenchmarkComponent, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT asset_id, weight FROM thyrasec.benchmark_components
+	WHERE benchmark_id = $1
+	ORDER BY weight DESC, asset_id
+	`, benchmarkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := []models.BenchmarkComponent{}
+	for rows.Next() {
+		var component models.BenchmarkComponent
+		if err := rows.Scan(&component.AssetID, &component.Weight); err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+	return components, rows.Err()
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"thyra/internal/analytics/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var ErrMissingFxRate = errors.New("no exchange rate available")
+
+type ValuationRepository struct {
+	db *sql.DB
+}
+
+func NewValuationRepository(db *sql.DB) *ValuationRepository {
+	return &ValuationRepository{db: db}
+}
+
+// GetAccounts returns the accounts that were open at some point on the date.
+func (r *ValuationRepository) GetAccounts(ctx context.Context, date time.Time) ([]models.ValuationAccount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT id, account_currency, account_balance
+	FROM thyrasec.accounts
+	WHERE (created_at IS NULL OR created_at::date <= $1)
+	    AND (closed_at IS NULL OR closed_at::date >= $1)
+	ORDER BY id
+	`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.ValuationAccount
+	for rows.Next() {
+		var account models.ValuationAccount
+		if err := rows.Scan(&account.ID, &account.Currency, &account.AccountBalance); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// GetCurrentPositions prices the account's current holdings as of the date.
+func (r *ValuationRepository) GetCurrentPositions(ctx context.Context, accountID uuid.UUID, date time.Time) ([]models.PricedPosition, error) {
+	return r.queryPositions(ctx, `
+	SELECT h.asset_id, h.quantity, COALESCE(
+	    (SELECT ap.price FROM thyrasec.asset_prices ap
+	     WHERE ap.asset_id = h.asset_id AND ap.price_date <= $2
+	     ORDER BY ap.price_date DESC LIMIT 1),
+	    a.current_price, 0), COALESCE(a.currency, '')
+	FROM thyrasec.holdings h
+	JOIN thyrasec.assets a ON a.id = h.asset_id
+	WHERE h.account_id = $1 AND h.quantity > 0
+	`, accountID, date)
+}
+
+// GetSnapshotPositions prices the account's latest holdings snapshot on or
+// before the date.
+func (r *ValuationRepository) GetSnapshotPositions(ctx context.Context, accountID uuid.UUID, date time.Time) ([]models.PricedPosition, error) {
+	return r.queryPositions(ctx, `
+	SELECT hs.asset_id, hs.quantity, COALESCE(
+	    (SELECT ap.price FROM thyrasec.asset_prices ap
+	     WHERE ap.asset_id = hs.asset_id AND ap.price_date <= $2
+	     ORDER BY ap.price_date DESC LIMIT 1),
+	    a.current_price, 0), COALESCE(a.currency, '')
+	FROM thyrasec.holdings_snapshots hs
+	JOIN thyrasec.assets a ON a.id = hs.asset_id
+	WHERE hs.account_id = $1 AND hs.quantity > 0
+	    AND hs.snapshot_date = (
+	        SELECT MAX(snapshot_date) FROM thyrasec.holdings_snapshots
+	        WHERE account_id = $1 AND snapshot_date <= $2
+	    )
+	`, accountID, date)
+}
+
+// GetFxRate returns how many units of quote one unit of base cost on the
+// date, using the latest rate on or before it. An inverse rate is used when
+// only the opposite pair is quoted.
+func (r *ValuationRepository) GetFxRate(ctx context.Context, base, quote string, date time.Time) (decimal.Decimal, error) {
+	var rate decimal.Decimal
+	err := r.db.QueryRowContext(ctx, `
+	SELECT rate FROM (
+	    SELECT rate, rate_date FROM thyrasec.fx_rates
+	    WHERE base_currency = $1 AND quote_currency = $2 AND rate_date <= $3
+	    UNION ALL
+	    SELECT 1 / rate, rate_date FROM thyrasec.fx_rates
+	    WHERE base_currency = $2 AND quote_currency = $1 AND rate_date <= $3
+	) rates
+	ORDER BY rate_date DESC
+	LIMIT 1
+	`, base, quote, date).Scan(&rate)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, ErrMissingFxRate
+	}
+	return rate, err
+}
+
+// GetCapturedCash returns the cash balance stored by the daily run closest
+// to the date, preferring earlier days, and the day it was captured on. It
+// reports false when the account has no captured cash balance at all.
+func (r *ValuationRepository) GetCapturedCash(ctx context.Context, accountID uuid.UUID, date time.Time) (decimal.Decimal, time.Time, bool, error) {
+	var cash decimal.Decimal
+	var capturedOn time.Time
+	err := r.db.QueryRowContext(ctx, `
+	SELECT cash_value, snapshot_date FROM thyrasec.account_snapshots
+	WHERE account_id = $1 AND NOT cash_estimated AND cash_value IS NOT NULL
+	ORDER BY snapshot_date > $2, ABS(snapshot_date - $2::date)
+	LIMIT 1
+	`, accountID, date).Scan(&cash, &capturedOn)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, time.Time{}, false, nil
+	}
+	return cash, capturedOn, err == nil, err
+}
+
+// UpsertValuation writes the account's valuation for the day, replacing any
+// earlier valuation of the same day.
+func (r *ValuationRepository) UpsertValuation(ctx context.Context, valuation models.AccountValuation) error {
+	_, err := r.db.ExecContext(ctx, `
+	INSERT INTO thyrasec.account_snapshots (
+	    account_id, snapshot_date, currency, securities_value, cash_value, total_value, cash_estimated, valued_at
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, now())
+	ON CONFLICT (account_id, snapshot_date) DO UPDATE SET
+	    currency = EXCLUDED.currency,
+	    securities_value = EXCLUDED.securities_value,
+	    cash_value = EXCLUDED.cash_value,
+	    total_value = EXCLUDED.total_value,
+	    cash_estimated = EXCLUDED.cash_estimated,
+	    valued_at = EXCLUDED.valued_at
+	`, valuation.AccountID, valuation.SnapshotDate, valuation.Currency, valuation.SecuritiesValue,
+		valuation.CashValue, valuation.TotalValue, valuation.CashEstimated)
+	return err
+}
+
+func (r *ValuationRepository) queryPositions(ctx context.Context, query string, args ...interface{}) ([]models.PricedPosition, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []models.PricedPosition
+	for rows.Next() {
+		var position models.PricedPosition
+		if err := rows.Scan(&position.AssetID, &position.Quantity, &position.Price, &position.Currency); err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, rows.Err()
+}
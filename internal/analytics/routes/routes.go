@@ -6,7 +6,23 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.RouterGroup, accountPerformanceHandler *handlers.AccountPerformanceHandler) {
-	//router.GET("/account/:accountId/performance-change", accountPerformanceHandler.GetAccountPerformanceChange)
-	//router.GET("/user/:userId/performance-change", accountPerformanceHandler.GetUserPerformanceChange)
+func SetupRoutes(router *gin.RouterGroup, accountPerformanceHandler *handlers.AccountPerformanceHandler, benchmarkHandler *handlers.BenchmarkHandler, riskHandler *handlers.RiskHandler, allocationHandler *handlers.AllocationHandler) {
+	router.GET("/account/:accountId/performance-change", accountPerformanceHandler.GetAccountPerformanceChange)
+	router.GET("/user/:userId/performance-change", accountPerformanceHandler.GetUserPerformanceChange)
+
+	router.GET("/benchmarks", benchmarkHandler.GetBenchmarks)
+	router.POST("/benchmarks", benchmarkHandler.CreateBenchmark)
+	router.GET("/benchmarks/:benchmarkId", benchmarkHandler.GetBenchmark)
+	router.PUT("/benchmarks/:benchmarkId", benchmarkHandler.UpdateBenchmark)
+	router.GET("/benchmarks/:benchmarkId/returns", benchmarkHandler.GetBenchmarkReturns)
+	router.GET("/account/:accountId/benchmark-comparison", benchmarkHandler.GetAccountComparison)
+	router.GET("/user/:userId/benchmark-comparison", benchmarkHandler.GetUserComparison)
+
+	router.GET("/account/:accountId/risk", riskHandler.GetAccountRisk)
+	router.GET("/user/:userId/risk", riskHandler.GetUserRisk)
+
+	router.GET("/account/:accountId/allocation", allocationHandler.GetAccountAllocation)
+	router.GET("/user/:userId/allocation", allocationHandler.GetUserAllocation)
+	router.GET("/funds/:assetId/constituents", allocationHandler.GetFundConstituents)
+	router.PUT("/funds/:assetId/constituents", allocationHandler.SetFundConstituents)
 }
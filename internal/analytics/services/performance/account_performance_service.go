@@ -2,12 +2,25 @@ package services
 
 import (
 	"context"
-	repository "thyra/internal/analytics/repositories/performance" // Replace with your actual package path
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"thyra/internal/analytics/models"
+	repository "thyra/internal/analytics/repositories/performance"
+	analyticsutils "thyra/internal/analytics/utils"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrPerformanceAccessDenied = errors.New("not allowed to view this performance")
+	ErrAccountNotFound         = errors.New("account not found")
+	ErrNoValuations            = errors.New("no valuations available for the requested date")
+	ErrInvalidPeriod           = errors.New("startDate must be before endDate")
+)
+
 type AccountPerformanceService struct {
 	repo *repository.AccountPerformanceRepository
 }
@@ -18,11 +31,194 @@ func NewAccountPerformanceService(repo *repository.AccountPerformanceRepository)
 	}
 }
 
-// GetAccountValueChange calculates the value change for an account between two dates
-func (s *AccountPerformanceService) GetAccountValueChange(ctx context.Context, accountID uuid.UUID, startDate, endDate time.Time) (repository.ValueChange, error) {
-	return s.repo.GetAccountPerformanceChange(ctx, accountID, startDate, endDate)
+// Period is a custom date range to report alongside the standard periods.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// GetAccountPerformance returns the account's time and money weighted returns
+// for the standard periods ending at asOf, and for the custom period if one
+// is given.
+func (s *AccountPerformanceService) GetAccountPerformance(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string, asOf time.Time, custom *Period) (models.PerformanceReport, error) {
+	report := models.PerformanceReport{AccountID: &accountID, AsOf: asOf}
+	valuations, flows, err := s.AccountSeries(ctx, accountID, authUserID, authUserRole, asOf)
+	if err != nil {
+		return report, err
+	}
+	return buildReport(report, valuations, flows, custom)
+}
+
+// GetUserPerformance returns the combined returns of all of a user's
+// accounts. Transfers between the user's own accounts cancel out.
+func (s *AccountPerformanceService) GetUserPerformance(ctx context.Context, userID, authUserID uuid.UUID, authUserRole string, asOf time.Time, custom *Period) (models.PerformanceReport, error) {
+	report := models.PerformanceReport{UserID: &userID, AsOf: asOf}
+	valuations, flows, err := s.UserSeries(ctx, userID, authUserID, authUserRole, asOf)
+	if err != nil {
+		return report, err
+	}
+	return buildReport(report, valuations, flows, custom)
+}
+
+// AccountSeries returns the account's valuations and external cash flows up
+// to asOf, after checking that the caller may see them.
+func (s *AccountPerformanceService) AccountSeries(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string, asOf time.Time) ([]models.Valuation, []models.CashFlow, error) {
+	holderID, err := s.repo.GetAccountHolderID(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return nil, nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if authUserRole != "admin" && holderID != authUserID {
+		return nil, nil, ErrPerformanceAccessDenied
+	}
+	return s.loadAccount(ctx, accountID, asOf)
+}
+
+// UserSeries combines the valuations and cash flows of all of a user's
+// accounts up to asOf.
+func (s *AccountPerformanceService) UserSeries(ctx context.Context, userID, authUserID uuid.UUID, authUserRole string, asOf time.Time) ([]models.Valuation, []models.CashFlow, error) {
+	if authUserRole != "admin" && userID != authUserID {
+		return nil, nil, ErrPerformanceAccessDenied
+	}
+
+	accountIDs, err := s.repo.GetUserAccountIDs(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var series [][]models.Valuation
+	var flows []models.CashFlow
+	for _, accountID := range accountIDs {
+		accountValuations, accountFlows, err := s.loadAccount(ctx, accountID, asOf)
+		if err != nil {
+			return nil, nil, err
+		}
+		series = append(series, accountValuations)
+		flows = append(flows, accountFlows...)
+	}
+	sort.SliceStable(flows, func(i, j int) bool { return flows[i].Date.Before(flows[j].Date) })
+	return analyticsutils.MergeValuations(series...), flows, nil
+}
+
+func (s *AccountPerformanceService) loadAccount(ctx context.Context, accountID uuid.UUID, asOf time.Time) ([]models.Valuation, []models.CashFlow, error) {
+	valuations, err := s.repo.GetValuations(ctx, accountID, asOf)
+	if err != nil || len(valuations) == 0 {
+		return nil, nil, err
+	}
+	flows, err := s.repo.GetExternalCashFlows(ctx, accountID, valuations[0].Date, asOf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return valuations, flows, nil
+}
+
+type reportPeriod struct {
+	name       string
+	start, end time.Time
+}
+
+func buildReport(report models.PerformanceReport, valuations []models.Valuation, flows []models.CashFlow, custom *Period) (models.PerformanceReport, error) {
+	if custom != nil && !custom.Start.Before(custom.End) {
+		return report, ErrInvalidPeriod
+	}
+	if len(valuations) == 0 {
+		return report, ErrNoValuations
+	}
+	report.InceptionDate = valuations[0].Date
+
+	asOf := report.AsOf
+	monthStart := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, asOf.Location())
+	quarterStart := time.Date(asOf.Year(), asOf.Month()-(asOf.Month()-1)%3, 1, 0, 0, 0, 0, asOf.Location())
+	yearStart := time.Date(asOf.Year(), time.January, 1, 0, 0, 0, 0, asOf.Location())
+
+	// Each period starts from the closing value of the day before it begins.
+	periods := []reportPeriod{
+		{models.PeriodMTD, monthStart.AddDate(0, 0, -1), asOf},
+		{models.PeriodQTD, quarterStart.AddDate(0, 0, -1), asOf},
+		{models.PeriodYTD, yearStart.AddDate(0, 0, -1), asOf},
+		{models.PeriodOneYear, asOf.AddDate(-1, 0, 0), asOf},
+		{models.PeriodSinceInception, report.InceptionDate, asOf},
+	}
+	if custom != nil {
+		periods = append(periods, reportPeriod{models.PeriodCustom, custom.Start.AddDate(0, 0, -1), custom.End})
+	}
+
+	for _, period := range periods {
+		result, ok := periodReturn(period.name, valuations, flows, period.start, period.end)
+		if ok {
+			report.Periods = append(report.Periods, result)
+		}
+	}
+	return report, nil
+}
+
+// periodWindow picks the valuations from the last one on or before start to
+// the last one on or before end, and the cash flows between them. A period
+// that begins before the first valuation starts from the first valuation.
+func periodWindow(valuations []models.Valuation, flows []models.CashFlow, start, end time.Time) ([]models.Valuation, []models.CashFlow, bool) {
+	first := sort.Search(len(valuations), func(i int) bool { return valuations[i].Date.After(start) }) - 1
+	if first < 0 {
+		first = 0
+	}
+	last := sort.Search(len(valuations), func(i int) bool { return valuations[i].Date.After(end) }) - 1
+	if last < first {
+		return nil, nil, false
+	}
+
+	series := valuations[first : last+1]
+	startDate, endDate := series[0].Date, series[len(series)-1].Date
+	var periodFlows []models.CashFlow
+	for _, flow := range flows {
+		if flow.Date.After(startDate) && !flow.Date.After(endDate) {
+			periodFlows = append(periodFlows, flow)
+		}
+	}
+	return series, periodFlows, true
+}
+
+func periodReturn(name string, valuations []models.Valuation, flows []models.CashFlow, start, end time.Time) (models.PeriodReturn, bool) {
+	series, periodFlows, ok := periodWindow(valuations, flows, start, end)
+	if !ok {
+		return models.PeriodReturn{}, false
+	}
+	startValuation, endValuation := series[0], series[len(series)-1]
+	netFlow := 0.0
+	for _, flow := range periodFlows {
+		netFlow += flow.Amount
+	}
+
+	twr := analyticsutils.TimeWeightedReturn(series, periodFlows)
+	result := models.PeriodReturn{
+		Period:             name,
+		StartDate:          startValuation.Date,
+		EndDate:            endValuation.Date,
+		StartValue:         roundTo(startValuation.Value, 2),
+		EndValue:           roundTo(endValuation.Value, 2),
+		NetCashFlow:        roundTo(netFlow, 2),
+		Gain:               roundTo(endValuation.Value-startValuation.Value-netFlow, 2),
+		TimeWeightedReturn: percent(twr),
+	}
+	if annualized, ok := analyticsutils.Annualize(twr, startValuation.Date, endValuation.Date); ok {
+		value := percent(annualized)
+		result.AnnualizedTimeWeightedReturn = &value
+	}
+	if mwr, ok := analyticsutils.MoneyWeightedReturn(startValuation, endValuation, periodFlows); ok {
+		value := percent(mwr)
+		result.MoneyWeightedReturn = &value
+		if annualized, ok := analyticsutils.Annualize(mwr, startValuation.Date, endValuation.Date); ok {
+			value := percent(annualized)
+			result.AnnualizedMoneyWeightedReturn = &value
+		}
+	}
+	return result, true
+}
+
+func percent(value float64) float64 {
+	return roundTo(value*100, 4)
 }
 
-func (s *AccountPerformanceService) GetUserValueChange(ctx context.Context, userID uuid.UUID, startDate, endDate time.Time) (repository.ValueChange, error) {
-	return s.repo.GetUserPerformanceChange(ctx, userID, startDate, endDate)
+func roundTo(value float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(value*factor) / factor
 }
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"thyra/internal/analytics/models"
+	repository "thyra/internal/analytics/repositories/performance"
+	analyticsutils "thyra/internal/analytics/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultReportingCurrency is used for a user's allocation when no currency
+// is requested.
+const DefaultReportingCurrency = "SEK"
+
+var (
+	ErrAllocationAccessDenied = errors.New("you do not have access to this portfolio")
+	ErrConstituentsDenied     = errors.New("only admins can manage fund constituents")
+	ErrInvalidCurrency        = errors.New("currency must be a three letter ISO 4217 code")
+	ErrFundNotFound           = errors.New("fund not found")
+	ErrInvalidConstituents    = errors.New("constituents must be other existing assets, each listed once, with weights above 0 adding up to at most 1")
+	ErrMissingFxRate          = repository.ErrMissingFxRate
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// AllocationService breaks a portfolio's current market value down by
+// sector, country, currency and asset type.
+type AllocationService struct {
+	repo *repository.AllocationRepository
+	fx   *repository.ValuationRepository
+}
+
+func NewAllocationService(repo *repository.AllocationRepository, fx *repository.ValuationRepository) *AllocationService {
+	return &AllocationService{repo: repo, fx: fx}
+}
+
+// GetAccountAllocation reports the account in currency, or in the account
+// currency when currency is empty. With lookThrough, funds with known
+// constituents are split over them.
+func (s *AllocationService) GetAccountAllocation(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole, currency string, lookThrough bool) (models.AllocationReport, error) {
+	report := models.AllocationReport{AccountID: &accountID, LookThrough: lookThrough}
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return report, ErrAccountNotFound
+	}
+	if err != nil {
+		return report, err
+	}
+	if !canViewAllocation(account.HolderID, authUserID, authUserRole) {
+		return report, ErrAllocationAccessDenied
+	}
+	if currency == "" {
+		currency = account.Currency
+	}
+	return s.buildAllocation(ctx, report, []models.AllocationAccount{account}, currency)
+}
+
+// GetUserAllocation reports all of the user's open accounts together, in
+// DefaultReportingCurrency unless another currency is given.
+func (s *AllocationService) GetUserAllocation(ctx context.Context, userID, authUserID uuid.UUID, authUserRole, currency string, lookThrough bool) (models.AllocationReport, error) {
+	report := models.AllocationReport{UserID: &userID, LookThrough: lookThrough}
+	if !canViewAllocation(userID, authUserID, authUserRole) {
+		return report, ErrAllocationAccessDenied
+	}
+	accounts, err := s.repo.GetUserAccounts(ctx, userID)
+	if err != nil {
+		return report, err
+	}
+	if currency == "" {
+		currency = DefaultReportingCurrency
+	}
+	return s.buildAllocation(ctx, report, accounts, currency)
+}
+
+func (s *AllocationService) GetFundConstituents(ctx context.Context, fundAssetID uuid.UUID) ([]models.FundConstituent, error) {
+	count, err := s.repo.CountAssets(ctx, []uuid.UUID{fundAssetID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrFundNotFound
+	}
+	constituents, err := s.repo.GetConstituents(ctx, fundAssetID)
+	if err != nil {
+		return nil, err
+	}
+	if constituents == nil {
+		constituents = []models.FundConstituent{}
+	}
+	return constituents, nil
+}
+
+// SetFundConstituents replaces the fund's constituents. An empty list turns
+// look-through off for the fund.
+func (s *AllocationService) SetFundConstituents(ctx context.Context, fundAssetID uuid.UUID, authUserRole string, req models.FundConstituentsRequest) ([]models.FundConstituent, error) {
+	if authUserRole != "admin" {
+		return nil, ErrConstituentsDenied
+	}
+	count, err := s.repo.CountAssets(ctx, []uuid.UUID{fundAssetID})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrFundNotFound
+	}
+
+	seen := map[uuid.UUID]bool{fundAssetID: true}
+	assetIDs := make([]uuid.UUID, 0, len(req.Constituents))
+	total := 0.0
+	for _, constituent := range req.Constituents {
+		if seen[constituent.AssetID] || constituent.Weight <= 0 || constituent.Weight > 1 {
+			return nil, ErrInvalidConstituents
+		}
+		seen[constituent.AssetID] = true
+		assetIDs = append(assetIDs, constituent.AssetID)
+		total += constituent.Weight
+	}
+	if total > 1+weightTolerance {
+		return nil, ErrInvalidConstituents
+	}
+	if len(assetIDs) > 0 {
+		count, err := s.repo.CountAssets(ctx, assetIDs)
+		if err != nil {
+			return nil, err
+		}
+		if count != len(assetIDs) {
+			return nil, ErrInvalidConstituents
+		}
+	}
+
+	if err := s.repo.SetConstituents(ctx, fundAssetID, req.Constituents); err != nil {
+		return nil, err
+	}
+	return s.GetFundConstituents(ctx, fundAssetID)
+}
+
+func (s *AllocationService) buildAllocation(ctx context.Context, report models.AllocationReport, accounts []models.AllocationAccount, currency string) (models.AllocationReport, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyPattern.MatchString(currency) {
+		return report, ErrInvalidCurrency
+	}
+	report.Currency = currency
+	report.AsOf = time.Now()
+
+	converter := &currencyConverter{fx: s.fx, to: currency, date: report.AsOf, rates: map[string]decimal.Decimal{}}
+	var exposures []models.Exposure
+	for _, account := range accounts {
+		positions, err := s.repo.GetPositions(ctx, account.ID)
+		if err != nil {
+			return report, err
+		}
+
+		var lookThrough map[uuid.UUID][]models.ConstituentExposure
+		if report.LookThrough && len(positions) > 0 {
+			assetIDs := make([]uuid.UUID, len(positions))
+			for i, position := range positions {
+				assetIDs[i] = position.AssetID
+			}
+			lookThrough, err = s.repo.GetLookThroughExposures(ctx, assetIDs)
+			if err != nil {
+				return report, err
+			}
+		}
+
+		for _, position := range positions {
+			classification := position.Classification
+			if classification.Currency == "" {
+				classification.Currency = account.Currency
+			}
+			value, err := converter.convert(ctx, position.Quantity.Mul(position.Price), classification.Currency)
+			if err != nil {
+				return report, fmt.Errorf("asset %s: %w", position.AssetID, err)
+			}
+			if constituents, ok := lookThrough[position.AssetID]; ok {
+				exposures = append(exposures, analyticsutils.LookThrough(value, classification, constituents)...)
+			} else {
+				exposures = append(exposures, models.Exposure{Value: value, Classification: classification})
+			}
+		}
+
+		cash, err := converter.convert(ctx, account.AccountBalance, account.Currency)
+		if err != nil {
+			return report, fmt.Errorf("account %s cash: %w", account.ID, err)
+		}
+		if !cash.IsZero() {
+			exposures = append(exposures, models.Exposure{Value: cash, Classification: models.Classification{
+				Sector:    models.AllocationCash,
+				Country:   models.AllocationCash,
+				Currency:  account.Currency,
+				AssetType: models.AllocationCash,
+			}})
+		}
+	}
+
+	total := decimal.Zero
+	for _, exposure := range exposures {
+		total = total.Add(exposure.Value)
+	}
+	report.TotalValue = total.Round(2)
+	report.Sectors = analyticsutils.GroupExposures(exposures, models.AllocationBySector, total)
+	report.Countries = analyticsutils.GroupExposures(exposures, models.AllocationByCountry, total)
+	report.Currencies = analyticsutils.GroupExposures(exposures, models.AllocationByCurrency, total)
+	report.AssetTypes = analyticsutils.GroupExposures(exposures, models.AllocationByAssetType, total)
+	return report, nil
+}
+
+// canViewAllocation lets admins and advisors see any client's portfolio and
+// customers their own.
+func canViewAllocation(holderID, authUserID uuid.UUID, authUserRole string) bool {
+	return authUserRole == "admin" || authUserRole == "partner_advisor" || holderID == authUserID
+}
+
+// currencyConverter converts amounts into one currency at the latest rates
+// on or before date, looking each rate up once.
+type currencyConverter struct {
+	fx    *repository.ValuationRepository
+	to    string
+	date  time.Time
+	rates map[string]decimal.Decimal
+}
+
+func (c *currencyConverter) convert(ctx context.Context, amount decimal.Decimal, from string) (decimal.Decimal, error) {
+	if from == c.to {
+		return amount, nil
+	}
+	rate, ok := c.rates[from]
+	if !ok {
+		var err error
+		rate, err = c.fx.GetFxRate(ctx, from, c.to, c.date)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("%s/%s: %w", from, c.to, err)
+		}
+		c.rates[from] = rate
+	}
+	return amount.Mul(rate), nil
+}
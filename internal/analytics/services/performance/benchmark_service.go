@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"thyra/internal/analytics/models"
+	repository "thyra/internal/analytics/repositories/performance"
+	analyticsutils "thyra/internal/analytics/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// weightTolerance absorbs rounding in weights such as three thirds.
+const weightTolerance = 1e-4
+
+var (
+	ErrBenchmarkAccessDenied     = errors.New("only admins can manage benchmarks")
+	ErrBenchmarkNotFound         = errors.New("benchmark not found")
+	ErrBenchmarkNameTaken        = errors.New("a benchmark with this name already exists")
+	ErrBenchmarkNameRequired     = errors.New("name is required")
+	ErrInvalidBenchmark          = errors.New("give either asset_id or components, each asset once")
+	ErrInvalidWeights            = errors.New("component weights must be positive and add up to 1")
+	ErrInvalidRebalanceFrequency = errors.New("rebalance_frequency must be none, daily, monthly, quarterly or annually")
+	ErrBenchmarkAssetNotFound    = errors.New("benchmark asset not found")
+	ErrNoBenchmarkPrices         = analyticsutils.ErrMissingBenchmarkPrice
+)
+
+type BenchmarkService struct {
+	repo        *repository.BenchmarkRepository
+	performance *AccountPerformanceService
+}
+
+func NewBenchmarkService(repo *repository.BenchmarkRepository, performance *AccountPerformanceService) *BenchmarkService {
+	return &BenchmarkService{repo: repo, performance: performance}
+}
+
+func (s *BenchmarkService) CreateBenchmark(ctx context.Context, authUserID uuid.UUID, authUserRole string, req models.BenchmarkRequest) (models.Benchmark, error) {
+	if authUserRole != "admin" {
+		return models.Benchmark{}, ErrBenchmarkAccessDenied
+	}
+	now := time.Now()
+	benchmark := models.Benchmark{ID: uuid.New(), CreatedBy: authUserID, CreatedAt: now, UpdatedAt: now}
+	if err := s.apply(ctx, &benchmark, req); err != nil {
+		return models.Benchmark{}, err
+	}
+	if err := s.repo.SaveBenchmark(ctx, benchmark); err != nil {
+		return models.Benchmark{}, err
+	}
+	return benchmark, nil
+}
+
+// UpdateBenchmark replaces the benchmark's definition. Returns are always
+// computed from the current definition.
+func (s *BenchmarkService) UpdateBenchmark(ctx context.Context, benchmarkID uuid.UUID, authUserRole string, req models.BenchmarkRequest) (models.Benchmark, error) {
+	if authUserRole != "admin" {
+		return models.Benchmark{}, ErrBenchmarkAccessDenied
+	}
+	benchmark, err := s.GetBenchmark(ctx, benchmarkID)
+	if err != nil {
+		return models.Benchmark{}, err
+	}
+	if err := s.apply(ctx, &benchmark, req); err != nil {
+		return models.Benchmark{}, err
+	}
+	benchmark.UpdatedAt = time.Now()
+	if err := s.repo.SaveBenchmark(ctx, benchmark); err != nil {
+		return models.Benchmark{}, err
+	}
+	return benchmark, nil
+}
+
+func (s *BenchmarkService) GetBenchmarks(ctx context.Context) ([]models.Benchmark, error) {
+	return s.repo.GetBenchmarks(ctx)
+}
+
+func (s *BenchmarkService) GetBenchmark(ctx context.Context, benchmarkID uuid.UUID) (models.Benchmark, error) {
+	benchmark, err := s.repo.GetBenchmark(ctx, benchmarkID)
+	if err == sql.ErrNoRows {
+		return benchmark, ErrBenchmarkNotFound
+	}
+	return benchmark, err
+}
+
+// GetBenchmarkReturns returns the benchmark's cumulative return over the
+// period, measured from the close of the day before start.
+func (s *BenchmarkService) GetBenchmarkReturns(ctx context.Context, benchmarkID uuid.UUID, start, end time.Time) (models.BenchmarkReturns, error) {
+	returns := models.BenchmarkReturns{BenchmarkID: benchmarkID, StartDate: start, EndDate: end}
+	if !start.Before(end) {
+		return returns, ErrInvalidPeriod
+	}
+	benchmark, err := s.GetBenchmark(ctx, benchmarkID)
+	if err != nil {
+		return returns, err
+	}
+	levels, err := s.levels(ctx, benchmark, start.AddDate(0, 0, -1), end)
+	if err != nil {
+		return returns, err
+	}
+
+	returns.Series = make([]models.CumulativePoint, len(levels))
+	for i, level := range levels {
+		returns.Series[i] = models.CumulativePoint{Date: level.Date, Return: percent(level.Value - 1)}
+	}
+	returns.TotalReturn = returns.Series[len(returns.Series)-1].Return
+	return returns, nil
+}
+
+// CompareAccount compares the account's time weighted return with the
+// benchmark from the close of the day before start to end.
+func (s *BenchmarkService) CompareAccount(ctx context.Context, accountID, benchmarkID, authUserID uuid.UUID, authUserRole string, start, end time.Time) (models.BenchmarkComparison, error) {
+	comparison := models.BenchmarkComparison{AccountID: &accountID, BenchmarkID: benchmarkID}
+	if !start.Before(end) {
+		return comparison, ErrInvalidPeriod
+	}
+	valuations, flows, err := s.performance.AccountSeries(ctx, accountID, authUserID, authUserRole, end)
+	if err != nil {
+		return comparison, err
+	}
+	return s.compare(ctx, comparison, valuations, flows, start, end)
+}
+
+// CompareUser compares the combined return of the user's accounts with the benchmark.
+func (s *BenchmarkService) CompareUser(ctx context.Context, userID, benchmarkID, authUserID uuid.UUID, authUserRole string, start, end time.Time) (models.BenchmarkComparison, error) {
+	comparison := models.BenchmarkComparison{UserID: &userID, BenchmarkID: benchmarkID}
+	if !start.Before(end) {
+		return comparison, ErrInvalidPeriod
+	}
+	valuations, flows, err := s.performance.UserSeries(ctx, userID, authUserID, authUserRole, end)
+	if err != nil {
+		return comparison, err
+	}
+	return s.compare(ctx, comparison, valuations, flows, start, end)
+}
+
+// compare measures the benchmark over exactly the portfolio's sub-periods,
+// so each active return compares like with like.
+func (s *BenchmarkService) compare(ctx context.Context, comparison models.BenchmarkComparison, valuations []models.Valuation, flows []models.CashFlow, start, end time.Time) (models.BenchmarkComparison, error) {
+	benchmark, err := s.GetBenchmark(ctx, comparison.BenchmarkID)
+	if err != nil {
+		return comparison, err
+	}
+	comparison.BenchmarkName = benchmark.Name
+
+	series, periodFlows, ok := periodWindow(valuations, flows, start.AddDate(0, 0, -1), end)
+	if !ok {
+		return comparison, ErrNoValuations
+	}
+	comparison.StartDate, comparison.EndDate = series[0].Date, series[len(series)-1].Date
+	subPeriods := analyticsutils.SubPeriodReturns(series, periodFlows)
+	benchmarkReturns, err := s.MatchSubPeriods(ctx, benchmark, subPeriods)
+	if err != nil {
+		return comparison, err
+	}
+
+	portfolioGrowth, benchmarkGrowth := 1.0, 1.0
+	var active []float64
+	comparison.Series = []models.ComparisonPoint{{Date: comparison.StartDate}}
+	for i, subPeriod := range subPeriods {
+		benchmarkReturn := benchmarkReturns[i]
+		portfolioGrowth *= 1 + subPeriod.Return
+		benchmarkGrowth *= 1 + benchmarkReturn
+		active = append(active, subPeriod.Return-benchmarkReturn)
+		comparison.Series = append(comparison.Series, models.ComparisonPoint{
+			Date:            subPeriod.End,
+			PortfolioReturn: percent(portfolioGrowth - 1),
+			BenchmarkReturn: percent(benchmarkGrowth - 1),
+		})
+	}
+
+	comparison.Observations = len(active)
+	comparison.PortfolioReturn = percent(portfolioGrowth - 1)
+	comparison.BenchmarkReturn = percent(benchmarkGrowth - 1)
+	comparison.ActiveReturn = percent(portfolioGrowth - benchmarkGrowth)
+	if len(active) >= 2 {
+		periodsPerYear := analyticsutils.PeriodsPerYear(len(active), comparison.EndDate.Sub(comparison.StartDate).Hours()/24)
+		trackingError := analyticsutils.StdDev(active) * math.Sqrt(periodsPerYear)
+		value := percent(trackingError)
+		comparison.TrackingError = &value
+		if trackingError > 0 {
+			ratio := roundTo(analyticsutils.Mean(active)*periodsPerYear/trackingError, 4)
+			comparison.InformationRatio = &ratio
+		}
+	}
+	return comparison, nil
+}
+
+// MatchSubPeriods returns the benchmark's return over each of the
+// portfolio's sub-periods.
+func (s *BenchmarkService) MatchSubPeriods(ctx context.Context, benchmark models.Benchmark, subPeriods []models.SubPeriodReturn) ([]float64, error) {
+	if len(subPeriods) == 0 {
+		return nil, nil
+	}
+	levels, err := s.levels(ctx, benchmark, subPeriods[0].Start, subPeriods[len(subPeriods)-1].End)
+	if err != nil {
+		return nil, err
+	}
+	returns := make([]float64, len(subPeriods))
+	for i, subPeriod := range subPeriods {
+		startLevel, _ := analyticsutils.LevelOn(levels, subPeriod.Start)
+		endLevel, _ := analyticsutils.LevelOn(levels, subPeriod.End)
+		returns[i] = endLevel/startLevel - 1
+	}
+	return returns, nil
+}
+
+func (s *BenchmarkService) levels(ctx context.Context, benchmark models.Benchmark, start, end time.Time) ([]models.Valuation, error) {
+	prices := make(map[uuid.UUID][]models.PricePoint, len(benchmark.Components))
+	for _, component := range benchmark.Components {
+		series, err := s.repo.GetPrices(ctx, component.AssetID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		prices[component.AssetID] = series
+	}
+	return analyticsutils.BenchmarkLevels(benchmark.Components, prices, benchmark.RebalanceFrequency, start, end)
+}
+
+// apply validates the request and copies it onto the benchmark.
+func (s *BenchmarkService) apply(ctx context.Context, benchmark *models.Benchmark, req models.BenchmarkRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ErrBenchmarkNameRequired
+	}
+	taken, err := s.repo.NameTaken(ctx, name, benchmark.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrBenchmarkNameTaken
+	}
+
+	frequency := req.RebalanceFrequency
+	switch frequency {
+	case "":
+		frequency = models.RebalanceNone
+	case models.RebalanceNone, models.RebalanceDaily, models.RebalanceMonthly, models.RebalanceQuarterly, models.RebalanceAnnually:
+	default:
+		return ErrInvalidRebalanceFrequency
+	}
+
+	components := req.Components
+	switch {
+	case req.AssetID != nil && len(components) == 0:
+		components = []models.BenchmarkComponent{{AssetID: *req.AssetID, Weight: 1}}
+	case req.AssetID != nil || len(components) == 0:
+		return ErrInvalidBenchmark
+	}
+
+	seen := map[uuid.UUID]bool{}
+	total := 0.0
+	for _, component := range components {
+		if seen[component.AssetID] {
+			return ErrInvalidBenchmark
+		}
+		seen[component.AssetID] = true
+		if component.Weight <= 0 || component.Weight > 1 {
+			return ErrInvalidWeights
+		}
+		total += component.Weight
+
+		exists, err := s.repo.AssetExists(ctx, component.AssetID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrBenchmarkAssetNotFound
+		}
+	}
+	if math.Abs(total-1) > weightTolerance {
+		return ErrInvalidWeights
+	}
+
+	benchmark.Name = name
+	benchmark.Description = req.Description
+	benchmark.RebalanceFrequency = frequency
+	benchmark.Components = components
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"thyra/internal/analytics/models"
+	analyticsutils "thyra/internal/analytics/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// minRiskObservations is the fewest sub-period returns a risk report is
+// computed from.
+const minRiskObservations = 2
+
+var ErrInsufficientHistory = errors.New("at least two daily valuations with money invested are needed in the period")
+
+var varConfidenceLevels = []float64{0.95, 0.99}
+
+// RiskService computes risk figures from the same valuations and cash flows
+// as the performance reports.
+type RiskService struct {
+	performance  *AccountPerformanceService
+	benchmarks   *BenchmarkService
+	riskFreeRate float64
+}
+
+// NewRiskService takes the annual risk free rate used for the Sharpe ratio
+// as a fraction.
+func NewRiskService(performance *AccountPerformanceService, benchmarks *BenchmarkService, riskFreeRate float64) *RiskService {
+	return &RiskService{performance: performance, benchmarks: benchmarks, riskFreeRate: riskFreeRate}
+}
+
+// NewRiskServiceFromEnv reads the annual risk free rate from RISK_FREE_RATE,
+// as a fraction (default 0).
+func NewRiskServiceFromEnv(performance *AccountPerformanceService, benchmarks *BenchmarkService) *RiskService {
+	riskFreeRate := 0.0
+	if value := os.Getenv("RISK_FREE_RATE"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= -1 || parsed >= 1 {
+			log.Printf("Ignoring invalid RISK_FREE_RATE %q", value)
+		} else {
+			riskFreeRate = parsed
+		}
+	}
+	return NewRiskService(performance, benchmarks, riskFreeRate)
+}
+
+// GetAccountRisk reports the account's risk from the close of the day
+// before start to end. Beta is included when benchmarkID is given.
+func (s *RiskService) GetAccountRisk(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string, start, end time.Time, benchmarkID *uuid.UUID) (models.RiskReport, error) {
+	report := models.RiskReport{AccountID: &accountID}
+	if !start.Before(end) {
+		return report, ErrInvalidPeriod
+	}
+	valuations, flows, err := s.performance.AccountSeries(ctx, accountID, authUserID, authUserRole, end)
+	if err != nil {
+		return report, err
+	}
+	return s.buildRiskReport(ctx, report, valuations, flows, start, end, benchmarkID)
+}
+
+// GetUserRisk reports the risk of the user's accounts taken together.
+func (s *RiskService) GetUserRisk(ctx context.Context, userID, authUserID uuid.UUID, authUserRole string, start, end time.Time, benchmarkID *uuid.UUID) (models.RiskReport, error) {
+	report := models.RiskReport{UserID: &userID}
+	if !start.Before(end) {
+		return report, ErrInvalidPeriod
+	}
+	valuations, flows, err := s.performance.UserSeries(ctx, userID, authUserID, authUserRole, end)
+	if err != nil {
+		return report, err
+	}
+	return s.buildRiskReport(ctx, report, valuations, flows, start, end, benchmarkID)
+}
+
+func (s *RiskService) buildRiskReport(ctx context.Context, report models.RiskReport, valuations []models.Valuation, flows []models.CashFlow, start, end time.Time, benchmarkID *uuid.UUID) (models.RiskReport, error) {
+	series, periodFlows, ok := periodWindow(valuations, flows, start.AddDate(0, 0, -1), end)
+	if !ok {
+		return report, ErrNoValuations
+	}
+	report.StartDate, report.EndDate = series[0].Date, series[len(series)-1].Date
+	subPeriods := analyticsutils.SubPeriodReturns(series, periodFlows)
+	if len(subPeriods) < minRiskObservations {
+		return report, ErrInsufficientHistory
+	}
+
+	returns := make([]float64, len(subPeriods))
+	growth := 1.0
+	for i, subPeriod := range subPeriods {
+		returns[i] = subPeriod.Return
+		growth *= 1 + subPeriod.Return
+	}
+	report.Observations = len(returns)
+	report.TotalReturn = percent(growth - 1)
+	report.RiskFreeRate = percent(s.riskFreeRate)
+
+	periodsPerYear := analyticsutils.PeriodsPerYear(len(returns), report.EndDate.Sub(report.StartDate).Hours()/24)
+	volatility := analyticsutils.StdDev(returns) * math.Sqrt(periodsPerYear)
+	report.Volatility = percent(volatility)
+	if volatility > 0 {
+		periodRiskFree := math.Pow(1+s.riskFreeRate, 1/periodsPerYear) - 1
+		excess := analyticsutils.Mean(returns) - periodRiskFree
+		sharpe := roundTo(excess*periodsPerYear/volatility, 4)
+		report.SharpeRatio = &sharpe
+	}
+
+	if drawdown, peak, trough, recovery, ok := analyticsutils.MaxDrawdown(subPeriods); ok {
+		report.MaxDrawdown = models.Drawdown{
+			Drawdown:     percent(drawdown),
+			PeakDate:     &peak,
+			TroughDate:   &trough,
+			RecoveryDate: recovery,
+		}
+	}
+
+	for _, confidence := range varConfidenceLevels {
+		historical, historicalShortfall := analyticsutils.HistoricalVaR(returns, confidence)
+		parametric, parametricShortfall := analyticsutils.ParametricVaR(returns, confidence)
+		report.ValueAtRisk = append(report.ValueAtRisk,
+			models.ValueAtRisk{
+				Method:                 models.VaRMethodHistorical,
+				Confidence:             percent(confidence),
+				ValueAtRisk:            percent(historical),
+				ConditionalValueAtRisk: percent(historicalShortfall),
+			},
+			models.ValueAtRisk{
+				Method:                 models.VaRMethodParametric,
+				Confidence:             percent(confidence),
+				ValueAtRisk:            percent(parametric),
+				ConditionalValueAtRisk: percent(parametricShortfall),
+			})
+	}
+
+	if benchmarkID != nil {
+		benchmark, err := s.benchmarks.GetBenchmark(ctx, *benchmarkID)
+		if err != nil {
+			return report, err
+		}
+		benchmarkReturns, err := s.benchmarks.MatchSubPeriods(ctx, benchmark, subPeriods)
+		if err != nil {
+			return report, err
+		}
+		report.Beta = &models.BetaEstimate{BenchmarkID: benchmark.ID, BenchmarkName: benchmark.Name}
+		if beta, ok := analyticsutils.Beta(returns, benchmarkReturns); ok {
+			value := roundTo(beta, 4)
+			report.Beta.Beta = &value
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"thyra/internal/analytics/models"
+	repository "thyra/internal/analytics/repositories/performance"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ValuationService writes the end-of-day value of every account to
+// account_snapshots. Holdings are priced at the latest asset price on or
+// before the day and converted to the account currency.
+type ValuationService struct {
+	repo *repository.ValuationRepository
+}
+
+func NewValuationService(repo *repository.ValuationRepository) *ValuationService {
+	return &ValuationService{repo: repo}
+}
+
+// RunDaily values today's holdings and cash balances. The scheduler runs it
+// once a day just before midnight; a retry or manual rerun overwrites the
+// day's rows, so the stored value is the one at close.
+func (s *ValuationService) RunDaily(ctx context.Context, now time.Time) (models.ValuationRunResult, error) {
+	result := models.ValuationRunResult{Dates: 1}
+	err := s.valueDate(ctx, valuationDate(now), true, &result)
+	return result, err
+}
+
+// Backfill values every day from from to to, both inclusive, from the
+// holdings snapshots. Days without a captured cash balance get the one
+// captured closest to them and are marked as estimated.
+func (s *ValuationService) Backfill(ctx context.Context, from, to time.Time) (models.ValuationRunResult, error) {
+	var result models.ValuationRunResult
+	from, to = valuationDate(from), valuationDate(to)
+	if from.After(to) {
+		return result, fmt.Errorf("backfill start %s is after end %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		if err := s.valueDate(ctx, date, false, &result); err != nil {
+			return result, err
+		}
+		result.Dates++
+	}
+	return result, nil
+}
+
+func (s *ValuationService) valueDate(ctx context.Context, date time.Time, current bool, result *models.ValuationRunResult) error {
+	accounts, err := s.repo.GetAccounts(ctx, date)
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		valuation, err := s.valueAccount(ctx, account, date, current)
+		if err == nil {
+			err = s.repo.UpsertValuation(ctx, valuation)
+		}
+		if err != nil {
+			log.Printf("Valuation of account %s on %s failed: %v", account.ID, date.Format("2006-01-02"), err)
+			result.Failures++
+			continue
+		}
+		result.AccountsValued++
+	}
+	return nil
+}
+
+func (s *ValuationService) valueAccount(ctx context.Context, account models.ValuationAccount, date time.Time, current bool) (models.AccountValuation, error) {
+	valuation := models.AccountValuation{AccountID: account.ID, SnapshotDate: date, Currency: account.Currency}
+
+	var positions []models.PricedPosition
+	var err error
+	if current {
+		positions, err = s.repo.GetCurrentPositions(ctx, account.ID, date)
+	} else {
+		positions, err = s.repo.GetSnapshotPositions(ctx, account.ID, date)
+	}
+	if err != nil {
+		return valuation, err
+	}
+
+	rates := map[string]decimal.Decimal{}
+	securities := decimal.Zero
+	for _, position := range positions {
+		rate, ok := rates[position.Currency]
+		if !ok {
+			rate, err = s.conversionRate(ctx, position.Currency, account.Currency, date)
+			if err != nil {
+				return valuation, fmt.Errorf("asset %s: %w", position.AssetID, err)
+			}
+			rates[position.Currency] = rate
+		}
+		securities = securities.Add(position.Quantity.Mul(position.Price).Mul(rate))
+	}
+
+	cash := account.AccountBalance
+	if !current {
+		captured, capturedOn, ok, err := s.repo.GetCapturedCash(ctx, account.ID, date)
+		if err != nil {
+			return valuation, err
+		}
+		if ok {
+			cash = captured
+		}
+		valuation.CashEstimated = !ok || !capturedOn.Equal(date)
+	}
+
+	valuation.SecuritiesValue = securities.Round(2)
+	valuation.CashValue = cash.Round(2)
+	valuation.TotalValue = valuation.SecuritiesValue.Add(valuation.CashValue)
+	return valuation, nil
+}
+
+// conversionRate returns the rate that turns an amount in from into an
+// amount in to. Assets without a currency are taken to be quoted in the
+// account currency.
+func (s *ValuationService) conversionRate(ctx context.Context, from, to string, date time.Time) (decimal.Decimal, error) {
+	if from == "" || from == to {
+		return decimal.NewFromInt(1), nil
+	}
+	rate, err := s.repo.GetFxRate(ctx, from, to, date)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("%s/%s on %s: %w", from, to, date.Format("2006-01-02"), err)
+	}
+	return rate, nil
+}
+
+func valuationDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"sort"
+	"thyra/internal/analytics/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// LookThrough splits a fund holding's value over the fund's constituents.
+// Whatever the constituent weights leave uncovered stays with the fund's
+// own classification.
+func LookThrough(value decimal.Decimal, fund models.Classification, constituents []models.ConstituentExposure) []models.Exposure {
+	exposures := make([]models.Exposure, 0, len(constituents)+1)
+	remaining := value
+	for _, constituent := range constituents {
+		share := value.Mul(decimal.NewFromFloat(constituent.Weight))
+		exposures = append(exposures, models.Exposure{Value: share, Classification: constituent.Classification})
+		remaining = remaining.Sub(share)
+	}
+	if !remaining.IsZero() {
+		exposures = append(exposures, models.Exposure{Value: remaining, Classification: fund})
+	}
+	return exposures
+}
+
+// GroupExposures adds up the exposures by one dimension of their
+// classification, largest first, with each group's weight in percent of
+// total.
+func GroupExposures(exposures []models.Exposure, dimension string, total decimal.Decimal) []models.AllocationBucket {
+	values := map[string]decimal.Decimal{}
+	for _, exposure := range exposures {
+		name := classificationName(exposure.Classification, dimension)
+		values[name] = values[name].Add(exposure.Value)
+	}
+
+	buckets := make([]models.AllocationBucket, 0, len(values))
+	for name, value := range values {
+		bucket := models.AllocationBucket{Name: name, Value: value.Round(2)}
+		if !total.IsZero() {
+			bucket.Weight, _ = value.Div(total).Mul(decimal.NewFromInt(100)).Round(4).Float64()
+		}
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if !buckets[i].Value.Equal(buckets[j].Value) {
+			return buckets[i].Value.GreaterThan(buckets[j].Value)
+		}
+		return buckets[i].Name < buckets[j].Name
+	})
+	return buckets
+}
+
+func classificationName(classification models.Classification, dimension string) string {
+	var name string
+	switch dimension {
+	case models.AllocationBySector:
+		name = classification.Sector
+	case models.AllocationByCountry:
+		name = classification.Country
+	case models.AllocationByCurrency:
+		name = classification.Currency
+	case models.AllocationByAssetType:
+		name = classification.AssetType
+	}
+	if name == "" {
+		return models.AllocationUnclassified
+	}
+	return name
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"errors"
+	"sort"
+	"thyra/internal/analytics/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrMissingBenchmarkPrice = errors.New("a benchmark component has no price on or before the start date")
+
+// BenchmarkLevels computes the benchmark's index level, starting at 1 on the
+// start date, for every date up to end on which a component has a price.
+// Components hold their units between rebalances, so weights drift with
+// prices; on the first price date of each new rebalance period the units
+// are reset to the target weights. Missing prices carry the last known
+// price forward. prices must hold each component's prices oldest first,
+// including its last price on or before start.
+func BenchmarkLevels(components []models.BenchmarkComponent, prices map[uuid.UUID][]models.PricePoint, frequency string, start, end time.Time) ([]models.Valuation, error) {
+	current := make([]float64, len(components))
+	positions := make([]int, len(components))
+	dateSet := map[time.Time]bool{}
+	for i, component := range components {
+		series := prices[component.AssetID]
+		for positions[i] < len(series) && !series[positions[i]].Date.After(start) {
+			current[i] = series[positions[i]].Price
+			positions[i]++
+		}
+		if current[i] <= 0 {
+			return nil, ErrMissingBenchmarkPrice
+		}
+		for _, point := range series[positions[i]:] {
+			if !point.Date.After(end) {
+				dateSet[point.Date] = true
+			}
+		}
+	}
+	dates := make([]time.Time, 0, len(dateSet))
+	for date := range dateSet {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	units := make([]float64, len(components))
+	rebalance := func(level float64) {
+		for i, component := range components {
+			units[i] = component.Weight * level / current[i]
+		}
+	}
+	rebalance(1)
+	period := rebalancePeriod(start, frequency)
+
+	levels := []models.Valuation{{Date: start, Value: 1}}
+	for _, date := range dates {
+		for i, component := range components {
+			series := prices[component.AssetID]
+			for positions[i] < len(series) && !series[positions[i]].Date.After(date) {
+				if series[positions[i]].Price > 0 {
+					current[i] = series[positions[i]].Price
+				}
+				positions[i]++
+			}
+		}
+
+		level := 0.0
+		for i := range components {
+			level += units[i] * current[i]
+		}
+		if frequency != models.RebalanceNone {
+			if next := rebalancePeriod(date, frequency); next != period {
+				rebalance(level)
+				period = next
+			}
+		}
+		levels = append(levels, models.Valuation{Date: date, Value: level})
+	}
+	return levels, nil
+}
+
+// LevelOn returns the last level on or before the date, or false when the
+// series starts after it.
+func LevelOn(levels []models.Valuation, date time.Time) (float64, bool) {
+	i := sort.Search(len(levels), func(i int) bool { return levels[i].Date.After(date) }) - 1
+	if i < 0 {
+		return 0, false
+	}
+	return levels[i].Value, true
+}
+
+func rebalancePeriod(date time.Time, frequency string) int {
+	switch frequency {
+	case models.RebalanceDaily:
+		return int(date.Unix() / 86400)
+	case models.RebalanceMonthly:
+		return date.Year()*12 + int(date.Month())
+	case models.RebalanceQuarterly:
+		return date.Year()*4 + (int(date.Month())-1)/3
+	case models.RebalanceAnnually:
+		return date.Year()
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"thyra/internal/analytics/models"
+	"time"
+)
+
+const (
+	daysPerYear     = 365.0
+	irrTolerance    = 1e-10
+	irrMaxIteration = 100
+	irrLowerBound   = -0.999999
+	irrUpperBound   = 1000.0
+)
+
+// TimeWeightedReturn chains the returns of the sub-periods between
+// consecutive valuations.
+func TimeWeightedReturn(valuations []models.Valuation, flows []models.CashFlow) float64 {
+	growth := 1.0
+	for _, subPeriod := range SubPeriodReturns(valuations, flows) {
+		growth *= 1 + subPeriod.Return
+	}
+	return growth - 1
+}
+
+// SubPeriodReturns returns the return between each pair of consecutive
+// valuations. A cash flow is taken to arrive at the end of the day it is
+// booked on, so it is part of that day's closing value and is removed before
+// the return is measured. When the opening value is zero, as on the day of a
+// first deposit, the flow is the base instead; sub-periods with nothing
+// invested are left out.
+func SubPeriodReturns(valuations []models.Valuation, flows []models.CashFlow) []models.SubPeriodReturn {
+	var returns []models.SubPeriodReturn
+	next := 0
+	for i := 1; i < len(valuations); i++ {
+		previous, current := valuations[i-1], valuations[i]
+		flow := 0.0
+		for next < len(flows) && !flows[next].Date.After(current.Date) {
+			if flows[next].Date.After(previous.Date) {
+				flow += flows[next].Amount
+			}
+			next++
+		}
+
+		var subReturn float64
+		switch {
+		case previous.Value > 0:
+			subReturn = (current.Value-flow)/previous.Value - 1
+		case previous.Value+flow > 0:
+			subReturn = current.Value/(previous.Value+flow) - 1
+		default:
+			continue
+		}
+		returns = append(returns, models.SubPeriodReturn{Start: previous.Date, End: current.Date, Return: subReturn})
+	}
+	return returns
+}
+
+// MoneyWeightedReturn solves for the internal rate of return over the period
+// from start to end, treating the start value as invested at the start and
+// the end value as withdrawn at the end. The rate is for the whole period;
+// use Annualize for a yearly rate. It reports false when no rate solves the
+// cash flows.
+func MoneyWeightedReturn(start, end models.Valuation, flows []models.CashFlow) (float64, bool) {
+	days := daysBetween(start.Date, end.Date)
+	if days <= 0 {
+		return 0, true
+	}
+
+	type discountedFlow struct {
+		amount   float64
+		fraction float64
+	}
+	cashFlows := []discountedFlow{{amount: -start.Value}, {amount: end.Value, fraction: 1}}
+	for _, flow := range flows {
+		cashFlows = append(cashFlows, discountedFlow{amount: -flow.Amount, fraction: daysBetween(start.Date, flow.Date) / days})
+	}
+
+	npv := func(rate float64) (float64, float64) {
+		value, derivative := 0.0, 0.0
+		for _, cf := range cashFlows {
+			discount := math.Pow(1+rate, -cf.fraction)
+			value += cf.amount * discount
+			derivative -= cf.fraction * cf.amount * discount / (1 + rate)
+		}
+		return value, derivative
+	}
+
+	// Newton's method converges quickly from the simple return in the
+	// usual case; bisection takes over when it leaves the valid range.
+	rate := 0.0
+	if start.Value > 0 {
+		rate = (end.Value - start.Value) / start.Value
+	}
+	for i := 0; i < irrMaxIteration; i++ {
+		value, derivative := npv(rate)
+		if math.Abs(value) < irrTolerance {
+			return rate, true
+		}
+		if derivative == 0 {
+			break
+		}
+		rate -= value / derivative
+		if rate <= irrLowerBound || rate > irrUpperBound || math.IsNaN(rate) {
+			break
+		}
+	}
+
+	low, high := irrLowerBound, irrUpperBound
+	lowValue, _ := npv(low)
+	highValue, _ := npv(high)
+	if math.IsNaN(lowValue) || math.IsNaN(highValue) || lowValue*highValue > 0 {
+		return 0, false
+	}
+	for i := 0; i < 4*irrMaxIteration; i++ {
+		mid := (low + high) / 2
+		midValue, _ := npv(mid)
+		if math.Abs(midValue) < irrTolerance || high-low < irrTolerance {
+			return mid, true
+		}
+		if lowValue*midValue < 0 {
+			high = mid
+		} else {
+			low, lowValue = mid, midValue
+		}
+	}
+	return (low + high) / 2, true
+}
+
+// Annualize converts a return over the period from start to end into a
+// yearly rate. It reports false for periods shorter than a year, where the
+// result would overstate the return.
+func Annualize(periodReturn float64, start, end time.Time) (float64, bool) {
+	days := daysBetween(start, end)
+	if days < daysPerYear || periodReturn <= -1 {
+		return 0, false
+	}
+	return math.Pow(1+periodReturn, daysPerYear/days) - 1, true
+}
+
+// MergeValuations adds up the valuation series of several accounts. Each
+// account contributes its latest value on or before every date, so a
+// missing snapshot in one account does not drop its value from the total.
+func MergeValuations(series ...[]models.Valuation) []models.Valuation {
+	dateSet := map[time.Time]bool{}
+	for _, valuations := range series {
+		for _, valuation := range valuations {
+			dateSet[valuation.Date] = true
+		}
+	}
+	dates := make([]time.Time, 0, len(dateSet))
+	for date := range dateSet {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	merged := make([]models.Valuation, len(dates))
+	positions := make([]int, len(series))
+	for i, date := range dates {
+		merged[i].Date = date
+		for s, valuations := range series {
+			for positions[s] < len(valuations) && !valuations[positions[s]].Date.After(date) {
+				positions[s]++
+			}
+			if positions[s] > 0 {
+				merged[i].Value += valuations[positions[s]-1].Value
+			}
+		}
+	}
+	return merged
+}
+
+func daysBetween(start, end time.Time) float64 {
+	return end.Sub(start).Hours() / 24
+}
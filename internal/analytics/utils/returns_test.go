@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"thyra/internal/analytics/models"
+	"time"
+)
+
+const tolerance = 1e-9
+
+func day(n int) time.Time {
+	return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+func valuation(n int, value float64) models.Valuation {
+	return models.Valuation{Date: day(n), Value: value}
+}
+
+func cashFlow(n int, amount float64) models.CashFlow {
+	return models.CashFlow{Date: day(n), Amount: amount}
+}
+
+func TestTimeWeightedReturn(t *testing.T) {
+	tests := []struct {
+		name       string
+		valuations []models.Valuation
+		flows      []models.CashFlow
+		want       float64
+	}{
+		{
+			name:       "no flows",
+			valuations: []models.Valuation{valuation(0, 100), valuation(1, 110), valuation(2, 99)},
+			want:       -0.01,
+		},
+		{
+			name:       "deposit is removed from the closing value",
+			valuations: []models.Valuation{valuation(0, 100), valuation(1, 110), valuation(2, 160)},
+			flows:      []models.CashFlow{cashFlow(2, 50)},
+			want:       0.1,
+		},
+		{
+			name:       "withdrawal is added back",
+			valuations: []models.Valuation{valuation(0, 100), valuation(1, 60)},
+			flows:      []models.CashFlow{cashFlow(1, -50)},
+			want:       0.1,
+		},
+		{
+			name:       "first deposit is the base",
+			valuations: []models.Valuation{valuation(0, 0), valuation(1, 100), valuation(2, 120)},
+			flows:      []models.CashFlow{cashFlow(1, 100)},
+			want:       0.2,
+		},
+		{
+			name:       "flows before the first valuation are ignored",
+			valuations: []models.Valuation{valuation(1, 100), valuation(2, 105)},
+			flows:      []models.CashFlow{cashFlow(0, 1000)},
+			want:       0.05,
+		},
+		{
+			name:       "nothing invested",
+			valuations: []models.Valuation{valuation(0, 0), valuation(1, 0)},
+			want:       0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TimeWeightedReturn(tt.valuations, tt.flows); math.Abs(got-tt.want) > tolerance {
+				t.Errorf("TimeWeightedReturn = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyWeightedReturn(t *testing.T) {
+	// A deposit of 100 halfway through on top of 100 growing to 210 solves
+	// 210x² - 100x - 100 = 0 for x = (1+r)^-½.
+	x := (100 + math.Sqrt(100*100+4*210*100)) / (2 * 210)
+	midDepositRate := 1/(x*x) - 1
+
+	tests := []struct {
+		name   string
+		start  models.Valuation
+		end    models.Valuation
+		flows  []models.CashFlow
+		want   float64
+		solved bool
+	}{
+		{
+			name:   "no flows is the simple return",
+			start:  valuation(0, 100),
+			end:    valuation(20, 110),
+			want:   0.1,
+			solved: true,
+		},
+		{
+			name:   "deposit halfway",
+			start:  valuation(0, 100),
+			end:    valuation(20, 210),
+			flows:  []models.CashFlow{cashFlow(10, 100)},
+			want:   midDepositRate,
+			solved: true,
+		},
+		{
+			name:   "near total loss",
+			start:  valuation(0, 100),
+			end:    valuation(20, 1),
+			want:   -0.99,
+			solved: true,
+		},
+		{
+			name:   "empty period",
+			start:  valuation(5, 100),
+			end:    valuation(5, 120),
+			want:   0,
+			solved: true,
+		},
+		{
+			name:   "no sign change when everything is lost",
+			start:  valuation(0, 100),
+			end:    valuation(20, 0),
+			flows:  []models.CashFlow{cashFlow(10, 50)},
+			solved: false,
+		},
+		{
+			name:   "no sign change from nothing invested",
+			start:  valuation(0, 0),
+			end:    valuation(20, 100),
+			solved: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, solved := MoneyWeightedReturn(tt.start, tt.end, tt.flows)
+			if solved != tt.solved {
+				t.Fatalf("MoneyWeightedReturn solved = %v, want %v (rate %v)", solved, tt.solved, got)
+			}
+			if solved && math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("MoneyWeightedReturn = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnualize(t *testing.T) {
+	tests := []struct {
+		name         string
+		periodReturn float64
+		days         int
+		want         float64
+		ok           bool
+	}{
+		{"two years", 0.21, 730, 0.1, true},
+		{"exactly a year", 0.05, 365, 0.05, true},
+		{"shorter than a year", 0.05, 364, 0, false},
+		{"total loss", -1, 730, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Annualize(tt.periodReturn, day(0), day(tt.days))
+			if ok != tt.ok {
+				t.Fatalf("Annualize ok = %v, want %v", ok, tt.ok)
+			}
+			if math.Abs(got-tt.want) > tolerance {
+				t.Errorf("Annualize = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeValuations(t *testing.T) {
+	merged := MergeValuations(
+		[]models.Valuation{valuation(0, 100), valuation(1, 110), valuation(2, 120)},
+		[]models.Valuation{valuation(1, 50), valuation(3, 70)},
+	)
+	want := []models.Valuation{valuation(0, 100), valuation(1, 160), valuation(2, 170), valuation(3, 190)}
+	if len(merged) != len(want) {
+		t.Fatalf("got %d valuations, want %d: %v", len(merged), len(want), merged)
+	}
+	for i := range want {
+		if !merged[i].Date.Equal(want[i].Date) || merged[i].Value != want[i].Value {
+			t.Errorf("valuation %d = %v, want %v", i, merged[i], want[i])
+		}
+	}
+}
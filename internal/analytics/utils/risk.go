@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"thyra/internal/analytics/models"
+	"time"
+)
+
+// MaxDrawdown returns the largest fall of the growth index built from the
+// sub-period returns, as a positive fraction, with the dates of the peak
+// before it and of the trough. recovery is the first date the index is back
+// at the peak, or nil if it has not recovered. ok is false if the index never
+// falls.
+func MaxDrawdown(subPeriods []models.SubPeriodReturn) (drawdown float64, peak, trough time.Time, recovery *time.Time, ok bool) {
+	if len(subPeriods) == 0 {
+		return 0, time.Time{}, time.Time{}, nil, false
+	}
+	levels := make([]float64, len(subPeriods))
+	level, peakLevel, drawdownPeakLevel := 1.0, 1.0, 1.0
+	peakDate, troughIndex := subPeriods[0].Start, 0
+	for i, subPeriod := range subPeriods {
+		level *= 1 + subPeriod.Return
+		levels[i] = level
+		if level >= peakLevel {
+			peakLevel, peakDate = level, subPeriod.End
+			continue
+		}
+		if fall := 1 - level/peakLevel; fall > drawdown {
+			drawdown, peak, trough, ok = fall, peakDate, subPeriod.End, true
+			drawdownPeakLevel, troughIndex = peakLevel, i
+		}
+	}
+	if !ok {
+		return 0, time.Time{}, time.Time{}, nil, false
+	}
+
+	for i := troughIndex + 1; i < len(levels); i++ {
+		if levels[i] >= drawdownPeakLevel {
+			date := subPeriods[i].End
+			recovery = &date
+			break
+		}
+	}
+	return drawdown, peak, trough, recovery, true
+}
+
+// HistoricalVaR returns the loss exceeded by only 1-confidence of the
+// observed returns, and the mean loss over those worst returns, both as
+// positive fractions.
+func HistoricalVaR(returns []float64, confidence float64) (valueAtRisk, expectedShortfall float64) {
+	if len(returns) == 0 {
+		return 0, 0
+	}
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	// The epsilon keeps 1-confidence, which is rarely exact in binary, from
+	// flooring 10 × 0.1 to 0.
+	tail := int(math.Floor(float64(len(sorted))*(1-confidence) + 1e-9))
+	if tail < 1 {
+		tail = 1
+	}
+	return -sorted[tail-1], -Mean(sorted[:tail])
+}
+
+// ParametricVaR returns value at risk and expected shortfall at the given
+// confidence assuming normally distributed returns with the sample mean and
+// standard deviation.
+func ParametricVaR(returns []float64, confidence float64) (valueAtRisk, expectedShortfall float64) {
+	mean, stdDev := Mean(returns), StdDev(returns)
+	z := NormalQuantile(confidence)
+	return z*stdDev - mean, stdDev*NormalDensity(z)/(1-confidence) - mean
+}
+
+// Beta returns the slope of the portfolio returns against the benchmark
+// returns. ok is false when the benchmark did not move.
+func Beta(portfolio, benchmark []float64) (float64, bool) {
+	variance := StdDev(benchmark) * StdDev(benchmark)
+	if variance == 0 {
+		return 0, false
+	}
+	return Covariance(portfolio, benchmark) / variance, true
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"thyra/internal/analytics/models"
+	"time"
+)
+
+func subPeriods(returns ...float64) []models.SubPeriodReturn {
+	periods := make([]models.SubPeriodReturn, len(returns))
+	for i, r := range returns {
+		periods[i] = models.SubPeriodReturn{Start: day(i), End: day(i + 1), Return: r}
+	}
+	return periods
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	dayPtr := func(n int) *time.Time {
+		d := day(n)
+		return &d
+	}
+
+	tests := []struct {
+		name     string
+		returns  []float64
+		drawdown float64
+		peak     time.Time
+		trough   time.Time
+		recovery *time.Time
+		ok       bool
+	}{
+		{
+			name:     "fall from the start",
+			returns:  []float64{-0.2, 0.1},
+			drawdown: 0.2,
+			peak:     day(0),
+			trough:   day(1),
+			ok:       true,
+		},
+		{
+			name:     "recovers to the peak",
+			returns:  []float64{0.1, -0.2, 0.3, -0.05},
+			drawdown: 0.2,
+			peak:     day(1),
+			trough:   day(2),
+			recovery: dayPtr(3),
+			ok:       true,
+		},
+		{
+			name:     "deeper fall after a new peak",
+			returns:  []float64{0.1, -0.2, 0.1, 0.3, -0.5},
+			drawdown: 0.5,
+			peak:     day(4),
+			trough:   day(5),
+			ok:       true,
+		},
+		{
+			name:     "smaller fall after a new peak keeps the first",
+			returns:  []float64{-0.5, 2, -0.1},
+			drawdown: 0.5,
+			peak:     day(0),
+			trough:   day(1),
+			recovery: dayPtr(2),
+			ok:       true,
+		},
+		{
+			name:    "never falls",
+			returns: []float64{0.1, 0, 0.05},
+			ok:      false,
+		},
+		{
+			name: "no periods",
+			ok:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drawdown, peak, trough, recovery, ok := MaxDrawdown(subPeriods(tt.returns...))
+			if ok != tt.ok {
+				t.Fatalf("MaxDrawdown ok = %v, want %v", ok, tt.ok)
+			}
+			if math.Abs(drawdown-tt.drawdown) > tolerance {
+				t.Errorf("drawdown = %v, want %v", drawdown, tt.drawdown)
+			}
+			if !peak.Equal(tt.peak) || !trough.Equal(tt.trough) {
+				t.Errorf("peak, trough = %s, %s, want %s, %s", peak, trough, tt.peak, tt.trough)
+			}
+			switch {
+			case recovery == nil && tt.recovery == nil:
+			case recovery == nil || tt.recovery == nil || !recovery.Equal(*tt.recovery):
+				t.Errorf("recovery = %v, want %v", recovery, tt.recovery)
+			}
+		})
+	}
+}
+
+func TestHistoricalVaR(t *testing.T) {
+	returns := []float64{0.01, -0.03, 0.02, -0.08, 0.00, -0.01, 0.04, -0.05, 0.03, -0.02}
+	hundred := make([]float64, 100)
+	for i := range hundred {
+		hundred[i] = -float64(i) / 100
+	}
+
+	tests := []struct {
+		name              string
+		returns           []float64
+		confidence        float64
+		valueAtRisk       float64
+		expectedShortfall float64
+	}{
+		{"worst of ten", returns, 0.9, 0.08, 0.08},
+		{"second worst of ten", returns, 0.8, 0.05, 0.065},
+		{"tail never empty", returns, 0.99, 0.08, 0.08},
+		{"tail of a hundred", hundred, 0.9, 0.9, 0.945},
+		{"no returns", nil, 0.95, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valueAtRisk, expectedShortfall := HistoricalVaR(tt.returns, tt.confidence)
+			if math.Abs(valueAtRisk-tt.valueAtRisk) > tolerance {
+				t.Errorf("value at risk = %v, want %v", valueAtRisk, tt.valueAtRisk)
+			}
+			if math.Abs(expectedShortfall-tt.expectedShortfall) > tolerance {
+				t.Errorf("expected shortfall = %v, want %v", expectedShortfall, tt.expectedShortfall)
+			}
+		})
+	}
+}
+
+func TestParametricVaR(t *testing.T) {
+	// Mean 0 and sample standard deviation 0.01.
+	returns := []float64{0.01, -0.01, 0.01, -0.01}
+	stdDev := 0.01 * math.Sqrt(4.0/3)
+
+	valueAtRisk, expectedShortfall := ParametricVaR(returns, 0.95)
+	if want := 1.6448536269514722 * stdDev; math.Abs(valueAtRisk-want) > tolerance {
+		t.Errorf("value at risk = %v, want %v", valueAtRisk, want)
+	}
+	if want := 2.0627128075074257 * stdDev; math.Abs(expectedShortfall-want) > tolerance {
+		t.Errorf("expected shortfall = %v, want %v", expectedShortfall, want)
+	}
+}
+
+func TestBeta(t *testing.T) {
+	tests := []struct {
+		name      string
+		portfolio []float64
+		benchmark []float64
+		want      float64
+		ok        bool
+	}{
+		{"moves twice as much", []float64{0.02, -0.04, 0.06}, []float64{0.01, -0.02, 0.03}, 2, true},
+		{"moves against", []float64{-0.01, 0.02, -0.03}, []float64{0.01, -0.02, 0.03}, -1, true},
+		{"flat benchmark", []float64{0.01, 0.02, 0.03}, []float64{0.01, 0.01, 0.01}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Beta(tt.portfolio, tt.benchmark)
+			if ok != tt.ok {
+				t.Fatalf("Beta ok = %v, want %v", ok, tt.ok)
+			}
+			if math.Abs(got-tt.want) > tolerance {
+				t.Errorf("Beta = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import "math"
+
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev returns the sample standard deviation, or zero for fewer than two
+// values.
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := Mean(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += (value - mean) * (value - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// PeriodsPerYear estimates how many observations of a series fall in a year,
+// given the observation count and the days the series spans.
+func PeriodsPerYear(observations int, days float64) float64 {
+	if observations == 0 || days <= 0 {
+		return 0
+	}
+	return float64(observations) * daysPerYear / days
+}
+
+// Covariance returns the sample covariance of two series of equal length, or
+// zero for fewer than two pairs.
+func Covariance(x, y []float64) float64 {
+	if len(x) < 2 || len(x) != len(y) {
+		return 0
+	}
+	meanX, meanY := Mean(x), Mean(y)
+	sum := 0.0
+	for i := range x {
+		sum += (x[i] - meanX) * (y[i] - meanY)
+	}
+	return sum / float64(len(x)-1)
+}
+
+// NormalQuantile returns the value below which the given share of a standard
+// normal distribution falls.
+func NormalQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
+
+// NormalDensity returns the standard normal probability density at x.
+func NormalDensity(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
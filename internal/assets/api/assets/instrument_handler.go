@@ -6,6 +6,7 @@ import (
 	"thyra/internal/assets/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AssetsHandler struct {
@@ -33,6 +34,34 @@ func (h *AssetsHandler) CreateInstrument(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Instrument created successfully", "instrument": createdInstrument})
 }
 
+func (h *AssetsHandler) UpdateQuantityPrecision(c *gin.Context) {
+	instrumentID, err := uuid.Parse(c.Param("instrumentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid instrument ID in URL"})
+		return
+	}
+	var req models.QuantityPrecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userRole, _ := c.Get("userType")
+	err = h.service.UpdateQuantityPrecision(c.Request.Context(), instrumentID, *req.QuantityPrecision, userRole.(string))
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Instrument precision updated successfully", "quantity_precision": *req.QuantityPrecision})
+	case services.ErrInvalidQuantityPrecision:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrInstrumentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrPrecisionAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (h *AssetsHandler) GetAllInstruments(c *gin.Context) {
 	userRole, _ := c.Get("userType")
 
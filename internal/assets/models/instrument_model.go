@@ -22,4 +22,11 @@ type Instrument struct {
 	CreatedAt      time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
 	UnifiedAssetID uuid.UUID `db:"unified_asset_id" json:"unified_asset_id"`
+	// QuantityPrecision is the number of decimals the instrument can be
+	// traded and held in; 0 means whole shares only.
+	QuantityPrecision int32 `db:"quantity_precision" json:"quantity_precision"`
+}
+
+type QuantityPrecisionRequest struct {
+	QuantityPrecision *int32 `json:"quantity_precision" binding:"required"`
 }
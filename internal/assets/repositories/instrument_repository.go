@@ -4,6 +4,7 @@ import (
 	"context"
 	"thyra/internal/assets/models"
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -17,11 +18,11 @@ func NewAssetsRepository(db *sqlx.DB) *AssetsRepository {
 
 func (r *AssetsRepository) CreateInstrument(ctx context.Context, instrument models.Instrument) (models.Instrument, error) {
 	query := `INSERT INTO assets (instrument_name, isin, ticker, exchange, currency, instrument_type, 
-                                 current_price, volume, country, sector, asset_type_id) 
+                                 current_price, volume, country, sector, asset_type_id, quantity_precision) 
               VALUES (:instrument_name, :isin, :ticker, :exchange, :currency, :instrument_type, 
-                      :current_price, :volume, :country, :sector, :asset_type_id)
+                      :current_price, :volume, :country, :sector, :asset_type_id, :quantity_precision)
               RETURNING id, instrument_name, isin, ticker, exchange, currency, 
-                        instrument_type, current_price, volume, country, sector, asset_type_id`
+                        instrument_type, current_price, volume, country, sector, asset_type_id, quantity_precision`
 
 	row, err := r.db.NamedQueryContext(ctx, query, instrument)
 	if err != nil {
@@ -41,6 +42,17 @@ func (r *AssetsRepository) GetAllInstruments(ctx context.Context) ([]models.Inst
 	return instruments, err
 }
 
+// UpdateQuantityPrecision sets the decimals the instrument can be traded in
+// and reports whether the instrument exists.
+func (r *AssetsRepository) UpdateQuantityPrecision(ctx context.Context, instrumentID uuid.UUID, precision int32) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE assets SET quantity_precision = $1, updated_at = NOW() WHERE id = $2", precision, instrumentID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
 func (r *AssetsRepository) GetAllAssetTypes(ctx context.Context) ([]models.Asset, error) {
 	var assets []models.Asset
 	query := "SELECT * FROM asset_types"
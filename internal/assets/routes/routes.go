@@ -11,5 +11,6 @@ func SetupRoutes(router *gin.RouterGroup, assetHandler *handlers.AssetsHandler)
 	router.GET("/instruments", assetHandler.GetAllInstruments)
 	router.GET("/types/asset", assetHandler.GetAllAssetTypes)
 	router.POST("/create/instruments", assetHandler.CreateInstrument)
+	router.PUT("/instruments/:instrumentId/quantity-precision", assetHandler.UpdateQuantityPrecision)
 
 }
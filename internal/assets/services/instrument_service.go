@@ -5,6 +5,17 @@ import (
 	"errors"
 	"thyra/internal/assets/models"
 	"thyra/internal/assets/repositories"
+
+	"github.com/google/uuid"
+)
+
+// MaxQuantityPrecision is the most decimals quantities are stored with.
+const MaxQuantityPrecision = 8
+
+var (
+	ErrInvalidQuantityPrecision = errors.New("quantity_precision must be between 0 and 8")
+	ErrInstrumentNotFound       = errors.New("instrument not found")
+	ErrPrecisionAccessDenied    = errors.New("only admins can change instrument precision")
 )
 
 type AssetsService struct {
@@ -20,9 +31,34 @@ func (s *AssetsService) CreateInstrument(ctx context.Context, instrument models.
 		return models.Instrument{}, errors.New("only admins can create instruments")
 	}
 
+	if instrument.QuantityPrecision < 0 || instrument.QuantityPrecision > MaxQuantityPrecision {
+		return models.Instrument{}, ErrInvalidQuantityPrecision
+	}
+
 	return s.repo.CreateInstrument(ctx, instrument)
 }
 
+// UpdateQuantityPrecision changes the decimals the instrument can be traded
+// in. Lowering it does not touch existing fractional holdings; they can
+// still be sold in full.
+func (s *AssetsService) UpdateQuantityPrecision(ctx context.Context, instrumentID uuid.UUID, precision int32, userRole string) error {
+	if userRole != "admin" {
+		return ErrPrecisionAccessDenied
+	}
+	if precision < 0 || precision > MaxQuantityPrecision {
+		return ErrInvalidQuantityPrecision
+	}
+
+	updated, err := s.repo.UpdateQuantityPrecision(ctx, instrumentID, precision)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrInstrumentNotFound
+	}
+	return nil
+}
+
 func (s *AssetsService) GetAllInstruments(ctx context.Context, userRole string) ([]models.Instrument, error) {
 	if userRole != "admin" {
 		return nil, errors.New("only admins can fetch all instruments")
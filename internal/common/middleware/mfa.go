@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"net/http"
+	"thyra/internal/common/db"
+	userutils "thyra/internal/users/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAStepUpWindow is how long a second factor verification is trusted for
+// sensitive operations such as withdrawals and order placement.
+const MFAStepUpWindow = 15 * time.Minute
+
+// MFAEnrollPath is the endpoint users without a second factor are sent to.
+const MFAEnrollPath = "/v1/v1/mfa/enroll"
+
+// MFAEnrollmentLookup reports whether the user has confirmed MFA enrollment.
+type MFAEnrollmentLookup func(c *gin.Context, userID string) (bool, error)
+
+// MFAEnrolled looks up the enrollment on the request's database connection.
+func MFAEnrolled(c *gin.Context, userID string) (bool, error) {
+	var enrolled bool
+	err := db.GetConnection(c).Get(&enrolled,
+		`SELECT EXISTS (SELECT 1 FROM thyrasec.user_mfa WHERE user_id = $1 AND enabled)`, userID)
+	return enrolled, err
+}
+
+// RequireMFA must run after TokenMiddleware. It rejects requests whose session
+// has not passed MFA within maxAge, signalling the client to call the step-up
+// endpoint and retry with the new token. Users who never enrolled are pointed
+// to the enrollment endpoint instead, since they cannot step up.
+func RequireMFA(maxAge time.Duration, clock userutils.Clock, enrolled MFAEnrollmentLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		verifiedAt := c.GetInt64("mfaVerifiedAt")
+		if verifiedAt != 0 && !clock.Now().After(time.Unix(verifiedAt, 0).Add(maxAge)) {
+			c.Next()
+			return
+		}
+
+		if verifiedAt == 0 {
+			ok, err := enrolled(c, c.GetString("userID"))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check MFA enrollment"})
+				c.Abort()
+				return
+			}
+			if !ok {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":                   "MFA enrollment required",
+					"mfa_enrollment_required": true,
+					"enroll_url":              MFAEnrollPath,
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "MFA step-up required", "mfa_required": true})
+		c.Abort()
+	}
+}
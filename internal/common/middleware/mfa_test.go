@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	userutils "thyra/internal/users/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	enrolled := func(c *gin.Context, userID string) (bool, error) { return true, nil }
+	notEnrolled := func(c *gin.Context, userID string) (bool, error) { return false, nil }
+	failing := func(c *gin.Context, userID string) (bool, error) { return false, errors.New("db down") }
+
+	tests := []struct {
+		name       string
+		verifiedAt time.Time
+		lookup     MFAEnrollmentLookup
+		wantStatus int
+		wantField  string
+	}{
+		{"verified just now", now, enrolled, http.StatusOK, ""},
+		{"verified at window edge", now.Add(-MFAStepUpWindow), enrolled, http.StatusOK, ""},
+		{"step-up expired", now.Add(-MFAStepUpWindow - time.Second), enrolled, http.StatusForbidden, "mfa_required"},
+		{"never verified but enrolled", time.Time{}, enrolled, http.StatusForbidden, "mfa_required"},
+		{"not enrolled", time.Time{}, notEnrolled, http.StatusForbidden, "mfa_enrollment_required"},
+		{"enrollment lookup fails", time.Time{}, failing, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/", func(c *gin.Context) {
+				c.Set("userID", "00000000-0000-0000-0000-000000000001")
+				if !tt.verifiedAt.IsZero() {
+					c.Set("mfaVerifiedAt", tt.verifiedAt.Unix())
+				}
+			}, RequireMFA(MFAStepUpWindow, userutils.FixedClock(now), tt.lookup), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantField == "" {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body[tt.wantField] != true {
+				t.Errorf("response %v lacks %s", body, tt.wantField)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package helpers
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
+	"thyra/internal/common/db"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
@@ -13,9 +16,30 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	UserType string `json:"user_type"` // Add this field
+	// Scope is only set on restricted tokens such as the MFA login challenge.
+	Scope         string `json:"scope,omitempty"`
+	MFAVerifiedAt int64  `json:"mfa_at,omitempty"`
 	jwt.StandardClaims
 }
 
+// PasswordChangeLookup returns when the user's password was last changed, or
+// nil if it never was.
+type PasswordChangeLookup func(c *gin.Context, userID string) (*time.Time, error)
+
+// PasswordChangedAt reads users.password_changed_at. Not every route group
+// runs DBContext before TokenMiddleware, so the shared connection is used.
+func PasswordChangedAt(c *gin.Context, userID string) (*time.Time, error) {
+	var changedAt sql.NullTime
+	err := db.GetDB().Get(&changedAt, `SELECT password_changed_at FROM thyrasec.users WHERE id = $1`, userID)
+	if err != nil || !changedAt.Valid {
+		return nil, err
+	}
+	return &changedAt.Time, nil
+}
+
+// passwordChanged is swapped out in tests.
+var passwordChanged PasswordChangeLookup = PasswordChangedAt
+
 // TokenMiddleware for JWT token validation
 func TokenMiddleware(c *gin.Context) {
 	// Extract the token from the cookie
@@ -46,10 +70,37 @@ func TokenMiddleware(c *gin.Context) {
 		return
 	}
 
+	// A login challenge token only proves the password, never accept it as a session
+	if claims.Scope != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is not a session token"})
+		c.Abort()
+		return
+	}
+
+	// A password reset revokes every session issued before it. The claim only
+	// has second precision, so tokens from the same second are still accepted.
+	changedAt, err := passwordChanged(c, claims.UserID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token user no longer exists"})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token revocation"})
+		c.Abort()
+		return
+	}
+	if changedAt != nil && claims.IssuedAt < changedAt.Unix() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token was revoked by a password change"})
+		c.Abort()
+		return
+	}
+
 	// Debug: Print out the extracted UserID to see if it's correctly parsed
 
 	c.Set("token", tokenString) // This will make the token available in the context
 	c.Set("username", claims.Username)
 	c.Set("userID", claims.UserID)
 	c.Set("userType", claims.UserType)
+	c.Set("mfaVerifiedAt", claims.MFAVerifiedAt)
 }
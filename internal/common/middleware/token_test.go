@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTokenMiddlewarePasswordChange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	issuedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	changedAt := func(at time.Time) PasswordChangeLookup {
+		return func(c *gin.Context, userID string) (*time.Time, error) { return &at, nil }
+	}
+	never := func(c *gin.Context, userID string) (*time.Time, error) { return nil, nil }
+	missing := func(c *gin.Context, userID string) (*time.Time, error) { return nil, sql.ErrNoRows }
+	failing := func(c *gin.Context, userID string) (*time.Time, error) { return nil, errors.New("db down") }
+
+	tests := []struct {
+		name       string
+		lookup     PasswordChangeLookup
+		wantStatus int
+	}{
+		{"password never changed", never, http.StatusOK},
+		{"changed before issue", changedAt(issuedAt.Add(-time.Hour)), http.StatusOK},
+		{"changed in the same second", changedAt(issuedAt.Add(500 * time.Millisecond)), http.StatusOK},
+		{"changed after issue", changedAt(issuedAt.Add(time.Second)), http.StatusUnauthorized},
+		{"user deleted", missing, http.StatusUnauthorized},
+		{"lookup fails", failing, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passwordChanged = tt.lookup
+			defer func() { passwordChanged = PasswordChangedAt }()
+
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+				UserID:         "00000000-0000-0000-0000-000000000001",
+				StandardClaims: jwt.StandardClaims{IssuedAt: issuedAt.Unix()},
+			})
+			signed, err := token.SignedString([]byte("LKJSDFS878dfsdLHLF$lkajd"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			router := gin.New()
+			router.GET("/", TokenMiddleware, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: signed})
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
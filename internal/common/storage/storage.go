@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps opaque blobs such as uploaded documents. Keys are slash
+// separated relative paths chosen by the caller, never by the client.
+type Storage interface {
+	Save(key string, r io.Reader) (int64, error)
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// NewStorageFromEnv returns local disk storage rooted at DOCUMENT_STORAGE_DIR,
+// falling back to ./data/documents.
+func NewStorageFromEnv() (Storage, error) {
+	root := os.Getenv("DOCUMENT_STORAGE_DIR")
+	if root == "" {
+		root = "./data/documents"
+	}
+	return NewLocalStorage(root)
+}
@@ -2,6 +2,11 @@ package utils
 
 import (
 	"database/sql"
+	"log"
+	"os"
+	"thyra/internal/common/mailer"
+	"thyra/internal/common/storage"
+
 	accounthandler "thyra/internal/accounts/api/accounts"
 	accountrepo "thyra/internal/accounts/repositories"
 	accountroutes "thyra/internal/accounts/routes"
@@ -12,20 +17,59 @@ import (
 	assetroutes "thyra/internal/assets/routes"
 	assetservice "thyra/internal/assets/services"
 
+	compliancehandlers "thyra/internal/compliance/api/compliance"
+	compliancerepo "thyra/internal/compliance/repositories"
+	complianceroutes "thyra/internal/compliance/routes"
+	complianceservices "thyra/internal/compliance/services"
+
 	analyticshandler "thyra/internal/analytics/api/performance"
 	analyticsrepo "thyra/internal/analytics/repositories/performance"
 	analyticsroutes "thyra/internal/analytics/routes"
 	analyticsservice "thyra/internal/analytics/services/performance"
 
+	jobhandlers "thyra/internal/jobs/api/jobs"
+	jobrepo "thyra/internal/jobs/repositories"
+	jobroutes "thyra/internal/jobs/routes"
+	jobservices "thyra/internal/jobs/services"
+
+	onboardinghandlers "thyra/internal/onboarding/api/onboarding"
+	onboardingrepo "thyra/internal/onboarding/repositories"
+	onboardingroutes "thyra/internal/onboarding/routes"
+	onboardingservices "thyra/internal/onboarding/services"
+
+	orderrepo "thyra/internal/orders/repositories"
+	orderservices "thyra/internal/orders/services"
+	portfoliohandlers "thyra/internal/portfolios/api/portfolios"
+	portfoliorepo "thyra/internal/portfolios/repositories"
+	portfolioroutes "thyra/internal/portfolios/routes"
+	portfolioservices "thyra/internal/portfolios/services"
+
+	savingshandlers "thyra/internal/savings/api/savings"
+	savingsrepo "thyra/internal/savings/repositories"
+	savingsroutes "thyra/internal/savings/routes"
+	savingsservices "thyra/internal/savings/services"
+
 	positionshandlers "thyra/internal/positions/api"
 	positionsrepo "thyra/internal/positions/repositories"
 	positionsroutes "thyra/internal/positions/routes"
 	positionsservices "thyra/internal/positions/services"
+	positionsutils "thyra/internal/positions/utils"
+
+	taxhandlers "thyra/internal/tax/api/tax"
+	taxrepo "thyra/internal/tax/repositories"
+	taxroutes "thyra/internal/tax/routes"
+	taxservices "thyra/internal/tax/services"
+
+	transferhandlers "thyra/internal/transfers/api/transfers"
+	transferrepo "thyra/internal/transfers/repositories"
+	transferroutes "thyra/internal/transfers/routes"
+	transferservices "thyra/internal/transfers/services"
 
 	userhandlers "thyra/internal/users/api/users"
 	userrepo "thyra/internal/users/repositories"
 	usersroutes "thyra/internal/users/routes"
 	userservices "thyra/internal/users/services"
+	userutils "thyra/internal/users/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -43,8 +87,24 @@ func InitializeAccountModule(dbx *sqlx.DB, db *sql.DB, router *gin.RouterGroup)
 	accountService := accountservices.NewAccountService(accountRepo)
 	accountHandler := accounthandler.NewAccountHandler(accountService)
 
+	// Closing accounts can sell off remaining holdings through the orders module
+	liquidator := orderservices.NewOrdersService(dbx, orderrepo.NewOrdersRepository(dbx),
+		complianceservices.NewPreTradeService(dbx, compliancerepo.NewPreTradeRepository(dbx)))
+	lifecycleService := accountservices.NewAccountLifecycleService(accountrepo.NewAccountStatusRepository(dbx), liquidator)
+	lifecycleHandler := accounthandler.NewAccountLifecycleHandler(lifecycleService)
+
+	interestService := accountservices.NewInterestServiceFromEnv(accountrepo.NewInterestRepository(dbx))
+	interestHandler := accounthandler.NewInterestHandler(interestService)
+
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+	marginService := accountservices.NewMarginServiceFromEnv(accountrepo.NewMarginRepository(dbx), liquidator, mail)
+	marginHandler := accounthandler.NewMarginHandler(marginService)
+
 	// Setup routes specific to the Account module
-	accountroutes.SetupRoutes(router, accountValueHandler, accountHandler)
+	accountroutes.SetupRoutes(router, accountValueHandler, accountHandler, lifecycleHandler, interestHandler, marginHandler)
 }
 
 func InitializeAssetModule(dbx *sqlx.DB, router *gin.RouterGroup) {
@@ -64,42 +124,196 @@ func InitializeAssetModule(dbx *sqlx.DB, router *gin.RouterGroup) {
 func InitializeAnalyticsModule(db *sql.DB, router *gin.RouterGroup) {
 	// Initialize repositories
 	accountPerformanceRepo := analyticsrepo.NewAccountPerformanceRepository(db)
+	benchmarkRepo := analyticsrepo.NewBenchmarkRepository(db)
+	allocationRepo := analyticsrepo.NewAllocationRepository(db)
+	valuationRepo := analyticsrepo.NewValuationRepository(db)
 
 	// Initialize services
 	accountPerformanceService := analyticsservice.NewAccountPerformanceService(accountPerformanceRepo)
+	benchmarkService := analyticsservice.NewBenchmarkService(benchmarkRepo, accountPerformanceService)
+	riskService := analyticsservice.NewRiskServiceFromEnv(accountPerformanceService, benchmarkService)
+	allocationService := analyticsservice.NewAllocationService(allocationRepo, valuationRepo)
 
 	// Initialize handlers
 	accountPerformanceHandler := analyticshandler.NewAccountPerformanceHandler(accountPerformanceService)
+	benchmarkHandler := analyticshandler.NewBenchmarkHandler(benchmarkService)
+	riskHandler := analyticshandler.NewRiskHandler(riskService)
+	allocationHandler := analyticshandler.NewAllocationHandler(allocationService)
 
 	// Setup routes specific to the Analytics module
-	analyticsroutes.SetupRoutes(router, accountPerformanceHandler)
+	analyticsroutes.SetupRoutes(router, accountPerformanceHandler, benchmarkHandler, riskHandler, allocationHandler)
 }
 
 func InitializePositionsModule(dbx *sqlx.DB, router *gin.RouterGroup) {
 	// Initialize repositories
 	holdingRepo := positionsrepo.NewHoldingsRepository(dbx)
+	taxLotRepo := positionsrepo.NewTaxLotRepository(dbx)
 
 	// Initialize services
 	holdingService := positionsservices.NewHoldingsService(holdingRepo)
+	taxLotService := positionsservices.NewTaxLotService(taxLotRepo)
 
 	// Initialize handlers
 	holdingHandler := positionshandlers.NewHoldingsHandler(holdingService)
+	taxLotHandler := positionshandlers.NewTaxLotHandler(taxLotService)
 
 	// Setup routes specific to the Positions module
-	positionsroutes.SetupRoutes(router, holdingHandler)
+	positionsroutes.SetupRoutes(router, holdingHandler, taxLotHandler)
 }
 
-func InitializeUsersModule(dbx *sqlx.DB, router *gin.RouterGroup) {
+// InitializeUsersModule returns the user service so other modules, such as
+// onboarding, can register users through it.
+func InitializeUsersModule(dbx *sqlx.DB, router *gin.RouterGroup, clock userutils.Clock) *userservices.UserService {
 	// Initialize repositories
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+	userTokenRepo := userrepo.NewUserTokenRepository(dbx)
+	credentialService := userservices.NewCredentialService(userTokenRepo, mail, clock, os.Getenv("APP_BASE_URL"))
+	credentialHandler := userhandlers.NewCredentialHandler(credentialService)
+
+	screeningService := complianceservices.NewScreeningServiceFromEnv(compliancerepo.NewScreeningRepository(dbx))
+
 	userRepo := userrepo.NewUserRepository(dbx)
 	// Initialize services
-	userService := userservices.NewUserService(userRepo)
+	userService := userservices.NewUserService(userRepo, credentialService, screeningService)
 	// Initialize handlers
 	userHandler := userhandlers.NewUserHandler(userService)
 
+	mfaRepo := userrepo.NewMFARepository(dbx)
+	mfaService := userservices.NewMFAService(mfaRepo, clock)
+	mfaHandler := userhandlers.NewMFAHandler(mfaService)
+
+	loginSecurityRepo := userrepo.NewLoginSecurityRepository(dbx)
+	loginSecurityService := userservices.NewLoginSecurityServiceFromEnv(loginSecurityRepo, clock)
+	loginSecurityHandler := userhandlers.NewLoginSecurityHandler(loginSecurityService)
+
 	authRepo := userrepo.NewAuthRepository(dbx)
-	authService := userservices.NewAuthService(authRepo)
+	authService := userservices.NewAuthService(authRepo, mfaService, loginSecurityService, clock)
 	authHandler := userhandlers.NewAuthHandler(authService)
 	// Setup routes specific to the Users module
-	usersroutes.SetupRoutes(router, userHandler, authHandler)
+	usersroutes.SetupRoutes(router, userHandler, authHandler, mfaHandler, loginSecurityHandler, credentialHandler)
+
+	return userService
+}
+
+func InitializeOnboardingModule(dbx *sqlx.DB, router *gin.RouterGroup, registrar onboardingservices.CustomerRegistrar) {
+	documentStorage, err := storage.NewStorageFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure document storage: %v", err)
+	}
+
+	// Initialize repositories
+	kycRepo := onboardingrepo.NewKYCRepository(dbx)
+	screeningRepo := compliancerepo.NewScreeningRepository(dbx)
+	// Initialize services
+	screeningService := complianceservices.NewScreeningServiceFromEnv(screeningRepo)
+	onboardingService := onboardingservices.NewOnboardingService(kycRepo, documentStorage, registrar, screeningService)
+	// Initialize handlers
+	onboardingHandler := onboardinghandlers.NewOnboardingHandler(onboardingService)
+
+	// Setup routes specific to the Onboarding module
+	onboardingroutes.SetupRoutes(router, onboardingHandler)
+}
+
+func InitializeComplianceModule(dbx *sqlx.DB, router *gin.RouterGroup) {
+	// Initialize repositories
+	preTradeRepo := compliancerepo.NewPreTradeRepository(dbx)
+	amlRepo := compliancerepo.NewAMLRepository(dbx)
+	screeningRepo := compliancerepo.NewScreeningRepository(dbx)
+
+	// Initialize services
+	preTradeService := complianceservices.NewPreTradeService(dbx, preTradeRepo)
+	amlService := complianceservices.NewAMLService(amlRepo)
+	screeningService := complianceservices.NewScreeningServiceFromEnv(screeningRepo)
+
+	// Initialize handlers
+	preTradeHandler := compliancehandlers.NewPreTradeHandler(preTradeService)
+	amlHandler := compliancehandlers.NewAMLHandler(amlService)
+	screeningHandler := compliancehandlers.NewScreeningHandler(screeningService)
+
+	// Setup routes specific to the Compliance module
+	complianceroutes.SetupRoutes(router, preTradeHandler, amlHandler, screeningHandler)
+}
+
+func InitializeTransfersModule(dbx *sqlx.DB, router *gin.RouterGroup) {
+	// Initialize repositories
+	transferRepo := transferrepo.NewTransferRepository(dbx)
+	deliveryRepo := transferrepo.NewDeliveryRepository(dbx)
+
+	// Initialize services
+	lotMethod := positionsutils.TaxLotMethodFromEnv()
+	transferService := transferservices.NewTransferService(transferRepo, lotMethod)
+	deliveryService := transferservices.NewDeliveryService(deliveryRepo, lotMethod)
+
+	// Initialize handlers
+	transferHandler := transferhandlers.NewTransferHandler(transferService)
+	deliveryHandler := transferhandlers.NewDeliveryHandler(deliveryService)
+
+	// Setup routes specific to the Transfers module
+	transferroutes.SetupRoutes(router, transferHandler, deliveryHandler)
+}
+
+func InitializeTaxModule(dbx *sqlx.DB, router *gin.RouterGroup) {
+	// Initialize repositories
+	iskRepo := taxrepo.NewIskRepository(dbx)
+
+	// Initialize services
+	iskService := taxservices.NewIskService(iskRepo)
+
+	// Initialize handlers
+	iskHandler := taxhandlers.NewIskHandler(iskService)
+
+	// Setup routes specific to the Tax module
+	taxroutes.SetupRoutes(router, iskHandler)
+}
+
+func InitializeJobsModule(dbx *sqlx.DB, router *gin.RouterGroup) {
+	// Initialize repositories
+	jobRepo := jobrepo.NewJobRepository(dbx)
+
+	// Initialize services
+	jobService := jobservices.NewJobService(jobRepo)
+
+	// Initialize handlers
+	jobHandler := jobhandlers.NewJobHandler(jobService)
+
+	// Setup routes specific to the Jobs module
+	jobroutes.SetupRoutes(router, jobHandler)
+}
+
+func InitializePortfoliosModule(dbx *sqlx.DB, router *gin.RouterGroup) {
+	// Initialize repositories
+	modelRepo := portfoliorepo.NewModelPortfolioRepository(dbx)
+	rebalanceRepo := portfoliorepo.NewRebalanceRepository(dbx)
+
+	// Initialize services
+	// Approved rebalancing trades are placed through the orders module
+	orders := orderservices.NewOrdersService(dbx, orderrepo.NewOrdersRepository(dbx),
+		complianceservices.NewPreTradeService(dbx, compliancerepo.NewPreTradeRepository(dbx)))
+	modelService := portfolioservices.NewModelPortfolioService(modelRepo)
+	rebalanceService := portfolioservices.NewRebalanceService(rebalanceRepo, modelService, orders)
+
+	// Initialize handlers
+	modelHandler := portfoliohandlers.NewModelPortfolioHandler(modelService)
+	rebalanceHandler := portfoliohandlers.NewRebalanceHandler(rebalanceService)
+
+	// Setup routes specific to the Portfolios module
+	portfolioroutes.SetupRoutes(router, modelHandler, rebalanceHandler)
+}
+
+func InitializeSavingsModule(dbx *sqlx.DB, router *gin.RouterGroup) {
+	// Initialize repositories
+	planRepo := savingsrepo.NewSavingsPlanRepository(dbx)
+
+	// Initialize services
+	// Plans are run by the scheduler; the API only manages them
+	planService := savingsservices.NewSavingsPlanService(planRepo)
+
+	// Initialize handlers
+	planHandler := savingshandlers.NewSavingsPlanHandler(planService)
+
+	// Setup routes specific to the Savings module
+	savingsroutes.SetupRoutes(router, planHandler)
 }
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/compliance/models"
+	"thyra/internal/compliance/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AMLHandler struct {
+	service *services.AMLService
+}
+
+func NewAMLHandler(service *services.AMLService) *AMLHandler {
+	return &AMLHandler{service: service}
+}
+
+// GetAlertsHandler lists alerts, optionally filtered by ?status= and
+// ?assignee= (a user ID, or "me").
+func (h *AMLHandler) GetAlertsHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	filter := models.AMLAlertFilter{Status: c.Query("status")}
+	if assignee := c.Query("assignee"); assignee == "me" {
+		filter.AssigneeID = &authUserID
+	} else if assignee != "" {
+		assigneeID, err := uuid.Parse(assignee)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignee"})
+			return
+		}
+		filter.AssigneeID = &assigneeID
+	}
+
+	alerts, err := h.service.GetAlerts(c.Request.Context(), userRole, filter)
+	if err != nil {
+		writeAMLError(c, err, "Failed to fetch AML alerts")
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+func (h *AMLHandler) GetAlertHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	alertID, ok := uuidParam(c, "alertId")
+	if !ok {
+		return
+	}
+
+	details, err := h.service.GetAlert(c.Request.Context(), alertID, userRole)
+	if err != nil {
+		writeAMLError(c, err, "Failed to fetch AML alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+func (h *AMLHandler) AssignAlertHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	alertID, ok := uuidParam(c, "alertId")
+	if !ok {
+		return
+	}
+
+	var req models.AssignAlertRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.AssignAlert(c.Request.Context(), alertID, userRole, req); err != nil {
+		writeAMLError(c, err, "Failed to assign AML alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert assigned"})
+}
+
+func (h *AMLHandler) AddNoteHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	alertID, ok := uuidParam(c, "alertId")
+	if !ok {
+		return
+	}
+
+	var req models.AlertNoteRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	note, err := h.service.AddNote(c.Request.Context(), alertID, authUserID, userRole, req)
+	if err != nil {
+		writeAMLError(c, err, "Failed to add note")
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+func (h *AMLHandler) UpdateAlertStatusHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	alertID, ok := uuidParam(c, "alertId")
+	if !ok {
+		return
+	}
+
+	var req models.AlertStatusRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.UpdateAlertStatus(c.Request.Context(), alertID, authUserID, userRole, req); err != nil {
+		writeAMLError(c, err, "Failed to update AML alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert updated"})
+}
+
+func (h *AMLHandler) CloseAlertHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	alertID, ok := uuidParam(c, "alertId")
+	if !ok {
+		return
+	}
+
+	var req models.CloseAlertRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.CloseAlert(c.Request.Context(), alertID, authUserID, userRole, req); err != nil {
+		writeAMLError(c, err, "Failed to close AML alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert closed"})
+}
+
+// RunBatchHandler runs the scheduled monitoring batch on demand.
+func (h *AMLHandler) RunBatchHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+	if userRole != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrAMLAccessDenied.Error()})
+		return
+	}
+
+	result, err := h.service.RunBatch(c.Request.Context())
+	if err != nil {
+		writeAMLError(c, err, "Failed to run AML monitoring")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AMLHandler) GetRuleSettingsHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.service.GetRuleSettings(c.Request.Context(), userRole)
+	if err != nil {
+		writeAMLError(c, err, "Failed to fetch AML rules")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *AMLHandler) SetRuleSettingHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AMLRuleSettingRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.SetRuleSetting(c.Request.Context(), c.Param("ruleName"), authUserID, userRole, req); err != nil {
+		writeAMLError(c, err, "Failed to save AML rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "AML rule saved"})
+}
+
+func writeAMLError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrAMLAccessDenied, services.ErrComplianceAdminOnly:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAlertNotFound, services.ErrUnknownAMLRule:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrAlertClosed:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrInvalidAssignee, services.ErrInvalidAlertStatus, services.ErrInvalidResolution,
+		services.ErrNoteRequired, services.ErrInvalidAMLParameter:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
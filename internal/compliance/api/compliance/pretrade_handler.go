@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/compliance/models"
+	"thyra/internal/compliance/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PreTradeHandler struct {
+	service *services.PreTradeService
+}
+
+func NewPreTradeHandler(service *services.PreTradeService) *PreTradeHandler {
+	return &PreTradeHandler{service: service}
+}
+
+func (h *PreTradeHandler) GetOrderChecksHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+	if userRole != "admin" && userRole != "partner_advisor" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+		return
+	}
+
+	orderID, ok := uuidParam(c, "orderId")
+	if !ok {
+		return
+	}
+
+	checks, err := h.service.GetOrderChecks(c.Request.Context(), orderID)
+	if err != nil {
+		writeComplianceError(c, err, "Failed to fetch compliance checks")
+		return
+	}
+
+	c.JSON(http.StatusOK, checks)
+}
+
+// GetAccountChecksHandler lists warnings and blocks raised for an account,
+// including orders that were never placed.
+func (h *PreTradeHandler) GetAccountChecksHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	accountID, ok := uuidParam(c, "accountId")
+	if !ok {
+		return
+	}
+
+	checks, err := h.service.GetAccountChecks(c.Request.Context(), accountID, userRole)
+	if err != nil {
+		writeComplianceError(c, err, "Failed to fetch compliance checks")
+		return
+	}
+
+	c.JSON(http.StatusOK, checks)
+}
+
+func (h *PreTradeHandler) GetSuitabilityHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := uuidParam(c, "userId")
+	if !ok {
+		return
+	}
+	if userID != authUserID && userRole != "admin" && userRole != "partner_advisor" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+		return
+	}
+
+	suitability, err := h.service.GetSuitability(c.Request.Context(), userID)
+	if err != nil {
+		writeComplianceError(c, err, "Failed to fetch suitability assessment")
+		return
+	}
+
+	c.JSON(http.StatusOK, suitability)
+}
+
+func (h *PreTradeHandler) SetSuitabilityHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := uuidParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	var req models.SuitabilityRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.SetSuitability(c.Request.Context(), userID, authUserID, userRole, req); err != nil {
+		writeComplianceError(c, err, "Failed to save suitability assessment")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suitability assessment saved"})
+}
+
+func (h *PreTradeHandler) GetClassificationsHandler(c *gin.Context) {
+	classifications, err := h.service.GetClassifications(c.Request.Context())
+	if err != nil {
+		writeComplianceError(c, err, "Failed to fetch instrument classifications")
+		return
+	}
+
+	c.JSON(http.StatusOK, classifications)
+}
+
+func (h *PreTradeHandler) SetClassificationHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.InstrumentClassificationRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.SetClassification(c.Request.Context(), userRole, req); err != nil {
+		writeComplianceError(c, err, "Failed to save instrument classification")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Instrument classification saved"})
+}
+
+func (h *PreTradeHandler) GetRestrictedInstrumentsHandler(c *gin.Context) {
+	restricted, err := h.service.GetRestrictedInstruments(c.Request.Context())
+	if err != nil {
+		writeComplianceError(c, err, "Failed to fetch restricted instruments")
+		return
+	}
+
+	c.JSON(http.StatusOK, restricted)
+}
+
+func (h *PreTradeHandler) AddRestrictedInstrumentHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RestrictedInstrumentRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	restricted, err := h.service.AddRestrictedInstrument(c.Request.Context(), authUserID, userRole, req)
+	if err != nil {
+		writeComplianceError(c, err, "Failed to restrict instrument")
+		return
+	}
+
+	c.JSON(http.StatusCreated, restricted)
+}
+
+func (h *PreTradeHandler) EndRestrictionHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	restrictionID, ok := uuidParam(c, "restrictionId")
+	if !ok {
+		return
+	}
+
+	if err := h.service.EndRestriction(c.Request.Context(), restrictionID, userRole); err != nil {
+		writeComplianceError(c, err, "Failed to lift restriction")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Restriction lifted"})
+}
+
+func (h *PreTradeHandler) SetConcentrationLimitHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	accountID, ok := uuidParam(c, "accountId")
+	if !ok {
+		return
+	}
+
+	var req models.ConcentrationLimitRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.SetConcentrationLimit(c.Request.Context(), accountID, authUserID, userRole, req); err != nil {
+		writeComplianceError(c, err, "Failed to save concentration limit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Concentration limit saved"})
+}
+
+func (h *PreTradeHandler) SetOrderLimitHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	accountTypeID, ok := uuidParam(c, "accountTypeId")
+	if !ok {
+		return
+	}
+
+	var req models.OrderLimitRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.SetOrderLimit(c.Request.Context(), accountTypeID, authUserID, userRole, req); err != nil {
+		writeComplianceError(c, err, "Failed to save order limit")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order limit saved"})
+}
+
+func (h *PreTradeHandler) GetRuleSettingsHandler(c *gin.Context) {
+	settings, err := h.service.GetRuleSettings(c.Request.Context())
+	if err != nil {
+		writeComplianceError(c, err, "Failed to fetch pre-trade rules")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *PreTradeHandler) SetRuleSettingHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.RuleSettingRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.SetRuleSetting(c.Request.Context(), c.Param("ruleName"), authUserID, userRole, req); err != nil {
+		writeComplianceError(c, err, "Failed to save pre-trade rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pre-trade rule saved"})
+}
+
+func authenticatedUserUUID(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userType, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+
+	return userUUID, userType, true
+}
+
+func uuidParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeComplianceError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrComplianceAdminOnly, services.ErrSuitabilityNotAllowed:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrSuitabilityNotFound, services.ErrRestrictionNotFound, services.ErrUnknownRule:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidRiskProfile, services.ErrInvalidKnowledgeLevel, services.ErrInvalidComplexity,
+		services.ErrInvalidClassification, services.ErrInvalidRestriction, services.ErrRestrictionReason,
+		services.ErrInvalidLimit, services.ErrInvalidRuleAction, services.ErrInvalidDate:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/compliance/models"
+	"thyra/internal/compliance/services"
+	"thyra/internal/compliance/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScreeningHandler struct {
+	service *services.ScreeningService
+}
+
+func NewScreeningHandler(service *services.ScreeningService) *ScreeningHandler {
+	return &ScreeningHandler{service: service}
+}
+
+func (h *ScreeningHandler) GetListsHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	lists, err := h.service.GetLists(c.Request.Context(), userRole)
+	if err != nil {
+		writeScreeningError(c, err, "Failed to fetch sanctions lists")
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// ImportListHandler imports a file already placed in the sanctions list
+// directory and re-screens all customers.
+func (h *ScreeningHandler) ImportListHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ImportListRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	result, err := h.service.ImportListFile(c.Request.Context(), authUserID, userRole, req)
+	if err != nil {
+		writeScreeningError(c, err, "Failed to import sanctions list")
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+func (h *ScreeningHandler) ScreenCustomerHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := uuidParam(c, "userId")
+	if !ok {
+		return
+	}
+
+	opened, err := h.service.ScreenCustomerManually(c.Request.Context(), userID, userRole)
+	if err != nil {
+		writeScreeningError(c, err, "Failed to screen customer")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"new_cases": opened})
+}
+
+// GetCasesHandler lists screening cases, optionally filtered by ?status=.
+func (h *ScreeningHandler) GetCasesHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	cases, err := h.service.GetCases(c.Request.Context(), c.Query("status"), userRole)
+	if err != nil {
+		writeScreeningError(c, err, "Failed to fetch screening cases")
+		return
+	}
+
+	c.JSON(http.StatusOK, cases)
+}
+
+func (h *ScreeningHandler) GetCaseHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	caseID, ok := uuidParam(c, "caseId")
+	if !ok {
+		return
+	}
+
+	details, err := h.service.GetCase(c.Request.Context(), caseID, userRole)
+	if err != nil {
+		writeScreeningError(c, err, "Failed to fetch screening case")
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+func (h *ScreeningHandler) DecideCaseHandler(c *gin.Context) {
+	authUserID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	caseID, ok := uuidParam(c, "caseId")
+	if !ok {
+		return
+	}
+
+	var req models.ScreeningDecisionRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.DecideCase(c.Request.Context(), caseID, authUserID, userRole, req); err != nil {
+		writeScreeningError(c, err, "Failed to record decision")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Decision recorded"})
+}
+
+func writeScreeningError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrScreeningAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrScreeningCaseNotFound, services.ErrCustomerNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrListAlreadyImported, services.ErrScreeningCaseDecided:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrListFileName, services.ErrListFileTooLarge, services.ErrEmptyList, services.ErrInvalidListType,
+		services.ErrInvalidScreeningState, services.ErrNoteRequired,
+		utils.ErrUnsupportedListFormat, utils.ErrMissingListColumns:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CashDirectionIn  = "in"
+	CashDirectionOut = "out"
+)
+
+const (
+	AlertStatusOpen      = "open"
+	AlertStatusInReview  = "in_review"
+	AlertStatusEscalated = "escalated"
+	AlertStatusClosed    = "closed"
+)
+
+const (
+	AlertResolutionFalsePositive = "false_positive"
+	AlertResolutionNoAction      = "no_action"
+	// Reported to the Financial Intelligence Unit.
+	AlertResolutionReported = "reported"
+)
+
+// CashEvent is a deposit or withdrawal as seen by AML monitoring.
+type CashEvent struct {
+	ID            uuid.UUID  `db:"id" json:"id"`
+	TransactionID uuid.UUID  `db:"transaction_id" json:"transaction_id"`
+	AccountID     uuid.UUID  `db:"account_id" json:"account_id"`
+	Direction     string     `db:"direction" json:"direction"`
+	Amount        float64    `db:"amount" json:"amount"`
+	OccurredAt    time.Time  `db:"occurred_at" json:"occurred_at"`
+	EvaluatedAt   *time.Time `db:"evaluated_at" json:"evaluated_at,omitempty"`
+}
+
+// AMLRuleSetting holds the tunable parameters of a rule. Each rule uses the
+// subset that applies to it.
+type AMLRuleSetting struct {
+	RuleName        string     `db:"rule_name" json:"rule_name"`
+	Enabled         bool       `db:"enabled" json:"enabled"`
+	AmountThreshold float64    `db:"amount_threshold" json:"amount_threshold"`
+	WindowDays      int        `db:"window_days" json:"window_days"`
+	MinCount        int        `db:"min_count" json:"min_count"`
+	UpdatedBy       *uuid.UUID `db:"updated_by" json:"updated_by,omitempty"`
+	UpdatedAt       *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// AMLContext is what the rules evaluate for one event. Recent holds the
+// account's other events inside the longest rule window; Previous is the last
+// event before this one, however old.
+type AMLContext struct {
+	Event    CashEvent
+	Recent   []CashEvent
+	Previous *CashEvent
+}
+
+type AMLAlert struct {
+	ID              uuid.UUID  `db:"id" json:"id"`
+	AccountID       uuid.UUID  `db:"account_id" json:"account_id"`
+	RuleName        string     `db:"rule_name" json:"rule_name"`
+	Description     string     `db:"description" json:"description"`
+	Status          string     `db:"status" json:"status"`
+	AssigneeID      *uuid.UUID `db:"assignee_id" json:"assignee_id,omitempty"`
+	Resolution      *string    `db:"resolution" json:"resolution,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	LastTriggeredAt time.Time  `db:"last_triggered_at" json:"last_triggered_at"`
+	ClosedAt        *time.Time `db:"closed_at" json:"closed_at,omitempty"`
+	ClosedBy        *uuid.UUID `db:"closed_by" json:"closed_by,omitempty"`
+}
+
+type AMLAlertNote struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	AlertID   uuid.UUID `db:"alert_id" json:"alert_id"`
+	AuthorID  uuid.UUID `db:"author_id" json:"author_id"`
+	Note      string    `db:"note" json:"note"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type AMLAlertDetails struct {
+	Alert  AMLAlert       `json:"alert"`
+	Events []CashEvent    `json:"events"`
+	Notes  []AMLAlertNote `json:"notes"`
+}
+
+type AMLAlertFilter struct {
+	Status     string
+	AssigneeID *uuid.UUID
+}
+
+type AssignAlertRequest struct {
+	AssigneeID uuid.UUID `json:"assignee_id"`
+}
+
+type AlertNoteRequest struct {
+	Note string `json:"note"`
+}
+
+type AlertStatusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+type CloseAlertRequest struct {
+	Resolution string `json:"resolution"`
+	Note       string `json:"note"`
+}
+
+type AMLRuleSettingRequest struct {
+	Enabled         bool    `json:"enabled"`
+	AmountThreshold float64 `json:"amount_threshold"`
+	WindowDays      int     `json:"window_days"`
+	MinCount        int     `json:"min_count"`
+}
+
+// AMLBatchResult summarises one scheduled run.
+type AMLBatchResult struct {
+	Imported  int `json:"imported"`
+	Evaluated int `json:"evaluated"`
+	Alerts    int `json:"alerts"`
+}
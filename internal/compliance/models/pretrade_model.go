@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OrderSideBuy  = "buy"
+	OrderSideSell = "sell"
+)
+
+// Outcome of a single rule, and of the check as a whole.
+const (
+	OutcomePass        = "pass"
+	OutcomeAcknowledge = "acknowledge"
+	OutcomeBlock       = "block"
+)
+
+// Rule actions configurable per rule in pretrade_rule_settings.
+const (
+	RuleActionBlock       = "block"
+	RuleActionAcknowledge = "acknowledge"
+)
+
+const (
+	ComplexityNonComplex    = "non_complex"
+	ComplexityComplex       = "complex"
+	ComplexityHighlyComplex = "highly_complex"
+)
+
+const (
+	KnowledgeNone     = "none"
+	KnowledgeBasic    = "basic"
+	KnowledgeInformed = "informed"
+	KnowledgeAdvanced = "advanced"
+)
+
+const (
+	RestrictionNoBuy   = "no_buy"
+	RestrictionNoTrade = "no_trade"
+)
+
+// PreTradeOrder is the part of an order the rules look at.
+type PreTradeOrder struct {
+	AccountID   uuid.UUID
+	AssetID     uuid.UUID
+	Side        string
+	Quantity    float64
+	TotalAmount float64
+}
+
+type CustomerSuitability struct {
+	UserID         uuid.UUID  `db:"user_id" json:"user_id"`
+	RiskProfile    int        `db:"risk_profile" json:"risk_profile"`
+	KnowledgeLevel string     `db:"knowledge_level" json:"knowledge_level"`
+	AssessedBy     uuid.UUID  `db:"assessed_by" json:"assessed_by"`
+	AssessedAt     time.Time  `db:"assessed_at" json:"assessed_at"`
+	ValidUntil     *time.Time `db:"valid_until" json:"valid_until"`
+}
+
+type InstrumentClassification struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	InstrumentType *string    `db:"instrument_type" json:"instrument_type"`
+	AssetTypeID    *uuid.UUID `db:"asset_type_id" json:"asset_type_id"`
+	Complexity     string     `db:"complexity" json:"complexity"`
+	RiskClass      int        `db:"risk_class" json:"risk_class"`
+}
+
+type RestrictedInstrument struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	AssetID     uuid.UUID  `db:"asset_id" json:"asset_id"`
+	Restriction string     `db:"restriction" json:"restriction"`
+	Reason      string     `db:"reason" json:"reason"`
+	ValidFrom   time.Time  `db:"valid_from" json:"valid_from"`
+	ValidTo     *time.Time `db:"valid_to" json:"valid_to"`
+	CreatedBy   uuid.UUID  `db:"created_by" json:"created_by"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+}
+
+type RuleSetting struct {
+	RuleName  string     `db:"rule_name" json:"rule_name"`
+	Enabled   bool       `db:"enabled" json:"enabled"`
+	Action    string     `db:"action" json:"action"`
+	UpdatedBy *uuid.UUID `db:"updated_by" json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// PreTradeContext is everything the rules need, loaded once per order.
+type PreTradeContext struct {
+	Order PreTradeOrder
+	Now   time.Time
+
+	AccountTypeID    uuid.UUID
+	AccountTypeName  string
+	HolderIsCustomer bool
+	// Cash plus holdings at current prices, and the part held in the asset.
+	AccountValue  float64
+	PositionValue float64
+
+	Suitability    *CustomerSuitability
+	Classification *InstrumentClassification
+	Restriction    *RestrictedInstrument
+
+	MaxPositionPct *float64
+	MaxOrderValue  *float64
+}
+
+type RuleResult struct {
+	RuleName string `json:"rule_name"`
+	Outcome  string `json:"outcome"`
+	Message  string `json:"message,omitempty"`
+}
+
+type PreTradeResult struct {
+	Outcome string       `json:"outcome"`
+	Results []RuleResult `json:"results"`
+}
+
+// PreTradeError rejects an order that is blocked, or that has warnings the
+// client has not acknowledged.
+type PreTradeError struct {
+	Outcome string
+	Results []RuleResult
+}
+
+func (e *PreTradeError) Error() string {
+	var messages []string
+	for _, r := range e.Results {
+		if r.Outcome == e.Outcome {
+			messages = append(messages, r.Message)
+		}
+	}
+	if e.Outcome == OutcomeBlock {
+		return fmt.Sprintf("order blocked by pre-trade checks: %s", strings.Join(messages, "; "))
+	}
+	return fmt.Sprintf("order requires acknowledgement: %s", strings.Join(messages, "; "))
+}
+
+type OrderComplianceCheck struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	OrderID        *uuid.UUID `db:"order_id" json:"order_id"`
+	AccountID      uuid.UUID  `db:"account_id" json:"account_id"`
+	AssetID        uuid.UUID  `db:"asset_id" json:"asset_id"`
+	RuleName       string     `db:"rule_name" json:"rule_name"`
+	Outcome        string     `db:"outcome" json:"outcome"`
+	Message        *string    `db:"message" json:"message,omitempty"`
+	Acknowledged   bool       `db:"acknowledged" json:"acknowledged"`
+	AcknowledgedBy *uuid.UUID `db:"acknowledged_by" json:"acknowledged_by,omitempty"`
+	EvaluatedAt    time.Time  `db:"evaluated_at" json:"evaluated_at"`
+}
+
+type SuitabilityRequest struct {
+	RiskProfile    int     `json:"risk_profile"`
+	KnowledgeLevel string  `json:"knowledge_level"`
+	ValidUntil     *string `json:"valid_until"` // YYYY-MM-DD
+}
+
+type InstrumentClassificationRequest struct {
+	InstrumentType *string    `json:"instrument_type"`
+	AssetTypeID    *uuid.UUID `json:"asset_type_id"`
+	Complexity     string     `json:"complexity"`
+	RiskClass      int        `json:"risk_class"`
+}
+
+type RestrictedInstrumentRequest struct {
+	AssetID     uuid.UUID `json:"asset_id"`
+	Restriction string    `json:"restriction"`
+	Reason      string    `json:"reason"`
+	ValidTo     *string   `json:"valid_to"` // YYYY-MM-DD
+}
+
+type ConcentrationLimitRequest struct {
+	MaxPositionPct float64 `json:"max_position_pct"`
+}
+
+type OrderLimitRequest struct {
+	MaxOrderValue float64 `json:"max_order_value"`
+}
+
+type RuleSettingRequest struct {
+	Enabled bool   `json:"enabled"`
+	Action  string `json:"action"`
+}
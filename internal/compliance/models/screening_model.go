@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	ListTypeSanctions = "sanctions"
+	ListTypePEP       = "pep"
+)
+
+const (
+	SubjectTypePerson = "person"
+	SubjectTypeEntity = "entity"
+)
+
+const (
+	ScreeningTriggerRegistration = "registration"
+	ScreeningTriggerKYCUpdate    = "kyc_update"
+	ScreeningTriggerListImport   = "list_import"
+	ScreeningTriggerManual       = "manual"
+)
+
+const (
+	ScreeningCaseOpen           = "open"
+	ScreeningCaseConfirmedMatch = "confirmed_match"
+	ScreeningCaseFalsePositive  = "false_positive"
+)
+
+type SanctionsList struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	Source     string     `db:"source" json:"source"`
+	ListType   string     `db:"list_type" json:"list_type"`
+	FileName   string     `db:"file_name" json:"file_name"`
+	FileSHA256 string     `db:"file_sha256" json:"file_sha256"`
+	EntryCount int        `db:"entry_count" json:"entry_count"`
+	Active     bool       `db:"active" json:"active"`
+	ImportedBy *uuid.UUID `db:"imported_by" json:"imported_by,omitempty"`
+	ImportedAt time.Time  `db:"imported_at" json:"imported_at"`
+}
+
+// SanctionsEntry is one listed subject with every name it is known by. Birth
+// dates are YYYY-MM-DD, or YYYY when only the year is known.
+type SanctionsEntry struct {
+	ID          uuid.UUID      `db:"id" json:"id"`
+	ListID      uuid.UUID      `db:"list_id" json:"list_id"`
+	ExternalID  string         `db:"external_id" json:"external_id"`
+	SubjectType string         `db:"subject_type" json:"subject_type"`
+	Names       pq.StringArray `db:"names" json:"names"`
+	BirthDates  pq.StringArray `db:"birth_dates" json:"birth_dates"`
+	Countries   pq.StringArray `db:"countries" json:"countries"`
+	Programme   *string        `db:"programme" json:"programme,omitempty"`
+}
+
+// ScreeningEntry is an active entry together with the list it belongs to.
+type ScreeningEntry struct {
+	SanctionsEntry
+	ListSource string `db:"list_source"`
+	ListType   string `db:"list_type"`
+}
+
+// ScreenedCustomer is the customer data compared against the lists.
+type ScreenedCustomer struct {
+	UserID      uuid.UUID  `db:"user_id"`
+	FullName    string     `db:"full_name"`
+	DateOfBirth *time.Time `db:"date_of_birth"`
+	Citizenship *string    `db:"citizenship"`
+}
+
+type ScreeningCase struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	UserID         uuid.UUID  `db:"user_id" json:"user_id"`
+	ListSource     string     `db:"list_source" json:"list_source"`
+	ListType       string     `db:"list_type" json:"list_type"`
+	ExternalID     string     `db:"external_id" json:"external_id"`
+	EntryID        *uuid.UUID `db:"entry_id" json:"entry_id,omitempty"`
+	CustomerName   string     `db:"customer_name" json:"customer_name"`
+	MatchedName    string     `db:"matched_name" json:"matched_name"`
+	Score          float64    `db:"score" json:"score"`
+	BirthDateMatch *bool      `db:"birth_date_match" json:"birth_date_match"`
+	Trigger        string     `db:"trigger" json:"trigger"`
+	Status         string     `db:"status" json:"status"`
+	ReviewedBy     *uuid.UUID `db:"reviewed_by" json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `db:"reviewed_at" json:"reviewed_at,omitempty"`
+	ReviewNote     *string    `db:"review_note" json:"review_note,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+type ScreeningCaseDetails struct {
+	Case  ScreeningCase   `json:"case"`
+	Entry *SanctionsEntry `json:"entry,omitempty"`
+}
+
+type ScreeningRun struct {
+	ID                uuid.UUID  `db:"id" json:"id"`
+	Trigger           string     `db:"trigger" json:"trigger"`
+	ListID            *uuid.UUID `db:"list_id" json:"list_id,omitempty"`
+	CustomersScreened int        `db:"customers_screened" json:"customers_screened"`
+	NewCases          int        `db:"new_cases" json:"new_cases"`
+	StartedAt         time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt        time.Time  `db:"finished_at" json:"finished_at"`
+}
+
+type ImportListRequest struct {
+	FileName string `json:"file_name"`
+	ListType string `json:"list_type"`
+}
+
+type ListImportResult struct {
+	List SanctionsList `json:"list"`
+	Run  ScreeningRun  `json:"run"`
+}
+
+type ScreeningDecisionRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/compliance/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type AMLRepository struct {
+	db *sqlx.DB
+}
+
+func NewAMLRepository(db *sqlx.DB) *AMLRepository {
+	return &AMLRepository{db: db}
+}
+
+// RecordEvent stores the event unless its transaction has been recorded
+// before, and returns the stored row either way.
+func (r *AMLRepository) RecordEvent(ctx context.Context, event models.CashEvent) (models.CashEvent, error) {
+	query := `
+        INSERT INTO thyrasec.aml_cash_events (id, transaction_id, account_id, direction, amount, occurred_at)
+        VALUES (:id, :transaction_id, :account_id, :direction, :amount, :occurred_at)
+        ON CONFLICT (transaction_id) DO NOTHING`
+	if _, err := r.db.NamedExecContext(ctx, query, event); err != nil {
+		return event, err
+	}
+
+	var stored models.CashEvent
+	err := r.db.GetContext(ctx, &stored, `
+        SELECT id, transaction_id, account_id, direction, amount, occurred_at, evaluated_at
+        FROM thyrasec.aml_cash_events WHERE transaction_id = $1`, event.TransactionID)
+	return stored, err
+}
+
+// ImportCashTransactions records deposits and withdrawals booked outside
+// TransactionService, or while monitoring was unavailable. House account legs
+// are skipped.
+func (r *AMLRepository) ImportCashTransactions(ctx context.Context) (int, error) {
+	query := `
+        INSERT INTO thyrasec.aml_cash_events (transaction_id, account_id, direction, amount, occurred_at)
+        SELECT t.id, t.transaction_owner_account_id,
+            CASE WHEN tt.transaction_type_name ILIKE '%withdraw%' THEN 'out' ELSE 'in' END,
+            ABS(t.cash_amount), t.created_at
+        FROM thyrasec.transactions t
+        JOIN thyrasec.transactions_types tt ON tt.type_id = t.type
+        JOIN thyrasec.accounts a ON a.id = t.transaction_owner_account_id
+        LEFT JOIN thyrasec.account_types at ON at.id = a.account_type
+        WHERE (tt.transaction_type_name ILIKE '%deposit%' OR tt.transaction_type_name ILIKE '%withdraw%')
+            AND t.cash_amount IS NOT NULL
+            AND t.canceled = false
+            AND at.account_type_name IS DISTINCT FROM 'House'
+        ON CONFLICT (transaction_id) DO NOTHING`
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	imported, err := result.RowsAffected()
+	return int(imported), err
+}
+
+func (r *AMLRepository) GetUnevaluatedEvents(ctx context.Context, limit int) ([]models.CashEvent, error) {
+	var events []models.CashEvent
+	query := `
+        SELECT id, transaction_id, account_id, direction, amount, occurred_at, evaluated_at
+        FROM thyrasec.aml_cash_events
+        WHERE evaluated_at IS NULL
+        ORDER BY occurred_at
+        LIMIT $1`
+	err := r.db.SelectContext(ctx, &events, query, limit)
+	return events, err
+}
+
+// LoadContext returns the account's other events from windowDays before the
+// event up to it, and the last event before it.
+func (r *AMLRepository) LoadContext(ctx context.Context, event models.CashEvent, windowDays int) (models.AMLContext, error) {
+	check := models.AMLContext{Event: event}
+
+	recentQuery := `
+        SELECT id, transaction_id, account_id, direction, amount, occurred_at, evaluated_at
+        FROM thyrasec.aml_cash_events
+        WHERE account_id = $1 AND id <> $2 AND occurred_at >= $3 AND occurred_at <= $4
+        ORDER BY occurred_at`
+	since := event.OccurredAt.AddDate(0, 0, -windowDays)
+	if err := r.db.SelectContext(ctx, &check.Recent, recentQuery, event.AccountID, event.ID, since, event.OccurredAt); err != nil {
+		return check, err
+	}
+
+	var previous models.CashEvent
+	previousQuery := `
+        SELECT id, transaction_id, account_id, direction, amount, occurred_at, evaluated_at
+        FROM thyrasec.aml_cash_events
+        WHERE account_id = $1 AND occurred_at < $2
+        ORDER BY occurred_at DESC
+        LIMIT 1`
+	if err := r.db.GetContext(ctx, &previous, previousQuery, event.AccountID, event.OccurredAt); err == nil {
+		check.Previous = &previous
+	} else if err != sql.ErrNoRows {
+		return check, err
+	}
+
+	return check, nil
+}
+
+func (r *AMLRepository) MarkEvaluated(ctx context.Context, eventID uuid.UUID, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE thyrasec.aml_cash_events SET evaluated_at = $2 WHERE id = $1`, eventID, now)
+	return err
+}
+
+// RaiseAlert links the event to the account's open alert for the rule, or
+// opens a new one. It reports whether a new alert was created.
+func (r *AMLRepository) RaiseAlert(ctx context.Context, accountID uuid.UUID, ruleName, description string, eventID uuid.UUID, now time.Time) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var alertID uuid.UUID
+	created := false
+	err = tx.GetContext(ctx, &alertID, `
+        SELECT id FROM thyrasec.aml_alerts
+        WHERE account_id = $1 AND rule_name = $2 AND status <> 'closed'
+        FOR UPDATE`, accountID, ruleName)
+	switch {
+	case err == sql.ErrNoRows:
+		alertID = uuid.New()
+		created = true
+		_, err = tx.ExecContext(ctx, `
+            INSERT INTO thyrasec.aml_alerts (id, account_id, rule_name, description, status, created_at, last_triggered_at)
+            VALUES ($1, $2, $3, $4, 'open', $5, $5)`, alertID, accountID, ruleName, description, now)
+	case err == nil:
+		_, err = tx.ExecContext(ctx, `
+            UPDATE thyrasec.aml_alerts SET description = $2, last_triggered_at = $3 WHERE id = $1`, alertID, description, now)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO thyrasec.aml_alert_events (alert_id, event_id) VALUES ($1, $2)
+        ON CONFLICT DO NOTHING`, alertID, eventID); err != nil {
+		return false, err
+	}
+
+	return created, tx.Commit()
+}
+
+func (r *AMLRepository) GetAlerts(ctx context.Context, filter models.AMLAlertFilter) ([]models.AMLAlert, error) {
+	var alerts []models.AMLAlert
+	query := `
+        SELECT id, account_id, rule_name, description, status, assignee_id, resolution,
+            created_at, last_triggered_at, closed_at, closed_by
+        FROM thyrasec.aml_alerts
+        WHERE ($1 = '' OR status = $1) AND ($2::uuid IS NULL OR assignee_id = $2)
+        ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &alerts, query, filter.Status, filter.AssigneeID)
+	return alerts, err
+}
+
+func (r *AMLRepository) GetAlert(ctx context.Context, alertID uuid.UUID) (models.AMLAlert, error) {
+	var alert models.AMLAlert
+	query := `
+        SELECT id, account_id, rule_name, description, status, assignee_id, resolution,
+            created_at, last_triggered_at, closed_at, closed_by
+        FROM thyrasec.aml_alerts
+        WHERE id = $1`
+	err := r.db.GetContext(ctx, &alert, query, alertID)
+	return alert, err
+}
+
+func (r *AMLRepository) GetAlertEvents(ctx context.Context, alertID uuid.UUID) ([]models.CashEvent, error) {
+	var events []models.CashEvent
+	query := `
+        SELECT e.id, e.transaction_id, e.account_id, e.direction, e.amount, e.occurred_at, e.evaluated_at
+        FROM thyrasec.aml_alert_events ae
+        JOIN thyrasec.aml_cash_events e ON e.id = ae.event_id
+        WHERE ae.alert_id = $1
+        ORDER BY e.occurred_at`
+	err := r.db.SelectContext(ctx, &events, query, alertID)
+	return events, err
+}
+
+func (r *AMLRepository) GetAlertNotes(ctx context.Context, alertID uuid.UUID) ([]models.AMLAlertNote, error) {
+	var notes []models.AMLAlertNote
+	query := `
+        SELECT id, alert_id, author_id, note, created_at
+        FROM thyrasec.aml_alert_notes
+        WHERE alert_id = $1
+        ORDER BY created_at`
+	err := r.db.SelectContext(ctx, &notes, query, alertID)
+	return notes, err
+}
+
+func (r *AMLRepository) InsertNote(ctx context.Context, note models.AMLAlertNote) error {
+	query := `
+        INSERT INTO thyrasec.aml_alert_notes (id, alert_id, author_id, note, created_at)
+        VALUES (:id, :alert_id, :author_id, :note, :created_at)`
+	_, err := r.db.NamedExecContext(ctx, query, note)
+	return err
+}
+
+// AssignAlert sets the assignee of an alert that is not closed, moving an
+// open alert into review.
+func (r *AMLRepository) AssignAlert(ctx context.Context, alertID, assigneeID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.aml_alerts
+        SET assignee_id = $2, status = CASE WHEN status = 'open' THEN 'in_review' ELSE status END
+        WHERE id = $1 AND status <> 'closed'`, alertID, assigneeID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *AMLRepository) UpdateAlertStatus(ctx context.Context, alertID uuid.UUID, status string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.aml_alerts SET status = $2 WHERE id = $1 AND status <> 'closed'`, alertID, status)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *AMLRepository) CloseAlert(ctx context.Context, alertID uuid.UUID, resolution string, closedBy uuid.UUID, now time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.aml_alerts
+        SET status = 'closed', resolution = $2, closed_by = $3, closed_at = $4
+        WHERE id = $1 AND status <> 'closed'`, alertID, resolution, closedBy, now)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// IsComplianceUser reports whether the user can be assigned alerts.
+func (r *AMLRepository) IsComplianceUser(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `
+        SELECT EXISTS (SELECT 1 FROM thyrasec.user_roles WHERE user_id = $1 AND role = 'admin')`, userID)
+	return exists, err
+}
+
+func (r *AMLRepository) GetRuleSettings(ctx context.Context) (map[string]models.AMLRuleSetting, error) {
+	var rows []models.AMLRuleSetting
+	query := `SELECT rule_name, enabled, amount_threshold, window_days, min_count, updated_by, updated_at FROM thyrasec.aml_rule_settings`
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]models.AMLRuleSetting, len(rows))
+	for _, row := range rows {
+		settings[row.RuleName] = row
+	}
+	return settings, nil
+}
+
+func (r *AMLRepository) UpsertRuleSetting(ctx context.Context, setting models.AMLRuleSetting) error {
+	query := `
+        INSERT INTO thyrasec.aml_rule_settings (rule_name, enabled, amount_threshold, window_days, min_count, updated_by, updated_at)
+        VALUES (:rule_name, :enabled, :amount_threshold, :window_days, :min_count, :updated_by, :updated_at)
+        ON CONFLICT (rule_name) DO UPDATE
+        SET enabled = EXCLUDED.enabled, amount_threshold = EXCLUDED.amount_threshold, window_days = EXCLUDED.window_days,
+            min_count = EXCLUDED.min_count, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.NamedExecContext(ctx, query, setting)
+	return err
+}
@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/compliance/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PreTradeRepository struct {
+	db *sqlx.DB
+}
+
+func NewPreTradeRepository(db *sqlx.DB) *PreTradeRepository {
+	return &PreTradeRepository{db: db}
+}
+
+// LoadContext gathers the account, customer and instrument data the rules
+// evaluate. It runs on the order's transaction so the rules see the same
+// balances the order is placed against.
+func (r *PreTradeRepository) LoadContext(q sqlx.Queryer, order models.PreTradeOrder, now time.Time) (models.PreTradeContext, error) {
+	check := models.PreTradeContext{Order: order, Now: now}
+
+	var account struct {
+		AccountTypeID    uuid.UUID      `db:"account_type"`
+		AccountTypeName  sql.NullString `db:"account_type_name"`
+		HolderID         uuid.UUID      `db:"account_holder_id"`
+		Balance          float64        `db:"account_balance"`
+		HolderIsCustomer bool           `db:"holder_is_customer"`
+	}
+	accountQuery := `
+        SELECT a.account_type, at.account_type_name, a.account_holder_id, COALESCE(a.account_balance, 0) AS account_balance,
+            EXISTS (SELECT 1 FROM thyrasec.user_roles r WHERE r.user_id = a.account_holder_id AND r.role = 'customer') AS holder_is_customer
+        FROM thyrasec.accounts a
+        LEFT JOIN thyrasec.account_types at ON at.id = a.account_type
+        WHERE a.id = $1`
+	if err := sqlx.Get(q, &account, accountQuery, order.AccountID); err != nil {
+		return check, err
+	}
+	check.AccountTypeID = account.AccountTypeID
+	check.AccountTypeName = account.AccountTypeName.String
+	check.HolderIsCustomer = account.HolderIsCustomer
+
+	var values struct {
+		Holdings float64 `db:"holdings_value"`
+		Position float64 `db:"position_value"`
+	}
+	valuesQuery := `
+        SELECT COALESCE(SUM(h.quantity * COALESCE(s.current_price, 0)), 0) AS holdings_value,
+            COALESCE(SUM(CASE WHEN h.asset_id = $2 THEN h.quantity * COALESCE(s.current_price, 0) ELSE 0 END), 0) AS position_value
+        FROM thyrasec.holdings h
+        JOIN thyrasec.assets s ON s.id = h.asset_id
+        WHERE h.account_id = $1`
+	if err := sqlx.Get(q, &values, valuesQuery, order.AccountID, order.AssetID); err != nil {
+		return check, err
+	}
+	check.AccountValue = account.Balance + values.Holdings
+	check.PositionValue = values.Position
+
+	var suitability models.CustomerSuitability
+	suitabilityQuery := `
+        SELECT user_id, risk_profile, knowledge_level, assessed_by, assessed_at, valid_until
+        FROM thyrasec.customer_suitability
+        WHERE user_id = $1 AND (valid_until IS NULL OR valid_until > $2)`
+	if err := sqlx.Get(q, &suitability, suitabilityQuery, account.HolderID, now); err == nil {
+		check.Suitability = &suitability
+	} else if err != sql.ErrNoRows {
+		return check, err
+	}
+
+	var classification models.InstrumentClassification
+	classificationQuery := `
+        SELECT c.id, c.instrument_type, c.asset_type_id, c.complexity, c.risk_class
+        FROM thyrasec.assets a
+        JOIN thyrasec.instrument_classifications c
+            ON lower(c.instrument_type) = lower(a.instrument_type) OR c.asset_type_id = a.asset_type_id
+        WHERE a.id = $1
+        ORDER BY c.instrument_type IS NULL
+        LIMIT 1`
+	if err := sqlx.Get(q, &classification, classificationQuery, order.AssetID); err == nil {
+		check.Classification = &classification
+	} else if err != sql.ErrNoRows {
+		return check, err
+	}
+
+	var restriction models.RestrictedInstrument
+	restrictionQuery := `
+        SELECT id, asset_id, restriction, reason, valid_from, valid_to, created_by, created_at
+        FROM thyrasec.restricted_instruments
+        WHERE asset_id = $1 AND valid_from <= $2 AND (valid_to IS NULL OR valid_to > $2)
+        ORDER BY restriction = 'no_trade' DESC
+        LIMIT 1`
+	if err := sqlx.Get(q, &restriction, restrictionQuery, order.AssetID, now); err == nil {
+		check.Restriction = &restriction
+	} else if err != sql.ErrNoRows {
+		return check, err
+	}
+
+	var maxPositionPct float64
+	err := sqlx.Get(q, &maxPositionPct, `SELECT max_position_pct FROM thyrasec.account_concentration_limits WHERE account_id = $1`, order.AccountID)
+	if err == nil {
+		check.MaxPositionPct = &maxPositionPct
+	} else if err != sql.ErrNoRows {
+		return check, err
+	}
+
+	var maxOrderValue float64
+	err = sqlx.Get(q, &maxOrderValue, `SELECT max_order_value FROM thyrasec.account_type_order_limits WHERE account_type_id = $1`, account.AccountTypeID)
+	if err == nil {
+		check.MaxOrderValue = &maxOrderValue
+	} else if err != sql.ErrNoRows {
+		return check, err
+	}
+
+	return check, nil
+}
+
+func (r *PreTradeRepository) GetRuleSettings(q sqlx.Queryer) (map[string]models.RuleSetting, error) {
+	var rows []models.RuleSetting
+	if err := sqlx.Select(q, &rows, `SELECT rule_name, enabled, action, updated_by, updated_at FROM thyrasec.pretrade_rule_settings`); err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]models.RuleSetting, len(rows))
+	for _, row := range rows {
+		settings[row.RuleName] = row
+	}
+	return settings, nil
+}
+
+func (r *PreTradeRepository) UpsertRuleSetting(ctx context.Context, setting models.RuleSetting) error {
+	query := `
+        INSERT INTO thyrasec.pretrade_rule_settings (rule_name, enabled, action, updated_by, updated_at)
+        VALUES (:rule_name, :enabled, :action, :updated_by, :updated_at)
+        ON CONFLICT (rule_name) DO UPDATE
+        SET enabled = EXCLUDED.enabled, action = EXCLUDED.action, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.NamedExecContext(ctx, query, setting)
+	return err
+}
+
+// InsertChecks stores the rule results. Pass a transaction to store them with
+// the order, or the database for rejected attempts.
+func (r *PreTradeRepository) InsertChecks(e sqlx.Ext, checks []models.OrderComplianceCheck) error {
+	query := `
+        INSERT INTO thyrasec.order_compliance_checks
+            (id, order_id, account_id, asset_id, rule_name, outcome, message, acknowledged, acknowledged_by, evaluated_at)
+        VALUES
+            (:id, :order_id, :account_id, :asset_id, :rule_name, :outcome, :message, :acknowledged, :acknowledged_by, :evaluated_at)`
+	for _, check := range checks {
+		if _, err := sqlx.NamedExec(e, query, check); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PreTradeRepository) GetOrderChecks(ctx context.Context, orderID uuid.UUID) ([]models.OrderComplianceCheck, error) {
+	var checks []models.OrderComplianceCheck
+	query := `
+        SELECT id, order_id, account_id, asset_id, rule_name, outcome, message, acknowledged, acknowledged_by, evaluated_at
+        FROM thyrasec.order_compliance_checks
+        WHERE order_id = $1
+        ORDER BY rule_name`
+	err := r.db.SelectContext(ctx, &checks, query, orderID)
+	return checks, err
+}
+
+func (r *PreTradeRepository) GetAccountChecks(ctx context.Context, accountID uuid.UUID, limit int) ([]models.OrderComplianceCheck, error) {
+	var checks []models.OrderComplianceCheck
+	query := `
+        SELECT id, order_id, account_id, asset_id, rule_name, outcome, message, acknowledged, acknowledged_by, evaluated_at
+        FROM thyrasec.order_compliance_checks
+        WHERE account_id = $1 AND outcome <> 'pass'
+        ORDER BY evaluated_at DESC
+        LIMIT $2`
+	err := r.db.SelectContext(ctx, &checks, query, accountID, limit)
+	return checks, err
+}
+
+func (r *PreTradeRepository) GetSuitability(ctx context.Context, userID uuid.UUID) (models.CustomerSuitability, error) {
+	var suitability models.CustomerSuitability
+	query := `SELECT user_id, risk_profile, knowledge_level, assessed_by, assessed_at, valid_until FROM thyrasec.customer_suitability WHERE user_id = $1`
+	err := r.db.GetContext(ctx, &suitability, query, userID)
+	return suitability, err
+}
+
+func (r *PreTradeRepository) UpsertSuitability(ctx context.Context, suitability models.CustomerSuitability) error {
+	query := `
+        INSERT INTO thyrasec.customer_suitability (user_id, risk_profile, knowledge_level, assessed_by, assessed_at, valid_until)
+        VALUES (:user_id, :risk_profile, :knowledge_level, :assessed_by, :assessed_at, :valid_until)
+        ON CONFLICT (user_id) DO UPDATE
+        SET risk_profile = EXCLUDED.risk_profile,
+            knowledge_level = EXCLUDED.knowledge_level,
+            assessed_by = EXCLUDED.assessed_by,
+            assessed_at = EXCLUDED.assessed_at,
+            valid_until = EXCLUDED.valid_until`
+	_, err := r.db.NamedExecContext(ctx, query, suitability)
+	return err
+}
+
+func (r *PreTradeRepository) GetClassifications(ctx context.Context) ([]models.InstrumentClassification, error) {
+	var classifications []models.InstrumentClassification
+	query := `SELECT id, instrument_type, asset_type_id, complexity, risk_class FROM thyrasec.instrument_classifications ORDER BY instrument_type NULLS LAST`
+	err := r.db.SelectContext(ctx, &classifications, query)
+	return classifications, err
+}
+
+func (r *PreTradeRepository) UpsertClassification(ctx context.Context, classification models.InstrumentClassification) error {
+	conflict := "(instrument_type)"
+	if classification.InstrumentType == nil {
+		conflict = "(asset_type_id)"
+	}
+	query := `
+        INSERT INTO thyrasec.instrument_classifications (id, instrument_type, asset_type_id, complexity, risk_class)
+        VALUES (:id, :instrument_type, :asset_type_id, :complexity, :risk_class)
+        ON CONFLICT ` + conflict + ` DO UPDATE
+        SET complexity = EXCLUDED.complexity, risk_class = EXCLUDED.risk_class`
+	_, err := r.db.NamedExecContext(ctx, query, classification)
+	return err
+}
+
+func (r *PreTradeRepository) GetRestrictedInstruments(ctx context.Context, now time.Time) ([]models.RestrictedInstrument, error) {
+	var restricted []models.RestrictedInstrument
+	query := `
+        SELECT id, asset_id, restriction, reason, valid_from, valid_to, created_by, created_at
+        FROM thyrasec.restricted_instruments
+        WHERE valid_to IS NULL OR valid_to > $1
+        ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &restricted, query, now)
+	return restricted, err
+}
+
+func (r *PreTradeRepository) InsertRestrictedInstrument(ctx context.Context, restricted models.RestrictedInstrument) error {
+	query := `
+        INSERT INTO thyrasec.restricted_instruments (id, asset_id, restriction, reason, valid_from, valid_to, created_by, created_at)
+        VALUES (:id, :asset_id, :restriction, :reason, :valid_from, :valid_to, :created_by, :created_at)`
+	_, err := r.db.NamedExecContext(ctx, query, restricted)
+	return err
+}
+
+// EndRestriction lifts a restriction by closing its validity period so the
+// history is kept.
+func (r *PreTradeRepository) EndRestriction(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE thyrasec.restricted_instruments SET valid_to = $1 WHERE id = $2 AND (valid_to IS NULL OR valid_to > $1)`, now, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *PreTradeRepository) UpsertConcentrationLimit(ctx context.Context, accountID uuid.UUID, maxPositionPct float64, updatedBy uuid.UUID, now time.Time) error {
+	query := `
+        INSERT INTO thyrasec.account_concentration_limits (account_id, max_position_pct, updated_by, updated_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (account_id) DO UPDATE
+        SET max_position_pct = EXCLUDED.max_position_pct, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, query, accountID, maxPositionPct, updatedBy, now)
+	return err
+}
+
+func (r *PreTradeRepository) UpsertOrderLimit(ctx context.Context, accountTypeID uuid.UUID, maxOrderValue float64, updatedBy uuid.UUID, now time.Time) error {
+	query := `
+        INSERT INTO thyrasec.account_type_order_limits (account_type_id, max_order_value, updated_by, updated_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (account_type_id) DO UPDATE
+        SET max_order_value = EXCLUDED.max_order_value, updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, query, accountTypeID, maxOrderValue, updatedBy, now)
+	return err
+}
@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/compliance/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ScreeningRepository struct {
+	db *sqlx.DB
+}
+
+func NewScreeningRepository(db *sqlx.DB) *ScreeningRepository {
+	return &ScreeningRepository{db: db}
+}
+
+func (r *ScreeningRepository) ListFileImported(ctx context.Context, sha256 string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM thyrasec.sanctions_lists WHERE file_sha256 = $1)`, sha256)
+	return exists, err
+}
+
+// ReplaceList stores a newly imported list and its entries, and deactivates
+// the previous list from the same source.
+func (r *ScreeningRepository) ReplaceList(ctx context.Context, list models.SanctionsList, entries []models.SanctionsEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE thyrasec.sanctions_lists SET active = false WHERE source = $1 AND active`, list.Source); err != nil {
+		return err
+	}
+
+	listQuery := `
+        INSERT INTO thyrasec.sanctions_lists (id, source, list_type, file_name, file_sha256, entry_count, active, imported_by, imported_at)
+        VALUES (:id, :source, :list_type, :file_name, :file_sha256, :entry_count, :active, :imported_by, :imported_at)`
+	if _, err := tx.NamedExecContext(ctx, listQuery, list); err != nil {
+		return err
+	}
+
+	entryQuery := `
+        INSERT INTO thyrasec.sanctions_entries (id, list_id, external_id, subject_type, names, birth_dates, countries, programme)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (list_id, external_id) DO NOTHING`
+	stmt, err := tx.PreparexContext(ctx, entryQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, entry := range entries {
+		if _, err := stmt.ExecContext(ctx, entry.ID, list.ID, entry.ExternalID, entry.SubjectType,
+			nonNull(entry.Names), nonNull(entry.BirthDates), nonNull(entry.Countries), entry.Programme); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ScreeningRepository) GetLists(ctx context.Context) ([]models.SanctionsList, error) {
+	var lists []models.SanctionsList
+	query := `
+        SELECT id, source, list_type, file_name, file_sha256, entry_count, active, imported_by, imported_at
+        FROM thyrasec.sanctions_lists
+        ORDER BY imported_at DESC`
+	err := r.db.SelectContext(ctx, &lists, query)
+	return lists, err
+}
+
+func (r *ScreeningRepository) GetActiveEntries(ctx context.Context) ([]models.ScreeningEntry, error) {
+	var entries []models.ScreeningEntry
+	query := `
+        SELECT e.id, e.list_id, e.external_id, e.subject_type, e.names, e.birth_dates, e.countries, e.programme,
+            l.source AS list_source, l.list_type
+        FROM thyrasec.sanctions_entries e
+        JOIN thyrasec.sanctions_lists l ON l.id = e.list_id
+        WHERE l.active`
+	err := r.db.SelectContext(ctx, &entries, query)
+	return entries, err
+}
+
+func (r *ScreeningRepository) GetEntry(ctx context.Context, entryID uuid.UUID) (models.SanctionsEntry, error) {
+	var entry models.SanctionsEntry
+	query := `
+        SELECT id, list_id, external_id, subject_type, names, birth_dates, countries, programme
+        FROM thyrasec.sanctions_entries
+        WHERE id = $1`
+	err := r.db.GetContext(ctx, &entry, query, entryID)
+	return entry, err
+}
+
+const screenedCustomerQuery = `
+        SELECT cp.user_id, cp.full_name, k.date_of_birth, k.citizenship
+        FROM thyrasec.customer_profiles cp
+        LEFT JOIN thyrasec.kyc_profiles k ON k.user_id = cp.user_id`
+
+func (r *ScreeningRepository) GetCustomer(ctx context.Context, userID uuid.UUID) (models.ScreenedCustomer, error) {
+	var customer models.ScreenedCustomer
+	err := r.db.GetContext(ctx, &customer, screenedCustomerQuery+` WHERE cp.user_id = $1`, userID)
+	return customer, err
+}
+
+func (r *ScreeningRepository) GetCustomers(ctx context.Context) ([]models.ScreenedCustomer, error) {
+	var customers []models.ScreenedCustomer
+	err := r.db.SelectContext(ctx, &customers, screenedCustomerQuery)
+	return customers, err
+}
+
+// InsertCase opens a case unless the customer already has one for the same
+// listed subject. It reports whether a case was created.
+func (r *ScreeningRepository) InsertCase(ctx context.Context, screeningCase models.ScreeningCase) (bool, error) {
+	query := `
+        INSERT INTO thyrasec.screening_cases
+            (id, user_id, list_source, list_type, external_id, entry_id, customer_name, matched_name, score,
+             birth_date_match, trigger, status, created_at)
+        VALUES
+            (:id, :user_id, :list_source, :list_type, :external_id, :entry_id, :customer_name, :matched_name, :score,
+             :birth_date_match, :trigger, :status, :created_at)
+        ON CONFLICT (user_id, list_source, external_id) DO NOTHING`
+	result, err := r.db.NamedExecContext(ctx, query, screeningCase)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *ScreeningRepository) InsertRun(ctx context.Context, run models.ScreeningRun) error {
+	query := `
+        INSERT INTO thyrasec.screening_runs (id, trigger, list_id, customers_screened, new_cases, started_at, finished_at)
+        VALUES (:id, :trigger, :list_id, :customers_screened, :new_cases, :started_at, :finished_at)`
+	_, err := r.db.NamedExecContext(ctx, query, run)
+	return err
+}
+
+func (r *ScreeningRepository) GetCases(ctx context.Context, status string) ([]models.ScreeningCase, error) {
+	var cases []models.ScreeningCase
+	query := `
+        SELECT id, user_id, list_source, list_type, external_id, entry_id, customer_name, matched_name, score,
+            birth_date_match, trigger, status, reviewed_by, reviewed_at, review_note, created_at
+        FROM thyrasec.screening_cases
+        WHERE $1 = '' OR status = $1
+        ORDER BY created_at DESC`
+	err := r.db.SelectContext(ctx, &cases, query, status)
+	return cases, err
+}
+
+func (r *ScreeningRepository) GetCase(ctx context.Context, caseID uuid.UUID) (models.ScreeningCase, error) {
+	var screeningCase models.ScreeningCase
+	query := `
+        SELECT id, user_id, list_source, list_type, external_id, entry_id, customer_name, matched_name, score,
+            birth_date_match, trigger, status, reviewed_by, reviewed_at, review_note, created_at
+        FROM thyrasec.screening_cases
+        WHERE id = $1`
+	err := r.db.GetContext(ctx, &screeningCase, query, caseID)
+	return screeningCase, err
+}
+
+// DecideCase records the review of an open case.
+func (r *ScreeningRepository) DecideCase(ctx context.Context, caseID uuid.UUID, status string, reviewerID uuid.UUID, note string, now time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.screening_cases
+        SET status = $2, reviewed_by = $3, reviewed_at = $4, review_note = $5
+        WHERE id = $1 AND status = 'open'`, caseID, status, reviewerID, now, note)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// nonNull keeps empty arrays from being stored as NULL.
+func nonNull(values pq.StringArray) pq.StringArray {
+	if values == nil {
+		return pq.StringArray{}
+	}
+	return values
+}
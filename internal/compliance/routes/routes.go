@@ -0,0 +1,47 @@
+package routes
+
+import (
+	handlers "thyra/internal/compliance/api/compliance"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, preTradeHandler *handlers.PreTradeHandler, amlHandler *handlers.AMLHandler, screeningHandler *handlers.ScreeningHandler) {
+	compliance := router.Group("/compliance")
+
+	// Pre-trade checks
+	compliance.GET("/orders/:orderId/checks", preTradeHandler.GetOrderChecksHandler)
+	compliance.GET("/accounts/:accountId/checks", preTradeHandler.GetAccountChecksHandler)
+	compliance.GET("/suitability/:userId", preTradeHandler.GetSuitabilityHandler)
+	compliance.PUT("/suitability/:userId", preTradeHandler.SetSuitabilityHandler)
+	compliance.GET("/instrument-classifications", preTradeHandler.GetClassificationsHandler)
+	compliance.PUT("/instrument-classifications", preTradeHandler.SetClassificationHandler)
+	compliance.GET("/restricted-instruments", preTradeHandler.GetRestrictedInstrumentsHandler)
+	compliance.POST("/restricted-instruments", preTradeHandler.AddRestrictedInstrumentHandler)
+	compliance.DELETE("/restricted-instruments/:restrictionId", preTradeHandler.EndRestrictionHandler)
+	compliance.PUT("/accounts/:accountId/concentration-limit", preTradeHandler.SetConcentrationLimitHandler)
+	compliance.PUT("/account-types/:accountTypeId/order-limit", preTradeHandler.SetOrderLimitHandler)
+	compliance.GET("/pretrade-rules", preTradeHandler.GetRuleSettingsHandler)
+	compliance.PUT("/pretrade-rules/:ruleName", preTradeHandler.SetRuleSettingHandler)
+
+	// AML transaction monitoring
+	aml := compliance.Group("/aml")
+	aml.GET("/alerts", amlHandler.GetAlertsHandler)
+	aml.GET("/alerts/:alertId", amlHandler.GetAlertHandler)
+	aml.POST("/alerts/:alertId/assign", amlHandler.AssignAlertHandler)
+	aml.POST("/alerts/:alertId/notes", amlHandler.AddNoteHandler)
+	aml.PUT("/alerts/:alertId/status", amlHandler.UpdateAlertStatusHandler)
+	aml.POST("/alerts/:alertId/close", amlHandler.CloseAlertHandler)
+	aml.POST("/batch", amlHandler.RunBatchHandler)
+	aml.GET("/rules", amlHandler.GetRuleSettingsHandler)
+	aml.PUT("/rules/:ruleName", amlHandler.SetRuleSettingHandler)
+
+	// Sanctions and PEP screening
+	screening := compliance.Group("/screening")
+	screening.GET("/lists", screeningHandler.GetListsHandler)
+	screening.POST("/lists", screeningHandler.ImportListHandler)
+	screening.POST("/customers/:userId", screeningHandler.ScreenCustomerHandler)
+	screening.GET("/cases", screeningHandler.GetCasesHandler)
+	screening.GET("/cases/:caseId", screeningHandler.GetCaseHandler)
+	screening.POST("/cases/:caseId/decision", screeningHandler.DecideCaseHandler)
+}
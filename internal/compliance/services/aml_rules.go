@@ -0,0 +1,154 @@
+package services
+
+import (
+	"fmt"
+	"thyra/internal/compliance/models"
+	"time"
+)
+
+// AMLRule is a single transaction monitoring scenario. Evaluate returns an
+// alert description when the event matches the rule under the given setting.
+type AMLRule interface {
+	Name() string
+	DefaultSetting() models.AMLRuleSetting
+	Evaluate(check models.AMLContext, setting models.AMLRuleSetting) (string, bool)
+}
+
+// DefaultAMLRules returns the rules every cash event is evaluated against.
+func DefaultAMLRules() []AMLRule {
+	return []AMLRule{
+		LargeCashRule{},
+		StructuringRule{},
+		RapidInOutRule{},
+		DormantReactivationRule{},
+	}
+}
+
+// LargeCashRule flags single deposits or withdrawals at or above the threshold.
+type LargeCashRule struct{}
+
+func (LargeCashRule) Name() string { return "large_cash_movement" }
+
+func (r LargeCashRule) DefaultSetting() models.AMLRuleSetting {
+	return models.AMLRuleSetting{RuleName: r.Name(), Enabled: true, AmountThreshold: 150000}
+}
+
+func (LargeCashRule) Evaluate(check models.AMLContext, setting models.AMLRuleSetting) (string, bool) {
+	if check.Event.Amount < setting.AmountThreshold {
+		return "", false
+	}
+	return fmt.Sprintf("%s of %.2f is at or above the reporting threshold of %.2f",
+		directionLabel(check.Event.Direction), check.Event.Amount, setting.AmountThreshold), true
+}
+
+// StructuringRule flags several deposits that each stay below the threshold
+// but together reach it within the window.
+type StructuringRule struct{}
+
+func (StructuringRule) Name() string { return "structuring" }
+
+func (r StructuringRule) DefaultSetting() models.AMLRuleSetting {
+	return models.AMLRuleSetting{RuleName: r.Name(), Enabled: true, AmountThreshold: 150000, WindowDays: 7, MinCount: 3}
+}
+
+func (StructuringRule) Evaluate(check models.AMLContext, setting models.AMLRuleSetting) (string, bool) {
+	event := check.Event
+	if event.Direction != models.CashDirectionIn || event.Amount >= setting.AmountThreshold {
+		return "", false
+	}
+
+	count, total := 1, event.Amount
+	for _, recent := range withinWindow(check, setting.WindowDays) {
+		if recent.Direction == models.CashDirectionIn && recent.Amount < setting.AmountThreshold {
+			count++
+			total += recent.Amount
+		}
+	}
+	if count < setting.MinCount || total < setting.AmountThreshold {
+		return "", false
+	}
+	return fmt.Sprintf("%d deposits below %.2f totalling %.2f within %d days",
+		count, setting.AmountThreshold, total, setting.WindowDays), true
+}
+
+// rapidOutShare is how much of the recent deposits must leave again for
+// RapidInOutRule to trigger.
+const rapidOutShare = 0.8
+
+// RapidInOutRule flags withdrawals that move most of recently deposited cash
+// straight back out.
+type RapidInOutRule struct{}
+
+func (RapidInOutRule) Name() string { return "rapid_in_out" }
+
+func (r RapidInOutRule) DefaultSetting() models.AMLRuleSetting {
+	return models.AMLRuleSetting{RuleName: r.Name(), Enabled: true, AmountThreshold: 50000, WindowDays: 3}
+}
+
+func (RapidInOutRule) Evaluate(check models.AMLContext, setting models.AMLRuleSetting) (string, bool) {
+	event := check.Event
+	if event.Direction != models.CashDirectionOut {
+		return "", false
+	}
+
+	deposited, withdrawn := 0.0, event.Amount
+	for _, recent := range withinWindow(check, setting.WindowDays) {
+		if recent.Direction == models.CashDirectionIn {
+			deposited += recent.Amount
+		} else {
+			withdrawn += recent.Amount
+		}
+	}
+	if deposited < setting.AmountThreshold || withdrawn < deposited*rapidOutShare {
+		return "", false
+	}
+	return fmt.Sprintf("%.2f withdrawn after %.2f was deposited within %d days",
+		withdrawn, deposited, setting.WindowDays), true
+}
+
+// DormantReactivationRule flags a sizeable movement on an account that has
+// had no cash activity for the window.
+type DormantReactivationRule struct{}
+
+func (DormantReactivationRule) Name() string { return "dormant_reactivation" }
+
+func (r DormantReactivationRule) DefaultSetting() models.AMLRuleSetting {
+	return models.AMLRuleSetting{RuleName: r.Name(), Enabled: true, AmountThreshold: 10000, WindowDays: 365}
+}
+
+func (DormantReactivationRule) Evaluate(check models.AMLContext, setting models.AMLRuleSetting) (string, bool) {
+	event := check.Event
+	// An account's first movement is an opening deposit, not a reactivation.
+	if check.Previous == nil || event.Amount < setting.AmountThreshold {
+		return "", false
+	}
+
+	idle := event.OccurredAt.Sub(check.Previous.OccurredAt)
+	if idle < days(setting.WindowDays) {
+		return "", false
+	}
+	return fmt.Sprintf("%s of %.2f after %d days without cash activity",
+		directionLabel(event.Direction), event.Amount, int(idle.Hours()/24)), true
+}
+
+func withinWindow(check models.AMLContext, windowDays int) []models.CashEvent {
+	since := check.Event.OccurredAt.Add(-days(windowDays))
+	events := make([]models.CashEvent, 0, len(check.Recent))
+	for _, recent := range check.Recent {
+		if !recent.OccurredAt.Before(since) {
+			events = append(events, recent)
+		}
+	}
+	return events
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+func directionLabel(direction string) string {
+	if direction == models.CashDirectionOut {
+		return "Withdrawal"
+	}
+	return "Deposit"
+}
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"thyra/internal/compliance/models"
+	"thyra/internal/compliance/repositories"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// amlBatchSize caps how many events one scheduled run evaluates, so a large
+// import is worked through over several runs.
+const amlBatchSize = 5000
+
+var (
+	ErrAMLAccessDenied     = errors.New("only admins can work AML alerts")
+	ErrAlertNotFound       = errors.New("alert not found")
+	ErrAlertClosed         = errors.New("alert is closed")
+	ErrInvalidAssignee     = errors.New("alerts can only be assigned to admins")
+	ErrInvalidAlertStatus  = errors.New("status must be open, in_review or escalated")
+	ErrInvalidResolution   = errors.New("resolution must be false_positive, no_action or reported")
+	ErrNoteRequired        = errors.New("a note is required")
+	ErrUnknownAMLRule      = errors.New("unknown AML rule")
+	ErrInvalidAMLParameter = errors.New("amount_threshold, window_days and min_count must not be negative")
+)
+
+type AMLService struct {
+	repo  *repositories.AMLRepository
+	rules []AMLRule
+}
+
+// NewAMLService evaluates the given rules, or DefaultAMLRules when none are
+// passed.
+func NewAMLService(repo *repositories.AMLRepository, rules ...AMLRule) *AMLService {
+	if len(rules) == 0 {
+		rules = DefaultAMLRules()
+	}
+	return &AMLService{repo: repo, rules: rules}
+}
+
+// MonitorCashTransaction records a booked deposit or withdrawal and evaluates
+// it straight away. An event that was already evaluated is left alone.
+func (s *AMLService) MonitorCashTransaction(ctx context.Context, event models.CashEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	stored, err := s.repo.RecordEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+	if stored.EvaluatedAt != nil {
+		return nil
+	}
+
+	settings, err := s.effectiveSettings(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.evaluate(ctx, stored, settings)
+	return err
+}
+
+// RunBatch imports cash transactions the monitor has not seen and evaluates
+// every event that is still pending.
+func (s *AMLService) RunBatch(ctx context.Context) (models.AMLBatchResult, error) {
+	var result models.AMLBatchResult
+
+	imported, err := s.repo.ImportCashTransactions(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Imported = imported
+
+	settings, err := s.effectiveSettings(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	events, err := s.repo.GetUnevaluatedEvents(ctx, amlBatchSize)
+	if err != nil {
+		return result, err
+	}
+	for _, event := range events {
+		alerts, err := s.evaluate(ctx, event, settings)
+		if err != nil {
+			log.Printf("AML evaluation of event %s failed: %v", event.ID, err)
+			continue
+		}
+		result.Evaluated++
+		result.Alerts += alerts
+	}
+	return result, nil
+}
+
+// evaluate runs every enabled rule against the event and returns the number
+// of new alerts opened.
+func (s *AMLService) evaluate(ctx context.Context, event models.CashEvent, settings map[string]models.AMLRuleSetting) (int, error) {
+	window := 0
+	for _, setting := range settings {
+		if setting.Enabled && setting.WindowDays > window {
+			window = setting.WindowDays
+		}
+	}
+
+	check, err := s.repo.LoadContext(ctx, event, window)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	alerts := 0
+	for _, rule := range s.rules {
+		setting := settings[rule.Name()]
+		if !setting.Enabled {
+			continue
+		}
+		description, hit := rule.Evaluate(check, setting)
+		if !hit {
+			continue
+		}
+		created, err := s.repo.RaiseAlert(ctx, event.AccountID, rule.Name(), description, event.ID, now)
+		if err != nil {
+			return alerts, err
+		}
+		if created {
+			alerts++
+		}
+	}
+
+	return alerts, s.repo.MarkEvaluated(ctx, event.ID, now)
+}
+
+func (s *AMLService) effectiveSettings(ctx context.Context) (map[string]models.AMLRuleSetting, error) {
+	overrides, err := s.repo.GetRuleSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]models.AMLRuleSetting, len(s.rules))
+	for _, rule := range s.rules {
+		setting, ok := overrides[rule.Name()]
+		if !ok {
+			setting = rule.DefaultSetting()
+		}
+		settings[rule.Name()] = setting
+	}
+	return settings, nil
+}
+
+func (s *AMLService) GetAlerts(ctx context.Context, authUserRole string, filter models.AMLAlertFilter) ([]models.AMLAlert, error) {
+	if authUserRole != "admin" {
+		return nil, ErrAMLAccessDenied
+	}
+	return s.repo.GetAlerts(ctx, filter)
+}
+
+func (s *AMLService) GetAlert(ctx context.Context, alertID uuid.UUID, authUserRole string) (models.AMLAlertDetails, error) {
+	var details models.AMLAlertDetails
+	if authUserRole != "admin" {
+		return details, ErrAMLAccessDenied
+	}
+
+	alert, err := s.repo.GetAlert(ctx, alertID)
+	if err == sql.ErrNoRows {
+		return details, ErrAlertNotFound
+	}
+	if err != nil {
+		return details, err
+	}
+	details.Alert = alert
+
+	if details.Events, err = s.repo.GetAlertEvents(ctx, alertID); err != nil {
+		return details, err
+	}
+	if details.Notes, err = s.repo.GetAlertNotes(ctx, alertID); err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+func (s *AMLService) AssignAlert(ctx context.Context, alertID uuid.UUID, authUserRole string, req models.AssignAlertRequest) error {
+	if authUserRole != "admin" {
+		return ErrAMLAccessDenied
+	}
+
+	eligible, err := s.repo.IsComplianceUser(ctx, req.AssigneeID)
+	if err != nil {
+		return err
+	}
+	if !eligible {
+		return ErrInvalidAssignee
+	}
+
+	updated, err := s.repo.AssignAlert(ctx, alertID, req.AssigneeID)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return s.missingOrClosed(ctx, alertID)
+	}
+	return nil
+}
+
+func (s *AMLService) AddNote(ctx context.Context, alertID, authorID uuid.UUID, authUserRole string, req models.AlertNoteRequest) (models.AMLAlertNote, error) {
+	if authUserRole != "admin" {
+		return models.AMLAlertNote{}, ErrAMLAccessDenied
+	}
+	text := strings.TrimSpace(req.Note)
+	if text == "" {
+		return models.AMLAlertNote{}, ErrNoteRequired
+	}
+	if _, err := s.repo.GetAlert(ctx, alertID); err == sql.ErrNoRows {
+		return models.AMLAlertNote{}, ErrAlertNotFound
+	} else if err != nil {
+		return models.AMLAlertNote{}, err
+	}
+
+	note := models.AMLAlertNote{
+		ID:        uuid.New(),
+		AlertID:   alertID,
+		AuthorID:  authorID,
+		Note:      text,
+		CreatedAt: time.Now(),
+	}
+	return note, s.repo.InsertNote(ctx, note)
+}
+
+// UpdateAlertStatus moves an alert between the working statuses. Closing goes
+// through CloseAlert, which requires a resolution.
+func (s *AMLService) UpdateAlertStatus(ctx context.Context, alertID, authorID uuid.UUID, authUserRole string, req models.AlertStatusRequest) error {
+	if authUserRole != "admin" {
+		return ErrAMLAccessDenied
+	}
+	switch req.Status {
+	case models.AlertStatusOpen, models.AlertStatusInReview, models.AlertStatusEscalated:
+	default:
+		return ErrInvalidAlertStatus
+	}
+
+	updated, err := s.repo.UpdateAlertStatus(ctx, alertID, req.Status)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return s.missingOrClosed(ctx, alertID)
+	}
+	return s.addOptionalNote(ctx, alertID, authorID, req.Note)
+}
+
+func (s *AMLService) CloseAlert(ctx context.Context, alertID, closedBy uuid.UUID, authUserRole string, req models.CloseAlertRequest) error {
+	if authUserRole != "admin" {
+		return ErrAMLAccessDenied
+	}
+	switch req.Resolution {
+	case models.AlertResolutionFalsePositive, models.AlertResolutionNoAction, models.AlertResolutionReported:
+	default:
+		return ErrInvalidResolution
+	}
+	// The rationale for closing is part of the audit trail.
+	if strings.TrimSpace(req.Note) == "" {
+		return ErrNoteRequired
+	}
+
+	closed, err := s.repo.CloseAlert(ctx, alertID, req.Resolution, closedBy, time.Now())
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return s.missingOrClosed(ctx, alertID)
+	}
+	return s.addOptionalNote(ctx, alertID, closedBy, req.Note)
+}
+
+func (s *AMLService) addOptionalNote(ctx context.Context, alertID, authorID uuid.UUID, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	return s.repo.InsertNote(ctx, models.AMLAlertNote{
+		ID:        uuid.New(),
+		AlertID:   alertID,
+		AuthorID:  authorID,
+		Note:      text,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *AMLService) missingOrClosed(ctx context.Context, alertID uuid.UUID) error {
+	if _, err := s.repo.GetAlert(ctx, alertID); err == sql.ErrNoRows {
+		return ErrAlertNotFound
+	} else if err != nil {
+		return err
+	}
+	return ErrAlertClosed
+}
+
+// GetRuleSettings lists every registered rule with its effective settings.
+func (s *AMLService) GetRuleSettings(ctx context.Context, authUserRole string) ([]models.AMLRuleSetting, error) {
+	if authUserRole != "admin" {
+		return nil, ErrAMLAccessDenied
+	}
+	settings, err := s.effectiveSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]models.AMLRuleSetting, 0, len(s.rules))
+	for _, rule := range s.rules {
+		list = append(list, settings[rule.Name()])
+	}
+	return list, nil
+}
+
+func (s *AMLService) SetRuleSetting(ctx context.Context, ruleName string, updatedBy uuid.UUID, authUserRole string, req models.AMLRuleSettingRequest) error {
+	if authUserRole != "admin" {
+		return ErrComplianceAdminOnly
+	}
+	if req.AmountThreshold < 0 || req.WindowDays < 0 || req.MinCount < 0 {
+		return ErrInvalidAMLParameter
+	}
+
+	known := false
+	for _, rule := range s.rules {
+		if rule.Name() == ruleName {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return ErrUnknownAMLRule
+	}
+
+	now := time.Now()
+	return s.repo.UpsertRuleSetting(ctx, models.AMLRuleSetting{
+		RuleName:        ruleName,
+		Enabled:         req.Enabled,
+		AmountThreshold: req.AmountThreshold,
+		WindowDays:      req.WindowDays,
+		MinCount:        req.MinCount,
+		UpdatedBy:       &updatedBy,
+		UpdatedAt:       &now,
+	})
+}
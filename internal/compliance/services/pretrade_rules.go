@@ -0,0 +1,128 @@
+package services
+
+import (
+	"fmt"
+	"thyra/internal/compliance/models"
+)
+
+// PreTradeRule is a single pre-trade check. Evaluate returns a message when
+// the order violates the rule; what happens then is decided by the rule's
+// action, which defaults to DefaultAction and can be overridden per rule.
+type PreTradeRule interface {
+	Name() string
+	DefaultAction() string
+	Evaluate(check models.PreTradeContext) (string, bool)
+}
+
+// DefaultPreTradeRules returns the rules evaluated for every order.
+func DefaultPreTradeRules() []PreTradeRule {
+	return []PreTradeRule{
+		RestrictedListRule{},
+		MaxOrderValueRule{},
+		ConcentrationRule{},
+		RiskProfileRule{},
+		KnowledgeRule{},
+	}
+}
+
+var requiredKnowledge = map[string]string{
+	models.ComplexityNonComplex:    models.KnowledgeNone,
+	models.ComplexityComplex:       models.KnowledgeInformed,
+	models.ComplexityHighlyComplex: models.KnowledgeAdvanced,
+}
+
+var knowledgeRank = map[string]int{
+	models.KnowledgeNone:     0,
+	models.KnowledgeBasic:    1,
+	models.KnowledgeInformed: 2,
+	models.KnowledgeAdvanced: 3,
+}
+
+type RestrictedListRule struct{}
+
+func (RestrictedListRule) Name() string          { return "restricted_list" }
+func (RestrictedListRule) DefaultAction() string { return models.RuleActionBlock }
+
+func (RestrictedListRule) Evaluate(check models.PreTradeContext) (string, bool) {
+	r := check.Restriction
+	if r == nil {
+		return "", false
+	}
+	if r.Restriction == models.RestrictionNoBuy && check.Order.Side != models.OrderSideBuy {
+		return "", false
+	}
+	return fmt.Sprintf("instrument is restricted (%s): %s", r.Restriction, r.Reason), true
+}
+
+type MaxOrderValueRule struct{}
+
+func (MaxOrderValueRule) Name() string          { return "max_order_value" }
+func (MaxOrderValueRule) DefaultAction() string { return models.RuleActionBlock }
+
+func (MaxOrderValueRule) Evaluate(check models.PreTradeContext) (string, bool) {
+	if check.MaxOrderValue == nil || check.Order.TotalAmount <= *check.MaxOrderValue {
+		return "", false
+	}
+	return fmt.Sprintf("order value %.2f exceeds the %.2f limit for %s accounts", check.Order.TotalAmount, *check.MaxOrderValue, check.AccountTypeName), true
+}
+
+// ConcentrationRule only looks at buys, as selling never increases a position.
+type ConcentrationRule struct{}
+
+func (ConcentrationRule) Name() string          { return "concentration_limit" }
+func (ConcentrationRule) DefaultAction() string { return models.RuleActionAcknowledge }
+
+func (ConcentrationRule) Evaluate(check models.PreTradeContext) (string, bool) {
+	if check.MaxPositionPct == nil || check.Order.Side != models.OrderSideBuy || check.AccountValue <= 0 {
+		return "", false
+	}
+	// Buying moves cash into the position, so the account value is unchanged.
+	pct := (check.PositionValue + check.Order.TotalAmount) / check.AccountValue * 100
+	if pct <= *check.MaxPositionPct {
+		return "", false
+	}
+	return fmt.Sprintf("position would be %.1f%% of the account, above the %.1f%% limit", pct, *check.MaxPositionPct), true
+}
+
+// RiskProfileRule is the suitability check: the instrument's risk class may
+// not exceed the customer's risk profile.
+type RiskProfileRule struct{}
+
+func (RiskProfileRule) Name() string          { return "risk_profile" }
+func (RiskProfileRule) DefaultAction() string { return models.RuleActionAcknowledge }
+
+func (RiskProfileRule) Evaluate(check models.PreTradeContext) (string, bool) {
+	if !check.HolderIsCustomer || check.Order.Side != models.OrderSideBuy || check.Classification == nil {
+		return "", false
+	}
+	if check.Suitability == nil {
+		return "customer has no valid suitability assessment", true
+	}
+	if check.Classification.RiskClass > check.Suitability.RiskProfile {
+		return fmt.Sprintf("instrument risk class %d is above the customer's risk profile %d", check.Classification.RiskClass, check.Suitability.RiskProfile), true
+	}
+	return "", false
+}
+
+// KnowledgeRule is the appropriateness check for complex instruments.
+type KnowledgeRule struct{}
+
+func (KnowledgeRule) Name() string          { return "knowledge_assessment" }
+func (KnowledgeRule) DefaultAction() string { return models.RuleActionAcknowledge }
+
+func (KnowledgeRule) Evaluate(check models.PreTradeContext) (string, bool) {
+	if !check.HolderIsCustomer || check.Order.Side != models.OrderSideBuy || check.Classification == nil {
+		return "", false
+	}
+	required := requiredKnowledge[check.Classification.Complexity]
+	if knowledgeRank[required] == 0 {
+		return "", false
+	}
+	if check.Suitability == nil {
+		return fmt.Sprintf("%s instrument requires a knowledge assessment", check.Classification.Complexity), true
+	}
+	if knowledgeRank[check.Suitability.KnowledgeLevel] < knowledgeRank[required] {
+		return fmt.Sprintf("%s instrument requires %s knowledge, customer is assessed as %s", check.Classification.Complexity, required, check.Suitability.KnowledgeLevel), true
+	}
+	return "", false
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"thyra/internal/compliance/models"
+	"thyra/internal/compliance/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const defaultAccountChecksLimit = 100
+
+var (
+	ErrComplianceAdminOnly   = errors.New("only admins can change compliance settings")
+	ErrSuitabilityNotAllowed = errors.New("only admins and advisors can assess customers")
+	ErrSuitabilityNotFound   = errors.New("no suitability assessment found")
+	ErrInvalidRiskProfile    = errors.New("risk_profile must be between 1 and 7")
+	ErrInvalidKnowledgeLevel = errors.New("knowledge_level must be none, basic, informed or advanced")
+	ErrInvalidComplexity     = errors.New("complexity must be non_complex, complex or highly_complex")
+	ErrInvalidClassification = errors.New("exactly one of instrument_type and asset_type_id is required")
+	ErrInvalidRestriction    = errors.New("restriction must be no_buy or no_trade")
+	ErrRestrictionReason     = errors.New("a reason is required")
+	ErrRestrictionNotFound   = errors.New("active restriction not found")
+	ErrInvalidLimit          = errors.New("limit must be positive, and percentages at most 100")
+	ErrUnknownRule           = errors.New("unknown pre-trade rule")
+	ErrInvalidRuleAction     = errors.New("action must be block or acknowledge")
+	ErrInvalidDate           = errors.New("dates must be formatted YYYY-MM-DD")
+)
+
+type PreTradeService struct {
+	db    *sqlx.DB
+	repo  *repositories.PreTradeRepository
+	rules []PreTradeRule
+}
+
+// NewPreTradeService evaluates the given rules, or DefaultPreTradeRules when
+// none are passed.
+func NewPreTradeService(db *sqlx.DB, repo *repositories.PreTradeRepository, rules ...PreTradeRule) *PreTradeService {
+	if len(rules) == 0 {
+		rules = DefaultPreTradeRules()
+	}
+	return &PreTradeService{db: db, repo: repo, rules: rules}
+}
+
+// Evaluate runs every enabled rule against the order on the order's
+// transaction. The overall outcome is the most severe rule outcome.
+func (s *PreTradeService) Evaluate(tx *sqlx.Tx, order models.PreTradeOrder) (models.PreTradeResult, error) {
+	check, err := s.repo.LoadContext(tx, order, time.Now())
+	if err != nil {
+		return models.PreTradeResult{}, err
+	}
+	settings, err := s.repo.GetRuleSettings(tx)
+	if err != nil {
+		return models.PreTradeResult{}, err
+	}
+
+	result := models.PreTradeResult{Outcome: models.OutcomePass}
+	for _, rule := range s.rules {
+		action := rule.DefaultAction()
+		if setting, ok := settings[rule.Name()]; ok {
+			if !setting.Enabled {
+				continue
+			}
+			action = setting.Action
+		}
+
+		ruleResult := models.RuleResult{RuleName: rule.Name(), Outcome: models.OutcomePass}
+		if message, violated := rule.Evaluate(check); violated {
+			ruleResult.Outcome = action
+			ruleResult.Message = message
+		}
+		result.Results = append(result.Results, ruleResult)
+
+		if ruleResult.Outcome == models.OutcomeBlock || (ruleResult.Outcome == models.OutcomeAcknowledge && result.Outcome == models.OutcomePass) {
+			result.Outcome = ruleResult.Outcome
+		}
+	}
+	return result, nil
+}
+
+// Enforce returns a *models.PreTradeError when the order may not be placed.
+func (s *PreTradeService) Enforce(result models.PreTradeResult, acknowledged bool) error {
+	switch {
+	case result.Outcome == models.OutcomeBlock:
+		return &models.PreTradeError{Outcome: models.OutcomeBlock, Results: result.Results}
+	case result.Outcome == models.OutcomeAcknowledge && !acknowledged:
+		return &models.PreTradeError{Outcome: models.OutcomeAcknowledge, Results: result.Results}
+	default:
+		return nil
+	}
+}
+
+// RecordForOrder stores the results with the order on its transaction.
+func (s *PreTradeService) RecordForOrder(tx *sqlx.Tx, orderID uuid.UUID, order models.PreTradeOrder, result models.PreTradeResult, acknowledgedBy uuid.UUID) error {
+	return s.repo.InsertChecks(tx, buildChecks(&orderID, order, result, &acknowledgedBy))
+}
+
+// RecordRejected keeps the results of an order that was not placed. It runs
+// outside the rolled back order transaction.
+func (s *PreTradeService) RecordRejected(order models.PreTradeOrder, result models.PreTradeResult) error {
+	return s.repo.InsertChecks(s.db, buildChecks(nil, order, result, nil))
+}
+
+func (s *PreTradeService) GetOrderChecks(ctx context.Context, orderID uuid.UUID) ([]models.OrderComplianceCheck, error) {
+	return s.repo.GetOrderChecks(ctx, orderID)
+}
+
+func (s *PreTradeService) GetAccountChecks(ctx context.Context, accountID uuid.UUID, authUserRole string) ([]models.OrderComplianceCheck, error) {
+	if authUserRole != "admin" {
+		return nil, ErrComplianceAdminOnly
+	}
+	return s.repo.GetAccountChecks(ctx, accountID, defaultAccountChecksLimit)
+}
+
+func (s *PreTradeService) GetSuitability(ctx context.Context, userID uuid.UUID) (models.CustomerSuitability, error) {
+	suitability, err := s.repo.GetSuitability(ctx, userID)
+	if err == sql.ErrNoRows {
+		return suitability, ErrSuitabilityNotFound
+	}
+	return suitability, err
+}
+
+func (s *PreTradeService) SetSuitability(ctx context.Context, userID, assessorID uuid.UUID, authUserRole string, req models.SuitabilityRequest) error {
+	if authUserRole != "admin" && authUserRole != "partner_advisor" {
+		return ErrSuitabilityNotAllowed
+	}
+	if req.RiskProfile < 1 || req.RiskProfile > 7 {
+		return ErrInvalidRiskProfile
+	}
+	if _, ok := knowledgeRank[req.KnowledgeLevel]; !ok {
+		return ErrInvalidKnowledgeLevel
+	}
+	validUntil, err := parseOptionalDate(req.ValidUntil)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpsertSuitability(ctx, models.CustomerSuitability{
+		UserID:         userID,
+		RiskProfile:    req.RiskProfile,
+		KnowledgeLevel: req.KnowledgeLevel,
+		AssessedBy:     assessorID,
+		AssessedAt:     time.Now(),
+		ValidUntil:     validUntil,
+	})
+}
+
+func (s *PreTradeService) GetClassifications(ctx context.Context) ([]models.InstrumentClassification, error) {
+	return s.repo.GetClassifications(ctx)
+}
+
+func (s *PreTradeService) SetClassification(ctx context.Context, authUserRole string, req models.InstrumentClassificationRequest) error {
+	if authUserRole != "admin" {
+		return ErrComplianceAdminOnly
+	}
+	if req.InstrumentType != nil && strings.TrimSpace(*req.InstrumentType) == "" {
+		req.InstrumentType = nil
+	}
+	if (req.InstrumentType == nil) == (req.AssetTypeID == nil) {
+		return ErrInvalidClassification
+	}
+	if _, ok := requiredKnowledge[req.Complexity]; !ok {
+		return ErrInvalidComplexity
+	}
+	if req.RiskClass < 1 || req.RiskClass > 7 {
+		return ErrInvalidRiskProfile
+	}
+
+	return s.repo.UpsertClassification(ctx, models.InstrumentClassification{
+		ID:             uuid.New(),
+		InstrumentType: req.InstrumentType,
+		AssetTypeID:    req.AssetTypeID,
+		Complexity:     req.Complexity,
+		RiskClass:      req.RiskClass,
+	})
+}
+
+func (s *PreTradeService) GetRestrictedInstruments(ctx context.Context) ([]models.RestrictedInstrument, error) {
+	return s.repo.GetRestrictedInstruments(ctx, time.Now())
+}
+
+func (s *PreTradeService) AddRestrictedInstrument(ctx context.Context, createdBy uuid.UUID, authUserRole string, req models.RestrictedInstrumentRequest) (models.RestrictedInstrument, error) {
+	if authUserRole != "admin" {
+		return models.RestrictedInstrument{}, ErrComplianceAdminOnly
+	}
+	if req.Restriction != models.RestrictionNoBuy && req.Restriction != models.RestrictionNoTrade {
+		return models.RestrictedInstrument{}, ErrInvalidRestriction
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return models.RestrictedInstrument{}, ErrRestrictionReason
+	}
+	validTo, err := parseOptionalDate(req.ValidTo)
+	if err != nil {
+		return models.RestrictedInstrument{}, err
+	}
+
+	now := time.Now()
+	restricted := models.RestrictedInstrument{
+		ID:          uuid.New(),
+		AssetID:     req.AssetID,
+		Restriction: req.Restriction,
+		Reason:      strings.TrimSpace(req.Reason),
+		ValidFrom:   now,
+		ValidTo:     validTo,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+	}
+	return restricted, s.repo.InsertRestrictedInstrument(ctx, restricted)
+}
+
+func (s *PreTradeService) EndRestriction(ctx context.Context, id uuid.UUID, authUserRole string) error {
+	if authUserRole != "admin" {
+		return ErrComplianceAdminOnly
+	}
+	ended, err := s.repo.EndRestriction(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ended {
+		return ErrRestrictionNotFound
+	}
+	return nil
+}
+
+func (s *PreTradeService) SetConcentrationLimit(ctx context.Context, accountID, updatedBy uuid.UUID, authUserRole string, req models.ConcentrationLimitRequest) error {
+	if authUserRole != "admin" {
+		return ErrComplianceAdminOnly
+	}
+	if req.MaxPositionPct <= 0 || req.MaxPositionPct > 100 {
+		return ErrInvalidLimit
+	}
+	return s.repo.UpsertConcentrationLimit(ctx, accountID, req.MaxPositionPct, updatedBy, time.Now())
+}
+
+func (s *PreTradeService) SetOrderLimit(ctx context.Context, accountTypeID, updatedBy uuid.UUID, authUserRole string, req models.OrderLimitRequest) error {
+	if authUserRole != "admin" {
+		return ErrComplianceAdminOnly
+	}
+	if req.MaxOrderValue <= 0 {
+		return ErrInvalidLimit
+	}
+	return s.repo.UpsertOrderLimit(ctx, accountTypeID, req.MaxOrderValue, updatedBy, time.Now())
+}
+
+// GetRuleSettings lists every registered rule with its effective settings.
+func (s *PreTradeService) GetRuleSettings(ctx context.Context) ([]models.RuleSetting, error) {
+	overrides, err := s.repo.GetRuleSettings(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make([]models.RuleSetting, 0, len(s.rules))
+	for _, rule := range s.rules {
+		setting, ok := overrides[rule.Name()]
+		if !ok {
+			setting = models.RuleSetting{RuleName: rule.Name(), Enabled: true, Action: rule.DefaultAction()}
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+func (s *PreTradeService) SetRuleSetting(ctx context.Context, ruleName string, updatedBy uuid.UUID, authUserRole string, req models.RuleSettingRequest) error {
+	if authUserRole != "admin" {
+		return ErrComplianceAdminOnly
+	}
+	if req.Action != models.RuleActionBlock && req.Action != models.RuleActionAcknowledge {
+		return ErrInvalidRuleAction
+	}
+
+	known := false
+	for _, rule := range s.rules {
+		if rule.Name() == ruleName {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return ErrUnknownRule
+	}
+
+	now := time.Now()
+	return s.repo.UpsertRuleSetting(ctx, models.RuleSetting{
+		RuleName:  ruleName,
+		Enabled:   req.Enabled,
+		Action:    req.Action,
+		UpdatedBy: &updatedBy,
+		UpdatedAt: &now,
+	})
+}
+
+func buildChecks(orderID *uuid.UUID, order models.PreTradeOrder, result models.PreTradeResult, acknowledgedBy *uuid.UUID) []models.OrderComplianceCheck {
+	now := time.Now()
+	checks := make([]models.OrderComplianceCheck, 0, len(result.Results))
+	for _, r := range result.Results {
+		check := models.OrderComplianceCheck{
+			ID:          uuid.New(),
+			OrderID:     orderID,
+			AccountID:   order.AccountID,
+			AssetID:     order.AssetID,
+			RuleName:    r.RuleName,
+			Outcome:     r.Outcome,
+			EvaluatedAt: now,
+		}
+		if r.Message != "" {
+			message := r.Message
+			check.Message = &message
+		}
+		// Only placed orders can have had their warnings acknowledged.
+		if r.Outcome == models.OutcomeAcknowledge && orderID != nil {
+			check.Acknowledged = true
+			check.AcknowledgedBy = acknowledgedBy
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func parseOptionalDate(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	return &t, nil
+}
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"thyra/internal/compliance/models"
+	"thyra/internal/compliance/repositories"
+	"thyra/internal/compliance/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMatchThreshold is the lowest similarity that opens a screening case.
+const DefaultMatchThreshold = 0.90
+
+const (
+	birthDateMatchBonus    = 0.05
+	birthDateMismatchCost  = 0.10
+	citizenshipMatchBonus  = 0.02
+	defaultSanctionsDir    = "./data/sanctions"
+	pepListFilePrefix      = "pep"
+	maxScreeningCaseScore  = 1.0
+	screeningListFileLimit = 200 << 20
+)
+
+var (
+	ErrScreeningAccessDenied = errors.New("only admins can work screening cases")
+	ErrListFileName          = errors.New("file_name must name an XML or CSV file in the sanctions list directory")
+	ErrListAlreadyImported   = errors.New("this list file has already been imported")
+	ErrListFileTooLarge      = errors.New("list file exceeds the 200 MB limit")
+	ErrEmptyList             = errors.New("list file contains no entries")
+	ErrInvalidListType       = errors.New("list_type must be sanctions or pep")
+	ErrScreeningCaseNotFound = errors.New("screening case not found")
+	ErrScreeningCaseDecided  = errors.New("screening case has already been decided")
+	ErrInvalidScreeningState = errors.New("status must be confirmed_match or false_positive")
+	ErrCustomerNotFound      = errors.New("customer not found")
+)
+
+// ScreeningService screens customers against the imported sanctions and PEP
+// lists. List files are read from a local directory; nothing is downloaded.
+type ScreeningService struct {
+	repo      *repositories.ScreeningRepository
+	listDir   string
+	threshold float64
+}
+
+func NewScreeningService(repo *repositories.ScreeningRepository, listDir string, threshold float64) *ScreeningService {
+	return &ScreeningService{repo: repo, listDir: listDir, threshold: threshold}
+}
+
+// NewScreeningServiceFromEnv reads list files from SANCTIONS_LIST_DIR (default
+// ./data/sanctions) and opens cases at SCREENING_MATCH_THRESHOLD (default
+// DefaultMatchThreshold).
+func NewScreeningServiceFromEnv(repo *repositories.ScreeningRepository) *ScreeningService {
+	listDir := os.Getenv("SANCTIONS_LIST_DIR")
+	if listDir == "" {
+		listDir = defaultSanctionsDir
+	}
+
+	threshold := DefaultMatchThreshold
+	if value := os.Getenv("SCREENING_MATCH_THRESHOLD"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			log.Printf("Ignoring invalid SCREENING_MATCH_THRESHOLD %q", value)
+		} else {
+			threshold = parsed
+		}
+	}
+
+	return NewScreeningService(repo, listDir, threshold)
+}
+
+// ScreenCustomer screens one customer against every active list and opens a
+// case for each hit. Users without a customer profile are ignored.
+func (s *ScreeningService) ScreenCustomer(ctx context.Context, userID uuid.UUID, trigger string) error {
+	_, err := s.screenCustomer(ctx, userID, trigger)
+	if err == ErrCustomerNotFound {
+		return nil
+	}
+	return err
+}
+
+// ScreenCustomerManually is the admin triggered variant of ScreenCustomer and
+// returns the number of new cases.
+func (s *ScreeningService) ScreenCustomerManually(ctx context.Context, userID uuid.UUID, authUserRole string) (int, error) {
+	if authUserRole != "admin" {
+		return 0, ErrScreeningAccessDenied
+	}
+	return s.screenCustomer(ctx, userID, models.ScreeningTriggerManual)
+}
+
+func (s *ScreeningService) screenCustomer(ctx context.Context, userID uuid.UUID, trigger string) (int, error) {
+	customer, err := s.repo.GetCustomer(ctx, userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrCustomerNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := s.repo.GetActiveEntries(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.openCases(ctx, customer, entries, trigger)
+}
+
+// RescreenAll screens every customer, typically after a list import.
+func (s *ScreeningService) RescreenAll(ctx context.Context, trigger string, listID *uuid.UUID) (models.ScreeningRun, error) {
+	run := models.ScreeningRun{ID: uuid.New(), Trigger: trigger, ListID: listID, StartedAt: time.Now()}
+
+	entries, err := s.repo.GetActiveEntries(ctx)
+	if err != nil {
+		return run, err
+	}
+	customers, err := s.repo.GetCustomers(ctx)
+	if err != nil {
+		return run, err
+	}
+
+	for _, customer := range customers {
+		opened, err := s.openCases(ctx, customer, entries, trigger)
+		if err != nil {
+			return run, err
+		}
+		run.CustomersScreened++
+		run.NewCases += opened
+	}
+
+	run.FinishedAt = time.Now()
+	return run, s.repo.InsertRun(ctx, run)
+}
+
+func (s *ScreeningService) openCases(ctx context.Context, customer models.ScreenedCustomer, entries []models.ScreeningEntry, trigger string) (int, error) {
+	opened := 0
+	for _, hit := range s.match(customer, entries) {
+		hit.ID = uuid.New()
+		hit.Trigger = trigger
+		hit.Status = models.ScreeningCaseOpen
+		hit.CreatedAt = time.Now()
+		created, err := s.repo.InsertCase(ctx, hit)
+		if err != nil {
+			return opened, err
+		}
+		if created {
+			opened++
+		}
+	}
+	return opened, nil
+}
+
+// match compares the customer with every listed person. The best scoring name
+// of an entry decides; a known birth date that agrees raises the score and
+// one that disagrees lowers it, as does a shared citizenship.
+func (s *ScreeningService) match(customer models.ScreenedCustomer, entries []models.ScreeningEntry) []models.ScreeningCase {
+	tokens := utils.NameTokens(customer.FullName)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var hits []models.ScreeningCase
+	for _, entry := range entries {
+		if entry.SubjectType == models.SubjectTypeEntity {
+			continue
+		}
+
+		best, bestName := 0.0, ""
+		for _, name := range entry.Names {
+			if score := utils.NameSimilarity(tokens, utils.NameTokens(name)); score > best {
+				best, bestName = score, name
+			}
+		}
+		if best < s.threshold-birthDateMatchBonus-citizenshipMatchBonus {
+			continue
+		}
+
+		score := best
+		var birthDateMatch *bool
+		if customer.DateOfBirth != nil && len(entry.BirthDates) > 0 {
+			matched := birthDateMatches(*customer.DateOfBirth, entry.BirthDates)
+			birthDateMatch = &matched
+			if matched {
+				score += birthDateMatchBonus
+			} else {
+				score -= birthDateMismatchCost
+			}
+		}
+		if customer.Citizenship != nil {
+			for _, country := range entry.Countries {
+				if strings.EqualFold(country, *customer.Citizenship) {
+					score += citizenshipMatchBonus
+					break
+				}
+			}
+		}
+		if score > maxScreeningCaseScore {
+			score = maxScreeningCaseScore
+		}
+		if score < s.threshold {
+			continue
+		}
+
+		entryID := entry.ID
+		hits = append(hits, models.ScreeningCase{
+			UserID:         customer.UserID,
+			ListSource:     entry.ListSource,
+			ListType:       entry.ListType,
+			ExternalID:     entry.ExternalID,
+			EntryID:        &entryID,
+			CustomerName:   customer.FullName,
+			MatchedName:    bestName,
+			Score:          score,
+			BirthDateMatch: birthDateMatch,
+		})
+	}
+	return hits
+}
+
+// birthDateMatches accepts a full date or, for entries where only the year is
+// listed, the year.
+func birthDateMatches(dateOfBirth time.Time, listed []string) bool {
+	full := dateOfBirth.Format("2006-01-02")
+	year := dateOfBirth.Format("2006")
+	for _, value := range listed {
+		if value == full || (len(value) == 4 && value == year) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportListFile imports a list file from the list directory and re-screens
+// every customer against the updated lists. The file name without extension
+// identifies the source, so a newer file with the same name replaces the
+// older list.
+func (s *ScreeningService) ImportListFile(ctx context.Context, importedBy uuid.UUID, authUserRole string, req models.ImportListRequest) (models.ListImportResult, error) {
+	if authUserRole != "admin" {
+		return models.ListImportResult{}, ErrScreeningAccessDenied
+	}
+	if req.ListType != models.ListTypeSanctions && req.ListType != models.ListTypePEP {
+		return models.ListImportResult{}, ErrInvalidListType
+	}
+	return s.importListFile(ctx, req.FileName, req.ListType, &importedBy)
+}
+
+// ImportNewListFiles imports every file in the list directory that has not
+// been imported before. Files whose name starts with "pep" are PEP lists.
+func (s *ScreeningService) ImportNewListFiles(ctx context.Context) ([]models.ListImportResult, error) {
+	files, err := os.ReadDir(s.listDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.ListImportResult
+	for _, file := range files {
+		if file.IsDir() || !isListFile(file.Name()) {
+			continue
+		}
+		listType := models.ListTypeSanctions
+		if strings.HasPrefix(strings.ToLower(file.Name()), pepListFilePrefix) {
+			listType = models.ListTypePEP
+		}
+
+		result, err := s.importListFile(ctx, file.Name(), listType, nil)
+		if err == ErrListAlreadyImported {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to import sanctions list %s: %v", file.Name(), err)
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *ScreeningService) importListFile(ctx context.Context, fileName, listType string, importedBy *uuid.UUID) (models.ListImportResult, error) {
+	var result models.ListImportResult
+	if fileName == "" || filepath.Base(fileName) != fileName || !isListFile(fileName) {
+		return result, ErrListFileName
+	}
+
+	path := filepath.Join(s.listDir, fileName)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return result, ErrListFileName
+	}
+	if err != nil {
+		return result, err
+	}
+	if info.Size() > screeningListFileLimit {
+		return result, ErrListFileTooLarge
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+	sum := sha256.Sum256(content)
+	fileHash := hex.EncodeToString(sum[:])
+
+	imported, err := s.repo.ListFileImported(ctx, fileHash)
+	if err != nil {
+		return result, err
+	}
+	if imported {
+		return result, ErrListAlreadyImported
+	}
+
+	entries, err := utils.ParseSanctionsList(fileName, bytes.NewReader(content))
+	if err != nil {
+		return result, err
+	}
+	if len(entries) == 0 {
+		return result, ErrEmptyList
+	}
+
+	list := models.SanctionsList{
+		ID:         uuid.New(),
+		Source:     strings.TrimSuffix(fileName, filepath.Ext(fileName)),
+		ListType:   listType,
+		FileName:   fileName,
+		FileSHA256: fileHash,
+		EntryCount: len(entries),
+		Active:     true,
+		ImportedBy: importedBy,
+		ImportedAt: time.Now(),
+	}
+	for i := range entries {
+		entries[i].ID = uuid.New()
+		entries[i].ListID = list.ID
+	}
+	if err := s.repo.ReplaceList(ctx, list, entries); err != nil {
+		return result, err
+	}
+	result.List = list
+
+	result.Run, err = s.RescreenAll(ctx, models.ScreeningTriggerListImport, &list.ID)
+	return result, err
+}
+
+func isListFile(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xml", ".csv":
+		return true
+	}
+	return false
+}
+
+func (s *ScreeningService) GetLists(ctx context.Context, authUserRole string) ([]models.SanctionsList, error) {
+	if authUserRole != "admin" {
+		return nil, ErrScreeningAccessDenied
+	}
+	return s.repo.GetLists(ctx)
+}
+
+func (s *ScreeningService) GetCases(ctx context.Context, status, authUserRole string) ([]models.ScreeningCase, error) {
+	if authUserRole != "admin" {
+		return nil, ErrScreeningAccessDenied
+	}
+	return s.repo.GetCases(ctx, status)
+}
+
+func (s *ScreeningService) GetCase(ctx context.Context, caseID uuid.UUID, authUserRole string) (models.ScreeningCaseDetails, error) {
+	var details models.ScreeningCaseDetails
+	if authUserRole != "admin" {
+		return details, ErrScreeningAccessDenied
+	}
+
+	screeningCase, err := s.repo.GetCase(ctx, caseID)
+	if err == sql.ErrNoRows {
+		return details, ErrScreeningCaseNotFound
+	}
+	if err != nil {
+		return details, err
+	}
+	details.Case = screeningCase
+
+	if screeningCase.EntryID != nil {
+		entry, err := s.repo.GetEntry(ctx, *screeningCase.EntryID)
+		if err != nil && err != sql.ErrNoRows {
+			return details, err
+		}
+		if err == nil {
+			details.Entry = &entry
+		}
+	}
+	return details, nil
+}
+
+func (s *ScreeningService) DecideCase(ctx context.Context, caseID, reviewerID uuid.UUID, authUserRole string, req models.ScreeningDecisionRequest) error {
+	if authUserRole != "admin" {
+		return ErrScreeningAccessDenied
+	}
+	if req.Status != models.ScreeningCaseConfirmedMatch && req.Status != models.ScreeningCaseFalsePositive {
+		return ErrInvalidScreeningState
+	}
+	if strings.TrimSpace(req.Note) == "" {
+		return ErrNoteRequired
+	}
+
+	decided, err := s.repo.DecideCase(ctx, caseID, req.Status, reviewerID, strings.TrimSpace(req.Note), time.Now())
+	if err != nil {
+		return err
+	}
+	if decided {
+		return nil
+	}
+	if _, err := s.repo.GetCase(ctx, caseID); err == sql.ErrNoRows {
+		return ErrScreeningCaseNotFound
+	} else if err != nil {
+		return err
+	}
+	return ErrScreeningCaseDecided
+}
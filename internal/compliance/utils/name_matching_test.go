@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"case and spacing", "  John   SMITH ", "john smith"},
+		{"punctuation", "O'Brien-Smith, Jr.", "o brien smith jr"},
+		{"diacritics", "José Müller Øvergård", "jose muller overgard"},
+		{"ligatures", "Æsa Strauß", "aesa strauss"},
+		{"cyrillic", "Владимир Щукин", "vladimir shchukin"},
+		{"digits kept", "Vessel 42", "vessel 42"},
+		{"nothing but punctuation", "--", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.in); got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"martha", "marhta", 0.9611},
+		{"dwayne", "duane", 0.8400},
+		{"dixon", "dicksonx", 0.8133},
+		{"same", "same", 1},
+		{"abc", "xyz", 0},
+		{"", "", 1},
+		{"a", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := JaroWinkler(tt.a, tt.b); math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("JaroWinkler(%q, %q) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	// The screening service opens a case from 0.90.
+	const threshold = 0.90
+
+	tests := []struct {
+		name  string
+		a, b  string
+		match bool
+	}{
+		{"identical", "Ivan Petrov", "Ivan Petrov", true},
+		{"word order", "Petrov Ivan", "Ivan Petrov", true},
+		{"missing middle name", "Ivan Petrov", "Ivan Sergeyevich Petrov", true},
+		{"transliterated", "Иван Петров", "Ivan Petrov", true},
+		{"diacritics", "José Álvarez", "Jose Alvarez", true},
+		{"spelling variant", "Mohammed Al Rashid", "Muhammad Al Rashid", true},
+		{"split differently", "Abdul Rahman", "Abdulrahman", true},
+		{"different surname", "Ivan Petrov", "Ivan Smirnov", false},
+		{"different person", "Anna Lindqvist", "Ivan Petrov", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := NameSimilarity(NameTokens(tt.a), NameTokens(tt.b))
+			if (score >= threshold) != tt.match {
+				t.Errorf("NameSimilarity(%q, %q) = %.3f, want match %v", tt.a, tt.b, score, tt.match)
+			}
+		})
+	}
+}
+
+func TestNameSimilarityEmpty(t *testing.T) {
+	if got := NameSimilarity(nil, NameTokens("Ivan Petrov")); got != 0 {
+		t.Errorf("NameSimilarity with no words = %v, want 0", got)
+	}
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"thyra/internal/compliance/models"
+)
+
+var (
+	ErrUnsupportedListFormat = errors.New("sanctions lists must be XML or CSV files")
+	ErrMissingListColumns    = errors.New("CSV list needs an id and a name column")
+)
+
+// ParseSanctionsList reads a list in the EU consolidated financial sanctions
+// format (FSF), as XML or as the semicolon separated CSV export. CSV files may
+// instead use plain headers (id, name, date_of_birth, country, type,
+// programme), which is how PEP lists are usually delivered.
+func ParseSanctionsList(fileName string, r io.Reader) ([]models.SanctionsEntry, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xml":
+		return parseSanctionsXML(r)
+	case ".csv":
+		return parseSanctionsCSV(r)
+	default:
+		return nil, ErrUnsupportedListFormat
+	}
+}
+
+type fsfExport struct {
+	Entities []fsfEntity `xml:"sanctionEntity"`
+}
+
+type fsfEntity struct {
+	LogicalID   string `xml:"logicalId,attr"`
+	SubjectType struct {
+		Code string `xml:"code,attr"`
+	} `xml:"subjectType"`
+	Regulations []struct {
+		Programme string `xml:"programme,attr"`
+	} `xml:"regulation"`
+	NameAliases []struct {
+		WholeName string `xml:"wholeName,attr"`
+	} `xml:"nameAlias"`
+	BirthDates []struct {
+		BirthDate string `xml:"birthdate,attr"`
+		Year      string `xml:"year,attr"`
+	} `xml:"birthdate"`
+	Citizenships []struct {
+		Country string `xml:"countryIso2Code,attr"`
+	} `xml:"citizenship"`
+	Addresses []struct {
+		Country string `xml:"countryIso2Code,attr"`
+	} `xml:"address"`
+}
+
+func parseSanctionsXML(r io.Reader) ([]models.SanctionsEntry, error) {
+	var export fsfExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.SanctionsEntry, 0, len(export.Entities))
+	for _, entity := range export.Entities {
+		entry := models.SanctionsEntry{
+			ExternalID:  entity.LogicalID,
+			SubjectType: subjectType(entity.SubjectType.Code),
+		}
+		for _, alias := range entity.NameAliases {
+			entry.Names = appendUnique(entry.Names, alias.WholeName)
+		}
+		for _, birth := range entity.BirthDates {
+			if birth.BirthDate != "" {
+				entry.BirthDates = appendUnique(entry.BirthDates, birth.BirthDate)
+			} else {
+				entry.BirthDates = appendUnique(entry.BirthDates, birth.Year)
+			}
+		}
+		for _, citizenship := range entity.Citizenships {
+			entry.Countries = appendUnique(entry.Countries, strings.ToUpper(citizenship.Country))
+		}
+		for _, address := range entity.Addresses {
+			entry.Countries = appendUnique(entry.Countries, strings.ToUpper(address.Country))
+		}
+		if len(entity.Regulations) > 0 && entity.Regulations[0].Programme != "" {
+			programme := entity.Regulations[0].Programme
+			entry.Programme = &programme
+		}
+		if entry.ExternalID != "" && len(entry.Names) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// csvColumns maps each field to the headers it may appear under, FSF first.
+var csvColumns = map[string][]string{
+	"id":        {"entity_logicalid", "id"},
+	"name":      {"namealias_wholename", "name", "full_name"},
+	"birthDate": {"birthdate_birthdate", "date_of_birth", "birth_date"},
+	"birthYear": {"birthdate_year"},
+	"country":   {"citizenship_countryiso2code", "address_countryiso2code", "country"},
+	"type":      {"entity_subjecttype", "entity_subjecttype_classificationcode", "type", "subject_type"},
+	"programme": {"entity_regulation_programme", "programme"},
+}
+
+// parseSanctionsCSV reads one row per name, birth date or address; rows with
+// the same id are merged into one entry.
+func parseSanctionsCSV(r io.Reader) ([]models.SanctionsEntry, error) {
+	buffered := bufio.NewReader(r)
+	if bom, _ := buffered.Peek(3); bytes.Equal(bom, []byte("\ufeff")) {
+		buffered.Discard(3)
+	}
+	head, err := buffered.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if line, _, _ := bytes.Cut(head, []byte("\n")); bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.Trim(column, " \""))
+		index[column] = i
+	}
+	columns := make(map[string]int, len(csvColumns))
+	for field, candidates := range csvColumns {
+		columns[field] = -1
+		for _, candidate := range candidates {
+			if i, ok := index[candidate]; ok {
+				columns[field] = i
+				break
+			}
+		}
+	}
+	if columns["id"] < 0 || columns["name"] < 0 {
+		return nil, ErrMissingListColumns
+	}
+
+	value := func(record []string, field string) string {
+		i := columns[field]
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	byID := make(map[string]*models.SanctionsEntry)
+	var order []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		id := value(record, "id")
+		if id == "" {
+			continue
+		}
+		entry, ok := byID[id]
+		if !ok {
+			entry = &models.SanctionsEntry{ExternalID: id, SubjectType: subjectType(value(record, "type"))}
+			byID[id] = entry
+			order = append(order, id)
+		}
+
+		entry.Names = appendUnique(entry.Names, value(record, "name"))
+		if birthDate := value(record, "birthDate"); birthDate != "" {
+			entry.BirthDates = appendUnique(entry.BirthDates, birthDate)
+		} else {
+			entry.BirthDates = appendUnique(entry.BirthDates, value(record, "birthYear"))
+		}
+		entry.Countries = appendUnique(entry.Countries, strings.ToUpper(value(record, "country")))
+		if programme := value(record, "programme"); programme != "" && entry.Programme == nil {
+			entry.Programme = &programme
+		}
+	}
+
+	entries := make([]models.SanctionsEntry, 0, len(order))
+	for _, id := range order {
+		if entry := byID[id]; len(entry.Names) > 0 {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, nil
+}
+
+// subjectType maps FSF subject codes ("person", "P", "enterprise", "E") and
+// plain CSV values to person or entity. Unknown values are treated as persons
+// so they are screened rather than skipped.
+func subjectType(code string) string {
+	switch strings.ToLower(strings.TrimSpace(code)) {
+	case "enterprise", "e", "entity", "organisation", "organization":
+		return models.SubjectTypeEntity
+	default:
+		return models.SubjectTypePerson
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
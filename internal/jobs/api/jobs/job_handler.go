@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"thyra/internal/jobs/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type JobHandler struct {
+	service *services.JobService
+}
+
+func NewJobHandler(service *services.JobService) *JobHandler {
+	return &JobHandler{service: service}
+}
+
+// GetJobs lists the registered jobs with their last run and next due time.
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	_, authUserRole, ok := jobUser(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.service.GetJobs(c.Request.Context(), authUserRole)
+	if err != nil {
+		writeJobError(c, err, "Failed to fetch jobs")
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetJobRuns lists a job's run history, optionally filtered by ?status= and
+// capped by ?limit=.
+func (h *JobHandler) GetJobRuns(c *gin.Context) {
+	_, authUserRole, ok := jobUser(c)
+	if !ok {
+		return
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit", "details": err.Error()})
+			return
+		}
+	}
+
+	runs, err := h.service.GetRuns(c.Request.Context(), c.Param("jobName"), c.Query("status"), limit, authUserRole)
+	if err != nil {
+		writeJobError(c, err, "Failed to fetch job runs")
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+func (h *JobHandler) GetJobRun(c *gin.Context) {
+	_, authUserRole, ok := jobUser(c)
+	if !ok {
+		return
+	}
+	runID, err := uuid.Parse(c.Param("runId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID in URL"})
+		return
+	}
+
+	run, err := h.service.GetRun(c.Request.Context(), runID, authUserRole)
+	if err != nil {
+		writeJobError(c, err, "Failed to fetch job run")
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// TriggerJob queues an immediate run of the job.
+func (h *JobHandler) TriggerJob(c *gin.Context) {
+	authUserID, authUserRole, ok := jobUser(c)
+	if !ok {
+		return
+	}
+
+	trigger, err := h.service.TriggerJob(c.Request.Context(), c.Param("jobName"), authUserID, authUserRole)
+	if err != nil {
+		writeJobError(c, err, "Failed to trigger job")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, trigger)
+}
+
+func jobUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+	return authUserID, userRole, true
+}
+
+func writeJobError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrJobAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrJobNotFound, services.ErrJobRunNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidRunStatus, services.ErrInvalidRunLimit:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+const (
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+)
+
+// ScheduledJob is a job as registered by the scheduler.
+type ScheduledJob struct {
+	Name        string    `db:"name" json:"name"`
+	Schedule    string    `db:"schedule" json:"schedule"`
+	MaxAttempts int       `db:"max_attempts" json:"max_attempts"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// JobRun is one attempt of a job. Retries of a run share its RunGroup.
+type JobRun struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	RunGroup   uuid.UUID  `db:"run_group" json:"run_group"`
+	JobName    string     `db:"job_name" json:"job_name"`
+	Trigger    string     `db:"trigger" json:"trigger"`
+	TriggerID  *uuid.UUID `db:"trigger_id" json:"trigger_id,omitempty"`
+	Attempt    int        `db:"attempt" json:"attempt"`
+	Status     string     `db:"status" json:"status"`
+	StartedAt  time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+	DurationMs *int64     `db:"duration_ms" json:"duration_ms,omitempty"`
+	Error      *string    `db:"error" json:"error,omitempty"`
+}
+
+type JobTrigger struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	JobName     string     `db:"job_name" json:"job_name"`
+	RequestedBy uuid.UUID  `db:"requested_by" json:"requested_by"`
+	RequestedAt time.Time  `db:"requested_at" json:"requested_at"`
+	ClaimedAt   *time.Time `db:"claimed_at" json:"claimed_at,omitempty"`
+}
+
+// JobStatus is a registered job with its most recent attempt and the next
+// time it is due.
+type JobStatus struct {
+	ScheduledJob
+	LastRun   *JobRun    `json:"last_run,omitempty"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/jobs/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// leaderLockKey is the session-level advisory lock held by the scheduler
+// replica that runs jobs.
+const leaderLockKey int64 = 0x74687972615f6a62
+
+type JobRepository struct {
+	db *sqlx.DB
+}
+
+func NewJobRepository(db *sqlx.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// AcquireLeaderLock tries to take the leader lock on a connection of its
+// own. The lock is held for as long as that connection stays open, so a
+// replica that dies loses it with its session. It returns nil when another
+// replica holds the lock.
+func (r *JobRepository) AcquireLeaderLock(ctx context.Context) (*sqlx.Conn, error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, "SELECT pg_try_advisory_lock($1)", leaderLockKey); err != nil || !locked {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// CheckLeaderLock verifies that the connection holding the lock is still alive.
+func (r *JobRepository) CheckLeaderLock(ctx context.Context, conn *sqlx.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT 1")
+	return err
+}
+
+func (r *JobRepository) ReleaseLeaderLock(ctx context.Context, conn *sqlx.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", leaderLockKey)
+	if closeErr := conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (r *JobRepository) RegisterJob(ctx context.Context, job models.ScheduledJob) error {
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO thyrasec.scheduled_jobs (name, schedule, max_attempts, updated_at)
+        VALUES ($1, $2, $3, now())
+        ON CONFLICT (name) DO UPDATE SET
+            schedule = EXCLUDED.schedule,
+            max_attempts = EXCLUDED.max_attempts,
+            updated_at = EXCLUDED.updated_at`, job.Name, job.Schedule, job.MaxAttempts)
+	return err
+}
+
+func (r *JobRepository) GetJobs(ctx context.Context) ([]models.ScheduledJob, error) {
+	jobs := []models.ScheduledJob{}
+	err := r.db.SelectContext(ctx, &jobs, `SELECT * FROM thyrasec.scheduled_jobs ORDER BY name`)
+	return jobs, err
+}
+
+func (r *JobRepository) GetJob(ctx context.Context, name string) (models.ScheduledJob, error) {
+	var job models.ScheduledJob
+	err := r.db.GetContext(ctx, &job, `SELECT * FROM thyrasec.scheduled_jobs WHERE name = $1`, name)
+	return job, err
+}
+
+// GetLastScheduledStart returns when the job last started on its schedule,
+// so a new leader picks up where the previous one stopped.
+func (r *JobRepository) GetLastScheduledStart(ctx context.Context, name string) (*time.Time, error) {
+	var startedAt *time.Time
+	err := r.db.GetContext(ctx, &startedAt, `
+        SELECT MAX(started_at) FROM thyrasec.job_runs
+        WHERE job_name = $1 AND trigger = 'schedule'`, name)
+	return startedAt, err
+}
+
+func (r *JobRepository) InsertRun(ctx context.Context, run models.JobRun) error {
+	_, err := r.db.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.job_runs (id, run_group, job_name, trigger, trigger_id, attempt, status, started_at)
+        VALUES (:id, :run_group, :job_name, :trigger, :trigger_id, :attempt, :status, :started_at)`, run)
+	return err
+}
+
+func (r *JobRepository) FinishRun(ctx context.Context, run models.JobRun) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.job_runs
+        SET status = $2, finished_at = $3, duration_ms = $4, error = $5
+        WHERE id = $1`, run.ID, run.Status, run.FinishedAt, run.DurationMs, run.Error)
+	return err
+}
+
+// FailAbandonedRuns marks runs left in running by a replica that stopped
+// mid-run as failed.
+func (r *JobRepository) FailAbandonedRuns(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.job_runs
+        SET status = 'failed', finished_at = now(), error = 'abandoned: scheduler stopped during the run'
+        WHERE status = 'running'`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetRuns returns the job's attempts, newest first, optionally limited to one status.
+func (r *JobRepository) GetRuns(ctx context.Context, name, status string, limit int) ([]models.JobRun, error) {
+	runs := []models.JobRun{}
+	err := r.db.SelectContext(ctx, &runs, `
+        SELECT * FROM thyrasec.job_runs
+        WHERE job_name = $1 AND ($2 = '' OR status = $2)
+        ORDER BY started_at DESC, attempt DESC
+        LIMIT $3`, name, status, limit)
+	return runs, err
+}
+
+func (r *JobRepository) GetRun(ctx context.Context, runID uuid.UUID) (models.JobRun, error) {
+	var run models.JobRun
+	err := r.db.GetContext(ctx, &run, `SELECT * FROM thyrasec.job_runs WHERE id = $1`, runID)
+	return run, err
+}
+
+func (r *JobRepository) GetLastRuns(ctx context.Context) (map[string]models.JobRun, error) {
+	var runs []models.JobRun
+	err := r.db.SelectContext(ctx, &runs, `
+        SELECT DISTINCT ON (job_name) * FROM thyrasec.job_runs
+        ORDER BY job_name, started_at DESC, attempt DESC`)
+	if err != nil {
+		return nil, err
+	}
+	lastRuns := make(map[string]models.JobRun, len(runs))
+	for _, run := range runs {
+		lastRuns[run.JobName] = run
+	}
+	return lastRuns, nil
+}
+
+func (r *JobRepository) InsertTrigger(ctx context.Context, trigger models.JobTrigger) error {
+	_, err := r.db.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.job_triggers (id, job_name, requested_by, requested_at)
+        VALUES (:id, :job_name, :requested_by, :requested_at)`, trigger)
+	return err
+}
+
+// ClaimTriggers marks every pending trigger as claimed and returns them,
+// oldest first.
+func (r *JobRepository) ClaimTriggers(ctx context.Context) ([]models.JobTrigger, error) {
+	triggers := []models.JobTrigger{}
+	err := r.db.SelectContext(ctx, &triggers, `
+        WITH claimed AS (
+            UPDATE thyrasec.job_triggers SET claimed_at = now()
+            WHERE id IN (
+                SELECT id FROM thyrasec.job_triggers
+                WHERE claimed_at IS NULL
+                ORDER BY requested_at
+                FOR UPDATE SKIP LOCKED
+            )
+            RETURNING *
+        )
+        SELECT * FROM claimed ORDER BY requested_at`)
+	return triggers, err
+}
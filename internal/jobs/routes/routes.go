@@ -0,0 +1,15 @@
+package routes
+
+import (
+	handlers "thyra/internal/jobs/api/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, jobHandler *handlers.JobHandler) {
+	jobs := router.Group("/jobs")
+	jobs.GET("", jobHandler.GetJobs)
+	jobs.GET("/runs/:runId", jobHandler.GetJobRun)
+	jobs.GET("/:jobName/runs", jobHandler.GetJobRuns)
+	jobs.POST("/:jobName/trigger", jobHandler.TriggerJob)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"thyra/internal/jobs/models"
+	"thyra/internal/jobs/repositories"
+	jobutils "thyra/internal/jobs/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRunLimit = 50
+	maxRunLimit     = 500
+)
+
+var (
+	ErrJobAccessDenied  = errors.New("only admins can manage scheduled jobs")
+	ErrJobNotFound      = errors.New("job not found")
+	ErrJobRunNotFound   = errors.New("job run not found")
+	ErrInvalidRunStatus = errors.New("status must be running, succeeded or failed")
+	ErrInvalidRunLimit  = errors.New("limit must be between 1 and 500")
+)
+
+// JobService exposes the scheduler's jobs and run history to admins and
+// queues on-demand runs for the scheduler to pick up.
+type JobService struct {
+	repo *repositories.JobRepository
+}
+
+func NewJobService(repo *repositories.JobRepository) *JobService {
+	return &JobService{repo: repo}
+}
+
+func (s *JobService) GetJobs(ctx context.Context, authUserRole string) ([]models.JobStatus, error) {
+	if authUserRole != "admin" {
+		return nil, ErrJobAccessDenied
+	}
+	jobs, err := s.repo.GetJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastRuns, err := s.repo.GetLastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	statuses := make([]models.JobStatus, len(jobs))
+	for i, job := range jobs {
+		statuses[i].ScheduledJob = job
+		if run, ok := lastRuns[job.Name]; ok {
+			statuses[i].LastRun = &run
+		}
+		if schedule, err := jobutils.ParseCron(job.Schedule); err == nil {
+			if next := schedule.Next(now); !next.IsZero() {
+				statuses[i].NextRunAt = &next
+			}
+		}
+	}
+	return statuses, nil
+}
+
+// GetRuns lists the job's attempts, newest first.
+func (s *JobService) GetRuns(ctx context.Context, name, status string, limit int, authUserRole string) ([]models.JobRun, error) {
+	if authUserRole != "admin" {
+		return nil, ErrJobAccessDenied
+	}
+	switch status {
+	case "", models.JobRunStatusRunning, models.JobRunStatusSucceeded, models.JobRunStatusFailed:
+	default:
+		return nil, ErrInvalidRunStatus
+	}
+	if limit == 0 {
+		limit = defaultRunLimit
+	}
+	if limit < 1 || limit > maxRunLimit {
+		return nil, ErrInvalidRunLimit
+	}
+	if _, err := s.getJob(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRuns(ctx, name, status, limit)
+}
+
+func (s *JobService) GetRun(ctx context.Context, runID uuid.UUID, authUserRole string) (models.JobRun, error) {
+	if authUserRole != "admin" {
+		return models.JobRun{}, ErrJobAccessDenied
+	}
+	run, err := s.repo.GetRun(ctx, runID)
+	if err == sql.ErrNoRows {
+		return run, ErrJobRunNotFound
+	}
+	return run, err
+}
+
+// TriggerJob queues a run of the job. The scheduler leader starts it on its
+// next poll.
+func (s *JobService) TriggerJob(ctx context.Context, name string, authUserID uuid.UUID, authUserRole string) (models.JobTrigger, error) {
+	if authUserRole != "admin" {
+		return models.JobTrigger{}, ErrJobAccessDenied
+	}
+	if _, err := s.getJob(ctx, name); err != nil {
+		return models.JobTrigger{}, err
+	}
+
+	trigger := models.JobTrigger{
+		ID:          uuid.New(),
+		JobName:     name,
+		RequestedBy: authUserID,
+		RequestedAt: time.Now(),
+	}
+	if err := s.repo.InsertTrigger(ctx, trigger); err != nil {
+		return models.JobTrigger{}, err
+	}
+	return trigger, nil
+}
+
+func (s *JobService) getJob(ctx context.Context, name string) (models.ScheduledJob, error) {
+	job, err := s.repo.GetJob(ctx, name)
+	if err == sql.ErrNoRows {
+		return job, ErrJobNotFound
+	}
+	return job, err
+}
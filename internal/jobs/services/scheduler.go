@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"thyra/internal/jobs/models"
+	"thyra/internal/jobs/repositories"
+	jobutils "thyra/internal/jobs/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	DefaultPollInterval = 30 * time.Second
+	DefaultRetryBackoff = 30 * time.Second
+	maxRetryBackoff     = 30 * time.Minute
+)
+
+var ErrDuplicateJob = errors.New("job is already registered")
+
+// Job is a named task run on a cron schedule. A failed run is retried up to
+// MaxAttempts attempts in total, waiting twice as long before each retry.
+type Job struct {
+	Name        string
+	Schedule    string
+	MaxAttempts int
+	Run         func(ctx context.Context) error
+}
+
+type registeredJob struct {
+	Job
+	schedule *jobutils.CronSchedule
+	nextRun  time.Time
+	retry    *pendingRetry
+}
+
+// pendingRetry is the next attempt of a failed run. It is picked up by a later
+// tick instead of being waited for, so other jobs keep running meanwhile.
+type pendingRetry struct {
+	at        time.Time
+	runGroup  uuid.UUID
+	attempt   int
+	trigger   string
+	triggerID *uuid.UUID
+}
+
+// Scheduler runs registered jobs on the replica holding the Postgres leader
+// lock. Other replicas poll until the lock is free, so a job is never run by
+// two replicas at once. Every attempt is recorded in job_runs.
+type Scheduler struct {
+	repo         *repositories.JobRepository
+	jobs         []*registeredJob
+	pollInterval time.Duration
+	retryBackoff time.Duration
+	leader       *sqlx.Conn
+}
+
+func NewScheduler(repo *repositories.JobRepository, pollInterval, retryBackoff time.Duration) *Scheduler {
+	return &Scheduler{repo: repo, pollInterval: pollInterval, retryBackoff: retryBackoff}
+}
+
+// NewSchedulerFromEnv reads the poll interval from JOB_POLL_INTERVAL_SECONDS
+// and the first retry delay from JOB_RETRY_BACKOFF_SECONDS.
+func NewSchedulerFromEnv(repo *repositories.JobRepository) *Scheduler {
+	return NewScheduler(repo,
+		secondsFromEnv("JOB_POLL_INTERVAL_SECONDS", DefaultPollInterval),
+		secondsFromEnv("JOB_RETRY_BACKOFF_SECONDS", DefaultRetryBackoff))
+}
+
+func secondsFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		log.Printf("Ignoring invalid %s %q", key, value)
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Register adds a job. Schedules are evaluated in the scheduler's local time.
+func (s *Scheduler) Register(job Job) error {
+	for _, registered := range s.jobs {
+		if registered.Name == job.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateJob, job.Name)
+		}
+	}
+	schedule, err := jobutils.ParseCron(job.Schedule)
+	if err != nil {
+		return err
+	}
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = 1
+	}
+	s.jobs = append(s.jobs, &registeredJob{Job: job, schedule: schedule})
+	return nil
+}
+
+// Run polls until the context is cancelled. While this replica is leader it
+// starts due jobs and jobs triggered through the API.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for _, job := range s.jobs {
+		err := s.repo.RegisterJob(ctx, models.ScheduledJob{Name: job.Name, Schedule: job.Schedule, MaxAttempts: job.MaxAttempts})
+		if err != nil {
+			return fmt.Errorf("registering job %s: %w", job.Name, err)
+		}
+	}
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		s.tick(ctx)
+		select {
+		case <-ctx.Done():
+			s.stepDown()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	if !s.ensureLeader(ctx) {
+		return
+	}
+
+	triggers, err := s.repo.ClaimTriggers(ctx)
+	if err != nil {
+		log.Printf("Failed to claim job triggers: %v", err)
+	}
+	for _, trigger := range triggers {
+		job := s.job(trigger.JobName)
+		if job == nil {
+			log.Printf("Ignoring trigger %s for unknown job %s", trigger.ID, trigger.JobName)
+			continue
+		}
+		triggerID := trigger.ID
+		s.startJob(ctx, job, models.JobTriggerManual, &triggerID)
+	}
+
+	for _, job := range s.jobs {
+		now := time.Now()
+		if job.nextRun.IsZero() || now.Before(job.nextRun) {
+			continue
+		}
+		job.nextRun = job.schedule.Next(now)
+		s.startJob(ctx, job, models.JobTriggerSchedule, nil)
+	}
+
+	for _, job := range s.jobs {
+		retry := job.retry
+		if retry == nil || time.Now().Before(retry.at) {
+			continue
+		}
+		job.retry = nil
+		s.runJob(ctx, job, retry.trigger, retry.triggerID, retry.runGroup, retry.attempt)
+	}
+}
+
+// ensureLeader keeps or takes the leader lock. On taking it, runs left
+// running by the previous leader are closed and each job's next run is
+// worked out from its last scheduled start, so a run missed during the
+// handover happens once, straight away.
+func (s *Scheduler) ensureLeader(ctx context.Context) bool {
+	if s.leader != nil {
+		err := s.repo.CheckLeaderLock(ctx, s.leader)
+		if err == nil {
+			return true
+		}
+		log.Printf("Lost scheduler leadership: %v", err)
+		s.leader.Close()
+		s.leader = nil
+	}
+
+	conn, err := s.repo.AcquireLeaderLock(ctx)
+	if err != nil {
+		log.Printf("Failed to acquire scheduler leader lock: %v", err)
+		return false
+	}
+	if conn == nil {
+		return false
+	}
+	s.leader = conn
+	log.Printf("Acquired scheduler leadership")
+
+	if abandoned, err := s.repo.FailAbandonedRuns(ctx); err != nil {
+		log.Printf("Failed to close abandoned job runs: %v", err)
+	} else if abandoned > 0 {
+		log.Printf("Marked %d abandoned job runs as failed", abandoned)
+	}
+
+	// Retries are only held in memory. A run that failed under an earlier
+	// leadership is not picked up again; its next scheduled run replaces it.
+	now := time.Now()
+	for _, job := range s.jobs {
+		job.retry = nil
+		job.nextRun = job.schedule.Next(now)
+		lastStart, err := s.repo.GetLastScheduledStart(ctx, job.Name)
+		if err != nil {
+			log.Printf("Failed to load last run of job %s: %v", job.Name, err)
+			continue
+		}
+		if lastStart != nil {
+			job.nextRun = job.schedule.Next(lastStart.In(now.Location()))
+		}
+	}
+	return true
+}
+
+func (s *Scheduler) stepDown() {
+	if s.leader == nil {
+		return
+	}
+	if err := s.repo.ReleaseLeaderLock(context.Background(), s.leader); err != nil {
+		log.Printf("Failed to release scheduler leader lock: %v", err)
+	}
+	s.leader = nil
+}
+
+func (s *Scheduler) job(name string) *registeredJob {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// startJob begins a new run of the job. A retry still pending from an earlier
+// run is dropped, since the new run supersedes it.
+func (s *Scheduler) startJob(ctx context.Context, job *registeredJob, trigger string, triggerID *uuid.UUID) {
+	job.retry = nil
+	s.runJob(ctx, job, trigger, triggerID, uuid.New(), 1)
+}
+
+// runJob runs one attempt of the job. A failed attempt schedules the next one
+// with exponential backoff, to be run by a later tick.
+func (s *Scheduler) runJob(ctx context.Context, job *registeredJob, trigger string, triggerID *uuid.UUID, runGroup uuid.UUID, attempt int) {
+	run := models.JobRun{
+		ID:        uuid.New(),
+		RunGroup:  runGroup,
+		JobName:   job.Name,
+		Trigger:   trigger,
+		TriggerID: triggerID,
+		Attempt:   attempt,
+		Status:    models.JobRunStatusRunning,
+		StartedAt: time.Now(),
+	}
+	if err := s.repo.InsertRun(ctx, run); err != nil {
+		log.Printf("Failed to record run of job %s: %v", job.Name, err)
+		return
+	}
+
+	err := runAttempt(ctx, job.Job)
+	finishedAt := time.Now()
+	duration := finishedAt.Sub(run.StartedAt).Milliseconds()
+	run.FinishedAt, run.DurationMs = &finishedAt, &duration
+	run.Status = models.JobRunStatusSucceeded
+	if err != nil {
+		message := err.Error()
+		run.Status, run.Error = models.JobRunStatusFailed, &message
+	}
+	if finishErr := s.repo.FinishRun(ctx, run); finishErr != nil {
+		log.Printf("Failed to record result of job %s: %v", job.Name, finishErr)
+	}
+
+	if err == nil {
+		log.Printf("Job %s succeeded in %dms", job.Name, duration)
+		return
+	}
+	log.Printf("Job %s failed (attempt %d/%d): %v", job.Name, attempt, job.MaxAttempts, err)
+	if attempt == job.MaxAttempts {
+		return
+	}
+	job.retry = &pendingRetry{
+		at:        finishedAt.Add(s.backoff(attempt)),
+		runGroup:  runGroup,
+		attempt:   attempt + 1,
+		trigger:   trigger,
+		triggerID: triggerID,
+	}
+}
+
+// backoff is the wait after the given failed attempt: the configured delay,
+// doubled for each earlier failure and capped at maxRetryBackoff.
+func (s *Scheduler) backoff(attempt int) time.Duration {
+	backoff := s.retryBackoff
+	for i := 1; i < attempt; i++ {
+		if backoff *= 2; backoff > maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+	return backoff
+}
+
+// runAttempt turns a panicking job into a failed attempt.
+func runAttempt(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five-field cron expression:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field accepts *, single values, ranges (1-5), lists (1,15) and steps
+// (*/15, 0-30/10). Day of week runs from 0 (Sunday) to 6; 7 is also Sunday.
+// As in classic cron, when both day fields are restricted a time matches if
+// either of them does.
+type CronSchedule struct {
+	expression string
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekday bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// searchLimit bounds Next for expressions that can never match, such as
+// 30 February.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+func ParseCron(expression string) (*CronSchedule, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields", expression, len(cronFields))
+	}
+
+	bits := make([]uint64, len(cronFields))
+	for i, field := range cronFields {
+		value, err := parseCronField(parts[i], field)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expression, err)
+		}
+		bits[i] = value
+	}
+
+	schedule := &CronSchedule{
+		expression: expression,
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+	return schedule, nil
+}
+
+func (s *CronSchedule) String() string {
+	return s.expression
+}
+
+// Next returns the first minute strictly after t that matches the schedule,
+// in t's location. Wall-clock times skipped by a daylight saving change do
+// not run, and times repeated by one run only once. It returns the zero time
+// when nothing matches.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+	after := wallClock(t)
+	for next.Before(limit) {
+		switch {
+		case s.months&(1<<uint(next.Month())) == 0:
+			next = advance(next, next.Year(), next.Month()+1, 1, 0)
+		case !s.matchesDay(next):
+			next = advance(next, next.Year(), next.Month(), next.Day()+1, 0)
+		case s.hours&(1<<uint(next.Hour())) == 0:
+			next = advance(next, next.Year(), next.Month(), next.Day(), next.Hour()+1)
+		case s.minutes&(1<<uint(next.Minute())) == 0 || !wallClock(next).After(after):
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// advance moves from to the start of the given hour. time.Date normalises a
+// time inside a daylight saving gap to before the gap, so when that would not
+// move forward it steps a minute instead.
+func advance(from time.Time, year int, month time.Month, day, hour int) time.Time {
+	next := time.Date(year, month, day, hour, 0, 0, 0, from.Location())
+	if !next.After(from) {
+		return from.Add(time.Minute)
+	}
+	return next
+}
+
+// wallClock returns t's local date and time read as UTC, so that the second
+// pass through an hour repeated by a daylight saving change compares equal to
+// the first.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	dayMatch := s.days&(1<<uint(t.Day())) != 0
+	weekdayMatch := s.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekdayMatch
+	case s.anyWeekday:
+		return dayMatch
+	default:
+		return dayMatch || weekdayMatch
+	}
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rangePart = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %s field %q", field.name, part)
+			}
+		}
+
+		low, high := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = cronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			if high, err = cronValue(bounds[1], field); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range in %s field %q", field.name, part)
+			}
+		default:
+			single, err := cronValue(rangePart, field)
+			if err != nil {
+				return 0, err
+			}
+			low = single
+			if step == 1 {
+				high = single
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(value string, field cronField) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < field.min || v > field.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %q", field.name, field.min, field.max, value)
+	}
+	return v, nil
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronFieldRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		valid      bool
+	}{
+		{"every minute", "* * * * *", true},
+		{"upper bounds", "59 23 31 12 7", true},
+		{"lower bounds", "0 0 1 1 0", true},
+		{"minute too high", "60 * * * *", false},
+		{"hour too high", "* 24 * * *", false},
+		{"day of month zero", "* * 0 * *", false},
+		{"day of month too high", "* * 32 * *", false},
+		{"month zero", "* * * 0 *", false},
+		{"month too high", "* * * 13 *", false},
+		{"day of week too high", "* * * * 8", false},
+		{"negative value", "-1 * * * *", false},
+		{"reversed range", "30-10 * * * *", false},
+		{"zero step", "*/0 * * * *", false},
+		{"non-numeric step", "*/x * * * *", false},
+		{"non-numeric value", "a * * * *", false},
+		{"too few fields", "* * * *", false},
+		{"too many fields", "* * * * * *", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCron(tt.expression)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ParseCron(%q) error = %v, want valid %v", tt.expression, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2026-10-19 is a Monday.
+	from := time.Date(2026, 10, 19, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expression string
+		want       time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2026, 10, 19, 10, 8, 0, 0, time.UTC)},
+		{"step from wildcard", "*/15 * * * *", time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC)},
+		{"step within range", "0-30/10 * * * *", time.Date(2026, 10, 19, 10, 10, 0, 0, time.UTC)},
+		{"step from single value", "5/20 * * * *", time.Date(2026, 10, 19, 10, 25, 0, 0, time.UTC)},
+		{"step past range end", "0-5/10 * * * *", time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC)},
+		{"list", "5,50 * * * *", time.Date(2026, 10, 19, 10, 50, 0, 0, time.UTC)},
+		{"hour step", "0 */6 * * *", time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)},
+		{"later today", "30 18 * * *", time.Date(2026, 10, 19, 18, 30, 0, 0, time.UTC)},
+		{"tomorrow", "0 6 * * *", time.Date(2026, 10, 20, 6, 0, 0, 0, time.UTC)},
+		{"weekday range", "0 9 * * 6-7", time.Date(2026, 10, 24, 9, 0, 0, 0, time.UTC)},
+		{"seven is Sunday", "0 9 * * 7", time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC)},
+		{"day of month or weekday", "0 0 1 * 3", time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)},
+		{"next month", "0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"next year", "0 0 1 1 *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never matches", "0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextIsStrictlyAfter(t *testing.T) {
+	schedule, err := ParseCron("0 12 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	if got := schedule.Next(at); !got.Equal(want) {
+		t.Errorf("Next(%s) = %s, want %s", at, got, want)
+	}
+}
+
+func TestCronNextAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		from       time.Time
+		want       []time.Time
+	}{
+		{
+			// Clocks jump from 02:00 to 03:00 on 8 March 2026, so 02:30 does
+			// not exist that day.
+			name:       "skipped hour",
+			expression: "30 2 * * *",
+			from:       time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name:       "hour after the gap",
+			expression: "0 3 * * *",
+			from:       time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 3, 0, 0, 0, newYork),
+				time.Date(2026, 3, 9, 3, 0, 0, 0, newYork),
+			},
+		},
+		{
+			// Clocks go back from 02:00 to 01:00 on 1 November 2026; the
+			// repeated 01:30 runs once.
+			name:       "repeated hour",
+			expression: "30 1 * * *",
+			from:       time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC),
+				time.Date(2026, 11, 2, 1, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name:       "hourly through the repeated hour",
+			expression: "0 * * * *",
+			from:       time.Date(2026, 11, 1, 0, 30, 0, 0, newYork),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 1, 7, 0, 0, 0, time.UTC),
+				time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := tt.from
+			for i, want := range tt.want {
+				got := schedule.Next(at)
+				if !got.Equal(want) {
+					t.Fatalf("run %d: Next(%s) = %s, want %s", i, at, got, want)
+				}
+				at = got
+			}
+		})
+	}
+}
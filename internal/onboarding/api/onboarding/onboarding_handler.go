@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"thyra/internal/onboarding/models"
+	"thyra/internal/onboarding/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type OnboardingHandler struct {
+	service *services.OnboardingService
+}
+
+func NewOnboardingHandler(service *services.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{service: service}
+}
+
+// SignUpHandler is public; customers register themselves and submit KYC
+// details in the same request.
+func (h *OnboardingHandler) SignUpHandler(c *gin.Context) {
+	var req models.SignUpRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+	if req.Username == "" || req.Password == "" || req.Email == "" || req.FullName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username, password, email and full_name are required"})
+		return
+	}
+
+	userID, err := h.service.SignUp(c.Request.Context(), req)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username or email is already registered"})
+			return
+		}
+		writeOnboardingError(c, err, "Error signing up")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Sign-up successful, KYC is pending review", "user_id": userID, "kyc_status": models.KYCStatusPending})
+}
+
+func (h *OnboardingHandler) GetKYCHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	kyc, err := h.service.GetKYC(c.Request.Context(), userID)
+	if err != nil {
+		writeOnboardingError(c, err, "Failed to fetch KYC profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, kyc)
+}
+
+func (h *OnboardingHandler) SubmitKYCHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var details models.KYCDetails
+	if err := c.BindJSON(&details); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.SubmitKYC(c.Request.Context(), userID, details); err != nil {
+		writeOnboardingError(c, err, "Failed to submit KYC profile")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "KYC profile submitted for review", "kyc_status": models.KYCStatusPending})
+}
+
+// UploadDocumentHandler expects a multipart form with "document_type" and "file".
+func (h *OnboardingHandler) UploadDocumentHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxDocumentSize+(1<<20))
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required", "details": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	doc, err := h.service.UploadDocument(c.Request.Context(), userID, c.PostForm("document_type"), fileHeader.Filename, fileHeader.Size, file)
+	if err != nil {
+		writeOnboardingError(c, err, "Failed to upload document")
+		return
+	}
+
+	c.JSON(http.StatusCreated, doc)
+}
+
+func (h *OnboardingHandler) DownloadDocumentHandler(c *gin.Context) {
+	userID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	documentID, err := uuid.Parse(c.Param("documentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	doc, content, err := h.service.OpenDocument(c.Request.Context(), documentID, userID, userRole)
+	if err != nil {
+		writeOnboardingError(c, err, "Failed to open document")
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Disposition", "attachment; filename=\""+doc.ID.String()+"\"")
+	c.Header("Content-Type", doc.ContentType)
+	c.Status(http.StatusOK)
+	io.Copy(c.Writer, content)
+}
+
+func (h *OnboardingHandler) GetReviewQueueHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	queue, err := h.service.GetReviewQueue(c.Request.Context(), c.DefaultQuery("status", models.KYCStatusPending), userRole)
+	if err != nil {
+		writeOnboardingError(c, err, "Failed to fetch review queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+func (h *OnboardingHandler) GetReviewHandler(c *gin.Context) {
+	_, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	customerID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	review, err := h.service.GetReview(c.Request.Context(), customerID, userRole)
+	if err != nil {
+		writeOnboardingError(c, err, "Failed to fetch KYC review")
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+func (h *OnboardingHandler) DecideReviewHandler(c *gin.Context) {
+	reviewerID, userRole, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	customerID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.ReviewDecisionRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.Decide(c.Request.Context(), customerID, reviewerID, userRole, req); err != nil {
+		writeOnboardingError(c, err, "Failed to record KYC decision")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "KYC decision recorded", "kyc_status": req.Status})
+}
+
+func authenticatedUserUUID(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userType, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+
+	return userUUID, userType, true
+}
+
+func writeOnboardingError(c *gin.Context, err error, message string) {
+	var validationErr *services.KYCValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid KYC details", "violations": validationErr.Violations})
+		return
+	}
+	var policyErr *userutils.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password does not meet policy", "violations": policyErr.Violations})
+		return
+	}
+
+	switch err {
+	case services.ErrKYCProfileNotFound, services.ErrDocumentNotFound, sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrNotComplianceUser:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrKYCAlreadyApproved, services.ErrKYCNotPending:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrInvalidDecision, services.ErrRejectionNote, services.ErrInvalidDocumentType,
+		services.ErrInvalidContentType:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrDocumentTooLarge:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KYCStatusPending  = "pending"
+	KYCStatusApproved = "approved"
+	KYCStatusRejected = "rejected"
+)
+
+const (
+	DocumentTypePassport       = "passport"
+	DocumentTypeNationalIDCard = "national_id_card"
+	DocumentTypeDriversLicense = "drivers_license"
+	DocumentTypeProofOfAddress = "proof_of_address"
+)
+
+type KYCProfile struct {
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	NationalID   *string    `db:"national_id" json:"national_id"`
+	DateOfBirth  *time.Time `db:"date_of_birth" json:"date_of_birth"`
+	Citizenship  *string    `db:"citizenship" json:"citizenship"`
+	TaxResidency *string    `db:"tax_residency" json:"tax_residency"`
+	IsPEP        bool       `db:"is_pep" json:"is_pep"`
+	PEPDetails   *string    `db:"pep_details" json:"pep_details,omitempty"`
+	Status       string     `db:"status" json:"status"`
+	SubmittedAt  *time.Time `db:"submitted_at" json:"submitted_at"`
+	ReviewedBy   *uuid.UUID `db:"reviewed_by" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `db:"reviewed_at" json:"reviewed_at,omitempty"`
+	ReviewNote   *string    `db:"review_note" json:"review_note,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+type KYCDocument struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	UserID       uuid.UUID `db:"user_id" json:"user_id"`
+	DocumentType string    `db:"document_type" json:"document_type"`
+	FileName     string    `db:"file_name" json:"file_name"`
+	ContentType  string    `db:"content_type" json:"content_type"`
+	SizeBytes    int64     `db:"size_bytes" json:"size_bytes"`
+	StorageKey   string    `db:"storage_key" json:"-"`
+	UploadedAt   time.Time `db:"uploaded_at" json:"uploaded_at"`
+}
+
+type KYCStatusChange struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	UserID     uuid.UUID `db:"user_id" json:"user_id"`
+	FromStatus *string   `db:"from_status" json:"from_status"`
+	ToStatus   string    `db:"to_status" json:"to_status"`
+	ChangedBy  uuid.UUID `db:"changed_by" json:"changed_by"`
+	Note       *string   `db:"note" json:"note,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// KYCDetails is the customer supplied part of the profile.
+type KYCDetails struct {
+	NationalID   string `json:"national_id"`
+	DateOfBirth  string `json:"date_of_birth"` // YYYY-MM-DD
+	Citizenship  string `json:"citizenship"`   // ISO 3166-1 alpha-2
+	TaxResidency string `json:"tax_residency"` // ISO 3166-1 alpha-2
+	IsPEP        bool   `json:"is_pep"`
+	PEPDetails   string `json:"pep_details"`
+}
+
+type SignUpRequest struct {
+	Username    string     `json:"username"`
+	Password    string     `json:"password"`
+	Email       string     `json:"email"`
+	FullName    string     `json:"full_name"`
+	Address     string     `json:"address"`
+	PhoneNumber string     `json:"phone_number"`
+	KYC         KYCDetails `json:"kyc"`
+}
+
+type ReviewDecisionRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// ReviewQueueItem is one row in the compliance review queue.
+type ReviewQueueItem struct {
+	UserID        uuid.UUID  `db:"user_id" json:"user_id"`
+	Username      string     `db:"username" json:"username"`
+	FullName      *string    `db:"full_name" json:"full_name"`
+	Email         *string    `db:"email" json:"email"`
+	Status        string     `db:"status" json:"status"`
+	IsPEP         bool       `db:"is_pep" json:"is_pep"`
+	SubmittedAt   *time.Time `db:"submitted_at" json:"submitted_at"`
+	DocumentCount int        `db:"document_count" json:"document_count"`
+}
+
+type KYCReview struct {
+	Profile   KYCProfile        `json:"profile"`
+	Documents []KYCDocument     `json:"documents"`
+	History   []KYCStatusChange `json:"history"`
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/onboarding/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type KYCRepository struct {
+	db *sqlx.DB
+}
+
+func NewKYCRepository(db *sqlx.DB) *KYCRepository {
+	return &KYCRepository{db: db}
+}
+
+const kycProfileColumns = `user_id, national_id, date_of_birth, citizenship, tax_residency, is_pep, pep_details,
+        status, submitted_at, reviewed_by, reviewed_at, review_note, created_at, updated_at`
+
+func (r *KYCRepository) GetProfile(ctx context.Context, userID uuid.UUID) (models.KYCProfile, error) {
+	var profile models.KYCProfile
+	query := `SELECT ` + kycProfileColumns + ` FROM thyrasec.kyc_profiles WHERE user_id = $1`
+	err := r.db.GetContext(ctx, &profile, query, userID)
+	return profile, err
+}
+
+// SaveProfile inserts or replaces the customer supplied fields, moves the
+// profile to pending and records the status change in one transaction.
+func (r *KYCRepository) SaveProfile(ctx context.Context, profile models.KYCProfile, fromStatus *string, changedBy uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO thyrasec.kyc_profiles (user_id, national_id, date_of_birth, citizenship, tax_residency, is_pep, pep_details,
+            status, submitted_at, created_at, updated_at)
+        VALUES (:user_id, :national_id, :date_of_birth, :citizenship, :tax_residency, :is_pep, :pep_details,
+            :status, :submitted_at, :created_at, :updated_at)
+        ON CONFLICT (user_id) DO UPDATE
+        SET national_id = EXCLUDED.national_id,
+            date_of_birth = EXCLUDED.date_of_birth,
+            citizenship = EXCLUDED.citizenship,
+            tax_residency = EXCLUDED.tax_residency,
+            is_pep = EXCLUDED.is_pep,
+            pep_details = EXCLUDED.pep_details,
+            status = EXCLUDED.status,
+            submitted_at = EXCLUDED.submitted_at,
+            reviewed_by = NULL,
+            reviewed_at = NULL,
+            review_note = NULL,
+            updated_at = EXCLUDED.updated_at`
+	if _, err := tx.NamedExecContext(ctx, query, profile); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if fromStatus == nil || *fromStatus != profile.Status {
+		if err := insertStatusChange(ctx, tx, profile.UserID, fromStatus, profile.Status, changedBy, nil, profile.UpdatedAt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus applies a review decision. It only succeeds while the profile is
+// still in fromStatus so two reviewers cannot both decide the same case.
+func (r *KYCRepository) UpdateStatus(ctx context.Context, userID uuid.UUID, fromStatus, toStatus string, reviewedBy uuid.UUID, note *string, now time.Time) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+        UPDATE thyrasec.kyc_profiles
+        SET status = $1, reviewed_by = $2, reviewed_at = $3, review_note = $4, updated_at = $3
+        WHERE user_id = $5 AND status = $6`
+	result, err := tx.ExecContext(ctx, query, toStatus, reviewedBy, now, note, userID, fromStatus)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := insertStatusChange(ctx, tx, userID, &fromStatus, toStatus, reviewedBy, note, now); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *KYCRepository) GetReviewQueue(ctx context.Context, status string) ([]models.ReviewQueueItem, error) {
+	var items []models.ReviewQueueItem
+	query := `
+        SELECT k.user_id, u.username, cp.full_name, u.email, k.status, k.is_pep, k.submitted_at,
+            (SELECT COUNT(*) FROM thyrasec.kyc_documents d WHERE d.user_id = k.user_id) AS document_count
+        FROM thyrasec.kyc_profiles k
+        JOIN thyrasec.users u ON u.id = k.user_id
+        LEFT JOIN thyrasec.customer_profiles cp ON cp.user_id = k.user_id
+        WHERE k.status = $1
+        ORDER BY k.submitted_at ASC NULLS LAST`
+	err := r.db.SelectContext(ctx, &items, query, status)
+	return items, err
+}
+
+func (r *KYCRepository) GetStatusHistory(ctx context.Context, userID uuid.UUID) ([]models.KYCStatusChange, error) {
+	var history []models.KYCStatusChange
+	query := `
+        SELECT id, user_id, from_status, to_status, changed_by, note, created_at
+        FROM thyrasec.kyc_status_history
+        WHERE user_id = $1
+        ORDER BY created_at`
+	err := r.db.SelectContext(ctx, &history, query, userID)
+	return history, err
+}
+
+func (r *KYCRepository) InsertDocument(ctx context.Context, doc models.KYCDocument) error {
+	query := `
+        INSERT INTO thyrasec.kyc_documents (id, user_id, document_type, file_name, content_type, size_bytes, storage_key, uploaded_at)
+        VALUES (:id, :user_id, :document_type, :file_name, :content_type, :size_bytes, :storage_key, :uploaded_at)`
+	_, err := r.db.NamedExecContext(ctx, query, doc)
+	return err
+}
+
+func (r *KYCRepository) GetDocuments(ctx context.Context, userID uuid.UUID) ([]models.KYCDocument, error) {
+	var docs []models.KYCDocument
+	query := `
+        SELECT id, user_id, document_type, file_name, content_type, size_bytes, storage_key, uploaded_at
+        FROM thyrasec.kyc_documents
+        WHERE user_id = $1
+        ORDER BY uploaded_at`
+	err := r.db.SelectContext(ctx, &docs, query, userID)
+	return docs, err
+}
+
+func (r *KYCRepository) GetDocument(ctx context.Context, documentID uuid.UUID) (models.KYCDocument, error) {
+	var doc models.KYCDocument
+	query := `
+        SELECT id, user_id, document_type, file_name, content_type, size_bytes, storage_key, uploaded_at
+        FROM thyrasec.kyc_documents
+        WHERE id = $1`
+	err := r.db.GetContext(ctx, &doc, query, documentID)
+	return doc, err
+}
+
+func insertStatusChange(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, fromStatus *string, toStatus string, changedBy uuid.UUID, note *string, now time.Time) error {
+	query := `
+        INSERT INTO thyrasec.kyc_status_history (user_id, from_status, to_status, changed_by, note, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := tx.ExecContext(ctx, query, userID, fromStatus, toStatus, changedBy, note, now)
+	return err
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	middleware "thyra/internal/common/middleware"
+	handlers "thyra/internal/onboarding/api/onboarding"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, onboardingHandler *handlers.OnboardingHandler) {
+	// Public route
+	router.POST("/onboarding/signup", onboardingHandler.SignUpHandler)
+
+	onboarding := router.Group("/onboarding")
+	onboarding.Use(middleware.TokenMiddleware)
+
+	onboarding.GET("/kyc", onboardingHandler.GetKYCHandler)
+	onboarding.PUT("/kyc", onboardingHandler.SubmitKYCHandler)
+	onboarding.POST("/kyc/documents", onboardingHandler.UploadDocumentHandler)
+	onboarding.GET("/kyc/documents/:documentId", onboardingHandler.DownloadDocumentHandler)
+
+	// Compliance review queue
+	onboarding.GET("/reviews", onboardingHandler.GetReviewQueueHandler)
+	onboarding.GET("/reviews/:userId", onboardingHandler.GetReviewHandler)
+	onboarding.POST("/reviews/:userId/decision", onboardingHandler.DecideReviewHandler)
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"thyra/internal/common/storage"
+	compliancemodels "thyra/internal/compliance/models"
+	"thyra/internal/onboarding/models"
+	"thyra/internal/onboarding/repositories"
+	"thyra/internal/onboarding/utils"
+	usermodels "thyra/internal/users/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxDocumentSize = 10 << 20
+	minCustomerAge  = 18
+)
+
+var allowedDocumentTypes = map[string]bool{
+	models.DocumentTypePassport:       true,
+	models.DocumentTypeNationalIDCard: true,
+	models.DocumentTypeDriversLicense: true,
+	models.DocumentTypeProofOfAddress: true,
+}
+
+var allowedContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+var (
+	ErrKYCProfileNotFound  = errors.New("KYC profile not found")
+	ErrKYCAlreadyApproved  = errors.New("KYC profile is already approved")
+	ErrKYCNotPending       = errors.New("KYC profile is not pending review")
+	ErrInvalidDecision     = errors.New("status must be approved or rejected")
+	ErrRejectionNote       = errors.New("a note is required when rejecting")
+	ErrInvalidDocumentType = errors.New("unsupported document type")
+	ErrDocumentTooLarge    = errors.New("document exceeds the 10 MB limit")
+	ErrInvalidContentType  = errors.New("document must be a PDF, JPEG or PNG")
+	ErrDocumentNotFound    = errors.New("document not found")
+	ErrNotComplianceUser   = errors.New("only admins can review KYC profiles")
+)
+
+// KYCValidationError lists every problem with the submitted KYC details.
+type KYCValidationError struct {
+	Violations []string
+}
+
+func (e *KYCValidationError) Error() string {
+	return "invalid KYC details: " + strings.Join(e.Violations, "; ")
+}
+
+// CustomerRegistrar creates the customer identity. It is implemented by the
+// users module so sign-up gets the same password policy and verification email.
+type CustomerRegistrar interface {
+	RegisterCustomer(ctx context.Context, customer usermodels.CustomerRegistrationRequest) (uuid.UUID, error)
+}
+
+// CustomerScreener screens customers against sanctions and PEP lists. The
+// date of birth and citizenship in the KYC profile sharpen the match, so
+// customers are screened again whenever their profile changes.
+type CustomerScreener interface {
+	ScreenCustomer(ctx context.Context, userID uuid.UUID, trigger string) error
+}
+
+type OnboardingService struct {
+	repo      *repositories.KYCRepository
+	storage   storage.Storage
+	registrar CustomerRegistrar
+	screener  CustomerScreener
+}
+
+func NewOnboardingService(repo *repositories.KYCRepository, storage storage.Storage, registrar CustomerRegistrar, screener CustomerScreener) *OnboardingService {
+	return &OnboardingService{repo: repo, storage: storage, registrar: registrar, screener: screener}
+}
+
+// SignUp registers a customer and submits the KYC profile for review. If the
+// profile cannot be stored the user still exists and can resubmit it after
+// logging in.
+func (s *OnboardingService) SignUp(ctx context.Context, req models.SignUpRequest) (uuid.UUID, error) {
+	now := time.Now()
+	profile, err := buildProfile(req.KYC, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := s.registrar.RegisterCustomer(ctx, usermodels.CustomerRegistrationRequest{
+		BaseRegistrationRequest: usermodels.BaseRegistrationRequest{
+			Username: req.Username,
+			Password: req.Password,
+			Email:    req.Email,
+		},
+		FullName:    req.FullName,
+		Address:     req.Address,
+		PhoneNumber: req.PhoneNumber,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	profile.UserID = userID
+	if err := s.repo.SaveProfile(ctx, profile, nil, userID); err != nil {
+		return userID, err
+	}
+	s.screenCustomer(ctx, userID)
+	return userID, nil
+}
+
+func (s *OnboardingService) GetKYC(ctx context.Context, userID uuid.UUID) (models.KYCReview, error) {
+	profile, err := s.repo.GetProfile(ctx, userID)
+	if err == sql.ErrNoRows {
+		return models.KYCReview{}, ErrKYCProfileNotFound
+	}
+	if err != nil {
+		return models.KYCReview{}, err
+	}
+
+	documents, err := s.repo.GetDocuments(ctx, userID)
+	if err != nil {
+		return models.KYCReview{}, err
+	}
+	history, err := s.repo.GetStatusHistory(ctx, userID)
+	if err != nil {
+		return models.KYCReview{}, err
+	}
+
+	return models.KYCReview{Profile: profile, Documents: documents, History: history}, nil
+}
+
+// SubmitKYC replaces the customer's KYC details. A rejected profile goes back
+// to pending; an approved one can no longer be changed by the customer.
+func (s *OnboardingService) SubmitKYC(ctx context.Context, userID uuid.UUID, details models.KYCDetails) error {
+	var fromStatus *string
+	current, err := s.repo.GetProfile(ctx, userID)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	case current.Status == models.KYCStatusApproved:
+		return ErrKYCAlreadyApproved
+	default:
+		fromStatus = &current.Status
+	}
+
+	profile, err := buildProfile(details, time.Now())
+	if err != nil {
+		return err
+	}
+	profile.UserID = userID
+
+	if err := s.repo.SaveProfile(ctx, profile, fromStatus, userID); err != nil {
+		return err
+	}
+	s.screenCustomer(ctx, userID)
+	return nil
+}
+
+// screenCustomer logs rather than returns errors; the profile is saved and the
+// next list import screens the customer again.
+func (s *OnboardingService) screenCustomer(ctx context.Context, userID uuid.UUID) {
+	if err := s.screener.ScreenCustomer(ctx, userID, compliancemodels.ScreeningTriggerKYCUpdate); err != nil {
+		log.Printf("Error screening customer %s: %v", userID, err)
+	}
+}
+
+func (s *OnboardingService) UploadDocument(ctx context.Context, userID uuid.UUID, documentType, fileName string, size int64, r io.Reader) (models.KYCDocument, error) {
+	if !allowedDocumentTypes[documentType] {
+		return models.KYCDocument{}, ErrInvalidDocumentType
+	}
+	if size > MaxDocumentSize {
+		return models.KYCDocument{}, ErrDocumentTooLarge
+	}
+
+	profile, err := s.repo.GetProfile(ctx, userID)
+	if err == sql.ErrNoRows {
+		return models.KYCDocument{}, ErrKYCProfileNotFound
+	}
+	if err != nil {
+		return models.KYCDocument{}, err
+	}
+	if profile.Status == models.KYCStatusApproved {
+		return models.KYCDocument{}, ErrKYCAlreadyApproved
+	}
+
+	// Trust the file contents rather than the client supplied content type.
+	buffered := bufio.NewReaderSize(r, 512)
+	head, _ := buffered.Peek(512)
+	contentType := http.DetectContentType(head)
+	if !allowedContentTypes[contentType] {
+		return models.KYCDocument{}, ErrInvalidContentType
+	}
+
+	doc := models.KYCDocument{
+		ID:           uuid.New(),
+		UserID:       userID,
+		DocumentType: documentType,
+		FileName:     filepath.Base(fileName),
+		ContentType:  contentType,
+		UploadedAt:   time.Now(),
+	}
+	doc.StorageKey = "kyc/" + userID.String() + "/" + doc.ID.String()
+
+	written, err := s.storage.Save(doc.StorageKey, io.LimitReader(buffered, MaxDocumentSize+1))
+	if err != nil {
+		return models.KYCDocument{}, err
+	}
+	if written > MaxDocumentSize {
+		s.deleteDocument(doc.StorageKey)
+		return models.KYCDocument{}, ErrDocumentTooLarge
+	}
+	doc.SizeBytes = written
+
+	if err := s.repo.InsertDocument(ctx, doc); err != nil {
+		s.deleteDocument(doc.StorageKey)
+		return models.KYCDocument{}, err
+	}
+	return doc, nil
+}
+
+// OpenDocument lets the owner and compliance read an uploaded document.
+func (s *OnboardingService) OpenDocument(ctx context.Context, documentID, authUserID uuid.UUID, authUserRole string) (models.KYCDocument, io.ReadCloser, error) {
+	doc, err := s.repo.GetDocument(ctx, documentID)
+	if err == sql.ErrNoRows {
+		return models.KYCDocument{}, nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return models.KYCDocument{}, nil, err
+	}
+	if doc.UserID != authUserID && authUserRole != "admin" {
+		return models.KYCDocument{}, nil, ErrDocumentNotFound
+	}
+
+	content, err := s.storage.Open(doc.StorageKey)
+	if err != nil {
+		return models.KYCDocument{}, nil, err
+	}
+	return doc, content, nil
+}
+
+func (s *OnboardingService) GetReviewQueue(ctx context.Context, status, authUserRole string) ([]models.ReviewQueueItem, error) {
+	if authUserRole != "admin" {
+		return nil, ErrNotComplianceUser
+	}
+	return s.repo.GetReviewQueue(ctx, status)
+}
+
+func (s *OnboardingService) GetReview(ctx context.Context, userID uuid.UUID, authUserRole string) (models.KYCReview, error) {
+	if authUserRole != "admin" {
+		return models.KYCReview{}, ErrNotComplianceUser
+	}
+	return s.GetKYC(ctx, userID)
+}
+
+// Decide moves a pending profile to approved or rejected.
+func (s *OnboardingService) Decide(ctx context.Context, userID, reviewerID uuid.UUID, authUserRole string, req models.ReviewDecisionRequest) error {
+	if authUserRole != "admin" {
+		return ErrNotComplianceUser
+	}
+	if req.Status != models.KYCStatusApproved && req.Status != models.KYCStatusRejected {
+		return ErrInvalidDecision
+	}
+
+	var note *string
+	if trimmed := strings.TrimSpace(req.Note); trimmed != "" {
+		note = &trimmed
+	} else if req.Status == models.KYCStatusRejected {
+		return ErrRejectionNote
+	}
+
+	updated, err := s.repo.UpdateStatus(ctx, userID, models.KYCStatusPending, req.Status, reviewerID, note, time.Now())
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrKYCNotPending
+	}
+	return nil
+}
+
+func (s *OnboardingService) deleteDocument(key string) {
+	if err := s.storage.Delete(key); err != nil {
+		log.Printf("Error deleting document %s: %v", key, err)
+	}
+}
+
+// buildProfile validates the details and returns a pending profile. Swedish
+// citizens and tax residents must give a valid personnummer matching the date
+// of birth; it is stored in its 12 digit form.
+func buildProfile(details models.KYCDetails, now time.Time) (models.KYCProfile, error) {
+	var violations []string
+
+	nationalID := strings.TrimSpace(details.NationalID)
+	citizenship := strings.ToUpper(strings.TrimSpace(details.Citizenship))
+	taxResidency := strings.ToUpper(strings.TrimSpace(details.TaxResidency))
+
+	if nationalID == "" {
+		violations = append(violations, "national_id is required")
+	}
+
+	dateOfBirth, err := time.Parse("2006-01-02", details.DateOfBirth)
+	if err != nil {
+		violations = append(violations, "date_of_birth must be formatted YYYY-MM-DD")
+	} else if dateOfBirth.AddDate(minCustomerAge, 0, 0).After(now) {
+		violations = append(violations, "customer must be at least 18 years old")
+	}
+
+	if !isCountryCode(citizenship) {
+		violations = append(violations, "citizenship must be an ISO 3166-1 alpha-2 country code")
+	}
+	if !isCountryCode(taxResidency) {
+		violations = append(violations, "tax_residency must be an ISO 3166-1 alpha-2 country code")
+	}
+
+	if nationalID != "" && (citizenship == "SE" || taxResidency == "SE") {
+		normalized, birthDate, err := utils.ParsePersonnummer(nationalID, now)
+		if err != nil {
+			violations = append(violations, "national_id must be a valid personnummer")
+		} else {
+			nationalID = normalized
+			if !dateOfBirth.IsZero() && !birthDate.Equal(dateOfBirth) {
+				violations = append(violations, "date_of_birth does not match the personnummer")
+			}
+		}
+	}
+
+	pepDetails := strings.TrimSpace(details.PEPDetails)
+	if details.IsPEP && pepDetails == "" {
+		violations = append(violations, "pep_details are required for politically exposed persons")
+	}
+
+	if len(violations) > 0 {
+		return models.KYCProfile{}, &KYCValidationError{Violations: violations}
+	}
+
+	profile := models.KYCProfile{
+		NationalID:   &nationalID,
+		DateOfBirth:  &dateOfBirth,
+		Citizenship:  &citizenship,
+		TaxResidency: &taxResidency,
+		IsPEP:        details.IsPEP,
+		Status:       models.KYCStatusPending,
+		SubmittedAt:  &now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if details.IsPEP {
+		profile.PEPDetails = &pepDetails
+	}
+	return profile, nil
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"database/sql"
+	"errors"
+	"thyra/internal/onboarding/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrKYCNotApproved = errors.New("account holder has not been approved by compliance")
+
+// CheckAccountKYCApproved fails unless the customer holding the account has an
+// approved KYC profile. Accounts held by non-customers, such as the house
+// account, are not subject to KYC.
+func CheckAccountKYCApproved(q sqlx.Queryer, accountID uuid.UUID) error {
+	var status sql.NullString
+	query := `
+        SELECT k.status
+        FROM thyrasec.accounts a
+        JOIN thyrasec.user_roles r ON r.user_id = a.account_holder_id AND r.role = 'customer'
+        LEFT JOIN thyrasec.kyc_profiles k ON k.user_id = a.account_holder_id
+        WHERE a.id = $1`
+	err := q.QueryRowx(query, accountID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if status.String != models.KYCStatusApproved {
+		return ErrKYCNotApproved
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidPersonnummer = errors.New("invalid personnummer")
+
+// ParsePersonnummer validates a Swedish personal identity number (or
+// samordningsnummer) and returns it as YYYYMMDDNNNC together with the date of
+// birth. Ten digit forms get their century from today, where a "+" separator
+// marks someone aged 100 or more.
+func ParsePersonnummer(value string, today time.Time) (string, time.Time, error) {
+	value = strings.TrimSpace(value)
+	centenarian := strings.Contains(value, "+")
+	digits := strings.NewReplacer("-", "", "+", "", " ", "").Replace(value)
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", time.Time{}, ErrInvalidPersonnummer
+		}
+	}
+
+	var century int
+	switch len(digits) {
+	case 12:
+		century, _ = strconv.Atoi(digits[:2])
+		digits = digits[2:]
+	case 10:
+		yy, _ := strconv.Atoi(digits[:2])
+		century = today.Year() / 100
+		if century*100+yy > today.Year() {
+			century--
+		}
+		if centenarian {
+			century--
+		}
+	default:
+		return "", time.Time{}, ErrInvalidPersonnummer
+	}
+
+	if !luhnValid(digits) {
+		return "", time.Time{}, ErrInvalidPersonnummer
+	}
+
+	year, _ := strconv.Atoi(digits[:2])
+	month, _ := strconv.Atoi(digits[2:4])
+	day, _ := strconv.Atoi(digits[4:6])
+	// Samordningsnummer add 60 to the day of birth.
+	if day > 60 {
+		day -= 60
+	}
+
+	birthDate := time.Date(century*100+year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if birthDate.Month() != time.Month(month) || birthDate.Day() != day || birthDate.After(today) {
+		return "", time.Time{}, ErrInvalidPersonnummer
+	}
+
+	return strconv.Itoa(century) + digits, birthDate, nil
+}
+
+func luhnValid(digits string) bool {
+	sum := 0
+	for i, r := range digits {
+		d := int(r - '0')
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
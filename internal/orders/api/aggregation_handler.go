@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/orders/models"
+	"thyra/internal/orders/services"
+	authutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AggregationHandler struct {
+	service *services.AggregationService
+}
+
+func NewAggregationHandler(service *services.AggregationService) *AggregationHandler {
+	return &AggregationHandler{service: service}
+}
+
+// GetAggregations lists how fractional client orders were netted by the
+// house and the whole-share market orders that resulted. The optional
+// market_status query parameter filters on the market order's status.
+func (h *AggregationHandler) GetAggregations(c *gin.Context) {
+	_, authUserRole, ok := authutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var marketStatus *string
+	if status := c.Query("market_status"); status != "" {
+		marketStatus = &status
+	}
+
+	aggregations, err := h.service.GetAggregations(c.Request.Context(), authUserRole, marketStatus)
+	if err == services.ErrAggregationAccessDenied {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order aggregations", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aggregations)
+}
+
+// UpdateMarketStatus records that an aggregation's market order was sent,
+// filled or cancelled. Cancelling nets the client orders again and returns
+// the replacement aggregation.
+func (h *AggregationHandler) UpdateMarketStatus(c *gin.Context) {
+	_, authUserRole, ok := authutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	aggregationID, err := uuid.Parse(c.Param("aggregationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid aggregation ID"})
+		return
+	}
+	var req models.MarketStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	aggregation, err := h.service.UpdateMarketStatus(c.Request.Context(), authUserRole, aggregationID, req.Status)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, aggregation)
+	case services.ErrAggregationAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAggregationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidMarketStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrMarketStatusTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update market order status", "details": err.Error()})
+	}
+}
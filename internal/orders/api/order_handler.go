@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	accountutils "thyra/internal/accounts/utils"
+	compliancemodels "thyra/internal/compliance/models"
+	onboardingutils "thyra/internal/onboarding/utils"
 	"thyra/internal/orders/models"
 	"thyra/internal/orders/services" // using alias
 	orderutils "thyra/internal/orders/utils"
@@ -17,6 +20,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
 )
 
 type OrderHandler struct {
@@ -35,8 +39,8 @@ func GetAllOrdersHandler(Service *services.OrdersService) gin.HandlerFunc {
 			return
 		}
 
-		if authUserRole != "admin" && authUserRole != "order_manager" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized. Admin or Order Manager access required"})
+		if authUserRole != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized. Admin access required"})
 			return
 		}
 
@@ -63,8 +67,31 @@ func CreateBuyOrderHandler(Service services.OrdersService) gin.HandlerFunc {
 			return
 		}
 
+		userID, _, ok := authutils.GetAuthenticatedUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+		newOrder.RequestedBy, _ = uuid.Parse(userID)
+
 		createdOrder, err := Service.CreateBuyOrder(newOrder)
 		if err != nil {
+			var statusErr *accountutils.AccountStatusError
+			if err == onboardingutils.ErrKYCNotApproved || errors.As(err, &statusErr) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, accountutils.ErrInsufficientBuyingPower) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidQuantity) || errors.Is(err, services.ErrQuantityPrecision) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if writePreTradeError(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order", "details": err.Error()})
 			return
 		}
@@ -81,8 +108,27 @@ func CreateSellOrderHandler(Service services.OrdersService) gin.HandlerFunc {
 			return
 		}
 
+		userID, _, ok := authutils.GetAuthenticatedUser(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+		newOrder.RequestedBy, _ = uuid.Parse(userID)
+
 		createdOrder, err := Service.CreateSellOrder(newOrder)
 		if err != nil {
+			var statusErr *accountutils.AccountStatusError
+			if err == onboardingutils.ErrKYCNotApproved || errors.As(err, &statusErr) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidQuantity) || errors.Is(err, services.ErrQuantityPrecision) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if writePreTradeError(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order", "details": err.Error()})
 			return
 		}
@@ -91,6 +137,23 @@ func CreateSellOrderHandler(Service services.OrdersService) gin.HandlerFunc {
 	}
 }
 
+// writePreTradeError reports whether err came from the pre-trade checks and,
+// if so, has written the rule results. Orders needing acknowledgement can be
+// resubmitted with acknowledge_warnings set.
+func writePreTradeError(c *gin.Context, err error) bool {
+	var preTradeErr *compliancemodels.PreTradeError
+	if !errors.As(err, &preTradeErr) {
+		return false
+	}
+
+	status := http.StatusForbidden
+	if preTradeErr.Outcome == compliancemodels.OutcomeAcknowledge {
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{"error": err.Error(), "outcome": preTradeErr.Outcome, "results": preTradeErr.Results})
+	return true
+}
+
 func ConfirmOrderHandler(Service services.OrdersService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		orderID := c.Param("orderId")
@@ -151,11 +214,11 @@ func ExecuteOrderHandler(Service services.OrdersService) gin.HandlerFunc {
 }
 
 type SettlementRequest struct {
-	SettledQuantity float64    `json:"quantity"`
-	SettledAmount   float64    `json:"amount"`
-	Comment         string     `json:"comment"`
-	TradeDate       *time.Time `json:"tradeDate"`
-	SettlementDate  *time.Time `json:"settlementDate"`
+	SettledQuantity decimal.Decimal `json:"quantity"`
+	SettledAmount   decimal.Decimal `json:"amount"`
+	Comment         string          `json:"comment"`
+	TradeDate       *time.Time      `json:"tradeDate"`
+	SettlementDate  *time.Time      `json:"settlementDate"`
 }
 
 func SettlementBuyHandler(Service services.OrdersService, transactionservice transactionservice.TransactionService) gin.HandlerFunc {
@@ -233,18 +296,23 @@ func SettlementBuyHandler(Service services.OrdersService, transactionservice tra
 		/*transactionRepo := transactionRepository.NewTransactionRepository(sqlxDB)
 		transactionService := transactionServices.NewTransactionService(transactionRepo)*/
 
+		// Transactions record amounts as floats
+		settledAmount := settlementRequest.SettledAmount.InexactFloat64()
+		settledQuantity := settlementRequest.SettledQuantity.InexactFloat64()
+		assetPrice := order.PricePerUnit.InexactFloat64()
+
 		clientCashTransaction := transactionmodels.Transaction{
 
 			Id:                        uuid.New(),
 			Type:                      transactionType,
 			AssetId:                   order.AssetID,
-			CashAmount:                &settlementRequest.SettledAmount,
-			AssetQuantity:             &settlementRequest.SettledQuantity,
+			CashAmount:                &settledAmount,
+			AssetQuantity:             &settledQuantity,
 			CashAccountId:             order.AccountID,
 			AssetAccountId:            order.AccountID,
 			AssetType:                 assetType,
 			TransactionCurrency:       order.Currency,
-			AssetPrice:                &order.PricePerUnit,
+			AssetPrice:                &assetPrice,
 			CreatedById:               userUUID,
 			UpdatedById:               userUUID,
 			CreatedAt:                 time.Now(),
@@ -311,10 +379,11 @@ func SettlementBuyHandler(Service services.OrdersService, transactionservice tra
 			AccountID: order.AccountID,
 			AssetID:   order.AssetID,
 			Quantity:  order.Quantity,
-			// Populate other fields as needed
+			// The settled amount is what the position cost
+			AcquisitionCost: settlementRequest.SettledAmount,
 		}
 
-		err = Service.InsertHolding(holding)
+		err = Service.InsertHolding(holding, *settlementRequest.TradeDate, order.ID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert into holding", "details": err.Error()})
 			return
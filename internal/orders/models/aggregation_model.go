@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MarketSideBuy  = "buy"
+	MarketSideSell = "sell"
+
+	MarketStatusPending   = "pending"
+	MarketStatusSent      = "sent"
+	MarketStatusFilled    = "filled"
+	MarketStatusCancelled = "cancelled"
+)
+
+// OrderAggregation is one netting of an instrument's fractional client
+// orders against the house. The house fills the client orders and, when its
+// leftover position would go negative or reach a whole share, trades the
+// difference on the market in whole shares.
+type OrderAggregation struct {
+	ID                  uuid.UUID       `db:"id" json:"id"`
+	AssetID             uuid.UUID       `db:"asset_id" json:"asset_id"`
+	OrderCount          int             `db:"order_count" json:"order_count"`
+	ClientBuyQuantity   decimal.Decimal `db:"client_buy_quantity" json:"client_buy_quantity"`
+	ClientSellQuantity  decimal.Decimal `db:"client_sell_quantity" json:"client_sell_quantity"`
+	HouseQuantityBefore decimal.Decimal `db:"house_quantity_before" json:"house_quantity_before"`
+	HouseQuantityAfter  decimal.Decimal `db:"house_quantity_after" json:"house_quantity_after"`
+	MarketSide          *string         `db:"market_side" json:"market_side,omitempty"`
+	MarketQuantity      decimal.Decimal `db:"market_quantity" json:"market_quantity"`
+	MarketStatus        *string         `db:"market_status" json:"market_status,omitempty"`
+	CreatedAt           time.Time       `db:"created_at" json:"created_at"`
+}
+
+// PendingFractionalOrder is an executed client order in a fractional
+// instrument not yet netted by the house. Orders are only netted once
+// executed, so nothing the house nets can still be cancelled.
+type PendingFractionalOrder struct {
+	ID            uuid.UUID       `db:"id"`
+	Quantity      decimal.Decimal `db:"quantity"`
+	OrderTypeName string          `db:"order_type_name"`
+}
+
+type MarketStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+type AggregationResult struct {
+	Instruments  int
+	Orders       int
+	MarketOrders int
+}
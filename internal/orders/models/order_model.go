@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type OrderStatusType string
@@ -20,24 +21,31 @@ const (
 
 // Order represents an order in the database.
 type Order struct {
-	ID              uuid.UUID       `db:"id" json:"id"`
-	AccountID       uuid.UUID       `db:"account_id" json:"account_id"`
-	AssetID         uuid.UUID       `db:"asset_id" json:"asset_id"`
-	Currency        uuid.UUID       `adb:"currency" json:"currency"`
-	Quantity        float64         `db:"quantity" json:"quantity"`
-	PricePerUnit    float64         `db:"price_per_unit" json:"price_per_unit,omitempty"` // omitempty will prevent zero value from being serialized
-	TotalAmount     float64         `db:"total_amount" json:"total_amount"`
-	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time       `db:"updated_at" json:"updated_at"`
-	TradeDate       time.Time       `db:"trade_date" json:"trade_date"`
-	SettlementDate  time.Time       `db:"settlement_date" json:"settlement_date"`
-	Comment         *string         `db:"comment" json:"comment"`
-	OwnerID         uuid.UUID       `db:"owner_id" json:"owner_id"`
-	OrderType       uuid.UUID       `db:"order_type" json:"order_type"` // Consider making this an enum if you have a limited set of order types
-	Status          OrderStatusType `db:"status" json:"status"`         // Using the defined OrderStatusType
-	SettledQuantity *float64        `db:"settledquantity"`
-	SettledAmount   *float64        `db:"settledamount"` //nullable
-	OrderNumber     string          `db:"order_number" json:"order_number"`
+	ID              uuid.UUID        `db:"id" json:"id"`
+	AccountID       uuid.UUID        `db:"account_id" json:"account_id"`
+	AssetID         uuid.UUID        `db:"asset_id" json:"asset_id"`
+	Currency        uuid.UUID        `adb:"currency" json:"currency"`
+	Quantity        decimal.Decimal  `db:"quantity" json:"quantity"`
+	PricePerUnit    decimal.Decimal  `db:"price_per_unit" json:"price_per_unit"`
+	TotalAmount     decimal.Decimal  `db:"total_amount" json:"total_amount"`
+	CreatedAt       time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time        `db:"updated_at" json:"updated_at"`
+	TradeDate       time.Time        `db:"trade_date" json:"trade_date"`
+	SettlementDate  time.Time        `db:"settlement_date" json:"settlement_date"`
+	Comment         *string          `db:"comment" json:"comment"`
+	OwnerID         uuid.UUID        `db:"owner_id" json:"owner_id"`
+	OrderType       uuid.UUID        `db:"order_type" json:"order_type"` // Consider making this an enum if you have a limited set of order types
+	Status          OrderStatusType  `db:"status" json:"status"`         // Using the defined OrderStatusType
+	SettledQuantity *decimal.Decimal `db:"settledquantity"`
+	SettledAmount   *decimal.Decimal `db:"settledamount"` //nullable
+	OrderNumber     string           `db:"order_number" json:"order_number"`
+	// AggregationID is the house aggregation that netted this fractional
+	// order into a whole-share market order.
+	AggregationID *uuid.UUID `db:"aggregation_id" json:"aggregation_id,omitempty"`
+	// AcknowledgeWarnings accepts pre-trade compliance warnings; RequestedBy
+	// is the authenticated user placing the order.
+	AcknowledgeWarnings bool      `db:"-" json:"acknowledge_warnings"`
+	RequestedBy         uuid.UUID `db:"-" json:"-"`
 }
 
 type OrderWithDetails struct {
@@ -48,9 +56,9 @@ type OrderWithDetails struct {
 }
 
 type SettlementRequest struct {
-	SettledQuantity float64    `json:"quantity"`
-	SettledAmount   float64    `json:"amount"`
-	Comment         string     `json:"comment"`
-	TradeDate       *time.Time `json:"tradeDate"`
-	SettlementDate  *time.Time `json:"settlementDate"`
+	SettledQuantity decimal.Decimal `json:"quantity"`
+	SettledAmount   decimal.Decimal `json:"amount"`
+	Comment         string          `json:"comment"`
+	TradeDate       *time.Time      `json:"tradeDate"`
+	SettlementDate  *time.Time      `json:"settlementDate"`
 }
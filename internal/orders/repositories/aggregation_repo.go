@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/orders/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+type AggregationRepository struct {
+	db *sqlx.DB
+}
+
+func NewAggregationRepository(db *sqlx.DB) *AggregationRepository {
+	return &AggregationRepository{db: db}
+}
+
+func (r *AggregationRepository) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
+	return r.db.BeginTxx(ctx, nil)
+}
+
+// GetPendingAssets returns the fractional instruments with executed client
+// orders, settled or not, not yet netted by the house.
+func (r *AggregationRepository) GetPendingAssets(ctx context.Context) ([]uuid.UUID, error) {
+	assetIDs := []uuid.UUID{}
+	err := r.db.SelectContext(ctx, &assetIDs, `
+        SELECT DISTINCT o.asset_id
+        FROM thyrasec.orders o
+        JOIN thyrasec.assets a ON a.id = o.asset_id
+        WHERE o.aggregation_id IS NULL
+          AND o.status IN ('executed', 'settled')
+          AND a.quantity_precision > 0`)
+	return assetIDs, err
+}
+
+// LockHousePosition returns the house's leftover position in the asset,
+// locked until the transaction ends.
+func (r *AggregationRepository) LockHousePosition(tx *sqlx.Tx, assetID uuid.UUID) (decimal.Decimal, error) {
+	_, err := tx.Exec(`
+        INSERT INTO thyrasec.house_fractional_positions (asset_id) VALUES ($1)
+        ON CONFLICT (asset_id) DO NOTHING`, assetID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	var quantity decimal.Decimal
+	err = tx.Get(&quantity, `
+        SELECT quantity FROM thyrasec.house_fractional_positions WHERE asset_id = $1 FOR UPDATE`, assetID)
+	return quantity, err
+}
+
+func (r *AggregationRepository) GetPendingOrders(tx *sqlx.Tx, assetID uuid.UUID) ([]models.PendingFractionalOrder, error) {
+	orders := []models.PendingFractionalOrder{}
+	err := tx.Select(&orders, `
+        SELECT o.id, o.quantity, ot.order_type_name
+        FROM thyrasec.orders o
+        JOIN thyrasec.order_types ot ON ot.id::text = o.order_type
+        WHERE o.asset_id = $1
+          AND o.aggregation_id IS NULL
+          AND o.status IN ('executed', 'settled')
+        ORDER BY o.created_at
+        FOR UPDATE OF o`, assetID)
+	return orders, err
+}
+
+func (r *AggregationRepository) InsertAggregation(tx *sqlx.Tx, aggregation models.OrderAggregation) error {
+	_, err := tx.NamedExec(`
+        INSERT INTO thyrasec.order_aggregations (
+            id, asset_id, order_count, client_buy_quantity, client_sell_quantity,
+            house_quantity_before, house_quantity_after, market_side, market_quantity, market_status, created_at
+        )
+        VALUES (
+            :id, :asset_id, :order_count, :client_buy_quantity, :client_sell_quantity,
+            :house_quantity_before, :house_quantity_after, :market_side, :market_quantity, :market_status, :created_at
+        )`, aggregation)
+	return err
+}
+
+func (r *AggregationRepository) MarkOrdersAggregated(tx *sqlx.Tx, aggregationID uuid.UUID, orderIDs []uuid.UUID) error {
+	ids := make(pq.StringArray, 0, len(orderIDs))
+	for _, id := range orderIDs {
+		ids = append(ids, id.String())
+	}
+	_, err := tx.Exec(`
+        UPDATE thyrasec.orders SET aggregation_id = $1, updated_at = NOW()
+        WHERE id = ANY($2::uuid[])`, aggregationID, ids)
+	return err
+}
+
+// ReleaseOrders detaches the client orders from an aggregation so they are
+// netted again.
+func (r *AggregationRepository) ReleaseOrders(tx *sqlx.Tx, aggregationID uuid.UUID) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.orders SET aggregation_id = NULL, updated_at = NOW()
+        WHERE aggregation_id = $1`, aggregationID)
+	return err
+}
+
+func (r *AggregationRepository) UpdateHousePosition(tx *sqlx.Tx, assetID uuid.UUID, quantity decimal.Decimal) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.house_fractional_positions SET quantity = $2, updated_at = now()
+        WHERE asset_id = $1`, assetID, quantity)
+	return err
+}
+
+// GetAggregation returns nil without error when the aggregation does not exist.
+func (r *AggregationRepository) GetAggregation(ctx context.Context, aggregationID uuid.UUID) (*models.OrderAggregation, error) {
+	var aggregation models.OrderAggregation
+	err := r.db.GetContext(ctx, &aggregation, `
+        SELECT * FROM thyrasec.order_aggregations WHERE id = $1`, aggregationID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &aggregation, nil
+}
+
+// LockMarketStatus returns the aggregation's market order status, locked
+// until the transaction ends.
+func (r *AggregationRepository) LockMarketStatus(tx *sqlx.Tx, aggregationID uuid.UUID) (*string, error) {
+	var status *string
+	err := tx.Get(&status, `
+        SELECT market_status FROM thyrasec.order_aggregations WHERE id = $1 FOR UPDATE`, aggregationID)
+	return status, err
+}
+
+func (r *AggregationRepository) UpdateMarketStatus(tx *sqlx.Tx, aggregationID uuid.UUID, status string) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.order_aggregations SET market_status = $2 WHERE id = $1`, aggregationID, status)
+	return err
+}
+
+// GetAggregations returns the most recent aggregations, optionally only
+// those whose market order has the given status.
+func (r *AggregationRepository) GetAggregations(ctx context.Context, marketStatus *string, limit int) ([]models.OrderAggregation, error) {
+	aggregations := []models.OrderAggregation{}
+	err := r.db.SelectContext(ctx, &aggregations, `
+        SELECT * FROM thyrasec.order_aggregations
+        WHERE $1::text IS NULL OR market_status = $1
+        ORDER BY created_at DESC
+        LIMIT $2`, marketStatus, limit)
+	return aggregations, err
+}
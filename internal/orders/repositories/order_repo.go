@@ -24,17 +24,17 @@ func NewOrdersRepository(db *sqlx.DB) *OrdersRepository {
 /* Inserts a asset reservation when selling assets */
 func (r *OrdersRepository) InsertReservation(tx *sqlx.Tx, order models.Order, reservedUntil time.Time) error {
 	reservation := struct {
-		OrderID       uuid.UUID `db:"order_id"`
-		AccountID     uuid.UUID `db:"account_id"`
-		AssetID       uuid.UUID `db:"asset_id"`
-		Quantity      int       `db:"quantity"`
-		ReservedUntil time.Time `db:"reserved_until"`
-		Status        string    `db:"status"`
+		OrderID       uuid.UUID       `db:"order_id"`
+		AccountID     uuid.UUID       `db:"account_id"`
+		AssetID       uuid.UUID       `db:"asset_id"`
+		Quantity      decimal.Decimal `db:"quantity"`
+		ReservedUntil time.Time       `db:"reserved_until"`
+		Status        string          `db:"status"`
 	}{
 		OrderID:       order.ID,
 		AccountID:     order.AccountID,
 		AssetID:       order.AssetID,
-		Quantity:      int(order.Quantity),
+		Quantity:      order.Quantity,
 		ReservedUntil: reservedUntil,
 		Status:        "reserved",
 	}
@@ -70,24 +70,26 @@ func (r *OrdersRepository) InsertReservation(tx *sqlx.Tx, order models.Order, re
 }*/
 
 /*Updates the available_cash and reserved_cash column in accounts table */
+// The buying power check has already locked the row. available_cash goes
+// negative when the order draws on the overdraft limit.
 func (r *OrdersRepository) ReserveCash(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) error {
 	updateQuery := `UPDATE thyrasec.accounts SET 
                     available_cash = available_cash - $1, 
                     reserved_cash = reserved_cash + $1 
-                    WHERE id = $2 AND available_cash >= $1`
+                    WHERE id = $2`
 
 	_, err := tx.Exec(updateQuery, amount, accountID)
 	return err
 }
 
-func (r *OrdersRepository) ReserveAsset(tx *sqlx.Tx, accountID uuid.UUID, amount float64, assetID uuid.UUID) error {
+func (r *OrdersRepository) ReserveAsset(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal, assetID uuid.UUID) error {
 	updateQuery := `
 	UPDATE thyrasec.holdings 
 	SET available_quantity = available_quantity - $1
 	WHERE account_id = $2 AND asset_id = $3 AND available_quantity >= $1
 `
 
-	_, err := tx.Exec(updateQuery, float64(amount), accountID, assetID)
+	_, err := tx.Exec(updateQuery, amount, accountID, assetID)
 	return err
 }
 
@@ -118,31 +120,20 @@ func (r *OrdersRepository) UpdateOrderStatus(tx *sqlx.Tx, orderID string, status
 }
 
 /*Checks whether there is enough holdings to sell an asset */
-func (r *OrdersRepository) CheckHoldings(tx *sqlx.Tx, accountID, assetID uuid.UUID) (int, error) {
-	var currentQuantity int
+func (r *OrdersRepository) CheckHoldings(tx *sqlx.Tx, accountID, assetID uuid.UUID) (decimal.Decimal, error) {
+	var currentQuantity decimal.Decimal
 	query := `SELECT quantity FROM thyrasec.holdings WHERE account_id = $1 AND asset_id = $2`
 	err := tx.Get(&currentQuantity, query, accountID, assetID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// No holdings found for the account and asset
-			return 0, errors.New("no holdings found for the specified account and asset")
+			return decimal.Zero, errors.New("no holdings found for the specified account and asset")
 		}
-		return 0, err
+		return decimal.Zero, err
 	}
 	return currentQuantity, nil
 }
 
-/* Checks whether there is enoguht cash when buying an asset */
-func (r *OrdersRepository) CheckAvailableCash(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) (bool, error) {
-	var availableCash decimal.Decimal
-	query := `SELECT available_cash FROM thyrasec.accounts WHERE id = $1`
-	err := tx.Get(&availableCash, query, accountID)
-	if err != nil {
-		return false, err
-	}
-	return availableCash.GreaterThanOrEqual(amount), nil
-}
-
 func (r *OrdersRepository) ReleaseReservation(tx *sqlx.Tx, orderID string, houseAccount string) error {
 	var accountID uuid.UUID
 	var amount decimal.Decimal
@@ -179,7 +170,7 @@ func (r *OrdersRepository) ReleaseReservation(tx *sqlx.Tx, orderID string, house
 	return nil
 }
 
-func (r *OrdersRepository) UpdateAccountBalance(tx *sqlx.Tx, accountID uuid.UUID, balanceChange float64) error {
+func (r *OrdersRepository) UpdateAccountBalance(tx *sqlx.Tx, accountID uuid.UUID, balanceChange decimal.Decimal) error {
 	// Define the query to update the account
 	query := `UPDATE thyrasec.accounts
               SET account_balance = account_balance - $1,
@@ -204,21 +195,24 @@ func (r *OrdersRepository) InsertHolding(tx *sqlx.Tx, holding positionmodels.Hol
 	}
 
 	if err == nil {
-		// Holding exists, update the quantity
-		newQuantity := existingHolding.Quantity + holding.Quantity
-		newAvailableQuantity := existingHolding.AvailableQuantity + holding.Quantity
-		_, err := tx.Exec("UPDATE thyrasec.holdings SET quantity = $1 AND available_quantity = $2 WHERE id = $3", newQuantity, newAvailableQuantity, existingHolding.ID)
+		// Holding exists, update the quantity and cost
+		newQuantity := existingHolding.Quantity.Add(holding.Quantity)
+		newAvailableQuantity := existingHolding.AvailableQuantity.Add(holding.Quantity)
+		newAcquisitionCost := existingHolding.AcquisitionCost.Add(holding.AcquisitionCost)
+		_, err := tx.Exec("UPDATE thyrasec.holdings SET quantity = $1, available_quantity = $2, acquisition_cost = $3 WHERE id = $4",
+			newQuantity, newAvailableQuantity, newAcquisitionCost, existingHolding.ID)
 		return err
 	}
 
 	// Holding doesn't exist, insert a new row
-	query := `INSERT INTO thyrasec.holdings (account_id, asset_id, quantity)
-		  VALUES (:account_id, :asset_id, :quantity, :available_quantity)`
+	holding.AvailableQuantity = holding.Quantity
+	query := `INSERT INTO thyrasec.holdings (account_id, asset_id, quantity, available_quantity, acquisition_cost)
+		  VALUES (:account_id, :asset_id, :quantity, :available_quantity, :acquisition_cost)`
 	_, err = tx.NamedExec(query, holding)
 	return err
 }
 
-func (r *OrdersRepository) DeductHolding(db *sqlx.Tx, accountID uuid.UUID, assetID uuid.UUID, quantity float64) error {
+func (r *OrdersRepository) DeductHolding(db *sqlx.Tx, accountID uuid.UUID, assetID uuid.UUID, quantity decimal.Decimal) error {
 	// Check if the holding exists for the given account and asset
 	existingHolding := positionmodels.Holding{}
 	err := db.Get(&existingHolding, "SELECT * FROM thyrasec.holdings WHERE account_id = $1 AND asset_id = $2", accountID, assetID)
@@ -228,24 +222,26 @@ func (r *OrdersRepository) DeductHolding(db *sqlx.Tx, accountID uuid.UUID, asset
 	}
 
 	// Check if the quantity to deduct is greater than the existing quantity
-	if quantity > existingHolding.Quantity {
+	if quantity.GreaterThan(existingHolding.Quantity) {
 		return errors.New("insufficient holdings to deduct")
 	}
 
-	// Deduct the quantity
-	newQuantity := existingHolding.Quantity - quantity
-	newAvalaibleQuantity := existingHolding.AvailableQuantity - quantity
+	// Deduct the quantity, and the cost at the holding's average cost
+	newQuantity := existingHolding.Quantity.Sub(quantity)
+	newAvalaibleQuantity := existingHolding.AvailableQuantity.Sub(quantity)
+	newAcquisitionCost := existingHolding.AcquisitionCost.Mul(newQuantity).Div(existingHolding.Quantity).Round(2)
 	// If the new quantity is zero, delete the holding row; otherwise, update the quantity
-	if newQuantity == 0 {
+	if newQuantity.IsZero() {
 		_, err = db.Exec("DELETE FROM thyrasec.holdings WHERE id = $1", existingHolding.ID)
 	} else {
-		_, err = db.Exec("UPDATE thyrasec.holdings SET quantity = $1, available_quantity = $2 WHERE id = $3", newQuantity, newAvalaibleQuantity, existingHolding.ID)
+		_, err = db.Exec("UPDATE thyrasec.holdings SET quantity = $1, available_quantity = $2, acquisition_cost = $3 WHERE id = $4",
+			newQuantity, newAvalaibleQuantity, newAcquisitionCost, existingHolding.ID)
 	}
 
 	return err
 }
 
-func (r *OrdersRepository) UpdateOrder(db *sqlx.Tx, orderID string, settledQuantity float64, settledAmount float64, status string, tradeDate *time.Time, settlementDate *time.Time, comment string) error {
+func (r *OrdersRepository) UpdateOrder(db *sqlx.Tx, orderID string, settledQuantity decimal.Decimal, settledAmount decimal.Decimal, status string, tradeDate *time.Time, settlementDate *time.Time, comment string) error {
 	query := `
         UPDATE thyrasec.orders
         SET settledQuantity = $1, settledAmount = $2, status = $3, trade_date = $4, settlement_date = $5, comment = $6, updated_at = NOW()
@@ -267,6 +263,16 @@ func (r *OrdersRepository) GetAssetType(db *sqlx.Tx, assetId uuid.UUID) (uuid.UU
 	return assetType, nil
 }
 
+// GetQuantityPrecision returns the number of decimals the asset can be
+// traded in.
+func (r *OrdersRepository) GetQuantityPrecision(tx *sqlx.Tx, assetID uuid.UUID) (int32, error) {
+	var precision int32
+	if err := tx.Get(&precision, "SELECT quantity_precision FROM thyrasec.assets WHERE id = $1", assetID); err != nil {
+		return 0, err
+	}
+	return precision, nil
+}
+
 func (r *OrdersRepository) GetOrderTypeByName(tx *sqlx.Tx, name string) (uuid.UUID, error) {
 	var orderType uuid.UUID
 	query := "SELECT id FROM order_types WHERE order_type_name = $1"
@@ -333,3 +339,28 @@ func (r *OrdersRepository) InsertCashReservation(tx *sqlx.Tx, order models.Order
 	_, err := tx.NamedExec(insertReservationQuery, reservationData)
 	return err
 }
+
+// LiquidationPosition is a holding that can be sold when winding down an
+// account, priced at the asset's current price.
+type LiquidationPosition struct {
+	AssetID         uuid.UUID       `db:"asset_id"`
+	Quantity        decimal.Decimal `db:"available_quantity"`
+	CurrentPrice    decimal.Decimal `db:"current_price"`
+	AccountHolderID uuid.UUID       `db:"account_holder_id"`
+}
+
+func (r *OrdersRepository) GetLiquidationPositions(accountID uuid.UUID) ([]LiquidationPosition, error) {
+	query := `
+    SELECT h.asset_id, h.available_quantity, COALESCE(asst.current_price, 0) AS current_price, a.account_holder_id
+    FROM thyrasec.holdings h
+    JOIN thyrasec.assets asst ON h.asset_id = asst.id
+    JOIN thyrasec.accounts a ON h.account_id = a.id
+    WHERE h.account_id = $1 AND h.available_quantity > 0
+    `
+
+	var positions []LiquidationPosition
+	if err := r.db.Select(&positions, query, accountID); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
@@ -1,20 +1,24 @@
 package routes
 
 import (
+	middleware "thyra/internal/common/middleware"
 	handlers "thyra/internal/orders/api" // Import the handlers package
+	userutils "thyra/internal/users/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.RouterGroup, settlementHandler *handlers.SettlementHandler, orderHandler *handlers.OrderHandler) {
+func SetupRoutes(router *gin.RouterGroup, settlementHandler *handlers.SettlementHandler, orderHandler *handlers.OrderHandler, aggregationHandler *handlers.AggregationHandler, clock userutils.Clock) {
     router.GET("/orders", handlers.GetAllOrdersHandler(orderHandler.Service))
-	router.POST("/orders/create/sell", handlers.CreateSellOrderHandler(*orderHandler.Service))
-	router.POST("/orders/create/buy", handlers.CreateBuyOrderHandler(*orderHandler.Service))
+	router.POST("/orders/create/sell", middleware.RequireMFA(middleware.MFAStepUpWindow, clock, middleware.MFAEnrolled), handlers.CreateSellOrderHandler(*orderHandler.Service))
+	router.POST("/orders/create/buy", middleware.RequireMFA(middleware.MFAStepUpWindow, clock, middleware.MFAEnrolled), handlers.CreateBuyOrderHandler(*orderHandler.Service))
 	router.PUT("/orders/:orderId/confirm", handlers.ConfirmOrderHandler(*orderHandler.Service))
 	router.PUT("/orders/:orderId/execute", handlers.ExecuteOrderHandler(*orderHandler.Service))
 	router.PUT("/orders/:orderId/settle/buy", settlementHandler.SettlementBuyHandler(settlementHandler.SetlementService))
 	router.PUT("/orders/:orderId/settle/sell", handlers.SettlementSellHandler))
 	router.GET("/orders/type/name", handlers.GetOrderTypeByName)
 	router.GET("/orders/type/id", handlers.GetOrderTypeByID)
+	router.GET("/orders/aggregations", aggregationHandler.GetAggregations)
+	router.PUT("/orders/aggregations/:aggregationId/market-status", aggregationHandler.UpdateMarketStatus)
 
 }
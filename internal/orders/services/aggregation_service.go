@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"thyra/internal/orders/models"
+	"thyra/internal/orders/repositories"
+	"thyra/internal/orders/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+const maxAggregationsListed = 500
+
+var (
+	ErrAggregationAccessDenied = errors.New("only admins can view or update order aggregations")
+	ErrAggregationNotFound     = errors.New("order aggregation not found")
+	ErrInvalidMarketStatus     = errors.New("market status must be sent, filled or cancelled")
+	ErrMarketStatusTransition  = errors.New("market order cannot move to that status")
+)
+
+// marketTransitions lists the statuses a market order can move to. A pending
+// order is sent to the market and then filled; it can be cancelled until it
+// is filled.
+var marketTransitions = map[string][]string{
+	models.MarketStatusPending: {models.MarketStatusSent, models.MarketStatusCancelled},
+	models.MarketStatusSent:    {models.MarketStatusFilled, models.MarketStatusCancelled},
+}
+
+// AggregationService nets client orders in fractional instruments against
+// the house, so only whole shares are traded on the market.
+type AggregationService struct {
+	repo *repositories.AggregationRepository
+}
+
+func NewAggregationService(repo *repositories.AggregationRepository) *AggregationService {
+	return &AggregationService{repo: repo}
+}
+
+// RunAggregation nets every fractional instrument's executed client orders.
+// Each instrument is netted in its own transaction with the house position
+// locked, so an order is only ever netted once.
+func (s *AggregationService) RunAggregation(ctx context.Context) (models.AggregationResult, error) {
+	var result models.AggregationResult
+	assetIDs, err := s.repo.GetPendingAssets(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	failed := 0
+	for _, assetID := range assetIDs {
+		aggregation, err := s.aggregateAsset(ctx, assetID)
+		if err != nil {
+			log.Printf("Failed to aggregate orders for asset %s: %v", assetID, err)
+			failed++
+			continue
+		}
+		if aggregation.OrderCount == 0 {
+			continue
+		}
+		result.Instruments++
+		result.Orders += aggregation.OrderCount
+		if aggregation.MarketSide != nil {
+			result.MarketOrders++
+		}
+	}
+	if failed > 0 {
+		return result, fmt.Errorf("order aggregation failed for %d of %d instruments", failed, len(assetIDs))
+	}
+	return result, nil
+}
+
+func (s *AggregationService) aggregateAsset(ctx context.Context, assetID uuid.UUID) (models.OrderAggregation, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return models.OrderAggregation{}, err
+	}
+	defer tx.Rollback()
+
+	house, err := s.repo.LockHousePosition(tx, assetID)
+	if err != nil {
+		return models.OrderAggregation{}, err
+	}
+	aggregation, err := s.netOrders(tx, assetID, house)
+	if err != nil || aggregation.OrderCount == 0 {
+		return aggregation, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.OrderAggregation{}, err
+	}
+	return aggregation, nil
+}
+
+// netOrders nets the asset's unaggregated executed orders against house, the
+// house position locked by the caller's transaction.
+func (s *AggregationService) netOrders(tx *sqlx.Tx, assetID uuid.UUID, house decimal.Decimal) (models.OrderAggregation, error) {
+	orders, err := s.repo.GetPendingOrders(tx, assetID)
+	if err != nil {
+		return models.OrderAggregation{}, err
+	}
+	if len(orders) == 0 {
+		return models.OrderAggregation{}, nil
+	}
+
+	aggregation := models.OrderAggregation{
+		ID:                  uuid.New(),
+		AssetID:             assetID,
+		OrderCount:          len(orders),
+		ClientBuyQuantity:   decimal.Zero,
+		ClientSellQuantity:  decimal.Zero,
+		HouseQuantityBefore: house,
+		CreatedAt:           time.Now(),
+	}
+	orderIDs := make([]uuid.UUID, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+		switch order.OrderTypeName {
+		case "order_type_buy":
+			aggregation.ClientBuyQuantity = aggregation.ClientBuyQuantity.Add(order.Quantity)
+		case "order_type_sell":
+			aggregation.ClientSellQuantity = aggregation.ClientSellQuantity.Add(order.Quantity)
+		default:
+			return models.OrderAggregation{}, fmt.Errorf("order %s has unknown order type %s", order.ID, order.OrderTypeName)
+		}
+	}
+
+	side, quantity, houseAfter := utils.NetAgainstHouse(house, aggregation.ClientBuyQuantity, aggregation.ClientSellQuantity)
+	aggregation.HouseQuantityAfter = houseAfter
+	aggregation.MarketQuantity = quantity
+	if side != "" {
+		status := models.MarketStatusPending
+		aggregation.MarketSide, aggregation.MarketStatus = &side, &status
+	}
+
+	if err := s.repo.InsertAggregation(tx, aggregation); err != nil {
+		return models.OrderAggregation{}, err
+	}
+	if err := s.repo.MarkOrdersAggregated(tx, aggregation.ID, orderIDs); err != nil {
+		return models.OrderAggregation{}, err
+	}
+	if err := s.repo.UpdateHousePosition(tx, assetID, houseAfter); err != nil {
+		return models.OrderAggregation{}, err
+	}
+	return aggregation, nil
+}
+
+// UpdateMarketStatus records the progress of an aggregation's market order.
+// Cancelling reverses the aggregation: its change to the house position is
+// undone and its client orders are netted again straight away, so the house
+// never carries the quantity the market did not trade. The returned
+// aggregation is the replacement when one was created.
+func (s *AggregationService) UpdateMarketStatus(ctx context.Context, authUserRole string, aggregationID uuid.UUID, status string) (models.OrderAggregation, error) {
+	if authUserRole != "admin" {
+		return models.OrderAggregation{}, ErrAggregationAccessDenied
+	}
+	switch status {
+	case models.MarketStatusSent, models.MarketStatusFilled, models.MarketStatusCancelled:
+	default:
+		return models.OrderAggregation{}, ErrInvalidMarketStatus
+	}
+
+	aggregation, err := s.repo.GetAggregation(ctx, aggregationID)
+	if err != nil {
+		return models.OrderAggregation{}, err
+	}
+	if aggregation == nil {
+		return models.OrderAggregation{}, ErrAggregationNotFound
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return models.OrderAggregation{}, err
+	}
+	defer tx.Rollback()
+
+	// The house position is locked first, as when netting
+	house, err := s.repo.LockHousePosition(tx, aggregation.AssetID)
+	if err != nil {
+		return models.OrderAggregation{}, err
+	}
+	current, err := s.repo.LockMarketStatus(tx, aggregationID)
+	if err != nil {
+		return models.OrderAggregation{}, err
+	}
+	if current == nil || !canMoveMarketStatus(*current, status) {
+		return models.OrderAggregation{}, ErrMarketStatusTransition
+	}
+	if err := s.repo.UpdateMarketStatus(tx, aggregationID, status); err != nil {
+		return models.OrderAggregation{}, err
+	}
+	aggregation.MarketStatus = &status
+
+	result := *aggregation
+	if status == models.MarketStatusCancelled {
+		if err := s.repo.ReleaseOrders(tx, aggregationID); err != nil {
+			return models.OrderAggregation{}, err
+		}
+		house = house.Sub(aggregation.HouseQuantityAfter.Sub(aggregation.HouseQuantityBefore))
+		result, err = s.netOrders(tx, aggregation.AssetID, house)
+		if err != nil {
+			return models.OrderAggregation{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.OrderAggregation{}, err
+	}
+	return result, nil
+}
+
+func canMoveMarketStatus(from, to string) bool {
+	for _, next := range marketTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAggregations lists recent aggregations, optionally only those whose
+// market order has the given status.
+func (s *AggregationService) GetAggregations(ctx context.Context, authUserRole string, marketStatus *string) ([]models.OrderAggregation, error) {
+	if authUserRole != "admin" {
+		return nil, ErrAggregationAccessDenied
+	}
+	return s.repo.GetAggregations(ctx, marketStatus, maxAggregationsListed)
+}
@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"log"
 	accountutils "thyra/internal/accounts/utils"
+	compliancemodels "thyra/internal/compliance/models"
+	complianceservices "thyra/internal/compliance/services"
+	onboardingutils "thyra/internal/onboarding/utils"
 	"thyra/internal/orders/models"
 	"thyra/internal/orders/repositories"
 	"thyra/internal/orders/utils"
 	positionsmodel "thyra/internal/positions/models"
+	positionutils "thyra/internal/positions/utils"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,29 +20,47 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var (
+	ErrInvalidQuantity   = errors.New("quantity must be above 0")
+	ErrQuantityPrecision = errors.New("quantity has more decimals than the instrument can be traded in")
+)
+
 type OrdersService struct {
-	db   *sqlx.DB
-	repo *repositories.OrdersRepository
+	db       *sqlx.DB
+	repo     *repositories.OrdersRepository
+	preTrade *complianceservices.PreTradeService
+}
+
+func NewOrdersService(db *sqlx.DB, repo *repositories.OrdersRepository, preTrade *complianceservices.PreTradeService) *OrdersService {
+	return &OrdersService{db: db, repo: repo, preTrade: preTrade}
 }
 
-func NewOrdersService(db *sqlx.DB, repo *repositories.OrdersRepository) *OrdersService {
-	return &OrdersService{db: db, repo: repo}
+// runPreTradeChecks evaluates the compliance rules on the order's transaction.
+// Rejected attempts are recorded before the error is returned.
+func (s *OrdersService) runPreTradeChecks(tx *sqlx.Tx, order compliancemodels.PreTradeOrder, acknowledged bool) (compliancemodels.PreTradeResult, error) {
+	result, err := s.preTrade.Evaluate(tx, order)
+	if err != nil {
+		return result, err
+	}
+
+	if err := s.preTrade.Enforce(result, acknowledged); err != nil {
+		if recordErr := s.preTrade.RecordRejected(order, result); recordErr != nil {
+			log.Printf("Error recording rejected pre-trade checks: %v", recordErr)
+		}
+		return result, err
+	}
+	return result, nil
 }
 
 /* Checks and reserves cash when buying an instrument */
 func (s *OrdersService) CheckAndReserveCash(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) error {
-	// Check if there is sufficient available cash
-	sufficientCash, err := s.repo.CheckAvailableCash(tx, accountID, amount)
-	if err != nil {
+	// Check the account's buying power, locking it until the reservation is made
+	if err := accountutils.CheckCashAvailable(tx, accountID, amount, accountutils.OperationBuy); err != nil {
 		return err
 	}
-	if !sufficientCash {
-		return errors.New("insufficient funds")
-	}
 
 	// Reserve the cash
-	err = s.repo.ReserveCash(tx, accountID, amount)
-	if err != nil {
+	if err := s.repo.ReserveCash(tx, accountID, amount); err != nil {
 		return err
 	}
 
@@ -59,7 +81,7 @@ func (s *OrdersService) CreateReservationAndDeductHoldings(db *sqlx.DB, order mo
 		return err
 	}
 
-	if float64(availableQuantity) < order.Quantity {
+	if availableQuantity.LessThan(order.Quantity) {
 		tx.Rollback()
 		return errors.New("insufficient holdings")
 	}
@@ -109,20 +131,8 @@ func (s *OrdersService) ConfirmOrder(db *sqlx.DB, orderID string) error {
 	}
 
 	// Perform actions specific to confirming the order
-	if orderTypeName == "order_type_buy" {
-		totalAmountDecimal := decimal.NewFromFloat(order.TotalAmount)
-		_, err := s.repo.CheckAvailableCash(tx, order.AccountID, totalAmountDecimal)
-		if err != nil {
-			log.Fatalf("error in checkAvailableCash: %v", err)
-			tx.Rollback()
-			return err
-		}
-
-		/*if !sufficientFunds {
-			tx.Rollback()
-			return errors.New("insufficient funds to execute buy order")
-		} */
-	} else if orderTypeName == "order_type_sell" {
+	// Cash for buys was checked and reserved when the order was created
+	if orderTypeName == "order_type_sell" {
 		// Logic to reserve assets for a sell order
 		availableQuantity, err := s.repo.CheckHoldings(tx, order.AccountID, order.AssetID)
 		if err != nil {
@@ -130,7 +140,7 @@ func (s *OrdersService) ConfirmOrder(db *sqlx.DB, orderID string) error {
 			tx.Rollback()
 			return err
 		}
-		if float64(availableQuantity) < order.Quantity {
+		if availableQuantity.LessThan(order.Quantity) {
 			tx.Rollback()
 			return errors.New("insufficient holdings for sell order")
 		}
@@ -174,25 +184,15 @@ func (s *OrdersService) ExecuteOrder(db *sqlx.DB, orderID string) error {
 		return err
 	}
 
-	if orderTypeName == "order_type_buy" {
-		totalAmountDecimal := decimal.NewFromFloat(order.TotalAmount)
-		_, err := s.repo.CheckAvailableCash(tx, order.AccountID, totalAmountDecimal)
-		if err != nil {
-			tx.Rollback()
-			return err
-		}
-		/*if !sufficientFunds {
-			tx.Rollback()
-			return errors.New("insufficient funds to execute buy order")
-		} */
-	} else if orderTypeName == "order_type_sell" {
+	// Cash for buys was checked and reserved when the order was created
+	if orderTypeName == "order_type_sell" {
 		// Logic to reserve assets for a sell order
 		availableQuantity, err := s.repo.CheckHoldings(tx, order.AccountID, order.AssetID)
 		if err != nil {
 			tx.Rollback()
 			return err
 		}
-		if float64(availableQuantity) < order.Quantity {
+		if availableQuantity.LessThan(order.Quantity) {
 			tx.Rollback()
 			return errors.New("insufficient holdings for sell order")
 		}
@@ -220,6 +220,32 @@ func (s *OrdersService) CreateBuyOrder(newOrder models.Order) (models.Order, err
 		return models.Order{}, err
 	}
 
+	if err := onboardingutils.CheckAccountKYCApproved(tx, newOrder.AccountID); err != nil {
+		tx.Rollback()
+		return models.Order{}, err
+	}
+	if err := accountutils.CheckAccountAllows(tx, newOrder.AccountID, accountutils.OperationBuy); err != nil {
+		tx.Rollback()
+		return models.Order{}, err
+	}
+	if err := s.checkQuantity(tx, newOrder.AssetID, newOrder.Quantity); err != nil {
+		tx.Rollback()
+		return models.Order{}, err
+	}
+
+	preTradeOrder := compliancemodels.PreTradeOrder{
+		AccountID:   newOrder.AccountID,
+		AssetID:     newOrder.AssetID,
+		Side:        compliancemodels.OrderSideBuy,
+		Quantity:    newOrder.Quantity.InexactFloat64(),
+		TotalAmount: newOrder.TotalAmount.InexactFloat64(),
+	}
+	preTradeResult, err := s.runPreTradeChecks(tx, preTradeOrder, newOrder.AcknowledgeWarnings)
+	if err != nil {
+		tx.Rollback()
+		return models.Order{}, err
+	}
+
 	// Set unique identifiers and status for the new order
 	newOrder.ID = uuid.New()
 	newOrder.OrderNumber = utils.GenerateOrderNumber()
@@ -231,9 +257,13 @@ func (s *OrdersService) CreateBuyOrder(newOrder models.Order) (models.Order, err
 		return models.Order{}, err
 	}
 
+	if err := s.preTrade.RecordForOrder(tx, newOrder.ID, preTradeOrder, preTradeResult, newOrder.RequestedBy); err != nil {
+		tx.Rollback()
+		return models.Order{}, err
+	}
+
 	// Example business logic for handling cash reservations
-	totalAmountDecimal := decimal.NewFromFloat(newOrder.TotalAmount)
-	if err := s.CheckAndReserveCash(tx, newOrder.AccountID, totalAmountDecimal); err != nil {
+	if err := s.CheckAndReserveCash(tx, newOrder.AccountID, newOrder.TotalAmount); err != nil {
 		tx.Rollback()
 		return models.Order{}, err
 	}
@@ -246,7 +276,7 @@ func (s *OrdersService) CreateBuyOrder(newOrder models.Order) (models.Order, err
 	}
 	houseAccountUUID := uuid.MustParse(houseAccount)
 
-	if err := s.CheckAndReserveCash(tx, houseAccountUUID, totalAmountDecimal); err != nil {
+	if err := s.CheckAndReserveCash(tx, houseAccountUUID, newOrder.TotalAmount); err != nil {
 		tx.Rollback()
 		return models.Order{}, err
 	}
@@ -267,11 +297,54 @@ func (s *OrdersService) CreateBuyOrder(newOrder models.Order) (models.Order, err
 }
 
 func (s *OrdersService) CreateSellOrder(newOrder models.Order) (models.Order, error) {
+	return s.createSellOrder(newOrder, true)
+}
+
+// createSellOrder places a sell order. Forced sales placed by the system pass
+// customerChecks false: they skip KYC, account restrictions and pre-trade
+// compliance, but the holding must still cover the quantity.
+func (s *OrdersService) createSellOrder(newOrder models.Order, customerChecks bool) (models.Order, error) {
 	tx, err := s.db.Beginx()
 	if err != nil {
 		return models.Order{}, err
 	}
 
+	if customerChecks {
+		if err := onboardingutils.CheckAccountKYCApproved(tx, newOrder.AccountID); err != nil {
+			tx.Rollback()
+			return models.Order{}, err
+		}
+		if err := accountutils.CheckAccountAllows(tx, newOrder.AccountID, accountutils.OperationSell); err != nil {
+			tx.Rollback()
+			return models.Order{}, err
+		}
+	}
+	if err := s.checkQuantity(tx, newOrder.AssetID, newOrder.Quantity); err != nil {
+		// A holding left with more decimals than the instrument now allows
+		// can still be sold in full
+		held, holdErr := s.repo.CheckHoldings(tx, newOrder.AccountID, newOrder.AssetID)
+		if !errors.Is(err, ErrQuantityPrecision) || holdErr != nil || !held.Equal(newOrder.Quantity) {
+			tx.Rollback()
+			return models.Order{}, err
+		}
+	}
+
+	preTradeOrder := compliancemodels.PreTradeOrder{
+		AccountID:   newOrder.AccountID,
+		AssetID:     newOrder.AssetID,
+		Side:        compliancemodels.OrderSideSell,
+		Quantity:    newOrder.Quantity.InexactFloat64(),
+		TotalAmount: newOrder.TotalAmount.InexactFloat64(),
+	}
+	var preTradeResult compliancemodels.PreTradeResult
+	if customerChecks {
+		preTradeResult, err = s.runPreTradeChecks(tx, preTradeOrder, newOrder.AcknowledgeWarnings)
+		if err != nil {
+			tx.Rollback()
+			return models.Order{}, err
+		}
+	}
+
 	newOrder.ID = uuid.New()
 	newOrder.Status = models.StatusCreated
 	newOrder.OrderNumber = utils.GenerateOrderNumber()
@@ -288,6 +361,13 @@ func (s *OrdersService) CreateSellOrder(newOrder models.Order) (models.Order, er
 		return models.Order{}, err
 	}
 
+	if customerChecks {
+		if err := s.preTrade.RecordForOrder(tx, newOrder.ID, preTradeOrder, preTradeResult, newOrder.RequestedBy); err != nil {
+			tx.Rollback()
+			return models.Order{}, err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return models.Order{}, err
 	}
@@ -296,6 +376,92 @@ func (s *OrdersService) CreateSellOrder(newOrder models.Order) (models.Order, er
 
 }
 
+// LiquidateAccount places sell orders at the current price for everything the
+// account has available, used when the account is being closed. Orders
+// already placed are returned even if a later one fails.
+func (s *OrdersService) LiquidateAccount(accountID, requestedBy uuid.UUID) ([]uuid.UUID, error) {
+	positions, err := s.repo.GetLiquidationPositions(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	sellOrderType, err := s.sellOrderType()
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDs := []uuid.UUID{}
+	for _, position := range positions {
+		orderID, err := s.placeLiquidationOrder(accountID, position, position.Quantity, sellOrderType, requestedBy, "Liquidation on account closure")
+		if err != nil {
+			return orderIDs, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	return orderIDs, nil
+}
+
+// LiquidatePosition sells up to quantity of one asset at the current price,
+// capped at what is available, for forced sales such as unmet margin calls.
+func (s *OrdersService) LiquidatePosition(accountID, assetID uuid.UUID, quantity decimal.Decimal, requestedBy uuid.UUID, reason string) (uuid.UUID, error) {
+	positions, err := s.repo.GetLiquidationPositions(accountID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	for _, position := range positions {
+		if position.AssetID != assetID {
+			continue
+		}
+		sellOrderType, err := s.sellOrderType()
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if quantity.GreaterThan(position.Quantity) {
+			quantity = position.Quantity
+		}
+		return s.placeLiquidationOrder(accountID, position, quantity, sellOrderType, requestedBy, reason)
+	}
+
+	return uuid.Nil, fmt.Errorf("account %s has no available holding of asset %s", accountID, assetID)
+}
+
+func (s *OrdersService) sellOrderType() (uuid.UUID, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	return s.repo.GetOrderTypeByName(tx, "order_type_sell")
+}
+
+func (s *OrdersService) placeLiquidationOrder(accountID uuid.UUID, position repositories.LiquidationPosition, quantity decimal.Decimal, sellOrderType, requestedBy uuid.UUID, comment string) (uuid.UUID, error) {
+	if !position.CurrentPrice.IsPositive() {
+		return uuid.Nil, fmt.Errorf("asset %s has no current price to liquidate at", position.AssetID)
+	}
+
+	tradeDate := time.Now()
+	order, err := s.createSellOrder(models.Order{
+		AccountID:      accountID,
+		AssetID:        position.AssetID,
+		Quantity:       quantity,
+		PricePerUnit:   position.CurrentPrice,
+		TotalAmount:    quantity.Mul(position.CurrentPrice).Round(2),
+		TradeDate:      tradeDate,
+		SettlementDate: tradeDate.AddDate(0, 0, 2),
+		Comment:        &comment,
+		OwnerID:        position.AccountHolderID,
+		OrderType:      sellOrderType,
+		RequestedBy:    requestedBy,
+	}, false)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("liquidating asset %s: %w", position.AssetID, err)
+	}
+	return order.ID, nil
+}
+
 func (s *OrdersService) GetOrder(orderID string) (models.Order, error) {
 	tx, err := s.db.Beginx()
 	if err != nil {
@@ -353,7 +519,7 @@ func (s *OrdersService) GetTransactionTypeByOrderTypeID(orderTypeID uuid.UUID) (
 	return transactionType, err
 }
 
-func (s *OrdersService) UpdateOrder(orderID string, settledQuantity float64, settledAmount float64, status string, tradeDate *time.Time, settlementDate *time.Time, comment string) error {
+func (s *OrdersService) UpdateOrder(orderID string, settledQuantity decimal.Decimal, settledAmount decimal.Decimal, status string, tradeDate *time.Time, settlementDate *time.Time, comment string) error {
 	tx, err := s.db.Beginx()
 	if err != nil {
 		return err
@@ -409,7 +575,7 @@ func (s *OrdersService) ReleaseReservation(orderID string, houseAccount string)
 	return nil
 }
 
-func (s *OrdersService) UpdateAccountBalance(accountID uuid.UUID, balanceChange float64) error {
+func (s *OrdersService) UpdateAccountBalance(accountID uuid.UUID, balanceChange decimal.Decimal) error {
 
 	tx, err := s.db.Beginx()
 	if err != nil {
@@ -437,7 +603,9 @@ func (s *OrdersService) UpdateAccountBalance(accountID uuid.UUID, balanceChange
 	return nil
 }
 
-func (s *OrdersService) InsertHolding(holding positionsmodel.Holding) error {
+// InsertHolding adds a settled buy to the holding and opens a tax lot for it
+// acquired on the trade date.
+func (s *OrdersService) InsertHolding(holding positionsmodel.Holding, acquiredAt time.Time, orderID uuid.UUID) error {
 
 	tx, err := s.db.Beginx()
 	if err != nil {
@@ -458,6 +626,20 @@ func (s *OrdersService) InsertHolding(holding positionsmodel.Holding) error {
 		return err
 	}
 
+	lot := positionsmodel.TaxLot{
+		AccountID:  holding.AccountID,
+		AssetID:    holding.AssetID,
+		AcquiredAt: &acquiredAt,
+		Quantity:   holding.Quantity,
+		Cost:       holding.AcquisitionCost,
+		Source:     positionsmodel.TaxLotSourceBuy,
+		SourceID:   &orderID,
+		CostKnown:  true,
+	}
+	if err = positionutils.OpenLot(tx, lot); err != nil {
+		return err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return err
 	}
@@ -465,6 +647,22 @@ func (s *OrdersService) InsertHolding(holding positionsmodel.Holding) error {
 	return nil
 }
 
+// checkQuantity rejects quantities the instrument cannot be traded in.
+// Instruments with a quantity precision of 0 trade in whole shares only.
+func (s *OrdersService) checkQuantity(tx *sqlx.Tx, assetID uuid.UUID, quantity decimal.Decimal) error {
+	if !quantity.IsPositive() {
+		return ErrInvalidQuantity
+	}
+	precision, err := s.repo.GetQuantityPrecision(tx, assetID)
+	if err != nil {
+		return err
+	}
+	if !quantity.Equal(quantity.Truncate(precision)) {
+		return fmt.Errorf("%w: at most %d decimals", ErrQuantityPrecision, precision)
+	}
+	return nil
+}
+
 func (s *OrdersService) GetOrderTypeByName(name string) (uuid.UUID, error) {
 
 	tx, err := s.db.Beginx()
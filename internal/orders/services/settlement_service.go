@@ -6,6 +6,7 @@ import (
 	"thyra/internal/orders/repositories"
 	orderrepo "thyra/internal/orders/repositories"
 	orderutils "thyra/internal/orders/utils"
+	positionutils "thyra/internal/positions/utils"
 	"time"
 
 	accountutils "thyra/internal/accounts/utils"
@@ -21,10 +22,12 @@ import (
 type SettlementService struct {
 	db   *sqlx.DB
 	repo *repositories.OrdersRepository
+	// lotMethod decides which tax lots a sale consumes.
+	lotMethod string
 }
 
-func NewSettlementService(db *sqlx.DB, repo *repositories.OrdersRepository) *OrdersService {
-	return &OrdersService{db: db, repo: repo}
+func NewSettlementService(db *sqlx.DB, repo *repositories.OrdersRepository, lotMethod string) *SettlementService {
+	return &SettlementService{db: db, repo: repo, lotMethod: lotMethod}
 }
 
 func (s *SettlementService) SellOrder(c *gin.Context, orderID, userIDStr string, settlementRequest ordermodels.SettlementRequest) error {
@@ -68,21 +71,25 @@ func (s *SettlementService) SellOrder(c *gin.Context, orderID, userIDStr string,
 	}
 
 	orderNumber := orderutils.GenerateOrderNumber()
+	// Transactions record amounts as floats
+	settledAmount := settlementRequest.SettledAmount.InexactFloat64()
+	settledQuantity := settlementRequest.SettledQuantity.InexactFloat64()
+	assetPrice := order.PricePerUnit.InexactFloat64()
 	transactionRepo := transactionrepo.NewTransactionRepository(tx)
-	transactionService := transactionservice.NewTransactionService(transactionRepo)
+	transactionService := transactionservice.NewTransactionService(transactionRepo, nil)
 
 	clientCashTransaction := transactionmodels.Transaction{
 
 		Id:                        uuid.New(),
 		Type:                      transactionType,
 		AssetId:                   order.AssetID,
-		CashAmount:                &settlementRequest.SettledAmount,
-		AssetQuantity:             &settlementRequest.SettledQuantity,
+		CashAmount:                &settledAmount,
+		AssetQuantity:             &settledQuantity,
 		CashAccountId:             order.AccountID,
 		AssetAccountId:            order.AccountID,
 		AssetType:                 assetType,
 		TransactionCurrency:       order.Currency,
-		AssetPrice:                &order.PricePerUnit,
+		AssetPrice:                &assetPrice,
 		CreatedById:               userID,
 		UpdatedById:               userID,
 		CreatedAt:                 time.Now(),
@@ -115,7 +122,19 @@ func (s *SettlementService) SellOrder(c *gin.Context, orderID, userIDStr string,
 		return err
 	}
 
-	err = s.repo.DeductHolding(tx, order.AccountID, order.AssetID, *order.SettledQuantity)
+	err = s.repo.DeductHolding(tx, order.AccountID, order.AssetID, settlementRequest.SettledQuantity)
+	if err != nil {
+		return err
+	}
+
+	// Book the realized gain against the lots the sale consumes
+	_, err = positionutils.RealizeSale(tx, order.AccountID, order.AssetID, &order.ID,
+		settlementRequest.SettledQuantity, settlementRequest.SettledAmount,
+		*settlementRequest.TradeDate, s.lotMethod)
+	if err != nil {
+		return err
+	}
+	err = positionutils.SyncHoldingCost(tx, order.AccountID, order.AssetID)
 	if err != nil {
 		return err
 	}
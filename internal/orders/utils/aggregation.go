@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"thyra/internal/orders/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// NetAgainstHouse fills the client buys and sells from the house's leftover
+// position in the instrument. If that would leave the house short, it buys
+// the shortfall rounded up to whole shares; if it would leave the house with
+// a whole share or more, it sells the whole shares. Either way the house ends
+// with less than one share. side is empty when no market order is needed.
+func NetAgainstHouse(house, clientBuys, clientSells decimal.Decimal) (side string, quantity, houseAfter decimal.Decimal) {
+	houseAfter = house.Sub(clientBuys).Add(clientSells)
+	switch {
+	case houseAfter.IsNegative():
+		quantity = houseAfter.Neg().Ceil()
+		return models.MarketSideBuy, quantity, houseAfter.Add(quantity)
+	case houseAfter.GreaterThanOrEqual(decimal.NewFromInt(1)):
+		quantity = houseAfter.Floor()
+		return models.MarketSideSell, quantity, houseAfter.Sub(quantity)
+	}
+	return "", decimal.Zero, houseAfter
+}
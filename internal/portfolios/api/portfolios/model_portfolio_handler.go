@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"thyra/internal/portfolios/models"
+	"thyra/internal/portfolios/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ModelPortfolioHandler struct {
+	service *services.ModelPortfolioService
+}
+
+func NewModelPortfolioHandler(service *services.ModelPortfolioService) *ModelPortfolioHandler {
+	return &ModelPortfolioHandler{service: service}
+}
+
+func (h *ModelPortfolioHandler) CreateModelPortfolio(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	var req models.ModelPortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	model, err := h.service.CreateModelPortfolio(c.Request.Context(), authUserID, authUserRole, req)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to create model portfolio")
+		return
+	}
+
+	c.JSON(http.StatusCreated, model)
+}
+
+func (h *ModelPortfolioHandler) UpdateModelPortfolio(c *gin.Context) {
+	_, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	modelID, ok := uuidParam(c, "modelId", "Invalid model portfolio ID in URL")
+	if !ok {
+		return
+	}
+	var req models.ModelPortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	model, err := h.service.UpdateModelPortfolio(c.Request.Context(), modelID, authUserRole, req)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to update model portfolio")
+		return
+	}
+
+	c.JSON(http.StatusOK, model)
+}
+
+func (h *ModelPortfolioHandler) GetModelPortfolios(c *gin.Context) {
+	_, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+
+	portfolios, err := h.service.GetModelPortfolios(c.Request.Context(), authUserRole)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to fetch model portfolios")
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolios)
+}
+
+func (h *ModelPortfolioHandler) GetModelPortfolio(c *gin.Context) {
+	_, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	modelID, ok := uuidParam(c, "modelId", "Invalid model portfolio ID in URL")
+	if !ok {
+		return
+	}
+
+	model, err := h.service.GetModelPortfolio(c.Request.Context(), modelID, authUserRole)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to fetch model portfolio")
+		return
+	}
+
+	c.JSON(http.StatusOK, model)
+}
+
+func (h *ModelPortfolioHandler) GetAssignment(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := uuidParam(c, "accountId", "Invalid account ID format")
+	if !ok {
+		return
+	}
+
+	assignment, err := h.service.GetAssignment(c.Request.Context(), accountID, authUserID, authUserRole)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to fetch model portfolio assignment")
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// AssignModelPortfolio puts the account on a model portfolio, replacing the
+// one it followed before.
+func (h *ModelPortfolioHandler) AssignModelPortfolio(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := uuidParam(c, "accountId", "Invalid account ID format")
+	if !ok {
+		return
+	}
+	var req models.AssignModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	assignment, err := h.service.AssignModelPortfolio(c.Request.Context(), accountID, authUserID, authUserRole, req)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to assign model portfolio")
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+func (h *ModelPortfolioHandler) UnassignModelPortfolio(c *gin.Context) {
+	_, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := uuidParam(c, "accountId", "Invalid account ID format")
+	if !ok {
+		return
+	}
+
+	if err := h.service.UnassignModelPortfolio(c.Request.Context(), accountID, authUserRole); err != nil {
+		writePortfolioError(c, err, "Failed to remove model portfolio assignment")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func portfolioUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+	return authUserID, userRole, true
+}
+
+func uuidParam(c *gin.Context, name, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writePortfolioError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrMissingPrice) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrPortfolioAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrModelPortfolioNotFound, services.ErrAccountNotFound, services.ErrNoModelPortfolioAssigned,
+		services.ErrProposalNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrModelNameRequired, services.ErrInvalidTargets, services.ErrInvalidDriftBand,
+		services.ErrInvalidCashBuffer, services.ErrInvalidMinTradeAmount:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrModelNameTaken, services.ErrNoRebalanceNeeded, services.ErrProposalNotPending, services.ErrProposalExpired:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrNothingToRebalance:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
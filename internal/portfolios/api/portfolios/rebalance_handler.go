@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/portfolios/models"
+	"thyra/internal/portfolios/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RebalanceHandler struct {
+	service *services.RebalanceService
+}
+
+func NewRebalanceHandler(service *services.RebalanceService) *RebalanceHandler {
+	return &RebalanceHandler{service: service}
+}
+
+// ProposeRebalance works out the trades that bring the account back to its
+// model portfolio. Nothing is traded until the proposal is approved.
+func (h *RebalanceHandler) ProposeRebalance(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := uuidParam(c, "accountId", "Invalid account ID format")
+	if !ok {
+		return
+	}
+
+	proposal, err := h.service.ProposeRebalance(c.Request.Context(), accountID, authUserID, authUserRole)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to create rebalance proposal")
+		return
+	}
+
+	c.JSON(http.StatusCreated, proposal)
+}
+
+func (h *RebalanceHandler) GetAccountProposals(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	accountID, ok := uuidParam(c, "accountId", "Invalid account ID format")
+	if !ok {
+		return
+	}
+
+	proposals, err := h.service.GetAccountProposals(c.Request.Context(), accountID, authUserID, authUserRole)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to fetch rebalance proposals")
+		return
+	}
+
+	c.JSON(http.StatusOK, proposals)
+}
+
+func (h *RebalanceHandler) GetProposal(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	proposalID, ok := uuidParam(c, "proposalId", "Invalid proposal ID in URL")
+	if !ok {
+		return
+	}
+
+	proposal, err := h.service.GetProposal(c.Request.Context(), proposalID, authUserID, authUserRole)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to fetch rebalance proposal")
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
+
+// ApproveProposal places the proposal's orders. The response lists the
+// order placed for each trade or why it was refused.
+func (h *RebalanceHandler) ApproveProposal(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	proposalID, ok := uuidParam(c, "proposalId", "Invalid proposal ID in URL")
+	if !ok {
+		return
+	}
+	var req models.ApproveProposalRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	proposal, err := h.service.ApproveProposal(c.Request.Context(), proposalID, authUserID, authUserRole, req)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to approve rebalance proposal")
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
+
+func (h *RebalanceHandler) RejectProposal(c *gin.Context) {
+	authUserID, authUserRole, ok := portfolioUser(c)
+	if !ok {
+		return
+	}
+	proposalID, ok := uuidParam(c, "proposalId", "Invalid proposal ID in URL")
+	if !ok {
+		return
+	}
+
+	proposal, err := h.service.RejectProposal(c.Request.Context(), proposalID, authUserID, authUserRole)
+	if err != nil {
+		writePortfolioError(c, err, "Failed to reject rebalance proposal")
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	TradeSideBuy  = "buy"
+	TradeSideSell = "sell"
+
+	ProposalStatusPending            = "pending"
+	ProposalStatusApproved           = "approved"
+	ProposalStatusRejected           = "rejected"
+	ProposalStatusExpired            = "expired"
+	ProposalStatusSubmitted          = "submitted"
+	ProposalStatusPartiallySubmitted = "partially_submitted"
+	ProposalStatusFailed             = "failed"
+)
+
+// ModelPortfolio is a target allocation. Target weights are shares of the
+// invested part of an account; CashBuffer is the share of its value kept
+// in cash. Bands and weights are fractions of the account's total value.
+type ModelPortfolio struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	Name           string          `db:"name" json:"name"`
+	Description    *string         `db:"description" json:"description,omitempty"`
+	DriftBand      decimal.Decimal `db:"drift_band" json:"drift_band"`
+	CashBuffer     decimal.Decimal `db:"cash_buffer" json:"cash_buffer"`
+	MinTradeAmount decimal.Decimal `db:"min_trade_amount" json:"min_trade_amount"`
+	Targets        []ModelTarget   `db:"-" json:"targets"`
+	CreatedBy      uuid.UUID       `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// ModelTarget is one instrument's target weight. DriftBand overrides the
+// portfolio's band when set.
+type ModelTarget struct {
+	ModelPortfolioID uuid.UUID        `db:"model_portfolio_id" json:"-"`
+	AssetID          uuid.UUID        `db:"asset_id" json:"asset_id" binding:"required"`
+	TargetWeight     decimal.Decimal  `db:"target_weight" json:"target_weight"`
+	DriftBand        *decimal.Decimal `db:"drift_band" json:"drift_band,omitempty"`
+}
+
+type ModelPortfolioRequest struct {
+	Name           string           `json:"name" binding:"required"`
+	Description    *string          `json:"description"`
+	DriftBand      *decimal.Decimal `json:"drift_band"`
+	CashBuffer     decimal.Decimal  `json:"cash_buffer"`
+	MinTradeAmount decimal.Decimal  `json:"min_trade_amount"`
+	Targets        []ModelTarget    `json:"targets" binding:"required"`
+}
+
+type AccountModelPortfolio struct {
+	AccountID        uuid.UUID `db:"account_id" json:"account_id"`
+	ModelPortfolioID uuid.UUID `db:"model_portfolio_id" json:"model_portfolio_id"`
+	AssignedBy       uuid.UUID `db:"assigned_by" json:"assigned_by"`
+	AssignedAt       time.Time `db:"assigned_at" json:"assigned_at"`
+}
+
+type AssignModelRequest struct {
+	ModelPortfolioID uuid.UUID `json:"model_portfolio_id" binding:"required"`
+}
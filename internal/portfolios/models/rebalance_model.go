@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type RebalanceAccount struct {
+	ID              uuid.UUID       `db:"id"`
+	AccountHolderID uuid.UUID       `db:"account_holder_id"`
+	AvailableCash   decimal.Decimal `db:"available_cash"`
+}
+
+// RebalancePosition is a holding's quantity not held for open orders, at the
+// price orders are placed at.
+type RebalancePosition struct {
+	AssetID  uuid.UUID       `db:"asset_id"`
+	Quantity decimal.Decimal `db:"available_quantity"`
+	Price    decimal.Decimal `db:"price"`
+}
+
+type RebalanceProposal struct {
+	ID               uuid.UUID        `db:"id" json:"id"`
+	AccountID        uuid.UUID        `db:"account_id" json:"account_id"`
+	ModelPortfolioID uuid.UUID        `db:"model_portfolio_id" json:"model_portfolio_id"`
+	Status           string           `db:"status" json:"status"`
+	TotalValue       decimal.Decimal  `db:"total_value" json:"total_value"`
+	CashValue        decimal.Decimal  `db:"cash_value" json:"cash_value"`
+	CreatedBy        uuid.UUID        `db:"created_by" json:"created_by"`
+	CreatedAt        time.Time        `db:"created_at" json:"created_at"`
+	DecidedBy        *uuid.UUID       `db:"decided_by" json:"decided_by,omitempty"`
+	DecidedAt        *time.Time       `db:"decided_at" json:"decided_at,omitempty"`
+	Trades           []RebalanceTrade `db:"-" json:"trades"`
+}
+
+// RebalanceTrade is a proposed order. Weights are shares of the account's
+// total value when the proposal was made.
+type RebalanceTrade struct {
+	ID            uuid.UUID       `db:"id" json:"id"`
+	ProposalID    uuid.UUID       `db:"proposal_id" json:"-"`
+	AssetID       uuid.UUID       `db:"asset_id" json:"asset_id"`
+	Side          string          `db:"side" json:"side"`
+	Quantity      decimal.Decimal `db:"quantity" json:"quantity"`
+	Price         decimal.Decimal `db:"price" json:"price"`
+	Amount        decimal.Decimal `db:"amount" json:"amount"`
+	CurrentWeight decimal.Decimal `db:"current_weight" json:"current_weight"`
+	TargetWeight  decimal.Decimal `db:"target_weight" json:"target_weight"`
+	OrderID       *uuid.UUID      `db:"order_id" json:"order_id,omitempty"`
+	Error         *string         `db:"error" json:"error,omitempty"`
+}
+
+type ApproveProposalRequest struct {
+	AcknowledgeWarnings bool `json:"acknowledge_warnings"`
+}
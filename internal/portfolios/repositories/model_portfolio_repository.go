@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/portfolios/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type ModelPortfolioRepository struct {
+	db *sqlx.DB
+}
+
+func NewModelPortfolioRepository(db *sqlx.DB) *ModelPortfolioRepository {
+	return &ModelPortfolioRepository{db: db}
+}
+
+func (r *ModelPortfolioRepository) CountAssets(ctx context.Context, assetIDs []uuid.UUID) (int, error) {
+	ids := make(pq.StringArray, 0, len(assetIDs))
+	for _, id := range assetIDs {
+		ids = append(ids, id.String())
+	}
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM thyrasec.assets WHERE id = ANY($1::uuid[])`, ids)
+	return count, err
+}
+
+func (r *ModelPortfolioRepository) NameTaken(ctx context.Context, name string, exceptID uuid.UUID) (bool, error) {
+	var taken bool
+	err := r.db.GetContext(ctx, &taken, `
+        SELECT EXISTS (SELECT 1 FROM thyrasec.model_portfolios WHERE lower(name) = lower($1) AND id <> $2)`,
+		name, exceptID)
+	return taken, err
+}
+
+// SaveModelPortfolio inserts or updates the model portfolio and replaces its targets.
+func (r *ModelPortfolioRepository) SaveModelPortfolio(ctx context.Context, model models.ModelPortfolio) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.model_portfolios (
+            id, name, description, drift_band, cash_buffer, min_trade_amount, created_by, created_at, updated_at
+        )
+        VALUES (:id, :name, :description, :drift_band, :cash_buffer, :min_trade_amount, :created_by, :created_at, :updated_at)
+        ON CONFLICT (id) DO UPDATE
+        SET name = EXCLUDED.name,
+            description = EXCLUDED.description,
+            drift_band = EXCLUDED.drift_band,
+            cash_buffer = EXCLUDED.cash_buffer,
+            min_trade_amount = EXCLUDED.min_trade_amount,
+            updated_at = EXCLUDED.updated_at`, model)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM thyrasec.model_portfolio_targets WHERE model_portfolio_id = $1`, model.ID); err != nil {
+		return err
+	}
+	for _, target := range model.Targets {
+		target.ModelPortfolioID = model.ID
+		_, err := tx.NamedExecContext(ctx, `
+            INSERT INTO thyrasec.model_portfolio_targets (model_portfolio_id, asset_id, target_weight, drift_band)
+            VALUES (:model_portfolio_id, :asset_id, :target_weight, :drift_band)`, target)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ModelPortfolioRepository) GetModelPortfolios(ctx context.Context) ([]models.ModelPortfolio, error) {
+	portfolios := []models.ModelPortfolio{}
+	if err := r.db.SelectContext(ctx, &portfolios, `SELECT * FROM thyrasec.model_portfolios ORDER BY name`); err != nil {
+		return nil, err
+	}
+	for i := range portfolios {
+		targets, err := r.getTargets(ctx, portfolios[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		portfolios[i].Targets = targets
+	}
+	return portfolios, nil
+}
+
+func (r *ModelPortfolioRepository) GetModelPortfolio(ctx context.Context, modelID uuid.UUID) (models.ModelPortfolio, error) {
+	var model models.ModelPortfolio
+	if err := r.db.GetContext(ctx, &model, `SELECT * FROM thyrasec.model_portfolios WHERE id = $1`, modelID); err != nil {
+		return model, err
+	}
+	targets, err := r.getTargets(ctx, modelID)
+	model.Targets = targets
+	return model, err
+}
+
+func (r *ModelPortfolioRepository) getTargets(ctx context.Context, modelID uuid.UUID) ([]models.ModelTarget, error) {
+	targets := []models.ModelTarget{}
+	err := r.db.SelectContext(ctx, &targets, `
+        SELECT * FROM thyrasec.model_portfolio_targets
+        WHERE model_portfolio_id = $1
+        ORDER BY target_weight DESC, asset_id`, modelID)
+	return targets, err
+}
+
+func (r *ModelPortfolioRepository) GetAccountHolderID(ctx context.Context, accountID uuid.UUID) (uuid.UUID, error) {
+	var holderID uuid.UUID
+	err := r.db.GetContext(ctx, &holderID, `SELECT account_holder_id FROM thyrasec.accounts WHERE id = $1`, accountID)
+	return holderID, err
+}
+
+func (r *ModelPortfolioRepository) GetAssignment(ctx context.Context, accountID uuid.UUID) (models.AccountModelPortfolio, error) {
+	var assignment models.AccountModelPortfolio
+	err := r.db.GetContext(ctx, &assignment, `SELECT * FROM thyrasec.account_model_portfolios WHERE account_id = $1`, accountID)
+	return assignment, err
+}
+
+// AssignModel puts the account on the model portfolio, replacing any earlier one.
+func (r *ModelPortfolioRepository) AssignModel(ctx context.Context, assignment models.AccountModelPortfolio) error {
+	_, err := r.db.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.account_model_portfolios (account_id, model_portfolio_id, assigned_by, assigned_at)
+        VALUES (:account_id, :model_portfolio_id, :assigned_by, :assigned_at)
+        ON CONFLICT (account_id) DO UPDATE
+        SET model_portfolio_id = EXCLUDED.model_portfolio_id,
+            assigned_by = EXCLUDED.assigned_by,
+            assigned_at = EXCLUDED.assigned_at`, assignment)
+	return err
+}
+
+func (r *ModelPortfolioRepository) RemoveAssignment(ctx context.Context, accountID uuid.UUID) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM thyrasec.account_model_portfolios WHERE account_id = $1`, accountID)
+	if err != nil {
+		return false, err
+	}
+	removed, err := result.RowsAffected()
+	return removed > 0, err
+}
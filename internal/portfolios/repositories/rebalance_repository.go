@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/portfolios/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+type RebalanceRepository struct {
+	db *sqlx.DB
+}
+
+func NewRebalanceRepository(db *sqlx.DB) *RebalanceRepository {
+	return &RebalanceRepository{db: db}
+}
+
+func (r *RebalanceRepository) GetAccount(ctx context.Context, accountID uuid.UUID) (models.RebalanceAccount, error) {
+	var account models.RebalanceAccount
+	err := r.db.GetContext(ctx, &account, `
+        SELECT id, account_holder_id, available_cash FROM thyrasec.accounts WHERE id = $1`, accountID)
+	return account, err
+}
+
+// GetPositions returns the account's holdings not held for open orders,
+// priced like the orders placed for them.
+func (r *RebalanceRepository) GetPositions(ctx context.Context, accountID uuid.UUID) ([]models.RebalancePosition, error) {
+	positions := []models.RebalancePosition{}
+	err := r.db.SelectContext(ctx, &positions, `
+        SELECT h.asset_id, h.available_quantity, COALESCE(a.current_price,
+            (SELECT ap.price FROM thyrasec.asset_prices ap
+             WHERE ap.asset_id = h.asset_id
+             ORDER BY ap.price_date DESC LIMIT 1), 0) AS price
+        FROM thyrasec.holdings h
+        JOIN thyrasec.assets a ON a.id = h.asset_id
+        WHERE h.account_id = $1 AND h.available_quantity > 0`, accountID)
+	return positions, err
+}
+
+func (r *RebalanceRepository) GetPrices(ctx context.Context, assetIDs []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	ids := make(pq.StringArray, 0, len(assetIDs))
+	for _, id := range assetIDs {
+		ids = append(ids, id.String())
+	}
+	rows := []struct {
+		AssetID uuid.UUID       `db:"asset_id"`
+		Price   decimal.Decimal `db:"price"`
+	}{}
+	err := r.db.SelectContext(ctx, &rows, `
+        SELECT a.id AS asset_id, COALESCE(a.current_price,
+            (SELECT ap.price FROM thyrasec.asset_prices ap
+             WHERE ap.asset_id = a.id
+             ORDER BY ap.price_date DESC LIMIT 1), 0) AS price
+        FROM thyrasec.assets a
+        WHERE a.id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[uuid.UUID]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		prices[row.AssetID] = row.Price
+	}
+	return prices, nil
+}
+
+// InsertProposal stores the proposal and its trades. Pending proposals
+// already made for the account are expired, as they were worked out from
+// older holdings.
+func (r *RebalanceRepository) InsertProposal(ctx context.Context, proposal models.RebalanceProposal) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE thyrasec.rebalance_proposals SET status = $1
+        WHERE account_id = $2 AND status = $3`,
+		models.ProposalStatusExpired, proposal.AccountID, models.ProposalStatusPending)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.rebalance_proposals (
+            id, account_id, model_portfolio_id, status, total_value, cash_value, created_by, created_at
+        )
+        VALUES (:id, :account_id, :model_portfolio_id, :status, :total_value, :cash_value, :created_by, :created_at)`, proposal)
+	if err != nil {
+		return err
+	}
+	for _, trade := range proposal.Trades {
+		trade.ProposalID = proposal.ID
+		_, err := tx.NamedExecContext(ctx, `
+            INSERT INTO thyrasec.rebalance_trades (
+                id, proposal_id, asset_id, side, quantity, price, amount, current_weight, target_weight
+            )
+            VALUES (:id, :proposal_id, :asset_id, :side, :quantity, :price, :amount, :current_weight, :target_weight)`, trade)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *RebalanceRepository) GetProposal(ctx context.Context, proposalID uuid.UUID) (models.RebalanceProposal, error) {
+	var proposal models.RebalanceProposal
+	if err := r.db.GetContext(ctx, &proposal, `SELECT * FROM thyrasec.rebalance_proposals WHERE id = $1`, proposalID); err != nil {
+		return proposal, err
+	}
+	trades, err := r.getTrades(ctx, proposalID)
+	proposal.Trades = trades
+	return proposal, err
+}
+
+func (r *RebalanceRepository) GetAccountProposals(ctx context.Context, accountID uuid.UUID) ([]models.RebalanceProposal, error) {
+	proposals := []models.RebalanceProposal{}
+	err := r.db.SelectContext(ctx, &proposals, `
+        SELECT * FROM thyrasec.rebalance_proposals
+        WHERE account_id = $1
+        ORDER BY created_at DESC`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range proposals {
+		trades, err := r.getTrades(ctx, proposals[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		proposals[i].Trades = trades
+	}
+	return proposals, nil
+}
+
+func (r *RebalanceRepository) getTrades(ctx context.Context, proposalID uuid.UUID) ([]models.RebalanceTrade, error) {
+	trades := []models.RebalanceTrade{}
+	err := r.db.SelectContext(ctx, &trades, `
+        SELECT * FROM thyrasec.rebalance_trades
+        WHERE proposal_id = $1
+        ORDER BY side DESC, amount DESC`, proposalID)
+	return trades, err
+}
+
+// UpdateStatus moves the proposal from one status to another and reports
+// whether it was still in the from status. decidedBy is recorded when the
+// proposal leaves pending.
+func (r *RebalanceRepository) UpdateStatus(ctx context.Context, proposalID uuid.UUID, from, to string, decidedBy *uuid.UUID, decidedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.rebalance_proposals
+        SET status = $3,
+            decided_by = COALESCE($4, decided_by),
+            decided_at = CASE WHEN $4::uuid IS NULL THEN decided_at ELSE $5 END
+        WHERE id = $1 AND status = $2`, proposalID, from, to, decidedBy, decidedAt)
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	return updated > 0, err
+}
+
+func (r *RebalanceRepository) RecordTradeResult(ctx context.Context, tradeID uuid.UUID, orderID *uuid.UUID, tradeErr *string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.rebalance_trades SET order_id = $2, error = $3 WHERE id = $1`, tradeID, orderID, tradeErr)
+	return err
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	handlers "thyra/internal/portfolios/api/portfolios"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, modelHandler *handlers.ModelPortfolioHandler, rebalanceHandler *handlers.RebalanceHandler) {
+	router.GET("/model-portfolios", modelHandler.GetModelPortfolios)
+	router.POST("/model-portfolios", modelHandler.CreateModelPortfolio)
+	router.GET("/model-portfolios/:modelId", modelHandler.GetModelPortfolio)
+	router.PUT("/model-portfolios/:modelId", modelHandler.UpdateModelPortfolio)
+
+	router.GET("/account/:accountId/model-portfolio", modelHandler.GetAssignment)
+	router.PUT("/account/:accountId/model-portfolio", modelHandler.AssignModelPortfolio)
+	router.DELETE("/account/:accountId/model-portfolio", modelHandler.UnassignModelPortfolio)
+
+	router.POST("/account/:accountId/rebalance-proposals", rebalanceHandler.ProposeRebalance)
+	router.GET("/account/:accountId/rebalance-proposals", rebalanceHandler.GetAccountProposals)
+	router.GET("/rebalance-proposals/:proposalId", rebalanceHandler.GetProposal)
+	router.POST("/rebalance-proposals/:proposalId/approve", rebalanceHandler.ApproveProposal)
+	router.POST("/rebalance-proposals/:proposalId/reject", rebalanceHandler.RejectProposal)
+}
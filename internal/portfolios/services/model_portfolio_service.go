@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"thyra/internal/portfolios/models"
+	"thyra/internal/portfolios/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrPortfolioAccessDenied    = errors.New("only advisors and admins can manage model portfolios")
+	ErrModelPortfolioNotFound   = errors.New("model portfolio not found")
+	ErrModelNameTaken           = errors.New("a model portfolio with this name already exists")
+	ErrModelNameRequired        = errors.New("name is required")
+	ErrInvalidTargets           = errors.New("targets must list existing instruments once each, with weights above 0 adding up to 1")
+	ErrInvalidDriftBand         = errors.New("drift_band must be above 0 and at most 1")
+	ErrInvalidCashBuffer        = errors.New("cash_buffer must be at least 0 and below 1")
+	ErrInvalidMinTradeAmount    = errors.New("min_trade_amount must be at least 0")
+	ErrAccountNotFound          = errors.New("account not found")
+	ErrNoModelPortfolioAssigned = errors.New("account has no model portfolio")
+
+	defaultDriftBand = decimal.NewFromFloat(0.05)
+	// weightTolerance absorbs rounding in weights such as three thirds.
+	weightTolerance = decimal.NewFromFloat(0.0001)
+)
+
+type ModelPortfolioService struct {
+	repo *repositories.ModelPortfolioRepository
+}
+
+func NewModelPortfolioService(repo *repositories.ModelPortfolioRepository) *ModelPortfolioService {
+	return &ModelPortfolioService{repo: repo}
+}
+
+func (s *ModelPortfolioService) CreateModelPortfolio(ctx context.Context, authUserID uuid.UUID, authUserRole string, req models.ModelPortfolioRequest) (models.ModelPortfolio, error) {
+	if !canManagePortfolios(authUserRole) {
+		return models.ModelPortfolio{}, ErrPortfolioAccessDenied
+	}
+	now := time.Now()
+	model := models.ModelPortfolio{ID: uuid.New(), CreatedBy: authUserID, CreatedAt: now, UpdatedAt: now}
+	if err := s.apply(ctx, &model, req); err != nil {
+		return models.ModelPortfolio{}, err
+	}
+	if err := s.repo.SaveModelPortfolio(ctx, model); err != nil {
+		return models.ModelPortfolio{}, err
+	}
+	return model, nil
+}
+
+// UpdateModelPortfolio replaces the model's definition. Accounts on the
+// model are rebalanced against the new targets from their next proposal.
+func (s *ModelPortfolioService) UpdateModelPortfolio(ctx context.Context, modelID uuid.UUID, authUserRole string, req models.ModelPortfolioRequest) (models.ModelPortfolio, error) {
+	if !canManagePortfolios(authUserRole) {
+		return models.ModelPortfolio{}, ErrPortfolioAccessDenied
+	}
+	model, err := s.GetModelPortfolio(ctx, modelID, authUserRole)
+	if err != nil {
+		return models.ModelPortfolio{}, err
+	}
+	if err := s.apply(ctx, &model, req); err != nil {
+		return models.ModelPortfolio{}, err
+	}
+	model.UpdatedAt = time.Now()
+	if err := s.repo.SaveModelPortfolio(ctx, model); err != nil {
+		return models.ModelPortfolio{}, err
+	}
+	return model, nil
+}
+
+func (s *ModelPortfolioService) GetModelPortfolios(ctx context.Context, authUserRole string) ([]models.ModelPortfolio, error) {
+	if !canManagePortfolios(authUserRole) {
+		return nil, ErrPortfolioAccessDenied
+	}
+	return s.repo.GetModelPortfolios(ctx)
+}
+
+func (s *ModelPortfolioService) GetModelPortfolio(ctx context.Context, modelID uuid.UUID, authUserRole string) (models.ModelPortfolio, error) {
+	if !canManagePortfolios(authUserRole) {
+		return models.ModelPortfolio{}, ErrPortfolioAccessDenied
+	}
+	model, err := s.repo.GetModelPortfolio(ctx, modelID)
+	if err == sql.ErrNoRows {
+		return model, ErrModelPortfolioNotFound
+	}
+	return model, err
+}
+
+// GetAssignment returns the model portfolio the account follows. Account
+// holders may see their own.
+func (s *ModelPortfolioService) GetAssignment(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string) (models.AccountModelPortfolio, error) {
+	holderID, err := s.accountHolder(ctx, accountID)
+	if err != nil {
+		return models.AccountModelPortfolio{}, err
+	}
+	if !canManagePortfolios(authUserRole) && holderID != authUserID {
+		return models.AccountModelPortfolio{}, ErrPortfolioAccessDenied
+	}
+	assignment, err := s.repo.GetAssignment(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return assignment, ErrNoModelPortfolioAssigned
+	}
+	return assignment, err
+}
+
+func (s *ModelPortfolioService) AssignModelPortfolio(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string, req models.AssignModelRequest) (models.AccountModelPortfolio, error) {
+	if !canManagePortfolios(authUserRole) {
+		return models.AccountModelPortfolio{}, ErrPortfolioAccessDenied
+	}
+	if _, err := s.accountHolder(ctx, accountID); err != nil {
+		return models.AccountModelPortfolio{}, err
+	}
+	if _, err := s.GetModelPortfolio(ctx, req.ModelPortfolioID, authUserRole); err != nil {
+		return models.AccountModelPortfolio{}, err
+	}
+
+	assignment := models.AccountModelPortfolio{
+		AccountID:        accountID,
+		ModelPortfolioID: req.ModelPortfolioID,
+		AssignedBy:       authUserID,
+		AssignedAt:       time.Now(),
+	}
+	if err := s.repo.AssignModel(ctx, assignment); err != nil {
+		return models.AccountModelPortfolio{}, err
+	}
+	return assignment, nil
+}
+
+func (s *ModelPortfolioService) UnassignModelPortfolio(ctx context.Context, accountID uuid.UUID, authUserRole string) error {
+	if !canManagePortfolios(authUserRole) {
+		return ErrPortfolioAccessDenied
+	}
+	removed, err := s.repo.RemoveAssignment(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNoModelPortfolioAssigned
+	}
+	return nil
+}
+
+func (s *ModelPortfolioService) accountHolder(ctx context.Context, accountID uuid.UUID) (uuid.UUID, error) {
+	holderID, err := s.repo.GetAccountHolderID(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrAccountNotFound
+	}
+	return holderID, err
+}
+
+// apply validates the request and copies it onto the model.
+func (s *ModelPortfolioService) apply(ctx context.Context, model *models.ModelPortfolio, req models.ModelPortfolioRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ErrModelNameRequired
+	}
+	taken, err := s.repo.NameTaken(ctx, name, model.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrModelNameTaken
+	}
+
+	driftBand := defaultDriftBand
+	if req.DriftBand != nil {
+		driftBand = *req.DriftBand
+	}
+	if !validBand(driftBand) {
+		return ErrInvalidDriftBand
+	}
+	if req.CashBuffer.IsNegative() || req.CashBuffer.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return ErrInvalidCashBuffer
+	}
+	if req.MinTradeAmount.IsNegative() {
+		return ErrInvalidMinTradeAmount
+	}
+
+	if len(req.Targets) == 0 {
+		return ErrInvalidTargets
+	}
+	seen := map[uuid.UUID]bool{}
+	assetIDs := make([]uuid.UUID, 0, len(req.Targets))
+	total := decimal.Zero
+	for _, target := range req.Targets {
+		if seen[target.AssetID] || !target.TargetWeight.IsPositive() || target.TargetWeight.GreaterThan(decimal.NewFromInt(1)) {
+			return ErrInvalidTargets
+		}
+		if target.DriftBand != nil && !validBand(*target.DriftBand) {
+			return ErrInvalidDriftBand
+		}
+		seen[target.AssetID] = true
+		assetIDs = append(assetIDs, target.AssetID)
+		total = total.Add(target.TargetWeight)
+	}
+	if total.Sub(decimal.NewFromInt(1)).Abs().GreaterThan(weightTolerance) {
+		return ErrInvalidTargets
+	}
+	count, err := s.repo.CountAssets(ctx, assetIDs)
+	if err != nil {
+		return err
+	}
+	if count != len(assetIDs) {
+		return ErrInvalidTargets
+	}
+
+	model.Name = name
+	model.Description = req.Description
+	model.DriftBand = driftBand
+	model.CashBuffer = req.CashBuffer
+	model.MinTradeAmount = req.MinTradeAmount
+	model.Targets = req.Targets
+	for i := range model.Targets {
+		model.Targets[i].ModelPortfolioID = model.ID
+	}
+	return nil
+}
+
+func validBand(band decimal.Decimal) bool {
+	return band.IsPositive() && band.LessThanOrEqual(decimal.NewFromInt(1))
+}
+
+func canManagePortfolios(authUserRole string) bool {
+	return authUserRole == "admin" || authUserRole == "partner_advisor"
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	ordermodels "thyra/internal/orders/models"
+	"thyra/internal/portfolios/models"
+	"thyra/internal/portfolios/repositories"
+	portfolioutils "thyra/internal/portfolios/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// proposalValidity is how long a proposal can be approved for; after that
+// prices and holdings have moved too far and a new one must be made.
+const proposalValidity = 24 * time.Hour
+
+var (
+	ErrNoRebalanceNeeded  = errors.New("account is within the drift bands of its model portfolio")
+	ErrNothingToRebalance = portfolioutils.ErrNothingToRebalance
+	ErrMissingPrice       = portfolioutils.ErrMissingPrice
+	ErrProposalNotFound   = errors.New("rebalance proposal not found")
+	ErrProposalNotPending = errors.New("rebalance proposal has already been decided")
+	ErrProposalExpired    = errors.New("rebalance proposal has expired, create a new one")
+)
+
+// OrderPlacer places orders the way clients and advisors place them.
+type OrderPlacer interface {
+	CreateBuyOrder(newOrder ordermodels.Order) (ordermodels.Order, error)
+	CreateSellOrder(newOrder ordermodels.Order) (ordermodels.Order, error)
+	GetOrderTypeByName(name string) (uuid.UUID, error)
+}
+
+type RebalanceService struct {
+	repo   *repositories.RebalanceRepository
+	models *ModelPortfolioService
+	orders OrderPlacer
+}
+
+func NewRebalanceService(repo *repositories.RebalanceRepository, models *ModelPortfolioService, orders OrderPlacer) *RebalanceService {
+	return &RebalanceService{repo: repo, models: models, orders: orders}
+}
+
+// ProposeRebalance compares the account with its model portfolio and stores
+// the trades that bring it back to target as a pending proposal.
+func (s *RebalanceService) ProposeRebalance(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string) (models.RebalanceProposal, error) {
+	if !canManagePortfolios(authUserRole) {
+		return models.RebalanceProposal{}, ErrPortfolioAccessDenied
+	}
+	assignment, err := s.models.GetAssignment(ctx, accountID, authUserID, authUserRole)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	model, err := s.models.GetModelPortfolio(ctx, assignment.ModelPortfolioID, authUserRole)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	positions, err := s.repo.GetPositions(ctx, accountID)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	assetIDs := make([]uuid.UUID, len(model.Targets))
+	for i, target := range model.Targets {
+		assetIDs[i] = target.AssetID
+	}
+	prices, err := s.repo.GetPrices(ctx, assetIDs)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+
+	trades, err := portfolioutils.ProposeTrades(model, positions, account.AvailableCash, prices)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	if len(trades) == 0 {
+		return models.RebalanceProposal{}, ErrNoRebalanceNeeded
+	}
+
+	total := account.AvailableCash
+	for _, position := range positions {
+		total = total.Add(position.Quantity.Mul(position.Price))
+	}
+	proposal := models.RebalanceProposal{
+		ID:               uuid.New(),
+		AccountID:        accountID,
+		ModelPortfolioID: model.ID,
+		Status:           models.ProposalStatusPending,
+		TotalValue:       total.Round(2),
+		CashValue:        account.AvailableCash.Round(2),
+		CreatedBy:        authUserID,
+		CreatedAt:        time.Now(),
+		Trades:           trades,
+	}
+	if err := s.repo.InsertProposal(ctx, proposal); err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	return proposal, nil
+}
+
+// GetProposal returns the proposal to advisors and to the account holder.
+func (s *RebalanceService) GetProposal(ctx context.Context, proposalID, authUserID uuid.UUID, authUserRole string) (models.RebalanceProposal, error) {
+	proposal, err := s.repo.GetProposal(ctx, proposalID)
+	if err == sql.ErrNoRows {
+		return proposal, ErrProposalNotFound
+	}
+	if err != nil {
+		return proposal, err
+	}
+	if !canManagePortfolios(authUserRole) {
+		holderID, err := s.models.accountHolder(ctx, proposal.AccountID)
+		if err != nil {
+			return models.RebalanceProposal{}, err
+		}
+		if holderID != authUserID {
+			return models.RebalanceProposal{}, ErrPortfolioAccessDenied
+		}
+	}
+	return proposal, nil
+}
+
+func (s *RebalanceService) GetAccountProposals(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string) ([]models.RebalanceProposal, error) {
+	holderID, err := s.models.accountHolder(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManagePortfolios(authUserRole) && holderID != authUserID {
+		return nil, ErrPortfolioAccessDenied
+	}
+	return s.repo.GetAccountProposals(ctx, accountID)
+}
+
+// ApproveProposal places the proposal's orders through the orders module,
+// sells first. A trade whose order is refused is recorded with the reason
+// and the rest are still placed.
+func (s *RebalanceService) ApproveProposal(ctx context.Context, proposalID, authUserID uuid.UUID, authUserRole string, req models.ApproveProposalRequest) (models.RebalanceProposal, error) {
+	if !canManagePortfolios(authUserRole) {
+		return models.RebalanceProposal{}, ErrPortfolioAccessDenied
+	}
+	proposal, err := s.pendingProposal(ctx, proposalID, authUserID)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	account, err := s.repo.GetAccount(ctx, proposal.AccountID)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	model, err := s.models.GetModelPortfolio(ctx, proposal.ModelPortfolioID, authUserRole)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+
+	now := time.Now()
+	claimed, err := s.repo.UpdateStatus(ctx, proposal.ID, models.ProposalStatusPending, models.ProposalStatusApproved, &authUserID, now)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	if !claimed {
+		return models.RebalanceProposal{}, ErrProposalNotPending
+	}
+
+	comment := fmt.Sprintf("Rebalance to model portfolio %s", model.Name)
+	placed := 0
+	for i, trade := range proposal.Trades {
+		orderID, err := s.placeOrder(trade, account, authUserID, req.AcknowledgeWarnings, comment, now)
+		if err != nil {
+			message := err.Error()
+			proposal.Trades[i].Error = &message
+		} else {
+			proposal.Trades[i].OrderID = &orderID
+			placed++
+		}
+		if err := s.repo.RecordTradeResult(ctx, trade.ID, proposal.Trades[i].OrderID, proposal.Trades[i].Error); err != nil {
+			log.Printf("Failed to record rebalance trade %s: %v", trade.ID, err)
+		}
+	}
+
+	status := models.ProposalStatusSubmitted
+	switch {
+	case placed == 0:
+		status = models.ProposalStatusFailed
+	case placed < len(proposal.Trades):
+		status = models.ProposalStatusPartiallySubmitted
+	}
+	if _, err := s.repo.UpdateStatus(ctx, proposal.ID, models.ProposalStatusApproved, status, nil, now); err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	proposal.Status, proposal.DecidedBy, proposal.DecidedAt = status, &authUserID, &now
+	return proposal, nil
+}
+
+func (s *RebalanceService) RejectProposal(ctx context.Context, proposalID, authUserID uuid.UUID, authUserRole string) (models.RebalanceProposal, error) {
+	if !canManagePortfolios(authUserRole) {
+		return models.RebalanceProposal{}, ErrPortfolioAccessDenied
+	}
+	proposal, err := s.pendingProposal(ctx, proposalID, authUserID)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+
+	now := time.Now()
+	rejected, err := s.repo.UpdateStatus(ctx, proposal.ID, models.ProposalStatusPending, models.ProposalStatusRejected, &authUserID, now)
+	if err != nil {
+		return models.RebalanceProposal{}, err
+	}
+	if !rejected {
+		return models.RebalanceProposal{}, ErrProposalNotPending
+	}
+	proposal.Status, proposal.DecidedBy, proposal.DecidedAt = models.ProposalStatusRejected, &authUserID, &now
+	return proposal, nil
+}
+
+// pendingProposal loads a proposal that can still be decided, expiring it
+// if it is too old.
+func (s *RebalanceService) pendingProposal(ctx context.Context, proposalID, authUserID uuid.UUID) (models.RebalanceProposal, error) {
+	proposal, err := s.repo.GetProposal(ctx, proposalID)
+	if err == sql.ErrNoRows {
+		return proposal, ErrProposalNotFound
+	}
+	if err != nil {
+		return proposal, err
+	}
+	if proposal.Status != models.ProposalStatusPending {
+		return proposal, ErrProposalNotPending
+	}
+	if time.Since(proposal.CreatedAt) > proposalValidity {
+		if _, err := s.repo.UpdateStatus(ctx, proposal.ID, models.ProposalStatusPending, models.ProposalStatusExpired, nil, time.Now()); err != nil {
+			return proposal, err
+		}
+		return proposal, ErrProposalExpired
+	}
+	return proposal, nil
+}
+
+func (s *RebalanceService) placeOrder(trade models.RebalanceTrade, account models.RebalanceAccount, approvedBy uuid.UUID, acknowledgeWarnings bool, comment string, tradeDate time.Time) (uuid.UUID, error) {
+	order := ordermodels.Order{
+		AccountID:           account.ID,
+		AssetID:             trade.AssetID,
+		Quantity:            trade.Quantity,
+		PricePerUnit:        trade.Price,
+		TotalAmount:         trade.Amount,
+		TradeDate:           tradeDate,
+		SettlementDate:      tradeDate.AddDate(0, 0, 2),
+		Comment:             &comment,
+		OwnerID:             account.AccountHolderID,
+		AcknowledgeWarnings: acknowledgeWarnings,
+		RequestedBy:         approvedBy,
+	}
+
+	orderTypeName := "order_type_buy"
+	place := s.orders.CreateBuyOrder
+	if trade.Side == models.TradeSideSell {
+		orderTypeName = "order_type_sell"
+		place = s.orders.CreateSellOrder
+	}
+	orderType, err := s.orders.GetOrderTypeByName(orderTypeName)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	order.OrderType = orderType
+
+	placedOrder, err := place(order)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return placedOrder.ID, nil
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"thyra/internal/portfolios/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrNothingToRebalance = errors.New("account has no value to rebalance")
+	ErrMissingPrice       = errors.New("instrument has no price to trade at")
+)
+
+type rebalanceLine struct {
+	assetID      uuid.UUID
+	quantity     decimal.Decimal
+	price        decimal.Decimal
+	value        decimal.Decimal
+	targetWeight decimal.Decimal
+	band         decimal.Decimal
+}
+
+// ProposeTrades compares the account with the model portfolio and returns
+// the trades that bring it back to target, or nothing while every
+// instrument is within its drift band. Holdings outside the model have a
+// target of zero.
+//
+// Once any band is breached every instrument is traded back to target in
+// whole shares, leaving out trades below the model's minimum trade amount.
+// Sale proceeds only become available at settlement, so buys are paid from
+// cash on hand above the cash buffer, scaled down when it does not cover
+// them all; a later rebalance invests the proceeds.
+func ProposeTrades(model models.ModelPortfolio, positions []models.RebalancePosition, cash decimal.Decimal, prices map[uuid.UUID]decimal.Decimal) ([]models.RebalanceTrade, error) {
+	total := cash
+	for _, position := range positions {
+		total = total.Add(position.Quantity.Mul(position.Price))
+	}
+	if !total.IsPositive() {
+		return nil, ErrNothingToRebalance
+	}
+
+	invested := decimal.NewFromInt(1).Sub(model.CashBuffer)
+	lines := map[uuid.UUID]*rebalanceLine{}
+	for _, target := range model.Targets {
+		band := model.DriftBand
+		if target.DriftBand != nil {
+			band = *target.DriftBand
+		}
+		lines[target.AssetID] = &rebalanceLine{
+			assetID:      target.AssetID,
+			quantity:     decimal.Zero,
+			price:        prices[target.AssetID],
+			value:        decimal.Zero,
+			targetWeight: target.TargetWeight.Mul(invested),
+			band:         band,
+		}
+	}
+	for _, position := range positions {
+		line, ok := lines[position.AssetID]
+		if !ok {
+			line = &rebalanceLine{assetID: position.AssetID, targetWeight: decimal.Zero, band: model.DriftBand}
+			lines[position.AssetID] = line
+		}
+		line.quantity = position.Quantity
+		line.price = position.Price
+		line.value = position.Quantity.Mul(position.Price)
+	}
+
+	drifted := false
+	for _, line := range lines {
+		if line.value.Div(total).Sub(line.targetWeight).Abs().GreaterThan(line.band) {
+			drifted = true
+			break
+		}
+	}
+	if !drifted {
+		return nil, nil
+	}
+
+	var sells, buys []models.RebalanceTrade
+	var underweight []*rebalanceLine
+	needed := decimal.Zero
+	for _, line := range lines {
+		targetValue := total.Mul(line.targetWeight)
+		switch {
+		case line.value.GreaterThan(targetValue):
+			quantity := line.quantity
+			if line.targetWeight.IsPositive() {
+				quantity = decimal.Min(line.value.Sub(targetValue).Div(line.price).Floor(), line.quantity)
+			}
+			if trade, ok := newTrade(line, models.TradeSideSell, quantity, total, model.MinTradeAmount); ok {
+				sells = append(sells, trade)
+			}
+		case line.value.LessThan(targetValue):
+			if !line.price.IsPositive() {
+				return nil, fmt.Errorf("%w: %s", ErrMissingPrice, line.assetID)
+			}
+			underweight = append(underweight, line)
+			needed = needed.Add(targetValue.Sub(line.value))
+		}
+	}
+
+	budget := cash.Sub(total.Mul(model.CashBuffer))
+	if budget.IsPositive() && needed.IsPositive() {
+		for _, line := range underweight {
+			// Multiplying by the budget before dividing by what is needed
+			// keeps a budget that exactly covers a share from falling short.
+			value := total.Mul(line.targetWeight).Sub(line.value)
+			if budget.LessThan(needed) {
+				value = value.Mul(budget).Div(needed)
+			}
+			quantity := value.Div(line.price).Floor()
+			if trade, ok := newTrade(line, models.TradeSideBuy, quantity, total, model.MinTradeAmount); ok {
+				buys = append(buys, trade)
+			}
+		}
+	}
+
+	sortTrades(sells)
+	sortTrades(buys)
+	return append(sells, buys...), nil
+}
+
+func newTrade(line *rebalanceLine, side string, quantity, total, minTradeAmount decimal.Decimal) (models.RebalanceTrade, bool) {
+	amount := quantity.Mul(line.price).Round(2)
+	if !quantity.IsPositive() || amount.LessThan(minTradeAmount) {
+		return models.RebalanceTrade{}, false
+	}
+	return models.RebalanceTrade{
+		ID:            uuid.New(),
+		AssetID:       line.assetID,
+		Side:          side,
+		Quantity:      quantity,
+		Price:         line.price,
+		Amount:        amount,
+		CurrentWeight: line.value.Div(total).Round(6),
+		TargetWeight:  line.targetWeight.Round(6),
+	}, true
+}
+
+// sortTrades puts the largest trades first.
+func sortTrades(trades []models.RebalanceTrade) {
+	sort.Slice(trades, func(i, j int) bool {
+		if !trades[i].Amount.Equal(trades[j].Amount) {
+			return trades[i].Amount.GreaterThan(trades[j].Amount)
+		}
+		return trades[i].AssetID.String() < trades[j].AssetID.String()
+	})
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"thyra/internal/portfolios/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	assetA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	assetB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	assetC = uuid.MustParse("00000000-0000-0000-0000-00000000000c")
+)
+
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+func target(assetID uuid.UUID, weight string) models.ModelTarget {
+	return models.ModelTarget{AssetID: assetID, TargetWeight: dec(weight)}
+}
+
+func position(assetID uuid.UUID, quantity, price string) models.RebalancePosition {
+	return models.RebalancePosition{AssetID: assetID, Quantity: dec(quantity), Price: dec(price)}
+}
+
+type wantTrade struct {
+	assetID  uuid.UUID
+	side     string
+	quantity string
+}
+
+func TestProposeTrades(t *testing.T) {
+	wideBand := dec("0.25")
+	prices := map[uuid.UUID]decimal.Decimal{assetA: dec("10"), assetB: dec("10")}
+
+	tests := []struct {
+		name      string
+		model     models.ModelPortfolio
+		positions []models.RebalancePosition
+		cash      string
+		want      []wantTrade
+		err       error
+	}{
+		{
+			name:      "within the band",
+			model:     models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{target(assetA, "0.6"), target(assetB, "0.4")}},
+			positions: []models.RebalancePosition{position(assetA, "63", "10"), position(assetB, "37", "10")},
+			cash:      "0",
+		},
+		{
+			name: "per-target band overrides the model band",
+			model: models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{
+				{AssetID: assetA, TargetWeight: dec("0.5"), DriftBand: &wideBand},
+				{AssetID: assetB, TargetWeight: dec("0.5"), DriftBand: &wideBand},
+			}},
+			positions: []models.RebalancePosition{position(assetA, "70", "10"), position(assetB, "30", "10")},
+			cash:      "0",
+		},
+		{
+			name:      "sells the overweight and buys with the cash on hand",
+			model:     models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{target(assetA, "0.5"), target(assetB, "0.5")}},
+			positions: []models.RebalancePosition{position(assetA, "70", "10"), position(assetB, "20", "10")},
+			cash:      "100",
+			want:      []wantTrade{{assetA, models.TradeSideSell, "20"}, {assetB, models.TradeSideBuy, "10"}},
+		},
+		{
+			name:      "holding outside the model is sold in full",
+			model:     models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{target(assetA, "1")}},
+			positions: []models.RebalancePosition{position(assetA, "50", "10"), position(assetC, "5", "100")},
+			cash:      "0",
+			want:      []wantTrade{{assetC, models.TradeSideSell, "5"}},
+		},
+		{
+			name:      "buys are scaled down to the cash",
+			model:     models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{target(assetA, "0.5"), target(assetB, "0.5")}},
+			positions: []models.RebalancePosition{position(assetC, "70", "10")},
+			cash:      "300",
+			want: []wantTrade{
+				{assetC, models.TradeSideSell, "70"},
+				{assetA, models.TradeSideBuy, "15"},
+				{assetB, models.TradeSideBuy, "15"},
+			},
+		},
+		{
+			name:  "cash buffer is kept",
+			model: models.ModelPortfolio{DriftBand: dec("0.05"), CashBuffer: dec("0.1"), Targets: []models.ModelTarget{target(assetA, "1")}},
+			cash:  "1000",
+			want:  []wantTrade{{assetA, models.TradeSideBuy, "90"}},
+		},
+		{
+			name:      "buys are whole shares",
+			model:     models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{target(assetA, "1")}},
+			positions: []models.RebalancePosition{position(assetC, "1", "10")},
+			cash:      "95",
+			want:      []wantTrade{{assetC, models.TradeSideSell, "1"}, {assetA, models.TradeSideBuy, "9"}},
+		},
+		{
+			name:      "trades below the minimum are left out",
+			model:     models.ModelPortfolio{DriftBand: dec("0.01"), MinTradeAmount: dec("100"), Targets: []models.ModelTarget{target(assetA, "0.5"), target(assetB, "0.5")}},
+			positions: []models.RebalancePosition{position(assetA, "52", "10"), position(assetB, "48", "10")},
+			cash:      "0",
+		},
+		{
+			name:  "nothing to rebalance",
+			model: models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{target(assetA, "1")}},
+			cash:  "0",
+			err:   ErrNothingToRebalance,
+		},
+		{
+			name:  "no price for an underweight instrument",
+			model: models.ModelPortfolio{DriftBand: dec("0.05"), Targets: []models.ModelTarget{target(assetC, "1")}},
+			cash:  "1000",
+			err:   ErrMissingPrice,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trades, err := ProposeTrades(tt.model, tt.positions, dec(tt.cash), prices)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ProposeTrades error = %v, want %v", err, tt.err)
+			}
+			if len(trades) != len(tt.want) {
+				t.Fatalf("got %d trades, want %d: %+v", len(trades), len(tt.want), trades)
+			}
+			for i, want := range tt.want {
+				trade := trades[i]
+				if trade.AssetID != want.assetID || trade.Side != want.side || !trade.Quantity.Equal(dec(want.quantity)) {
+					t.Errorf("trade %d = %s %s %s, want %s %s %s", i, trade.Side, trade.Quantity, trade.AssetID, want.side, want.quantity, want.assetID)
+				}
+				if !trade.Amount.Equal(trade.Quantity.Mul(trade.Price)) {
+					t.Errorf("trade %d amount = %s, want %s", i, trade.Amount, trade.Quantity.Mul(trade.Price))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/positions/models"
+	"thyra/internal/positions/repositories"
+	"thyra/internal/positions/services"
+	userutils "thyra/internal/users/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TaxLotHandler struct {
+	service services.TaxLotService
+}
+
+func NewTaxLotHandler(service services.TaxLotService) *TaxLotHandler {
+	return &TaxLotHandler{service: service}
+}
+
+// GetOpenLots returns the account's open tax lots, optionally for ?assetId=.
+func (h *TaxLotHandler) GetOpenLots(c *gin.Context) {
+	accountID, authUserID, authUserRole, ok := pnlRequest(c)
+	if !ok {
+		return
+	}
+	var assetID *uuid.UUID
+	if value := c.Query("assetId"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asset ID"})
+			return
+		}
+		assetID = &parsed
+	}
+
+	lots, err := h.service.GetOpenLots(accountID, authUserID, authUserRole, assetID)
+	if err != nil {
+		writePnLError(c, err, "Failed to retrieve tax lots")
+		return
+	}
+
+	c.JSON(http.StatusOK, lots)
+}
+
+func (h *TaxLotHandler) GetUnrealizedPnL(c *gin.Context) {
+	accountID, authUserID, authUserRole, ok := pnlRequest(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetUnrealizedPnL(accountID, authUserID, authUserRole)
+	if err != nil {
+		writePnLError(c, err, "Failed to compute unrealized profit and loss")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetRealizedPnL reports sales between ?from= and ?to= (YYYY-MM-DD). The
+// period defaults to the current year up to today.
+func (h *TaxLotHandler) GetRealizedPnL(c *gin.Context) {
+	accountID, authUserID, authUserRole, ok := pnlRequest(c)
+	if !ok {
+		return
+	}
+
+	layout := "2006-01-02"
+	now := time.Now()
+	from := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(layout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(layout, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format"})
+			return
+		}
+		to = parsed
+	}
+
+	report, err := h.service.GetRealizedPnL(accountID, authUserID, authUserRole, from, to)
+	if err != nil {
+		writePnLError(c, err, "Failed to compute realized profit and loss")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SetLotCost enters the cost and acquisition date of a migrated lot whose
+// cost was unknown.
+func (h *TaxLotHandler) SetLotCost(c *gin.Context) {
+	accountID, _, authUserRole, ok := pnlRequest(c)
+	if !ok {
+		return
+	}
+	lotID, err := uuid.Parse(c.Param("lotId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax lot ID"})
+		return
+	}
+	var req models.TaxLotCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	lot, err := h.service.SetLotCost(accountID, lotID, authUserRole, req)
+	if err != nil {
+		writePnLError(c, err, "Failed to set tax lot cost")
+		return
+	}
+
+	c.JSON(http.StatusOK, lot)
+}
+
+func pnlRequest(c *gin.Context) (uuid.UUID, uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, uuid.Nil, "", false
+	}
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return uuid.Nil, uuid.Nil, "", false
+	}
+	return accountID, authUserID, userRole, true
+}
+
+func writePnLError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrPnLAccessDenied, services.ErrLotCostAdminOnly:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrPnLAccountMissing:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidPeriod, services.ErrInvalidLotCost:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case repositories.ErrLotCostKnown:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -2,12 +2,16 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type Holding struct {
-	ID                uuid.UUID `db:"id"`
-	AccountID         uuid.UUID `db:"account_id"`
-	AssetID           uuid.UUID `db:"asset_id"`
-	Quantity          float64   `db:"quantity"`
-	AvailableQuantity float64   `db:"available_quantity"`
+	ID                uuid.UUID       `db:"id"`
+	AccountID         uuid.UUID       `db:"account_id"`
+	AssetID           uuid.UUID       `db:"asset_id"`
+	Quantity          decimal.Decimal `db:"quantity"`
+	AvailableQuantity decimal.Decimal `db:"available_quantity"`
+	// AcquisitionCost is the total cost of the quantity held, in the
+	// account's currency.
+	AcquisitionCost decimal.Decimal `db:"acquisition_cost"`
 }
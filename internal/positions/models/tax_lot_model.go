@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Methods for deciding which lots a sale consumes. Average cost, the
+// Swedish genomsnittsmetoden, takes every open lot down pro rata so the
+// cost removed is the quantity times the average cost.
+const (
+	TaxLotMethodFIFO    = "fifo"
+	TaxLotMethodAverage = "average"
+)
+
+// Where a lot came from.
+const (
+	TaxLotSourceOpening  = "opening"
+	TaxLotSourceBuy      = "buy"
+	TaxLotSourceTransfer = "transfer"
+	TaxLotSourceDelivery = "delivery"
+)
+
+// TaxLot is one acquisition. Lots migrated without a purchase record have
+// CostKnown false and no AcquiredAt until an admin enters them.
+type TaxLot struct {
+	ID                uuid.UUID       `db:"id" json:"id"`
+	AccountID         uuid.UUID       `db:"account_id" json:"account_id"`
+	AssetID           uuid.UUID       `db:"asset_id" json:"asset_id"`
+	AcquiredAt        *time.Time      `db:"acquired_at" json:"acquired_at"`
+	Quantity          decimal.Decimal `db:"quantity" json:"quantity"`
+	RemainingQuantity decimal.Decimal `db:"remaining_quantity" json:"remaining_quantity"`
+	Cost              decimal.Decimal `db:"cost" json:"cost"`
+	RemainingCost     decimal.Decimal `db:"remaining_cost" json:"remaining_cost"`
+	Source            string          `db:"source" json:"source"`
+	SourceID          *uuid.UUID      `db:"source_id" json:"source_id"`
+	CostKnown         bool            `db:"cost_known" json:"cost_known"`
+	CreatedAt         time.Time       `db:"created_at" json:"created_at"`
+	ClosedAt          *time.Time      `db:"closed_at" json:"closed_at"`
+}
+
+// LotUse is the part of a lot taken by a sale or an outbound transfer.
+type LotUse struct {
+	TaxLotID   uuid.UUID       `db:"tax_lot_id" json:"tax_lot_id"`
+	AcquiredAt *time.Time      `db:"acquired_at" json:"acquired_at"`
+	Quantity   decimal.Decimal `db:"quantity" json:"quantity"`
+	Cost       decimal.Decimal `db:"cost" json:"cost"`
+	CostKnown  bool            `db:"cost_known" json:"cost_known"`
+}
+
+// TaxLotCostRequest enters the cost and acquisition date of a lot that was
+// migrated without them.
+type TaxLotCostRequest struct {
+	Cost       decimal.Decimal `json:"cost" binding:"required"`
+	AcquiredAt string          `json:"acquired_at" binding:"required"`
+}
+
+type RealizedGain struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	AccountID   uuid.UUID       `db:"account_id" json:"account_id"`
+	AssetID     uuid.UUID       `db:"asset_id" json:"asset_id"`
+	OrderID     *uuid.UUID      `db:"order_id" json:"order_id"`
+	SaleDate    time.Time       `db:"sale_date" json:"sale_date"`
+	Quantity    decimal.Decimal `db:"quantity" json:"quantity"`
+	Proceeds    decimal.Decimal `db:"proceeds" json:"proceeds"`
+	CostBasis   decimal.Decimal `db:"cost_basis" json:"cost_basis"`
+	RealizedPnL decimal.Decimal `db:"realized_pnl" json:"realized_pnl"`
+	Method      string          `db:"method" json:"method"`
+	CostKnown   bool            `db:"cost_known" json:"cost_known"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	Lots        []LotUse        `db:"-" json:"lots,omitempty"`
+}
+
+// PositionPnL values one holding at the current price against the cost of
+// its open lots. While any of them has an unknown cost, CostKnown is false
+// and the position is left out of the report totals.
+type PositionPnL struct {
+	AssetID              uuid.UUID       `db:"asset_id" json:"asset_id"`
+	InstrumentName       string          `db:"instrument_name" json:"instrument_name"`
+	Ticker               string          `db:"ticker" json:"ticker"`
+	Quantity             decimal.Decimal `db:"quantity" json:"quantity"`
+	CostBasis            decimal.Decimal `db:"cost_basis" json:"cost_basis"`
+	CostKnown            bool            `db:"cost_known" json:"cost_known"`
+	AverageCost          decimal.Decimal `db:"-" json:"average_cost"`
+	CurrentPrice         decimal.Decimal `db:"current_price" json:"current_price"`
+	MarketValue          decimal.Decimal `db:"-" json:"market_value"`
+	UnrealizedPnL        decimal.Decimal `db:"-" json:"unrealized_pnl"`
+	UnrealizedPnLPercent decimal.Decimal `db:"-" json:"unrealized_pnl_percent"`
+}
+
+type UnrealizedPnLReport struct {
+	AccountID          uuid.UUID       `json:"account_id"`
+	Positions          []PositionPnL   `json:"positions"`
+	TotalCostBasis     decimal.Decimal `json:"total_cost_basis"`
+	TotalMarketValue   decimal.Decimal `json:"total_market_value"`
+	TotalUnrealizedPnL decimal.Decimal `json:"total_unrealized_pnl"`
+}
+
+// RealizedPnLReport sums the sales in [From, To] whose cost is known.
+type RealizedPnLReport struct {
+	AccountID        uuid.UUID       `json:"account_id"`
+	From             time.Time       `json:"from"`
+	To               time.Time       `json:"to"`
+	Gains            []RealizedGain  `json:"gains"`
+	TotalProceeds    decimal.Decimal `json:"total_proceeds"`
+	TotalCostBasis   decimal.Decimal `json:"total_cost_basis"`
+	TotalRealizedPnL decimal.Decimal `json:"total_realized_pnl"`
+	TotalGains       decimal.Decimal `json:"total_gains"`
+	TotalLosses      decimal.Decimal `json:"total_losses"`
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"thyra/internal/positions/models"
+	positionutils "thyra/internal/positions/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// ErrLotCostKnown is returned when entering the cost of a lot that already
+// has one.
+var ErrLotCostKnown = errors.New("tax lot not found or its cost is already known")
+
+type TaxLotRepository interface {
+	GetAccountHolderID(accountID uuid.UUID) (uuid.UUID, error)
+	GetOpenLots(accountID uuid.UUID, assetID *uuid.UUID) ([]models.TaxLot, error)
+	GetPositionCosts(accountID uuid.UUID) ([]models.PositionPnL, error)
+	GetRealizedGains(accountID uuid.UUID, from, to time.Time) ([]models.RealizedGain, error)
+	GetRealizedGainLots(gainIDs []uuid.UUID) (map[uuid.UUID][]models.LotUse, error)
+	SetLotCost(accountID, lotID uuid.UUID, cost decimal.Decimal, acquiredAt time.Time) (models.TaxLot, error)
+}
+
+type taxLotRepository struct {
+	db *sqlx.DB
+}
+
+func NewTaxLotRepository(db *sqlx.DB) TaxLotRepository {
+	return &taxLotRepository{db: db}
+}
+
+func (r *taxLotRepository) GetAccountHolderID(accountID uuid.UUID) (uuid.UUID, error) {
+	var holderID uuid.UUID
+	err := r.db.Get(&holderID, `SELECT account_holder_id FROM thyrasec.accounts WHERE id = $1`, accountID)
+	return holderID, err
+}
+
+// GetOpenLots returns the account's lots with quantity left, oldest first,
+// optionally for one asset.
+func (r *taxLotRepository) GetOpenLots(accountID uuid.UUID, assetID *uuid.UUID) ([]models.TaxLot, error) {
+	lots := []models.TaxLot{}
+	err := r.db.Select(&lots, `
+        SELECT * FROM thyrasec.tax_lots
+        WHERE account_id = $1 AND ($2::uuid IS NULL OR asset_id = $2) AND remaining_quantity > 0
+        ORDER BY asset_id, acquired_at NULLS FIRST, created_at`, accountID, assetID)
+	return lots, err
+}
+
+// GetPositionCosts sums the open lots per asset with the asset's current price.
+func (r *taxLotRepository) GetPositionCosts(accountID uuid.UUID) ([]models.PositionPnL, error) {
+	positions := []models.PositionPnL{}
+	err := r.db.Select(&positions, `
+        SELECT l.asset_id, a.instrument_name, a.ticker,
+            SUM(l.remaining_quantity) AS quantity,
+            SUM(l.remaining_cost) AS cost_basis,
+            bool_and(l.cost_known) AS cost_known,
+            COALESCE(a.current_price, 0) AS current_price
+        FROM thyrasec.tax_lots l
+        JOIN thyrasec.assets a ON a.id = l.asset_id
+        WHERE l.account_id = $1 AND l.remaining_quantity > 0
+        GROUP BY l.asset_id, a.instrument_name, a.ticker, a.current_price
+        ORDER BY a.instrument_name`, accountID)
+	return positions, err
+}
+
+func (r *taxLotRepository) GetRealizedGains(accountID uuid.UUID, from, to time.Time) ([]models.RealizedGain, error) {
+	gains := []models.RealizedGain{}
+	err := r.db.Select(&gains, `
+        SELECT * FROM thyrasec.realized_gains
+        WHERE account_id = $1 AND sale_date BETWEEN $2 AND $3
+        ORDER BY sale_date, created_at`, accountID, from, to)
+	return gains, err
+}
+
+// GetRealizedGainLots returns the lots consumed by each of the gains.
+func (r *taxLotRepository) GetRealizedGainLots(gainIDs []uuid.UUID) (map[uuid.UUID][]models.LotUse, error) {
+	lots := map[uuid.UUID][]models.LotUse{}
+	if len(gainIDs) == 0 {
+		return lots, nil
+	}
+
+	query, args, err := sqlx.In(`
+        SELECT gl.realized_gain_id, gl.tax_lot_id, l.acquired_at, gl.quantity, gl.cost, l.cost_known
+        FROM thyrasec.realized_gain_lots gl
+        JOIN thyrasec.tax_lots l ON l.id = gl.tax_lot_id
+        WHERE gl.realized_gain_id IN (?)
+        ORDER BY l.acquired_at NULLS FIRST`, gainIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		RealizedGainID uuid.UUID `db:"realized_gain_id"`
+		models.LotUse
+	}
+	if err := r.db.Select(&rows, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		lots[row.RealizedGainID] = append(lots[row.RealizedGainID], row.LotUse)
+	}
+	return lots, nil
+}
+
+// SetLotCost enters the cost and acquisition date of a lot migrated without
+// them. The cost is for the lot's full quantity; what is still held carries
+// its share. Sales that already took from the lot keep their unknown cost.
+func (r *taxLotRepository) SetLotCost(accountID, lotID uuid.UUID, cost decimal.Decimal, acquiredAt time.Time) (models.TaxLot, error) {
+	var lot models.TaxLot
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return lot, err
+	}
+	defer tx.Rollback()
+
+	err = tx.Get(&lot, `
+        UPDATE thyrasec.tax_lots
+        SET cost = $1,
+            remaining_cost = ROUND($1 * remaining_quantity / quantity, 2),
+            acquired_at = $2,
+            cost_known = true
+        WHERE id = $3 AND account_id = $4 AND NOT cost_known
+        RETURNING *`, cost, acquiredAt, lotID, accountID)
+	if err == sql.ErrNoRows {
+		return lot, ErrLotCostKnown
+	}
+	if err != nil {
+		return lot, err
+	}
+
+	if err := positionutils.SyncHoldingCost(tx, lot.AccountID, lot.AssetID); err != nil {
+		return lot, err
+	}
+	return lot, tx.Commit()
+}
@@ -6,8 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.RouterGroup, holdingHandler *handlers.HoldingsHandler) {
+func SetupRoutes(router *gin.RouterGroup, holdingHandler *handlers.HoldingsHandler, taxLotHandler *handlers.TaxLotHandler) {
 	router.GET("/currency", holdingHandler.GetCurrencyID)
 	router.GET("/account/:accountId/holdings", holdingHandler.GetAccountHoldingsWithDetails)
 
+	router.GET("/account/:accountId/tax-lots", taxLotHandler.GetOpenLots)
+	router.GET("/account/:accountId/unrealized-pnl", taxLotHandler.GetUnrealizedPnL)
+	router.GET("/account/:accountId/realized-pnl", taxLotHandler.GetRealizedPnL)
+	router.PUT("/account/:accountId/tax-lots/:lotId/cost", taxLotHandler.SetLotCost)
 }
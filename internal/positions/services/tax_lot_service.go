@@ -0,0 +1,149 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"thyra/internal/positions/models"
+	"thyra/internal/positions/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrPnLAccessDenied   = errors.New("not allowed to view this account's profit and loss")
+	ErrPnLAccountMissing = errors.New("account not found")
+	ErrInvalidPeriod     = errors.New("from must not be after to")
+	ErrLotCostAdminOnly  = errors.New("only admins can enter tax lot costs")
+	ErrInvalidLotCost    = errors.New("cost must not be negative and acquired_at must be a past date as YYYY-MM-DD")
+)
+
+type TaxLotService interface {
+	GetOpenLots(accountID, authUserID uuid.UUID, authUserRole string, assetID *uuid.UUID) ([]models.TaxLot, error)
+	GetUnrealizedPnL(accountID, authUserID uuid.UUID, authUserRole string) (models.UnrealizedPnLReport, error)
+	GetRealizedPnL(accountID, authUserID uuid.UUID, authUserRole string, from, to time.Time) (models.RealizedPnLReport, error)
+	SetLotCost(accountID, lotID uuid.UUID, authUserRole string, req models.TaxLotCostRequest) (models.TaxLot, error)
+}
+
+type taxLotService struct {
+	repo repositories.TaxLotRepository
+}
+
+func NewTaxLotService(repo repositories.TaxLotRepository) TaxLotService {
+	return &taxLotService{repo: repo}
+}
+
+func (s *taxLotService) GetOpenLots(accountID, authUserID uuid.UUID, authUserRole string, assetID *uuid.UUID) ([]models.TaxLot, error) {
+	if err := s.checkAccess(accountID, authUserID, authUserRole); err != nil {
+		return nil, err
+	}
+	return s.repo.GetOpenLots(accountID, assetID)
+}
+
+// GetUnrealizedPnL values each position at the asset's current price against
+// the cost of its open lots. Positions with a lot of unknown cost are listed
+// at market value but get no profit and loss and stay out of the totals.
+func (s *taxLotService) GetUnrealizedPnL(accountID, authUserID uuid.UUID, authUserRole string) (models.UnrealizedPnLReport, error) {
+	report := models.UnrealizedPnLReport{AccountID: accountID}
+	if err := s.checkAccess(accountID, authUserID, authUserRole); err != nil {
+		return report, err
+	}
+
+	positions, err := s.repo.GetPositionCosts(accountID)
+	if err != nil {
+		return report, err
+	}
+	for i := range positions {
+		position := &positions[i]
+		position.MarketValue = position.Quantity.Mul(position.CurrentPrice).Round(2)
+		if !position.CostKnown {
+			continue
+		}
+		position.UnrealizedPnL = position.MarketValue.Sub(position.CostBasis)
+		if position.Quantity.IsPositive() {
+			position.AverageCost = position.CostBasis.Div(position.Quantity).Round(4)
+		}
+		if position.CostBasis.IsPositive() {
+			position.UnrealizedPnLPercent = position.UnrealizedPnL.Div(position.CostBasis).Mul(decimal.NewFromInt(100)).Round(2)
+		}
+
+		report.TotalCostBasis = report.TotalCostBasis.Add(position.CostBasis)
+		report.TotalMarketValue = report.TotalMarketValue.Add(position.MarketValue)
+		report.TotalUnrealizedPnL = report.TotalUnrealizedPnL.Add(position.UnrealizedPnL)
+	}
+	report.Positions = positions
+	return report, nil
+}
+
+// GetRealizedPnL sums the gains and losses booked on sales between from and
+// to, both inclusive. Sales of lots with unknown cost are listed but not
+// summed.
+func (s *taxLotService) GetRealizedPnL(accountID, authUserID uuid.UUID, authUserRole string, from, to time.Time) (models.RealizedPnLReport, error) {
+	report := models.RealizedPnLReport{AccountID: accountID, From: from, To: to}
+	if from.After(to) {
+		return report, ErrInvalidPeriod
+	}
+	if err := s.checkAccess(accountID, authUserID, authUserRole); err != nil {
+		return report, err
+	}
+
+	gains, err := s.repo.GetRealizedGains(accountID, from, to)
+	if err != nil {
+		return report, err
+	}
+	gainIDs := make([]uuid.UUID, len(gains))
+	for i, gain := range gains {
+		gainIDs[i] = gain.ID
+	}
+	lots, err := s.repo.GetRealizedGainLots(gainIDs)
+	if err != nil {
+		return report, err
+	}
+
+	for i := range gains {
+		gain := &gains[i]
+		gain.Lots = lots[gain.ID]
+		if !gain.CostKnown {
+			continue
+		}
+
+		report.TotalProceeds = report.TotalProceeds.Add(gain.Proceeds)
+		report.TotalCostBasis = report.TotalCostBasis.Add(gain.CostBasis)
+		report.TotalRealizedPnL = report.TotalRealizedPnL.Add(gain.RealizedPnL)
+		if gain.RealizedPnL.IsPositive() {
+			report.TotalGains = report.TotalGains.Add(gain.RealizedPnL)
+		} else {
+			report.TotalLosses = report.TotalLosses.Add(gain.RealizedPnL.Neg())
+		}
+	}
+	report.Gains = gains
+	return report, nil
+}
+
+// SetLotCost lets an admin enter the cost and acquisition date of a lot that
+// was migrated without them, bringing it into profit and loss.
+func (s *taxLotService) SetLotCost(accountID, lotID uuid.UUID, authUserRole string, req models.TaxLotCostRequest) (models.TaxLot, error) {
+	if authUserRole != "admin" {
+		return models.TaxLot{}, ErrLotCostAdminOnly
+	}
+	acquiredAt, err := time.Parse("2006-01-02", req.AcquiredAt)
+	if err != nil || acquiredAt.After(time.Now()) || req.Cost.IsNegative() {
+		return models.TaxLot{}, ErrInvalidLotCost
+	}
+	return s.repo.SetLotCost(accountID, lotID, req.Cost.Round(2), acquiredAt)
+}
+
+func (s *taxLotService) checkAccess(accountID, authUserID uuid.UUID, authUserRole string) error {
+	holderID, err := s.repo.GetAccountHolderID(accountID)
+	if err == sql.ErrNoRows {
+		return ErrPnLAccountMissing
+	}
+	if err != nil {
+		return err
+	}
+	if authUserRole != "admin" && holderID != authUserID {
+		return ErrPnLAccessDenied
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"thyra/internal/positions/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// ErrInsufficientLots means the open lots hold less than is being sold or
+// moved, which points at a holding that was changed without its lots.
+var ErrInsufficientLots = errors.New("open tax lots do not cover the quantity")
+
+// TaxLotMethodFromEnv reads the lot method from TAX_LOT_METHOD, defaulting
+// to average cost as Swedish accounts require.
+func TaxLotMethodFromEnv() string {
+	switch value := os.Getenv("TAX_LOT_METHOD"); value {
+	case models.TaxLotMethodFIFO, models.TaxLotMethodAverage:
+		return value
+	case "":
+	default:
+		log.Printf("Ignoring invalid TAX_LOT_METHOD %q", value)
+	}
+	return models.TaxLotMethodAverage
+}
+
+// OpenLot records a new lot with everything still remaining.
+func OpenLot(tx *sqlx.Tx, lot models.TaxLot) error {
+	if lot.ID == uuid.Nil {
+		lot.ID = uuid.New()
+	}
+	lot.Cost = lot.Cost.Round(2)
+	lot.RemainingQuantity = lot.Quantity
+	lot.RemainingCost = lot.Cost
+
+	_, err := tx.NamedExec(`
+        INSERT INTO thyrasec.tax_lots (
+            id, account_id, asset_id, acquired_at, quantity, remaining_quantity, cost, remaining_cost, source, source_id, cost_known
+        )
+        VALUES (
+            :id, :account_id, :asset_id, :acquired_at, :quantity, :remaining_quantity, :cost, :remaining_cost, :source, :source_id, :cost_known
+        )`, lot)
+	return err
+}
+
+// ConsumeLots takes quantity out of the account's open lots of the asset
+// using method and returns what was taken from each lot and the total cost.
+// Lots of unknown date predate every recorded purchase, so they come first.
+func ConsumeLots(tx *sqlx.Tx, accountID, assetID uuid.UUID, quantity decimal.Decimal, method string) ([]models.LotUse, decimal.Decimal, error) {
+	var lots []models.TaxLot
+	err := tx.Select(&lots, `
+        SELECT * FROM thyrasec.tax_lots
+        WHERE account_id = $1 AND asset_id = $2 AND remaining_quantity > 0
+        ORDER BY acquired_at NULLS FIRST, created_at
+        FOR UPDATE`, accountID, assetID)
+	if err != nil {
+		return nil, decimal.Zero, fmt.Errorf("error loading tax lots: %w", err)
+	}
+
+	var uses []models.LotUse
+	switch method {
+	case models.TaxLotMethodFIFO:
+		uses, err = fifoUses(lots, quantity)
+	case models.TaxLotMethodAverage:
+		uses, err = averageUses(lots, quantity)
+	default:
+		err = fmt.Errorf("unknown tax lot method %q", method)
+	}
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	for _, use := range uses {
+		_, err := tx.Exec(`
+            UPDATE thyrasec.tax_lots
+            SET remaining_quantity = remaining_quantity - $1,
+                remaining_cost = remaining_cost - $2,
+                closed_at = CASE WHEN remaining_quantity - $1 = 0 THEN NOW() ELSE closed_at END
+            WHERE id = $3`, use.Quantity, use.Cost, use.TaxLotID)
+		if err != nil {
+			return nil, decimal.Zero, fmt.Errorf("error updating tax lot %s: %w", use.TaxLotID, err)
+		}
+		total = total.Add(use.Cost)
+	}
+	return uses, total, nil
+}
+
+// fifoUses takes whole lots oldest first and the remainder from the next.
+func fifoUses(lots []models.TaxLot, quantity decimal.Decimal) ([]models.LotUse, error) {
+	var uses []models.LotUse
+	left := quantity
+	for _, lot := range lots {
+		if !left.IsPositive() {
+			break
+		}
+		take := decimal.Min(left, lot.RemainingQuantity)
+		cost := lot.RemainingCost
+		if take.LessThan(lot.RemainingQuantity) {
+			cost = lot.RemainingCost.Mul(take).Div(lot.RemainingQuantity).Round(2)
+		}
+		uses = append(uses, models.LotUse{TaxLotID: lot.ID, AcquiredAt: lot.AcquiredAt, Quantity: take, Cost: cost, CostKnown: lot.CostKnown})
+		left = left.Sub(take)
+	}
+	if left.IsPositive() {
+		return nil, ErrInsufficientLots
+	}
+	return uses, nil
+}
+
+// averageUses takes the same share of every open lot, so the cost removed
+// is the quantity at the average cost of the position.
+func averageUses(lots []models.TaxLot, quantity decimal.Decimal) ([]models.LotUse, error) {
+	totalQuantity, totalCost := decimal.Zero, decimal.Zero
+	for _, lot := range lots {
+		totalQuantity = totalQuantity.Add(lot.RemainingQuantity)
+		totalCost = totalCost.Add(lot.RemainingCost)
+	}
+	if totalQuantity.LessThan(quantity) || len(lots) == 0 {
+		return nil, ErrInsufficientLots
+	}
+
+	share := quantity.Div(totalQuantity)
+	wantCost := totalCost.Mul(share).Round(2)
+	if quantity.Equal(totalQuantity) {
+		share, wantCost = decimal.NewFromInt(1), totalCost
+	}
+
+	uses := make([]models.LotUse, 0, len(lots))
+	takenQuantity, takenCost := decimal.Zero, decimal.Zero
+	for i, lot := range lots {
+		take := lot.RemainingQuantity.Mul(share).Round(8)
+		cost := lot.RemainingCost.Mul(share).Round(2)
+		// The last lot absorbs rounding so the totals come out exact.
+		if i == len(lots)-1 {
+			take = decimal.Min(quantity.Sub(takenQuantity), lot.RemainingQuantity)
+			cost = decimal.Min(wantCost.Sub(takenCost), lot.RemainingCost)
+		}
+		takenQuantity = takenQuantity.Add(take)
+		takenCost = takenCost.Add(cost)
+		if take.IsZero() && cost.IsZero() {
+			continue
+		}
+		uses = append(uses, models.LotUse{TaxLotID: lot.ID, AcquiredAt: lot.AcquiredAt, Quantity: take, Cost: cost, CostKnown: lot.CostKnown})
+	}
+	return uses, nil
+}
+
+// RealizeSale consumes lots for a sale and books the realized gain or loss
+// against the proceeds. A sale taking from a lot of unknown cost has no known
+// gain and is recorded with CostKnown false.
+func RealizeSale(tx *sqlx.Tx, accountID, assetID uuid.UUID, orderID *uuid.UUID, quantity, proceeds decimal.Decimal, saleDate time.Time, method string) (models.RealizedGain, error) {
+	uses, cost, err := ConsumeLots(tx, accountID, assetID, quantity, method)
+	if err != nil {
+		return models.RealizedGain{}, err
+	}
+
+	costKnown := true
+	for _, use := range uses {
+		costKnown = costKnown && use.CostKnown
+	}
+
+	proceeds = proceeds.Round(2)
+	gain := models.RealizedGain{
+		ID:          uuid.New(),
+		AccountID:   accountID,
+		AssetID:     assetID,
+		OrderID:     orderID,
+		SaleDate:    saleDate,
+		Quantity:    quantity,
+		Proceeds:    proceeds,
+		CostBasis:   cost,
+		RealizedPnL: proceeds.Sub(cost),
+		Method:      method,
+		CostKnown:   costKnown,
+		CreatedAt:   time.Now(),
+		Lots:        uses,
+	}
+	_, err = tx.NamedExec(`
+        INSERT INTO thyrasec.realized_gains (
+            id, account_id, asset_id, order_id, sale_date, quantity, proceeds, cost_basis, realized_pnl, method, cost_known, created_at
+        )
+        VALUES (
+            :id, :account_id, :asset_id, :order_id, :sale_date, :quantity, :proceeds, :cost_basis, :realized_pnl, :method, :cost_known, :created_at
+        )`, gain)
+	if err != nil {
+		return models.RealizedGain{}, fmt.Errorf("error recording realized gain: %w", err)
+	}
+
+	for _, use := range uses {
+		_, err := tx.Exec(`
+            INSERT INTO thyrasec.realized_gain_lots (realized_gain_id, tax_lot_id, quantity, cost)
+            VALUES ($1, $2, $3, $4)`, gain.ID, use.TaxLotID, use.Quantity, use.Cost)
+		if err != nil {
+			return models.RealizedGain{}, fmt.Errorf("error recording lots of realized gain: %w", err)
+		}
+	}
+	return gain, nil
+}
+
+// TransferLots moves quantity from one account's lots to another's. The new
+// lots keep their acquisition dates, so moving between own accounts does
+// not change what a later sale realizes. It returns the cost moved.
+func TransferLots(tx *sqlx.Tx, fromAccountID, toAccountID, assetID uuid.UUID, quantity decimal.Decimal, method string, source string, sourceID uuid.UUID) (decimal.Decimal, error) {
+	uses, cost, err := ConsumeLots(tx, fromAccountID, assetID, quantity, method)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, use := range uses {
+		lot := models.TaxLot{
+			AccountID:  toAccountID,
+			AssetID:    assetID,
+			AcquiredAt: use.AcquiredAt,
+			Quantity:   use.Quantity,
+			Cost:       use.Cost,
+			Source:     source,
+			SourceID:   &sourceID,
+			CostKnown:  use.CostKnown,
+		}
+		if err := OpenLot(tx, lot); err != nil {
+			return decimal.Zero, err
+		}
+	}
+	return cost, nil
+}
+
+// SyncHoldingCost sets the holding's acquisition cost to the cost left in
+// its open lots.
+func SyncHoldingCost(tx *sqlx.Tx, accountID, assetID uuid.UUID) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.holdings
+        SET acquisition_cost = (
+            SELECT COALESCE(SUM(remaining_cost), 0) FROM thyrasec.tax_lots
+            WHERE account_id = $1 AND asset_id = $2 AND remaining_quantity > 0
+        )
+        WHERE account_id = $1 AND asset_id = $2`, accountID, assetID)
+	return err
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"thyra/internal/positions/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func testLot(quantity, cost string, acquiredAt *time.Time, costKnown bool) models.TaxLot {
+	return models.TaxLot{
+		ID:                uuid.New(),
+		AcquiredAt:        acquiredAt,
+		RemainingQuantity: decimal.RequireFromString(quantity),
+		RemainingCost:     decimal.RequireFromString(cost),
+		CostKnown:         costKnown,
+	}
+}
+
+type wantUse struct {
+	lot      int
+	quantity string
+	cost     string
+}
+
+func checkUses(t *testing.T, lots []models.TaxLot, got []models.LotUse, want []wantUse) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d lot uses, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		use := got[i]
+		if use.TaxLotID != lots[w.lot].ID {
+			t.Errorf("use %d took lot %s, want lot %d", i, use.TaxLotID, w.lot)
+		}
+		if !use.Quantity.Equal(decimal.RequireFromString(w.quantity)) {
+			t.Errorf("use %d quantity = %s, want %s", i, use.Quantity, w.quantity)
+		}
+		if !use.Cost.Equal(decimal.RequireFromString(w.cost)) {
+			t.Errorf("use %d cost = %s, want %s", i, use.Cost, w.cost)
+		}
+		if use.CostKnown != lots[w.lot].CostKnown {
+			t.Errorf("use %d CostKnown = %v, want %v", i, use.CostKnown, lots[w.lot].CostKnown)
+		}
+	}
+}
+
+func TestFIFOUses(t *testing.T) {
+	january := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	lots := []models.TaxLot{
+		testLot("10", "1000", nil, false),
+		testLot("5", "600", &january, true),
+		testLot("3", "100", &march, true),
+	}
+
+	tests := []struct {
+		name     string
+		quantity string
+		want     []wantUse
+		err      error
+	}{
+		{"part of the oldest lot", "4", []wantUse{{0, "4", "400"}}, nil},
+		{"exactly the oldest lot", "10", []wantUse{{0, "10", "1000"}}, nil},
+		{"whole lot and part of the next", "12", []wantUse{{0, "10", "1000"}, {1, "2", "240"}}, nil},
+		{"partial cost is rounded to cents", "11", []wantUse{{0, "10", "1000"}, {1, "1", "120"}}, nil},
+		{"into the last lot", "16", []wantUse{{0, "10", "1000"}, {1, "5", "600"}, {2, "1", "33.33"}}, nil},
+		{"fractional quantity", "0.5", []wantUse{{0, "0.5", "50"}}, nil},
+		{"every lot", "18", []wantUse{{0, "10", "1000"}, {1, "5", "600"}, {2, "3", "100"}}, nil},
+		{"more than the lots hold", "18.00000001", nil, ErrInsufficientLots},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uses, err := fifoUses(lots, decimal.RequireFromString(tt.quantity))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("fifoUses error = %v, want %v", err, tt.err)
+			}
+			checkUses(t, lots, uses, tt.want)
+		})
+	}
+}
+
+func TestAverageUses(t *testing.T) {
+	january := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	march := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		lots     []models.TaxLot
+		quantity string
+		want     []wantUse
+		err      error
+	}{
+		{
+			name:     "same share of every lot",
+			lots:     []models.TaxLot{testLot("10", "1000", &january, true), testLot("10", "1400", &march, true)},
+			quantity: "5",
+			want:     []wantUse{{0, "2.5", "250"}, {1, "2.5", "350"}},
+		},
+		{
+			name:     "last lot absorbs rounding",
+			lots:     []models.TaxLot{testLot("1", "10", &january, true), testLot("1", "10", &january, true), testLot("1", "10", &march, true)},
+			quantity: "1",
+			want:     []wantUse{{0, "0.33333333", "3.33"}, {1, "0.33333333", "3.33"}, {2, "0.33333334", "3.34"}},
+		},
+		{
+			name:     "whole position takes all the cost",
+			lots:     []models.TaxLot{testLot("3", "100", nil, false), testLot("7", "200.01", &march, true)},
+			quantity: "10",
+			want:     []wantUse{{0, "3", "100"}, {1, "7", "200.01"}},
+		},
+		{
+			name:     "more than the lots hold",
+			lots:     []models.TaxLot{testLot("3", "100", &january, true)},
+			quantity: "4",
+			err:      ErrInsufficientLots,
+		},
+		{
+			name:     "no lots",
+			quantity: "1",
+			err:      ErrInsufficientLots,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uses, err := averageUses(tt.lots, decimal.RequireFromString(tt.quantity))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("averageUses error = %v, want %v", err, tt.err)
+			}
+			checkUses(t, tt.lots, uses, tt.want)
+
+			total := decimal.Zero
+			for _, use := range uses {
+				total = total.Add(use.Quantity)
+			}
+			if tt.err == nil && !total.Equal(decimal.RequireFromString(tt.quantity)) {
+				t.Errorf("total quantity taken = %s, want %s", total, tt.quantity)
+			}
+		})
+	}
+}
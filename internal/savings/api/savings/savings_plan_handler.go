@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/savings/models"
+	"thyra/internal/savings/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SavingsPlanHandler struct {
+	service *services.SavingsPlanService
+}
+
+func NewSavingsPlanHandler(service *services.SavingsPlanService) *SavingsPlanHandler {
+	return &SavingsPlanHandler{service: service}
+}
+
+func (h *SavingsPlanHandler) CreatePlan(c *gin.Context) {
+	authUserID, authUserRole, ok := savingsUser(c)
+	if !ok {
+		return
+	}
+	var req models.SavingsPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	plan, err := h.service.CreatePlan(c.Request.Context(), authUserID, authUserRole, req)
+	if err != nil {
+		writeSavingsError(c, err, "Failed to create savings plan")
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+func (h *SavingsPlanHandler) UpdatePlan(c *gin.Context) {
+	authUserID, authUserRole, ok := savingsUser(c)
+	if !ok {
+		return
+	}
+	planID, ok := uuidParam(c, "planId", "Invalid savings plan ID in URL")
+	if !ok {
+		return
+	}
+	var req models.SavingsPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	plan, err := h.service.UpdatePlan(c.Request.Context(), planID, authUserID, authUserRole, req)
+	if err != nil {
+		writeSavingsError(c, err, "Failed to update savings plan")
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *SavingsPlanHandler) GetPlan(c *gin.Context) {
+	authUserID, authUserRole, ok := savingsUser(c)
+	if !ok {
+		return
+	}
+	planID, ok := uuidParam(c, "planId", "Invalid savings plan ID in URL")
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GetPlan(c.Request.Context(), planID, authUserID, authUserRole)
+	if err != nil {
+		writeSavingsError(c, err, "Failed to fetch savings plan")
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *SavingsPlanHandler) GetUserPlans(c *gin.Context) {
+	authUserID, authUserRole, ok := savingsUser(c)
+	if !ok {
+		return
+	}
+	userID, ok := uuidParam(c, "userId", "Invalid user ID in URL")
+	if !ok {
+		return
+	}
+
+	plans, err := h.service.GetUserPlans(c.Request.Context(), userID, authUserID, authUserRole)
+	if err != nil {
+		writeSavingsError(c, err, "Failed to fetch savings plans")
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+func (h *SavingsPlanHandler) SetStatus(c *gin.Context) {
+	authUserID, authUserRole, ok := savingsUser(c)
+	if !ok {
+		return
+	}
+	planID, ok := uuidParam(c, "planId", "Invalid savings plan ID in URL")
+	if !ok {
+		return
+	}
+	var req models.PlanStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	plan, err := h.service.SetStatus(c.Request.Context(), planID, authUserID, authUserRole, req)
+	if err != nil {
+		writeSavingsError(c, err, "Failed to change savings plan status")
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *SavingsPlanHandler) GetRuns(c *gin.Context) {
+	authUserID, authUserRole, ok := savingsUser(c)
+	if !ok {
+		return
+	}
+	planID, ok := uuidParam(c, "planId", "Invalid savings plan ID in URL")
+	if !ok {
+		return
+	}
+
+	runs, err := h.service.GetRuns(c.Request.Context(), planID, authUserID, authUserRole)
+	if err != nil {
+		writeSavingsError(c, err, "Failed to fetch savings plan runs")
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+func savingsUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+	return authUserID, userRole, true
+}
+
+func uuidParam(c *gin.Context, name, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeSavingsError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrSavingsAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrPlanNotFound, services.ErrAccountNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrPlanNameRequired, services.ErrInvalidAmount, services.ErrInvalidDayOfMonth,
+		services.ErrInvalidRoundingRule, services.ErrInvalidAllocations, services.ErrInvalidDate, services.ErrInvalidPlanStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrPlanCancelled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PlanStatusActive    = "active"
+	PlanStatusPaused    = "paused"
+	PlanStatusCancelled = "cancelled"
+
+	// RoundDown buys whole shares for at most each allocation's amount and
+	// leaves the rest in cash. RoundNearest buys the nearest whole number of
+	// shares, so a run may invest up to half a share more or less per
+	// instrument than the plan amount. RoundFractional buys fractions down
+	// to the instrument's quantity precision, investing close to the full
+	// amount in instruments that can be traded in fractions.
+	RoundDown       = "round_down"
+	RoundNearest    = "round_nearest"
+	RoundFractional = "fractional"
+
+	RunStatusRunning           = "running"
+	RunStatusExecuted          = "executed"
+	RunStatusPartiallyExecuted = "partially_executed"
+	RunStatusSkipped           = "skipped"
+	RunStatusFailed            = "failed"
+)
+
+type SavingsPlan struct {
+	ID           uuid.UUID           `db:"id" json:"id"`
+	AccountID    uuid.UUID           `db:"account_id" json:"account_id"`
+	Name         string              `db:"name" json:"name"`
+	Amount       decimal.Decimal     `db:"amount" json:"amount"`
+	DayOfMonth   int                 `db:"day_of_month" json:"day_of_month"`
+	RoundingRule string              `db:"rounding_rule" json:"rounding_rule"`
+	Status       string              `db:"status" json:"status"`
+	StartDate    time.Time           `db:"start_date" json:"start_date"`
+	EndDate      *time.Time          `db:"end_date" json:"end_date,omitempty"`
+	NextRunDate  time.Time           `db:"next_run_date" json:"next_run_date"`
+	Allocations  []SavingsAllocation `db:"-" json:"allocations"`
+	CreatedBy    uuid.UUID           `db:"created_by" json:"created_by"`
+	CreatedAt    time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time           `db:"updated_at" json:"updated_at"`
+}
+
+type SavingsAllocation struct {
+	PlanID  uuid.UUID       `db:"plan_id" json:"-"`
+	AssetID uuid.UUID       `db:"asset_id" json:"asset_id" binding:"required"`
+	Weight  decimal.Decimal `db:"weight" json:"weight"`
+}
+
+// SavingsPlanRequest creates or changes a plan. Dates are YYYY-MM-DD; the
+// start date defaults to today. The account cannot be changed.
+type SavingsPlanRequest struct {
+	AccountID    uuid.UUID           `json:"account_id"`
+	Name         string              `json:"name" binding:"required"`
+	Amount       decimal.Decimal     `json:"amount"`
+	DayOfMonth   int                 `json:"day_of_month" binding:"required"`
+	RoundingRule string              `json:"rounding_rule"`
+	StartDate    *string             `json:"start_date"`
+	EndDate      *string             `json:"end_date"`
+	Allocations  []SavingsAllocation `json:"allocations" binding:"required"`
+}
+
+type PlanStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+type SavingsPlanRun struct {
+	ID             uuid.UUID         `db:"id" json:"id"`
+	PlanID         uuid.UUID         `db:"plan_id" json:"plan_id"`
+	ScheduledDate  time.Time         `db:"scheduled_date" json:"scheduled_date"`
+	Status         string            `db:"status" json:"status"`
+	Message        *string           `db:"message" json:"message,omitempty"`
+	InvestedAmount decimal.Decimal   `db:"invested_amount" json:"invested_amount"`
+	StartedAt      time.Time         `db:"started_at" json:"started_at"`
+	FinishedAt     *time.Time        `db:"finished_at" json:"finished_at,omitempty"`
+	Orders         []SavingsRunOrder `db:"-" json:"orders"`
+}
+
+// SavingsRunOrder is one allocation of a run: the order placed for it, or
+// why none was.
+type SavingsRunOrder struct {
+	ID       uuid.UUID       `db:"id" json:"id"`
+	RunID    uuid.UUID       `db:"run_id" json:"-"`
+	AssetID  uuid.UUID       `db:"asset_id" json:"asset_id"`
+	Quantity decimal.Decimal `db:"quantity" json:"quantity"`
+	Price    decimal.Decimal `db:"price" json:"price"`
+	Amount   decimal.Decimal `db:"amount" json:"amount"`
+	OrderID  *uuid.UUID      `db:"order_id" json:"order_id,omitempty"`
+	Error    *string         `db:"error" json:"error,omitempty"`
+}
+
+// SavingsContact is who to notify about a plan's runs.
+type SavingsContact struct {
+	AccountHolderID uuid.UUID `db:"account_holder_id"`
+	AccountNumber   string    `db:"account_number"`
+	Username        string    `db:"username"`
+	Email           *string   `db:"email"`
+}
+
+type SavingsRunResult struct {
+	PlansDue int
+	Executed int
+	Skipped  int
+	Failed   int
+}
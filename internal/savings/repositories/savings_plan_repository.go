@@ -0,0 +1,270 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/savings/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+type SavingsPlanRepository struct {
+	db *sqlx.DB
+}
+
+func NewSavingsPlanRepository(db *sqlx.DB) *SavingsPlanRepository {
+	return &SavingsPlanRepository{db: db}
+}
+
+func (r *SavingsPlanRepository) GetAccountHolderID(ctx context.Context, accountID uuid.UUID) (uuid.UUID, error) {
+	var holderID uuid.UUID
+	err := r.db.GetContext(ctx, &holderID, `SELECT account_holder_id FROM thyrasec.accounts WHERE id = $1`, accountID)
+	return holderID, err
+}
+
+func (r *SavingsPlanRepository) CountAssets(ctx context.Context, assetIDs []uuid.UUID) (int, error) {
+	ids := make(pq.StringArray, 0, len(assetIDs))
+	for _, id := range assetIDs {
+		ids = append(ids, id.String())
+	}
+	var count int
+	err := r.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM thyrasec.assets WHERE id = ANY($1::uuid[])`, ids)
+	return count, err
+}
+
+// SavePlan inserts or updates the plan and replaces its allocations.
+func (r *SavingsPlanRepository) SavePlan(ctx context.Context, plan models.SavingsPlan) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.savings_plans (
+            id, account_id, name, amount, day_of_month, rounding_rule, status,
+            start_date, end_date, next_run_date, created_by, created_at, updated_at
+        )
+        VALUES (
+            :id, :account_id, :name, :amount, :day_of_month, :rounding_rule, :status,
+            :start_date, :end_date, :next_run_date, :created_by, :created_at, :updated_at
+        )
+        ON CONFLICT (id) DO UPDATE
+        SET name = EXCLUDED.name,
+            amount = EXCLUDED.amount,
+            day_of_month = EXCLUDED.day_of_month,
+            rounding_rule = EXCLUDED.rounding_rule,
+            status = EXCLUDED.status,
+            start_date = EXCLUDED.start_date,
+            end_date = EXCLUDED.end_date,
+            next_run_date = EXCLUDED.next_run_date,
+            updated_at = EXCLUDED.updated_at`, plan)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM thyrasec.savings_plan_allocations WHERE plan_id = $1`, plan.ID); err != nil {
+		return err
+	}
+	for _, allocation := range plan.Allocations {
+		allocation.PlanID = plan.ID
+		_, err := tx.NamedExecContext(ctx, `
+            INSERT INTO thyrasec.savings_plan_allocations (plan_id, asset_id, weight)
+            VALUES (:plan_id, :asset_id, :weight)`, allocation)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *SavingsPlanRepository) UpdateStatus(ctx context.Context, planID uuid.UUID, status string, nextRunDate time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.savings_plans SET status = $2, next_run_date = $3, updated_at = now()
+        WHERE id = $1`, planID, status, nextRunDate)
+	return err
+}
+
+func (r *SavingsPlanRepository) GetPlan(ctx context.Context, planID uuid.UUID) (models.SavingsPlan, error) {
+	var plan models.SavingsPlan
+	if err := r.db.GetContext(ctx, &plan, `SELECT * FROM thyrasec.savings_plans WHERE id = $1`, planID); err != nil {
+		return plan, err
+	}
+	allocations, err := r.getAllocations(ctx, planID)
+	plan.Allocations = allocations
+	return plan, err
+}
+
+// GetUserPlans returns the plans on all of the user's accounts.
+func (r *SavingsPlanRepository) GetUserPlans(ctx context.Context, userID uuid.UUID) ([]models.SavingsPlan, error) {
+	return r.selectPlans(ctx, `
+        SELECT sp.* FROM thyrasec.savings_plans sp
+        JOIN thyrasec.accounts a ON a.id = sp.account_id
+        WHERE a.account_holder_id = $1
+        ORDER BY sp.created_at`, userID)
+}
+
+// GetDuePlans returns the active plans whose next run is on or before the
+// date and not after their end date.
+func (r *SavingsPlanRepository) GetDuePlans(ctx context.Context, date time.Time) ([]models.SavingsPlan, error) {
+	return r.selectPlans(ctx, `
+        SELECT * FROM thyrasec.savings_plans
+        WHERE status = 'active' AND next_run_date <= $1
+          AND (end_date IS NULL OR next_run_date <= end_date)
+        ORDER BY next_run_date, id`, date)
+}
+
+func (r *SavingsPlanRepository) selectPlans(ctx context.Context, query string, args ...interface{}) ([]models.SavingsPlan, error) {
+	plans := []models.SavingsPlan{}
+	if err := r.db.SelectContext(ctx, &plans, query, args...); err != nil {
+		return nil, err
+	}
+	for i := range plans {
+		allocations, err := r.getAllocations(ctx, plans[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		plans[i].Allocations = allocations
+	}
+	return plans, nil
+}
+
+func (r *SavingsPlanRepository) getAllocations(ctx context.Context, planID uuid.UUID) ([]models.SavingsAllocation, error) {
+	allocations := []models.SavingsAllocation{}
+	err := r.db.SelectContext(ctx, &allocations, `
+        SELECT * FROM thyrasec.savings_plan_allocations
+        WHERE plan_id = $1
+        ORDER BY weight DESC, asset_id`, planID)
+	return allocations, err
+}
+
+// GetPrices returns the price orders are placed at for each asset.
+func (r *SavingsPlanRepository) GetPrices(ctx context.Context, assetIDs []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	ids := make(pq.StringArray, 0, len(assetIDs))
+	for _, id := range assetIDs {
+		ids = append(ids, id.String())
+	}
+	rows := []struct {
+		AssetID uuid.UUID       `db:"asset_id"`
+		Price   decimal.Decimal `db:"price"`
+	}{}
+	err := r.db.SelectContext(ctx, &rows, `
+        SELECT a.id AS asset_id, COALESCE(a.current_price,
+            (SELECT ap.price FROM thyrasec.asset_prices ap
+             WHERE ap.asset_id = a.id
+             ORDER BY ap.price_date DESC LIMIT 1), 0) AS price
+        FROM thyrasec.assets a
+        WHERE a.id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[uuid.UUID]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		prices[row.AssetID] = row.Price
+	}
+	return prices, nil
+}
+
+// GetQuantityPrecisions returns the decimals each asset can be traded in.
+func (r *SavingsPlanRepository) GetQuantityPrecisions(ctx context.Context, assetIDs []uuid.UUID) (map[uuid.UUID]int32, error) {
+	ids := make(pq.StringArray, 0, len(assetIDs))
+	for _, id := range assetIDs {
+		ids = append(ids, id.String())
+	}
+	rows := []struct {
+		AssetID   uuid.UUID `db:"id"`
+		Precision int32     `db:"quantity_precision"`
+	}{}
+	err := r.db.SelectContext(ctx, &rows, `
+        SELECT id, quantity_precision FROM thyrasec.assets WHERE id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return nil, err
+	}
+	precisions := make(map[uuid.UUID]int32, len(rows))
+	for _, row := range rows {
+		precisions[row.AssetID] = row.Precision
+	}
+	return precisions, nil
+}
+
+// StartRun records the run and moves the plan on to its next run date in
+// one transaction. It reports false if the plan already has a run for the
+// scheduled date or is no longer due.
+func (r *SavingsPlanRepository) StartRun(ctx context.Context, run models.SavingsPlanRun, nextRunDate time.Time) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+        UPDATE thyrasec.savings_plans SET next_run_date = $3
+        WHERE id = $1 AND next_run_date = $2 AND status = 'active'`, run.PlanID, run.ScheduledDate, nextRunDate)
+	if err != nil {
+		return false, err
+	}
+	if moved, err := result.RowsAffected(); err != nil || moved == 0 {
+		return false, err
+	}
+
+	result, err = tx.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.savings_plan_runs (id, plan_id, scheduled_date, status, started_at)
+        VALUES (:id, :plan_id, :scheduled_date, :status, :started_at)
+        ON CONFLICT (plan_id, scheduled_date) DO NOTHING`, run)
+	if err != nil {
+		return false, err
+	}
+	if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *SavingsPlanRepository) InsertRunOrder(ctx context.Context, order models.SavingsRunOrder) error {
+	_, err := r.db.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.savings_plan_run_orders (id, run_id, asset_id, quantity, price, amount, order_id, error)
+        VALUES (:id, :run_id, :asset_id, :quantity, :price, :amount, :order_id, :error)`, order)
+	return err
+}
+
+func (r *SavingsPlanRepository) FinishRun(ctx context.Context, run models.SavingsPlanRun) error {
+	_, err := r.db.NamedExecContext(ctx, `
+        UPDATE thyrasec.savings_plan_runs
+        SET status = :status, message = :message, invested_amount = :invested_amount, finished_at = :finished_at
+        WHERE id = :id`, run)
+	return err
+}
+
+func (r *SavingsPlanRepository) GetRuns(ctx context.Context, planID uuid.UUID) ([]models.SavingsPlanRun, error) {
+	runs := []models.SavingsPlanRun{}
+	err := r.db.SelectContext(ctx, &runs, `
+        SELECT * FROM thyrasec.savings_plan_runs
+        WHERE plan_id = $1
+        ORDER BY scheduled_date DESC`, planID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		orders := []models.SavingsRunOrder{}
+		err := r.db.SelectContext(ctx, &orders, `
+            SELECT * FROM thyrasec.savings_plan_run_orders WHERE run_id = $1 ORDER BY amount DESC`, runs[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		runs[i].Orders = orders
+	}
+	return runs, nil
+}
+
+func (r *SavingsPlanRepository) GetContact(ctx context.Context, accountID uuid.UUID) (models.SavingsContact, error) {
+	var contact models.SavingsContact
+	err := r.db.GetContext(ctx, &contact, `
+        SELECT a.account_holder_id, COALESCE(a.account_number, '') AS account_number, u.username, u.email
+        FROM thyrasec.accounts a
+        JOIN thyrasec.users u ON u.id = a.account_holder_id
+        WHERE a.id = $1`, accountID)
+	return contact, err
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	handlers "thyra/internal/savings/api/savings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, planHandler *handlers.SavingsPlanHandler) {
+	router.POST("/savings-plans", planHandler.CreatePlan)
+	router.GET("/savings-plans/:planId", planHandler.GetPlan)
+	router.PUT("/savings-plans/:planId", planHandler.UpdatePlan)
+	router.PUT("/savings-plans/:planId/status", planHandler.SetStatus)
+	router.GET("/savings-plans/:planId/runs", planHandler.GetRuns)
+	router.GET("/user/:userId/savings-plans", planHandler.GetUserPlans)
+}
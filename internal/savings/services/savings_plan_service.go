@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"thyra/internal/savings/models"
+	"thyra/internal/savings/repositories"
+	savingsutils "thyra/internal/savings/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrSavingsAccessDenied = errors.New("not allowed to manage savings plans on this account")
+	ErrPlanNotFound        = errors.New("savings plan not found")
+	ErrAccountNotFound     = errors.New("account not found")
+	ErrPlanNameRequired    = errors.New("name is required")
+	ErrInvalidAmount       = errors.New("amount must be above 0")
+	ErrInvalidDayOfMonth   = errors.New("day_of_month must be between 1 and 31")
+	ErrInvalidRoundingRule = errors.New("rounding_rule must be round_down, round_nearest or fractional")
+	ErrInvalidAllocations  = errors.New("allocations must list existing instruments once each, with weights above 0 adding up to 1")
+	ErrInvalidDate         = errors.New("dates must be YYYY-MM-DD and end_date must not be before start_date")
+	ErrInvalidPlanStatus   = errors.New("status must be active, paused or cancelled")
+	ErrPlanCancelled       = errors.New("savings plan is cancelled")
+
+	// weightTolerance absorbs rounding in weights such as three thirds.
+	weightTolerance = decimal.NewFromFloat(0.0001)
+)
+
+type SavingsPlanService struct {
+	repo *repositories.SavingsPlanRepository
+}
+
+func NewSavingsPlanService(repo *repositories.SavingsPlanRepository) *SavingsPlanService {
+	return &SavingsPlanService{repo: repo}
+}
+
+func (s *SavingsPlanService) CreatePlan(ctx context.Context, authUserID uuid.UUID, authUserRole string, req models.SavingsPlanRequest) (models.SavingsPlan, error) {
+	if err := s.checkAccountAccess(ctx, req.AccountID, authUserID, authUserRole); err != nil {
+		return models.SavingsPlan{}, err
+	}
+	now := time.Now()
+	plan := models.SavingsPlan{
+		ID:        uuid.New(),
+		AccountID: req.AccountID,
+		Status:    models.PlanStatusActive,
+		CreatedBy: authUserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.apply(ctx, &plan, req, now); err != nil {
+		return models.SavingsPlan{}, err
+	}
+	if err := s.repo.SavePlan(ctx, plan); err != nil {
+		return models.SavingsPlan{}, err
+	}
+	return plan, nil
+}
+
+// UpdatePlan replaces the plan's definition from its next run on.
+func (s *SavingsPlanService) UpdatePlan(ctx context.Context, planID, authUserID uuid.UUID, authUserRole string, req models.SavingsPlanRequest) (models.SavingsPlan, error) {
+	plan, err := s.GetPlan(ctx, planID, authUserID, authUserRole)
+	if err != nil {
+		return models.SavingsPlan{}, err
+	}
+	if plan.Status == models.PlanStatusCancelled {
+		return models.SavingsPlan{}, ErrPlanCancelled
+	}
+	now := time.Now()
+	if err := s.apply(ctx, &plan, req, now); err != nil {
+		return models.SavingsPlan{}, err
+	}
+	plan.UpdatedAt = now
+	if err := s.repo.SavePlan(ctx, plan); err != nil {
+		return models.SavingsPlan{}, err
+	}
+	return plan, nil
+}
+
+func (s *SavingsPlanService) GetPlan(ctx context.Context, planID, authUserID uuid.UUID, authUserRole string) (models.SavingsPlan, error) {
+	plan, err := s.repo.GetPlan(ctx, planID)
+	if err == sql.ErrNoRows {
+		return plan, ErrPlanNotFound
+	}
+	if err != nil {
+		return plan, err
+	}
+	if err := s.checkAccountAccess(ctx, plan.AccountID, authUserID, authUserRole); err != nil {
+		return models.SavingsPlan{}, err
+	}
+	return plan, nil
+}
+
+func (s *SavingsPlanService) GetUserPlans(ctx context.Context, userID, authUserID uuid.UUID, authUserRole string) ([]models.SavingsPlan, error) {
+	if !canManageSavings(authUserRole) && userID != authUserID {
+		return nil, ErrSavingsAccessDenied
+	}
+	return s.repo.GetUserPlans(ctx, userID)
+}
+
+// SetStatus pauses, resumes or cancels the plan. A resumed plan runs next on
+// its day of the month from today; runs missed while paused are not caught
+// up. Cancelling is final.
+func (s *SavingsPlanService) SetStatus(ctx context.Context, planID, authUserID uuid.UUID, authUserRole string, req models.PlanStatusRequest) (models.SavingsPlan, error) {
+	plan, err := s.GetPlan(ctx, planID, authUserID, authUserRole)
+	if err != nil {
+		return models.SavingsPlan{}, err
+	}
+	switch req.Status {
+	case models.PlanStatusActive, models.PlanStatusPaused, models.PlanStatusCancelled:
+	default:
+		return models.SavingsPlan{}, ErrInvalidPlanStatus
+	}
+	if plan.Status == models.PlanStatusCancelled {
+		return models.SavingsPlan{}, ErrPlanCancelled
+	}
+
+	if req.Status == models.PlanStatusActive && plan.Status != models.PlanStatusActive {
+		plan.NextRunDate = firstRunDate(plan.DayOfMonth, plan.StartDate, time.Now())
+	}
+	plan.Status = req.Status
+	if err := s.repo.UpdateStatus(ctx, plan.ID, plan.Status, plan.NextRunDate); err != nil {
+		return models.SavingsPlan{}, err
+	}
+	plan.UpdatedAt = time.Now()
+	return plan, nil
+}
+
+// GetRuns returns the plan's run log, newest first.
+func (s *SavingsPlanService) GetRuns(ctx context.Context, planID, authUserID uuid.UUID, authUserRole string) ([]models.SavingsPlanRun, error) {
+	if _, err := s.GetPlan(ctx, planID, authUserID, authUserRole); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRuns(ctx, planID)
+}
+
+// checkAccountAccess lets account holders manage plans on their own
+// accounts and advisors and admins on any account.
+func (s *SavingsPlanService) checkAccountAccess(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole string) error {
+	holderID, err := s.repo.GetAccountHolderID(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !canManageSavings(authUserRole) && holderID != authUserID {
+		return ErrSavingsAccessDenied
+	}
+	return nil
+}
+
+// apply validates the request and copies it onto the plan.
+func (s *SavingsPlanService) apply(ctx context.Context, plan *models.SavingsPlan, req models.SavingsPlanRequest, now time.Time) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ErrPlanNameRequired
+	}
+	if !req.Amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+	if req.DayOfMonth < 1 || req.DayOfMonth > 31 {
+		return ErrInvalidDayOfMonth
+	}
+	roundingRule := req.RoundingRule
+	if roundingRule == "" {
+		roundingRule = models.RoundDown
+	}
+	switch roundingRule {
+	case models.RoundDown, models.RoundNearest, models.RoundFractional:
+	default:
+		return ErrInvalidRoundingRule
+	}
+
+	startDate := now.UTC().Truncate(24 * time.Hour)
+	if !plan.StartDate.IsZero() {
+		startDate = plan.StartDate
+	}
+	if req.StartDate != nil {
+		parsed, err := time.Parse("2006-01-02", *req.StartDate)
+		if err != nil {
+			return ErrInvalidDate
+		}
+		startDate = parsed
+	}
+	var endDate *time.Time
+	if req.EndDate != nil {
+		parsed, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil || parsed.Before(startDate) {
+			return ErrInvalidDate
+		}
+		endDate = &parsed
+	}
+
+	if len(req.Allocations) == 0 {
+		return ErrInvalidAllocations
+	}
+	seen := map[uuid.UUID]bool{}
+	assetIDs := make([]uuid.UUID, 0, len(req.Allocations))
+	total := decimal.Zero
+	for _, allocation := range req.Allocations {
+		if seen[allocation.AssetID] || !allocation.Weight.IsPositive() || allocation.Weight.GreaterThan(decimal.NewFromInt(1)) {
+			return ErrInvalidAllocations
+		}
+		seen[allocation.AssetID] = true
+		assetIDs = append(assetIDs, allocation.AssetID)
+		total = total.Add(allocation.Weight)
+	}
+	if total.Sub(decimal.NewFromInt(1)).Abs().GreaterThan(weightTolerance) {
+		return ErrInvalidAllocations
+	}
+	count, err := s.repo.CountAssets(ctx, assetIDs)
+	if err != nil {
+		return err
+	}
+	if count != len(assetIDs) {
+		return ErrInvalidAllocations
+	}
+
+	plan.Name = name
+	plan.Amount = req.Amount
+	plan.DayOfMonth = req.DayOfMonth
+	plan.RoundingRule = roundingRule
+	plan.StartDate = startDate
+	plan.EndDate = endDate
+	plan.NextRunDate = firstRunDate(req.DayOfMonth, startDate, now)
+	plan.Allocations = req.Allocations
+	for i := range plan.Allocations {
+		plan.Allocations[i].PlanID = plan.ID
+	}
+	return nil
+}
+
+// firstRunDate is the plan's next run from today, or from its start date if
+// that is later.
+func firstRunDate(dayOfMonth int, startDate, now time.Time) time.Time {
+	from := now.UTC()
+	if startDate.After(from) {
+		from = startDate
+	}
+	return savingsutils.NextRunDate(dayOfMonth, from)
+}
+
+func canManageSavings(authUserRole string) bool {
+	return authUserRole == "admin" || authUserRole == "partner_advisor"
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	accountutils "thyra/internal/accounts/utils"
+	"thyra/internal/common/mailer"
+	ordermodels "thyra/internal/orders/models"
+	"thyra/internal/savings/models"
+	"thyra/internal/savings/repositories"
+	savingsutils "thyra/internal/savings/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// OrderPlacer places orders the way clients and advisors place them.
+type OrderPlacer interface {
+	CreateBuyOrder(newOrder ordermodels.Order) (ordermodels.Order, error)
+	GetOrderTypeByName(name string) (uuid.UUID, error)
+}
+
+// SavingsRunService executes due savings plans. Each plan date is run at
+// most once: the run is recorded and the plan moved on to its next date
+// before any order is placed.
+type SavingsRunService struct {
+	db     sqlx.Queryer
+	repo   *repositories.SavingsPlanRepository
+	orders OrderPlacer
+	mailer mailer.Mailer
+}
+
+func NewSavingsRunService(db sqlx.Queryer, repo *repositories.SavingsPlanRepository, orders OrderPlacer, mail mailer.Mailer) *SavingsRunService {
+	return &SavingsRunService{db: db, repo: repo, orders: orders, mailer: mail}
+}
+
+// RunDuePlans runs every plan due on or before now. A plan whose account
+// lacks the cash for the full amount is skipped and the holder notified.
+func (s *SavingsRunService) RunDuePlans(ctx context.Context, now time.Time) (models.SavingsRunResult, error) {
+	var result models.SavingsRunResult
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	plans, err := s.repo.GetDuePlans(ctx, today)
+	if err != nil {
+		return result, err
+	}
+	result.PlansDue = len(plans)
+
+	for _, plan := range plans {
+		status, err := s.runPlan(ctx, plan, today)
+		if err != nil {
+			log.Printf("Error running savings plan %s: %v", plan.ID, err)
+			result.Failed++
+			continue
+		}
+		switch status {
+		case models.RunStatusExecuted, models.RunStatusPartiallyExecuted:
+			result.Executed++
+		case models.RunStatusSkipped:
+			result.Skipped++
+		case models.RunStatusFailed:
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// runPlan runs the plan for its scheduled date and returns the run status,
+// or "" if another run already claimed the date.
+func (s *SavingsRunService) runPlan(ctx context.Context, plan models.SavingsPlan, today time.Time) (string, error) {
+	run := models.SavingsPlanRun{
+		ID:             uuid.New(),
+		PlanID:         plan.ID,
+		ScheduledDate:  plan.NextRunDate,
+		Status:         models.RunStatusRunning,
+		InvestedAmount: decimal.Zero,
+		StartedAt:      time.Now(),
+	}
+	claimed, err := s.repo.StartRun(ctx, run, savingsutils.NextRunDate(plan.DayOfMonth, today.AddDate(0, 0, 1)))
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		return "", nil
+	}
+
+	s.execute(ctx, plan, &run)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	if err := s.repo.FinishRun(ctx, run); err != nil {
+		return run.Status, err
+	}
+
+	switch run.Status {
+	case models.RunStatusSkipped:
+		s.notify(ctx, plan, "Savings plan skipped",
+			fmt.Sprintf("Your savings plan %q was not run on %s: %s. Deposit cash before the next run date to keep saving.",
+				plan.Name, run.ScheduledDate.Format("2006-01-02"), *run.Message))
+	case models.RunStatusFailed, models.RunStatusPartiallyExecuted:
+		s.notify(ctx, plan, "Savings plan not fully invested",
+			fmt.Sprintf("Your savings plan %q invested %s of %s on %s. Some orders could not be placed; see the plan's run log for details.",
+				plan.Name, run.InvestedAmount.StringFixed(2), plan.Amount.StringFixed(2), run.ScheduledDate.Format("2006-01-02")))
+	}
+	return run.Status, nil
+}
+
+// execute places the run's orders and sets its status, message and
+// invested amount.
+func (s *SavingsRunService) execute(ctx context.Context, plan models.SavingsPlan, run *models.SavingsPlanRun) {
+	fail := func(status, message string) {
+		run.Status, run.Message = status, &message
+	}
+
+	power, err := accountutils.GetBuyingPower(s.db, plan.AccountID)
+	if err != nil {
+		fail(models.RunStatusFailed, fmt.Sprintf("could not load available cash: %v", err))
+		return
+	}
+	if power.AvailableCash.LessThan(plan.Amount) {
+		fail(models.RunStatusSkipped, fmt.Sprintf("available cash %s is below the plan amount %s",
+			power.AvailableCash.StringFixed(2), plan.Amount.StringFixed(2)))
+		return
+	}
+
+	assetIDs := make([]uuid.UUID, len(plan.Allocations))
+	for i, allocation := range plan.Allocations {
+		assetIDs[i] = allocation.AssetID
+	}
+	prices, err := s.repo.GetPrices(ctx, assetIDs)
+	if err != nil {
+		fail(models.RunStatusFailed, fmt.Sprintf("could not load prices: %v", err))
+		return
+	}
+	precisions, err := s.repo.GetQuantityPrecisions(ctx, assetIDs)
+	if err != nil {
+		fail(models.RunStatusFailed, fmt.Sprintf("could not load quantity precisions: %v", err))
+		return
+	}
+	holderID, err := s.repo.GetAccountHolderID(ctx, plan.AccountID)
+	if err != nil {
+		fail(models.RunStatusFailed, fmt.Sprintf("could not load account holder: %v", err))
+		return
+	}
+	orderType, err := s.orders.GetOrderTypeByName("order_type_buy")
+	if err != nil {
+		fail(models.RunStatusFailed, fmt.Sprintf("could not load order type: %v", err))
+		return
+	}
+
+	comment := fmt.Sprintf("Savings plan %s", plan.Name)
+	tradeDate := time.Now()
+	placed := 0
+	for _, line := range savingsutils.PlanOrders(plan.Amount, plan.Allocations, prices, precisions, plan.RoundingRule) {
+		line.RunID = run.ID
+		if line.Error == nil {
+			order, err := s.orders.CreateBuyOrder(ordermodels.Order{
+				AccountID:      plan.AccountID,
+				AssetID:        line.AssetID,
+				Quantity:       line.Quantity,
+				PricePerUnit:   line.Price,
+				TotalAmount:    line.Amount,
+				TradeDate:      tradeDate,
+				SettlementDate: tradeDate.AddDate(0, 0, 2),
+				Comment:        &comment,
+				OwnerID:        holderID,
+				OrderType:      orderType,
+				RequestedBy:    plan.CreatedBy,
+			})
+			if err != nil {
+				message := err.Error()
+				line.Error = &message
+			} else {
+				line.OrderID = &order.ID
+				run.InvestedAmount = run.InvestedAmount.Add(line.Amount)
+				placed++
+			}
+		}
+		if err := s.repo.InsertRunOrder(ctx, line); err != nil {
+			log.Printf("Failed to record savings plan order for run %s: %v", run.ID, err)
+		}
+		run.Orders = append(run.Orders, line)
+	}
+
+	switch {
+	case placed == 0:
+		fail(models.RunStatusFailed, "no orders could be placed")
+	case placed < len(run.Orders):
+		fail(models.RunStatusPartiallyExecuted, fmt.Sprintf("%d of %d orders placed", placed, len(run.Orders)))
+	default:
+		run.Status = models.RunStatusExecuted
+	}
+}
+
+func (s *SavingsRunService) notify(ctx context.Context, plan models.SavingsPlan, subject, body string) {
+	if s.mailer == nil {
+		return
+	}
+	contact, err := s.repo.GetContact(ctx, plan.AccountID)
+	if err != nil {
+		log.Printf("Error loading contact for savings plan notification on account %s: %v", plan.AccountID, err)
+		return
+	}
+	if contact.Email == nil || *contact.Email == "" {
+		log.Printf("No email address for savings plan notification on account %s", plan.AccountID)
+		return
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      *contact.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hi %s,\n\nAccount %s:\n\n%s", contact.Username, contact.AccountNumber, body),
+	})
+	if err != nil {
+		log.Printf("Error sending savings plan notification for account %s: %v", plan.AccountID, err)
+	}
+}
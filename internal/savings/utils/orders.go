@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"thyra/internal/savings/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	errNoPrice       = "instrument has no price to buy at"
+	errBelowOneShare = "allocated amount does not buy the smallest tradable quantity"
+)
+
+// PlanOrders splits the amount over the allocations by weight and turns
+// each part into a quantity at the instrument's price under the rounding
+// rule. precisions holds the decimals each instrument can be traded in, used
+// by the fractional rule. Allocations that cannot be bought are returned with
+// a zero quantity and the reason.
+func PlanOrders(amount decimal.Decimal, allocations []models.SavingsAllocation, prices map[uuid.UUID]decimal.Decimal, precisions map[uuid.UUID]int32, roundingRule string) []models.SavingsRunOrder {
+	orders := make([]models.SavingsRunOrder, 0, len(allocations))
+	for _, allocation := range allocations {
+		order := models.SavingsRunOrder{ID: uuid.New(), AssetID: allocation.AssetID, Quantity: decimal.Zero, Amount: decimal.Zero}
+		price := prices[allocation.AssetID]
+		order.Price = price
+		if !price.IsPositive() {
+			message := errNoPrice
+			order.Error = &message
+			orders = append(orders, order)
+			continue
+		}
+
+		shares := amount.Mul(allocation.Weight).Div(price)
+		switch roundingRule {
+		case models.RoundNearest:
+			order.Quantity = shares.Round(0)
+		case models.RoundFractional:
+			order.Quantity = shares.Truncate(precisions[allocation.AssetID])
+		default:
+			order.Quantity = shares.Floor()
+		}
+		if !order.Quantity.IsPositive() {
+			message := errBelowOneShare
+			order.Error = &message
+			orders = append(orders, order)
+			continue
+		}
+		order.Amount = order.Quantity.Mul(price).Round(2)
+		orders = append(orders, order)
+	}
+	return orders
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "time"
+
+// NextRunDate returns the first date on or after from that falls on the
+// plan's day of the month, or on the last day of months too short for it.
+func NextRunDate(dayOfMonth int, from time.Time) time.Time {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	for month := 0; ; month++ {
+		first := time.Date(from.Year(), from.Month()+time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		day := dayOfMonth
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		runDate := first.AddDate(0, 0, day-1)
+		if !runDate.Before(from) {
+			return runDate
+		}
+	}
+}
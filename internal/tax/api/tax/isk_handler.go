@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"thyra/internal/tax/models"
+	"thyra/internal/tax/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type IskHandler struct {
+	service *services.IskService
+}
+
+func NewIskHandler(service *services.IskService) *IskHandler {
+	return &IskHandler{service: service}
+}
+
+func (h *IskHandler) GetRates(c *gin.Context) {
+	if _, _, ok := taxUser(c); !ok {
+		return
+	}
+
+	rates, err := h.service.GetRates(c.Request.Context())
+	if err != nil {
+		writeTaxError(c, err, "Failed to fetch ISK tax rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SetRate stores the government borrowing rate and tax-free amount for a tax year.
+func (h *IskHandler) SetRate(c *gin.Context) {
+	authUserID, authUserRole, ok := taxUser(c)
+	if !ok {
+		return
+	}
+	taxYear, ok := taxYearParam(c)
+	if !ok {
+		return
+	}
+	var req models.IskTaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	rate, err := h.service.SetRate(c.Request.Context(), taxYear, authUserID, authUserRole, req)
+	if err != nil {
+		writeTaxError(c, err, "Failed to set ISK tax rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// GetSummary returns the customer's ISK capital base and standard income. For
+// the running year the figures are preliminary.
+func (h *IskHandler) GetSummary(c *gin.Context) {
+	authUserID, authUserRole, ok := taxUser(c)
+	if !ok {
+		return
+	}
+	taxYear, ok := taxYearParam(c)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID in URL"})
+		return
+	}
+
+	summary, err := h.service.GetSummary(c.Request.Context(), userID, taxYear, authUserID, authUserRole)
+	if err != nil {
+		writeTaxError(c, err, "Failed to compute ISK tax summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// GetStatement downloads the customer's control statement file for an ended tax year.
+func (h *IskHandler) GetStatement(c *gin.Context) {
+	authUserID, authUserRole, ok := taxUser(c)
+	if !ok {
+		return
+	}
+	taxYear, ok := taxYearParam(c)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID in URL"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.service.ExportStatements(c.Request.Context(), &buf, taxYear, &userID, authUserID, authUserRole); err != nil {
+		writeTaxError(c, err, "Failed to export ISK control statement")
+		return
+	}
+
+	writeStatementFile(c, fmt.Sprintf("ku-isk-%d-%s.txt", taxYear, userID), buf.Bytes())
+}
+
+// GetStatements downloads the control statements of every ISK holder for an
+// ended tax year. Admin only.
+func (h *IskHandler) GetStatements(c *gin.Context) {
+	authUserID, authUserRole, ok := taxUser(c)
+	if !ok {
+		return
+	}
+	taxYear, ok := taxYearParam(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.service.ExportStatements(c.Request.Context(), &buf, taxYear, nil, authUserID, authUserRole); err != nil {
+		writeTaxError(c, err, "Failed to export ISK control statements")
+		return
+	}
+
+	writeStatementFile(c, fmt.Sprintf("ku-isk-%d.txt", taxYear), buf.Bytes())
+}
+
+func writeStatementFile(c *gin.Context, filename string, content []byte) {
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", content)
+}
+
+func taxUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+	return authUserID, userRole, true
+}
+
+func taxYearParam(c *gin.Context) (int, bool) {
+	taxYear, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tax year in URL"})
+		return 0, false
+	}
+	return taxYear, true
+}
+
+func writeTaxError(c *gin.Context, err error, message string) {
+	switch err {
+	case services.ErrTaxAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrTaxRateNotFound, services.ErrTaxHolderNotFound, services.ErrNoIskAccounts:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidTaxYear, services.ErrInvalidTaxRate:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrTaxYearNotEnded:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
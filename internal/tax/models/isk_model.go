@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const TaxRegimeISK = "isk"
+
+// ISK standard income is the capital base times the government borrowing
+// rate plus one percentage point, at least 1.25 percent, taxed as capital
+// income at 30 percent.
+var (
+	StandardIncomeRateAddition = decimal.NewFromInt(1)
+	StandardIncomeRateFloor    = decimal.NewFromFloat(1.25)
+	CapitalIncomeTaxRate       = decimal.NewFromInt(30)
+)
+
+type IskTaxRate struct {
+	TaxYear                 int             `db:"tax_year" json:"tax_year"`
+	GovernmentBorrowingRate decimal.Decimal `db:"government_borrowing_rate" json:"government_borrowing_rate"`
+	TaxFreeAmount           decimal.Decimal `db:"tax_free_amount" json:"tax_free_amount"`
+	UpdatedBy               *uuid.UUID      `db:"updated_by" json:"updated_by"`
+	UpdatedAt               time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+type IskTaxRateRequest struct {
+	GovernmentBorrowingRate decimal.Decimal `json:"government_borrowing_rate"`
+	TaxFreeAmount           decimal.Decimal `json:"tax_free_amount"`
+}
+
+type IskAccount struct {
+	ID              uuid.UUID       `db:"id"`
+	AccountHolderID uuid.UUID       `db:"account_holder_id"`
+	AccountNumber   string          `db:"account_number"`
+	AccountBalance  decimal.Decimal `db:"account_balance"`
+}
+
+type IskHolder struct {
+	UserID     uuid.UUID `db:"user_id"`
+	NationalID string    `db:"national_id"`
+	FullName   string    `db:"full_name"`
+}
+
+// IskQuarterValue is an account's market value at the start of a quarter.
+// Values not captured on the day are computed from holdings snapshots and
+// prices and marked Estimated, as they leave out cash.
+type IskQuarterValue struct {
+	AccountID       uuid.UUID       `db:"account_id" json:"-"`
+	QuarterDate     time.Time       `db:"quarter_date" json:"quarter_date"`
+	SecuritiesValue decimal.Decimal `db:"securities_value" json:"securities_value"`
+	CashValue       decimal.Decimal `db:"cash_value" json:"cash_value"`
+	TotalValue      decimal.Decimal `db:"total_value" json:"total_value"`
+	CashEstimated   bool            `db:"cash_estimated" json:"cash_estimated"`
+	CapturedAt      time.Time       `db:"captured_at" json:"captured_at"`
+	Estimated       bool            `db:"-" json:"estimated"`
+}
+
+type IskAccountBase struct {
+	AccountID      uuid.UUID         `json:"account_id"`
+	AccountNumber  string            `json:"account_number"`
+	Quarters       []IskQuarterValue `json:"quarters"`
+	QuarterSum     decimal.Decimal   `json:"quarter_sum"`
+	Deposits       decimal.Decimal   `json:"deposits"`
+	CapitalBase    decimal.Decimal   `json:"capital_base"`
+	TaxFreeAmount  decimal.Decimal   `json:"tax_free_amount"`
+	TaxableBase    decimal.Decimal   `json:"taxable_base"`
+	StandardIncome decimal.Decimal   `json:"standard_income"`
+}
+
+// IskTaxSummary is a customer's ISK taxation for one year. It is
+// Preliminary while the year has quarters left.
+type IskTaxSummary struct {
+	UserID                  uuid.UUID        `json:"user_id"`
+	TaxYear                 int              `json:"tax_year"`
+	NationalID              string           `json:"national_id"`
+	FullName                string           `json:"full_name"`
+	GovernmentBorrowingRate decimal.Decimal  `json:"government_borrowing_rate"`
+	StandardIncomeRate      decimal.Decimal  `json:"standard_income_rate"`
+	TaxFreeAmount           decimal.Decimal  `json:"tax_free_amount"`
+	Accounts                []IskAccountBase `json:"accounts"`
+	CapitalBase             decimal.Decimal  `json:"capital_base"`
+	TaxableBase             decimal.Decimal  `json:"taxable_base"`
+	StandardIncome          decimal.Decimal  `json:"standard_income"`
+	EstimatedTax            decimal.Decimal  `json:"estimated_tax"`
+	Preliminary             bool             `json:"preliminary"`
+}
+
+type IskCaptureResult struct {
+	AccountsChecked int `json:"accounts_checked"`
+	ValuesCaptured  int `json:"values_captured"`
+}
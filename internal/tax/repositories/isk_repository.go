@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/tax/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type IskRepository struct {
+	db *sqlx.DB
+}
+
+func NewIskRepository(db *sqlx.DB) *IskRepository {
+	return &IskRepository{db: db}
+}
+
+func (r *IskRepository) GetRates(ctx context.Context) ([]models.IskTaxRate, error) {
+	rates := []models.IskTaxRate{}
+	err := r.db.SelectContext(ctx, &rates, `SELECT * FROM thyrasec.isk_tax_rates ORDER BY tax_year DESC`)
+	return rates, err
+}
+
+func (r *IskRepository) GetRate(ctx context.Context, taxYear int) (models.IskTaxRate, error) {
+	var rate models.IskTaxRate
+	err := r.db.GetContext(ctx, &rate, `SELECT * FROM thyrasec.isk_tax_rates WHERE tax_year = $1`, taxYear)
+	return rate, err
+}
+
+func (r *IskRepository) SetRate(ctx context.Context, rate models.IskTaxRate) error {
+	_, err := r.db.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.isk_tax_rates (tax_year, government_borrowing_rate, tax_free_amount, updated_by, updated_at)
+        VALUES (:tax_year, :government_borrowing_rate, :tax_free_amount, :updated_by, :updated_at)
+        ON CONFLICT (tax_year) DO UPDATE
+        SET government_borrowing_rate = EXCLUDED.government_borrowing_rate,
+            tax_free_amount = EXCLUDED.tax_free_amount,
+            updated_by = EXCLUDED.updated_by,
+            updated_at = EXCLUDED.updated_at`, rate)
+	return err
+}
+
+// GetIskAccounts returns the ISK accounts open at some point in [start, end),
+// optionally for one holder.
+func (r *IskRepository) GetIskAccounts(ctx context.Context, holderID *uuid.UUID, start, end time.Time) ([]models.IskAccount, error) {
+	accounts := []models.IskAccount{}
+	err := r.db.SelectContext(ctx, &accounts, `
+        SELECT a.id, a.account_holder_id, COALESCE(a.account_number, '') AS account_number, a.account_balance
+        FROM thyrasec.accounts a
+        JOIN thyrasec.account_types at ON at.id = a.account_type
+        WHERE at.tax_regime = 'isk'
+            AND ($1::uuid IS NULL OR a.account_holder_id = $1)
+            AND a.created_at < $3
+            AND (a.closed_at IS NULL OR a.closed_at >= $2)
+        ORDER BY a.account_holder_id, a.account_number`, holderID, start, end)
+	return accounts, err
+}
+
+func (r *IskRepository) GetHolder(ctx context.Context, userID uuid.UUID) (models.IskHolder, error) {
+	var holder models.IskHolder
+	err := r.db.GetContext(ctx, &holder, `
+        SELECT u.id AS user_id, COALESCE(k.national_id, '') AS national_id,
+            COALESCE(cp.full_name, u.username) AS full_name
+        FROM thyrasec.users u
+        LEFT JOIN thyrasec.kyc_profiles k ON k.user_id = u.id
+        LEFT JOIN thyrasec.customer_profiles cp ON cp.user_id = u.id
+        WHERE u.id = $1`, userID)
+	return holder, err
+}
+
+func (r *IskRepository) GetQuarterValues(ctx context.Context, accountID uuid.UUID, start, end time.Time) ([]models.IskQuarterValue, error) {
+	values := []models.IskQuarterValue{}
+	err := r.db.SelectContext(ctx, &values, `
+        SELECT * FROM thyrasec.isk_quarter_values
+        WHERE account_id = $1 AND quarter_date >= $2 AND quarter_date < $3
+        ORDER BY quarter_date`, accountID, start, end)
+	return values, err
+}
+
+func (r *IskRepository) QuarterValueExists(ctx context.Context, accountID uuid.UUID, quarterDate time.Time) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `
+        SELECT EXISTS (SELECT 1 FROM thyrasec.isk_quarter_values WHERE account_id = $1 AND quarter_date = $2)`,
+		accountID, quarterDate)
+	return exists, err
+}
+
+func (r *IskRepository) InsertQuarterValue(ctx context.Context, value models.IskQuarterValue) error {
+	_, err := r.db.NamedExecContext(ctx, `
+        INSERT INTO thyrasec.isk_quarter_values (
+            account_id, quarter_date, securities_value, cash_value, total_value, cash_estimated, captured_at
+        )
+        VALUES (:account_id, :quarter_date, :securities_value, :cash_value, :total_value, :cash_estimated, :captured_at)
+        ON CONFLICT (account_id, quarter_date) DO NOTHING`, value)
+	return err
+}
+
+// GetSecuritiesValue values the account's latest holdings snapshot on or
+// before date at the latest price on or before date, falling back to the
+// asset's current price.
+func (r *IskRepository) GetSecuritiesValue(ctx context.Context, accountID uuid.UUID, date time.Time) (decimal.Decimal, error) {
+	var value decimal.Decimal
+	err := r.db.GetContext(ctx, &value, `
+        SELECT COALESCE(SUM(hs.quantity * COALESCE(
+            (SELECT ap.price FROM thyrasec.asset_prices ap
+             WHERE ap.asset_id = hs.asset_id AND ap.price_date <= $2
+             ORDER BY ap.price_date DESC LIMIT 1),
+            a.current_price, 0)), 0)
+        FROM thyrasec.holdings_snapshots hs
+        JOIN thyrasec.assets a ON a.id = hs.asset_id
+        WHERE hs.account_id = $1
+            AND hs.snapshot_date = (
+                SELECT MAX(snapshot_date) FROM thyrasec.holdings_snapshots
+                WHERE account_id = $1 AND snapshot_date <= $2
+            )`, accountID, date)
+	return value.Round(2), err
+}
+
+// GetDeposits sums what was paid into the account in [start, end): cash
+// deposits, transfers in and free-of-payment deliveries in, with securities
+// at their price on the day. Transfers from the holder's other ISK accounts
+// are not deposits.
+func (r *IskRepository) GetDeposits(ctx context.Context, accountID uuid.UUID, start, end time.Time) (decimal.Decimal, error) {
+	var deposits decimal.Decimal
+	err := r.db.GetContext(ctx, &deposits, `
+        WITH own_isk_transfers AS (
+            SELECT t.in_transaction_id
+            FROM thyrasec.account_transfers t
+            JOIN thyrasec.accounts fa ON fa.id = t.from_account_id
+            JOIN thyrasec.account_types fat ON fat.id = fa.account_type
+            JOIN thyrasec.accounts ta ON ta.id = t.to_account_id
+            WHERE t.to_account_id = $1 AND t.status = 'completed'
+                AND fat.tax_regime = 'isk' AND fa.account_holder_id = ta.account_holder_id
+        ),
+        cash_in AS (
+            SELECT COALESCE(SUM(tr.cash_amount), 0) AS amount
+            FROM thyrasec.transactions tr
+            JOIN thyrasec.transactions_types tt ON tt.type_id = tr.type
+            WHERE tr.transaction_owner_account_id = $1
+                AND tr.trade_date >= $2 AND tr.trade_date < $3
+                AND NOT tr.canceled AND tr.cash_amount > 0
+                AND (tt.transaction_type_name ILIKE '%deposit%' OR tt.transaction_type_name = 'Cash Transfer In')
+                AND tr.id NOT IN (SELECT in_transaction_id FROM own_isk_transfers WHERE in_transaction_id IS NOT NULL)
+        ),
+        securities_in AS (
+            SELECT COALESCE(SUM(tr.asset_quantity * COALESCE(
+                (SELECT ap.price FROM thyrasec.asset_prices ap
+                 WHERE ap.asset_id = tr.asset_id AND ap.price_date <= tr.trade_date::date
+                 ORDER BY ap.price_date DESC LIMIT 1),
+                a.current_price, 0)), 0) AS amount
+            FROM thyrasec.transactions tr
+            JOIN thyrasec.transactions_types tt ON tt.type_id = tr.type
+            JOIN thyrasec.assets a ON a.id = tr.asset_id
+            WHERE tr.transaction_owner_account_id = $1
+                AND tr.trade_date >= $2 AND tr.trade_date < $3
+                AND NOT tr.canceled AND tr.asset_quantity > 0
+                AND (tt.transaction_type_name = 'Security Transfer In'
+                    OR (tt.transaction_type_name = 'Security Delivery In' AND tr.cash_amount IS NULL))
+                AND tr.id NOT IN (SELECT in_transaction_id FROM own_isk_transfers WHERE in_transaction_id IS NOT NULL)
+        )
+        SELECT (SELECT amount FROM cash_in) + (SELECT amount FROM securities_in)`, accountID, start, end)
+	return deposits.Round(2), err
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	handlers "thyra/internal/tax/api/tax"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, iskHandler *handlers.IskHandler) {
+	router.GET("/tax/isk/rates", iskHandler.GetRates)
+	router.PUT("/tax/isk/rates/:year", iskHandler.SetRate)
+	router.GET("/tax/isk/:year/statements", iskHandler.GetStatements)
+	router.GET("/tax/isk/:year/users/:userId", iskHandler.GetSummary)
+	router.GET("/tax/isk/:year/users/:userId/statement", iskHandler.GetStatement)
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"thyra/internal/tax/models"
+	"thyra/internal/tax/repositories"
+	taxutils "thyra/internal/tax/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrTaxAccessDenied   = errors.New("not allowed to access this tax information")
+	ErrTaxRateNotFound   = errors.New("no ISK tax rate configured for this tax year")
+	ErrInvalidTaxRate    = errors.New("government_borrowing_rate and tax_free_amount must be at least zero")
+	ErrInvalidTaxYear    = errors.New("invalid tax year")
+	ErrTaxYearNotEnded   = errors.New("control statements can only be exported for ended tax years")
+	ErrTaxHolderNotFound = errors.New("user not found")
+	ErrNoIskAccounts     = errors.New("user has no ISK accounts in this tax year")
+	quartersPerYear      = decimal.NewFromInt(4)
+	oneHundred           = decimal.NewFromInt(100)
+	minimumTaxYear       = 2012
+	quarterStartMonths   = []time.Month{time.January, time.April, time.July, time.October}
+	stockholmLocation    = loadStockholm()
+)
+
+func loadStockholm() *time.Location {
+	location, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		log.Printf("Falling back to UTC for ISK quarter dates: %v", err)
+		return time.UTC
+	}
+	return location
+}
+
+type IskService struct {
+	repo *repositories.IskRepository
+}
+
+func NewIskService(repo *repositories.IskRepository) *IskService {
+	return &IskService{repo: repo}
+}
+
+func (s *IskService) GetRates(ctx context.Context) ([]models.IskTaxRate, error) {
+	return s.repo.GetRates(ctx)
+}
+
+func (s *IskService) SetRate(ctx context.Context, taxYear int, authUserID uuid.UUID, authUserRole string, req models.IskTaxRateRequest) (models.IskTaxRate, error) {
+	if authUserRole != "admin" {
+		return models.IskTaxRate{}, ErrTaxAccessDenied
+	}
+	if taxYear < minimumTaxYear {
+		return models.IskTaxRate{}, ErrInvalidTaxYear
+	}
+	if req.GovernmentBorrowingRate.IsNegative() || req.TaxFreeAmount.IsNegative() {
+		return models.IskTaxRate{}, ErrInvalidTaxRate
+	}
+
+	rate := models.IskTaxRate{
+		TaxYear:                 taxYear,
+		GovernmentBorrowingRate: req.GovernmentBorrowingRate,
+		TaxFreeAmount:           req.TaxFreeAmount.Round(2),
+		UpdatedBy:               &authUserID,
+		UpdatedAt:               time.Now(),
+	}
+	if err := s.repo.SetRate(ctx, rate); err != nil {
+		return models.IskTaxRate{}, err
+	}
+	return rate, nil
+}
+
+// CaptureQuarterValues records the market value of every ISK account for
+// each quarter start of the current year that has passed and has no value
+// yet. Only values captured on the quarter date itself include the cash
+// balance as it was on that date.
+func (s *IskService) CaptureQuarterValues(ctx context.Context, now time.Time) (models.IskCaptureResult, error) {
+	var result models.IskCaptureResult
+	today := dateOf(now)
+	start, end := yearBounds(today.Year())
+
+	accounts, err := s.repo.GetIskAccounts(ctx, nil, start, end)
+	if err != nil {
+		return result, err
+	}
+	for _, account := range accounts {
+		result.AccountsChecked++
+		for _, quarterDate := range quarterDates(today.Year()) {
+			if quarterDate.After(today) {
+				break
+			}
+			exists, err := s.repo.QuarterValueExists(ctx, account.ID, quarterDate)
+			if err != nil {
+				return result, err
+			}
+			if exists {
+				continue
+			}
+
+			securities, err := s.repo.GetSecuritiesValue(ctx, account.ID, quarterDate)
+			if err != nil {
+				return result, err
+			}
+			cash := account.AccountBalance.Round(2)
+			value := models.IskQuarterValue{
+				AccountID:       account.ID,
+				QuarterDate:     quarterDate,
+				SecuritiesValue: securities,
+				CashValue:       cash,
+				TotalValue:      securities.Add(cash),
+				CashEstimated:   !quarterDate.Equal(today),
+				CapturedAt:      now,
+			}
+			if err := s.repo.InsertQuarterValue(ctx, value); err != nil {
+				return result, err
+			}
+			result.ValuesCaptured++
+		}
+	}
+	return result, nil
+}
+
+// GetSummary computes a customer's ISK capital base and standard income for
+// the tax year.
+func (s *IskService) GetSummary(ctx context.Context, userID uuid.UUID, taxYear int, authUserID uuid.UUID, authUserRole string) (models.IskTaxSummary, error) {
+	if authUserRole != "admin" && userID != authUserID {
+		return models.IskTaxSummary{}, ErrTaxAccessDenied
+	}
+	rate, err := s.getRate(ctx, taxYear)
+	if err != nil {
+		return models.IskTaxSummary{}, err
+	}
+	start, end := yearBounds(taxYear)
+	accounts, err := s.repo.GetIskAccounts(ctx, &userID, start, end)
+	if err != nil {
+		return models.IskTaxSummary{}, err
+	}
+	if len(accounts) == 0 {
+		return models.IskTaxSummary{}, ErrNoIskAccounts
+	}
+	return s.summarize(ctx, userID, rate, accounts, time.Now())
+}
+
+// ExportStatements writes the control statements for the ended tax year, for
+// one customer or, for admins, for every ISK holder when userID is nil.
+func (s *IskService) ExportStatements(ctx context.Context, w io.Writer, taxYear int, userID *uuid.UUID, authUserID uuid.UUID, authUserRole string) error {
+	if authUserRole != "admin" && (userID == nil || *userID != authUserID) {
+		return ErrTaxAccessDenied
+	}
+	now := time.Now()
+	if taxYear >= dateOf(now).Year() {
+		return ErrTaxYearNotEnded
+	}
+	rate, err := s.getRate(ctx, taxYear)
+	if err != nil {
+		return err
+	}
+
+	start, end := yearBounds(taxYear)
+	accounts, err := s.repo.GetIskAccounts(ctx, userID, start, end)
+	if err != nil {
+		return err
+	}
+	if userID != nil && len(accounts) == 0 {
+		return ErrNoIskAccounts
+	}
+
+	var holders []uuid.UUID
+	byHolder := map[uuid.UUID][]models.IskAccount{}
+	for _, account := range accounts {
+		if _, ok := byHolder[account.AccountHolderID]; !ok {
+			holders = append(holders, account.AccountHolderID)
+		}
+		byHolder[account.AccountHolderID] = append(byHolder[account.AccountHolderID], account)
+	}
+
+	summaries := make([]models.IskTaxSummary, 0, len(holders))
+	for _, holderID := range holders {
+		summary, err := s.summarize(ctx, holderID, rate, byHolder[holderID], now)
+		if err != nil {
+			return err
+		}
+		summaries = append(summaries, summary)
+	}
+	return taxutils.WriteKUStatements(w, taxYear, summaries, now)
+}
+
+func (s *IskService) summarize(ctx context.Context, userID uuid.UUID, rate models.IskTaxRate, accounts []models.IskAccount, now time.Time) (models.IskTaxSummary, error) {
+	holder, err := s.repo.GetHolder(ctx, userID)
+	if err == sql.ErrNoRows {
+		return models.IskTaxSummary{}, ErrTaxHolderNotFound
+	}
+	if err != nil {
+		return models.IskTaxSummary{}, err
+	}
+
+	incomeRate := decimal.Max(rate.GovernmentBorrowingRate.Add(models.StandardIncomeRateAddition), models.StandardIncomeRateFloor)
+	summary := models.IskTaxSummary{
+		UserID:                  userID,
+		TaxYear:                 rate.TaxYear,
+		NationalID:              holder.NationalID,
+		FullName:                holder.FullName,
+		GovernmentBorrowingRate: rate.GovernmentBorrowingRate,
+		StandardIncomeRate:      incomeRate,
+		TaxFreeAmount:           rate.TaxFreeAmount,
+		Accounts:                make([]models.IskAccountBase, 0, len(accounts)),
+	}
+
+	today := dateOf(now)
+	start, end := yearBounds(rate.TaxYear)
+	for _, account := range accounts {
+		base, preliminary, err := s.accountBase(ctx, account, start, end, today)
+		if err != nil {
+			return models.IskTaxSummary{}, err
+		}
+		summary.Preliminary = summary.Preliminary || preliminary
+		summary.CapitalBase = summary.CapitalBase.Add(base.CapitalBase)
+		summary.Accounts = append(summary.Accounts, base)
+	}
+
+	// The tax-free amount applies per person, so it is split over the
+	// accounts in proportion to their capital base.
+	taxFree := decimal.Min(rate.TaxFreeAmount, summary.CapitalBase)
+	summary.TaxableBase = summary.CapitalBase.Sub(taxFree)
+	allocated := decimal.Zero
+	for i := range summary.Accounts {
+		base := &summary.Accounts[i]
+		share := decimal.Zero
+		if summary.CapitalBase.IsPositive() {
+			share = taxFree.Mul(base.CapitalBase).Div(summary.CapitalBase).Round(2)
+		}
+		if i == len(summary.Accounts)-1 {
+			share = taxFree.Sub(allocated)
+		}
+		allocated = allocated.Add(share)
+
+		base.TaxFreeAmount = share
+		base.TaxableBase = base.CapitalBase.Sub(share)
+		base.StandardIncome = base.TaxableBase.Mul(incomeRate).Div(oneHundred).Round(2)
+		summary.StandardIncome = summary.StandardIncome.Add(base.StandardIncome)
+	}
+	summary.EstimatedTax = summary.StandardIncome.Mul(models.CapitalIncomeTaxRate).Div(oneHundred).Round(0)
+	return summary, nil
+}
+
+// accountBase works out the account's capital base: the quarter start
+// values plus the year's deposits, divided by four. Quarters still ahead
+// count as zero and make the result preliminary.
+func (s *IskService) accountBase(ctx context.Context, account models.IskAccount, start, end, today time.Time) (models.IskAccountBase, bool, error) {
+	base := models.IskAccountBase{AccountID: account.ID, AccountNumber: account.AccountNumber}
+	captured, err := s.repo.GetQuarterValues(ctx, account.ID, start, end)
+	if err != nil {
+		return base, false, err
+	}
+	byDate := map[time.Time]models.IskQuarterValue{}
+	for _, value := range captured {
+		byDate[dateOf(value.QuarterDate)] = value
+	}
+
+	preliminary := false
+	for _, quarterDate := range quarterDates(start.Year()) {
+		value, ok := byDate[quarterDate]
+		switch {
+		case ok:
+		case quarterDate.After(today):
+			preliminary = true
+			value = models.IskQuarterValue{QuarterDate: quarterDate, Estimated: true}
+		default:
+			securities, err := s.repo.GetSecuritiesValue(ctx, account.ID, quarterDate)
+			if err != nil {
+				return base, false, err
+			}
+			value = models.IskQuarterValue{
+				QuarterDate:     quarterDate,
+				SecuritiesValue: securities,
+				TotalValue:      securities,
+				CashEstimated:   true,
+				Estimated:       true,
+			}
+		}
+		base.Quarters = append(base.Quarters, value)
+		base.QuarterSum = base.QuarterSum.Add(value.TotalValue)
+	}
+
+	depositsEnd := end
+	if today.Before(end) {
+		depositsEnd = today.AddDate(0, 0, 1)
+		preliminary = true
+	}
+	base.Deposits, err = s.repo.GetDeposits(ctx, account.ID, start, depositsEnd)
+	if err != nil {
+		return base, false, err
+	}
+	base.CapitalBase = base.QuarterSum.Add(base.Deposits).Div(quartersPerYear).Round(2)
+	return base, preliminary, nil
+}
+
+func (s *IskService) getRate(ctx context.Context, taxYear int) (models.IskTaxRate, error) {
+	if taxYear < minimumTaxYear {
+		return models.IskTaxRate{}, ErrInvalidTaxYear
+	}
+	rate, err := s.repo.GetRate(ctx, taxYear)
+	if err == sql.ErrNoRows {
+		return models.IskTaxRate{}, ErrTaxRateNotFound
+	}
+	return rate, err
+}
+
+// dateOf returns midnight UTC of the Swedish calendar date of t, matching
+// how date columns are read back.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.In(stockholmLocation).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func yearBounds(year int) (time.Time, time.Time) {
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func quarterDates(year int) []time.Time {
+	dates := make([]time.Time, len(quarterStartMonths))
+	for i, month := range quarterStartMonths {
+		dates[i] = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return dates
+}
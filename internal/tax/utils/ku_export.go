@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"thyra/internal/tax/models"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// KUFormatVersion identifies the layout written by WriteKUStatements.
+const KUFormatVersion = "1"
+
+// WriteKUStatements writes the annual ISK control statements (kontroll-
+// uppgifter) for the summaries in the following format.
+//
+// The file is UTF-8 text with one record per line, ended by "\n". Fields are
+// separated by ";" and a field never contains ";" or a line break. Amounts
+// are in SEK with two decimals and "." as decimal separator, rates are
+// percentages with four decimals and dates are YYYY-MM-DD.
+//
+// The first record is the header:
+//
+//	H;<format version>;<tax year>;<created at, RFC 3339>
+//
+// followed by one K record per ISK account:
+//
+//	K;<tax year>;<national id>;<name>;<account number>;
+//	  <value 1 Jan>;<value 1 Apr>;<value 1 Jul>;<value 1 Oct>;<deposits>;
+//	  <capital base>;<tax-free amount applied>;<standard income rate>;
+//	  <standard income>
+//
+// (one line, wrapped here). The capital base is the sum of the four quarter
+// values and the deposits divided by four. The tax-free amount is the
+// holder's tax-free capital base split over their accounts by capital base.
+// The last record is the trailer:
+//
+//	T;<number of K records>;<sum of capital base>;<sum of standard income>
+func WriteKUStatements(w io.Writer, taxYear int, summaries []models.IskTaxSummary, createdAt time.Time) error {
+	out := bufio.NewWriter(w)
+	writeRecord(out, "H", KUFormatVersion, fmt.Sprint(taxYear), createdAt.Format(time.RFC3339))
+
+	records := 0
+	capitalBase, standardIncome := decimal.Zero, decimal.Zero
+	for _, summary := range summaries {
+		for _, account := range summary.Accounts {
+			fields := []string{"K", fmt.Sprint(taxYear), summary.NationalID, summary.FullName, account.AccountNumber}
+			for _, quarter := range account.Quarters {
+				fields = append(fields, quarter.TotalValue.StringFixed(2))
+			}
+			fields = append(fields,
+				account.Deposits.StringFixed(2),
+				account.CapitalBase.StringFixed(2),
+				account.TaxFreeAmount.StringFixed(2),
+				summary.StandardIncomeRate.StringFixed(4),
+				account.StandardIncome.StringFixed(2),
+			)
+			writeRecord(out, fields...)
+
+			records++
+			capitalBase = capitalBase.Add(account.CapitalBase)
+			standardIncome = standardIncome.Add(account.StandardIncome)
+		}
+	}
+
+	writeRecord(out, "T", fmt.Sprint(records), capitalBase.StringFixed(2), standardIncome.StringFixed(2))
+	return out.Flush()
+}
+
+func writeRecord(out *bufio.Writer, fields ...string) {
+	for i, field := range fields {
+		fields[i] = strings.NewReplacer(";", " ", "\r", " ", "\n", " ").Replace(field)
+	}
+	out.WriteString(strings.Join(fields, ";"))
+	out.WriteString("\n")
+}
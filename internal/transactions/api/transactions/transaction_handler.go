@@ -2,8 +2,14 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	accountutils "thyra/internal/accounts/utils"
 	"thyra/internal/common/db"
+	compliancemodels "thyra/internal/compliance/models"
+	compliancerepo "thyra/internal/compliance/repositories"
+	complianceservices "thyra/internal/compliance/services"
+	onboardingutils "thyra/internal/onboarding/utils"
 	"thyra/internal/transactions/models"
 	"thyra/internal/transactions/repositories"
 	"thyra/internal/transactions/services"
@@ -46,21 +52,45 @@ func CreateDeposit(c *gin.Context) {
 		return
 	}
 
-	// Create a new repository and service instance
-	repo := repositories.NewTransactionRepository(&sqlx.Tx{})
-	service := services.NewTransactionService(repo)
+	// The KYC and account status checks, the balance updates and the inserts
+	// share one transaction
+	tx, err := database.Beginx()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	repo := repositories.NewTransactionRepository(tx)
+	amlService := complianceservices.NewAMLService(compliancerepo.NewAMLRepository(database))
+	service := services.NewTransactionService(repo, amlService)
 
 	// Use the service to create the deposit
-	debitTransactionID, creditTransactionID, err := service.CreateDeposit(c, userIDStr, newTransaction)
+	clientTransaction, houseAccountID, err := service.CreateDeposit(c, userIDStr, newTransaction)
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
+		var statusErr *accountutils.AccountStatusError
+		if err == onboardingutils.ErrKYCNotApproved || errors.As(err, &statusErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error createdeposit": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Transaction created successfully", "Parent debitTransactionID": debitTransactionID, "Parent CreditTransactionID": creditTransactionID})
+	service.MonitorCash(c, &clientTransaction, compliancemodels.CashDirectionIn)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Transaction created successfully", "Parent debitTransactionID": clientTransaction.Id, "Parent CreditTransactionID": houseAccountID})
 }
 
 func CreateWithdrawal(c *gin.Context) {
+	database := db.GetConnection(c)
+	if database == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection issue"})
+		return
+	}
 
 	// Extract user ID from the context
 	userIDInterface, exists := c.Get("userID")
@@ -82,22 +112,46 @@ func CreateWithdrawal(c *gin.Context) {
 		return
 	}
 
-	// Create a new service instance
-	//tx := db.GetConnection(c)
-	repo := repositories.NewTransactionRepository(&sqlx.Tx{})
-	service := services.NewTransactionService(repo)
+	// The cash check, the balance updates and the inserts share one
+	// transaction so the account rows stay locked until the withdrawal is booked
+	tx, err := database.Beginx()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	repo := repositories.NewTransactionRepository(tx)
+	amlService := complianceservices.NewAMLService(compliancerepo.NewAMLRepository(database))
+	service := services.NewTransactionService(repo, amlService)
 
 	// Use the service to create the withdrawal
-	debitTransactionID, creditTransactionID, err := service.CreateWithdrawal(c, userIDStr, newTransaction)
+	clientTransaction, houseTransactionID, err := service.CreateWithdrawal(c, userIDStr, newTransaction)
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
+		var statusErr *accountutils.AccountStatusError
+		if errors.As(err, &statusErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, accountutils.ErrInsufficientBuyingPower) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Monitoring runs outside the booking transaction, which holds the
+	// account rows the AML event references
+	service.MonitorCash(c, &clientTransaction, compliancemodels.CashDirectionOut)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message":                    "Transaction created successfully",
-		"Parent debitTransactionID":  debitTransactionID,
-		"Parent CreditTransactionID": creditTransactionID,
+		"Parent debitTransactionID":  clientTransaction.Id,
+		"Parent CreditTransactionID": houseTransactionID,
 	})
 }
 
@@ -1,18 +1,25 @@
 package repositories
 
 import (
+	accountutils "thyra/internal/accounts/utils"
+	onboardingutils "thyra/internal/onboarding/utils"
 	"thyra/internal/transactions/models"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
 )
 
 type TransactionRepository interface {
 	InsertTransaction(transaction *models.Transaction) error
 	UpdateAccountBalance(accountID uuid.UUID, newBalance float64, availableBalance float64) error
+	DebitAccountBalance(accountID uuid.UUID, amount float64) error
 	GetAccountBalance(accountID uuid.UUID) (float64, error)
 	GetAccountAvailableBalance(accountID uuid.UUID) (float64, error)
+	CheckAccountKYCApproved(accountID uuid.UUID) error
+	CheckAccountAllows(accountID uuid.UUID, operation string) error
+	CheckCashAvailable(accountID uuid.UUID, amount float64, operation string) error
 }
 
 type transactionRepository struct {
@@ -53,6 +60,16 @@ func (r *transactionRepository) UpdateAccountBalance(accountID uuid.UUID, newBal
 	return err
 }
 
+// DebitAccountBalance subtracts amount from the balance and available cash in
+// place, so concurrent debits cannot overwrite each other.
+func (r *transactionRepository) DebitAccountBalance(accountID uuid.UUID, amount float64) error {
+	_, err := r.db.Exec(`
+        UPDATE thyrasec.accounts
+        SET account_balance = account_balance - $1, available_cash = available_cash - $1, updated_at = $2
+        WHERE id = $3`, amount, time.Now(), accountID)
+	return err
+}
+
 func (r *transactionRepository) GetAccountBalance(accountID uuid.UUID) (float64, error) {
 	var balance float64
 	err := r.db.QueryRow("SELECT account_balance FROM accounts WHERE id = $1", accountID).Scan(&balance)
@@ -72,6 +89,18 @@ func (r *transactionRepository) GetAccountAvailableBalance(accountID uuid.UUID)
 	return availableBalance, nil
 }
 
+func (r *transactionRepository) CheckAccountKYCApproved(accountID uuid.UUID) error {
+	return onboardingutils.CheckAccountKYCApproved(r.db, accountID)
+}
+
+func (r *transactionRepository) CheckAccountAllows(accountID uuid.UUID, operation string) error {
+	return accountutils.CheckAccountAllows(r.db, accountID, operation)
+}
+
+func (r *transactionRepository) CheckCashAvailable(accountID uuid.UUID, amount float64, operation string) error {
+	return accountutils.CheckCashAvailable(r.db, accountID, decimal.NewFromFloat(amount), operation)
+}
+
 func (r *transactionRepository) GetAccountAvailableIinstrument(accountID uuid.UUID, instrumentID uuid.UUID) (float64, error) {
 	var availableQuantity float64
 	err := r.db.QueryRow("SELECT quantity FROM holdings WHERE account_id = $1 AND asset_id = $2", accountID, instrumentID).Scan(&availableQuantity)
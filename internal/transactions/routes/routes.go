@@ -5,15 +5,16 @@ package routes
 import (
 	middleware "thyra/internal/common/middleware"      // Middleware imports
 	api "thyra/internal/transactions/api/transactions" // Import other necessary packages
+	userutils "thyra/internal/users/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.RouterGroup) {
+func SetupRoutes(router *gin.RouterGroup, clock userutils.Clock) {
 	router.GET("/user/:userId/transactions", middleware.TokenMiddleware, api.GetTransactionByUserHandler)
 	router.GET("/transactions", middleware.TokenMiddleware, api.GetAllTransactionsHandler)
 	router.GET("/transaction/types", middleware.TokenMiddleware, api.GetTransactionTypesHandler)
 	router.POST("/transaction/create/deposit", middleware.TokenMiddleware, api.CreateDeposit)
-	router.POST("/transaction/create/withdrawal", middleware.TokenMiddleware, api.CreateWithdrawal)
+	router.POST("/transaction/create/withdrawal", middleware.TokenMiddleware, middleware.RequireMFA(middleware.MFAStepUpWindow, clock, middleware.MFAEnrolled), api.CreateWithdrawal)
 	router.GET("/assets/id", api.GetAssetID)
 }
@@ -1,10 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"log"
 	accountutils "thyra/internal/accounts/utils"
+	compliancemodels "thyra/internal/compliance/models"
 	orderutils "thyra/internal/orders/utils"
 	"thyra/internal/transactions/models"
 	"thyra/internal/transactions/repositories"
@@ -66,19 +68,63 @@ func InsertParentTransaction(database *sqlx.DB, transaction *models.Transaction)
 	return nil
 }
 
+// CashMonitor is told about every deposit and withdrawal once it is booked.
+type CashMonitor interface {
+	MonitorCashTransaction(ctx context.Context, event compliancemodels.CashEvent) error
+}
+
 type TransactionService struct {
 	transactionRepo repositories.TransactionRepository
+	cashMonitor     CashMonitor
 }
 
-func NewTransactionService(transactionRepo repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{transactionRepo: transactionRepo}
+// NewTransactionService creates the service. cashMonitor may be nil when the
+// caller books no cash movements.
+func NewTransactionService(transactionRepo repositories.TransactionRepository, cashMonitor CashMonitor) *TransactionService {
+	return &TransactionService{transactionRepo: transactionRepo, cashMonitor: cashMonitor}
+}
+
+// MonitorCash passes a booked cash movement to AML monitoring. Call it once
+// the booking has committed: the AML event references the account rows the
+// booking locks, and must not point at a movement that was rolled back. A
+// monitoring failure is logged rather than returned; the scheduled AML batch
+// picks up anything missed here.
+func (s *TransactionService) MonitorCash(ctx context.Context, transaction *models.Transaction, direction string) {
+	if s.cashMonitor == nil || transaction.CashAmount == nil {
+		return
+	}
+
+	event := compliancemodels.CashEvent{
+		TransactionID: transaction.Id,
+		AccountID:     transaction.TransactionOwnerAccountId,
+		Direction:     direction,
+		Amount:        *transaction.CashAmount,
+		OccurredAt:    transaction.CreatedAt,
+	}
+	if event.Amount < 0 {
+		event.Amount = -event.Amount
+	}
+	if err := s.cashMonitor.MonitorCashTransaction(ctx, event); err != nil {
+		log.Printf("AML monitoring of transaction %s failed: %v", transaction.Id, err)
+	}
 }
 
-func (s *TransactionService) CreateDeposit(c *gin.Context, userID string, transactionData *models.Transaction) (uuid.UUID, uuid.UUID, error) {
+// CreateDeposit books the deposit on the repository's transaction and returns
+// the client's transaction and the house account ID. The caller commits and
+// then passes the client's transaction to MonitorCash.
+func (s *TransactionService) CreateDeposit(c *gin.Context, userID string, transactionData *models.Transaction) (models.Transaction, uuid.UUID, error) {
 	//Gets UUID for the current authenticated user
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, errors.New("invalid user ID")
+		return models.Transaction{}, uuid.Nil, errors.New("invalid user ID")
+	}
+
+	// Deposits are only accepted once the account holder has passed KYC
+	if err := s.transactionRepo.CheckAccountKYCApproved(transactionData.TransactionOwnerAccountId); err != nil {
+		return models.Transaction{}, uuid.Nil, err
+	}
+	if err := s.transactionRepo.CheckAccountAllows(transactionData.TransactionOwnerAccountId, accountutils.OperationDeposit); err != nil {
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	//Generates ordernumber
@@ -87,12 +133,12 @@ func (s *TransactionService) CreateDeposit(c *gin.Context, userID string, transa
 	//Fetches house account
 	houseAccountID, err := accountutils.GetHouseAccount(c)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	houseAccountUUID, err := uuid.Parse(houseAccountID)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	clientTransactionID := uuid.New()
@@ -116,13 +162,13 @@ func (s *TransactionService) CreateDeposit(c *gin.Context, userID string, transa
 	currentBalance, err := s.transactionRepo.GetAccountBalance(clientTransaction.TransactionOwnerAccountId)
 	if err != nil {
 		fmt.Printf("error in currentBalance: %v")
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	currentAvailableBalance, err := s.transactionRepo.GetAccountAvailableBalance(clientTransaction.TransactionOwnerAccountId)
 	if err != nil {
 		fmt.Printf("error in currentAvailableBalance: %v")
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	/* BALANCE CHECK FOR HOUSE */
@@ -130,13 +176,13 @@ func (s *TransactionService) CreateDeposit(c *gin.Context, userID string, transa
 	currentBalanceHouse, err := s.transactionRepo.GetAccountBalance(houseAccountUUID)
 	if err != nil {
 		fmt.Printf("error in currentBalanceHouse: %v")
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	currentAvailableBalanceHouse, err := s.transactionRepo.GetAccountAvailableBalance(houseAccountUUID)
 	if err != nil {
 		fmt.Printf("error in currentAvailableBalanceHouse: %v")
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	/* CALCULATES BALANCE FOR CUSTOMER AND HOUSE */
@@ -156,27 +202,27 @@ func (s *TransactionService) CreateDeposit(c *gin.Context, userID string, transa
 	err = s.transactionRepo.UpdateAccountBalance(clientTransaction.CashAccountId, newBalance, availableBalance)
 	if err != nil {
 		fmt.Printf("error in UpdateAccountBalance: %v", err)
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 	err = s.transactionRepo.UpdateAccountBalance(houseAccountUUID, newBalanceHouse, availableBalanceHouse)
 	if err != nil {
 		fmt.Printf("error in UpdateAccountBalance: %v", err)
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	// Insert into database using repository
 	err = s.transactionRepo.InsertTransaction(&clientTransaction)
 	if err != nil {
 		fmt.Printf("error in ClientTransaction: %v", err)
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 	err = s.transactionRepo.InsertTransaction(&houseTransaction)
 	if err != nil {
 		fmt.Printf("error in InsertCreditTransaction: %v", err)
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
-	return clientTransactionID, houseAccountUUID, nil
+	return clientTransaction, houseAccountUUID, nil
 }
 
 func (s *TransactionService) CreateInstrumentPurchaseTransaction(c *gin.Context, accountID uuid.UUID, userID string, transactionData *models.Transaction, transactionInstrumentData *models.Transaction) (uuid.UUID, uuid.UUID, error) {
@@ -433,11 +479,18 @@ func (s *TransactionService) CreateInstrumentSellTransaction(c *gin.Context, acc
 
 }
 
-func (s *TransactionService) CreateWithdrawal(c *gin.Context, userID string, transactionData *models.Transaction) (uuid.UUID, uuid.UUID, error) {
+// CreateWithdrawal books the withdrawal on the repository's transaction and
+// returns the client's transaction and the house transaction ID. The caller
+// commits and then passes the client's transaction to MonitorCash.
+func (s *TransactionService) CreateWithdrawal(c *gin.Context, userID string, transactionData *models.Transaction) (models.Transaction, uuid.UUID, error) {
 	//Gets UUID for the current authenticated user
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, errors.New("invalid user ID")
+		return models.Transaction{}, uuid.Nil, errors.New("invalid user ID")
+	}
+
+	if err := s.transactionRepo.CheckAccountAllows(transactionData.TransactionOwnerAccountId, accountutils.OperationWithdrawal); err != nil {
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	//Generates ordernumber
@@ -446,12 +499,12 @@ func (s *TransactionService) CreateWithdrawal(c *gin.Context, userID string, tra
 	//Fetches house account
 	houseAccountID, err := accountutils.GetHouseAccount(c)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	houseAccountUUID, err := uuid.Parse(houseAccountID)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	clientTransactionID := uuid.New()
@@ -471,84 +524,37 @@ func (s *TransactionService) CreateWithdrawal(c *gin.Context, userID string, tra
 	houseTransaction.Id = houseTransactionID
 	houseTransaction.TransactionOwnerAccountId = houseAccountUUID
 
-	/* CHECKS THE BALANCE OF THE CUSTOMER ACCOUNT */
-	currentBalance, err := s.transactionRepo.GetAccountBalance(clientTransaction.TransactionOwnerAccountId)
-	if err != nil {
-		fmt.Printf("error in currentBalance: %v")
-		return uuid.Nil, uuid.Nil, err
+	if clientTransaction.CashAmount == nil {
+		return models.Transaction{}, uuid.Nil, errors.New("cash amount is required")
 	}
+	amount := *clientTransaction.CashAmount
 
-	currentAvailableBalance, err := s.transactionRepo.GetAccountAvailableBalance(clientTransaction.TransactionOwnerAccountId)
-	if err != nil {
-		fmt.Printf("error in currentAvailableBalance: %v")
-		return uuid.Nil, uuid.Nil, err
+	// Both checks lock the account rows on the repository's transaction
+	if err := s.transactionRepo.CheckCashAvailable(clientTransaction.TransactionOwnerAccountId, amount, accountutils.OperationWithdrawal); err != nil {
+		return models.Transaction{}, uuid.Nil, fmt.Errorf("customer's account: %w", err)
 	}
-
-	/* BALANCE CHECK FOR HOUSE */
-
-	currentBalanceHouse, err := s.transactionRepo.GetAccountBalance(houseAccountUUID)
-	if err != nil {
-		fmt.Printf("error in currentBalanceHouse: %v")
-		return uuid.Nil, uuid.Nil, err
+	if err := s.transactionRepo.CheckCashAvailable(houseAccountUUID, amount, accountutils.OperationWithdrawal); err != nil {
+		return models.Transaction{}, uuid.Nil, fmt.Errorf("house account: %w", err)
 	}
 
-	currentAvailableBalanceHouse, err := s.transactionRepo.GetAccountAvailableBalance(houseAccountUUID)
+	err = s.transactionRepo.DebitAccountBalance(clientTransaction.CashAccountId, amount)
 	if err != nil {
-		fmt.Printf("error in currentAvailableBalanceHouse: %v")
-		return uuid.Nil, uuid.Nil, err
-	}
-	fmt.Printf("currentAvailableBalanceHouse before calculation: ", currentAvailableBalance)
-	/* CALCULATES BALANCE FOR CUSTOMER AND HOUSE */
-	var newBalance, availableBalance, newBalanceHouse, availableBalanceHouse float64
-	if clientTransaction.CashAmount != nil {
-		newBalance = currentBalance - *clientTransaction.CashAmount
-		availableBalance = currentAvailableBalance - *clientTransaction.CashAmount
-		newBalanceHouse = currentBalanceHouse - *clientTransaction.CashAmount // Assuming house loses this amount
-		availableBalanceHouse = currentAvailableBalanceHouse - *clientTransaction.CashAmount
-	} else {
-		newBalance = currentBalance
-		availableBalance = currentAvailableBalance
-		newBalanceHouse = currentBalanceHouse
-		availableBalanceHouse = currentAvailableBalanceHouse
+		return models.Transaction{}, uuid.Nil, err
 	}
-
-	var cashAmountStr string
-	if clientTransaction.CashAmount != nil {
-		cashAmountStr = strconv.FormatFloat(*houseTransaction.CashAmount, 'f', 2, 64)
-	} else {
-		cashAmountStr = "default_value" // Or any other appropriate handling
-	}
-
-	fmt.Println(cashAmountStr)
-	fmt.Println(availableBalance)
-	if currentAvailableBalance < *clientTransaction.CashAmount {
-		return uuid.Nil, uuid.Nil, fmt.Errorf("insufficient funds in customer's account")
-	}
-
-	fmt.Println(cashAmountStr)
-	fmt.Println(availableBalanceHouse)
-	if currentAvailableBalanceHouse < *houseTransaction.CashAmount {
-		return uuid.Nil, uuid.Nil, fmt.Errorf("insufficient funds in house account")
-	}
-
-	err = s.transactionRepo.UpdateAccountBalance(clientTransaction.CashAccountId, newBalance, availableBalance)
-	if err != nil {
-		return uuid.Nil, uuid.Nil, err
-	}
-	err = s.transactionRepo.UpdateAccountBalance(houseAccountUUID, newBalanceHouse, availableBalanceHouse)
+	err = s.transactionRepo.DebitAccountBalance(houseAccountUUID, amount)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
 	// Insert into database using repository
 	err = s.transactionRepo.InsertTransaction(&clientTransaction)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 	err = s.transactionRepo.InsertTransaction(&houseTransaction)
 	if err != nil {
-		return uuid.Nil, uuid.Nil, err
+		return models.Transaction{}, uuid.Nil, err
 	}
 
-	return clientTransactionID, houseTransactionID, nil
+	return clientTransaction, houseTransactionID, nil
 }
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	accountutils "thyra/internal/accounts/utils"
+	onboardingutils "thyra/internal/onboarding/utils"
+	"thyra/internal/transfers/models"
+	"thyra/internal/transfers/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DeliveryHandler struct {
+	service *services.DeliveryService
+}
+
+func NewDeliveryHandler(service *services.DeliveryService) *DeliveryHandler {
+	return &DeliveryHandler{service: service}
+}
+
+func (h *DeliveryHandler) InstructDelivery(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	var req models.DeliveryInstructionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	delivery, err := h.service.InstructDelivery(c.Request.Context(), authUserID, authUserRole, req)
+	if err != nil {
+		writeDeliveryError(c, err, "Failed to instruct delivery")
+		return
+	}
+
+	c.JSON(http.StatusCreated, delivery)
+}
+
+// GetDeliveries lists deliveries across accounts, optionally filtered by ?status=.
+func (h *DeliveryHandler) GetDeliveries(c *gin.Context) {
+	_, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.service.GetDeliveries(c.Request.Context(), authUserRole, c.Query("status"))
+	if err != nil {
+		writeDeliveryError(c, err, "Failed to fetch deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *DeliveryHandler) GetDelivery(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.GetDelivery(c.Request.Context(), deliveryID, authUserID, authUserRole)
+	if err != nil {
+		writeDeliveryError(c, err, "Failed to fetch delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (h *DeliveryHandler) GetAccountDeliveries(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID in URL"})
+		return
+	}
+
+	deliveries, err := h.service.GetAccountDeliveries(c.Request.Context(), accountID, authUserID, authUserRole, c.Query("status"))
+	if err != nil {
+		writeDeliveryError(c, err, "Failed to fetch account deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ConfirmDelivery marks the external leg as matched by the counterparty.
+func (h *DeliveryHandler) ConfirmDelivery(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.DeliveryConfirmationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	delivery, err := h.service.ConfirmDelivery(c.Request.Context(), deliveryID, authUserID, authUserRole, req)
+	if err != nil {
+		writeDeliveryError(c, err, "Failed to confirm delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (h *DeliveryHandler) SettleDelivery(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDParam(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.SettleDelivery(c.Request.Context(), deliveryID, authUserID, authUserRole)
+	if err != nil {
+		writeDeliveryError(c, err, "Failed to settle delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (h *DeliveryHandler) FailDelivery(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	deliveryID, ok := deliveryIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.DeliveryFailureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	delivery, err := h.service.FailDelivery(c.Request.Context(), deliveryID, authUserID, authUserRole, req)
+	if err != nil {
+		writeDeliveryError(c, err, "Failed to fail delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func deliveryIDParam(c *gin.Context) (uuid.UUID, bool) {
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID in URL"})
+		return uuid.Nil, false
+	}
+	return deliveryID, true
+}
+
+func writeDeliveryError(c *gin.Context, err error, message string) {
+	var statusErr *accountutils.AccountStatusError
+	if errors.As(err, &statusErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, accountutils.ErrInsufficientBuyingPower) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidDeliveryTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrDeliveryAccessDenied, onboardingutils.ErrKYCNotApproved:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrAssetNotFound, services.ErrDeliveryNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidDirection, services.ErrInvalidSettlementType, services.ErrInvalidQuantity,
+		services.ErrCashAmountRequired, services.ErrCashAmountNotAllowed, services.ErrCostBasisRequired,
+		services.ErrQuantityPrecision, services.ErrInvalidDeliveryStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrInsufficientHoldings:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	accountutils "thyra/internal/accounts/utils"
+	onboardingutils "thyra/internal/onboarding/utils"
+	"thyra/internal/transfers/models"
+	"thyra/internal/transfers/services"
+	userutils "thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TransferHandler struct {
+	service *services.TransferService
+}
+
+func NewTransferHandler(service *services.TransferService) *TransferHandler {
+	return &TransferHandler{service: service}
+}
+
+func (h *TransferHandler) CreateCashTransfer(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	var req models.CashTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	transfer, err := h.service.CreateCashTransfer(c.Request.Context(), authUserID, authUserRole, req)
+	if err != nil {
+		writeTransferError(c, err, "Failed to transfer cash")
+		return
+	}
+
+	c.JSON(transferStatusCode(transfer), transfer)
+}
+
+func (h *TransferHandler) CreateSecuritiesTransfer(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	var req models.SecuritiesTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	transfer, err := h.service.CreateSecuritiesTransfer(c.Request.Context(), authUserID, authUserRole, req)
+	if err != nil {
+		writeTransferError(c, err, "Failed to transfer securities")
+		return
+	}
+
+	c.JSON(transferStatusCode(transfer), transfer)
+}
+
+// GetTransfers lists transfers across accounts, optionally filtered by ?status=.
+func (h *TransferHandler) GetTransfers(c *gin.Context) {
+	_, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+
+	transfers, err := h.service.GetTransfers(c.Request.Context(), authUserRole, c.Query("status"))
+	if err != nil {
+		writeTransferError(c, err, "Failed to fetch transfers")
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+func (h *TransferHandler) GetTransfer(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	transferID, ok := transferIDParam(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.service.GetTransfer(c.Request.Context(), transferID, authUserID, authUserRole)
+	if err != nil {
+		writeTransferError(c, err, "Failed to fetch transfer")
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+func (h *TransferHandler) GetAccountTransfers(c *gin.Context) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	accountID, err := uuid.Parse(c.Param("accountId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID in URL"})
+		return
+	}
+
+	transfers, err := h.service.GetAccountTransfers(c.Request.Context(), accountID, authUserID, authUserRole, c.Query("status"))
+	if err != nil {
+		writeTransferError(c, err, "Failed to fetch account transfers")
+		return
+	}
+
+	c.JSON(http.StatusOK, transfers)
+}
+
+func (h *TransferHandler) ApproveTransfer(c *gin.Context) {
+	h.decide(c, h.service.ApproveTransfer, "Failed to approve transfer")
+}
+
+func (h *TransferHandler) RejectTransfer(c *gin.Context) {
+	h.decide(c, h.service.RejectTransfer, "Failed to reject transfer")
+}
+
+type transferDecision func(ctx context.Context, transferID, authUserID uuid.UUID, authUserRole string, req models.TransferDecisionRequest) (models.Transfer, error)
+
+func (h *TransferHandler) decide(c *gin.Context, decision transferDecision, message string) {
+	authUserID, authUserRole, ok := transferUser(c)
+	if !ok {
+		return
+	}
+	transferID, ok := transferIDParam(c)
+	if !ok {
+		return
+	}
+	// The note is optional on approval, so an empty body is fine.
+	var req models.TransferDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	transfer, err := decision(c.Request.Context(), transferID, authUserID, authUserRole, req)
+	if err != nil {
+		writeTransferError(c, err, message)
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// transferStatusCode tells a booked transfer apart from one that still
+// needs approval.
+func transferStatusCode(transfer models.Transfer) int {
+	if transfer.Status == models.TransferStatusPendingApproval {
+		return http.StatusAccepted
+	}
+	return http.StatusCreated
+}
+
+func transferUser(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userRole, ok := userutils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+	authUserID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+	return authUserID, userRole, true
+}
+
+func transferIDParam(c *gin.Context) (uuid.UUID, bool) {
+	transferID, err := uuid.Parse(c.Param("transferId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID in URL"})
+		return uuid.Nil, false
+	}
+	return transferID, true
+}
+
+func writeTransferError(c *gin.Context, err error, message string) {
+	var statusErr *accountutils.AccountStatusError
+	if errors.As(err, &statusErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, accountutils.ErrInsufficientBuyingPower) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrTransferAccessDenied, services.ErrSelfApproval, onboardingutils.ErrKYCNotApproved:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrTransferNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrSameAccount, services.ErrCurrencyMismatch, services.ErrInvalidTransferAmount,
+		services.ErrInvalidQuantity, services.ErrQuantityPrecision, services.ErrDecisionNoteRequired, services.ErrInvalidTransferStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrTransferNotPending:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrHoldingNotFound, services.ErrInsufficientHoldings:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DeliveryDirectionIn  = "in"
+	DeliveryDirectionOut = "out"
+
+	// Free of payment deliveries move securities only, delivery versus
+	// payment deliveries settle against a cash amount.
+	SettlementTypeFOP = "fop"
+	SettlementTypeDVP = "dvp"
+
+	DeliveryStatusInstructed = "instructed"
+	DeliveryStatusMatched    = "matched"
+	DeliveryStatusSettled    = "settled"
+	DeliveryStatusFailed     = "failed"
+
+	TransactionTypeSecurityDeliveryIn  = "Security Delivery In"
+	TransactionTypeSecurityDeliveryOut = "Security Delivery Out"
+)
+
+type Delivery struct {
+	ID                     uuid.UUID        `db:"id" json:"id"`
+	Direction              string           `db:"direction" json:"direction"`
+	SettlementType         string           `db:"settlement_type" json:"settlement_type"`
+	Status                 string           `db:"status" json:"status"`
+	AccountID              uuid.UUID        `db:"account_id" json:"account_id"`
+	AssetID                uuid.UUID        `db:"asset_id" json:"asset_id"`
+	Quantity               decimal.Decimal  `db:"quantity" json:"quantity"`
+	CashAmount             *decimal.Decimal `db:"cash_amount" json:"cash_amount,omitempty"`
+	CostBasis              *decimal.Decimal `db:"cost_basis" json:"cost_basis,omitempty"`
+	Currency               string           `db:"currency" json:"currency"`
+	CounterpartyName       string           `db:"counterparty_name" json:"counterparty_name"`
+	CounterpartyAccount    string           `db:"counterparty_account" json:"counterparty_account"`
+	CounterpartyBIC        *string          `db:"counterparty_bic" json:"counterparty_bic"`
+	IntendedSettlementDate *time.Time       `db:"intended_settlement_date" json:"intended_settlement_date"`
+	ExternalReference      *string          `db:"external_reference" json:"external_reference"`
+	FailureReason          *string          `db:"failure_reason" json:"failure_reason"`
+	Comment                *string          `db:"comment" json:"comment"`
+	InstructedBy           uuid.UUID        `db:"instructed_by" json:"instructed_by"`
+	ConfirmedBy            *uuid.UUID       `db:"confirmed_by" json:"confirmed_by"`
+	ConfirmedAt            *time.Time       `db:"confirmed_at" json:"confirmed_at"`
+	SettledBy              *uuid.UUID       `db:"settled_by" json:"settled_by"`
+	SettledAt              *time.Time       `db:"settled_at" json:"settled_at"`
+	FailedAt               *time.Time       `db:"failed_at" json:"failed_at"`
+	TransactionID          *uuid.UUID       `db:"transaction_id" json:"transaction_id"`
+	OrderNumber            *string          `db:"order_no" json:"order_no"`
+	CreatedAt              time.Time        `db:"created_at" json:"created_at"`
+}
+
+// DeliveryInstructionRequest instructs a delivery. CostBasis is the total
+// acquisition cost of an inbound delivery as supplied by the client; for
+// inbound DVP deliveries it defaults to the cash paid.
+type DeliveryInstructionRequest struct {
+	AccountID              uuid.UUID        `json:"account_id" binding:"required"`
+	Direction              string           `json:"direction" binding:"required"`
+	SettlementType         string           `json:"settlement_type" binding:"required"`
+	AssetID                uuid.UUID        `json:"asset_id" binding:"required"`
+	Quantity               decimal.Decimal  `json:"quantity"`
+	CashAmount             *decimal.Decimal `json:"cash_amount"`
+	CostBasis              *decimal.Decimal `json:"cost_basis"`
+	CounterpartyName       string           `json:"counterparty_name" binding:"required"`
+	CounterpartyAccount    string           `json:"counterparty_account" binding:"required"`
+	CounterpartyBIC        *string          `json:"counterparty_bic"`
+	IntendedSettlementDate *time.Time       `json:"intended_settlement_date"`
+	Comment                *string          `json:"comment"`
+}
+
+// DeliveryConfirmationRequest records that the counterparty has matched
+// the external leg.
+type DeliveryConfirmationRequest struct {
+	ExternalReference string `json:"external_reference" binding:"required"`
+}
+
+type DeliveryFailureRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	TransferTypeCash       = "cash"
+	TransferTypeSecurities = "securities"
+
+	TransferStatusPendingApproval = "pending_approval"
+	TransferStatusCompleted       = "completed"
+	TransferStatusRejected        = "rejected"
+)
+
+// Transaction type names booked on each side of a transfer.
+const (
+	TransactionTypeCashTransferOut     = "Cash Transfer Out"
+	TransactionTypeCashTransferIn      = "Cash Transfer In"
+	TransactionTypeSecurityTransferOut = "Security Transfer Out"
+	TransactionTypeSecurityTransferIn  = "Security Transfer In"
+)
+
+type Transfer struct {
+	ID               uuid.UUID        `db:"id" json:"id"`
+	TransferType     string           `db:"transfer_type" json:"transfer_type"`
+	Status           string           `db:"status" json:"status"`
+	FromAccountID    uuid.UUID        `db:"from_account_id" json:"from_account_id"`
+	ToAccountID      uuid.UUID        `db:"to_account_id" json:"to_account_id"`
+	Amount           *decimal.Decimal `db:"amount" json:"amount,omitempty"`
+	Currency         string           `db:"currency" json:"currency"`
+	AssetID          *uuid.UUID       `db:"asset_id" json:"asset_id,omitempty"`
+	Quantity         *decimal.Decimal `db:"quantity" json:"quantity,omitempty"`
+	CostBasis        *decimal.Decimal `db:"cost_basis" json:"cost_basis,omitempty"`
+	Comment          *string          `db:"comment" json:"comment"`
+	RequiresApproval bool             `db:"requires_approval" json:"requires_approval"`
+	RequestedBy      uuid.UUID        `db:"requested_by" json:"requested_by"`
+	DecidedBy        *uuid.UUID       `db:"decided_by" json:"decided_by"`
+	DecisionNote     *string          `db:"decision_note" json:"decision_note"`
+	DecidedAt        *time.Time       `db:"decided_at" json:"decided_at"`
+	OutTransactionID *uuid.UUID       `db:"out_transaction_id" json:"out_transaction_id"`
+	InTransactionID  *uuid.UUID       `db:"in_transaction_id" json:"in_transaction_id"`
+	OrderNumber      *string          `db:"order_no" json:"order_no"`
+	CreatedAt        time.Time        `db:"created_at" json:"created_at"`
+	CompletedAt      *time.Time       `db:"completed_at" json:"completed_at"`
+}
+
+type CashTransferRequest struct {
+	FromAccountID uuid.UUID       `json:"from_account_id" binding:"required"`
+	ToAccountID   uuid.UUID       `json:"to_account_id" binding:"required"`
+	Amount        decimal.Decimal `json:"amount"`
+	Comment       *string         `json:"comment"`
+}
+
+type SecuritiesTransferRequest struct {
+	FromAccountID uuid.UUID       `json:"from_account_id" binding:"required"`
+	ToAccountID   uuid.UUID       `json:"to_account_id" binding:"required"`
+	AssetID       uuid.UUID       `json:"asset_id" binding:"required"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	Comment       *string         `json:"comment"`
+}
+
+type TransferDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// TransferAccount is what a transfer needs to know about either account.
+type TransferAccount struct {
+	ID              uuid.UUID `db:"id"`
+	AccountHolderID uuid.UUID `db:"account_holder_id"`
+	Currency        string    `db:"account_currency"`
+}
+
+// TransferHolding is the source position of a securities transfer.
+type TransferHolding struct {
+	ID                uuid.UUID       `db:"id"`
+	Quantity          decimal.Decimal `db:"quantity"`
+	AvailableQuantity decimal.Decimal `db:"available_quantity"`
+	AcquisitionCost   decimal.Decimal `db:"acquisition_cost"`
+	AssetTypeID       *uuid.UUID      `db:"asset_type_id"`
+}
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/transfers/models"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type DeliveryRepository struct {
+	db *sqlx.DB
+}
+
+func NewDeliveryRepository(db *sqlx.DB) *DeliveryRepository {
+	return &DeliveryRepository{db: db}
+}
+
+func (r *DeliveryRepository) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
+	return r.db.BeginTxx(ctx, nil)
+}
+
+func (r *DeliveryRepository) GetAccount(ctx context.Context, accountID uuid.UUID) (models.TransferAccount, error) {
+	var account models.TransferAccount
+	err := r.db.GetContext(ctx, &account, `
+        SELECT id, account_holder_id, account_currency
+        FROM thyrasec.accounts
+        WHERE id = $1`, accountID)
+	return account, err
+}
+
+// GetAssetTypeID returns the asset's type, and sql.ErrNoRows if the asset
+// does not exist.
+func (r *DeliveryRepository) GetAssetTypeID(ctx context.Context, assetID uuid.UUID) (*uuid.UUID, error) {
+	var assetTypeID *uuid.UUID
+	err := r.db.GetContext(ctx, &assetTypeID, `SELECT asset_type_id FROM thyrasec.assets WHERE id = $1`, assetID)
+	return assetTypeID, err
+}
+
+func (r *DeliveryRepository) LockAccount(tx *sqlx.Tx, accountID uuid.UUID) error {
+	var locked uuid.UUID
+	return tx.Get(&locked, `SELECT id FROM thyrasec.accounts WHERE id = $1 FOR UPDATE`, accountID)
+}
+
+// ReserveHolding takes quantity out of the available quantity of a holding
+// until the delivery settles or fails. It returns false if not enough was
+// available.
+func (r *DeliveryRepository) ReserveHolding(tx *sqlx.Tx, accountID, assetID uuid.UUID, quantity decimal.Decimal) (bool, error) {
+	result, err := tx.Exec(`
+        UPDATE thyrasec.holdings
+        SET available_quantity = available_quantity - $1
+        WHERE account_id = $2 AND asset_id = $3 AND available_quantity >= $1`, quantity, accountID, assetID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *DeliveryRepository) ReleaseHolding(tx *sqlx.Tx, accountID, assetID uuid.UUID, quantity decimal.Decimal) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.holdings
+        SET available_quantity = available_quantity + $1
+        WHERE account_id = $2 AND asset_id = $3`, quantity, accountID, assetID)
+	return err
+}
+
+// DeliverReservedHolding removes an already reserved quantity from a holding
+// along with the cost of the lots it takes.
+func (r *DeliveryRepository) DeliverReservedHolding(tx *sqlx.Tx, accountID, assetID uuid.UUID, quantity decimal.Decimal, cost decimal.Decimal) error {
+	holding, err := getHoldingForUpdate(tx, accountID, assetID)
+	if err != nil {
+		return err
+	}
+
+	if quantity.GreaterThanOrEqual(holding.Quantity) {
+		_, err = tx.Exec(`DELETE FROM thyrasec.holdings WHERE id = $1`, holding.ID)
+		return err
+	}
+	_, err = tx.Exec(`
+        UPDATE thyrasec.holdings
+        SET quantity = quantity - $1, acquisition_cost = acquisition_cost - $2
+        WHERE id = $3`, quantity, cost, holding.ID)
+	return err
+}
+
+func (r *DeliveryRepository) AddHolding(tx *sqlx.Tx, accountID, assetID uuid.UUID, quantity decimal.Decimal, cost decimal.Decimal) error {
+	return addHolding(tx, accountID, assetID, quantity, cost)
+}
+
+// GetHoldingForUpdate locks the account's holding of the asset.
+func (r *DeliveryRepository) GetHoldingForUpdate(tx *sqlx.Tx, accountID, assetID uuid.UUID) (models.TransferHolding, error) {
+	return getHoldingForUpdate(tx, accountID, assetID)
+}
+
+func (r *DeliveryRepository) GetQuantityPrecision(tx *sqlx.Tx, assetID uuid.UUID) (int32, error) {
+	return getQuantityPrecision(tx, assetID)
+}
+
+// ReserveCash sets aside the payment for an inbound DVP delivery.
+func (r *DeliveryRepository) ReserveCash(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.accounts
+        SET available_cash = available_cash - $1, reserved_cash = reserved_cash + $1
+        WHERE id = $2`, amount, accountID)
+	return err
+}
+
+func (r *DeliveryRepository) ReleaseCash(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.accounts
+        SET available_cash = available_cash + $1, reserved_cash = reserved_cash - $1
+        WHERE id = $2`, amount, accountID)
+	return err
+}
+
+// PayReservedCash books the payment of an inbound DVP delivery against the
+// cash reserved when it was instructed.
+func (r *DeliveryRepository) PayReservedCash(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.accounts
+        SET account_balance = account_balance - $1, reserved_cash = reserved_cash - $1, updated_at = NOW()
+        WHERE id = $2`, amount, accountID)
+	return err
+}
+
+// ReceiveCash books the payment received for an outbound DVP delivery.
+func (r *DeliveryRepository) ReceiveCash(tx *sqlx.Tx, accountID uuid.UUID, amount decimal.Decimal) error {
+	_, err := tx.Exec(`
+        UPDATE thyrasec.accounts
+        SET account_balance = account_balance + $1, available_cash = available_cash + $1, updated_at = NOW()
+        WHERE id = $2`, amount, accountID)
+	return err
+}
+
+func (r *DeliveryRepository) GetTransactionTypeID(tx *sqlx.Tx, name string) (uuid.UUID, error) {
+	var typeID uuid.UUID
+	err := tx.Get(&typeID, `SELECT type_id FROM thyrasec.transactions_types WHERE transaction_type_name = $1 LIMIT 1`, name)
+	return typeID, err
+}
+
+func (r *DeliveryRepository) InsertTransaction(tx *sqlx.Tx, transaction TransferTransaction) error {
+	return insertTransaction(tx, transaction)
+}
+
+func (r *DeliveryRepository) InsertDelivery(tx *sqlx.Tx, delivery models.Delivery) error {
+	_, err := tx.NamedExec(`
+        INSERT INTO thyrasec.security_deliveries (
+            id, direction, settlement_type, status, account_id, asset_id, quantity, cash_amount, cost_basis,
+            currency, counterparty_name, counterparty_account, counterparty_bic, intended_settlement_date,
+            comment, instructed_by, created_at
+        )
+        VALUES (
+            :id, :direction, :settlement_type, :status, :account_id, :asset_id, :quantity, :cash_amount, :cost_basis,
+            :currency, :counterparty_name, :counterparty_account, :counterparty_bic, :intended_settlement_date,
+            :comment, :instructed_by, :created_at
+        )`, delivery)
+	return err
+}
+
+// GetDeliveryForUpdate locks a delivery so its status can be moved on.
+func (r *DeliveryRepository) GetDeliveryForUpdate(tx *sqlx.Tx, deliveryID uuid.UUID) (models.Delivery, error) {
+	var delivery models.Delivery
+	err := tx.Get(&delivery, `SELECT * FROM thyrasec.security_deliveries WHERE id = $1 FOR UPDATE`, deliveryID)
+	return delivery, err
+}
+
+// UpdateDelivery writes the status and the fields that change with it.
+func (r *DeliveryRepository) UpdateDelivery(tx *sqlx.Tx, delivery models.Delivery) error {
+	_, err := tx.NamedExec(`
+        UPDATE thyrasec.security_deliveries
+        SET status = :status, cost_basis = :cost_basis, external_reference = :external_reference,
+            failure_reason = :failure_reason, confirmed_by = :confirmed_by, confirmed_at = :confirmed_at,
+            settled_by = :settled_by, settled_at = :settled_at, failed_at = :failed_at,
+            transaction_id = :transaction_id, order_no = :order_no
+        WHERE id = :id`, delivery)
+	return err
+}
+
+func (r *DeliveryRepository) GetDelivery(ctx context.Context, deliveryID uuid.UUID) (models.Delivery, error) {
+	var delivery models.Delivery
+	err := r.db.GetContext(ctx, &delivery, `SELECT * FROM thyrasec.security_deliveries WHERE id = $1`, deliveryID)
+	return delivery, err
+}
+
+// GetDeliveries lists deliveries, optionally for one account or in one
+// status.
+func (r *DeliveryRepository) GetDeliveries(ctx context.Context, accountID *uuid.UUID, status string) ([]models.Delivery, error) {
+	deliveries := []models.Delivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+        SELECT * FROM thyrasec.security_deliveries
+        WHERE ($1::uuid IS NULL OR account_id = $1)
+            AND ($2 = '' OR status = $2)
+        ORDER BY created_at DESC`, accountID, status)
+	return deliveries, err
+}
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"thyra/internal/transfers/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+type TransferRepository struct {
+	db *sqlx.DB
+}
+
+func NewTransferRepository(db *sqlx.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// TransferTransaction is one side of a transfer booked in the transactions
+// table. Cash transfers set CashAmount, securities transfers the asset fields.
+type TransferTransaction struct {
+	ID            uuid.UUID
+	TypeID        uuid.UUID
+	AccountID     uuid.UUID
+	OwnerID       uuid.UUID
+	CashAmount    *float64
+	AssetID       *uuid.UUID
+	AssetQuantity *float64
+	AssetType     *uuid.UUID
+	AssetPrice    *float64
+	Comment       *string
+	OrderNumber   string
+	CreatedBy     uuid.UUID
+}
+
+func (r *TransferRepository) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
+	return r.db.BeginTxx(ctx, nil)
+}
+
+func (r *TransferRepository) GetAccount(ctx context.Context, accountID uuid.UUID) (models.TransferAccount, error) {
+	var account models.TransferAccount
+	err := r.db.GetContext(ctx, &account, `
+        SELECT id, account_holder_id, account_currency
+        FROM thyrasec.accounts
+        WHERE id = $1`, accountID)
+	return account, err
+}
+
+// LockAccounts locks both accounts in a fixed order so concurrent transfers
+// in opposite directions cannot deadlock.
+func (r *TransferRepository) LockAccounts(tx *sqlx.Tx, first, second uuid.UUID) error {
+	var locked []uuid.UUID
+	return tx.Select(&locked, `
+        SELECT id FROM thyrasec.accounts
+        WHERE id IN ($1, $2)
+        ORDER BY id
+        FOR UPDATE`, first, second)
+}
+
+func (r *TransferRepository) GetTransactionTypeID(tx *sqlx.Tx, name string) (uuid.UUID, error) {
+	var typeID uuid.UUID
+	err := tx.Get(&typeID, `SELECT type_id FROM thyrasec.transactions_types WHERE transaction_type_name = $1 LIMIT 1`, name)
+	return typeID, err
+}
+
+func (r *TransferRepository) MoveCash(tx *sqlx.Tx, fromAccountID, toAccountID uuid.UUID, amount decimal.Decimal) error {
+	if _, err := tx.Exec(`
+        UPDATE thyrasec.accounts
+        SET account_balance = account_balance - $1, available_cash = available_cash - $1, updated_at = NOW()
+        WHERE id = $2`, amount, fromAccountID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+        UPDATE thyrasec.accounts
+        SET account_balance = account_balance + $1, available_cash = available_cash + $1, updated_at = NOW()
+        WHERE id = $2`, amount, toAccountID)
+	return err
+}
+
+// GetHoldingForUpdate locks the account's holding of the asset.
+func (r *TransferRepository) GetHoldingForUpdate(tx *sqlx.Tx, accountID, assetID uuid.UUID) (models.TransferHolding, error) {
+	return getHoldingForUpdate(tx, accountID, assetID)
+}
+
+func (r *TransferRepository) GetQuantityPrecision(tx *sqlx.Tx, assetID uuid.UUID) (int32, error) {
+	return getQuantityPrecision(tx, assetID)
+}
+
+// DeductHolding removes quantity and its share of the cost from a holding,
+// deleting it once nothing is left.
+func (r *TransferRepository) DeductHolding(tx *sqlx.Tx, holding models.TransferHolding, quantity decimal.Decimal, cost decimal.Decimal) error {
+	if quantity.GreaterThanOrEqual(holding.Quantity) {
+		_, err := tx.Exec(`DELETE FROM thyrasec.holdings WHERE id = $1`, holding.ID)
+		return err
+	}
+	_, err := tx.Exec(`
+        UPDATE thyrasec.holdings
+        SET quantity = quantity - $1, available_quantity = available_quantity - $1, acquisition_cost = acquisition_cost - $2
+        WHERE id = $3`, quantity, cost, holding.ID)
+	return err
+}
+
+// AddHolding adds quantity and cost to the account's holding of the asset,
+// creating it if needed.
+func (r *TransferRepository) AddHolding(tx *sqlx.Tx, accountID, assetID uuid.UUID, quantity decimal.Decimal, cost decimal.Decimal) error {
+	return addHolding(tx, accountID, assetID, quantity, cost)
+}
+
+func (r *TransferRepository) InsertTransaction(tx *sqlx.Tx, transaction TransferTransaction) error {
+	return insertTransaction(tx, transaction)
+}
+
+func (r *TransferRepository) InsertTransfer(tx *sqlx.Tx, transfer models.Transfer) error {
+	_, err := tx.NamedExec(`
+        INSERT INTO thyrasec.account_transfers (
+            id, transfer_type, status, from_account_id, to_account_id, amount, currency, asset_id, quantity,
+            cost_basis, comment, requires_approval, requested_by, out_transaction_id, in_transaction_id,
+            order_no, created_at, completed_at
+        )
+        VALUES (
+            :id, :transfer_type, :status, :from_account_id, :to_account_id, :amount, :currency, :asset_id, :quantity,
+            :cost_basis, :comment, :requires_approval, :requested_by, :out_transaction_id, :in_transaction_id,
+            :order_no, :created_at, :completed_at
+        )`, transfer)
+	return err
+}
+
+// CompleteTransfer records the approval and booking of a pending transfer.
+// It returns false if the transfer was no longer pending.
+func (r *TransferRepository) CompleteTransfer(tx *sqlx.Tx, transfer models.Transfer) (bool, error) {
+	result, err := tx.NamedExec(`
+        UPDATE thyrasec.account_transfers
+        SET status = :status, cost_basis = :cost_basis, decided_by = :decided_by, decision_note = :decision_note,
+            decided_at = :decided_at, out_transaction_id = :out_transaction_id, in_transaction_id = :in_transaction_id,
+            order_no = :order_no, completed_at = :completed_at
+        WHERE id = :id AND status = 'pending_approval'`, transfer)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RejectTransfer returns false if the transfer was no longer pending.
+func (r *TransferRepository) RejectTransfer(ctx context.Context, transferID, decidedBy uuid.UUID, note string, decidedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE thyrasec.account_transfers
+        SET status = 'rejected', decided_by = $1, decision_note = $2, decided_at = $3
+        WHERE id = $4 AND status = 'pending_approval'`, decidedBy, note, decidedAt, transferID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *TransferRepository) GetTransfer(ctx context.Context, transferID uuid.UUID) (models.Transfer, error) {
+	var transfer models.Transfer
+	err := r.db.GetContext(ctx, &transfer, `SELECT * FROM thyrasec.account_transfers WHERE id = $1`, transferID)
+	return transfer, err
+}
+
+// GetTransfers lists transfers, optionally those touching one account or in
+// one status.
+func (r *TransferRepository) GetTransfers(ctx context.Context, accountID *uuid.UUID, status string) ([]models.Transfer, error) {
+	transfers := []models.Transfer{}
+	err := r.db.SelectContext(ctx, &transfers, `
+        SELECT * FROM thyrasec.account_transfers
+        WHERE ($1::uuid IS NULL OR from_account_id = $1 OR to_account_id = $1)
+            AND ($2 = '' OR status = $2)
+        ORDER BY created_at DESC`, accountID, status)
+	return transfers, err
+}
+
+func getHoldingForUpdate(tx *sqlx.Tx, accountID, assetID uuid.UUID) (models.TransferHolding, error) {
+	var holding models.TransferHolding
+	err := tx.Get(&holding, `
+        SELECT h.id, h.quantity, h.available_quantity, h.acquisition_cost, a.asset_type_id
+        FROM thyrasec.holdings h
+        JOIN thyrasec.assets a ON a.id = h.asset_id
+        WHERE h.account_id = $1 AND h.asset_id = $2
+        FOR UPDATE OF h`, accountID, assetID)
+	return holding, err
+}
+
+// getQuantityPrecision returns the number of decimals the asset can be held in.
+func getQuantityPrecision(tx *sqlx.Tx, assetID uuid.UUID) (int32, error) {
+	var precision int32
+	err := tx.Get(&precision, `SELECT quantity_precision FROM thyrasec.assets WHERE id = $1`, assetID)
+	return precision, err
+}
+
+// addHolding adds quantity and cost to the account's holding of the asset,
+// creating it if needed.
+func addHolding(tx *sqlx.Tx, accountID, assetID uuid.UUID, quantity decimal.Decimal, cost decimal.Decimal) error {
+	result, err := tx.Exec(`
+        UPDATE thyrasec.holdings
+        SET quantity = quantity + $1, available_quantity = available_quantity + $1, acquisition_cost = acquisition_cost + $2
+        WHERE account_id = $3 AND asset_id = $4`, quantity, cost, accountID, assetID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows > 0 {
+		return err
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO thyrasec.holdings (account_id, asset_id, quantity, available_quantity, acquisition_cost)
+        VALUES ($1, $2, $3, $3, $4)`, accountID, assetID, quantity, cost)
+	return err
+}
+
+func insertTransaction(tx *sqlx.Tx, transaction TransferTransaction) error {
+	now := time.Now()
+	_, err := tx.Exec(`
+        INSERT INTO thyrasec.transactions (
+            id, type, asset_id, cash_amount, asset_quantity, cash_account_id, asset_account_id, asset_type,
+            asset_price, created_by_id, updated_by_id, created_at, updated_at, corrected, canceled, comment,
+            transaction_owner_id, transaction_owner_account_id, trade_date, settlement_date, order_no
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8, $9, $9, $10, $10, false, false, $11, $12, $6, $10, $10, $13)`,
+		transaction.ID, transaction.TypeID, transaction.AssetID, transaction.CashAmount, transaction.AssetQuantity,
+		transaction.AccountID, transaction.AssetType, transaction.AssetPrice, transaction.CreatedBy, now,
+		transaction.Comment, transaction.OwnerID, transaction.OrderNumber)
+	return err
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	handlers "thyra/internal/transfers/api/transfers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.RouterGroup, transferHandler *handlers.TransferHandler, deliveryHandler *handlers.DeliveryHandler) {
+	router.POST("/transfers/cash", transferHandler.CreateCashTransfer)
+	router.POST("/transfers/securities", transferHandler.CreateSecuritiesTransfer)
+	router.GET("/transfers", transferHandler.GetTransfers)
+	router.GET("/transfers/:transferId", transferHandler.GetTransfer)
+	router.POST("/transfers/:transferId/approve", transferHandler.ApproveTransfer)
+	router.POST("/transfers/:transferId/reject", transferHandler.RejectTransfer)
+	router.GET("/account/:accountId/transfers", transferHandler.GetAccountTransfers)
+
+	router.POST("/deliveries", deliveryHandler.InstructDelivery)
+	router.GET("/deliveries", deliveryHandler.GetDeliveries)
+	router.GET("/deliveries/:deliveryId", deliveryHandler.GetDelivery)
+	router.POST("/deliveries/:deliveryId/confirm", deliveryHandler.ConfirmDelivery)
+	router.POST("/deliveries/:deliveryId/settle", deliveryHandler.SettleDelivery)
+	router.POST("/deliveries/:deliveryId/fail", deliveryHandler.FailDelivery)
+	router.GET("/account/:accountId/deliveries", deliveryHandler.GetAccountDeliveries)
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	accountutils "thyra/internal/accounts/utils"
+	onboardingutils "thyra/internal/onboarding/utils"
+	orderutils "thyra/internal/orders/utils"
+	positionmodels "thyra/internal/positions/models"
+	positionutils "thyra/internal/positions/utils"
+	"thyra/internal/transfers/models"
+	"thyra/internal/transfers/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrDeliveryAccessDenied      = errors.New("not allowed to access this delivery")
+	ErrDeliveryNotFound          = errors.New("delivery not found")
+	ErrAssetNotFound             = errors.New("asset not found")
+	ErrInvalidDirection          = errors.New("direction must be in or out")
+	ErrInvalidSettlementType     = errors.New("settlement_type must be fop or dvp")
+	ErrCashAmountRequired        = errors.New("cash_amount must be greater than zero for dvp deliveries")
+	ErrCashAmountNotAllowed      = errors.New("cash_amount is only allowed for dvp deliveries")
+	ErrCostBasisRequired         = errors.New("cost_basis of at least zero is required for inbound fop deliveries")
+	ErrInvalidDeliveryStatus     = errors.New("status must be one of instructed, matched, settled or failed")
+	ErrInvalidDeliveryTransition = errors.New("invalid delivery status transition")
+)
+
+// deliveryTransitions lists the statuses a delivery may move to from each
+// status. Settled and failed are final.
+var deliveryTransitions = map[string][]string{
+	models.DeliveryStatusInstructed: {models.DeliveryStatusMatched, models.DeliveryStatusFailed},
+	models.DeliveryStatusMatched:    {models.DeliveryStatusSettled, models.DeliveryStatusFailed},
+}
+
+type DeliveryService struct {
+	repo *repositories.DeliveryRepository
+	// lotMethod decides which tax lots an outbound delivery takes.
+	lotMethod string
+}
+
+func NewDeliveryService(repo *repositories.DeliveryRepository, lotMethod string) *DeliveryService {
+	return &DeliveryService{repo: repo, lotMethod: lotMethod}
+}
+
+// InstructDelivery records a delivery to or from another institution.
+// Outbound quantity, and the payment for inbound DVP deliveries, are
+// reserved until the delivery settles or fails.
+func (s *DeliveryService) InstructDelivery(ctx context.Context, authUserID uuid.UUID, authUserRole string, req models.DeliveryInstructionRequest) (models.Delivery, error) {
+	if req.Direction != models.DeliveryDirectionIn && req.Direction != models.DeliveryDirectionOut {
+		return models.Delivery{}, ErrInvalidDirection
+	}
+	if !req.Quantity.IsPositive() {
+		return models.Delivery{}, ErrInvalidQuantity
+	}
+	switch req.SettlementType {
+	case models.SettlementTypeDVP:
+		if req.CashAmount == nil || !req.CashAmount.IsPositive() {
+			return models.Delivery{}, ErrCashAmountRequired
+		}
+		cashAmount := req.CashAmount.Round(2)
+		req.CashAmount = &cashAmount
+	case models.SettlementTypeFOP:
+		if req.CashAmount != nil {
+			return models.Delivery{}, ErrCashAmountNotAllowed
+		}
+	default:
+		return models.Delivery{}, ErrInvalidSettlementType
+	}
+
+	// Outbound cost basis comes from the holding when the delivery settles.
+	var costBasis *decimal.Decimal
+	if req.Direction == models.DeliveryDirectionIn {
+		if req.CostBasis == nil && req.SettlementType == models.SettlementTypeDVP {
+			req.CostBasis = req.CashAmount
+		}
+		if req.CostBasis == nil || req.CostBasis.IsNegative() {
+			return models.Delivery{}, ErrCostBasisRequired
+		}
+		cost := req.CostBasis.Round(2)
+		costBasis = &cost
+	}
+
+	account, err := s.getAccount(ctx, req.AccountID)
+	if err != nil {
+		return models.Delivery{}, err
+	}
+	if authUserRole != "admin" && account.AccountHolderID != authUserID {
+		return models.Delivery{}, ErrDeliveryAccessDenied
+	}
+	if _, err := s.repo.GetAssetTypeID(ctx, req.AssetID); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Delivery{}, ErrAssetNotFound
+		}
+		return models.Delivery{}, err
+	}
+
+	delivery := models.Delivery{
+		ID:                     uuid.New(),
+		Direction:              req.Direction,
+		SettlementType:         req.SettlementType,
+		Status:                 models.DeliveryStatusInstructed,
+		AccountID:              req.AccountID,
+		AssetID:                req.AssetID,
+		Quantity:               req.Quantity,
+		CashAmount:             req.CashAmount,
+		CostBasis:              costBasis,
+		Currency:               account.Currency,
+		CounterpartyName:       req.CounterpartyName,
+		CounterpartyAccount:    req.CounterpartyAccount,
+		CounterpartyBIC:        req.CounterpartyBIC,
+		IntendedSettlementDate: req.IntendedSettlementDate,
+		Comment:                req.Comment,
+		InstructedBy:           authUserID,
+		CreatedAt:              time.Now(),
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return models.Delivery{}, err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.LockAccount(tx, delivery.AccountID); err != nil {
+		return models.Delivery{}, err
+	}
+	if err := s.reserve(tx, delivery); err != nil {
+		return models.Delivery{}, err
+	}
+	if err := s.repo.InsertDelivery(tx, delivery); err != nil {
+		return models.Delivery{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Delivery{}, err
+	}
+	return delivery, nil
+}
+
+func (s *DeliveryService) reserve(tx *sqlx.Tx, delivery models.Delivery) error {
+	precision, err := s.repo.GetQuantityPrecision(tx, delivery.AssetID)
+	if err != nil {
+		return err
+	}
+
+	if delivery.Direction == models.DeliveryDirectionOut {
+		if err := accountutils.CheckAccountAllows(tx, delivery.AccountID, accountutils.OperationTransferOut); err != nil {
+			return err
+		}
+		holding, err := s.repo.GetHoldingForUpdate(tx, delivery.AccountID, delivery.AssetID)
+		if err == sql.ErrNoRows {
+			return ErrInsufficientHoldings
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkQuantityPrecision(precision, delivery.Quantity, holding.Quantity); err != nil {
+			return err
+		}
+		reserved, err := s.repo.ReserveHolding(tx, delivery.AccountID, delivery.AssetID, delivery.Quantity)
+		if err != nil {
+			return err
+		}
+		if !reserved {
+			return ErrInsufficientHoldings
+		}
+		return nil
+	}
+
+	if err := checkQuantityPrecision(precision, delivery.Quantity, decimal.Zero); err != nil {
+		return err
+	}
+	if err := accountutils.CheckAccountAllows(tx, delivery.AccountID, accountutils.OperationTransferIn); err != nil {
+		return err
+	}
+	if err := onboardingutils.CheckAccountKYCApproved(tx, delivery.AccountID); err != nil {
+		return err
+	}
+	if delivery.SettlementType != models.SettlementTypeDVP {
+		return nil
+	}
+	if err := accountutils.CheckCashAvailable(tx, delivery.AccountID, *delivery.CashAmount, accountutils.OperationBuy); err != nil {
+		return err
+	}
+	return s.repo.ReserveCash(tx, delivery.AccountID, *delivery.CashAmount)
+}
+
+// ConfirmDelivery records that the counterparty has matched the external
+// leg of the delivery.
+func (s *DeliveryService) ConfirmDelivery(ctx context.Context, deliveryID, authUserID uuid.UUID, authUserRole string, req models.DeliveryConfirmationRequest) (models.Delivery, error) {
+	return s.transition(ctx, deliveryID, authUserRole, models.DeliveryStatusMatched, func(tx *sqlx.Tx, delivery *models.Delivery) error {
+		now := time.Now()
+		delivery.ExternalReference = &req.ExternalReference
+		delivery.ConfirmedBy = &authUserID
+		delivery.ConfirmedAt = &now
+		return nil
+	})
+}
+
+// SettleDelivery moves the securities, and the payment for DVP deliveries,
+// once the external leg has settled.
+func (s *DeliveryService) SettleDelivery(ctx context.Context, deliveryID, authUserID uuid.UUID, authUserRole string) (models.Delivery, error) {
+	return s.transition(ctx, deliveryID, authUserRole, models.DeliveryStatusSettled, func(tx *sqlx.Tx, delivery *models.Delivery) error {
+		return s.settle(ctx, tx, delivery, authUserID)
+	})
+}
+
+// FailDelivery marks a delivery as failed and releases what it reserved.
+func (s *DeliveryService) FailDelivery(ctx context.Context, deliveryID, authUserID uuid.UUID, authUserRole string, req models.DeliveryFailureRequest) (models.Delivery, error) {
+	return s.transition(ctx, deliveryID, authUserRole, models.DeliveryStatusFailed, func(tx *sqlx.Tx, delivery *models.Delivery) error {
+		now := time.Now()
+		delivery.FailureReason = &req.Reason
+		delivery.FailedAt = &now
+
+		if delivery.Direction == models.DeliveryDirectionOut {
+			return s.repo.ReleaseHolding(tx, delivery.AccountID, delivery.AssetID, delivery.Quantity)
+		}
+		if delivery.SettlementType == models.SettlementTypeDVP {
+			return s.repo.ReleaseCash(tx, delivery.AccountID, *delivery.CashAmount)
+		}
+		return nil
+	})
+}
+
+// transition locks the delivery, checks that it may move to status and
+// applies the change within one database transaction.
+func (s *DeliveryService) transition(ctx context.Context, deliveryID uuid.UUID, authUserRole, status string, apply func(tx *sqlx.Tx, delivery *models.Delivery) error) (models.Delivery, error) {
+	if authUserRole != "admin" {
+		return models.Delivery{}, ErrDeliveryAccessDenied
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return models.Delivery{}, err
+	}
+	defer tx.Rollback()
+
+	delivery, err := s.repo.GetDeliveryForUpdate(tx, deliveryID)
+	if err == sql.ErrNoRows {
+		return models.Delivery{}, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return models.Delivery{}, err
+	}
+	if !canTransition(delivery.Status, status) {
+		return models.Delivery{}, fmt.Errorf("%w: %s to %s", ErrInvalidDeliveryTransition, delivery.Status, status)
+	}
+
+	if err := apply(tx, &delivery); err != nil {
+		return models.Delivery{}, err
+	}
+	delivery.Status = status
+	if err := s.repo.UpdateDelivery(tx, delivery); err != nil {
+		return models.Delivery{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Delivery{}, err
+	}
+	return delivery, nil
+}
+
+func canTransition(from, to string) bool {
+	for _, status := range deliveryTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *DeliveryService) settle(ctx context.Context, tx *sqlx.Tx, delivery *models.Delivery, settledBy uuid.UUID) error {
+	account, err := s.getAccount(ctx, delivery.AccountID)
+	if err != nil {
+		return err
+	}
+	assetTypeID, err := s.repo.GetAssetTypeID(ctx, delivery.AssetID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.LockAccount(tx, delivery.AccountID); err != nil {
+		return err
+	}
+
+	typeName := models.TransactionTypeSecurityDeliveryIn
+	// Transactions record quantities and prices as floats
+	quantity := delivery.Quantity.InexactFloat64()
+	var cashAmount *float64
+	if delivery.Direction == models.DeliveryDirectionIn {
+		if err := s.repo.AddHolding(tx, delivery.AccountID, delivery.AssetID, delivery.Quantity, *delivery.CostBasis); err != nil {
+			return err
+		}
+		acquiredAt := time.Now()
+		lot := positionmodels.TaxLot{
+			AccountID:  delivery.AccountID,
+			AssetID:    delivery.AssetID,
+			AcquiredAt: &acquiredAt,
+			Quantity:   delivery.Quantity,
+			Cost:       *delivery.CostBasis,
+			Source:     positionmodels.TaxLotSourceDelivery,
+			SourceID:   &delivery.ID,
+			CostKnown:  true,
+		}
+		if err := positionutils.OpenLot(tx, lot); err != nil {
+			return err
+		}
+		if delivery.SettlementType == models.SettlementTypeDVP {
+			if err := s.repo.PayReservedCash(tx, delivery.AccountID, *delivery.CashAmount); err != nil {
+				return err
+			}
+			paid := -delivery.CashAmount.InexactFloat64()
+			cashAmount = &paid
+		}
+	} else {
+		typeName = models.TransactionTypeSecurityDeliveryOut
+		quantity = -quantity
+		_, cost, err := positionutils.ConsumeLots(tx, delivery.AccountID, delivery.AssetID, delivery.Quantity, s.lotMethod)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeliverReservedHolding(tx, delivery.AccountID, delivery.AssetID, delivery.Quantity, cost); err != nil {
+			return err
+		}
+		delivery.CostBasis = &cost
+		if delivery.SettlementType == models.SettlementTypeDVP {
+			if err := s.repo.ReceiveCash(tx, delivery.AccountID, *delivery.CashAmount); err != nil {
+				return err
+			}
+			received := delivery.CashAmount.InexactFloat64()
+			cashAmount = &received
+		}
+	}
+
+	// DVP deliveries are priced at the payment, FOP deliveries at cost.
+	price := delivery.CostBasis.Div(delivery.Quantity).InexactFloat64()
+	if delivery.SettlementType == models.SettlementTypeDVP {
+		price = delivery.CashAmount.Div(delivery.Quantity).InexactFloat64()
+	}
+
+	typeID, err := s.repo.GetTransactionTypeID(tx, typeName)
+	if err != nil {
+		return fmt.Errorf("transaction type %q: %w", typeName, err)
+	}
+	orderNumber := orderutils.GenerateOrderNumber()
+	transaction := repositories.TransferTransaction{
+		ID:            uuid.New(),
+		TypeID:        typeID,
+		AccountID:     delivery.AccountID,
+		OwnerID:       account.AccountHolderID,
+		CashAmount:    cashAmount,
+		AssetID:       &delivery.AssetID,
+		AssetQuantity: &quantity,
+		AssetType:     assetTypeID,
+		AssetPrice:    &price,
+		Comment:       delivery.Comment,
+		OrderNumber:   orderNumber,
+		CreatedBy:     settledBy,
+	}
+	if err := s.repo.InsertTransaction(tx, transaction); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	delivery.SettledBy = &settledBy
+	delivery.SettledAt = &now
+	delivery.TransactionID = &transaction.ID
+	delivery.OrderNumber = &orderNumber
+	return nil
+}
+
+func (s *DeliveryService) GetDelivery(ctx context.Context, deliveryID, authUserID uuid.UUID, authUserRole string) (models.Delivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err == sql.ErrNoRows {
+		return models.Delivery{}, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return models.Delivery{}, err
+	}
+	if authUserRole != "admin" {
+		account, err := s.getAccount(ctx, delivery.AccountID)
+		if err != nil {
+			return models.Delivery{}, err
+		}
+		if account.AccountHolderID != authUserID {
+			return models.Delivery{}, ErrDeliveryAccessDenied
+		}
+	}
+	return delivery, nil
+}
+
+// GetDeliveries lists all deliveries for admins, optionally by status.
+func (s *DeliveryService) GetDeliveries(ctx context.Context, authUserRole, status string) ([]models.Delivery, error) {
+	if authUserRole != "admin" {
+		return nil, ErrDeliveryAccessDenied
+	}
+	if err := validateDeliveryStatus(status); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDeliveries(ctx, nil, status)
+}
+
+func (s *DeliveryService) GetAccountDeliveries(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole, status string) ([]models.Delivery, error) {
+	account, err := s.getAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if authUserRole != "admin" && account.AccountHolderID != authUserID {
+		return nil, ErrDeliveryAccessDenied
+	}
+	if err := validateDeliveryStatus(status); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDeliveries(ctx, &accountID, status)
+}
+
+func validateDeliveryStatus(status string) error {
+	switch status {
+	case "", models.DeliveryStatusInstructed, models.DeliveryStatusMatched, models.DeliveryStatusSettled, models.DeliveryStatusFailed:
+		return nil
+	}
+	return ErrInvalidDeliveryStatus
+}
+
+func (s *DeliveryService) getAccount(ctx context.Context, accountID uuid.UUID) (models.TransferAccount, error) {
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return models.TransferAccount{}, ErrAccountNotFound
+	}
+	return account, err
+}
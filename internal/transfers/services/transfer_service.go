@@ -0,0 +1,426 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	accountutils "thyra/internal/accounts/utils"
+	onboardingutils "thyra/internal/onboarding/utils"
+	orderutils "thyra/internal/orders/utils"
+	positionmodels "thyra/internal/positions/models"
+	positionutils "thyra/internal/positions/utils"
+	"thyra/internal/transfers/models"
+	"thyra/internal/transfers/repositories"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrTransferAccessDenied  = errors.New("not allowed to access this transfer")
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrTransferNotFound      = errors.New("transfer not found")
+	ErrSameAccount           = errors.New("from and to account must differ")
+	ErrCurrencyMismatch      = errors.New("accounts must have the same currency")
+	ErrInvalidTransferAmount = errors.New("amount must be greater than zero")
+	ErrInvalidQuantity       = errors.New("quantity must be greater than zero")
+	ErrQuantityPrecision     = errors.New("quantity has more decimals than the instrument can be held in")
+	ErrHoldingNotFound       = errors.New("source account does not hold this asset")
+	ErrInsufficientHoldings  = errors.New("insufficient available quantity")
+	ErrTransferNotPending    = errors.New("transfer is not pending approval")
+	ErrSelfApproval          = errors.New("a transfer cannot be decided by its requester")
+	ErrDecisionNoteRequired  = errors.New("note is required when rejecting a transfer")
+	ErrInvalidTransferStatus = errors.New("status must be one of pending_approval, completed or rejected")
+)
+
+type TransferService struct {
+	repo *repositories.TransferRepository
+	// lotMethod decides which tax lots a securities transfer moves.
+	lotMethod string
+}
+
+func NewTransferService(repo *repositories.TransferRepository, lotMethod string) *TransferService {
+	return &TransferService{repo: repo, lotMethod: lotMethod}
+}
+
+// CreateCashTransfer moves cash between two accounts. Transfers between
+// accounts of the same holder are booked right away, others wait for an
+// admin to approve them.
+func (s *TransferService) CreateCashTransfer(ctx context.Context, authUserID uuid.UUID, authUserRole string, req models.CashTransferRequest) (models.Transfer, error) {
+	if !req.Amount.IsPositive() {
+		return models.Transfer{}, ErrInvalidTransferAmount
+	}
+	amount := req.Amount.Round(2)
+	transfer, err := s.newTransfer(ctx, authUserID, authUserRole, req.FromAccountID, req.ToAccountID, req.Comment)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	transfer.TransferType = models.TransferTypeCash
+	transfer.Amount = &amount
+
+	return s.submit(ctx, transfer)
+}
+
+// CreateSecuritiesTransfer moves part of a holding between two accounts,
+// carrying its share of the acquisition cost along.
+func (s *TransferService) CreateSecuritiesTransfer(ctx context.Context, authUserID uuid.UUID, authUserRole string, req models.SecuritiesTransferRequest) (models.Transfer, error) {
+	if !req.Quantity.IsPositive() {
+		return models.Transfer{}, ErrInvalidQuantity
+	}
+	transfer, err := s.newTransfer(ctx, authUserID, authUserRole, req.FromAccountID, req.ToAccountID, req.Comment)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	transfer.TransferType = models.TransferTypeSecurities
+	transfer.AssetID = &req.AssetID
+	transfer.Quantity = &req.Quantity
+
+	return s.submit(ctx, transfer)
+}
+
+func (s *TransferService) newTransfer(ctx context.Context, authUserID uuid.UUID, authUserRole string, fromAccountID, toAccountID uuid.UUID, comment *string) (models.Transfer, error) {
+	if fromAccountID == toAccountID {
+		return models.Transfer{}, ErrSameAccount
+	}
+	from, err := s.getAccount(ctx, fromAccountID)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	if authUserRole != "admin" && from.AccountHolderID != authUserID {
+		return models.Transfer{}, ErrTransferAccessDenied
+	}
+	to, err := s.getAccount(ctx, toAccountID)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	// Cost basis is kept in account currency, so securities need this too.
+	if from.Currency != to.Currency {
+		return models.Transfer{}, ErrCurrencyMismatch
+	}
+
+	return models.Transfer{
+		ID:               uuid.New(),
+		FromAccountID:    fromAccountID,
+		ToAccountID:      toAccountID,
+		Currency:         from.Currency,
+		Comment:          comment,
+		RequiresApproval: from.AccountHolderID != to.AccountHolderID,
+		RequestedBy:      authUserID,
+		CreatedAt:        time.Now(),
+	}, nil
+}
+
+// submit books a transfer, or records it for approval. Both paths check the
+// accounts and availability so a request that cannot go through fails now.
+func (s *TransferService) submit(ctx context.Context, transfer models.Transfer) (models.Transfer, error) {
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	if transfer.RequiresApproval {
+		transfer.Status = models.TransferStatusPendingApproval
+		err = s.check(tx, transfer)
+	} else {
+		err = s.execute(ctx, tx, &transfer, transfer.RequestedBy)
+	}
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	if err := s.repo.InsertTransfer(tx, transfer); err != nil {
+		return models.Transfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+// ApproveTransfer books a pending cross-owner transfer. Availability is
+// checked again since balances may have changed since the request.
+func (s *TransferService) ApproveTransfer(ctx context.Context, transferID, authUserID uuid.UUID, authUserRole string, req models.TransferDecisionRequest) (models.Transfer, error) {
+	transfer, err := s.getPendingForDecision(ctx, transferID, authUserID, authUserRole)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	tx, err := s.repo.BeginTx(ctx)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	if err := s.execute(ctx, tx, &transfer, authUserID); err != nil {
+		return models.Transfer{}, err
+	}
+	now := time.Now()
+	transfer.DecidedBy = &authUserID
+	transfer.DecidedAt = &now
+	if req.Note != "" {
+		transfer.DecisionNote = &req.Note
+	}
+	updated, err := s.repo.CompleteTransfer(tx, transfer)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	if !updated {
+		return models.Transfer{}, ErrTransferNotPending
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+func (s *TransferService) RejectTransfer(ctx context.Context, transferID, authUserID uuid.UUID, authUserRole string, req models.TransferDecisionRequest) (models.Transfer, error) {
+	if req.Note == "" {
+		return models.Transfer{}, ErrDecisionNoteRequired
+	}
+	if _, err := s.getPendingForDecision(ctx, transferID, authUserID, authUserRole); err != nil {
+		return models.Transfer{}, err
+	}
+	updated, err := s.repo.RejectTransfer(ctx, transferID, authUserID, req.Note, time.Now())
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	if !updated {
+		return models.Transfer{}, ErrTransferNotPending
+	}
+	return s.repo.GetTransfer(ctx, transferID)
+}
+
+func (s *TransferService) getPendingForDecision(ctx context.Context, transferID, authUserID uuid.UUID, authUserRole string) (models.Transfer, error) {
+	if authUserRole != "admin" {
+		return models.Transfer{}, ErrTransferAccessDenied
+	}
+	transfer, err := s.getTransfer(ctx, transferID)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	if transfer.Status != models.TransferStatusPendingApproval {
+		return models.Transfer{}, ErrTransferNotPending
+	}
+	if transfer.RequestedBy == authUserID {
+		return models.Transfer{}, ErrSelfApproval
+	}
+	return transfer, nil
+}
+
+func (s *TransferService) GetTransfer(ctx context.Context, transferID, authUserID uuid.UUID, authUserRole string) (models.Transfer, error) {
+	transfer, err := s.getTransfer(ctx, transferID)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+	if authUserRole == "admin" || transfer.RequestedBy == authUserID {
+		return transfer, nil
+	}
+	for _, accountID := range []uuid.UUID{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := s.getAccount(ctx, accountID)
+		if err != nil {
+			return models.Transfer{}, err
+		}
+		if account.AccountHolderID == authUserID {
+			return transfer, nil
+		}
+	}
+	return models.Transfer{}, ErrTransferAccessDenied
+}
+
+// GetTransfers lists all transfers for admins, optionally by status.
+func (s *TransferService) GetTransfers(ctx context.Context, authUserRole, status string) ([]models.Transfer, error) {
+	if authUserRole != "admin" {
+		return nil, ErrTransferAccessDenied
+	}
+	if err := validateStatus(status); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTransfers(ctx, nil, status)
+}
+
+func (s *TransferService) GetAccountTransfers(ctx context.Context, accountID, authUserID uuid.UUID, authUserRole, status string) ([]models.Transfer, error) {
+	account, err := s.getAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if authUserRole != "admin" && account.AccountHolderID != authUserID {
+		return nil, ErrTransferAccessDenied
+	}
+	if err := validateStatus(status); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTransfers(ctx, &accountID, status)
+}
+
+func validateStatus(status string) error {
+	switch status {
+	case "", models.TransferStatusPendingApproval, models.TransferStatusCompleted, models.TransferStatusRejected:
+		return nil
+	}
+	return ErrInvalidTransferStatus
+}
+
+// check verifies that both accounts may take part in the transfer and that
+// the source has enough cash or available quantity. It locks the rows it
+// reads so the result holds for the rest of the transaction.
+func (s *TransferService) check(tx *sqlx.Tx, transfer models.Transfer) error {
+	if err := s.repo.LockAccounts(tx, transfer.FromAccountID, transfer.ToAccountID); err != nil {
+		return err
+	}
+	if err := accountutils.CheckAccountAllows(tx, transfer.FromAccountID, accountutils.OperationTransferOut); err != nil {
+		return err
+	}
+	if err := accountutils.CheckAccountAllows(tx, transfer.ToAccountID, accountutils.OperationTransferIn); err != nil {
+		return err
+	}
+	if err := onboardingutils.CheckAccountKYCApproved(tx, transfer.ToAccountID); err != nil {
+		return err
+	}
+
+	if transfer.TransferType == models.TransferTypeCash {
+		return accountutils.CheckCashAvailable(tx, transfer.FromAccountID, *transfer.Amount, accountutils.OperationTransferOut)
+	}
+	_, err := s.lockHolding(tx, transfer)
+	return err
+}
+
+func (s *TransferService) lockHolding(tx *sqlx.Tx, transfer models.Transfer) (models.TransferHolding, error) {
+	holding, err := s.repo.GetHoldingForUpdate(tx, transfer.FromAccountID, *transfer.AssetID)
+	if err == sql.ErrNoRows {
+		return models.TransferHolding{}, ErrHoldingNotFound
+	}
+	if err != nil {
+		return models.TransferHolding{}, err
+	}
+	if holding.AvailableQuantity.LessThan(*transfer.Quantity) {
+		return models.TransferHolding{}, ErrInsufficientHoldings
+	}
+	precision, err := s.repo.GetQuantityPrecision(tx, *transfer.AssetID)
+	if err != nil {
+		return models.TransferHolding{}, err
+	}
+	if err := checkQuantityPrecision(precision, *transfer.Quantity, holding.Quantity); err != nil {
+		return models.TransferHolding{}, err
+	}
+	return holding, nil
+}
+
+// checkQuantityPrecision rejects quantities with more decimals than the
+// instrument allows. A holding left with more decimals after the precision
+// was lowered can still be moved in full, so quantity may equal held.
+func checkQuantityPrecision(precision int32, quantity, held decimal.Decimal) error {
+	if quantity.Equal(quantity.Truncate(precision)) || quantity.Equal(held) {
+		return nil
+	}
+	return ErrQuantityPrecision
+}
+
+// execute moves the cash or securities and books both sides in the
+// transactions table, filling in the booking details on the transfer.
+func (s *TransferService) execute(ctx context.Context, tx *sqlx.Tx, transfer *models.Transfer, bookedBy uuid.UUID) error {
+	if err := s.check(tx, *transfer); err != nil {
+		return err
+	}
+	from, err := s.getAccount(ctx, transfer.FromAccountID)
+	if err != nil {
+		return err
+	}
+	to, err := s.getAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		return err
+	}
+
+	orderNumber := orderutils.GenerateOrderNumber()
+	out := repositories.TransferTransaction{
+		ID:          uuid.New(),
+		AccountID:   transfer.FromAccountID,
+		OwnerID:     from.AccountHolderID,
+		Comment:     transfer.Comment,
+		OrderNumber: orderNumber,
+		CreatedBy:   bookedBy,
+	}
+	in := out
+	in.ID = uuid.New()
+	in.AccountID = transfer.ToAccountID
+	in.OwnerID = to.AccountHolderID
+
+	outType, inType := models.TransactionTypeCashTransferOut, models.TransactionTypeCashTransferIn
+	if transfer.TransferType == models.TransferTypeCash {
+		if err := s.repo.MoveCash(tx, transfer.FromAccountID, transfer.ToAccountID, *transfer.Amount); err != nil {
+			return err
+		}
+		amount := transfer.Amount.InexactFloat64()
+		outAmount, inAmount := -amount, amount
+		out.CashAmount, in.CashAmount = &outAmount, &inAmount
+	} else {
+		outType, inType = models.TransactionTypeSecurityTransferOut, models.TransactionTypeSecurityTransferIn
+		holding, err := s.lockHolding(tx, *transfer)
+		if err != nil {
+			return err
+		}
+		quantity := *transfer.Quantity
+		cost, err := positionutils.TransferLots(tx, transfer.FromAccountID, transfer.ToAccountID, *transfer.AssetID,
+			quantity, s.lotMethod, positionmodels.TaxLotSourceTransfer, transfer.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeductHolding(tx, holding, quantity, cost); err != nil {
+			return err
+		}
+		if err := s.repo.AddHolding(tx, transfer.ToAccountID, *transfer.AssetID, quantity, cost); err != nil {
+			return err
+		}
+		transfer.CostBasis = &cost
+
+		// Transactions record quantities and prices as floats
+		unitCost := cost.Div(quantity).InexactFloat64()
+		inQuantity := quantity.InexactFloat64()
+		outQuantity := -inQuantity
+		out.AssetID, in.AssetID = transfer.AssetID, transfer.AssetID
+		out.AssetType, in.AssetType = holding.AssetTypeID, holding.AssetTypeID
+		out.AssetPrice, in.AssetPrice = &unitCost, &unitCost
+		out.AssetQuantity, in.AssetQuantity = &outQuantity, &inQuantity
+	}
+
+	if out.TypeID, err = s.repo.GetTransactionTypeID(tx, outType); err != nil {
+		return fmt.Errorf("transaction type %q: %w", outType, err)
+	}
+	if in.TypeID, err = s.repo.GetTransactionTypeID(tx, inType); err != nil {
+		return fmt.Errorf("transaction type %q: %w", inType, err)
+	}
+	if err := s.repo.InsertTransaction(tx, out); err != nil {
+		return err
+	}
+	if err := s.repo.InsertTransaction(tx, in); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	transfer.Status = models.TransferStatusCompleted
+	transfer.OutTransactionID = &out.ID
+	transfer.InTransactionID = &in.ID
+	transfer.OrderNumber = &orderNumber
+	transfer.CompletedAt = &now
+	return nil
+}
+
+func (s *TransferService) getAccount(ctx context.Context, accountID uuid.UUID) (models.TransferAccount, error) {
+	account, err := s.repo.GetAccount(ctx, accountID)
+	if err == sql.ErrNoRows {
+		return models.TransferAccount{}, ErrAccountNotFound
+	}
+	return account, err
+}
+
+func (s *TransferService) getTransfer(ctx context.Context, transferID uuid.UUID) (models.Transfer, error) {
+	transfer, err := s.repo.GetTransfer(ctx, transferID)
+	if err == sql.ErrNoRows {
+		return models.Transfer{}, ErrTransferNotFound
+	}
+	return transfer, err
+}
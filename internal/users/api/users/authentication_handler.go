@@ -3,9 +3,13 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"thyra/internal/users/models"
 	"thyra/internal/users/services"
+	"thyra/internal/users/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,11 +29,71 @@ func (h *AuthHandler) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.AuthenticateUser(c.Request.Context(), loginRequest.Username, loginRequest.Password)
+	result, err := h.service.AuthenticateUser(c.Request.Context(), loginRequest.Username, loginRequest.Password, loginContext(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		writeLoginError(c, err, "Invalid credentials")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *AuthHandler) MFALoginHandler(c *gin.Context) {
+	var request models.MFALoginRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	token, err := h.service.CompleteMFALogin(c.Request.Context(), request, loginContext(c))
+	if err != nil {
+		writeLoginError(c, err, "MFA verification failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+func (h *AuthHandler) StepUpHandler(c *gin.Context) {
+	var request models.MFACodeRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	userID, userType, ok := utils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	token, err := h.service.StepUp(c.Request.Context(), userID, c.GetString("username"), userType, request)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA verification failed", "details": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
+
+func loginContext(c *gin.Context) models.LoginContext {
+	return models.LoginContext{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// writeLoginError keeps credential failures generic while still telling the
+// client when it is throttled or the account is locked or suspended.
+func writeLoginError(c *gin.Context, err error, message string) {
+	var throttled *services.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": throttled.Error()})
+	case err == services.ErrAccountLocked, err == services.ErrAccountSuspended:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": message})
+	}
+}
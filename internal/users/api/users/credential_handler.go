@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"thyra/internal/users/models"
+	"thyra/internal/users/repositories"
+	"thyra/internal/users/services"
+	"thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CredentialHandler struct {
+	service *services.CredentialService
+}
+
+func NewCredentialHandler(service *services.CredentialService) *CredentialHandler {
+	return &CredentialHandler{service: service}
+}
+
+func (h *CredentialHandler) VerifyEmailHandler(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.BindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		writeTokenError(c, err, "Failed to verify email")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (h *CredentialHandler) ResendEmailVerificationHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SendEmailVerification(c.Request.Context(), userID); err != nil {
+		switch err {
+		case services.ErrEmailAlreadyVerified, services.ErrEmailMissing:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// ForgotPasswordHandler answers the same way whether or not the email exists.
+func (h *CredentialHandler) ForgotPasswordHandler(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.BindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request password reset", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered a reset link has been sent"})
+}
+
+func (h *CredentialHandler) ResetPasswordHandler(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.BindJSON(&req); err != nil || req.Token == "" || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token and new password are required"})
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req); err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
+		writeTokenError(c, err, "Failed to reset password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+func writeTokenError(c *gin.Context, err error, message string) {
+	if err == repositories.ErrInvalidUserToken {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+}
+
+// writePasswordPolicyError reports whether err was a policy violation and,
+// if so, has already written the 400 response listing the violations.
+func writePasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *utils.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Password does not meet policy", "violations": policyErr.Violations})
+	return true
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/users/services"
+	"thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type LoginSecurityHandler struct {
+	service *services.LoginSecurityService
+}
+
+func NewLoginSecurityHandler(service *services.LoginSecurityService) *LoginSecurityHandler {
+	return &LoginSecurityHandler{service: service}
+}
+
+// GetLoginHistoryHandler returns the authenticated user's own recent logins.
+func (h *LoginSecurityHandler) GetLoginHistoryHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetLoginHistory(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch login history", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *LoginSecurityHandler) UnlockUserHandler(c *gin.Context) {
+	_, authUserRole, ok := utils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.UnlockUser(c.Request.Context(), userID, authUserRole); err != nil {
+		switch err {
+		case services.ErrUserNotLocked:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
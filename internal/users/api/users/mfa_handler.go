@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"net/http"
+	"thyra/internal/users/models"
+	"thyra/internal/users/services"
+	"thyra/internal/users/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MFAHandler struct {
+	service *services.MFAService
+}
+
+func NewMFAHandler(service *services.MFAService) *MFAHandler {
+	return &MFAHandler{service: service}
+}
+
+func (h *MFAHandler) StartEnrollmentHandler(c *gin.Context) {
+	userID, userType, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.service.StartEnrollment(c.Request.Context(), userID, c.GetString("username"), userType)
+	if err != nil {
+		if err == services.ErrMFAAlreadyEnabled {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start MFA enrollment", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *MFAHandler) ConfirmEnrollmentHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var request models.MFACodeRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	codes, err := h.service.ConfirmEnrollment(c.Request.Context(), userID, request.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA enabled", "recovery_codes": codes})
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodesHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var request models.MFACodeRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, request)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *MFAHandler) DisableHandler(c *gin.Context) {
+	userID, _, ok := authenticatedUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var request models.MFACodeRequest
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse JSON data"})
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), userID, request); err != nil {
+		writeMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "MFA disabled"})
+}
+
+func authenticatedUserUUID(c *gin.Context) (uuid.UUID, string, bool) {
+	userID, userType, ok := utils.GetAuthenticatedUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return uuid.Nil, "", false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "UserID is not a valid UUID", "details": err.Error()})
+		return uuid.Nil, "", false
+	}
+
+	return userUUID, userType, true
+}
+
+func writeMFAError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrInvalidMFACode, services.ErrMFACodeRequired:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case services.ErrMFANotEnrolled, services.ErrMFAEnrollmentState, services.ErrMFAAlreadyEnabled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "MFA request failed", "details": err.Error()})
+	}
+}
@@ -57,6 +57,9 @@ func (h *UserHandler) RegisterAdminHandler(c *gin.Context) {
 	}
 
 	if err := h.service.RegisterAdmin(c.Request.Context(), admin); err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registering admin", "detail": err.Error()})
 		return
 	}
@@ -72,6 +75,9 @@ func (h *UserHandler) RegisterPartnerAdvisorHandler(c *gin.Context) {
 	}
 
 	if err := h.service.RegisterPartnerAdvisor(c.Request.Context(), advisor); err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registering partner/advisor", "detail": err.Error()})
 		return
 	}
@@ -86,7 +92,10 @@ func (h *UserHandler) RegisterCustomerHandler(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.RegisterCustomer(c.Request.Context(), customer); err != nil {
+	if _, err := h.service.RegisterCustomer(c.Request.Context(), customer); err != nil {
+		if writePasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error registering customer", "detail": err.Error()})
 		return
 	}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Values of the user_status enum.
+const (
+	UserStatusActive    = "ACTIVE"
+	UserStatusLocked    = "LOCKED"
+	UserStatusSuspended = "SUSPENDED"
+)
+
+type LoginFailureKey string
+
+const (
+	LoginFailureKeyUsername LoginFailureKey = "username"
+	LoginFailureKeyIP       LoginFailureKey = "ip"
+)
+
+type LoginFailure struct {
+	KeyType       LoginFailureKey `db:"key_type"`
+	KeyValue      string          `db:"key_value"`
+	FailureCount  int             `db:"failure_count"`
+	LastFailureAt time.Time       `db:"last_failure_at"`
+}
+
+type LoginHistoryEntry struct {
+	ID            uuid.UUID  `db:"id" json:"id"`
+	UserID        *uuid.UUID `db:"user_id" json:"user_id,omitempty"`
+	Username      string     `db:"username" json:"username"`
+	IPAddress     *string    `db:"ip_address" json:"ip_address,omitempty"`
+	UserAgent     *string    `db:"user_agent" json:"user_agent,omitempty"`
+	Success       bool       `db:"success" json:"success"`
+	FailureReason *string    `db:"failure_reason" json:"failure_reason,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// LoginContext describes where a login attempt came from.
+type LoginContext struct {
+	IPAddress string
+	UserAgent string
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserMFA struct {
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	UserType     string     `db:"user_type" json:"user_type"`
+	TOTPSecret   string     `db:"totp_secret" json:"-"`
+	Enabled      bool       `db:"enabled" json:"enabled"`
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+	ConfirmedAt  *time.Time `db:"confirmed_at" json:"confirmed_at,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+type RecoveryCode struct {
+	ID       uuid.UUID  `db:"id"`
+	UserID   uuid.UUID  `db:"user_id"`
+	CodeHash string     `db:"code_hash"`
+	UsedAt   *time.Time `db:"used_at"`
+}
+
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFACodeRequest carries either a TOTP code or a recovery code.
+type MFACodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	MFACodeRequest
+}
+
+// LoginResult is returned by the first login step. Token is set when no
+// second factor is needed, otherwise ChallengeToken must be exchanged at the
+// MFA login endpoint.
+type LoginResult struct {
+	Token          string `json:"token,omitempty"`
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+}
@@ -3,8 +3,6 @@
 package repositories
 
 import (
-	"database/sql"
-
 	"github.com/jmoiron/sqlx"
 )
 
@@ -16,26 +14,23 @@ func NewAuthRepository(db *sqlx.DB) *AuthRepository {
 	return &AuthRepository{db: db}
 }
 
+// GetUserCredentials looks the username up in the unified users table. A user
+// holding several roles logs in with the most privileged one.
 func (r *AuthRepository) GetUserCredentials(username string) (string, string, string, error) {
-	userTypes := map[string]string{
-		"admins":            "admin",
-		"partners_advisors": "partner_advisor",
-		"customers":         "customer",
-	}
-
 	var userType, storedPassword, userID string
-	var err error
 
-	for table, uType := range userTypes {
-		query := `SELECT id, password_hash FROM ` + table + ` WHERE username = $1`
-		err = r.db.QueryRow(query, username).Scan(&userID, &storedPassword)
-		if err == nil {
-			userType = uType
-			break
-		} else if err != sql.ErrNoRows {
-			break // Exit the loop on error other than ErrNoRows
-		}
-	}
+	query := `
+        SELECT u.id, u.password_hash, ur.role
+        FROM thyrasec.users u
+        INNER JOIN thyrasec.user_roles ur ON ur.user_id = u.id
+        WHERE u.username = $1
+        ORDER BY CASE ur.role
+            WHEN 'admin' THEN 1
+            WHEN 'partner_advisor' THEN 2
+            ELSE 3
+        END
+        LIMIT 1`
+	err := r.db.QueryRow(query, username).Scan(&userID, &storedPassword, &userType)
 
 	return userID, storedPassword, userType, err
 }
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/users/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginSecurityRepository struct {
+	db *sqlx.DB
+}
+
+func NewLoginSecurityRepository(db *sqlx.DB) *LoginSecurityRepository {
+	return &LoginSecurityRepository{db: db}
+}
+
+// GetFailure returns nil when no failures are recorded for the key.
+func (r *LoginSecurityRepository) GetFailure(ctx context.Context, keyType models.LoginFailureKey, keyValue string) (*models.LoginFailure, error) {
+	var failure models.LoginFailure
+	query := `SELECT key_type, key_value, failure_count, last_failure_at FROM thyrasec.login_failures WHERE key_type = $1 AND key_value = $2`
+	err := r.db.GetContext(ctx, &failure, query, keyType, keyValue)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// IncrementFailure bumps the counter for the key and returns the new count.
+// Counters whose last failure is older than resetBefore start over at one.
+func (r *LoginSecurityRepository) IncrementFailure(ctx context.Context, keyType models.LoginFailureKey, keyValue string, now, resetBefore time.Time) (int, error) {
+	var count int
+	query := `
+        INSERT INTO thyrasec.login_failures (key_type, key_value, failure_count, last_failure_at)
+        VALUES ($1, $2, 1, $3)
+        ON CONFLICT (key_type, key_value) DO UPDATE
+        SET failure_count = CASE
+                WHEN thyrasec.login_failures.last_failure_at < $4 THEN 1
+                ELSE thyrasec.login_failures.failure_count + 1
+            END,
+            last_failure_at = EXCLUDED.last_failure_at
+        RETURNING failure_count`
+	err := r.db.QueryRowContext(ctx, query, keyType, keyValue, now, resetBefore).Scan(&count)
+	return count, err
+}
+
+func (r *LoginSecurityRepository) ResetFailures(ctx context.Context, keyType models.LoginFailureKey, keyValue string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM thyrasec.login_failures WHERE key_type = $1 AND key_value = $2`, keyType, keyValue)
+	return err
+}
+
+func (r *LoginSecurityRepository) GetUserStatus(ctx context.Context, userID uuid.UUID) (string, error) {
+	var status string
+	err := r.db.GetContext(ctx, &status, `SELECT COALESCE(status::text, 'ACTIVE') FROM thyrasec.users WHERE id = $1`, userID)
+	return status, err
+}
+
+func (r *LoginSecurityRepository) GetUserIDByUsername(ctx context.Context, username string) (*uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.GetContext(ctx, &userID, `SELECT id FROM thyrasec.users WHERE username = $1`, username)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &userID, nil
+}
+
+// LockUser only locks active users so a suspension is never downgraded.
+func (r *LoginSecurityRepository) LockUser(ctx context.Context, userID uuid.UUID, reason string, now time.Time) error {
+	query := `UPDATE thyrasec.users SET status = 'LOCKED', locked_at = $1, locked_reason = $2, updated_at = $1 WHERE id = $3 AND status = 'ACTIVE'`
+	_, err := r.db.ExecContext(ctx, query, now, reason, userID)
+	return err
+}
+
+// UnlockUser reactivates a locked user and clears its username failure counter.
+func (r *LoginSecurityRepository) UnlockUser(ctx context.Context, userID uuid.UUID, now time.Time) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var username string
+	query := `UPDATE thyrasec.users SET status = 'ACTIVE', locked_at = NULL, locked_reason = NULL, updated_at = $1
+              WHERE id = $2 AND status = 'LOCKED' RETURNING username`
+	err = tx.GetContext(ctx, &username, query, now, userID)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return false, nil
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM thyrasec.login_failures WHERE key_type = $1 AND key_value = $2`, models.LoginFailureKeyUsername, username); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (r *LoginSecurityRepository) RecordLastLogin(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE thyrasec.users SET last_login = $1 WHERE id = $2`, now, userID)
+	return err
+}
+
+func (r *LoginSecurityRepository) InsertLoginHistory(ctx context.Context, entry models.LoginHistoryEntry) error {
+	query := `
+        INSERT INTO thyrasec.login_history (user_id, username, ip_address, user_agent, success, failure_reason, created_at)
+        VALUES (:user_id, :username, :ip_address, :user_agent, :success, :failure_reason, :created_at)`
+	_, err := r.db.NamedExecContext(ctx, query, entry)
+	return err
+}
+
+func (r *LoginSecurityRepository) GetLoginHistory(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginHistoryEntry, error) {
+	var entries []models.LoginHistoryEntry
+	query := `
+        SELECT id, user_id, username, ip_address, user_agent, success, failure_reason, created_at
+        FROM thyrasec.login_history
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2`
+	err := r.db.SelectContext(ctx, &entries, query, userID, limit)
+	return entries, err
+}
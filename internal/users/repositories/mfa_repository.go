@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"thyra/internal/users/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type MFARepository struct {
+	db *sqlx.DB
+}
+
+func NewMFARepository(db *sqlx.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// GetMFA returns nil without error when the user has never started enrollment.
+func (r *MFARepository) GetMFA(ctx context.Context, userID uuid.UUID) (*models.UserMFA, error) {
+	var mfa models.UserMFA
+	query := `SELECT user_id, user_type, totp_secret, enabled, last_used_step, confirmed_at, created_at, updated_at
+              FROM thyrasec.user_mfa WHERE user_id = $1`
+	err := r.db.GetContext(ctx, &mfa, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// UpsertPendingSecret stores a new, not yet confirmed, secret. Enrollment can
+// only be restarted while MFA is disabled.
+func (r *MFARepository) UpsertPendingSecret(ctx context.Context, userID uuid.UUID, userType, secret string, now time.Time) error {
+	query := `
+        INSERT INTO thyrasec.user_mfa (user_id, user_type, totp_secret, enabled, last_used_step, created_at, updated_at)
+        VALUES ($1, $2, $3, false, 0, $4, $4)
+        ON CONFLICT (user_id) DO UPDATE
+        SET totp_secret = EXCLUDED.totp_secret, last_used_step = 0, updated_at = EXCLUDED.updated_at
+        WHERE thyrasec.user_mfa.enabled = false`
+	_, err := r.db.ExecContext(ctx, query, userID, userType, secret, now)
+	return err
+}
+
+// EnableMFA confirms enrollment and replaces any recovery codes in one transaction.
+func (r *MFARepository) EnableMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string, now time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE thyrasec.user_mfa SET enabled = true, last_used_step = $1, confirmed_at = $2, updated_at = $2 WHERE user_id = $3`
+	if _, err := tx.ExecContext(ctx, query, step, now, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes, now); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *MFARepository) DisableMFA(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM thyrasec.user_mfa WHERE user_id = $1`, userID)
+	return err
+}
+
+// MarkStepUsed advances last_used_step. It returns false when the step was
+// already consumed, which means the code is being replayed.
+func (r *MFARepository) MarkStepUsed(ctx context.Context, userID uuid.UUID, step int64, now time.Time) (bool, error) {
+	query := `UPDATE thyrasec.user_mfa SET last_used_step = $1, updated_at = $2 WHERE user_id = $3 AND last_used_step < $1`
+	res, err := r.db.ExecContext(ctx, query, step, now, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string, now time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes, now); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *MFARepository) GetUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]models.RecoveryCode, error) {
+	var codes []models.RecoveryCode
+	query := `SELECT id, user_id, code_hash, used_at FROM thyrasec.user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	err := r.db.SelectContext(ctx, &codes, query, userID)
+	return codes, err
+}
+
+// UseRecoveryCode marks a code as used, returning false if it was used concurrently.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, codeID uuid.UUID, now time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE thyrasec.user_recovery_codes SET used_at = $1 WHERE id = $2 AND used_at IS NULL`, now, codeID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, codeHashes []string, now time.Time) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM thyrasec.user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		query := `INSERT INTO thyrasec.user_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`
+		if _, err := tx.ExecContext(ctx, query, userID, hash, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
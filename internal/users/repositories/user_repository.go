@@ -7,10 +7,18 @@ import (
 	"database/sql"
 	"thyra/internal/users/models" // assuming this is where UserResponse is located
 
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Role names stored in thyrasec.user_roles and carried as user_type in tokens.
+const (
+	RoleAdmin          = "admin"
+	RolePartnerAdvisor = "partner_advisor"
+	RoleCustomer       = "customer"
+)
+
 type UserRepository struct {
 	db *sqlx.DB
 }
@@ -20,19 +28,24 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 }
 
 func (r *UserRepository) GetAllUsers(ctx context.Context, role string) ([]models.UserResponse, error) {
-	var query string
 	switch role {
 	case "admin":
-		query = "SELECT id, username, email, customer_number FROM admins"
-	case "advisor":
-		query = "SELECT id, username, email, customer_number FROM partners_advisors"
+		role = RoleAdmin
+	case "advisor", RolePartnerAdvisor:
+		role = RolePartnerAdvisor
 	case "customer":
-		query = "SELECT id, username, email, customer_number FROM customers"
+		role = RoleCustomer
 	default:
 		return nil, sql.ErrNoRows // Handle invalid role
 	}
 
-	rows, err := r.db.QueryContext(ctx, query)
+	query := `
+        SELECT u.id, u.username, COALESCE(u.email, ''), COALESCE(u.customer_number, '')
+        FROM thyrasec.users u
+        INNER JOIN thyrasec.user_roles ur ON ur.user_id = u.id
+        WHERE ur.role = $1`
+
+	rows, err := r.db.QueryContext(ctx, query, role)
 	if err != nil {
 		return nil, err
 	}
@@ -49,42 +62,93 @@ func (r *UserRepository) GetAllUsers(ctx context.Context, role string) ([]models
 	return users, rows.Err()
 }
 
+// GetUsernameByUUID returns the display name from the role profile, falling
+// back to the login name for users without one (admins).
 func (r *UserRepository) GetUsernameByUUID(ctx context.Context, uuid string) (string, error) {
 	var username string
-	query := `SELECT full_name FROM customers WHERE id = $1`
+	query := `
+        SELECT COALESCE(cp.full_name, pp.full_name, u.username)
+        FROM thyrasec.users u
+        LEFT JOIN thyrasec.customer_profiles cp ON cp.user_id = u.id
+        LEFT JOIN thyrasec.partner_advisor_profiles pp ON pp.user_id = u.id
+        WHERE u.id = $1`
 	err := r.db.QueryRowContext(ctx, query, uuid).Scan(&username)
 	return username, err
 }
 
-func (r *UserRepository) RegisterAdmin(ctx context.Context, admin models.AdminRegistrationRequest) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(admin.Password), bcrypt.DefaultCost)
+func (r *UserRepository) RegisterAdmin(ctx context.Context, admin models.AdminRegistrationRequest) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 
-	query := "INSERT INTO admins (username, password_hash, email, customer_number) VALUES ($1, $2, $3, $4)"
-	_, err = r.db.ExecContext(ctx, query, admin.Username, string(hashedPassword), admin.Email, admin.CustomerNumber)
-	return err
+	userID, err := insertUser(ctx, tx, admin.BaseRegistrationRequest, RoleAdmin)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	return userID, tx.Commit()
 }
 
-func (r *UserRepository) RegisterPartnerAdvisor(ctx context.Context, advisor models.PartnerAdvisorRegistrationRequest) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(advisor.Password), bcrypt.DefaultCost)
+func (r *UserRepository) RegisterPartnerAdvisor(ctx context.Context, advisor models.PartnerAdvisorRegistrationRequest) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return uuid.Nil, err
 	}
 
-	query := "INSERT INTO partners_advisors (username, password_hash, email, full_name, company_name, phone_number, customer_number) VALUES ($1, $2, $3, $4, $5, $6, $7)"
-	_, err = r.db.ExecContext(ctx, query, advisor.Username, string(hashedPassword), advisor.Email, advisor.FullName, advisor.CompanyName, advisor.PhoneNumber, advisor.CustomerNumber)
-	return err
+	userID, err := insertUser(ctx, tx, advisor.BaseRegistrationRequest, RolePartnerAdvisor)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	query := "INSERT INTO thyrasec.partner_advisor_profiles (user_id, full_name, company_name, phone_number) VALUES ($1, $2, $3, $4)"
+	if _, err := tx.ExecContext(ctx, query, userID, advisor.FullName, advisor.CompanyName, advisor.PhoneNumber); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	return userID, tx.Commit()
 }
 
-func (r *UserRepository) RegisterCustomer(ctx context.Context, customer models.CustomerRegistrationRequest) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(customer.Password), bcrypt.DefaultCost)
+func (r *UserRepository) RegisterCustomer(ctx context.Context, customer models.CustomerRegistrationRequest) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return err
+		return uuid.Nil, err
+	}
+
+	userID, err := insertUser(ctx, tx, customer.BaseRegistrationRequest, RoleCustomer)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	query := "INSERT INTO thyrasec.customer_profiles (user_id, full_name, address, phone_number) VALUES ($1, $2, $3, $4)"
+	if _, err := tx.ExecContext(ctx, query, userID, customer.FullName, customer.Address, customer.PhoneNumber); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// insertUser creates the identity row and its role assignment.
+func insertUser(ctx context.Context, tx *sqlx.Tx, base models.BaseRegistrationRequest, role string) (uuid.UUID, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(base.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	var userID uuid.UUID
+	query := "INSERT INTO thyrasec.users (username, password_hash, email, customer_number) VALUES ($1, $2, $3, $4) RETURNING id"
+	if err := tx.QueryRowContext(ctx, query, base.Username, string(hashedPassword), base.Email, base.CustomerNumber).Scan(&userID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO thyrasec.user_roles (user_id, role) VALUES ($1, $2)", userID, role); err != nil {
+		return uuid.Nil, err
 	}
 
-	query := "INSERT INTO customers (username, password_hash, email, full_name, address, phone_number, customer_number) VALUES ($1, $2, $3, $4, $5, $6, $7)"
-	_, err = r.db.ExecContext(ctx, query, customer.Username, string(hashedPassword), customer.Email, customer.FullName, customer.Address, customer.PhoneNumber, customer.CustomerNumber)
-	return err
+	return userID, nil
 }
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"thyra/internal/users/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrInvalidUserToken = errors.New("token is invalid, expired or already used")
+
+type UserTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserTokenRepository(db *sqlx.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+func (r *UserTokenRepository) GetUserContact(ctx context.Context, userID uuid.UUID) (models.UserContact, error) {
+	var contact models.UserContact
+	query := `SELECT id, username, email, email_verified_at FROM thyrasec.users WHERE id = $1`
+	err := r.db.GetContext(ctx, &contact, query, userID)
+	return contact, err
+}
+
+func (r *UserTokenRepository) GetUserContactByEmail(ctx context.Context, email string) (models.UserContact, error) {
+	var contact models.UserContact
+	query := `SELECT id, username, email, email_verified_at FROM thyrasec.users WHERE lower(email) = lower($1)`
+	err := r.db.GetContext(ctx, &contact, query, email)
+	return contact, err
+}
+
+// CreateToken stores a new token and invalidates any earlier unused token of
+// the same purpose, so only the most recent link works.
+func (r *UserTokenRepository) CreateToken(ctx context.Context, userID uuid.UUID, purpose models.UserTokenPurpose, tokenHash string, expiresAt, now time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	invalidate := `UPDATE thyrasec.user_tokens SET used_at = $1 WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL`
+	if _, err := tx.ExecContext(ctx, invalidate, now, userID, purpose); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	insert := `INSERT INTO thyrasec.user_tokens (user_id, purpose, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.ExecContext(ctx, insert, userID, purpose, tokenHash, expiresAt, now); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *UserTokenRepository) VerifyEmail(ctx context.Context, tokenHash string, now time.Time) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := consumeToken(ctx, tx, models.TokenPurposeEmailVerification, tokenHash, now)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE thyrasec.users SET email_verified_at = $1, updated_at = $1 WHERE id = $2`, now, userID); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// ResetPassword consumes the token and stores the new hash atomically. Other
+// outstanding reset tokens for the user are invalidated as well. Proving
+// ownership of the mailbox also lifts a lockout from failed logins, so a
+// locked user is reactivated and its username failure counter cleared.
+func (r *UserTokenRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (uuid.UUID, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := consumeToken(ctx, tx, models.TokenPurposePasswordReset, tokenHash, now)
+	if err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	var username string
+	update := `
+        UPDATE thyrasec.users SET password_hash = $1, password_changed_at = $2, updated_at = $2,
+            status = CASE WHEN status = 'LOCKED' THEN 'ACTIVE' ELSE status END,
+            locked_at = CASE WHEN status = 'LOCKED' THEN NULL ELSE locked_at END,
+            locked_reason = CASE WHEN status = 'LOCKED' THEN NULL ELSE locked_reason END
+        WHERE id = $3
+        RETURNING username`
+	if err := tx.GetContext(ctx, &username, update, passwordHash, now, userID); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	clearFailures := `DELETE FROM thyrasec.login_failures WHERE key_type = $1 AND key_value = $2`
+	if _, err := tx.ExecContext(ctx, clearFailures, models.LoginFailureKeyUsername, username); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	invalidate := `UPDATE thyrasec.user_tokens SET used_at = $1 WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL`
+	if _, err := tx.ExecContext(ctx, invalidate, now, userID, models.TokenPurposePasswordReset); err != nil {
+		tx.Rollback()
+		return uuid.Nil, err
+	}
+
+	return userID, tx.Commit()
+}
+
+func consumeToken(ctx context.Context, tx *sqlx.Tx, purpose models.UserTokenPurpose, tokenHash string, now time.Time) (uuid.UUID, error) {
+	var userID uuid.UUID
+	query := `
+        UPDATE thyrasec.user_tokens SET used_at = $1
+        WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+        RETURNING user_id`
+	err := tx.GetContext(ctx, &userID, query, now, tokenHash, purpose)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrInvalidUserToken
+	}
+	return userID, err
+}
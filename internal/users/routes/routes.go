@@ -7,9 +7,13 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.RouterGroup, userHandler *handlers.UserHandler, authHandler *handlers.AuthHandler) {
+func SetupRoutes(router *gin.RouterGroup, userHandler *handlers.UserHandler, authHandler *handlers.AuthHandler, mfaHandler *handlers.MFAHandler, loginSecurityHandler *handlers.LoginSecurityHandler, credentialHandler *handlers.CredentialHandler) {
 	// Public route
 	router.POST("/login", authHandler.LoginHandler) // This route is public and outside the protected group
+	router.POST("/login/mfa", authHandler.MFALoginHandler)
+	router.POST("/verify-email", credentialHandler.VerifyEmailHandler)
+	router.POST("/password/forgot", credentialHandler.ForgotPasswordHandler)
+	router.POST("/password/reset", credentialHandler.ResetPasswordHandler)
 	// Group for version 1 APIs with Token Middleware
 	v1 := router.Group("/v1")
 	v1.Use(middleware.TokenMiddleware) // Apply token middleware to all routes in this group
@@ -20,4 +24,15 @@ func SetupRoutes(router *gin.RouterGroup, userHandler *handlers.UserHandler, aut
 	v1.POST("/register/customer", userHandler.RegisterCustomerHandler)
 	v1.GET("/fetch/users", userHandler.GetAllUsersHandler)
 	v1.GET("/fetch/username", userHandler.GetUserNameByUuid) // Protected route
+
+	v1.POST("/mfa/enroll", mfaHandler.StartEnrollmentHandler)
+	v1.POST("/mfa/enroll/confirm", mfaHandler.ConfirmEnrollmentHandler)
+	v1.POST("/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodesHandler)
+	v1.POST("/mfa/disable", mfaHandler.DisableHandler)
+	v1.POST("/mfa/step-up", authHandler.StepUpHandler)
+
+	v1.GET("/login-history", loginSecurityHandler.GetLoginHistoryHandler)
+	v1.POST("/users/:userId/unlock", loginSecurityHandler.UnlockUserHandler)
+
+	v1.POST("/verify-email/resend", credentialHandler.ResendEmailVerificationHandler)
 }
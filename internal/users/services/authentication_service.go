@@ -2,29 +2,135 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"thyra/internal/users/models"
 	"thyra/internal/users/repositories"
 	"thyra/internal/users/utils"
+	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	repo *repositories.AuthRepository
+	repo          *repositories.AuthRepository
+	mfaService    *MFAService
+	loginSecurity *LoginSecurityService
+	clock         utils.Clock
 }
 
-func NewAuthService(repo *repositories.AuthRepository) *AuthService {
-	return &AuthService{repo: repo}
+func NewAuthService(repo *repositories.AuthRepository, mfaService *MFAService, loginSecurity *LoginSecurityService, clock utils.Clock) *AuthService {
+	return &AuthService{repo: repo, mfaService: mfaService, loginSecurity: loginSecurity, clock: clock}
 }
 
-func (s *AuthService) AuthenticateUser(ctx context.Context, username, password string) (string, error) {
+// AuthenticateUser is the first login step. Users with MFA enabled get a
+// challenge token instead of a session token.
+func (s *AuthService) AuthenticateUser(ctx context.Context, username, password string, loginCtx models.LoginContext) (models.LoginResult, error) {
+	if err := s.loginSecurity.CheckThrottle(ctx, username, loginCtx); err != nil {
+		return models.LoginResult{}, err
+	}
+
 	userID, storedPassword, userType, err := s.repo.GetUserCredentials(username)
 	if err != nil {
-		return "", err
+		if err == sql.ErrNoRows {
+			s.loginSecurity.RecordFailure(ctx, username, loginCtx, "unknown username")
+		}
+		return models.LoginResult{}, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password)); err != nil {
+		s.loginSecurity.RecordFailure(ctx, username, loginCtx, "invalid password")
+		return models.LoginResult{}, err
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+
+	if err := s.loginSecurity.CheckStatus(ctx, userUUID); err != nil {
+		s.loginSecurity.RecordFailure(ctx, username, loginCtx, err.Error())
+		return models.LoginResult{}, err
+	}
+
+	mfaEnabled, err := s.mfaService.IsEnabled(ctx, userUUID)
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+
+	now := s.clock.Now()
+	if mfaEnabled {
+		challenge, err := utils.GenerateMFAChallengeToken(userID, username, userType, now)
+		if err != nil {
+			return models.LoginResult{}, err
+		}
+		return models.LoginResult{MFARequired: true, ChallengeToken: challenge}, nil
+	}
+
+	token, err := utils.GenerateSessionToken(userID, username, userType, time.Time{}, now)
+	if err != nil {
+		return models.LoginResult{}, err
+	}
+
+	if err := s.loginSecurity.RecordSuccess(ctx, userUUID, username, loginCtx); err != nil {
+		return models.LoginResult{}, err
+	}
+	return models.LoginResult{Token: token}, nil
+}
+
+// CompleteMFALogin is the second login step. The issued session token records
+// the time of MFA verification so step-up checks pass right after login.
+func (s *AuthService) CompleteMFALogin(ctx context.Context, req models.MFALoginRequest, loginCtx models.LoginContext) (string, error) {
+	now := s.clock.Now()
+	claims, err := utils.ParseMFAChallengeToken(req.ChallengeToken, now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.loginSecurity.CheckThrottle(ctx, claims.Username, loginCtx); err != nil {
+		return "", err
+	}
+
+	userUUID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.mfaService.Verify(ctx, userUUID, req.MFACodeRequest); err != nil {
+		if err == ErrInvalidMFACode {
+			s.loginSecurity.RecordFailure(ctx, claims.Username, loginCtx, "invalid MFA code")
+		}
+		return "", err
+	}
+
+	// The user may have been locked between the two steps
+	if err := s.loginSecurity.CheckStatus(ctx, userUUID); err != nil {
+		return "", err
+	}
+
+	token, err := utils.GenerateSessionToken(claims.UserID, claims.Username, claims.UserType, now, now)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.loginSecurity.RecordSuccess(ctx, userUUID, claims.Username, loginCtx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// StepUp re-verifies the second factor for an existing session and returns a
+// fresh token carrying the new verification time.
+func (s *AuthService) StepUp(ctx context.Context, userID, username, userType string, req models.MFACodeRequest) (string, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.mfaService.Verify(ctx, userUUID, req); err != nil {
 		return "", err
 	}
 
-	return utils.GenerateJWTToken(userID, username, userType) // Assuming jwt.GenerateToken is implemented
+	now := s.clock.Now()
+	return utils.GenerateSessionToken(userID, username, userType, now, now)
 }
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"thyra/internal/common/mailer"
+	"thyra/internal/users/models"
+	"thyra/internal/users/repositories"
+	"thyra/internal/users/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	emailVerificationTTL = 48 * time.Hour
+	passwordResetTTL     = time.Hour
+)
+
+var (
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrEmailMissing         = errors.New("user has no email address")
+)
+
+// CredentialService owns the emailed token flows: verifying the address a
+// user registered with and resetting a forgotten password.
+type CredentialService struct {
+	repo    *repositories.UserTokenRepository
+	mailer  mailer.Mailer
+	clock   utils.Clock
+	baseURL string
+}
+
+func NewCredentialService(repo *repositories.UserTokenRepository, mailer mailer.Mailer, clock utils.Clock, baseURL string) *CredentialService {
+	return &CredentialService{repo: repo, mailer: mailer, clock: clock, baseURL: baseURL}
+}
+
+func (s *CredentialService) SendEmailVerification(ctx context.Context, userID uuid.UUID) error {
+	contact, err := s.repo.GetUserContact(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if contact.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	if contact.Email == nil || *contact.Email == "" {
+		return ErrEmailMissing
+	}
+
+	token, err := s.issueToken(ctx, userID, models.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      *contact.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n",
+			contact.Username, s.link("/verify-email", token), int(emailVerificationTTL.Hours())),
+	})
+}
+
+func (s *CredentialService) VerifyEmail(ctx context.Context, token string) error {
+	_, err := s.repo.VerifyEmail(ctx, utils.HashUserToken(token), s.clock.Now())
+	return err
+}
+
+// RequestPasswordReset emails a reset link if the address belongs to a user.
+// Unknown addresses are not reported so the endpoint cannot be used to probe
+// for registered emails.
+func (s *CredentialService) RequestPasswordReset(ctx context.Context, email string) error {
+	contact, err := s.repo.GetUserContactByEmail(ctx, email)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.issueToken(ctx, contact.ID, models.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      *contact.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nA password reset was requested for your account. Open the link below to choose a new password:\n\n%s\n\nThe link expires in %d minutes. If you did not request this you can ignore this email.\n",
+			contact.Username, s.link("/reset-password", token), int(passwordResetTTL.Minutes())),
+	})
+	if err != nil {
+		log.Printf("Error sending password reset email to user %s: %v", contact.ID, err)
+	}
+	return nil
+}
+
+func (s *CredentialService) ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error {
+	// The username is unknown until the token is consumed, so only the
+	// generic rules are checked here.
+	if err := utils.ValidatePassword(req.NewPassword, ""); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.ResetPassword(ctx, utils.HashUserToken(req.Token), string(hash), s.clock.Now())
+	return err
+}
+
+func (s *CredentialService) issueToken(ctx context.Context, userID uuid.UUID, purpose models.UserTokenPurpose, ttl time.Duration) (string, error) {
+	token, hash, err := utils.GenerateUserToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := s.clock.Now()
+	if err := s.repo.CreateToken(ctx, userID, purpose, hash, now.Add(ttl), now); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *CredentialService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"thyra/internal/users/models"
+	"thyra/internal/users/repositories"
+	"thyra/internal/users/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Failures tolerated before every further attempt is delayed.
+	DefaultFailureDelayThreshold = 3
+	DefaultBaseFailureDelay      = 2 * time.Second
+	DefaultMaxFailureDelay       = 15 * time.Minute
+	// Consecutive failures after which the user is locked until an admin unlocks it.
+	DefaultUsernameLockThreshold = 10
+	// IP counters are forgotten after this long without failures.
+	ipFailureWindow = time.Hour
+
+	defaultLoginHistoryLimit = 50
+)
+
+var (
+	ErrAccountLocked    = errors.New("account is locked")
+	ErrAccountSuspended = errors.New("account is suspended")
+	ErrUserNotLocked    = errors.New("user is not locked")
+)
+
+// LoginThrottledError is returned while a username or IP is in its back-off period.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginSecurityConfig sets how failed logins are throttled and when a user
+// is locked.
+type LoginSecurityConfig struct {
+	DelayThreshold int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	LockThreshold  int
+}
+
+func DefaultLoginSecurityConfig() LoginSecurityConfig {
+	return LoginSecurityConfig{
+		DelayThreshold: DefaultFailureDelayThreshold,
+		BaseDelay:      DefaultBaseFailureDelay,
+		MaxDelay:       DefaultMaxFailureDelay,
+		LockThreshold:  DefaultUsernameLockThreshold,
+	}
+}
+
+type LoginSecurityService struct {
+	repo   *repositories.LoginSecurityRepository
+	clock  utils.Clock
+	config LoginSecurityConfig
+}
+
+func NewLoginSecurityService(repo *repositories.LoginSecurityRepository, clock utils.Clock, config LoginSecurityConfig) *LoginSecurityService {
+	return &LoginSecurityService{repo: repo, clock: clock, config: config}
+}
+
+// NewLoginSecurityServiceFromEnv reads the failures before back-off from
+// LOGIN_DELAY_THRESHOLD, the first and longest delays from
+// LOGIN_BASE_DELAY_SECONDS and LOGIN_MAX_DELAY_SECONDS, and the failures
+// before the user is locked from LOGIN_LOCK_THRESHOLD.
+func NewLoginSecurityServiceFromEnv(repo *repositories.LoginSecurityRepository, clock utils.Clock) *LoginSecurityService {
+	config := DefaultLoginSecurityConfig()
+	config.DelayThreshold = positiveIntFromEnv("LOGIN_DELAY_THRESHOLD", config.DelayThreshold)
+	config.BaseDelay = time.Duration(positiveIntFromEnv("LOGIN_BASE_DELAY_SECONDS", int(config.BaseDelay/time.Second))) * time.Second
+	config.MaxDelay = time.Duration(positiveIntFromEnv("LOGIN_MAX_DELAY_SECONDS", int(config.MaxDelay/time.Second))) * time.Second
+	config.LockThreshold = positiveIntFromEnv("LOGIN_LOCK_THRESHOLD", config.LockThreshold)
+	return NewLoginSecurityService(repo, clock, config)
+}
+
+func positiveIntFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Ignoring invalid %s %q", key, value)
+		return fallback
+	}
+	return n
+}
+
+// CheckThrottle is called before the password is verified and rejects the
+// attempt while either the username or the IP is backing off.
+func (s *LoginSecurityService) CheckThrottle(ctx context.Context, username string, loginCtx models.LoginContext) error {
+	now := s.clock.Now()
+
+	keys := []struct {
+		keyType models.LoginFailureKey
+		value   string
+	}{
+		{models.LoginFailureKeyUsername, username},
+		{models.LoginFailureKeyIP, loginCtx.IPAddress},
+	}
+
+	var wait time.Duration
+	for _, key := range keys {
+		if key.value == "" {
+			continue
+		}
+		failure, err := s.repo.GetFailure(ctx, key.keyType, key.value)
+		if err != nil {
+			return err
+		}
+		if failure == nil {
+			continue
+		}
+		if key.keyType == models.LoginFailureKeyIP && now.Sub(failure.LastFailureAt) > ipFailureWindow {
+			continue
+		}
+		if remaining := failure.LastFailureAt.Add(s.failureDelay(failure.FailureCount)).Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// CheckStatus enforces the user_status enum once the password has been verified.
+func (s *LoginSecurityService) CheckStatus(ctx context.Context, userID uuid.UUID) error {
+	status, err := s.repo.GetUserStatus(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case models.UserStatusLocked:
+		return ErrAccountLocked
+	case models.UserStatusSuspended:
+		return ErrAccountSuspended
+	default:
+		return nil
+	}
+}
+
+// RecordFailure updates both counters, locks the user once the threshold is
+// reached and writes the attempt to the login history. Unknown usernames
+// only count against the IP, so guessing names cannot fill login_failures.
+func (s *LoginSecurityService) RecordFailure(ctx context.Context, username string, loginCtx models.LoginContext, reason string) {
+	now := s.clock.Now()
+
+	userID, err := s.repo.GetUserIDByUsername(ctx, username)
+	if err != nil {
+		log.Printf("Error looking up user %s for failed login: %v", username, err)
+	}
+
+	count := 0
+	if userID != nil {
+		count, err = s.repo.IncrementFailure(ctx, models.LoginFailureKeyUsername, username, now, time.Time{})
+		if err != nil {
+			log.Printf("Error recording failed login for %s: %v", username, err)
+		}
+	}
+	if loginCtx.IPAddress != "" {
+		if _, err := s.repo.IncrementFailure(ctx, models.LoginFailureKeyIP, loginCtx.IPAddress, now, now.Add(-ipFailureWindow)); err != nil {
+			log.Printf("Error recording failed login for IP %s: %v", loginCtx.IPAddress, err)
+		}
+	}
+
+	if userID != nil && count >= s.config.LockThreshold {
+		reason := fmt.Sprintf("locked after %d failed login attempts", count)
+		if err := s.repo.LockUser(ctx, *userID, reason, now); err != nil {
+			log.Printf("Error locking user %s: %v", userID, err)
+		}
+	}
+
+	s.recordHistory(ctx, userID, username, loginCtx, false, reason, now)
+}
+
+// RecordSuccess clears the username counter, stores last_login and logs the
+// attempt. The IP counter is kept so one valid account cannot reset it.
+func (s *LoginSecurityService) RecordSuccess(ctx context.Context, userID uuid.UUID, username string, loginCtx models.LoginContext) error {
+	now := s.clock.Now()
+
+	if err := s.repo.ResetFailures(ctx, models.LoginFailureKeyUsername, username); err != nil {
+		return err
+	}
+	if err := s.repo.RecordLastLogin(ctx, userID, now); err != nil {
+		return err
+	}
+
+	s.recordHistory(ctx, &userID, username, loginCtx, true, "", now)
+	return nil
+}
+
+func (s *LoginSecurityService) UnlockUser(ctx context.Context, userID uuid.UUID, authUserRole string) error {
+	if authUserRole != repositories.RoleAdmin {
+		return errors.New("only admins can unlock users")
+	}
+
+	unlocked, err := s.repo.UnlockUser(ctx, userID, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return ErrUserNotLocked
+	}
+	return nil
+}
+
+func (s *LoginSecurityService) GetLoginHistory(ctx context.Context, userID uuid.UUID) ([]models.LoginHistoryEntry, error) {
+	return s.repo.GetLoginHistory(ctx, userID, defaultLoginHistoryLimit)
+}
+
+func (s *LoginSecurityService) recordHistory(ctx context.Context, userID *uuid.UUID, username string, loginCtx models.LoginContext, success bool, reason string, now time.Time) {
+	entry := models.LoginHistoryEntry{
+		UserID:    userID,
+		Username:  username,
+		Success:   success,
+		CreatedAt: now,
+	}
+	if loginCtx.IPAddress != "" {
+		entry.IPAddress = &loginCtx.IPAddress
+	}
+	if loginCtx.UserAgent != "" {
+		userAgent := loginCtx.UserAgent
+		if len(userAgent) > 512 {
+			userAgent = userAgent[:512]
+		}
+		entry.UserAgent = &userAgent
+	}
+	if reason != "" {
+		entry.FailureReason = &reason
+	}
+
+	if err := s.repo.InsertLoginHistory(ctx, entry); err != nil {
+		log.Printf("Error writing login history for %s: %v", username, err)
+	}
+}
+
+// failureDelay doubles the back-off for every failure past the threshold.
+func (s *LoginSecurityService) failureDelay(failures int) time.Duration {
+	if failures < s.config.DelayThreshold {
+		return 0
+	}
+
+	delay := s.config.BaseDelay
+	for i := s.config.DelayThreshold; i < failures; i++ {
+		delay *= 2
+		if delay >= s.config.MaxDelay {
+			return s.config.MaxDelay
+		}
+	}
+	return delay
+}
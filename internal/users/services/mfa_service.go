@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"thyra/internal/users/models"
+	"thyra/internal/users/repositories"
+	"thyra/internal/users/utils"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrMFANotEnrolled     = errors.New("MFA is not enrolled")
+	ErrMFAAlreadyEnabled  = errors.New("MFA is already enabled")
+	ErrInvalidMFACode     = errors.New("invalid MFA code")
+	ErrMFACodeRequired    = errors.New("an MFA code or recovery code is required")
+	ErrMFAEnrollmentState = errors.New("MFA enrollment has not been started")
+)
+
+type MFAService struct {
+	repo  *repositories.MFARepository
+	clock utils.Clock
+}
+
+func NewMFAService(repo *repositories.MFARepository, clock utils.Clock) *MFAService {
+	return &MFAService{repo: repo, clock: clock}
+}
+
+func (s *MFAService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return mfa != nil && mfa.Enabled, nil
+}
+
+// StartEnrollment generates a new secret which stays inactive until the
+// user proves possession of it through ConfirmEnrollment.
+func (s *MFAService) StartEnrollment(ctx context.Context, userID uuid.UUID, username, userType string) (models.MFAEnrollmentResponse, error) {
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return models.MFAEnrollmentResponse{}, err
+	}
+	if enabled {
+		return models.MFAEnrollmentResponse{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return models.MFAEnrollmentResponse{}, err
+	}
+
+	if err := s.repo.UpsertPendingSecret(ctx, userID, userType, secret, s.clock.Now()); err != nil {
+		return models.MFAEnrollmentResponse{}, err
+	}
+
+	return models.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(utils.TOTPIssuer, username, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables MFA and returns the plaintext recovery codes. They
+// are only stored hashed, so this is the only time they can be shown.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, ErrMFAEnrollmentState
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	now := s.clock.Now()
+	step, ok := utils.ValidateTOTP(mfa.TOTPSecret, code, now)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableMFA(ctx, userID, step, hashes, now); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP code, falling back to a one-time recovery code.
+func (s *MFAService) Verify(ctx context.Context, userID uuid.UUID, req models.MFACodeRequest) error {
+	mfa, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return ErrMFANotEnrolled
+	}
+
+	now := s.clock.Now()
+	switch {
+	case req.Code != "":
+		step, ok := utils.ValidateTOTP(mfa.TOTPSecret, req.Code, now)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		fresh, err := s.repo.MarkStepUsed(ctx, userID, step, now)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidMFACode
+		}
+		return nil
+	case req.RecoveryCode != "":
+		return s.useRecoveryCode(ctx, userID, req.RecoveryCode)
+	default:
+		return ErrMFACodeRequired
+	}
+}
+
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req models.MFACodeRequest) ([]string, error) {
+	if err := s.Verify(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, req models.MFACodeRequest) error {
+	if err := s.Verify(ctx, userID, req); err != nil {
+		return err
+	}
+	return s.repo.DisableMFA(ctx, userID)
+}
+
+func (s *MFAService) useRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	codes, err := s.repo.GetUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	normalized := utils.NormalizeRecoveryCode(code)
+	for _, rc := range codes {
+		if bcrypt.CompareHashAndPassword([]byte(rc.CodeHash), []byte(normalized)) != nil {
+			continue
+		}
+		used, err := s.repo.UseRecoveryCode(ctx, rc.ID, s.clock.Now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+	return ErrInvalidMFACode
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(utils.NormalizeRecoveryCode(code)), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes = append(hashes, string(hash))
+	}
+	return codes, hashes, nil
+}
@@ -4,17 +4,29 @@ package services
 
 import (
 	"context"
+	"log"
+	compliancemodels "thyra/internal/compliance/models"
 	"thyra/internal/users/models"
 	"thyra/internal/users/repositories"
 	"thyra/internal/users/utils"
+
+	"github.com/google/uuid"
 )
 
+// CustomerScreener screens customers against sanctions and PEP lists. It is
+// implemented by the compliance module.
+type CustomerScreener interface {
+	ScreenCustomer(ctx context.Context, userID uuid.UUID, trigger string) error
+}
+
 type UserService struct {
-	repo *repositories.UserRepository
+	repo        *repositories.UserRepository
+	credentials *CredentialService
+	screener    CustomerScreener
 }
 
-func NewUserService(repo *repositories.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo *repositories.UserRepository, credentials *CredentialService, screener CustomerScreener) *UserService {
+	return &UserService{repo: repo, credentials: credentials, screener: screener}
 }
 
 func (s *UserService) GetAllUsers(ctx context.Context, role string) ([]models.UserResponse, error) {
@@ -28,16 +40,59 @@ func (s *UserService) GetUsernameByUUID(ctx context.Context, uuid string) (strin
 }
 
 func (s *UserService) RegisterAdmin(ctx context.Context, admin models.AdminRegistrationRequest) error {
+	if err := utils.ValidatePassword(admin.Password, admin.Username); err != nil {
+		return err
+	}
 	admin.CustomerNumber = utils.GenerateCustomerNumber()
-	return s.repo.RegisterAdmin(ctx, admin)
+	userID, err := s.repo.RegisterAdmin(ctx, admin)
+	if err != nil {
+		return err
+	}
+	s.sendEmailVerification(ctx, userID)
+	return nil
 }
 
 func (s *UserService) RegisterPartnerAdvisor(ctx context.Context, advisor models.PartnerAdvisorRegistrationRequest) error {
+	if err := utils.ValidatePassword(advisor.Password, advisor.Username); err != nil {
+		return err
+	}
 	advisor.CustomerNumber = utils.GenerateCustomerNumber()
-	return s.repo.RegisterPartnerAdvisor(ctx, advisor)
+	userID, err := s.repo.RegisterPartnerAdvisor(ctx, advisor)
+	if err != nil {
+		return err
+	}
+	s.sendEmailVerification(ctx, userID)
+	return nil
 }
 
-func (s *UserService) RegisterCustomer(ctx context.Context, customer models.CustomerRegistrationRequest) error {
+// RegisterCustomer returns the new user's ID so self sign-up can attach the
+// KYC profile to it.
+func (s *UserService) RegisterCustomer(ctx context.Context, customer models.CustomerRegistrationRequest) (uuid.UUID, error) {
+	if err := utils.ValidatePassword(customer.Password, customer.Username); err != nil {
+		return uuid.Nil, err
+	}
 	customer.CustomerNumber = utils.GenerateCustomerNumber()
-	return s.repo.RegisterCustomer(ctx, customer)
+	userID, err := s.repo.RegisterCustomer(ctx, customer)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.sendEmailVerification(ctx, userID)
+	s.screenCustomer(ctx, userID)
+	return userID, nil
+}
+
+// screenCustomer does not fail the registration either; a customer that could
+// not be screened now is screened on the next list import.
+func (s *UserService) screenCustomer(ctx context.Context, userID uuid.UUID) {
+	if err := s.screener.ScreenCustomer(ctx, userID, compliancemodels.ScreeningTriggerRegistration); err != nil {
+		log.Printf("Error screening customer %s: %v", userID, err)
+	}
+}
+
+// sendEmailVerification does not fail the registration; the user can ask for
+// a new link through /verify-email/resend.
+func (s *UserService) sendEmailVerification(ctx context.Context, userID uuid.UUID) {
+	if err := s.credentials.SendEmailVerification(ctx, userID); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", userID, err)
+	}
 }
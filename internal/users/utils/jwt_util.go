@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -8,24 +9,85 @@ import (
 
 var jwtSecret = []byte("LKJSDFS878dfsdLHLF$lkajd") // Should ideally be from an environment variable or secure storage
 
+// ScopeMFAChallenge marks the short lived token returned by the first login
+// step. It is only accepted by the MFA verification endpoint.
+const ScopeMFAChallenge = "mfa_challenge"
+
+const (
+	sessionTokenTTL   = 24 * time.Hour
+	challengeTokenTTL = 5 * time.Minute
+)
+
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	UserType string `json:"user_type"`
+	UserID        string `json:"user_id"`
+	Username      string `json:"username"`
+	UserType      string `json:"user_type"`
+	Scope         string `json:"scope,omitempty"`
+	MFAVerifiedAt int64  `json:"mfa_at,omitempty"`
 	jwt.StandardClaims
 }
 
 func GenerateJWTToken(userID, username, userType string) (string, error) {
+	return GenerateSessionToken(userID, username, userType, time.Time{}, time.Now())
+}
+
+// GenerateSessionToken issues a regular session token. mfaVerifiedAt is zero
+// when the user has not passed a second factor in this session.
+func GenerateSessionToken(userID, username, userType string, mfaVerifiedAt, now time.Time) (string, error) {
 	claims := Claims{
 		UserID:   userID,
 		Username: username,
 		UserType: userType,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
-			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: now.Add(sessionTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
 		},
 	}
+	if !mfaVerifiedAt.IsZero() {
+		claims.MFAVerifiedAt = mfaVerifiedAt.Unix()
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtSecret)
 }
+
+func GenerateMFAChallengeToken(userID, username, userType string, now time.Time) (string, error) {
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		UserType: userType,
+		Scope:    ScopeMFAChallenge,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(challengeTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ParseMFAChallengeToken validates a challenge token against the supplied
+// clock and returns its claims.
+func ParseMFAChallengeToken(tokenString string, now time.Time) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("invalid token signing method")
+		}
+		return jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid challenge token")
+	}
+
+	if claims.Scope != ScopeMFAChallenge {
+		return nil, fmt.Errorf("token is not an MFA challenge")
+	}
+	if !claims.VerifyExpiresAt(now.Unix(), true) {
+		return nil, fmt.Errorf("challenge token expired")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	MinPasswordLength = 12
+	// bcrypt silently ignores everything past 72 bytes
+	MaxPasswordBytes = 72
+)
+
+// PasswordPolicyError lists every rule the password broke so the client can
+// show them all at once.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Violations, "; ")
+}
+
+func ValidatePassword(password, username string) error {
+	var violations []string
+
+	if len([]rune(password)) < MinPasswordLength {
+		violations = append(violations, "must be at least 12 characters long")
+	}
+	if len(password) > MaxPasswordBytes {
+		violations = append(violations, "must be at most 72 bytes long")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if !hasUpper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if !hasLower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "must not contain the username")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}